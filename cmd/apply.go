@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	dryRunNone   = "none"
+	dryRunServer = "server"
+	dryRunClient = "client"
+)
+
+// applySkipped is the outcome of applying a resource that already exists
+// without --overwrite-existing.
+const applySkipped = "skipped"
+
+// fieldManager is the field manager of the resources applied with
+// server-side apply.
+const fieldManager = "ingress2gateway"
+
+// newScheme returns a scheme registering the Kubernetes and Gateway API types.
+func newScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		gatewayv1.AddToScheme,
+		gatewayv1alpha2.AddToScheme,
+		gatewayv1alpha3.AddToScheme,
+		gatewayv1beta1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			return nil, err
+		}
+	}
+	return scheme, nil
+}
+
+// applyResources applies the generated resources to the cluster with
+// server-side apply. Resources that already exist are updated with overwrite,
+// otherwise they are skipped with a warning, so that manual changes aren't
+// reverted. The outcome for every resource is reported to w. With server dry
+// run, the requests are validated by the server but not persisted.
+func applyResources(ctx context.Context, cl client.Client, httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant, policies []unstructured.Unstructured, dryRun string, overwrite bool, w io.Writer) error {
+	objs := clientObjects(httpRoutes, grpcRoutes, tcpRoutes, tlsRoutes, gateways, backendTLSPolicies, referenceGrants, policies)
+
+	suffix := ""
+	if dryRun == dryRunServer {
+		suffix = " (server dry run)"
+	}
+	var failed int
+	for _, obj := range objs {
+		resource := fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
+		result, err := applyObject(ctx, cl, obj, dryRun == dryRunServer, overwrite)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s failed: %v\n", resource, err)
+			continue
+		}
+		if result == applySkipped {
+			fmt.Fprintf(w, "# Warning: %s skipped, it already exists and is only updated with --overwrite-existing\n", resource)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s%s\n", resource, result, suffix)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to apply %d of %d resources", failed, len(objs))
+	}
+	return nil
+}
+
+// clientObjects returns the generated resources as client objects, the
+// Gateways first so that the routes are applied along with their parents.
+func clientObjects(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant, policies []unstructured.Unstructured) []client.Object {
+	var objs []client.Object
+	for i := range gateways {
+		objs = append(objs, &gateways[i])
+	}
+	for i := range httpRoutes {
+		objs = append(objs, &httpRoutes[i])
+	}
+	for i := range grpcRoutes {
+		objs = append(objs, &grpcRoutes[i])
+	}
+	for i := range tcpRoutes {
+		objs = append(objs, &tcpRoutes[i])
+	}
+	for i := range tlsRoutes {
+		objs = append(objs, &tlsRoutes[i])
+	}
+	for i := range backendTLSPolicies {
+		objs = append(objs, &backendTLSPolicies[i])
+	}
+	for i := range referenceGrants {
+		objs = append(objs, &referenceGrants[i])
+	}
+	for i := range policies {
+		objs = append(objs, &policies[i])
+	}
+	return objs
+}
+
+// applyObject applies obj with server-side apply, or skips it without
+// overwrite when it already exists, and returns whether it was created,
+// configured or skipped. The fields of obj set by other field managers are
+// taken over, as with overwrite the generated resource replaces the existing
+// one.
+func applyObject(ctx context.Context, cl client.Client, obj client.Object, dryRun bool, overwrite bool) (string, error) {
+	existing, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return "", fmt.Errorf("unexpected type %T", obj)
+	}
+	result := "configured"
+	err := cl.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		result = "created"
+	case err != nil:
+		return "", err
+	case !overwrite:
+		return applySkipped, nil
+	}
+
+	opts := []client.PatchOption{client.FieldOwner(fieldManager), client.ForceOwnership}
+	if dryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	// Apply requests must not set the resource version or the managed fields.
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	if err := cl.Patch(ctx, obj, client.Apply, opts...); err != nil {
+		return "", err
+	}
+	return result, nil
+}
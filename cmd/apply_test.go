@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// serverSideApply emulates the apply patches the fake client doesn't support,
+// creating or updating the object, and checks they are sent as the field
+// manager of ingress2gateway forcing the ownership of the fields.
+func serverSideApply(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return cl.Patch(ctx, obj, patch, opts...)
+	}
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+	if patchOpts.FieldManager != fieldManager || patchOpts.Force == nil || !*patchOpts.Force {
+		return fmt.Errorf("unexpected apply options %+v", patchOpts)
+	}
+	existing, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", obj)
+	}
+	err := cl.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if apierrors.IsNotFound(err) {
+		return cl.Create(ctx, obj, &client.CreateOptions{DryRun: patchOpts.DryRun})
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return cl.Update(ctx, obj, &client.UpdateOptions{DryRun: patchOpts.DryRun})
+}
+
+func Test_applyResources(t *testing.T) {
+	newGateway := func(className string) gatewayv1beta1.Gateway {
+		gw := gatewayv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: gatewayv1beta1.ObjectName(className)},
+		}
+		gw.SetGroupVersionKind(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"})
+		return gw
+	}
+	newHTTPRoute := func() gatewayv1beta1.HTTPRoute {
+		route := gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+		}
+		route.SetGroupVersionKind(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"})
+		return route
+	}
+
+	testCases := []struct {
+		name              string
+		dryRun            string
+		overwrite         bool
+		expectedOutput    string
+		expectedClassName gatewayv1beta1.ObjectName
+		expectRoute       bool
+	}{{
+		name:              "apply",
+		dryRun:            dryRunNone,
+		overwrite:         true,
+		expectedOutput:    "Gateway/test/nginx configured\nHTTPRoute/test/example-com created\n",
+		expectedClassName: "nginx",
+		expectRoute:       true,
+	}, {
+		name:              "server dry run",
+		dryRun:            dryRunServer,
+		overwrite:         true,
+		expectedOutput:    "Gateway/test/nginx configured (server dry run)\nHTTPRoute/test/example-com created (server dry run)\n",
+		expectedClassName: "previous",
+	}, {
+		name:              "existing resources not overwritten",
+		dryRun:            dryRunNone,
+		expectedOutput:    "# Warning: Gateway/test/nginx skipped, it already exists and is only updated with --overwrite-existing\nHTTPRoute/test/example-com created\n",
+		expectedClassName: "previous",
+		expectRoute:       true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme, err := newScheme()
+			if err != nil {
+				t.Fatalf("Failed to create scheme: %v", err)
+			}
+			existing := newGateway("previous")
+			cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&existing).WithInterceptorFuncs(interceptor.Funcs{Patch: serverSideApply}).Build()
+
+			var out bytes.Buffer
+			err = applyResources(context.Background(), cl, []gatewayv1beta1.HTTPRoute{newHTTPRoute()}, nil, nil, nil, []gatewayv1beta1.Gateway{newGateway("nginx")}, nil, nil, nil, tc.dryRun, tc.overwrite, &out)
+			if err != nil {
+				t.Fatalf("applyResources() failed: %v", err)
+			}
+			if out.String() != tc.expectedOutput {
+				t.Errorf("Expected output %q, got %q", tc.expectedOutput, out.String())
+			}
+
+			var gw gatewayv1beta1.Gateway
+			if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "nginx"}, &gw); err != nil {
+				t.Fatalf("Failed to get Gateway: %v", err)
+			}
+			if gw.Spec.GatewayClassName != tc.expectedClassName {
+				t.Errorf("Expected gatewayClassName %s, got %s", tc.expectedClassName, gw.Spec.GatewayClassName)
+			}
+
+			var route gatewayv1beta1.HTTPRoute
+			err = cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "example-com"}, &route)
+			if tc.expectRoute != (err == nil) {
+				t.Errorf("Expected HTTPRoute to exist: %v, got error: %v", tc.expectRoute, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// compactObject returns obj as an unstructured object without its null
+// fields, such as the creationTimestamp of the generated resources, and
+// without its empty maps and lists, such as the status of the generated
+// routes. The spec is kept, as it's required, as are the empty elements of
+// lists, which are meaningful, e.g. an empty header match.
+func compactObject(obj runtime.Object) (runtime.Object, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range content {
+		if key == "spec" {
+			if spec, ok := value.(map[string]interface{}); ok {
+				compactMap(spec)
+			}
+			continue
+		}
+		if isEmpty(compactValue(value)) {
+			delete(content, key)
+		}
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+// compactMap removes the null, empty map and empty list values of m,
+// recursively.
+func compactMap(m map[string]interface{}) {
+	for key, value := range m {
+		if isEmpty(compactValue(value)) {
+			delete(m, key)
+		}
+	}
+}
+
+// compactValue compacts the maps of value, as well as the maps of the
+// elements of its lists, and returns it.
+func compactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		compactMap(v)
+	case []interface{}:
+		for _, elem := range v {
+			compactValue(elem)
+		}
+	}
+	return value
+}
+
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
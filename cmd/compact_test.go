@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_compactObject(t *testing.T) {
+	route := gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{Name: "nginx"}}},
+			Hostnames:       []gatewayv1.Hostname{"example.com"},
+			Rules: []gatewayv1beta1.HTTPRouteRule{{
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{}},
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{Name: "web"}},
+				}},
+			}},
+		},
+		Status: gatewayv1beta1.HTTPRouteStatus{RouteStatus: gatewayv1.RouteStatus{Parents: []gatewayv1.RouteParentStatus{}}},
+	}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+	emptyRoute := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "test"}}
+	emptyRoute.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+
+	testCases := []struct {
+		name     string
+		route    gatewayv1beta1.HTTPRoute
+		expected string
+	}{{
+		name:  "route",
+		route: route,
+		expected: `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: example-com
+  namespace: test
+spec:
+  hostnames:
+  - example.com
+  parentRefs:
+  - name: nginx
+  rules:
+  - backendRefs:
+    - name: web
+    matches:
+    - {}
+`,
+	}, {
+		name:  "empty spec",
+		route: emptyRoute,
+		expected: `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  name: empty
+  namespace: test
+spec: {}
+`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := &PrintRunner{resourcePrinter: &printers.YAMLPrinter{}, compact: true}
+			var buf bytes.Buffer
+			if err := pr.printObjWithComments(&tc.route, nil, &buf); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if buf.String() != tc.expected {
+				t.Errorf("printObjWithComments() printed %q, expected %q", buf.String(), tc.expected)
+			}
+		})
+	}
+}
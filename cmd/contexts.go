@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+)
+
+// validateKubeconfigContexts checks that the kubeconfig contexts are named
+// and listed once.
+func validateKubeconfigContexts(kubeContexts []string) error {
+	seen := map[string]bool{}
+	for _, kubeContext := range kubeContexts {
+		if kubeContext == "" {
+			return fmt.Errorf("context names must not be empty")
+		}
+		if seen[kubeContext] {
+			return fmt.Errorf("context %s is listed several times", kubeContext)
+		}
+		seen[kubeContext] = true
+	}
+	return nil
+}
+
+// convertContexts converts the Ingresses of every kubeconfig context, read
+// from its cluster along with the resources they reference, and merges the
+// results, see i2gw.MergeContextResults. The Ingresses of all the contexts
+// are returned along with the merged result.
+func (pr *PrintRunner) convertContexts(ctx context.Context, opts i2gw.Options, since time.Time) (*networkingv1.IngressList, i2gw.Result, error) {
+	ingressList := &networkingv1.IngressList{}
+	var results []i2gw.ContextResult
+	for _, kubeContext := range pr.kubeconfigContexts {
+		cl, err := newClient(kubeContext)
+		if err != nil {
+			return nil, i2gw.Result{}, fmt.Errorf("kubeconfig context %s: %w", kubeContext, err)
+		}
+		namespaceFilter := pr.namespaceFilter
+		if !pr.allNamespaces && pr.namespace == "" {
+			namespaceFilter, err = getNamespaceInContext(kubeContext)
+			if err != nil {
+				return nil, i2gw.Result{}, fmt.Errorf("failed to get the namespace of kubeconfig context %s: %w", kubeContext, err)
+			}
+		}
+		if namespaceFilter == "" {
+			klog.V(1).Infof("Converting the resources of all namespaces of kubeconfig context %s", kubeContext)
+		} else {
+			klog.V(1).Infof("Converting the resources of namespace %s of kubeconfig context %s", namespaceFilter, kubeContext)
+		}
+
+		contextIngresses, err := getIngessList(ctx, cl, namespaceFilter, pr.excludeNamespaces, "", "")
+		if pr.emptySource(err) {
+			klog.V(1).Infof("No Ingresses to convert in kubeconfig context %s: %v", kubeContext, err)
+			continue
+		}
+		if err != nil {
+			return nil, i2gw.Result{}, fmt.Errorf("failed to get ingresses from kubeconfig context %s: %w", kubeContext, pr.timeoutError(ctx, err))
+		}
+		if !since.IsZero() {
+			contextIngresses.Items = createdSince(contextIngresses.Items, since)
+		}
+		configMapList, serviceList, err := getReferencedResources(ctx, cl, contextIngresses, "", "")
+		if err != nil {
+			return nil, i2gw.Result{}, fmt.Errorf("failed to get referenced resources from kubeconfig context %s: %w", kubeContext, pr.timeoutError(ctx, err))
+		}
+		contextOpts := opts
+		contextOpts.ConfigMaps = configMapList.Items
+		contextOpts.Services = serviceList.Items
+
+		result, err := i2gw.Convert(contextIngresses.Items, contextOpts)
+		if err != nil {
+			return nil, i2gw.Result{}, fmt.Errorf("kubeconfig context %s: %w", kubeContext, conversionError(err))
+		}
+		results = append(results, i2gw.ContextResult{Context: kubeContext, Result: result})
+		ingressList.Items = append(ingressList.Items, contextIngresses.Items...)
+	}
+	if !since.IsZero() && len(ingressList.Items) == 0 {
+		return nil, i2gw.Result{}, noResourcesError(fmt.Sprintf("no Ingresses created since %s", pr.since))
+	}
+	if len(ingressList.Items) == 0 {
+		return nil, i2gw.Result{}, noResourcesError("no Ingresses found in the kubeconfig contexts")
+	}
+
+	result, err := i2gw.MergeContextResults(results)
+	if err != nil {
+		return nil, i2gw.Result{}, err
+	}
+	return ingressList, result, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+)
+
+func Test_validateKubeconfigContexts(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contexts    []string
+		expectError bool
+	}{
+		{name: "distinct contexts", contexts: []string{"example", "kind-i2gw"}},
+		{name: "empty context", contexts: []string{"example", ""}, expectError: true},
+		{name: "duplicate context", contexts: []string{"example", "example"}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateKubeconfigContexts(tc.contexts)
+			if (err != nil) != tc.expectError {
+				t.Errorf("validateKubeconfigContexts(%v) = %v, expecting error: %v", tc.contexts, err, tc.expectError)
+			}
+		})
+	}
+}
+
+func Test_getNamespaceInContext(t *testing.T) {
+	destroy, err := setupKubeConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer destroy()
+
+	testCases := []struct {
+		kubeContext       string
+		expectedNamespace string
+	}{
+		{kubeContext: "", expectedNamespace: "non-default-ns"},
+		{kubeContext: "example", expectedNamespace: "non-default-ns"},
+		{kubeContext: "kind-i2gw", expectedNamespace: "default"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.kubeContext, func(t *testing.T) {
+			namespace, err := getNamespaceInContext(tc.kubeContext)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if namespace != tc.expectedNamespace {
+				t.Errorf("Expected namespace %q of context %q, got %q", tc.expectedNamespace, tc.kubeContext, namespace)
+			}
+		})
+	}
+}
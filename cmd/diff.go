@@ -0,0 +1,244 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// diffContext is the number of unchanged lines around the changes of a hunk.
+const diffContext = 3
+
+// lastAppliedAnnotation is set by kubectl apply on the resources it applies.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// diffResources writes a unified diff of every generated resource against the
+// resource of the same kind, namespace and name in the cluster to w. The
+// resources that don't exist in the cluster, including those of kinds without
+// CRDs, are marked as new and diffed against an empty document, and the
+// unchanged resources aren't written. The cluster is only read.
+func diffResources(ctx context.Context, cl client.Client, objs []client.Object, w io.Writer) error {
+	scheme, err := newOutputScheme()
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, obj := range objs {
+		resource := fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
+		diff, err := diffObject(ctx, cl, scheme, resource, obj)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "# %s failed: %v\n", resource, err)
+			continue
+		}
+		fmt.Fprint(w, diff)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to diff %d of %d resources", failed, len(objs))
+	}
+	return nil
+}
+
+// diffObject returns the unified diff of obj against the resource of the
+// cluster, empty when they are the same.
+func diffObject(ctx context.Context, cl client.Client, scheme *runtime.Scheme, resource string, obj client.Object) (string, error) {
+	generated, err := diffYAML(scheme, obj)
+	if err != nil {
+		return "", err
+	}
+
+	existing, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return "", fmt.Errorf("unexpected type %T", obj)
+	}
+	err = cl.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return fmt.Sprintf("# %s is new\n", resource) + unifiedDiff("/dev/null", resource+" (generated)", "", generated), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// Typed objects are read without their kind.
+	existing.GetObjectKind().SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	live, err := diffYAML(scheme, existing)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(resource+" (cluster)", resource+" (generated)", live, generated), nil
+}
+
+// diffYAML returns obj as YAML, defaulted as by the schemas of the CRDs and
+// without its status, its server populated metadata fields and the
+// configuration recorded by kubectl apply, so that only the changes made by
+// applying the generated resources are compared.
+func diffYAML(scheme *runtime.Scheme, obj runtime.Object) (string, error) {
+	obj = obj.DeepCopyObject()
+	if scheme.Recognizes(obj.GetObjectKind().GroupVersionKind()) {
+		scheme.Default(obj)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+	delete(content, "status")
+	for _, f := range serverMetadataFields {
+		unstructured.RemoveNestedField(content, "metadata", f)
+	}
+	unstructured.RemoveNestedField(content, "metadata", "annotations", lastAppliedAnnotation)
+	if annotations, _, _ := unstructured.NestedMap(content, "metadata", "annotations"); len(annotations) == 0 {
+		unstructured.RemoveNestedField(content, "metadata", "annotations")
+	}
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// diffOp is a line of a diff, kept (' '), removed ('-') or added ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the operations turning the lines a into the lines b,
+// through their longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff returns the unified diff of the texts a and b, named from and
+// to in its header, with diffContext lines of context. It is empty when the
+// texts are the same.
+func unifiedDiff(from, to, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	// The lines of a and b before every operation, for the hunk ranges.
+	aLines, bLines := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if op.kind != '+' {
+			aLines[i+1]++
+		}
+		if op.kind != '-' {
+			bLines[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// The hunk extends over the changes separated by less than twice
+		// the context.
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = next
+		}
+		begin := max(start-diffContext, 0)
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLines[begin], aLines[end]-aLines[begin]), hunkRange(bLines[begin], bLines[end]-bLines[begin]))
+		for _, op := range ops[begin:end] {
+			fmt.Fprintf(&sb, "%c%s\n", op.kind, op.line)
+		}
+		start = end
+	}
+	return sb.String()
+}
+
+// hunkRange returns the range of a hunk starting after line start, as
+// written by diff -u.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_unifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{{
+		name: "same",
+		a:    "a\nb\n",
+		b:    "a\nb\n",
+	}, {
+		name:     "new",
+		b:        "a\nb\n",
+		expected: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+	}, {
+		name:     "changed line",
+		a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+		b:        "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+		expected: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+	}, {
+		name:     "distant changes",
+		a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		b:        "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+		expected: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -8,3 +8,4 @@\n 8\n 9\n 10\n+11\n",
+	}, {
+		name:     "close changes",
+		a:        "1\n2\n3\n4\n5\n",
+		b:        "one\n2\n3\n4\nfive\n",
+		expected: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := unifiedDiff("a", "b", tc.a, tc.b); diff != tc.expected {
+				t.Errorf("unifiedDiff() = %q, expected %q", diff, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_diffResources(t *testing.T) {
+	newGateway := func(className string) gatewayv1beta1.Gateway {
+		gw := gatewayv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			Spec: gatewayv1beta1.GatewaySpec{
+				GatewayClassName: gatewayv1beta1.ObjectName(className),
+				Listeners:        []gatewayv1beta1.Listener{{Name: "example-com-http", Port: 80, Protocol: "HTTP"}},
+			},
+		}
+		gw.SetGroupVersionKind(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "Gateway"})
+		return gw
+	}
+	route := gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+	}
+	route.SetGroupVersionKind(schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"})
+
+	scheme, err := newScheme()
+	if err != nil {
+		t.Fatalf("Failed to create scheme: %v", err)
+	}
+	existing := newGateway("previous")
+	existing.Annotations = map[string]string{lastAppliedAnnotation: "{}"}
+	unchanged := newGateway("nginx")
+	unchanged.Name = "unchanged"
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&existing, &unchanged).Build()
+
+	gateway := newGateway("nginx")
+	var out bytes.Buffer
+	if err := diffResources(context.Background(), cl, []client.Object{&gateway, &unchanged, &route}, &out); err != nil {
+		t.Fatalf("diffResources() failed: %v", err)
+	}
+	expected := `--- Gateway/test/nginx (cluster)
++++ Gateway/test/nginx (generated)
+@@ -4,7 +4,7 @@
+   name: nginx
+   namespace: test
+ spec:
+-  gatewayClassName: previous
++  gatewayClassName: nginx
+   listeners:
+   - allowedRoutes:
+       namespaces:
+# HTTPRoute/test/example-com is new
+--- /dev/null
++++ HTTPRoute/test/example-com (generated)
+@@ -0,0 +1,11 @@
++apiVersion: gateway.networking.k8s.io/v1beta1
++kind: HTTPRoute
++metadata:
++  name: example-com
++  namespace: test
++spec:
++  rules:
++  - matches:
++    - path:
++        type: PathPrefix
++        value: /
+`
+	if out.String() != expected {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	// The cluster is only read.
+	var gw gatewayv1beta1.Gateway
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "nginx"}, &gw); err != nil {
+		t.Fatalf("Failed to get Gateway: %v", err)
+	}
+	if gw.Spec.GatewayClassName != "previous" {
+		t.Errorf("Expected gatewayClassName previous, got %s", gw.Spec.GatewayClassName)
+	}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(&route), &gatewayv1beta1.HTTPRoute{}); err == nil {
+		t.Errorf("Expected the HTTPRoute not to be created")
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// gatewayClassComment returns the comment leading the resources of a
+// GatewayClass.
+func gatewayClassComment(class string) string {
+	if class == "" {
+		return "# Resources without GatewayClass"
+	}
+	return fmt.Sprintf("# GatewayClass: %s", class)
+}
+
+// outputGatewayClasses prints the resources of every GatewayClass in a
+// contiguous block. With YAML output, every block is led by a comment naming
+// its class. The printing errors of the classes are aggregated.
+func (pr *PrintRunner) outputGatewayClasses(classResults []i2gw.GatewayClassResult, stdout, stderr io.Writer) error {
+	_, isYAML := pr.resourcePrinter.(*printers.YAMLPrinter)
+	var failed []string
+	for _, classResult := range classResults {
+		r := classResult.Result
+		var buf bytes.Buffer
+		if err := pr.outputResult(r.HTTPRoutes, r.GRPCRoutes, r.TCPRoutes, r.TLSRoutes, r.Gateways, r.BackendTLSPolicies, r.ReferenceGrants, r.Policies, r.Warnings, &buf, stderr); err != nil {
+			failed = append(failed, err.Error())
+		}
+		out := buf.String()
+		if isYAML && out != "" {
+			// The comment follows the separator of the first document, as
+			// do the warnings of the routes.
+			separator := "---\n"
+			if strings.HasPrefix(out, separator) {
+				out = strings.TrimPrefix(out, separator)
+				fmt.Fprint(stdout, separator)
+			}
+			fmt.Fprintln(stdout, gatewayClassComment(classResult.GatewayClassName))
+		}
+		if _, err := fmt.Fprint(stdout, out); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// writeGatewayClassSplitOutput writes the resources of every GatewayClass to
+// a subdirectory of dir named after the class, as writeSplitOutput does. The
+// resources without class are written to dir itself.
+func (pr *PrintRunner) writeGatewayClassSplitOutput(dir string, classResults []i2gw.GatewayClassResult, stderr io.Writer) error {
+	for _, classResult := range classResults {
+		r := classResult.Result
+		objs := toObjects(r.HTTPRoutes, r.GRPCRoutes, r.TCPRoutes, r.TLSRoutes, r.Gateways, r.BackendTLSPolicies, r.ReferenceGrants, r.Policies)
+		classDir := dir
+		if classResult.GatewayClassName != "" {
+			classDir = filepath.Join(dir, classResult.GatewayClassName)
+		}
+		if err := pr.writeSplitOutput(classDir, objs, r.Warnings, stderr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func gatewayClassResults() []i2gw.GatewayClassResult {
+	newGateway := func(name string) gatewayv1beta1.Gateway {
+		gateway := gatewayv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: gatewayv1beta1.ObjectName(name)},
+		}
+		gateway.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("Gateway"))
+		return gateway
+	}
+	route := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "orphan", Namespace: "test"}}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+	return []i2gw.GatewayClassResult{
+		{GatewayClassName: "external", Result: i2gw.Result{Gateways: []gatewayv1beta1.Gateway{newGateway("external")}}},
+		{GatewayClassName: "internal", Result: i2gw.Result{Gateways: []gatewayv1beta1.Gateway{newGateway("internal")}}},
+		{Result: i2gw.Result{HTTPRoutes: []gatewayv1beta1.HTTPRoute{route}}},
+	}
+}
+
+func Test_outputGatewayClasses(t *testing.T) {
+	pr := PrintRunner{outputFormat: "yaml"}
+	if err := pr.initializeResourcePrinter(); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := pr.outputGatewayClasses(gatewayClassResults(), &stdout, &stderr); err != nil {
+		t.Fatalf("outputGatewayClasses() error = %v", err)
+	}
+
+	docs := strings.Split(stdout.String(), "---\n")
+	expectedComments := []string{"# GatewayClass: external\n", "# GatewayClass: internal\n", "# Resources without GatewayClass\n"}
+	if len(docs) != len(expectedComments) {
+		t.Fatalf("Expected %d documents, got %d:\n%s", len(expectedComments), len(docs), stdout.String())
+	}
+	for i, comment := range expectedComments {
+		if !strings.HasPrefix(docs[i], comment) {
+			t.Errorf("Expected document %d to start with %q, got:\n%s", i, comment, docs[i])
+		}
+	}
+}
+
+func Test_writeGatewayClassSplitOutput(t *testing.T) {
+	pr := PrintRunner{outputFormat: "yaml"}
+	if err := pr.initializeResourcePrinter(); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := pr.writeGatewayClassSplitOutput(dir, gatewayClassResults(), &bytes.Buffer{}); err != nil {
+		t.Fatalf("writeGatewayClassSplitOutput() error = %v", err)
+	}
+	for _, name := range []string{"external/gateway-test-external.yaml", "internal/gateway-test-internal.yaml", "httproute-test-orphan.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// helmCommand is the Helm binary rendering the charts of --from-helm.
+var helmCommand = "helm"
+
+// defaultHelmNamespace is the namespace Helm renders charts in when no
+// namespace is given.
+const defaultHelmNamespace = "default"
+
+// helmChart is a Helm chart rendered into the converted resources.
+type helmChart struct {
+	chart       string
+	release     string
+	namespace   string
+	valuesFiles []string
+	set         []string
+}
+
+// renderHelmChart renders the chart with helm template and writes the
+// rendered objects to a manifest file in dir, whose path is returned. Helm
+// doesn't set the namespace of the rendered objects, so objects without
+// namespace are set the namespace of the release.
+func renderHelmChart(ctx context.Context, chart helmChart, dir string) (string, error) {
+	args := []string{"template", chart.release, chart.chart, "--namespace", chart.namespace}
+	for _, f := range chart.valuesFiles {
+		args = append(args, "--values", f)
+	}
+	for _, s := range chart.set {
+		args = append(args, "--set", s)
+	}
+
+	var stdout, stderr bytes.Buffer
+	helm := exec.CommandContext(ctx, helmCommand, args...)
+	helm.Stdout = &stdout
+	helm.Stderr = &stderr
+	if err := helm.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("failed to render chart %s: %w: %s", chart.chart, err, msg)
+		}
+		return "", fmt.Errorf("failed to render chart %s: %w", chart.chart, err)
+	}
+
+	items, err := decodeRenderedObjects(&stdout, chart.namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the manifests rendered from chart %s: %w", chart.chart, err)
+	}
+	list := map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "rendered.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// decodeRenderedObjects decodes the YAML documents rendered by Helm, setting
+// the namespace of the objects without namespace. Empty documents, such as
+// templates disabled by the values, are skipped. Cluster scoped objects are
+// set the namespace as well, which is harmless as only namespaced objects are
+// converted.
+func decodeRenderedObjects(r io.Reader, namespace string) ([]interface{}, error) {
+	items := []interface{}{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		items = append(items, obj.Object)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const renderedChart = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: release-name-web
+spec:
+  ports:
+  - port: 80
+---
+# Source: web/templates/disabled.yaml
+---
+# Source: web/templates/ingress.yaml
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: release-name-web
+spec:
+  ingressClassName: nginx
+  rules:
+  - host: example.com
+---
+# Source: web/templates/other.yaml
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: other
+  namespace: other
+`
+
+func Test_renderHelmChart(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	helm := filepath.Join(dir, "helm")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\ncat <<'EOF'\n" + renderedChart + "EOF\n"
+	if err := os.WriteFile(helm, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	defer func(command string) { helmCommand = command }(helmCommand)
+	helmCommand = helm
+
+	path, err := renderHelmChart(context.Background(), helmChart{
+		chart:       "./web",
+		release:     "release-name",
+		namespace:   "test",
+		valuesFiles: []string{"prod.yaml"},
+		set:         []string{"ingress.enabled=true"},
+	}, dir)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedArgs := "template release-name ./web --namespace test --values prod.yaml --set ingress.enabled=true"
+	if strings.TrimSpace(string(args)) != expectedArgs {
+		t.Errorf("Expected helm %s, got helm %s", expectedArgs, args)
+	}
+
+	ingressList := &networkingv1.IngressList{}
+	if err := i2gw.ConstructIngressesFromFile(ingressList, path, "test", ""); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(ingressList.Items) != 1 || ingressList.Items[0].Name != "release-name-web" {
+		t.Errorf("Expected the release-name-web Ingress of the release namespace, got %+v", ingressList.Items)
+	}
+}
+
+func Test_renderHelmChartError(t *testing.T) {
+	dir := t.TempDir()
+	helm := filepath.Join(dir, "helm")
+	if err := os.WriteFile(helm, []byte("#!/bin/sh\necho 'Error: chart not found' >&2\nexit 1\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	defer func(command string) { helmCommand = command }(helmCommand)
+	helmCommand = helm
+
+	_, err := renderHelmChart(context.Background(), helmChart{chart: "./missing", release: "release-name", namespace: "default"}, dir)
+	if err == nil || !strings.Contains(err.Error(), "chart not found") {
+		t.Errorf("Expected the helm error, got %v", err)
+	}
+}
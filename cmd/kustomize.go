@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// buildKustomization builds the kustomization of kustomizeDir, as kustomize
+// build does, and writes the built objects to a manifest file in dir, whose
+// path is returned. All the kinds are written, the resources that aren't
+// converted are ignored when the manifest is read, as for --input_file.
+func buildKustomization(kustomizeDir, dir string) (string, error) {
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resources, err := kustomizer.Run(filesys.MakeFsOnDisk(), kustomizeDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to build kustomization %s: %w", kustomizeDir, err)
+	}
+
+	items := []interface{}{}
+	for _, resource := range resources.Resources() {
+		obj, err := resource.Map()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the resources built from kustomization %s: %w", kustomizeDir, err)
+		}
+		items = append(items, obj)
+	}
+	list := map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "kustomized.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const kustomizedResources = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  ingressClassName: nginx
+  rules:
+  - host: example.com
+`
+
+func Test_buildKustomization(t *testing.T) {
+	kustomizeDir := t.TempDir()
+	kustomization := "namespace: test\nnamePrefix: prod-\nresources:\n- resources.yaml\n"
+	if err := os.WriteFile(filepath.Join(kustomizeDir, "kustomization.yaml"), []byte(kustomization), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(kustomizeDir, "resources.yaml"), []byte(kustomizedResources), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := buildKustomization(kustomizeDir, t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	ingressList := &networkingv1.IngressList{}
+	if err := i2gw.ConstructIngressesFromFile(ingressList, path, "test", ""); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(ingressList.Items) != 1 || ingressList.Items[0].Name != "prod-web" {
+		t.Errorf("Expected the prod-web Ingress of the kustomization, got %+v", ingressList.Items)
+	}
+}
+
+func Test_buildKustomizationError(t *testing.T) {
+	_, err := buildKustomization(t.TempDir(), t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "failed to build kustomization") {
+		t.Errorf("Expected the kustomize error, got %v", err)
+	}
+}
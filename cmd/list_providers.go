@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	"github.com/spf13/cobra"
+)
+
+var listProvidersCmd = &cobra.Command{
+	Use:   "list-providers",
+	Short: "List the providers whose Ingress annotations are converted",
+	Long: `List the providers whose Ingress annotations are converted, as selected with
+the --providers flag of the print command, along with the prefix of their
+annotations, the kinds of the resources they read and what they convert.
+No cluster is accessed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return writeProviders(cmd.OutOrStdout(), i2gw.ListProviders())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listProvidersCmd)
+}
+
+// writeProviders writes a table of the providers.
+func writeProviders(w io.Writer, providers []i2gw.ProviderInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tANNOTATION PREFIX\tINPUT KINDS\tSUMMARY")
+	for _, p := range providers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Name, p.AnnotationPrefix, strings.Join(p.InputKinds, ","), p.Summary)
+	}
+	return tw.Flush()
+}
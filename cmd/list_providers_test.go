@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+)
+
+func Test_writeProviders(t *testing.T) {
+	var b bytes.Buffer
+	err := writeProviders(&b, []i2gw.ProviderInfo{{
+		Name:             "example",
+		AnnotationPrefix: "example.com/",
+		InputKinds:       []string{"Ingress", "ConfigMap"},
+		Summary:          "Rewrites of the example controller",
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "NAME     ANNOTATION PREFIX  INPUT KINDS        SUMMARY\n" +
+		"example  example.com/       Ingress,ConfigMap  Rewrites of the example controller\n"
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func Test_listProvidersCmd(t *testing.T) {
+	var b bytes.Buffer
+	rootCmd.SetOut(&b)
+	rootCmd.SetArgs([]string{"list-providers"})
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != len(i2gw.ProviderNames())+1 {
+		t.Fatalf("Expected a header and a line per provider, got:\n%s", b.String())
+	}
+	for i, name := range i2gw.ProviderNames() {
+		if !strings.HasPrefix(lines[i+1], name+" ") {
+			t.Errorf("Expected line %d to list the %s provider, got %q", i+1, name, lines[i+1])
+		}
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// newOutputScheme returns the scheme of newScheme along with the conversions
+// between the v1beta1 and v1 Gateways and HTTPRoutes, and the defaulting of
+// the Gateway API resources, which the Gateway API module leaves to the
+// schemas of its CRDs.
+func newOutputScheme() (*runtime.Scheme, error) {
+	scheme, err := newScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	// The specs and statuses of the v1beta1 resources are aliases of the v1
+	// ones, so the conversions copy them.
+	conversions := []struct {
+		a, b interface{}
+		fn   conversion.ConversionFunc
+	}{{
+		a: (*gatewayv1beta1.HTTPRoute)(nil), b: (*gatewayv1.HTTPRoute)(nil),
+		fn: func(a, b interface{}, _ conversion.Scope) error {
+			in, out := a.(*gatewayv1beta1.HTTPRoute), b.(*gatewayv1.HTTPRoute)
+			out.ObjectMeta, out.Spec, out.Status = in.ObjectMeta, in.Spec, in.Status
+			return nil
+		},
+	}, {
+		a: (*gatewayv1.HTTPRoute)(nil), b: (*gatewayv1beta1.HTTPRoute)(nil),
+		fn: func(a, b interface{}, _ conversion.Scope) error {
+			in, out := a.(*gatewayv1.HTTPRoute), b.(*gatewayv1beta1.HTTPRoute)
+			out.ObjectMeta, out.Spec, out.Status = in.ObjectMeta, in.Spec, in.Status
+			return nil
+		},
+	}, {
+		a: (*gatewayv1beta1.Gateway)(nil), b: (*gatewayv1.Gateway)(nil),
+		fn: func(a, b interface{}, _ conversion.Scope) error {
+			in, out := a.(*gatewayv1beta1.Gateway), b.(*gatewayv1.Gateway)
+			out.ObjectMeta, out.Spec, out.Status = in.ObjectMeta, in.Spec, in.Status
+			return nil
+		},
+	}, {
+		a: (*gatewayv1.Gateway)(nil), b: (*gatewayv1beta1.Gateway)(nil),
+		fn: func(a, b interface{}, _ conversion.Scope) error {
+			in, out := a.(*gatewayv1.Gateway), b.(*gatewayv1beta1.Gateway)
+			out.ObjectMeta, out.Spec, out.Status = in.ObjectMeta, in.Spec, in.Status
+			return nil
+		},
+	}}
+	for _, c := range conversions {
+		if err := scheme.AddConversionFunc(c.a, c.b, c.fn); err != nil {
+			return nil, err
+		}
+	}
+
+	scheme.AddTypeDefaultingFunc(&gatewayv1beta1.HTTPRoute{}, func(obj interface{}) {
+		defaultHTTPRouteSpec(&obj.(*gatewayv1beta1.HTTPRoute).Spec)
+	})
+	scheme.AddTypeDefaultingFunc(&gatewayv1.HTTPRoute{}, func(obj interface{}) {
+		defaultHTTPRouteSpec(&obj.(*gatewayv1.HTTPRoute).Spec)
+	})
+	scheme.AddTypeDefaultingFunc(&gatewayv1beta1.Gateway{}, func(obj interface{}) {
+		defaultGatewaySpec(&obj.(*gatewayv1beta1.Gateway).Spec)
+	})
+	scheme.AddTypeDefaultingFunc(&gatewayv1.Gateway{}, func(obj interface{}) {
+		defaultGatewaySpec(&obj.(*gatewayv1.Gateway).Spec)
+	})
+	scheme.AddTypeDefaultingFunc(&gatewayv1.GRPCRoute{}, func(obj interface{}) {
+		defaultGRPCRouteSpec(&obj.(*gatewayv1.GRPCRoute).Spec)
+	})
+	scheme.AddTypeDefaultingFunc(&gatewayv1alpha2.TCPRoute{}, func(obj interface{}) {
+		route := obj.(*gatewayv1alpha2.TCPRoute)
+		defaultParentRefs(route.Spec.ParentRefs)
+		for i := range route.Spec.Rules {
+			defaultBackendRefs(route.Spec.Rules[i].BackendRefs)
+		}
+	})
+	scheme.AddTypeDefaultingFunc(&gatewayv1alpha2.TLSRoute{}, func(obj interface{}) {
+		route := obj.(*gatewayv1alpha2.TLSRoute)
+		defaultParentRefs(route.Spec.ParentRefs)
+		for i := range route.Spec.Rules {
+			defaultBackendRefs(route.Spec.Rules[i].BackendRefs)
+		}
+	})
+	return scheme, nil
+}
+
+// normalizeObject defaults obj with the scheme and, when it is a Gateway or
+// an HTTPRoute of another version, converts it to the Gateway API version. The
+// kinds that the version doesn't have, and the objects the scheme doesn't
+// know, such as the implementation specific policies, are only defaulted.
+func normalizeObject(scheme *runtime.Scheme, obj runtime.Object, version string) (runtime.Object, error) {
+	obj = obj.DeepCopyObject()
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if !scheme.Recognizes(gvk) {
+		return obj, nil
+	}
+	scheme.Default(obj)
+
+	target := schema.GroupVersion{Group: gatewayv1.GroupName, Version: version}
+	if gvk.Group != target.Group || gvk.Version == target.Version || !scheme.Recognizes(target.WithKind(gvk.Kind)) {
+		return obj, nil
+	}
+	converted, err := scheme.ConvertToVersion(obj, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s to %s: %w", gvk, target, err)
+	}
+	return converted, nil
+}
+
+// defaultHTTPRouteSpec sets the defaults of the HTTPRoute schema: a rule
+// matching all paths, path matches of the / prefix, Exact header and query
+// parameter matches, and the defaults of the references.
+func defaultHTTPRouteSpec(spec *gatewayv1.HTTPRouteSpec) {
+	defaultParentRefs(spec.ParentRefs)
+	if len(spec.Rules) == 0 {
+		spec.Rules = []gatewayv1.HTTPRouteRule{{}}
+	}
+	for i := range spec.Rules {
+		rule := &spec.Rules[i]
+		if len(rule.Matches) == 0 {
+			rule.Matches = []gatewayv1.HTTPRouteMatch{{}}
+		}
+		for j := range rule.Matches {
+			match := &rule.Matches[j]
+			if match.Path == nil {
+				match.Path = &gatewayv1.HTTPPathMatch{}
+			}
+			if match.Path.Type == nil {
+				pathType := gatewayv1.PathMatchPathPrefix
+				match.Path.Type = &pathType
+			}
+			if match.Path.Value == nil {
+				value := "/"
+				match.Path.Value = &value
+			}
+			for k := range match.Headers {
+				if match.Headers[k].Type == nil {
+					headerType := gatewayv1.HeaderMatchExact
+					match.Headers[k].Type = &headerType
+				}
+			}
+			for k := range match.QueryParams {
+				if match.QueryParams[k].Type == nil {
+					queryType := gatewayv1.QueryParamMatchExact
+					match.QueryParams[k].Type = &queryType
+				}
+			}
+		}
+		defaultHTTPRouteFilters(rule.Filters)
+		for j := range rule.BackendRefs {
+			defaultBackendRef(&rule.BackendRefs[j].BackendRef)
+			defaultHTTPRouteFilters(rule.BackendRefs[j].Filters)
+		}
+	}
+}
+
+// defaultHTTPRouteFilters sets the defaults of the references of the
+// request mirror filters.
+func defaultHTTPRouteFilters(filters []gatewayv1.HTTPRouteFilter) {
+	for i := range filters {
+		if filters[i].RequestMirror != nil {
+			defaultBackendObjectReference(&filters[i].RequestMirror.BackendRef)
+		}
+	}
+}
+
+// defaultGRPCRouteSpec sets the defaults of the GRPCRoute schema: Exact
+// method and header matches, and the defaults of the references.
+func defaultGRPCRouteSpec(spec *gatewayv1.GRPCRouteSpec) {
+	defaultParentRefs(spec.ParentRefs)
+	for i := range spec.Rules {
+		rule := &spec.Rules[i]
+		for j := range rule.Matches {
+			match := &rule.Matches[j]
+			if match.Method != nil && match.Method.Type == nil {
+				methodType := gatewayv1.GRPCMethodMatchExact
+				match.Method.Type = &methodType
+			}
+			for k := range match.Headers {
+				if match.Headers[k].Type == nil {
+					headerType := gatewayv1.HeaderMatchExact
+					match.Headers[k].Type = &headerType
+				}
+			}
+		}
+		for j := range rule.BackendRefs {
+			defaultBackendRef(&rule.BackendRefs[j].BackendRef)
+		}
+	}
+}
+
+// defaultGatewaySpec sets the defaults of the Gateway schema: IP addresses,
+// routes allowed from the namespace of the Gateway, terminated TLS and Secret
+// certificate references.
+func defaultGatewaySpec(spec *gatewayv1.GatewaySpec) {
+	for i := range spec.Addresses {
+		if spec.Addresses[i].Type == nil {
+			addressType := gatewayv1.IPAddressType
+			spec.Addresses[i].Type = &addressType
+		}
+	}
+	for i := range spec.Listeners {
+		listener := &spec.Listeners[i]
+		if listener.AllowedRoutes == nil {
+			listener.AllowedRoutes = &gatewayv1.AllowedRoutes{}
+		}
+		if listener.AllowedRoutes.Namespaces == nil {
+			listener.AllowedRoutes.Namespaces = &gatewayv1.RouteNamespaces{}
+		}
+		if listener.AllowedRoutes.Namespaces.From == nil {
+			from := gatewayv1.NamespacesFromSame
+			listener.AllowedRoutes.Namespaces.From = &from
+		}
+		if listener.TLS == nil {
+			continue
+		}
+		if listener.TLS.Mode == nil {
+			mode := gatewayv1.TLSModeTerminate
+			listener.TLS.Mode = &mode
+		}
+		for j := range listener.TLS.CertificateRefs {
+			ref := &listener.TLS.CertificateRefs[j]
+			if ref.Group == nil {
+				group := gatewayv1.Group("")
+				ref.Group = &group
+			}
+			if ref.Kind == nil {
+				kind := gatewayv1.Kind("Secret")
+				ref.Kind = &kind
+			}
+		}
+	}
+}
+
+// defaultParentRefs sets the Gateway group and kind of the parentRefs.
+func defaultParentRefs(refs []gatewayv1.ParentReference) {
+	for i := range refs {
+		if refs[i].Group == nil {
+			group := gatewayv1.Group(gatewayv1.GroupName)
+			refs[i].Group = &group
+		}
+		if refs[i].Kind == nil {
+			kind := gatewayv1.Kind("Gateway")
+			refs[i].Kind = &kind
+		}
+	}
+}
+
+// defaultBackendRefs sets the defaults of the backendRefs.
+func defaultBackendRefs(refs []gatewayv1.BackendRef) {
+	for i := range refs {
+		defaultBackendRef(&refs[i])
+	}
+}
+
+// defaultBackendRef sets the Service group and kind, and the weight of 1, of
+// the backendRef.
+func defaultBackendRef(ref *gatewayv1.BackendRef) {
+	defaultBackendObjectReference(&ref.BackendObjectReference)
+	if ref.Weight == nil {
+		weight := int32(1)
+		ref.Weight = &weight
+	}
+}
+
+// defaultBackendObjectReference sets the Service group and kind of the
+// reference.
+func defaultBackendObjectReference(ref *gatewayv1.BackendObjectReference) {
+	if ref.Group == nil {
+		group := gatewayv1.Group("")
+		ref.Group = &group
+	}
+	if ref.Kind == nil {
+		kind := gatewayv1.Kind("Service")
+		ref.Kind = &kind
+	}
+}
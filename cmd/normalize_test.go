@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_normalizeObject(t *testing.T) {
+	scheme, err := newOutputScheme()
+	if err != nil {
+		t.Fatalf("Failed to create the output scheme: %v", err)
+	}
+
+	exact := gatewayv1.PathMatchExact
+	prefix := gatewayv1.PathMatchPathPrefix
+	headerExact := gatewayv1.HeaderMatchExact
+	same := gatewayv1.NamespacesFromSame
+	terminate := gatewayv1.TLSModeTerminate
+	parentRef := gatewayv1.ParentReference{Name: "nginx"}
+	defaultedParentRef := gatewayv1.ParentReference{
+		Group: (*gatewayv1.Group)(pointer.String(gatewayv1.GroupName)),
+		Kind:  (*gatewayv1.Kind)(pointer.String("Gateway")),
+		Name:  "nginx",
+	}
+	backendRef := gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{
+		Name: "app",
+		Port: (*gatewayv1.PortNumber)(pointer.Int32(80)),
+	}}
+	defaultedBackendRef := gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{
+			Group: (*gatewayv1.Group)(pointer.String("")),
+			Kind:  (*gatewayv1.Kind)(pointer.String("Service")),
+			Name:  "app",
+			Port:  (*gatewayv1.PortNumber)(pointer.Int32(80)),
+		},
+		Weight: pointer.Int32(1),
+	}
+	meta := metav1.ObjectMeta{Name: "example", Namespace: "test"}
+
+	withGVK := func(obj runtime.Object, gv schema.GroupVersion, kind string) runtime.Object {
+		obj.GetObjectKind().SetGroupVersionKind(gv.WithKind(kind))
+		return obj
+	}
+
+	testCases := []struct {
+		name     string
+		obj      runtime.Object
+		version  string
+		expected runtime.Object
+	}{{
+		name: "v1beta1 HTTPRoute converted to v1 and defaulted",
+		obj: withGVK(&gatewayv1beta1.HTTPRoute{
+			ObjectMeta: meta,
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{parentRef}},
+				Rules: []gatewayv1.HTTPRouteRule{{
+					Matches: []gatewayv1.HTTPRouteMatch{{
+						Path:    &gatewayv1.HTTPPathMatch{Type: &exact, Value: pointer.String("/exact")},
+						Headers: []gatewayv1.HTTPHeaderMatch{{Name: "x-canary", Value: "always"}},
+					}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{{BackendRef: backendRef}},
+				}, {
+					BackendRefs: []gatewayv1.HTTPBackendRef{{BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: backendRef.BackendObjectReference,
+						Weight:                 pointer.Int32(0),
+					}}},
+				}},
+			},
+		}, gatewayv1beta1.SchemeGroupVersion, "HTTPRoute"),
+		version: "v1",
+		expected: withGVK(&gatewayv1.HTTPRoute{
+			ObjectMeta: meta,
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{defaultedParentRef}},
+				Rules: []gatewayv1.HTTPRouteRule{{
+					Matches: []gatewayv1.HTTPRouteMatch{{
+						Path:    &gatewayv1.HTTPPathMatch{Type: &exact, Value: pointer.String("/exact")},
+						Headers: []gatewayv1.HTTPHeaderMatch{{Type: &headerExact, Name: "x-canary", Value: "always"}},
+					}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{{BackendRef: defaultedBackendRef}},
+				}, {
+					Matches: []gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{Type: &prefix, Value: pointer.String("/")}}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{{BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: defaultedBackendRef.BackendObjectReference,
+						Weight:                 pointer.Int32(0),
+					}}},
+				}},
+			},
+		}, gatewayv1.SchemeGroupVersion, "HTTPRoute"),
+	}, {
+		name: "v1 Gateway converted to v1beta1 and defaulted",
+		obj: withGVK(&gatewayv1.Gateway{
+			ObjectMeta: meta,
+			Spec: gatewayv1.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []gatewayv1.Listener{{
+					Name:     "https",
+					Port:     443,
+					Protocol: gatewayv1.HTTPSProtocolType,
+					TLS:      &gatewayv1.GatewayTLSConfig{CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "cert"}}},
+				}},
+			},
+		}, gatewayv1.SchemeGroupVersion, "Gateway"),
+		version: "v1beta1",
+		expected: withGVK(&gatewayv1beta1.Gateway{
+			ObjectMeta: meta,
+			Spec: gatewayv1.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []gatewayv1.Listener{{
+					Name:          "https",
+					Port:          443,
+					Protocol:      gatewayv1.HTTPSProtocolType,
+					AllowedRoutes: &gatewayv1.AllowedRoutes{Namespaces: &gatewayv1.RouteNamespaces{From: &same}},
+					TLS: &gatewayv1.GatewayTLSConfig{
+						Mode: &terminate,
+						CertificateRefs: []gatewayv1.SecretObjectReference{{
+							Group: (*gatewayv1.Group)(pointer.String("")),
+							Kind:  (*gatewayv1.Kind)(pointer.String("Secret")),
+							Name:  "cert",
+						}},
+					},
+				}},
+			},
+		}, gatewayv1beta1.SchemeGroupVersion, "Gateway"),
+	}, {
+		name: "TCPRoute without v1 version only defaulted",
+		obj: withGVK(&gatewayv1alpha2.TCPRoute{
+			ObjectMeta: meta,
+			Spec: gatewayv1alpha2.TCPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{parentRef}},
+				Rules:           []gatewayv1alpha2.TCPRouteRule{{BackendRefs: []gatewayv1.BackendRef{backendRef}}},
+			},
+		}, gatewayv1alpha2.SchemeGroupVersion, "TCPRoute"),
+		version: "v1",
+		expected: withGVK(&gatewayv1alpha2.TCPRoute{
+			ObjectMeta: meta,
+			Spec: gatewayv1alpha2.TCPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{defaultedParentRef}},
+				Rules:           []gatewayv1alpha2.TCPRouteRule{{BackendRefs: []gatewayv1.BackendRef{defaultedBackendRef}}},
+			},
+		}, gatewayv1alpha2.SchemeGroupVersion, "TCPRoute"),
+	}, {
+		name: "policy unknown to the scheme unchanged",
+		obj: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.nginx.org/v1alpha1",
+			"kind":       "ClientSettingsPolicy",
+			"metadata":   map[string]interface{}{"name": "example", "namespace": "test"},
+		}},
+		version: "v1",
+		expected: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.nginx.org/v1alpha1",
+			"kind":       "ClientSettingsPolicy",
+			"metadata":   map[string]interface{}{"name": "example", "namespace": "test"},
+		}},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := tc.obj.DeepCopyObject()
+			actual, err := normalizeObject(scheme, tc.obj, tc.version)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("Unexpected normalized object (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(original, tc.obj); diff != "" {
+				t.Errorf("Expected the object to be left unchanged (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,127 +17,1460 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	"sigs.k8s.io/yaml"
 )
 
+const (
+	fromIngress  = "ingress"
+	fromContour  = "contour"
+	fromIstio    = "istio"
+	fromEmissary = "emissary"
+)
+
+// stdinInputFile is the input file reading the manifests from stdin.
+const stdinInputFile = "-"
+
 type PrintRunner struct {
 	// outputFormat contains currently set output format. Value assigned via --output/-o flag.
 	// Defaults to YAML.
 	outputFormat string
 
-	// The path to the input yaml config file. Value assigned via --input_file flag
-	inputFile string
+	// The path to the input yaml config file. Value assigned via --input_file flag
+	inputFile string
+
+	// inputFormat forces the format of the manifests of the input file, or
+	// lets the format be read from their extension or content. Value assigned
+	// via --input-format flag.
+	inputFormat string
+
+	// The namespace used to query Gateway API objects. Value assigned via
+	// --namespace/-n flag.
+	// On absence, the current user active namespace is used.
+	namespace string
+
+	// allNamespaces indicates whether all namespaces should be used. Value assigned via
+	// --all-namespaces/-A flag.
+	allNamespaces bool
+
+	// resourcePrinter determines how resource objects are printed out
+	resourcePrinter printers.ResourcePrinter
+
+	// Only resources that matches this filter will be processed.
+	namespaceFilter string
+
+	// excludeNamespaces are the namespaces whose resources are skipped. Value
+	// assigned via --exclude-namespaces flag.
+	excludeNamespaces []string
+
+	// since is an RFC3339 time, only the Ingresses of the cluster created after
+	// it are converted. Value assigned via --since flag.
+	since string
+
+	// compatCheck is the Gateway API implementation the generated resources are
+	// checked against. Value assigned via --compat-check flag.
+	// When set, a compatibility matrix is printed instead of the resources.
+	compatCheck string
+
+	// exposureReport indicates whether a report of the ports exposed per host by
+	// the Ingresses and by the generated Gateways is printed instead of the
+	// resources. Value assigned via --exposure-report flag.
+	exposureReport bool
+
+	// helmValues indicates whether the generated resources are printed as a
+	// values.yaml of a generic Gateway API Helm chart. Value assigned via
+	// --helm-values flag.
+	helmValues bool
+
+	// apply indicates whether the generated resources are created or updated
+	// in the cluster instead of being printed. Value assigned via --apply flag.
+	apply bool
+
+	// diff indicates whether a unified diff of the generated resources against
+	// the resources of the same name in the cluster is printed instead of the
+	// resources. Value assigned via --diff flag.
+	diff bool
+
+	// includeStatus indicates whether the load balancer addresses of the
+	// Ingresses read from the cluster are printed as comments before the
+	// generated resources. Value assigned via --include-status flag.
+	includeStatus bool
+
+	// overwriteExisting indicates whether --apply updates the resources that
+	// already exist in the cluster, which are skipped otherwise. Value assigned
+	// via --overwrite-existing flag.
+	overwriteExisting bool
+
+	// dryRun is the dry run strategy: server dry run of --apply, or local
+	// validation of the generated resources before printing them. Value
+	// assigned via --dry-run flag.
+	dryRun string
+
+	// crdPath is the file or directory of the Gateway API CRDs the generated
+	// resources are validated against with --dry-run=client, instead of the
+	// bundled schemas. Value assigned via --crd-path flag.
+	crdPath string
+
+	// addLabels are merged into the labels of every generated resource. Value
+	// assigned via --add-labels flag.
+	addLabels map[string]string
+
+	// preserveAnnotations extends the allowlist of Ingress annotations copied
+	// onto the generated Gateways and HTTPRoutes. Value assigned via
+	// --preserve-annotations flag.
+	preserveAnnotations []string
+
+	// preflight indicates whether the backends of the generated routes are
+	// checked for existence and readiness in the cluster. Value assigned via
+	// --preflight flag.
+	preflight bool
+
+	// reportFile is the path of the JSON file the conversion report, mapping
+	// every Ingress to the resources generated from it, is written to. Value
+	// assigned via --report-file flag.
+	reportFile string
+
+	// namespaceMapping holds the old=new pairs of namespaces the generated
+	// resources are moved to. Value assigned via --namespace-mapping flag.
+	namespaceMapping []string
+
+	// allowConflicts indicates whether conflicting paths are reported as
+	// warnings instead of errors. Value assigned via --allow-conflicts flag.
+	allowConflicts bool
+
+	// timeout bounds the reads from the cluster. Value assigned via --timeout
+	// flag.
+	timeout time.Duration
+
+	// splitOutputDir is the directory every generated resource is written to
+	// in its own file, instead of stdout. Value assigned via
+	// --split-output-dir flag.
+	splitOutputDir string
+
+	// asList indicates whether the generated resources are printed as a single
+	// v1 List. Value assigned via --as-list flag.
+	asList bool
+
+	// groupByGatewayClass indicates whether the generated resources are
+	// output grouped by the GatewayClass of their Gateway. Value assigned via
+	// --group-by-gatewayclass flag.
+	groupByGatewayClass bool
+
+	// allowEmpty indicates whether a source without resources to convert is
+	// output as an empty result instead of an error. Value assigned via
+	// --allow-empty flag.
+	allowEmpty bool
+
+	// stripManagedFields indicates whether the metadata fields populated by
+	// the API server are removed from the printed resources. Value assigned
+	// via --strip-managed-fields flag.
+	stripManagedFields bool
+
+	// compact indicates whether the null fields and the empty maps and lists
+	// are removed from the printed resources. Value assigned via --compact
+	// flag.
+	compact bool
+
+	// from is the kind of resources converted, Ingresses, Contour
+	// HTTPProxies, Istio resources or Emissary-ingress Mappings. Value
+	// assigned via --from flag.
+	from string
+
+	// listenerPort and tlsListenerPort are the ports of the generated HTTP
+	// and HTTPS listeners. Values assigned via --listener-port and
+	// --tls-listener-port flags.
+	listenerPort    int32
+	tlsListenerPort int32
+
+	// mergeCertificateRefs indicates whether the HTTPS hosts of every Gateway
+	// are served by a single listener with the certificateRefs of all their
+	// Secrets. Value assigned via --merge-certificate-refs flag.
+	mergeCertificateRefs bool
+
+	// singleGatewayPerNamespace indicates whether the Ingresses of every
+	// namespace are converted to a single Gateway. Value assigned via
+	// --single-gateway-per-namespace flag.
+	singleGatewayPerNamespace bool
+
+	// mergeGatewaysAcrossNamespaces indicates whether the Gateways of all the
+	// namespaces are merged into a single Gateway of sharedGatewayNamespace.
+	// Values assigned via --merge-gateways-across-namespaces and
+	// --shared-gateway-namespace flags.
+	mergeGatewaysAcrossNamespaces bool
+	sharedGatewayNamespace        string
+
+	// apiVersion is the version of the generated Gateways and HTTPRoutes.
+	// Value assigned via --api-version flag.
+	apiVersion string
+
+	// outputVersion is the Gateway API version the printed resources are
+	// normalized to, through outputScheme, no normalization when empty. Value
+	// assigned via --output-version flag.
+	outputVersion string
+	outputScheme  *runtime.Scheme
+
+	// quiet indicates whether the errors of the resources failing to be
+	// printed are omitted. Value assigned via the global --quiet flag.
+	quiet bool
+
+	// nameTemplate is the template of the names of the generated routes.
+	// Value assigned via --name-template flag.
+	nameTemplate string
+
+	// keepIngressName indicates whether the generated routes are named after
+	// their Ingress, the default, instead of their host. Value assigned via
+	// --keep-ingress-name flag.
+	keepIngressName bool
+
+	// gatewayNameTemplate is the template of the names of the generated
+	// Gateways. Value assigned via --gateway-name flag.
+	gatewayNameTemplate string
+
+	// routeGranularity is the granularity of the generated HTTPRoutes: host,
+	// ingress or path. Value assigned via --route-granularity flag.
+	routeGranularity string
+
+	// backendRefGroup and backendRefKind are the group and kind of the
+	// backendRefs of Service backends. Values assigned via --backend-ref-group
+	// and --backend-ref-kind flags.
+	backendRefGroup string
+	backendRefKind  string
+
+	// continueOnError indicates whether the Ingresses failing to be converted
+	// are left out, instead of failing the conversion. Value assigned via
+	// --continue-on-error flag.
+	continueOnError bool
+
+	// pruneUnusedListeners indicates whether the listeners of the generated
+	// Gateways without routes are removed, and pruneTLSListeners whether the
+	// TLS listeners without routes are removed too. Values assigned via
+	// --prune-unused-listeners and --prune-tls-listeners flags.
+	pruneUnusedListeners bool
+	pruneTLSListeners    bool
+
+	// sourceIngressAnnotation indicates whether the generated resources are
+	// annotated with the Ingresses they are generated from. Value assigned
+	// via --source-ingress-annotation flag.
+	sourceIngressAnnotation bool
+
+	// recordIngressClasses indicates whether the Ingress classes of every
+	// generated Gateway are recorded in its infrastructure. Value assigned via
+	// --record-ingress-class flag.
+	recordIngressClasses bool
+
+	// failOnWarning indicates whether the command fails when the conversion
+	// emitted warnings, once the resources are output. Value assigned via
+	// --fail-on-warning flag.
+	failOnWarning bool
+
+	// targetImplementation is the Gateway API implementation whose policy
+	// attachments are generated. Value assigned via --target-implementation
+	// flag.
+	targetImplementation string
+
+	// securityPolicy is the kind of the policies generated for the source IP
+	// allow-lists of the Ingresses. Value assigned via the deprecated
+	// --security-policy flag, an alias of --target-implementation.
+	securityPolicy string
+
+	// providers are the providers whose Ingress annotations are converted,
+	// detected from the annotations when empty. Value assigned via --providers
+	// flag.
+	providers []string
+
+	// annotationPrefix restricts the converted provider annotations to the
+	// ones of the provider with the prefix. Value assigned via
+	// --annotation-prefix flag.
+	annotationPrefix string
+
+	// fromHelm is the path of a Helm chart rendered into the converted
+	// resources, instead of reading them from the cluster or a file. Value
+	// assigned via --from-helm flag. The release name, values files and
+	// values set are assigned via --helm-release, --helm-chart-values and
+	// --helm-set flags.
+	fromHelm        string
+	helmRelease     string
+	helmChartValues []string
+	helmSetValues   []string
+
+	// kustomize is the path of a kustomization directory built into the
+	// converted resources, instead of reading them from the cluster or a
+	// file. Value assigned via --kustomize flag.
+	kustomize string
+
+	// kubeconfigContexts are the kubeconfig contexts whose Ingresses are
+	// converted and merged, instead of the current context. Value assigned
+	// via --kubeconfig-contexts flag.
+	kubeconfigContexts []string
+}
+
+// PrintGatewaysAndHTTPRoutes performs necessary steps to digest and print
+// converted Gateways and HTTP Routes. The steps includes reading from the source,
+// construct ingresses, convert them, then print them out.
+func (pr *PrintRunner) PrintGatewaysAndHTTPRoutes(cmd *cobra.Command, args []string) error {
+	pr.quiet, _ = cmd.Flags().GetBool(quietFlag)
+	err := pr.initializeNamespaceFilter()
+	if err != nil {
+		return fmt.Errorf("failed to initialize namespace filter: %w", err)
+	}
+	err = pr.initializeResourcePrinter()
+	if err != nil {
+		return fmt.Errorf("failed to initialize resrouce printer: %w", err)
+	}
+	if _, ok := pr.resourcePrinter.(*namePrinter); ok && (pr.asList || pr.splitOutputDir != "") {
+		return fmt.Errorf("--output=name cannot be used with --as-list or --split-output-dir")
+	}
+	if err := i2gw.ValidateInputFormat(pr.inputFormat); err != nil {
+		return err
+	}
+	if pr.dryRun != dryRunNone && pr.dryRun != dryRunServer && pr.dryRun != dryRunClient {
+		return fmt.Errorf("%s is not a supported dry run strategy, must be %s, %s or %s", pr.dryRun, dryRunNone, dryRunServer, dryRunClient)
+	}
+	if pr.dryRun == dryRunServer && !pr.apply {
+		return fmt.Errorf("--dry-run=%s requires --apply", dryRunServer)
+	}
+	if pr.overwriteExisting && !pr.apply {
+		return fmt.Errorf("--overwrite-existing requires --apply")
+	}
+	if pr.dryRun == dryRunClient && pr.apply {
+		return fmt.Errorf("--dry-run=%s cannot be used with --apply, use --dry-run=%s", dryRunClient, dryRunServer)
+	}
+	if pr.crdPath != "" && pr.dryRun != dryRunClient {
+		return fmt.Errorf("--crd-path requires --dry-run=%s", dryRunClient)
+	}
+	if pr.from != fromIngress && pr.from != fromContour && pr.from != fromIstio && pr.from != fromEmissary {
+		return fmt.Errorf("%s is not a supported input, must be %s, %s, %s or %s", pr.from, fromIngress, fromContour, fromIstio, fromEmissary)
+	}
+	if pr.from != fromIngress && (pr.exposureReport || pr.reportFile != "") {
+		return fmt.Errorf("--exposure-report and --report-file require --from=%s", fromIngress)
+	}
+	if pr.includeStatus && pr.from != fromIngress {
+		return fmt.Errorf("--include-status requires --from=%s", fromIngress)
+	}
+	if pr.annotationPrefix != "" && pr.from != fromIngress {
+		return fmt.Errorf("--annotation-prefix requires --from=%s", fromIngress)
+	}
+	if pr.mergeCertificateRefs && pr.from != fromIngress {
+		return fmt.Errorf("--merge-certificate-refs requires --from=%s", fromIngress)
+	}
+	if pr.recordIngressClasses && pr.from != fromIngress {
+		return fmt.Errorf("--record-ingress-class requires --from=%s", fromIngress)
+	}
+	if pr.mergeGatewaysAcrossNamespaces && pr.from != fromIngress {
+		return fmt.Errorf("--merge-gateways-across-namespaces requires --from=%s", fromIngress)
+	}
+	var sharedGatewayNamespace string
+	if pr.mergeGatewaysAcrossNamespaces {
+		if pr.sharedGatewayNamespace == "" {
+			return fmt.Errorf("--merge-gateways-across-namespaces requires --shared-gateway-namespace")
+		}
+		if err := i2gw.ValidateSharedGatewayNamespace(pr.sharedGatewayNamespace); err != nil {
+			return fmt.Errorf("invalid --shared-gateway-namespace: %w", err)
+		}
+		sharedGatewayNamespace = pr.sharedGatewayNamespace
+	}
+
+	namespaceMapping, err := i2gw.ParseNamespaceMapping(pr.namespaceMapping)
+	if err != nil {
+		return fmt.Errorf("failed to parse namespace mapping: %w", err)
+	}
+
+	if pr.timeout <= 0 {
+		return fmt.Errorf("--timeout must be positive, got %s", pr.timeout)
+	}
+	if err := i2gw.ValidateProviders(pr.providers); err != nil {
+		return err
+	}
+	if err := i2gw.ValidateLabels(pr.addLabels); err != nil {
+		return fmt.Errorf("invalid --add-labels: %w", err)
+	}
+	if err := i2gw.ValidateAnnotationPrefix(pr.annotationPrefix); err != nil {
+		return fmt.Errorf("invalid --annotation-prefix: %w", err)
+	}
+	if err := i2gw.ValidateListenerPorts(pr.listenerPort, pr.tlsListenerPort); err != nil {
+		return fmt.Errorf("invalid --listener-port or --tls-listener-port: %w", err)
+	}
+	if err := i2gw.ValidateAPIVersion(pr.apiVersion); err != nil {
+		return fmt.Errorf("invalid --api-version: %w", err)
+	}
+	if pr.outputVersion != "" {
+		if err := i2gw.ValidateAPIVersion(pr.outputVersion); err != nil {
+			return fmt.Errorf("invalid --output-version: %w", err)
+		}
+		if pr.outputScheme, err = newOutputScheme(); err != nil {
+			return fmt.Errorf("failed to create the output scheme: %w", err)
+		}
+	}
+	var crds *i2gw.CRDSchemas
+	if pr.crdPath != "" {
+		crds, err = i2gw.LoadCRDSchemas(pr.crdPath)
+		if err != nil {
+			return fmt.Errorf("invalid --crd-path: %w", err)
+		}
+	}
+	nameTemplate, err := routeNameTemplate(pr.from, pr.nameTemplate, pr.keepIngressName, cmd.Flags().Changed("keep-ingress-name"))
+	if err != nil {
+		return err
+	}
+	if pr.gatewayNameTemplate != "" {
+		if pr.from != fromIngress {
+			return fmt.Errorf("--gateway-name requires --from=%s", fromIngress)
+		}
+		if err := i2gw.ValidateGatewayNameTemplate(pr.gatewayNameTemplate); err != nil {
+			return fmt.Errorf("invalid --gateway-name: %w", err)
+		}
+	}
+	if err := i2gw.ValidateRouteGranularity(pr.routeGranularity); err != nil {
+		return fmt.Errorf("invalid --route-granularity: %w", err)
+	}
+	if pr.routeGranularity != i2gw.RouteGranularityHost && pr.from != fromIngress {
+		return fmt.Errorf("--route-granularity requires --from=%s", fromIngress)
+	}
+	if pr.backendRefGroup != "" || pr.backendRefKind != "" {
+		if pr.from != fromIngress {
+			return fmt.Errorf("--backend-ref-group and --backend-ref-kind require --from=%s", fromIngress)
+		}
+		if err := i2gw.ValidateBackendRefKind(pr.backendRefGroup, pr.backendRefKind); err != nil {
+			return fmt.Errorf("invalid --backend-ref-group or --backend-ref-kind: %w", err)
+		}
+	}
+	if len(pr.kubeconfigContexts) > 0 {
+		if pr.from != fromIngress {
+			return fmt.Errorf("--kubeconfig-contexts requires --from=%s", fromIngress)
+		}
+		if err := validateKubeconfigContexts(pr.kubeconfigContexts); err != nil {
+			return fmt.Errorf("invalid --kubeconfig-contexts: %w", err)
+		}
+	}
+	if pr.continueOnError && pr.from != fromIngress {
+		return fmt.Errorf("--continue-on-error requires --from=%s", fromIngress)
+	}
+	if pr.pruneTLSListeners && !pr.pruneUnusedListeners {
+		return fmt.Errorf("--prune-tls-listeners requires --prune-unused-listeners")
+	}
+	targetImplementation := pr.targetImplementation
+	if pr.securityPolicy != "" {
+		if pr.securityPolicy != i2gw.TargetImplementationEnvoyGateway {
+			return fmt.Errorf("invalid --security-policy: %s is not a supported security policy, must be: %s", pr.securityPolicy, i2gw.TargetImplementationEnvoyGateway)
+		}
+		if targetImplementation != "" && targetImplementation != pr.securityPolicy {
+			return fmt.Errorf("--security-policy cannot be used with --target-implementation")
+		}
+		targetImplementation = pr.securityPolicy
+	}
+	if targetImplementation != "" {
+		if pr.from != fromIngress {
+			return fmt.Errorf("--target-implementation requires --from=%s", fromIngress)
+		}
+		if !i2gw.IsKnownTargetImplementation(targetImplementation) {
+			fmt.Fprintf(os.Stderr, "# Warning: no policies are known for the target implementation %s, only the core resources are generated. Known target implementations: %s\n",
+				targetImplementation, strings.Join(i2gw.TargetImplementations(), ", "))
+		}
+	}
+	var since time.Time
+	if pr.since != "" {
+		if pr.from != fromIngress {
+			return fmt.Errorf("--since requires --from=%s", fromIngress)
+		}
+		since, err = time.Parse(time.RFC3339, pr.since)
+		if err != nil {
+			return fmt.Errorf("invalid --since, must be an RFC3339 time: %w", err)
+		}
+	}
+
+	var cl client.Client
+	ctx := cmd.Context()
+	if pr.fromHelm != "" {
+		dir, err := os.MkdirTemp("", "ingress2gateway-helm-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		namespace := pr.namespaceFilter
+		if namespace == "" {
+			namespace = defaultHelmNamespace
+		}
+		pr.inputFile, err = renderHelmChart(ctx, helmChart{
+			chart:       pr.fromHelm,
+			release:     pr.helmRelease,
+			namespace:   namespace,
+			valuesFiles: pr.helmChartValues,
+			set:         pr.helmSetValues,
+		}, dir)
+		if err != nil {
+			return err
+		}
+	}
+	if pr.kustomize != "" {
+		dir, err := os.MkdirTemp("", "ingress2gateway-kustomize-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		pr.inputFile, err = buildKustomization(pr.kustomize, dir)
+		if err != nil {
+			return err
+		}
+	}
+	if pr.inputFile == stdinInputFile {
+		dir, err := os.MkdirTemp("", "ingress2gateway-stdin-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		pr.inputFile, err = copyStdin(cmd.InOrStdin(), dir)
+		if err != nil {
+			return err
+		}
+	}
+	if pr.inputFile == "" {
+		// The clients of the kubeconfig contexts are created along with the
+		// conversion of their Ingresses.
+		if len(pr.kubeconfigContexts) == 0 {
+			cl, err = newClient("")
+			if err != nil {
+				return err
+			}
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pr.timeout)
+		defer cancel()
+	}
+
+	opts := i2gw.Options{
+		PreserveAnnotations: pr.preserveAnnotations,
+		AllowConflicts:      pr.allowConflicts,
+		Labels:              pr.addLabels,
+		Providers:           pr.providers,
+		AnnotationPrefix:    pr.annotationPrefix,
+		HTTPListenerPort:    pr.listenerPort,
+		HTTPSListenerPort:   pr.tlsListenerPort,
+
+		MergeCertificateRefs:      pr.mergeCertificateRefs,
+		SingleGatewayPerNamespace: pr.singleGatewayPerNamespace,
+		SharedGatewayNamespace:    sharedGatewayNamespace,
+		APIVersion:                pr.apiVersion,
+		NameTemplate:              nameTemplate,
+		GatewayNameTemplate:       pr.gatewayNameTemplate,
+		RouteGranularity:          pr.routeGranularity,
+		TargetImplementation:      targetImplementation,
+		BackendRefGroup:           pr.backendRefGroup,
+		BackendRefKind:            pr.backendRefKind,
+		ContinueOnError:           pr.continueOnError,
+		PruneUnusedListeners:      pr.pruneUnusedListeners,
+		PruneTLSListeners:         pr.pruneTLSListeners,
+		AnnotateSourceIngresses:   pr.sourceIngressAnnotation,
+		RecordIngressClasses:      pr.recordIngressClasses,
+	}
+	var ingressList *networkingv1.IngressList
+	var result i2gw.Result
+	// ingressErrs reports the Ingresses left out of the conversion once the
+	// other resources are output, as do the warnings with --fail-on-warning.
+	var ingressErrs error
+	withIngressErrors := func(err error) error {
+		if err != nil {
+			return err
+		}
+		if ingressErrs != nil {
+			return ingressErrs
+		}
+		if pr.failOnWarning {
+			return warningsError(result.Warnings)
+		}
+		return nil
+	}
+	switch pr.from {
+	case fromIngress:
+		if len(pr.kubeconfigContexts) > 0 {
+			ingressList, result, err = pr.convertContexts(ctx, opts, since)
+			if pr.emptySource(err) {
+				return pr.outputEmpty(os.Stdout, os.Stderr)
+			}
+			if err != nil {
+				return err
+			}
+			if len(result.IngressErrors) > 0 {
+				ingressErrs = ingressErrors(result.IngressErrors, os.Stderr)
+			}
+			break
+		}
+		ingressList, err = getIngessList(ctx, cl, pr.namespaceFilter, pr.excludeNamespaces, pr.inputFile, pr.inputFormat)
+		if pr.emptySource(err) {
+			return pr.outputEmpty(os.Stdout, os.Stderr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get ingresses from source: %w", pr.timeoutError(ctx, err))
+		}
+		// Ingresses read from files are always converted.
+		if pr.inputFile == "" && !since.IsZero() {
+			ingressList.Items = createdSince(ingressList.Items, since)
+			if len(ingressList.Items) == 0 {
+				if pr.allowEmpty {
+					return pr.outputEmpty(os.Stdout, os.Stderr)
+				}
+				return fmt.Errorf("no Ingresses created since %s", pr.since)
+			}
+		}
+
+		configMapList, serviceList, err := getReferencedResources(ctx, cl, ingressList, pr.inputFile, pr.inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to get referenced resources from source: %w", pr.timeoutError(ctx, err))
+		}
+		opts.ConfigMaps = configMapList.Items
+		opts.Services = serviceList.Items
+
+		result, err = i2gw.Convert(ingressList.Items, opts)
+		if err != nil {
+			return conversionError(err)
+		}
+		if len(result.IngressErrors) > 0 {
+			ingressErrs = ingressErrors(result.IngressErrors, os.Stderr)
+		}
+	case fromContour:
+		proxies, err := getHTTPProxies(ctx, cl, pr.namespaceFilter, pr.excludeNamespaces, pr.inputFile, pr.inputFormat)
+		if pr.emptySource(err) {
+			return pr.outputEmpty(os.Stdout, os.Stderr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get HTTPProxies from source: %w", pr.timeoutError(ctx, err))
+		}
+
+		result, err = i2gw.ConvertHTTPProxies(proxies, opts)
+		if err != nil {
+			return conversionError(err)
+		}
+	case fromIstio:
+		gateways, virtualServices, err := getIstioResources(ctx, cl, pr.namespaceFilter, pr.excludeNamespaces, pr.inputFile, pr.inputFormat)
+		if pr.emptySource(err) {
+			return pr.outputEmpty(os.Stdout, os.Stderr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get Istio resources from source: %w", pr.timeoutError(ctx, err))
+		}
+
+		result, err = i2gw.ConvertIstio(gateways, virtualServices, opts)
+		if err != nil {
+			return conversionError(err)
+		}
+	case fromEmissary:
+		mappings, err := getMappings(ctx, cl, pr.namespaceFilter, pr.excludeNamespaces, pr.inputFile, pr.inputFormat)
+		if pr.emptySource(err) {
+			return pr.outputEmpty(os.Stdout, os.Stderr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get Mappings from source: %w", pr.timeoutError(ctx, err))
+		}
+
+		result, err = i2gw.ConvertEmissary(mappings, opts)
+		if err != nil {
+			return conversionError(err)
+		}
+	}
+
+	if pr.preflight {
+		preflightWarnings, err := i2gw.Preflight(ctx, cl, result)
+		if err != nil {
+			return fmt.Errorf("failed to run preflight checks: %w", pr.timeoutError(ctx, err))
+		}
+		result.Warnings = append(result.Warnings, preflightWarnings...)
+	}
+	i2gw.MapNamespaces(namespaceMapping, result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies, result.Warnings)
+	if pr.reportFile != "" {
+		report := i2gw.NewConversionReport(ingressList.Items, result, namespaceMapping)
+		if err := writeConversionReport(report, pr.reportFile); err != nil {
+			return err
+		}
+	}
+	logGeneratedResources(toObjects(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies), result.Warnings)
+	if pr.dryRun == dryRunClient {
+		var err error
+		if crds != nil {
+			err = i2gw.ValidateResourcesWithCRDs(crds, result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants)
+		} else {
+			err = i2gw.ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants)
+		}
+		if err != nil {
+			return validationError(err)
+		}
+	}
+
+	if pr.compatCheck != "" {
+		return withIngressErrors(outputCompatibility(pr.compatCheck, result, os.Stdout))
+	}
+	if pr.exposureReport {
+		return withIngressErrors(outputExposureReport(i2gw.ExposureReport(ingressList.Items, result.Gateways), os.Stdout))
+	}
+
+	if pr.apply {
+		for _, w := range result.Warnings {
+			writeWarning(os.Stderr, w)
+		}
+		return withIngressErrors(applyResources(cmd.Context(), cl, result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies, pr.dryRun, pr.overwriteExisting, os.Stdout))
+	}
+	if pr.diff {
+		for _, w := range result.Warnings {
+			writeWarning(os.Stderr, w)
+		}
+		objs := clientObjects(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies)
+		return withIngressErrors(diffResources(ctx, cl, objs, os.Stdout))
+	}
+	if pr.helmValues {
+		for _, w := range result.Warnings {
+			writeWarning(os.Stderr, w)
+		}
+		return withIngressErrors(outputHelmValues(i2gw.ToHelmValues(result), os.Stdout))
+	}
+
+	if pr.groupByGatewayClass && pr.splitOutputDir != "" {
+		return withIngressErrors(pr.writeGatewayClassSplitOutput(pr.splitOutputDir, i2gw.GroupByGatewayClass(result), os.Stderr))
+	}
+	if pr.splitOutputDir != "" {
+		objs := toObjects(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies)
+		return withIngressErrors(pr.writeSplitOutput(pr.splitOutputDir, objs, result.Warnings, os.Stderr))
+	}
+
+	if pr.includeStatus {
+		_, isYAML := pr.resourcePrinter.(*printers.YAMLPrinter)
+		w := os.Stdout
+		if !isYAML {
+			w = os.Stderr
+		}
+		writeIngressStatus(ingressList.Items, w)
+	}
+	if pr.groupByGatewayClass {
+		return withIngressErrors(pr.outputGatewayClasses(i2gw.GroupByGatewayClass(result), os.Stdout, os.Stderr))
+	}
+	return withIngressErrors(pr.outputResult(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies, result.Warnings, os.Stdout, os.Stderr))
+}
+
+// logGeneratedResources logs the number of generated resources and warnings
+// at verbosity 1, and every generated resource at verbosity 2.
+func logGeneratedResources(objs []runtime.Object, warnings []i2gw.Warning) {
+	klog.V(1).Infof("Generated %d resources with %d warnings", len(objs), len(warnings))
+	if !klog.V(2).Enabled() {
+		return
+	}
+	for _, obj := range objs {
+		if o, ok := obj.(client.Object); ok {
+			klog.V(2).Infof("Generated %s %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, o.GetNamespace(), o.GetName())
+		}
+	}
+}
+
+// timeoutError returns a clear error when a read from the cluster failed
+// because the deadline set by --timeout was exceeded, and err otherwise.
+func (pr *PrintRunner) timeoutError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s reading from the cluster, use --timeout to wait longer: %w", pr.timeout, err)
+	}
+	return err
+}
+
+// conversionError lists the errors aggregated in the error of a conversion.
+func conversionError(err error) error {
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok {
+		return err
+	}
+	errMsg := fmt.Errorf("\n# Encountered %d errors", len(agg.Errors()))
+	for _, err := range agg.Errors() {
+		errMsg = fmt.Errorf("\n%w # %s", errMsg, err)
+	}
+	return errMsg
+}
+
+// writeIngressStatus writes a comment block with the load balancer addresses
+// of every Ingress, so that the addresses the Ingresses were served on can be
+// compared with those of the generated Gateways.
+func writeIngressStatus(ingresses []networkingv1.Ingress, w io.Writer) {
+	for _, ingress := range ingresses {
+		fmt.Fprintf(w, "# Status of Ingress %s/%s:\n", ingress.Namespace, ingress.Name)
+		if len(ingress.Status.LoadBalancer.Ingress) == 0 {
+			fmt.Fprintln(w, "#   no load balancer address")
+			continue
+		}
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			var addresses []string
+			if lb.IP != "" {
+				addresses = append(addresses, lb.IP)
+			}
+			if lb.Hostname != "" {
+				addresses = append(addresses, lb.Hostname)
+			}
+			var ports []string
+			for _, port := range lb.Ports {
+				ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+			}
+			fmt.Fprintf(w, "#   load balancer: %s", strings.Join(addresses, ", "))
+			if len(ports) > 0 {
+				fmt.Fprintf(w, " (ports %s)", strings.Join(ports, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// ingressErrors writes the errors of the Ingresses left out of a conversion
+// continuing on errors to w, and returns the error reporting them.
+func ingressErrors(ingressErrs []i2gw.IngressError, w io.Writer) error {
+	for _, ingressErr := range ingressErrs {
+		for _, err := range ingressErr.Errors {
+			fmt.Fprintf(w, "# Error: %s: %s\n", ingressErr.Ingress, err)
+		}
+	}
+	return fmt.Errorf("failed to convert %d Ingresses, the resources of the other Ingresses were output", len(ingressErrs))
+}
+
+// warningsError returns the error failing the command when the conversion
+// emitted warnings, and nil otherwise.
+func warningsError(warnings []i2gw.Warning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the conversion emitted %d warnings, failing as --fail-on-warning is set", len(warnings))
+}
+
+// validationError lists the errors aggregated in the error of the validation
+// of the generated resources.
+func validationError(err error) error {
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok {
+		return err
+	}
+	errMsg := fmt.Errorf("\n# The generated resources failed validation with %d errors", len(agg.Errors()))
+	for _, err := range agg.Errors() {
+		errMsg = fmt.Errorf("\n%w # %s", errMsg, err)
+	}
+	return errMsg
+}
+
+// outputCompatibility writes a table of the features used by the resources of
+// the conversion result, and whether the implementation supports them.
+func outputCompatibility(implementation string, result i2gw.Result, w io.Writer) error {
+	matrix, err := i2gw.CheckCompatibility(implementation, result)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEATURE\tSUPPORTED\tRESOURCES")
+	for _, fs := range matrix {
+		supported := "yes"
+		if !fs.Supported {
+			supported = "no"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", fs.Feature, supported, strings.Join(fs.Resources, ","))
+	}
+	return tw.Flush()
+}
+
+// outputExposureReport writes a table of the ports exposed per host by the
+// Ingresses and the generated Gateways, along with their differences.
+func outputExposureReport(report []i2gw.HostExposure, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tINGRESS\tGATEWAY\tCHANGES")
+	for _, he := range report {
+		var changes []string
+		for _, pe := range he.Added {
+			changes = append(changes, "+"+pe.String())
+		}
+		for _, pe := range he.Removed {
+			changes = append(changes, "-"+pe.String())
+		}
+		if len(changes) == 0 {
+			changes = []string{"none"}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", he.Host, joinExposures(he.Original), joinExposures(he.Generated), strings.Join(changes, ","))
+	}
+	return tw.Flush()
+}
+
+func joinExposures(exposures []i2gw.PortExposure) string {
+	if len(exposures) == 0 {
+		return "-"
+	}
+	var s []string
+	for _, pe := range exposures {
+		s = append(s, pe.String())
+	}
+	return strings.Join(s, ",")
+}
+
+// outputHelmValues writes the Helm values as YAML.
+func outputHelmValues(values i2gw.HelmValues, w io.Writer) error {
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Helm values: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// writeConversionReport writes the conversion report as JSON to the file at
+// path.
+func writeConversionReport(report i2gw.ConversionReport, path string) error {
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversion report: %w", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write conversion report: %w", err)
+	}
+	return nil
+}
+
+// newClient returns a client of the cluster of the kubeconfig context, the
+// current context when empty, able to handle Gateway API resources.
+func newClient(kubeContext string) (client.Client, error) {
+	conf, err := config.GetConfigWithContext(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client config: %w", err)
+	}
+	scheme, err := newScheme()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheme: %w", err)
+	}
+	cl, err := client.New(conf, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return cl, nil
+}
+
+// routeNameTemplate returns the name template of the routes. The routes
+// converted from Ingresses are named after their Ingress by default, unless
+// the name template is set or keepIngressName is false; only an explicit
+// --keep-ingress-name conflicts with a name template or with other inputs.
+func routeNameTemplate(from, nameTemplate string, keepIngressName, keepIngressNameSet bool) (string, error) {
+	explicit := keepIngressNameSet && keepIngressName
+	switch {
+	case explicit && nameTemplate != "":
+		return "", fmt.Errorf("--keep-ingress-name cannot be used with --name-template")
+	case explicit && from != fromIngress:
+		return "", fmt.Errorf("--keep-ingress-name requires --from=%s", fromIngress)
+	case keepIngressName && nameTemplate == "" && from == fromIngress:
+		return i2gw.KeepIngressNameTemplate, nil
+	case nameTemplate == "":
+		return "", nil
+	case from != fromIngress:
+		return "", fmt.Errorf("--name-template requires --from=%s", fromIngress)
+	}
+	if err := i2gw.ValidateNameTemplate(nameTemplate); err != nil {
+		return "", fmt.Errorf("invalid --name-template: %w", err)
+	}
+	return nameTemplate, nil
+}
+
+func getIngessList(ctx context.Context, cl client.Client, namespaceFilter string, excludeNamespaces []string, inputFile string, inputFormat string) (*networkingv1.IngressList, error) {
+	ingressList := &networkingv1.IngressList{}
+	if inputFile != "" {
+		err := skipManifestErrors(i2gw.ConstructIngressesFromFile(ingressList, inputFile, namespaceFilter, inputFormat), os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+	} else {
+		err := i2gw.ConstructIngressesFromCluster(ctx, client.NewNamespacedClient(cl, namespaceFilter), ingressList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ingress resources from kubenetes cluster: %w", err)
+		}
+	}
+
+	var ingresses []networkingv1.Ingress
+	for _, ingress := range ingressList.Items {
+		if !namespaceExcluded(ingress.Namespace, excludeNamespaces) {
+			ingresses = append(ingresses, ingress)
+		}
+	}
+	ingressList.Items = ingresses
 
-	// The namespace used to query Gateway API objects. Value assigned via
-	// --namespace/-n flag.
-	// On absence, the current user active namespace is used.
-	namespace string
+	if len(ingressList.Items) == 0 {
+		return nil, noResources("Ingresses", namespaceFilter, inputFile)
+	}
+	return ingressList, nil
+}
 
-	// allNamespaces indicates whether all namespaces should be used. Value assigned via
-	// --all-namespaces/-A flag.
-	allNamespaces bool
+// noResourcesError is returned when the source has no resources to convert,
+// which isn't an error with --allow-empty.
+type noResourcesError string
 
-	// resourcePrinter determines how resource objects are printed out
-	resourcePrinter printers.ResourcePrinter
+func (e noResourcesError) Error() string {
+	return string(e)
+}
 
-	// Only resources that matches this filter will be processed.
-	namespaceFilter string
+// noResources returns the error of a source without resources of the kind,
+// telling an input file without them from a namespace or a cluster without
+// them.
+func noResources(kind, namespaceFilter, inputFile string) error {
+	switch {
+	case inputFile != "" && namespaceFilter != "":
+		return noResourcesError(fmt.Sprintf("the input file %s contained no %s of namespace %s", inputFile, kind, namespaceFilter))
+	case inputFile != "":
+		return noResourcesError(fmt.Sprintf("the input file %s contained no %s", inputFile, kind))
+	case namespaceFilter != "":
+		return noResourcesError(fmt.Sprintf("namespace %s has no %s", namespaceFilter, kind))
+	default:
+		return noResourcesError(fmt.Sprintf("no %s found in the cluster", kind))
+	}
 }
 
-// PrintGatewaysAndHTTPRoutes performs necessary steps to digest and print
-// converted Gateways and HTTP Routes. The steps includes reading from the source,
-// construct ingresses, convert them, then print them out.
-func (pr *PrintRunner) PrintGatewaysAndHTTPRoutes(cmd *cobra.Command, args []string) error {
-	err := pr.initializeResourcePrinter()
+// emptySource returns whether err reports a source without resources to
+// convert, which --allow-empty outputs as an empty result.
+func (pr *PrintRunner) emptySource(err error) bool {
+	var noResourcesErr noResourcesError
+	return pr.allowEmpty && errors.As(err, &noResourcesErr)
+}
+
+// outputEmpty prints the result of a source without resources to convert,
+// nothing, or an empty List with --as-list.
+func (pr *PrintRunner) outputEmpty(stdout, stderr io.Writer) error {
+	return pr.outputResult(nil, nil, nil, nil, nil, nil, nil, nil, nil, stdout, stderr)
+}
+
+// copyStdin copies the manifests of stdin to a file of the directory, read as
+// an input file. The file has no extension, so that its format is guessed from
+// its content unless --input-format forces one.
+func copyStdin(stdin io.Reader, dir string) (string, error) {
+	data, err := io.ReadAll(stdin)
 	if err != nil {
-		return fmt.Errorf("failed to initialize resrouce printer: %w", err)
+		return "", fmt.Errorf("failed to read stdin: %w", err)
 	}
-	err = pr.initializeNamespaceFilter()
-	if err != nil {
-		return fmt.Errorf("failed to initialize namespace filter: %w", err)
+	path := filepath.Join(dir, "stdin")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
 	}
+	return path, nil
+}
 
-	ingressList, err := getIngessList(pr.namespaceFilter, pr.inputFile)
-	if err != nil {
-		return fmt.Errorf("failed to get ingresses from source: %w", err)
+// skipManifestErrors writes a warning for every document of the input file
+// failing to be parsed, which is skipped, and returns the other errors.
+func skipManifestErrors(err error, w io.Writer) error {
+	var manifestErrs i2gw.ManifestErrors
+	if !errors.As(err, &manifestErrs) {
+		return err
 	}
+	for _, manifestErr := range manifestErrs {
+		fmt.Fprintf(w, "# Warning: skipped, %v\n", manifestErr)
+	}
+	return nil
+}
 
-	httpRoutes, gateways, errList := i2gw.Ingresses2GatewaysAndHTTPRoutes(ingressList.Items)
-	if len(errList) > 0 {
-		errMsg := fmt.Errorf("\n# Encountered %d errors", len(errList))
-		for _, err := range errList {
-			errMsg = fmt.Errorf("\n%w # %s", errMsg, err)
+// getHTTPProxies returns the HTTPProxies of the input file or of the cluster.
+func getHTTPProxies(ctx context.Context, cl client.Client, namespaceFilter string, excludeNamespaces []string, inputFile string, inputFormat string) ([]i2gw.HTTPProxy, error) {
+	var proxies []i2gw.HTTPProxy
+	var err error
+	if inputFile != "" {
+		proxies, err = i2gw.ConstructHTTPProxiesFromFile(inputFile, namespaceFilter, inputFormat)
+		if err = skipManifestErrors(err, os.Stderr); err != nil {
+			return nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+	} else {
+		proxies, err = i2gw.ConstructHTTPProxiesFromCluster(ctx, cl, namespaceFilter)
+		if err != nil {
+			return nil, err
 		}
-		return errMsg
 	}
 
-	pr.outputResult(httpRoutes, gateways)
+	var included []i2gw.HTTPProxy
+	for _, proxy := range proxies {
+		if !namespaceExcluded(proxy.Namespace, excludeNamespaces) {
+			included = append(included, proxy)
+		}
+	}
+	proxies = included
 
-	return nil
+	if len(proxies) == 0 {
+		return nil, noResources("HTTPProxies", namespaceFilter, inputFile)
+	}
+	return proxies, nil
 }
 
-func getIngessList(namespaceFilter string, inputFile string) (*networkingv1.IngressList, error) {
-	ingressList := &networkingv1.IngressList{}
+// getMappings returns the Emissary-ingress Mappings of the input file or of
+// the cluster.
+func getMappings(ctx context.Context, cl client.Client, namespaceFilter string, excludeNamespaces []string, inputFile string, inputFormat string) ([]i2gw.EmissaryMapping, error) {
+	var mappings []i2gw.EmissaryMapping
+	var err error
 	if inputFile != "" {
-		err := i2gw.ConstructIngressesFromFile(ingressList, inputFile, namespaceFilter)
-		if err != nil {
+		mappings, err = i2gw.ConstructMappingsFromFile(inputFile, namespaceFilter, inputFormat)
+		if err = skipManifestErrors(err, os.Stderr); err != nil {
 			return nil, fmt.Errorf("failed to open input file: %w", err)
 		}
 	} else {
-		conf, err := config.GetConfig()
+		mappings, err = i2gw.ConstructMappingsFromCluster(ctx, cl, namespaceFilter)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get client config: %w", err)
+			return nil, err
 		}
+	}
 
-		cl, err := client.New(conf, client.Options{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create client: %w", err)
+	var included []i2gw.EmissaryMapping
+	for _, mapping := range mappings {
+		if !namespaceExcluded(mapping.Namespace, excludeNamespaces) {
+			included = append(included, mapping)
 		}
-		cl = client.NewNamespacedClient(cl, namespaceFilter)
+	}
+	mappings = included
+
+	if len(mappings) == 0 {
+		return nil, noResources("Mappings", namespaceFilter, inputFile)
+	}
+	return mappings, nil
+}
 
-		err = i2gw.ConstructIngressesFromCluster(cl, ingressList)
+// getIstioResources returns the Istio Gateways and VirtualServices of the
+// input file or of the cluster.
+func getIstioResources(ctx context.Context, cl client.Client, namespaceFilter string, excludeNamespaces []string, inputFile string, inputFormat string) ([]i2gw.IstioGateway, []i2gw.IstioVirtualService, error) {
+	var gateways []i2gw.IstioGateway
+	var virtualServices []i2gw.IstioVirtualService
+	var err error
+	if inputFile != "" {
+		gateways, virtualServices, err = i2gw.ConstructIstioResourcesFromFile(inputFile, namespaceFilter, inputFormat)
+		if err = skipManifestErrors(err, os.Stderr); err != nil {
+			return nil, nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+	} else {
+		gateways, virtualServices, err = i2gw.ConstructIstioResourcesFromCluster(ctx, cl, namespaceFilter)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get ingress resources from kubenetes cluster: %w", err)
+			return nil, nil, err
 		}
 	}
 
-	if len(ingressList.Items) == 0 {
-		msg := "No resources found"
-		if namespaceFilter != "" {
-			return nil, fmt.Errorf("%s in %s namespace", msg, namespaceFilter)
+	var includedGateways []i2gw.IstioGateway
+	for _, gateway := range gateways {
+		if !namespaceExcluded(gateway.Namespace, excludeNamespaces) {
+			includedGateways = append(includedGateways, gateway)
 		}
-		return nil, fmt.Errorf(msg)
 	}
-	return ingressList, nil
+	var includedVirtualServices []i2gw.IstioVirtualService
+	for _, vs := range virtualServices {
+		if !namespaceExcluded(vs.Namespace, excludeNamespaces) {
+			includedVirtualServices = append(includedVirtualServices, vs)
+		}
+	}
+
+	if len(includedGateways) == 0 && len(includedVirtualServices) == 0 {
+		return nil, nil, noResources("Istio Gateways or VirtualServices", namespaceFilter, inputFile)
+	}
+	return includedGateways, includedVirtualServices, nil
 }
 
-func (pr *PrintRunner) outputResult(httpRoutes []gatewayv1beta1.HTTPRoute, gateways []gatewayv1beta1.Gateway) {
-	for i := range gateways {
-		err := pr.resourcePrinter.PrintObj(&gateways[i], os.Stdout)
+// createdSince returns the Ingresses created after the given time.
+func createdSince(ingresses []networkingv1.Ingress, since time.Time) []networkingv1.Ingress {
+	var created []networkingv1.Ingress
+	for _, ingress := range ingresses {
+		if ingress.CreationTimestamp.Time.After(since) {
+			created = append(created, ingress)
+		}
+	}
+	return created
+}
+
+// namespaceExcluded returns whether the namespace is one of the excluded
+// namespaces.
+func namespaceExcluded(namespace string, excludeNamespaces []string) bool {
+	for _, excluded := range excludeNamespaces {
+		if namespace == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// getReferencedResources returns the TCP services and custom headers
+// ConfigMaps and backend Services referenced by the Ingresses. When reading
+// from a file, all ConfigMaps and Services of the file are returned.
+func getReferencedResources(ctx context.Context, cl client.Client, ingressList *networkingv1.IngressList, inputFile string, inputFormat string) (*corev1.ConfigMapList, *corev1.ServiceList, error) {
+	configMapList := &corev1.ConfigMapList{}
+	serviceList := &corev1.ServiceList{}
+	if inputFile != "" {
+		err := i2gw.ConstructConfigMapsFromFile(configMapList, inputFile, inputFormat)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+		err = i2gw.ConstructServicesFromFile(serviceList, inputFile, inputFormat)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open input file: %w", err)
+		}
+		return configMapList, serviceList, nil
+	}
+
+	err := i2gw.ConstructConfigMapsFromCluster(ctx, cl, ingressList.Items, configMapList)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = i2gw.ConstructServicesFromCluster(ctx, cl, ingressList.Items, serviceList)
+	if err != nil {
+		return nil, nil, err
+	}
+	return configMapList, serviceList, nil
+}
+
+// outputResult prints the generated resources to stdout. With YAML output,
+// warnings bound to an HTTPRoute are written as comments above that route.
+// All other warnings are written to stderr. Resources failing to be printed
+// are reported as an error once all the others are printed.
+func (pr *PrintRunner) outputResult(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant, policies []unstructured.Unstructured, warnings []i2gw.Warning, stdout, stderr io.Writer) error {
+	_, isYAML := pr.resourcePrinter.(*printers.YAMLPrinter)
+	warningsByRoute := map[types.NamespacedName][]i2gw.Warning{}
+	for _, w := range warnings {
+		if !isYAML || pr.asList || w.HTTPRoute.Name == "" {
+			writeWarning(stderr, w)
+			continue
+		}
+		warningsByRoute[w.HTTPRoute] = append(warningsByRoute[w.HTTPRoute], w)
+	}
+
+	// Errors are written to stderr, unless quiet, so that the printed
+	// resources remain a valid stream of documents.
+	var failed int
+	printError := func(kind, name string, err error) {
+		failed++
+		if !pr.quiet {
+			fmt.Fprintf(stderr, "# Error printing %s %s: %v\n", name, kind, err)
+		}
+	}
+
+	if pr.asList {
+		list, err := toList(httpRoutes, grpcRoutes, tcpRoutes, tlsRoutes, gateways, backendTLSPolicies, referenceGrants, policies, pr.printable)
+		if err == nil {
+			err = pr.resourcePrinter.PrintObj(list, stdout)
+		}
 		if err != nil {
-			fmt.Printf("# Error printing %s HTTPRoute: %v\n", gateways[i].Name, err)
+			if !pr.quiet {
+				fmt.Fprintf(stderr, "# Error printing List: %v\n", err)
+			}
+			return fmt.Errorf("failed to print the List: %w", err)
+		}
+		return nil
+	}
+
+	for i := range gateways {
+		if err := pr.printObjWithComments(&gateways[i], nil, stdout); err != nil {
+			printError("Gateway", gateways[i].Name, err)
+		}
+	}
+
+	for i := range httpRoutes {
+		routeKey := types.NamespacedName{Namespace: httpRoutes[i].Namespace, Name: httpRoutes[i].Name}
+		if err := pr.printObjWithComments(&httpRoutes[i], warningsByRoute[routeKey], stdout); err != nil {
+			printError("HTTPRoute", httpRoutes[i].Name, err)
+		}
+	}
+
+	for i := range grpcRoutes {
+		if err := pr.printObjWithComments(&grpcRoutes[i], nil, stdout); err != nil {
+			printError("GRPCRoute", grpcRoutes[i].Name, err)
+		}
+	}
+
+	for i := range tcpRoutes {
+		if err := pr.printObjWithComments(&tcpRoutes[i], nil, stdout); err != nil {
+			printError("TCPRoute", tcpRoutes[i].Name, err)
+		}
+	}
+
+	for i := range tlsRoutes {
+		if err := pr.printObjWithComments(&tlsRoutes[i], nil, stdout); err != nil {
+			printError("TLSRoute", tlsRoutes[i].Name, err)
+		}
+	}
+
+	for i := range backendTLSPolicies {
+		if err := pr.printObjWithComments(&backendTLSPolicies[i], nil, stdout); err != nil {
+			printError("BackendTLSPolicy", backendTLSPolicies[i].Name, err)
+		}
+	}
+
+	for i := range referenceGrants {
+		if err := pr.printObjWithComments(&referenceGrants[i], nil, stdout); err != nil {
+			printError("ReferenceGrant", referenceGrants[i].Name, err)
+		}
+	}
+
+	for i := range policies {
+		if err := pr.printObjWithComments(&policies[i], nil, stdout); err != nil {
+			printError(policies[i].GetKind(), policies[i].GetName(), err)
 		}
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("failed to print %d resources", failed)
+	}
+	return nil
+}
+
+// toObjects returns the generated resources in the order they are printed.
+func toObjects(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant, policies []unstructured.Unstructured) []runtime.Object {
+	var objs []runtime.Object
+	for i := range gateways {
+		objs = append(objs, &gateways[i])
+	}
 	for i := range httpRoutes {
-		err := pr.resourcePrinter.PrintObj(&httpRoutes[i], os.Stdout)
+		objs = append(objs, &httpRoutes[i])
+	}
+	for i := range grpcRoutes {
+		objs = append(objs, &grpcRoutes[i])
+	}
+	for i := range tcpRoutes {
+		objs = append(objs, &tcpRoutes[i])
+	}
+	for i := range tlsRoutes {
+		objs = append(objs, &tlsRoutes[i])
+	}
+	for i := range backendTLSPolicies {
+		objs = append(objs, &backendTLSPolicies[i])
+	}
+	for i := range referenceGrants {
+		objs = append(objs, &referenceGrants[i])
+	}
+	for i := range policies {
+		objs = append(objs, &policies[i])
+	}
+	return objs
+}
+
+// toList wraps the generated resources in a v1 List, in the order they are
+// otherwise printed. The resources are transformed by printable, when set,
+// such as to remove their server populated metadata fields.
+func toList(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant, policies []unstructured.Unstructured, printable func(runtime.Object) (runtime.Object, error)) (*corev1.List, error) {
+	objs := toObjects(httpRoutes, grpcRoutes, tcpRoutes, tlsRoutes, gateways, backendTLSPolicies, referenceGrants, policies)
+
+	list := &corev1.List{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"},
+		Items:    []runtime.RawExtension{},
+	}
+	for _, obj := range objs {
+		if printable != nil {
+			var err error
+			if obj, err = printable(obj); err != nil {
+				return nil, err
+			}
+		}
+		// RawExtension is marshaled from Raw only.
+		raw, err := json.Marshal(obj)
 		if err != nil {
-			fmt.Printf("# Error printing %s HTTPRoute: %v\n", httpRoutes[i].Name, err)
+			return nil, err
+		}
+		list.Items = append(list.Items, runtime.RawExtension{Raw: raw})
+	}
+	return list, nil
+}
+
+// serverMetadataFields are the metadata fields populated by the API server,
+// which prevent the printed resources from being applied as is.
+var serverMetadataFields = []string{
+	"creationTimestamp",
+	"deletionGracePeriodSeconds",
+	"deletionTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// stripServerMetadata returns obj as an unstructured object without the
+// server populated metadata fields. Name, namespace, labels and annotations
+// are kept.
+func stripServerMetadata(obj runtime.Object) (runtime.Object, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range serverMetadataFields {
+		unstructured.RemoveNestedField(content, "metadata", f)
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+// printable returns obj as printed: normalized to --output-version when set,
+// without its server populated metadata fields with --strip-managed-fields,
+// and without its null and empty fields with --compact.
+func (pr *PrintRunner) printable(obj runtime.Object) (runtime.Object, error) {
+	var err error
+	if pr.outputScheme != nil {
+		if obj, err = normalizeObject(pr.outputScheme, obj, pr.outputVersion); err != nil {
+			return nil, err
+		}
+	}
+	if pr.stripManagedFields {
+		if obj, err = stripServerMetadata(obj); err != nil {
+			return nil, err
+		}
+	}
+	if pr.compact {
+		if obj, err = compactObject(obj); err != nil {
+			return nil, err
 		}
 	}
+	return obj, nil
+}
+
+// printObjWithComments prints obj, preceded by the given warnings as YAML
+// comments. The comments are placed after the document separator so they stay
+// attached to the object they describe.
+func (pr *PrintRunner) printObjWithComments(obj runtime.Object, warnings []i2gw.Warning, w io.Writer) error {
+	obj, err := pr.printable(obj)
+	if err != nil {
+		return err
+	}
+	if len(warnings) == 0 {
+		return pr.resourcePrinter.PrintObj(obj, w)
+	}
+
+	var buf bytes.Buffer
+	if err := pr.resourcePrinter.PrintObj(obj, &buf); err != nil {
+		return err
+	}
+	out := buf.String()
+	separator := "---\n"
+	if strings.HasPrefix(out, separator) {
+		out = strings.TrimPrefix(out, separator)
+		fmt.Fprint(w, separator)
+	}
+	for _, warning := range warnings {
+		writeWarning(w, warning)
+	}
+	_, err = fmt.Fprint(w, out)
+	return err
+}
+
+// writeWarning writes a warning as a comment, prefixing every line of
+// multi-line messages so the output remains valid YAML.
+func writeWarning(w io.Writer, warning i2gw.Warning) {
+	for _, line := range strings.Split(warning.String(), "\n") {
+		fmt.Fprintf(w, "# Warning: %s\n", line)
+	}
 }
 
 // initializeResourcePrinter assign a specific type of printers.ResourcePrinter
@@ -150,46 +1483,96 @@ func (pr *PrintRunner) initializeResourcePrinter() error {
 	case "json":
 		pr.resourcePrinter = &printers.JSONPrinter{}
 		return nil
+	case "jsonl":
+		pr.resourcePrinter = &jsonLinesPrinter{}
+		return nil
+	case "name":
+		pr.resourcePrinter = &namePrinter{namespace: pr.namespaceFilter}
+		return nil
 	default:
 		return fmt.Errorf("%s is not a supported output format", pr.outputFormat)
 	}
 
 }
 
+// jsonLinesPrinter prints every object as a single line JSON object, in the
+// JSON Lines format.
+type jsonLinesPrinter struct{}
+
+func (p *jsonLinesPrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// namePrinter prints the resource and name of every object, as with kubectl
+// get -o name. Objects outside of namespace, which is empty when all
+// namespaces are converted, are prefixed with the -n flag of their namespace
+// so every line can be passed to kubectl as is.
+type namePrinter struct {
+	namespace string
+}
+
+func (p *namePrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	if acc, err := meta.Accessor(obj); err == nil && acc.GetNamespace() != "" && acc.GetNamespace() != p.namespace {
+		if _, err := fmt.Fprintf(w, "-n %s ", acc.GetNamespace()); err != nil {
+			return err
+		}
+	}
+	return (&printers.NamePrinter{}).PrintObj(obj, w)
+}
+
 // initializeNamespaceFilter initializes the correct namespace filter for resource processing with these scenarios:
 // 1. If the --all-namespaces flag is used, it processes all resources, regardless of whether they are from the cluster or file.
 // 2. If namespace is specified, it filters resources based on that namespace.
-// 3. If no namespace is specified and reading from the cluster, it attempts to get the namespace from the cluster; if unsuccessful, initialization fails.
-// 4. If no namespace is specified and reading from a file, it attempts to get the namespace from the cluster; if unsuccessful, it reads all resources.
+// 3. If no namespace is specified and reading from a file or a kustomization, it reads the resources of all namespaces, without reading the kubeconfig.
+// 4. If no namespace is specified and reading from several kubeconfig contexts, the namespace of every context is used.
+// 5. If no namespace is specified and reading from the cluster, it attempts to get the namespace from the cluster; if unsuccessful, initialization fails.
+// 6. If no namespace is specified and rendering a Helm chart, it attempts to get the namespace from the cluster; if unsuccessful, it reads all resources.
 func (pr *PrintRunner) initializeNamespaceFilter() error {
-	// When we should use all namespaces, empty string is used as the filter.
-	if pr.allNamespaces {
+	switch {
+	case pr.allNamespaces:
+		// When we should use all namespaces, empty string is used as the filter.
+		pr.namespaceFilter = ""
+	case pr.namespace != "":
+		pr.namespaceFilter = pr.namespace
+	case pr.inputFile != "" || pr.kustomize != "":
+		// Files don't depend on a cluster, so the kubeconfig, which may not
+		// exist, isn't read and all the namespaces of the file are used.
+		pr.namespaceFilter = ""
+	case len(pr.kubeconfigContexts) > 0:
+		// The namespace of every context is read along with its Ingresses.
 		pr.namespaceFilter = ""
+		klog.V(1).Infof("Converting the resources of the namespace of every kubeconfig context: %s", strings.Join(pr.kubeconfigContexts, ", "))
 		return nil
-	}
-
-	// If namespace flag is not specified, try to use the default namespace from the cluster
-	if pr.namespace == "" {
+	default:
+		// If namespace flag is not specified, try to use the default namespace from the cluster
 		ns, err := getNamespaceInCurrentContext()
-		if err != nil && pr.inputFile == "" {
+		if err != nil && pr.fromHelm == "" {
 			// When asked to read from the cluster, but getting the current namespace
 			// failed for whatever reason - do not process the request.
 			return err
 		}
 		// If err is nil we got the right filtered namespace.
-		// If the input file is specified, and we failed to get the namespace, use all namespaces.
+		// If a Helm chart is rendered, and we failed to get the namespace, use all namespaces.
 		pr.namespaceFilter = ns
-		return nil
 	}
 
-	pr.namespaceFilter = pr.namespace
+	if pr.namespaceFilter == "" {
+		klog.V(1).Info("Converting the resources of all namespaces")
+	} else {
+		klog.V(1).Infof("Converting the resources of namespace %s", pr.namespaceFilter)
+	}
 	return nil
 }
 
 func newPrintCommand() *cobra.Command {
 	pr := &PrintRunner{}
 	var printFlags genericclioptions.JSONYamlPrintFlags
-	allowedFormats := printFlags.AllowedFormats()
+	allowedFormats := append(printFlags.AllowedFormats(), "jsonl", "name")
 
 	// printCmd represents the print command. It prints HTTPRoutes and Gateways
 	// generated from Ingress resources.
@@ -203,7 +1586,10 @@ func newPrintCommand() *cobra.Command {
 		fmt.Sprintf(`Output format. One of: (%s)`, strings.Join(allowedFormats, ", ")))
 
 	cmd.Flags().StringVar(&pr.inputFile, "input_file", "",
-		`Path to the manifest file. When set, the tool will read ingresses from the file instead of reading from the cluster. Supported files are yaml and json`)
+		`Path to the manifest file, or to a directory of manifest files. When set, the tool will read ingresses from the file instead of reading from the cluster. Supported files are yaml and json, directories are read recursively and each of their .yaml, .yml and .json files is parsed in its own format. The manifests are read from stdin with -`)
+
+	cmd.Flags().StringVar(&pr.inputFormat, "input-format", i2gw.InputFormatAuto,
+		fmt.Sprintf(`Format of the manifests of the input file. One of: (%s, %s, %s). With %s, the format is given by the extension of the files, or guessed from their first non-whitespace character for stdin and other extensions`, i2gw.InputFormatAuto, i2gw.InputFormatYAML, i2gw.InputFormatJSON, i2gw.InputFormatAuto))
 
 	cmd.Flags().StringVarP(&pr.namespace, "namespace", "n", "",
 		`If present, the namespace scope for this CLI request`)
@@ -212,15 +1598,200 @@ func newPrintCommand() *cobra.Command {
 		`If present, list the requested object(s) across all namespaces. Namespace in current context is ignored even
 if specified with --namespace.`)
 
+	cmd.Flags().StringSliceVar(&pr.excludeNamespaces, "exclude-namespaces", nil,
+		`Namespaces, separated by commas, whose resources are not converted, e.g. kube-system,ingress-nginx. Cannot be used with --namespace`)
+
+	cmd.Flags().StringVar(&pr.since, "since", "",
+		`If present, only convert the Ingresses of the cluster created after this RFC3339 time, e.g. 2024-01-02T15:04:05Z. Ignored when reading from --input_file`)
+
+	cmd.Flags().StringVar(&pr.compatCheck, "compat-check", "",
+		fmt.Sprintf(`If present, print which features used by the generated resources are supported by this implementation instead of the resources. One of: (%s)`, strings.Join(i2gw.SupportedImplementations(), ", ")))
+
+	cmd.Flags().BoolVar(&pr.exposureReport, "exposure-report", false,
+		`If present, print the ports and protocols exposed per host by the Ingresses and by the generated Gateways, highlighting the differences, instead of the resources`)
+
+	cmd.Flags().BoolVar(&pr.helmValues, "helm-values", false,
+		fmt.Sprintf(`If present, print the generated resources as a values.yaml of a generic Gateway API Helm chart, in the %s format, instead of the resources`, i2gw.HelmValuesVersion))
+
+	cmd.Flags().BoolVar(&pr.apply, "apply", false,
+		`If present, create the generated resources in the cluster instead of printing them. Resources that already exist are skipped with a warning, unless --overwrite-existing is set`)
+
+	cmd.Flags().BoolVar(&pr.includeStatus, "include-status", false,
+		`If present, the load balancer addresses of the status of every Ingress read from the cluster are printed as comments before the generated resources, for debugging`)
+
+	cmd.Flags().BoolVar(&pr.diff, "diff", false,
+		`If present, print a unified diff of every generated resource against the resource of the same kind, namespace and name in the cluster instead of the resources, marking the resources that don't exist yet as new. The cluster isn't modified`)
+
+	cmd.Flags().BoolVar(&pr.overwriteExisting, "overwrite-existing", false,
+		`If present, --apply updates the generated resources that already exist in the cluster, reverting their manual changes`)
+
+	cmd.Flags().StringVar(&pr.dryRun, "dry-run", dryRunNone,
+		fmt.Sprintf(`Must be "%s", "%s" or "%s". With "%s", the resources are submitted to the server with --apply without being persisted. With "%s", the generated resources are validated locally against the Gateway API schemas, without cluster access, and only printed when they are all valid`, dryRunNone, dryRunServer, dryRunClient, dryRunServer, dryRunClient))
+
+	cmd.Flags().StringVar(&pr.crdPath, "crd-path", "",
+		fmt.Sprintf(`With --dry-run=%s, the generated resources are validated against the schemas of the Gateway API CRDs of this file or directory, such as the CRDs installed in the cluster, instead of the bundled schemas`, dryRunClient))
+
+	cmd.Flags().StringToStringVar(&pr.addLabels, "add-labels", nil,
+		`Labels added to every generated resource, as key=value pairs separated by commas, e.g. app.kubernetes.io/managed-by=ingress2gateway. Labels already set on a resource are kept`)
+
+	cmd.Flags().StringSliceVar(&pr.preserveAnnotations, "preserve-annotations", nil,
+		fmt.Sprintf(`Ingress annotations copied onto the generated Gateways and HTTPRoutes, in addition to: (%s). Keys ending with a "/" match all annotations with that prefix`, strings.Join(i2gw.DefaultPreservedAnnotations, ", ")))
+
+	cmd.Flags().BoolVar(&pr.preflight, "preflight", false,
+		`If present, check that the backend Services of the generated routes exist in the cluster, expose the referenced ports and have ready endpoints, and warn about the ones that don't`)
+
+	cmd.Flags().StringVar(&pr.reportFile, "report-file", "",
+		`If present, write a JSON report listing every Ingress along with the Gateways, routes and warnings generated from it to this file`)
+
+	cmd.Flags().StringSliceVar(&pr.namespaceMapping, "namespace-mapping", nil,
+		`Namespaces the generated resources are moved to, as old=new pairs separated by commas, e.g. staging=prod. References to other namespaces are rewritten as well. Unmapped namespaces are left unchanged`)
+
+	cmd.Flags().BoolVar(&pr.allowConflicts, "allow-conflicts", false,
+		`If present, paths of a host routed to different backends by several Ingress rules are reported as warnings instead of errors, and the first rule takes precedence`)
+
+	cmd.Flags().Int32Var(&pr.listenerPort, "listener-port", i2gw.DefaultHTTPListenerPort,
+		`The port of the generated HTTP listeners`)
+
+	cmd.Flags().Int32Var(&pr.tlsListenerPort, "tls-listener-port", i2gw.DefaultHTTPSListenerPort,
+		`The port of the generated HTTPS listeners, must differ from --listener-port`)
+
+	cmd.Flags().BoolVar(&pr.mergeCertificateRefs, "merge-certificate-refs", false,
+		`If present, the HTTPS hosts of every Gateway served with different TLS Secrets share a single listener whose certificateRefs are all their Secrets, instead of a listener per host. Hosts served with several Secrets are reported as errors`)
+
+	cmd.Flags().StringVar(&pr.apiVersion, "api-version", i2gw.APIVersionV1Beta1,
+		fmt.Sprintf(`The version of the generated Gateways and HTTPRoutes: %s or %s. Session affinity is only converted with %s`, i2gw.APIVersionV1Beta1, i2gw.APIVersionV1, i2gw.APIVersionV1))
+
+	cmd.Flags().StringVar(&pr.nameTemplate, "name-template", "",
+		`If present, the template of the names of the routes generated for every host of the Ingresses, instead of their Ingress, e.g. {namespace}-{ingress}. Placeholders are {ingress}, the name of the first Ingress of the host, {namespace} and {host}. Names are made valid DNS-1123 labels, truncated with a hash suffix beyond 63 characters, and suffixed with the host when they collide`)
+
+	cmd.Flags().BoolVar(&pr.keepIngressName, "keep-ingress-name", true,
+		fmt.Sprintf(`The routes generated for every host are named after their Ingress, as with --name-template=%s, unless --name-template is set. With --keep-ingress-name=false, they are named after their host`, i2gw.KeepIngressNameTemplate))
+
+	cmd.Flags().StringVar(&pr.gatewayNameTemplate, "gateway-name", "",
+		`If present, the template of the names of the generated Gateways, instead of their class, e.g. {namespace}-{class}. Placeholders are {class}, the class of the Gateway, and {namespace}. Names are made valid DNS-1123 labels, and names shared by several Gateways of a namespace are suffixed with a hash of their class and listeners`)
+
+	cmd.Flags().StringVar(&pr.backendRefGroup, "backend-ref-group", "",
+		`If present, the group of the backendRefs of the Service backends of the Ingresses, instead of the core group. Requires --backend-ref-kind`)
+
+	cmd.Flags().StringVar(&pr.backendRefKind, "backend-ref-kind", "",
+		`If present, the kind of the backendRefs of the Service backends of the Ingresses, instead of Service, e.g. a custom resource proxying the traffic. The ingress2gateway.kubernetes.io/backend-ref-group and backend-ref-kind annotations of an Ingress take precedence`)
+
+	cmd.Flags().StringVar(&pr.routeGranularity, "route-granularity", i2gw.RouteGranularityHost,
+		fmt.Sprintf(`The granularity of the generated HTTPRoutes: an HTTPRoute per host with %s, per host and Ingress with %s, or per host and path with %s. The routes of a host are attached to the same Gateway listeners and route the same requests whatever the granularity`, i2gw.RouteGranularityHost, i2gw.RouteGranularityIngress, i2gw.RouteGranularityPath))
+
+	cmd.Flags().BoolVar(&pr.continueOnError, "continue-on-error", false,
+		`If present, the Ingresses failing to be converted are left out and their errors are reported on stderr, while the resources of the other Ingresses are still output. The command fails if any Ingress failed`)
+
+	cmd.Flags().BoolVar(&pr.pruneUnusedListeners, "prune-unused-listeners", false,
+		`If present, the listeners of the generated Gateways that no generated route is attached to are removed, keeping at least one listener per Gateway. The TLS listeners terminating the TLS of a host are kept, as they express its TLS intent, unless --prune-tls-listeners is set`)
+
+	cmd.Flags().BoolVar(&pr.pruneTLSListeners, "prune-tls-listeners", false,
+		`With --prune-unused-listeners, the TLS listeners without routes are removed too`)
+
+	cmd.Flags().BoolVar(&pr.sourceIngressAnnotation, "source-ingress-annotation", true,
+		fmt.Sprintf(`Annotate the resources generated from Ingresses with %s, listing the Ingresses every resource is generated from as <namespace>/<name>. Use --source-ingress-annotation=false to suppress it`, i2gw.SourceIngressAnnotation))
+
+	cmd.Flags().BoolVar(&pr.recordIngressClasses, "record-ingress-class", false,
+		fmt.Sprintf(`If present, the Ingress classes of the Ingresses of every generated Gateway are recorded in the %s annotation of its spec.infrastructure, an experimental Gateway API field, so they can be mapped to the GatewayClass of their controller`, i2gw.IngressClassAnnotation))
+
+	cmd.Flags().BoolVar(&pr.failOnWarning, "fail-on-warning", false,
+		`If present, the command fails when the conversion emitted any warning, such as an annotation or path that can't be represented in Gateway API, once the resources and warnings are output. By default warnings don't fail the command`)
+
+	cmd.Flags().StringVar(&pr.targetImplementation, "target-implementation", "",
+		fmt.Sprintf(`If present, the Gateway API implementation whose policy attachments are generated, alongside the core resources, for the Ingress annotations Gateway API can't represent: %s. Only the core resources are generated for other implementations, and the annotations are reported as warnings`, strings.Join(i2gw.TargetImplementations(), ", ")))
+
+	cmd.Flags().StringVar(&pr.securityPolicy, "security-policy", "",
+		fmt.Sprintf(`If present, the kind of the policies generated to enforce the source IP allow-lists of the Ingresses: %s`, i2gw.TargetImplementationEnvoyGateway))
+	_ = cmd.Flags().MarkDeprecated("security-policy", fmt.Sprintf("use --target-implementation=%s instead", i2gw.TargetImplementationEnvoyGateway))
+
+	cmd.Flags().BoolVar(&pr.singleGatewayPerNamespace, "single-gateway-per-namespace", false,
+		`If present, the Ingresses of every namespace are converted to a single Gateway aggregating all their listeners, instead of a Gateway per Ingress class`)
+
+	cmd.Flags().BoolVar(&pr.mergeGatewaysAcrossNamespaces, "merge-gateways-across-namespaces", false,
+		`If present, the Gateways of all the namespaces are merged into a single Gateway of --shared-gateway-namespace, which the routes reference with cross-namespace parentRefs. Its listeners allow the routes of their namespaces, and ReferenceGrants allow it to reference the TLS Secrets of the other namespaces`)
+
+	cmd.Flags().StringVar(&pr.sharedGatewayNamespace, "shared-gateway-namespace", "infra",
+		`The namespace of the Gateway shared across namespaces with --merge-gateways-across-namespaces`)
+
+	cmd.Flags().StringSliceVar(&pr.providers, "providers", nil,
+		fmt.Sprintf(`Providers whose Ingress annotations are converted, separated by commas: %s. If empty, the providers are detected from the annotations of every Ingress`, strings.Join(i2gw.ProviderNames(), ", ")))
+
+	cmd.Flags().StringVar(&pr.annotationPrefix, "annotation-prefix", "",
+		`The annotation prefix of the provider whose annotations are converted, such as nginx.ingress.kubernetes.io, ignoring the annotations of the other providers, e.g. the ones of another Ingress controller of the cluster. If empty, the annotations of all the enabled providers are converted`)
+
+	cmd.Flags().DurationVar(&pr.timeout, "timeout", 30*time.Second,
+		`The maximum time spent reading resources from the cluster. Ignored when reading from --input_file`)
+
+	cmd.Flags().StringVar(&pr.outputVersion, "output-version", "",
+		fmt.Sprintf(`If set to "%s" or "%s", the printed resources are normalized through the Gateway API scheme: the Gateways and HTTPRoutes are converted to this version and the defaults of the Gateway API schemas are applied to all the resources. By default, the resources are printed as generated`, i2gw.APIVersionV1Beta1, i2gw.APIVersionV1))
+
+	cmd.Flags().BoolVar(&pr.stripManagedFields, "strip-managed-fields", true,
+		`If true, the metadata fields populated by the API server, such as resourceVersion, uid, creationTimestamp and managedFields, are removed from the printed resources so they can be applied as is`)
+
+	cmd.Flags().BoolVar(&pr.compact, "compact", false,
+		`If present, the null fields, such as creationTimestamp, and the empty maps and lists, such as the status of the generated routes, are removed from the printed resources`)
+
+	cmd.Flags().BoolVar(&pr.asList, "as-list", false,
+		`If present, print the generated resources wrapped in a single v1 List instead of one document per resource. Warnings are written to stderr`)
+
+	cmd.Flags().BoolVar(&pr.groupByGatewayClass, "group-by-gatewayclass", false,
+		`If present, the generated resources are output grouped by the GatewayClass of their Gateway, in contiguous blocks led by a comment naming the class, or with --split-output-dir in a subdirectory per class. Resources belonging to no generated Gateway come last, in the output directory itself`)
+
+	cmd.Flags().BoolVar(&pr.allowEmpty, "allow-empty", false,
+		`If present, a source without resources to convert, such as a namespace without Ingresses, isn't an error: nothing is printed, or an empty List with --as-list, and the command exits 0`)
+
+	cmd.Flags().StringVar(&pr.splitOutputDir, "split-output-dir", "",
+		`If set, every generated resource is written to its own <kind>-<namespace>-<name>.yaml file in the directory, created if missing, instead of stdout. Existing files are overwritten`)
+
+	cmd.Flags().StringVar(&pr.fromHelm, "from-helm", "",
+		`Path of a Helm chart, rendered with helm template, whose resources are converted instead of reading them from the cluster. Objects without namespace are in the namespace of the release, the --namespace or the current namespace, otherwise "default". Requires helm in the PATH`)
+
+	cmd.Flags().StringVar(&pr.helmRelease, "helm-release", "release-name",
+		`The name of the release the --from-helm chart is rendered as`)
+
+	cmd.Flags().StringSliceVar(&pr.helmChartValues, "helm-chart-values", nil,
+		`Values files of the --from-helm chart, separated by commas, as with helm template --values`)
+
+	cmd.Flags().StringArrayVar(&pr.helmSetValues, "helm-set", nil,
+		`Values of the --from-helm chart, as with helm template --set. Can be repeated`)
+
+	cmd.Flags().StringVar(&pr.kustomize, "kustomize", "",
+		`Path of a kustomization directory, built as with kustomize build, whose resources are converted instead of reading them from the cluster. The kinds that aren't converted are ignored`)
+
+	cmd.Flags().StringSliceVar(&pr.kubeconfigContexts, "kubeconfig-contexts", nil,
+		fmt.Sprintf(`Kubeconfig contexts, separated by commas, whose Ingresses are converted and merged into a single output, instead of the current context. Every generated resource is labeled with %s, and resources given the same name from several contexts are suffixed with their context`, i2gw.SourceContextLabel))
+
+	cmd.Flags().StringVar(&pr.from, "from", fromIngress,
+		fmt.Sprintf(`The resources converted. One of: (%s, %s, %s, %s). With "%s", Contour HTTPProxies are converted. With "%s", Istio Gateways and VirtualServices are converted. With "%s", Emissary-ingress Mappings are converted. The annotations of the Ingress controllers, such as the GKE Ingress annotations, are selected with --providers instead`, fromIngress, fromContour, fromIstio, fromEmissary, fromContour, fromIstio, fromEmissary))
+
 	cmd.MarkFlagsMutuallyExclusive("namespace", "all-namespaces")
+	cmd.MarkFlagsMutuallyExclusive("namespace", "exclude-namespaces")
+	cmd.MarkFlagsMutuallyExclusive("compat-check", "exposure-report", "helm-values", "apply", "diff", "as-list", "split-output-dir")
+	cmd.MarkFlagsMutuallyExclusive("group-by-gatewayclass", "as-list")
+	cmd.MarkFlagsMutuallyExclusive("apply", "input_file")
+	cmd.MarkFlagsMutuallyExclusive("diff", "input_file")
+	cmd.MarkFlagsMutuallyExclusive("preflight", "input_file")
+	cmd.MarkFlagsMutuallyExclusive("include-status", "input_file")
+	cmd.MarkFlagsMutuallyExclusive("include-status", "from-helm")
+	cmd.MarkFlagsMutuallyExclusive("from-helm", "input_file", "apply", "diff", "preflight")
+	cmd.MarkFlagsMutuallyExclusive("kustomize", "from-helm", "input_file", "apply", "diff", "preflight")
+	cmd.MarkFlagsMutuallyExclusive("include-status", "kustomize")
+	cmd.MarkFlagsMutuallyExclusive("single-gateway-per-namespace", "merge-gateways-across-namespaces")
+	cmd.MarkFlagsMutuallyExclusive("kubeconfig-contexts", "from-helm", "kustomize", "input_file", "apply", "diff", "preflight")
 	return cmd
 }
 
 // getNamespaceInCurrentContext returns the namespace in the current active context of the user.
 func getNamespaceInCurrentContext() (string, error) {
+	return getNamespaceInContext("")
+}
+
+// getNamespaceInContext returns the namespace of the kubeconfig context, the
+// current context when empty.
+func getNamespaceInContext(kubeContext string) (string, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
 	currentNamespace, _, err := kubeConfig.Namespace()
 
 	return currentNamespace, err
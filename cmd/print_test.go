@@ -17,14 +17,28 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/cli-runtime/pkg/printers"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 func Test_getResourcePrinter(t *testing.T) {
@@ -52,6 +66,18 @@ func Test_getResourcePrinter(t *testing.T) {
 			expectedPrinter: &printers.YAMLPrinter{},
 			expectingError:  false,
 		},
+		{
+			name:            "JSON Lines format",
+			outputFormat:    "jsonl",
+			expectedPrinter: &jsonLinesPrinter{},
+			expectingError:  false,
+		},
+		{
+			name:            "Name format",
+			outputFormat:    "name",
+			expectedPrinter: &namePrinter{},
+			expectingError:  false,
+		},
 		{
 			name:            "Unsupported format",
 			outputFormat:    "invalid",
@@ -80,6 +106,239 @@ func Test_getResourcePrinter(t *testing.T) {
 	}
 }
 
+func Test_jsonLinesPrinter(t *testing.T) {
+	p := &jsonLinesPrinter{}
+	var buf bytes.Buffer
+	for _, name := range []string{"a-com", "b-com"} {
+		route := &gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"}}
+		route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+		if err := p.PrintObj(route, &buf); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Errorf("Expected a JSON object per line, got %q: %v", line, err)
+		}
+		if obj["kind"] != "HTTPRoute" {
+			t.Errorf("Expected an HTTPRoute, got %q", line)
+		}
+	}
+}
+
+func Test_namePrinter(t *testing.T) {
+	gateway := &gatewayv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"}}
+	gateway.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("Gateway"))
+	route := &gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "prod"}}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+
+	testCases := []struct {
+		name      string
+		namespace string
+		expected  string
+	}{{
+		name:      "all namespaces",
+		namespace: "",
+		expected:  "-n test gateway.gateway.networking.k8s.io/nginx\n-n prod httproute.gateway.networking.k8s.io/example-com\n",
+	}, {
+		name:      "namespace scope",
+		namespace: "test",
+		expected:  "gateway.gateway.networking.k8s.io/nginx\n-n prod httproute.gateway.networking.k8s.io/example-com\n",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &namePrinter{namespace: tc.namespace}
+			var buf bytes.Buffer
+			for _, obj := range []runtime.Object{gateway, route} {
+				if err := p.PrintObj(obj, &buf); err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+			}
+			if buf.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func Test_printObjWithComments(t *testing.T) {
+	pr := PrintRunner{resourcePrinter: &printers.YAMLPrinter{}}
+	route := &gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"}}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+	warnings := []i2gw.Warning{{
+		Ingress: types.NamespacedName{Namespace: "test", Name: "example"},
+		Field:   field.NewPath("example", "metadata", "annotations").Key("nginx.ingress.kubernetes.io/server-snippet"),
+		Message: "line one\nline two",
+	}}
+
+	var buf bytes.Buffer
+	if err := pr.printObjWithComments(route, nil, &buf); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	buf.Reset()
+	if err := pr.printObjWithComments(route, warnings, &buf); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expectedPrefix := `---
+# Warning: test/example: example.metadata.annotations[nginx.ingress.kubernetes.io/server-snippet]: line one
+# Warning: line two
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+`
+	if !strings.HasPrefix(buf.String(), expectedPrefix) {
+		t.Errorf("printObjWithComments() = %q, expected prefix %q", buf.String(), expectedPrefix)
+	}
+}
+
+// failingPrinter fails to print the objects of a kind, and prints the name of
+// the others.
+type failingPrinter struct {
+	kind string
+}
+
+func (p *failingPrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	if obj.GetObjectKind().GroupVersionKind().Kind == p.kind {
+		return fmt.Errorf("cannot print %s", p.kind)
+	}
+	return (&printers.NamePrinter{}).PrintObj(obj, w)
+}
+
+func Test_outputResult(t *testing.T) {
+	gateway := gatewayv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"}}
+	gateway.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("Gateway"))
+	route := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"}}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+
+	testCases := []struct {
+		name           string
+		failingKind    string
+		quiet          bool
+		expectedStdout string
+		expectedStderr string
+		expectingError bool
+	}{{
+		name:           "all resources printed",
+		expectedStdout: "gateway.gateway.networking.k8s.io/nginx\nhttproute.gateway.networking.k8s.io/example-com\n",
+	}, {
+		name:           "errors written to stderr",
+		failingKind:    "HTTPRoute",
+		expectedStdout: "gateway.gateway.networking.k8s.io/nginx\n",
+		expectedStderr: "# Error printing example-com HTTPRoute: cannot print HTTPRoute\n",
+		expectingError: true,
+	}, {
+		name:           "quiet",
+		failingKind:    "HTTPRoute",
+		quiet:          true,
+		expectedStdout: "gateway.gateway.networking.k8s.io/nginx\n",
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := PrintRunner{resourcePrinter: &failingPrinter{kind: tc.failingKind}, quiet: tc.quiet}
+			var stdout, stderr bytes.Buffer
+			err := pr.outputResult([]gatewayv1beta1.HTTPRoute{route}, nil, nil, nil, []gatewayv1beta1.Gateway{gateway}, nil, nil, nil, nil, &stdout, &stderr)
+			if tc.expectingError != (err != nil) {
+				t.Errorf("outputResult() error = %v, expecting error: %v", err, tc.expectingError)
+			}
+			if stdout.String() != tc.expectedStdout {
+				t.Errorf("Expected stdout %q, got %q", tc.expectedStdout, stdout.String())
+			}
+			if stderr.String() != tc.expectedStderr {
+				t.Errorf("Expected stderr %q, got %q", tc.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func Test_toList(t *testing.T) {
+	gateway := gatewayv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"}}
+	gateway.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("Gateway"))
+	route := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"}}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+
+	list, err := toList([]gatewayv1beta1.HTTPRoute{route}, nil, nil, nil, []gatewayv1beta1.Gateway{gateway}, nil, nil, nil, stripServerMetadata)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := (&printers.YAMLPrinter{}).PrintObj(list, &buf); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `apiVersion: v1
+items:
+- apiVersion: gateway.networking.k8s.io/v1beta1
+  kind: Gateway
+  metadata:
+    name: nginx
+    namespace: test
+  spec:
+    gatewayClassName: ""
+    listeners: null
+  status: {}
+- apiVersion: gateway.networking.k8s.io/v1beta1
+  kind: HTTPRoute
+  metadata:
+    name: example-com
+    namespace: test
+  spec: {}
+  status:
+    parents: null
+kind: List
+metadata: {}
+`
+	if buf.String() != expected {
+		t.Errorf("toList() printed %q, expected %q", buf.String(), expected)
+	}
+}
+
+func Test_stripServerMetadata(t *testing.T) {
+	route := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{
+		Name:              "example-com",
+		Namespace:         "test",
+		Labels:            map[string]string{"app": "example"},
+		Annotations:       map[string]string{"example.com/owner": "web"},
+		ResourceVersion:   "42",
+		UID:               "0b4bd2d4-4a5e-4bd1-9f4a-5e2c0f1c7a19",
+		Generation:        3,
+		CreationTimestamp: metav1.Now(),
+		ManagedFields:     []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+	}}
+	route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+
+	pr := &PrintRunner{resourcePrinter: &printers.YAMLPrinter{}, stripManagedFields: true}
+	var buf bytes.Buffer
+	if err := pr.printObjWithComments(&route, nil, &buf); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: HTTPRoute
+metadata:
+  annotations:
+    example.com/owner: web
+  labels:
+    app: example
+  name: example-com
+  namespace: test
+spec: {}
+status:
+  parents: null
+`
+	if buf.String() != expected {
+		t.Errorf("printObjWithComments() printed %q, expected %q", buf.String(), expected)
+	}
+}
+
 func Test_getNamespaceFilter(t *testing.T) {
 	testCases := []struct {
 		name                      string
@@ -149,6 +408,54 @@ func Test_getNamespaceFilter(t *testing.T) {
 	}
 }
 
+func Test_getNamespaceFilterFromFile(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		pr                      PrintRunner
+		kubeconfig              bool
+		expectedNamespaceFilter string
+	}{{
+		name:                    "file without kubeconfig",
+		pr:                      PrintRunner{inputFile: "ingresses.yaml"},
+		expectedNamespaceFilter: "",
+	}, {
+		name:                    "file ignores the namespace of the current context",
+		pr:                      PrintRunner{inputFile: "ingresses.yaml"},
+		kubeconfig:              true,
+		expectedNamespaceFilter: "",
+	}, {
+		name:                    "kustomization without kubeconfig",
+		pr:                      PrintRunner{kustomize: "./overlays/prod"},
+		expectedNamespaceFilter: "",
+	}, {
+		name:                    "file with namespace",
+		pr:                      PrintRunner{inputFile: "ingresses.yaml", namespace: "test"},
+		expectedNamespaceFilter: "test",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.kubeconfig {
+				destroy, err := setupKubeConfig()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer destroy()
+			} else {
+				t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+			}
+
+			pr := tc.pr
+			if err := pr.initializeNamespaceFilter(); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if pr.namespaceFilter != tc.expectedNamespaceFilter {
+				t.Errorf("initializeNamespaceFilter() filter = %q, expected %q", pr.namespaceFilter, tc.expectedNamespaceFilter)
+			}
+		})
+	}
+}
+
 func setupKubeConfig() (func(), error) {
 
 	// Clean up from the last test, just in case...
@@ -236,3 +543,358 @@ func Test_getNamespaceInCurrentContext(t *testing.T) {
 			actualNamespace, err, expectedNamespace, nil)
 	}
 }
+
+func Test_getIngessListExcludeNamespaces(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "ingresses.yaml")
+	content := `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: app
+  namespace: default
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: dashboard
+  namespace: kube-system
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: metrics
+  namespace: ingress-nginx
+`
+	if err := os.WriteFile(inputFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name              string
+		excludeNamespaces []string
+		expectedIngresses []string
+		expectingError    bool
+	}{{
+		name:              "no exclusion",
+		expectedIngresses: []string{"default/app", "kube-system/dashboard", "ingress-nginx/metrics"},
+	}, {
+		name:              "system namespaces excluded",
+		excludeNamespaces: []string{"kube-system", "ingress-nginx"},
+		expectedIngresses: []string{"default/app"},
+	}, {
+		name:              "all namespaces excluded",
+		excludeNamespaces: []string{"default", "kube-system", "ingress-nginx"},
+		expectingError:    true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingressList, err := getIngessList(context.Background(), nil, "", tc.excludeNamespaces, inputFile, "")
+			if tc.expectingError != (err != nil) {
+				t.Fatalf("getIngessList() error = %v, expecting error: %v", err, tc.expectingError)
+			}
+			if tc.expectingError {
+				return
+			}
+			var got []string
+			for _, ingress := range ingressList.Items {
+				got = append(got, ingress.Namespace+"/"+ingress.Name)
+			}
+			if !reflect.DeepEqual(got, tc.expectedIngresses) {
+				t.Errorf("getIngessList() = %v, expected %v", got, tc.expectedIngresses)
+			}
+		})
+	}
+}
+
+func Test_noResources(t *testing.T) {
+	testCases := []struct {
+		name            string
+		namespaceFilter string
+		inputFile       string
+		expectedMessage string
+	}{{
+		name:            "cluster",
+		expectedMessage: "no Ingresses found in the cluster",
+	}, {
+		name:            "namespace",
+		namespaceFilter: "staging",
+		expectedMessage: "namespace staging has no Ingresses",
+	}, {
+		name:            "input file",
+		inputFile:       "ingresses.yaml",
+		expectedMessage: "the input file ingresses.yaml contained no Ingresses",
+	}, {
+		name:            "namespace of the input file",
+		namespaceFilter: "staging",
+		inputFile:       "ingresses.yaml",
+		expectedMessage: "the input file ingresses.yaml contained no Ingresses of namespace staging",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := noResources("Ingresses", tc.namespaceFilter, tc.inputFile)
+			if err.Error() != tc.expectedMessage {
+				t.Errorf("noResources() = %q, expected %q", err, tc.expectedMessage)
+			}
+		})
+	}
+}
+
+func Test_emptySource(t *testing.T) {
+	noIngresses := fmt.Errorf("failed to get ingresses from source: %w", noResources("Ingresses", "staging", ""))
+	testCases := []struct {
+		name       string
+		allowEmpty bool
+		err        error
+		expected   bool
+	}{{
+		name:       "no resources allowed",
+		allowEmpty: true,
+		err:        noIngresses,
+		expected:   true,
+	}, {
+		name: "no resources not allowed",
+		err:  noIngresses,
+	}, {
+		name:       "other error",
+		allowEmpty: true,
+		err:        errors.New("failed to open input file"),
+	}, {
+		name:       "no error",
+		allowEmpty: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := PrintRunner{allowEmpty: tc.allowEmpty}
+			if got := pr.emptySource(tc.err); got != tc.expected {
+				t.Errorf("emptySource(%v) = %t, expected %t", tc.err, got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_outputEmpty(t *testing.T) {
+	testCases := []struct {
+		name           string
+		asList         bool
+		expectedStdout string
+	}{{
+		name: "nothing printed",
+	}, {
+		name:           "empty List",
+		asList:         true,
+		expectedStdout: "apiVersion: v1\nitems: []\nkind: List\nmetadata: {}\n",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := PrintRunner{resourcePrinter: &printers.YAMLPrinter{}, asList: tc.asList}
+			var stdout, stderr bytes.Buffer
+			if err := pr.outputEmpty(&stdout, &stderr); err != nil {
+				t.Fatalf("outputEmpty() error = %v", err)
+			}
+			if stdout.String() != tc.expectedStdout {
+				t.Errorf("Expected stdout %q, got %q", tc.expectedStdout, stdout.String())
+			}
+			if stderr.Len() > 0 {
+				t.Errorf("Expected no stderr, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func Test_createdSince(t *testing.T) {
+	since := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	newIngress := func(name string, created time.Time) networkingv1.Ingress {
+		return networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)}}
+	}
+	ingresses := []networkingv1.Ingress{
+		newIngress("old", since.Add(-time.Hour)),
+		newIngress("same-time", since),
+		newIngress("new", since.Add(time.Minute)),
+	}
+
+	var got []string
+	for _, ingress := range createdSince(ingresses, since) {
+		got = append(got, ingress.Name)
+	}
+	if expected := []string{"new"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("createdSince() = %v, expected %v", got, expected)
+	}
+}
+
+func Test_timeoutError(t *testing.T) {
+	pr := PrintRunner{timeout: time.Second}
+	listErr := fmt.Errorf("failed to list")
+
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+
+	testCases := []struct {
+		name            string
+		ctx             context.Context
+		expectedMessage string
+	}{{
+		name:            "deadline exceeded",
+		ctx:             expired,
+		expectedMessage: "timed out after 1s reading from the cluster, use --timeout to wait longer: failed to list",
+	}, {
+		name:            "other error",
+		ctx:             context.Background(),
+		expectedMessage: "failed to list",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pr.timeoutError(tc.ctx, listErr)
+			if err.Error() != tc.expectedMessage {
+				t.Errorf("timeoutError() = %q, expected %q", err, tc.expectedMessage)
+			}
+		})
+	}
+}
+
+func Test_routeNameTemplate(t *testing.T) {
+	testCases := []struct {
+		name               string
+		from               string
+		nameTemplate       string
+		keepIngressName    bool
+		keepIngressNameSet bool
+		expected           string
+		expectedError      bool
+	}{{
+		name:            "default for Ingresses",
+		from:            fromIngress,
+		keepIngressName: true,
+		expected:        i2gw.KeepIngressNameTemplate,
+	}, {
+		name:            "default for other inputs",
+		from:            "contour",
+		keepIngressName: true,
+	}, {
+		name:               "keep-ingress-name set for other inputs",
+		from:               "contour",
+		keepIngressName:    true,
+		keepIngressNameSet: true,
+		expectedError:      true,
+	}, {
+		name:               "keep-ingress-name set with a name template",
+		from:               fromIngress,
+		nameTemplate:       "{ingress}-{host}",
+		keepIngressName:    true,
+		keepIngressNameSet: true,
+		expectedError:      true,
+	}, {
+		name:               "keep-ingress-name disabled",
+		from:               fromIngress,
+		keepIngressNameSet: true,
+	}, {
+		name:            "name template overrides the default",
+		from:            fromIngress,
+		nameTemplate:    "{ingress}-{host}",
+		keepIngressName: true,
+		expected:        "{ingress}-{host}",
+	}, {
+		name:            "invalid name template",
+		from:            fromIngress,
+		nameTemplate:    "{service}",
+		keepIngressName: true,
+		expectedError:   true,
+	}, {
+		name:            "name template for other inputs",
+		from:            "contour",
+		nameTemplate:    "{ingress}-{host}",
+		keepIngressName: true,
+		expectedError:   true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := routeNameTemplate(tc.from, tc.nameTemplate, tc.keepIngressName, tc.keepIngressNameSet)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("routeNameTemplate() error = %v, expected error %t", err, tc.expectedError)
+			}
+			if got != tc.expected {
+				t.Errorf("routeNameTemplate() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_warningsError(t *testing.T) {
+	if err := warningsError(nil); err != nil {
+		t.Errorf("warningsError() = %v, expected no error without warnings", err)
+	}
+	warnings := []i2gw.Warning{{Message: "foo"}, {Message: "bar"}}
+	expectedMessage := "the conversion emitted 2 warnings, failing as --fail-on-warning is set"
+	if err := warningsError(warnings); err == nil || err.Error() != expectedMessage {
+		t.Errorf("warningsError() = %v, expected %q", err, expectedMessage)
+	}
+}
+
+func Test_skipManifestErrors(t *testing.T) {
+	var w bytes.Buffer
+	err := skipManifestErrors(i2gw.ManifestErrors{errors.New("failed to parse app.yaml: document starting at line 3")}, &w)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := w.String(), "# Warning: skipped, failed to parse app.yaml: document starting at line 3\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if err := skipManifestErrors(errors.New("no such file"), &w); err == nil {
+		t.Errorf("Expected the error of the file")
+	}
+}
+
+func Test_copyStdin(t *testing.T) {
+	manifest := `{"apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "metadata": {"name": "app", "namespace": "test"}}`
+	path, err := copyStdin(strings.NewReader(manifest), t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filepath.Ext(path) != "" {
+		t.Errorf("Expected a file without extension, got %s", path)
+	}
+
+	ingressList := &networkingv1.IngressList{}
+	if err := i2gw.ConstructIngressesFromFile(ingressList, path, "", i2gw.InputFormatAuto); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ingressList.Items) != 1 || ingressList.Items[0].Name != "app" {
+		t.Errorf("Expected Ingress app, got %+v", ingressList.Items)
+	}
+}
+
+func Test_writeIngressStatus(t *testing.T) {
+	ingresses := []networkingv1.Ingress{{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+				Ingress: []networkingv1.IngressLoadBalancerIngress{{
+					IP: "203.0.113.10",
+				}, {
+					Hostname: "lb.example.com",
+					Ports:    []networkingv1.IngressPortStatus{{Port: 443, Protocol: "TCP"}},
+				}},
+			},
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "test"},
+	}}
+
+	var w bytes.Buffer
+	writeIngressStatus(ingresses, &w)
+	expected := `# Status of Ingress test/app:
+#   load balancer: 203.0.113.10
+#   load balancer: lb.example.com (ports 443/TCP)
+# Status of Ingress test/pending:
+#   no load balancer address
+`
+	if w.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, w.String())
+	}
+}
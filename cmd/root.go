@@ -17,17 +17,37 @@ limitations under the License.
 package cmd
 
 import (
+	"flag"
 	"os"
 
 	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
 )
 
+// quietFlag is the name of the global flag omitting the per resource error
+// comments.
+const quietFlag = "quiet"
+
 var rootCmd = &cobra.Command{
 	Use:   "ingress2gateway",
 	Short: "Convert Ingress manifests to Gateway API manifests",
 }
 
+func init() {
+	rootCmd.PersistentFlags().Bool(quietFlag, false,
+		`If present, the errors of the resources that fail to be printed aren't reported. The command still fails when any resource fails to be printed`)
+
+	// The logs of klog are written to stderr, so that they never mix with
+	// the resources printed on stdout. Only its verbosity flags are exposed.
+	klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
+	klog.InitFlags(klogFlags)
+	for _, name := range []string{"v", "vmodule"} {
+		rootCmd.PersistentFlags().AddGoFlag(klogFlags.Lookup(name))
+	}
+}
+
 func Execute() {
+	defer klog.Flush()
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
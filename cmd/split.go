@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// splitOutputFileName returns the name of the file of obj in the split output
+// directory, <kind>-<namespace>-<name> with the extension of the output
+// format.
+func (pr *PrintRunner) splitOutputFileName(obj runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	ext := ".yaml"
+	if _, isYAML := pr.resourcePrinter.(*printers.YAMLPrinter); !isYAML {
+		ext = ".json"
+	}
+	kind := strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind)
+	return fmt.Sprintf("%s-%s-%s%s", kind, accessor.GetNamespace(), accessor.GetName(), ext), nil
+}
+
+// writeSplitOutput writes every object to its own file in dir, created when
+// missing. Existing files are overwritten. With YAML output, the warnings
+// bound to an HTTPRoute are written as comments in the file of the route, all
+// other warnings are written to stderr. Objects whose file names collide are
+// reported as an error before any file is written.
+func (pr *PrintRunner) writeSplitOutput(dir string, objs []runtime.Object, warnings []i2gw.Warning, stderr io.Writer) error {
+	_, isYAML := pr.resourcePrinter.(*printers.YAMLPrinter)
+	warningsByRoute := map[types.NamespacedName][]i2gw.Warning{}
+	for _, w := range warnings {
+		if !isYAML || w.HTTPRoute.Name == "" {
+			writeWarning(stderr, w)
+			continue
+		}
+		warningsByRoute[w.HTTPRoute] = append(warningsByRoute[w.HTTPRoute], w)
+	}
+
+	names := make([]string, 0, len(objs))
+	owners := map[string]string{}
+	for _, obj := range objs {
+		name, err := pr.splitOutputFileName(obj)
+		if err != nil {
+			return err
+		}
+		accessor, _ := meta.Accessor(obj)
+		resource := fmt.Sprintf("%s %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, accessor.GetNamespace(), accessor.GetName())
+		if owner, ok := owners[name]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s", owner, resource, name)
+		}
+		owners[name] = resource
+		names = append(names, name)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i, obj := range objs {
+		var routeWarnings []i2gw.Warning
+		if obj.GetObjectKind().GroupVersionKind().Kind == "HTTPRoute" {
+			accessor, _ := meta.Accessor(obj)
+			routeWarnings = warningsByRoute[types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}]
+		}
+		// Every file is printed by a new printer, as the YAML printer only
+		// starts the documents after the first one with a separator.
+		filePr := *pr
+		if err := filePr.initializeResourcePrinter(); err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := filePr.printObjWithComments(obj, routeWarnings, &buf); err != nil {
+			return fmt.Errorf("failed to print %s: %w", owners[names[i]], err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, names[i]), buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
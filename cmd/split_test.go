@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_writeSplitOutput(t *testing.T) {
+	newRoute := func(namespace, name string) gatewayv1beta1.HTTPRoute {
+		route := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+		return route
+	}
+	gateway := gatewayv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"}}
+	gateway.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("Gateway"))
+	warnings := []i2gw.Warning{{
+		Ingress:   types.NamespacedName{Namespace: "test", Name: "example"},
+		HTTPRoute: types.NamespacedName{Namespace: "test", Name: "example-com"},
+		Field:     field.NewPath("example", "metadata", "annotations").Key("nginx.ingress.kubernetes.io/server-snippet"),
+		Message:   "snippet",
+	}, {
+		Ingress: types.NamespacedName{Namespace: "test", Name: "example"},
+		Message: "unbound",
+	}}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gateway-test-nginx.yaml"), []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := PrintRunner{outputFormat: "yaml", stripManagedFields: true}
+	if err := pr.initializeResourcePrinter(); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	err := pr.writeSplitOutput(dir, toObjects([]gatewayv1beta1.HTTPRoute{newRoute("test", "example-com")}, nil, nil, nil, []gatewayv1beta1.Gateway{gateway}, nil, nil, nil), warnings, &stderr)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	if diff := cmp.Diff([]string{"gateway-test-nginx.yaml", "httproute-test-example-com.yaml"}, names); diff != "" {
+		t.Errorf("Unexpected files (-want +got):\n%s", diff)
+	}
+
+	gatewayFile, err := os.ReadFile(filepath.Join(dir, "gateway-test-nginx.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(gatewayFile), "apiVersion: gateway.networking.k8s.io/v1beta1\nkind: Gateway\n") {
+		t.Errorf("Expected the Gateway to overwrite the existing file, got:\n%s", gatewayFile)
+	}
+	routeFile, err := os.ReadFile(filepath.Join(dir, "httproute-test-example-com.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(routeFile), "# Warning: test/example: example.metadata.annotations[nginx.ingress.kubernetes.io/server-snippet]: snippet\napiVersion:") {
+		t.Errorf("Expected the HTTPRoute preceded by its warning, got:\n%s", routeFile)
+	}
+	if !strings.Contains(stderr.String(), "unbound") || strings.Contains(stderr.String(), "snippet") {
+		t.Errorf("Expected only the unbound warning on stderr, got %q", stderr.String())
+	}
+}
+
+func Test_writeSplitOutputCollision(t *testing.T) {
+	newRoute := func(namespace, name string) gatewayv1beta1.HTTPRoute {
+		route := gatewayv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		route.SetGroupVersionKind(gatewayv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+		return route
+	}
+	dir := filepath.Join(t.TempDir(), "out")
+	pr := PrintRunner{outputFormat: "yaml"}
+	if err := pr.initializeResourcePrinter(); err != nil {
+		t.Fatal(err)
+	}
+
+	routes := []gatewayv1beta1.HTTPRoute{newRoute("a-b", "c"), newRoute("a", "b-c")}
+	err := pr.writeSplitOutput(dir, toObjects(routes, nil, nil, nil, nil, nil, nil, nil), nil, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "httproute-a-b-c.yaml") {
+		t.Fatalf("Expected a file name collision error, got %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be written, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/kubernetes-sigs/ingress2gateway/pkg/i2gw"
+	"github.com/spf13/cobra"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// gatewayAPIModule is the module of the Gateway API types the conversion
+// emits.
+const gatewayAPIModule = "sigs.k8s.io/gateway-api"
+
+// version and gitCommit are set for release builds with:
+//
+//	-ldflags "-X github.com/kubernetes-sigs/ingress2gateway/cmd.version=$VERSION -X github.com/kubernetes-sigs/ingress2gateway/cmd.gitCommit=$COMMIT"
+var (
+	version   = "dev"
+	gitCommit = "unknown"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of ingress2gateway and of the Gateway API it emits",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		writeVersion(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	// The --version flag of the root command prints the same information as
+	// the version subcommand.
+	rootCmd.Version = version
+	var b strings.Builder
+	writeVersion(&b)
+	rootCmd.SetVersionTemplate(b.String())
+}
+
+// writeVersion writes the build version, git commit and Go version of the
+// binary, along with the version of the Gateway API module and the API
+// versions of the generated resources.
+func writeVersion(w io.Writer) {
+	fmt.Fprintf(w, "ingress2gateway version: %s\n", version)
+	fmt.Fprintf(w, "git commit: %s\n", gitCommit)
+	fmt.Fprintf(w, "go version: %s\n", runtime.Version())
+	fmt.Fprintf(w, "gateway api version: %s\n", gatewayAPIVersion())
+	fmt.Fprintf(w, "gateway api group versions: %s/%s (default), %s/%s\n",
+		gatewayv1.GroupName, i2gw.APIVersionV1Beta1, gatewayv1.GroupName, i2gw.APIVersionV1)
+}
+
+// gatewayAPIVersion returns the version of the Gateway API module the binary
+// is built with, unknown when the build information isn't available.
+func gatewayAPIVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != gatewayAPIModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
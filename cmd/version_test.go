@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_writeVersion(t *testing.T) {
+	var b bytes.Buffer
+	writeVersion(&b)
+
+	for _, expected := range []string{
+		"ingress2gateway version: " + version + "\n",
+		"git commit: " + gitCommit + "\n",
+		"go version: " + runtime.Version() + "\n",
+		"gateway api version: ",
+		"gateway api group versions: gateway.networking.k8s.io/v1beta1 (default), gateway.networking.k8s.io/v1\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("Expected the version to contain %q, got:\n%s", expected, b.String())
+		}
+	}
+}
+
+func Test_versionFlag(t *testing.T) {
+	var b bytes.Buffer
+	rootCmd.SetOut(&b)
+	rootCmd.SetArgs([]string{"--version"})
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var expected bytes.Buffer
+	writeVersion(&expected)
+	if b.String() != expected.String() {
+		t.Errorf("Expected the --version flag to print:\n%s\ngot:\n%s", expected.String(), b.String())
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const (
+	affinityAnnotation               = "nginx.ingress.kubernetes.io/affinity"
+	affinityModeAnnotation           = "nginx.ingress.kubernetes.io/affinity-mode"
+	affinityCanaryBehaviorAnnotation = "nginx.ingress.kubernetes.io/affinity-canary-behavior"
+	sessionCookieAnnotationPrefix    = "nginx.ingress.kubernetes.io/session-cookie-"
+	sessionCookieNameAnnotation      = sessionCookieAnnotationPrefix + "name"
+	sessionCookieMaxAgeAnnotation    = sessionCookieAnnotationPrefix + "max-age"
+	sessionCookieExpiresAnnotation   = sessionCookieAnnotationPrefix + "expires"
+	defaultSessionCookieName         = "INGRESSCOOKIE"
+	// maxGatewayDurationHours is the largest number of hours of a Gateway
+	// API duration, which has at most 5 digits per unit.
+	maxGatewayDurationHours = 99999
+)
+
+// sessionAffinity is the cookie affinity of the paths of an Ingress.
+type sessionAffinity struct {
+	// persistence is the session persistence of the routes, which only
+	// Gateway API v1 routes have.
+	persistence *gatewayv1.SessionPersistence
+	// settings are all the affinity annotations, as name=value pairs without
+	// the annotation prefix, and unconverted the ones persistence doesn't
+	// represent.
+	settings    []string
+	unconverted []string
+}
+
+// getSessionAffinity reads the cookie affinity annotations of ingress-nginx.
+// The name and lifetime of the cookie are converted to session persistence,
+// the other settings are reported by warnings.
+func getSessionAffinity(ingress networkingv1.Ingress, fieldPath *field.Path) (*sessionAffinity, field.ErrorList) {
+	affinity, ok := ingress.Annotations[affinityAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	if affinity != "cookie" {
+		return nil, field.ErrorList{field.NotSupported(fieldPath.Key(affinityAnnotation), affinity, []string{"cookie"})}
+	}
+
+	var errs field.ErrorList
+	cookie := gatewayv1.CookieBasedSessionPersistence
+	sa := &sessionAffinity{
+		persistence: &gatewayv1.SessionPersistence{
+			SessionName: pointer.String(defaultSessionCookieName),
+			Type:        &cookie,
+		},
+	}
+	for key, value := range ingress.Annotations {
+		if key != affinityAnnotation && key != affinityModeAnnotation && key != affinityCanaryBehaviorAnnotation && !strings.HasPrefix(key, sessionCookieAnnotationPrefix) {
+			continue
+		}
+		setting := fmt.Sprintf("%s=%s", strings.TrimPrefix(key, "nginx.ingress.kubernetes.io/"), value)
+		sa.settings = append(sa.settings, setting)
+		switch key {
+		case affinityAnnotation:
+		case sessionCookieNameAnnotation:
+			sa.persistence.SessionName = pointer.String(value)
+		case sessionCookieMaxAgeAnnotation, sessionCookieExpiresAnnotation:
+			// max-age takes precedence over expires, as with ingress-nginx.
+			if key == sessionCookieExpiresAnnotation && ingress.Annotations[sessionCookieMaxAgeAnnotation] != "" {
+				continue
+			}
+			timeout, err := toGatewayDuration(value)
+			if err != nil {
+				errs = append(errs, field.Invalid(fieldPath.Key(key), value, err.Error()))
+				continue
+			}
+			lifetime := gatewayv1.PermanentCookieLifetimeType
+			sa.persistence.AbsoluteTimeout = &timeout
+			sa.persistence.CookieConfig = &gatewayv1.CookieConfig{LifetimeType: &lifetime}
+		default:
+			sa.unconverted = append(sa.unconverted, setting)
+		}
+	}
+	sort.Strings(sa.settings)
+	sort.Strings(sa.unconverted)
+	return sa, errs
+}
+
+// warnings returns the warnings of the session affinity of the Ingress. Without session persistence, all its settings are reported,
+// otherwise only the settings persistence doesn't represent.
+func (sa *sessionAffinity) warnings(ingress types.NamespacedName, persistence bool) []Warning {
+	fieldPath := field.NewPath(ingress.Name, "metadata", "annotations").Key(affinityAnnotation)
+	if !persistence {
+		return []Warning{{
+			Ingress: ingress,
+			Field:   fieldPath,
+			Message: fmt.Sprintf("session affinity cannot be represented in HTTPRoute %s, convert to Gateway API v1 to map it to sessionPersistence or configure sticky sessions on the Gateway implementation: %s",
+				httpRouteGVK.Version, strings.Join(sa.settings, ", ")),
+		}}
+	}
+	if len(sa.unconverted) == 0 {
+		return nil
+	}
+	return []Warning{{
+		Ingress: ingress,
+		Field:   fieldPath,
+		Message: fmt.Sprintf("session affinity is converted to sessionPersistence, but these settings cannot be represented and must be configured on the Gateway implementation: %s",
+			strings.Join(sa.unconverted, ", ")),
+	}}
+}
+
+// toGatewayDuration converts a number of seconds to a Gateway API duration.
+func toGatewayDuration(value string) (gatewayv1.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return "", fmt.Errorf("must be a positive number of seconds")
+	}
+	hours, minutes := seconds/3600, seconds%3600/60
+	seconds %= 60
+	if hours > maxGatewayDurationHours {
+		return "", fmt.Errorf("must be at most %d hours", maxGatewayDurationHours)
+	}
+	var d string
+	if hours > 0 {
+		d += fmt.Sprintf("%dh", hours)
+	}
+	if minutes > 0 {
+		d += fmt.Sprintf("%dm", minutes)
+	}
+	if seconds > 0 {
+		d += fmt.Sprintf("%ds", seconds)
+	}
+	return gatewayv1.Duration(d), nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_getSessionAffinity(t *testing.T) {
+	cookie := gatewayv1.CookieBasedSessionPersistence
+	permanent := gatewayv1.PermanentCookieLifetimeType
+	timeout := gatewayv1.Duration("48h")
+
+	testCases := []struct {
+		name             string
+		annotations      map[string]string
+		expectedAffinity *sessionAffinity
+		expectNumErrors  int
+	}{{
+		name:        "no affinity",
+		annotations: map[string]string{"nginx.ingress.kubernetes.io/session-cookie-name": "route"},
+	}, {
+		name:        "default cookie",
+		annotations: map[string]string{"nginx.ingress.kubernetes.io/affinity": "cookie"},
+		expectedAffinity: &sessionAffinity{
+			persistence: &gatewayv1.SessionPersistence{SessionName: pointer.String("INGRESSCOOKIE"), Type: &cookie},
+			settings:    []string{"affinity=cookie"},
+		},
+	}, {
+		name: "cookie settings",
+		annotations: map[string]string{
+			"nginx.ingress.kubernetes.io/affinity":                "cookie",
+			"nginx.ingress.kubernetes.io/affinity-mode":           "persistent",
+			"nginx.ingress.kubernetes.io/session-cookie-name":     "route",
+			"nginx.ingress.kubernetes.io/session-cookie-max-age":  "172800",
+			"nginx.ingress.kubernetes.io/session-cookie-expires":  "3600",
+			"nginx.ingress.kubernetes.io/session-cookie-samesite": "Strict",
+		},
+		expectedAffinity: &sessionAffinity{
+			persistence: &gatewayv1.SessionPersistence{
+				SessionName:     pointer.String("route"),
+				AbsoluteTimeout: &timeout,
+				Type:            &cookie,
+				CookieConfig:    &gatewayv1.CookieConfig{LifetimeType: &permanent},
+			},
+			settings: []string{
+				"affinity-mode=persistent",
+				"affinity=cookie",
+				"session-cookie-expires=3600",
+				"session-cookie-max-age=172800",
+				"session-cookie-name=route",
+				"session-cookie-samesite=Strict",
+			},
+			unconverted: []string{"affinity-mode=persistent", "session-cookie-samesite=Strict"},
+		},
+	}, {
+		name:            "unsupported affinity",
+		annotations:     map[string]string{"nginx.ingress.kubernetes.io/affinity": "ip"},
+		expectNumErrors: 1,
+	}, {
+		name: "invalid max age",
+		annotations: map[string]string{
+			"nginx.ingress.kubernetes.io/affinity":               "cookie",
+			"nginx.ingress.kubernetes.io/session-cookie-max-age": "1d",
+		},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "example", Annotations: tc.annotations}}
+			affinity, errs := getSessionAffinity(ingress, field.NewPath("example", "metadata", "annotations"))
+			if len(errs) != tc.expectNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+			if tc.expectNumErrors > 0 {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedAffinity, affinity, cmp.AllowUnexported(sessionAffinity{})); diff != "" {
+				t.Errorf("Unexpected session affinity (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_sessionAffinityWarnings(t *testing.T) {
+	ingress := types.NamespacedName{Namespace: "test", Name: "example"}
+	affinity := &sessionAffinity{
+		settings:    []string{"affinity-mode=persistent", "affinity=cookie"},
+		unconverted: []string{"affinity-mode=persistent"},
+	}
+
+	if warnings := affinity.warnings(ingress, false); len(warnings) != 1 {
+		t.Errorf("Expected 1 warning without session persistence, got %+v", warnings)
+	}
+	if warnings := affinity.warnings(ingress, true); len(warnings) != 1 {
+		t.Errorf("Expected 1 warning for the unconverted settings, got %+v", warnings)
+	}
+	if warnings := (&sessionAffinity{settings: []string{"affinity=cookie"}}).warnings(ingress, true); len(warnings) != 0 {
+		t.Errorf("Expected no warning, got %+v", warnings)
+	}
+}
+
+func Test_toGatewayDuration(t *testing.T) {
+	testCases := []struct {
+		value          string
+		expected       gatewayv1.Duration
+		expectingError bool
+	}{
+		{value: "30", expected: "30s"},
+		{value: "3600", expected: "1h"},
+		{value: "3725", expected: "1h2m5s"},
+		{value: "172800", expected: "48h"},
+		{value: "0", expectingError: true},
+		{value: "1h", expectingError: true},
+		{value: "360000000", expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			d, err := toGatewayDuration(tc.value)
+			if tc.expectingError {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if d != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, d)
+			}
+		})
+	}
+}
@@ -18,16 +18,23 @@ package i2gw
 
 import (
 	"fmt"
+	"net"
 	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
@@ -50,6 +57,60 @@ type ruleGroupKey string
 type ingressAggregator struct {
 	ruleGroups      map[ruleGroupKey]*ingressRuleGroup
 	defaultBackends []ingressDefaultBackend
+	tcpServices     map[string]tcpServices
+	configMaps      map[types.NamespacedName]corev1.ConfigMap
+	// services are used to resolve named Service ports of backends.
+	services map[types.NamespacedName]corev1.Service
+	// preservedAnnotations is the allowlist of Ingress annotations copied onto
+	// the generated Gateways and HTTPRoutes.
+	preservedAnnotations []string
+	// gatewayAnnotations holds the annotations preserved from the Ingresses of
+	// every Gateway, and those converted onto the Gateway by the providers, by
+	// <namespace>/<name>.
+	gatewayAnnotations map[string]map[string]string
+	// annotateSourceIngresses indicates whether the generated resources are
+	// annotated with their Ingresses, and gatewayIngresses holds the
+	// Ingresses of every Gateway, by <namespace>/<name>.
+	annotateSourceIngresses bool
+	gatewayIngresses        map[string][]types.NamespacedName
+	// annotationPrefix restricts the converted provider annotations to the
+	// ones of its provider, see Options.AnnotationPrefix.
+	annotationPrefix string
+	// mergeCertificateRefs indicates whether the HTTPS hosts of every Gateway
+	// share a listener with the certificateRefs of all their Secrets, see
+	// Options.MergeCertificateRefs.
+	mergeCertificateRefs bool
+	// recordIngressClasses indicates whether the Ingress classes of every
+	// Gateway, held by gatewayIngressClasses by <namespace>/<name>, are
+	// recorded in its infrastructure, see Options.RecordIngressClasses.
+	recordIngressClasses  bool
+	gatewayIngressClasses map[string]sets.Set[string]
+	// warnings holds warnings that aren't bound to any generated HTTPRoute.
+	warnings []Warning
+	// allowConflicts is passed to the rule groups.
+	allowConflicts bool
+	// providers are the names of the providers whose annotations are
+	// converted, detected on every Ingress when empty.
+	providers []string
+	// apiV1 indicates whether the routes are converted to Gateway API v1, so
+	// that session affinity and timeouts are converted to the session
+	// persistence and timeouts of their rules.
+	apiV1 bool
+	// backendTLSPolicies holds the TLS configuration of the Services used as
+	// HTTPS backends, by Service.
+	backendTLSPolicies map[types.NamespacedName]*backendTLSPolicy
+	// namespaceGateways holds the name of the single Gateway of every
+	// namespace, nil when Ingresses are converted to a Gateway per class.
+	namespaceGateways map[string]string
+	// nameTemplate is the template of the names of the routes, which are
+	// named after their host when empty.
+	nameTemplate string
+	// targetImplementation is the Gateway API implementation whose policies
+	// are generated, none when empty or unknown.
+	targetImplementation string
+	// backendKind is the kind of the backendRefs of the Service backends of
+	// the Ingresses without backend-ref annotations.
+	backendKind backendKind
 }
 
 type pathMatchKey string
@@ -59,12 +120,33 @@ type ingressRuleGroup struct {
 	ingressClass string
 	host         string
 	tls          []networkingv1.IngressTLS
+	// tlsIngresses are the Ingresses of the TLS entries.
+	tlsIngresses []types.NamespacedName
 	rules        []ingressRule
+	services     map[types.NamespacedName]corev1.Service
+	annotations  map[string]string
+	// name is the name of the routes, set from the name template.
+	name string
+	// allowConflicts indicates whether paths conflicting with a path of a
+	// previous rule are reported as warnings instead of errors.
+	allowConflicts bool
+	// passthrough indicates whether the TLS connections of the host are passed
+	// through to its backend, in which case it is converted to a TLSRoute.
+	passthrough bool
+	// ruleIngresses are the Ingresses of the rules of the HTTPRoute, by path,
+	// see rulePathKey, set when the HTTPRoute is converted. The rules of a
+	// path shared by several Ingresses, such as canaries, are of the first
+	// Ingress not being a canary.
+	ruleIngresses map[string]types.NamespacedName
+	// enforced are the annotations enforced by the policies generated for
+	// the routes of the rule group, see toPolicies.
+	enforced map[string]bool
 }
 
 type ingressRule struct {
-	rule  networkingv1.IngressRule
-	extra *extra
+	ingress types.NamespacedName
+	rule    networkingv1.IngressRule
+	extra   *extra
 }
 
 type ingressDefaultBackend struct {
@@ -72,18 +154,129 @@ type ingressDefaultBackend struct {
 	namespace    string
 	ingressClass string
 	backend      networkingv1.IngressBackend
+	extra        *extra
 }
 
 type ingressPath struct {
+	ingress  types.NamespacedName
 	ruleIdx  int
 	pathIdx  int
 	ruleType string
 	path     networkingv1.HTTPIngressPath
-	extra    *extra
+	// regex indicates whether the path is a regular expression, converted to
+	// a RegularExpression path match.
+	regex bool
+	// appProtocol is the appProtocol of the backend Service port, which
+	// chooses the route type of the path over the backend-protocol
+	// annotation when set.
+	appProtocol string
+	extra       *extra
 }
 
 type extra struct {
-	canary *canary
+	canary          *canary
+	rewriteTarget   string
+	backendProtocol string
+	backendTLS      *backendTLS
+	// requestHeaders and responseHeaders are the header mutations converted
+	// from the header annotations, nil when there is none.
+	requestHeaders  *gatewayv1.HTTPHeaderFilter
+	responseHeaders *gatewayv1.HTTPHeaderFilter
+	// pathRewrite is the haproxy.org/path-rewrite annotation.
+	pathRewrite string
+	sslRedirect *sslRedirect
+	// stripPath and protocols are the konghq.com/strip-path and
+	// konghq.com/protocols annotations.
+	stripPath bool
+	protocols []gatewayv1.ProtocolType
+	// listenPorts and albActions are the alb.ingress.kubernetes.io/listen-ports
+	// and actions annotations, by action name.
+	listenPorts []albListenPort
+	albActions  map[string]*albAction
+	// affinity is the cookie affinity of the paths, whose session persistence
+	// is only set when converting to Gateway API v1.
+	affinity *sessionAffinity
+	// timeouts are the timeouts of the rules of the paths, only set when
+	// converting to Gateway API v1.
+	timeouts *gatewayv1.HTTPRouteTimeouts
+	// useRegex is the nginx.ingress.kubernetes.io/use-regex annotation.
+	useRegex bool
+	// sourceRanges are the CIDRs of the source IP allow-list of the paths.
+	sourceRanges []string
+	// bodySize is the maximum size of the request bodies of the paths.
+	bodySize string
+	// rateLimits are the request rate limits of the paths.
+	rateLimits *rateLimits
+	// basicAuth is the HTTP basic authentication of the paths.
+	basicAuth *basicAuth
+	// sslPassthrough is the nginx.ingress.kubernetes.io/ssl-passthrough
+	// annotation.
+	sslPassthrough bool
+	// redirect is the redirect of all the paths, converted from the
+	// permanent-redirect and temporal-redirect annotations.
+	redirect *gatewayv1beta1.HTTPRequestRedirectFilter
+	// appRoot is the path the requests of the root path of the host are
+	// redirected to, converted from the app-root annotation.
+	appRoot string
+	// methods are the HTTP methods the paths are restricted to, converted
+	// to a match of every method.
+	methods []gatewayv1.HTTPMethod
+	// annotations holds the Ingress annotations copied onto the generated
+	// resources.
+	annotations map[string]string
+	// gatewayAnnotations holds the annotations converted onto the Gateway of
+	// the Ingress only, such as its static IP.
+	gatewayAnnotations map[string]string
+	// httpDisabled is the kubernetes.io/ingress.allow-http annotation set to
+	// false: the hosts of the Ingress are only served over HTTPS.
+	httpDisabled bool
+	// managedCertificates are the ManagedCertificates of the
+	// networking.gke.io/managed-certificates annotation, referenced by the
+	// HTTPS listeners of the hosts of the Ingress.
+	managedCertificates []string
+	// backendKind is the kind of the backendRefs of the Service backends.
+	backendKind backendKind
+	warnings    []Warning
+	// policyWarnings are the warnings of the annotations converted to the
+	// policies of some target implementations, only reported by toPolicies
+	// when no generated policy enforces them.
+	policyWarnings []policyWarning
+}
+
+// canaryConfig returns the canary configuration, or nil when canary is not
+// configured.
+func (e *extra) canaryConfig() *canary {
+	if e == nil {
+		return nil
+	}
+	return e.canary
+}
+
+// requestRedirect returns the redirect of all the paths, nil when they are
+// routed to their backend.
+func (e *extra) requestRedirect() *gatewayv1beta1.HTTPRequestRedirectFilter {
+	if e == nil {
+		return nil
+	}
+	return e.redirect
+}
+
+// serviceBackendKind returns the kind of the backendRefs of the Service
+// backends, a core Service by default.
+func (e *extra) serviceBackendKind() backendKind {
+	if e == nil {
+		return backendKind{}
+	}
+	return e.backendKind
+}
+
+// sessionPersistence returns the session persistence of the routes, or nil
+// when there is none.
+func (e *extra) sessionPersistence() *gatewayv1.SessionPersistence {
+	if e == nil || e.affinity == nil {
+		return nil
+	}
+	return e.affinity.persistence
 }
 
 type canary struct {
@@ -91,25 +284,123 @@ type canary struct {
 	headerKey        string
 	headerValue      string
 	headerRegexMatch bool
-	weight           int
-	weightTotal      int
+	// headerFlag is whether the header is a flag, routing the requests to the
+	// canary when set to always, and never to the canary when set to never,
+	// as with canary-by-header without a value or pattern.
+	headerFlag bool
+	weight     int
+	// weighted is whether the weight is set, possibly to 0.
+	weighted    bool
+	weightTotal int
 }
 
-func (a *ingressAggregator) addIngress(ingress networkingv1.Ingress) field.ErrorList {
-	var ingressClass string
+// normalizeIngressClass sets the class of Ingresses identifying their
+// controller through the legacy kubernetes.io/ingress.class annotation as
+// spec.ingressClassName, so that only the field has to be checked. The field
+// takes precedence when both are set.
+func normalizeIngressClass(ingress *networkingv1.Ingress) {
 	if ingress.Spec.IngressClassName != nil && *ingress.Spec.IngressClassName != "" {
-		ingressClass = *ingress.Spec.IngressClassName
-	} else if _, ok := ingress.Annotations[networkingv1beta1.AnnotationIngressClass]; ok {
-		ingressClass = ingress.Annotations[networkingv1beta1.AnnotationIngressClass]
-	} else {
-		ingressClass = ingress.Name
+		return
+	}
+	if class := ingress.Annotations[networkingv1beta1.AnnotationIngressClass]; class != "" {
+		ingress.Spec.IngressClassName = &class
+	}
+}
+
+// getIngressClass returns the class of the normalized Ingress, which names the
+// Gateway it is converted to. Ingresses without class are converted to a
+// Gateway named after the Ingress.
+func getIngressClass(ingress networkingv1.Ingress) string {
+	if ingress.Spec.IngressClassName != nil && *ingress.Spec.IngressClassName != "" {
+		return *ingress.Spec.IngressClassName
+	}
+	return ingress.Name
+}
+
+// gatewayName returns the name of the Gateway the normalized Ingress is
+// converted to: the single Gateway of its namespace when there is one,
+// otherwise the Gateway of its class.
+func (a *ingressAggregator) gatewayName(ingress networkingv1.Ingress) string {
+	if name, ok := a.namespaceGateways[ingress.Namespace]; ok {
+		return name
+	}
+	return getIngressClass(ingress)
+}
+
+func (a *ingressAggregator) addIngress(ingress networkingv1.Ingress) field.ErrorList {
+	normalizeIngressClass(&ingress)
+	if a.annotationPrefix != "" {
+		scopeAnnotations(&ingress, a.annotationPrefix)
 	}
-	e, errs := getExtra(ingress)
+	a.warnings = append(a.warnings, normalizeHosts(&ingress)...)
+	ingressClass := a.gatewayName(ingress)
+	e, errs := getExtra(ingress, a.configMaps, a.providers)
 	if len(errs) > 0 {
 		return errs
 	}
-	for _, rule := range ingress.Spec.Rules {
-		a.addIngressRule(ingress.Namespace, ingressClass, rule, ingress.Spec, e)
+	if e.affinity != nil {
+		e.warnings = append(e.warnings, e.affinity.warnings(types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, a.apiV1)...)
+		if !a.apiV1 {
+			e.affinity = nil
+		}
+	}
+	if e.timeouts != nil && !a.apiV1 {
+		e.warnings = append(e.warnings, timeoutsWarning(types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, e.timeouts))
+		e.timeouts = nil
+	}
+	e.backendKind, errs = getBackendKind(ingress, a.backendKind)
+	if len(errs) > 0 {
+		return errs
+	}
+	e.annotations = preservedAnnotations(ingress, a.preservedAnnotations)
+	if a.annotateSourceIngresses {
+		if a.gatewayIngresses == nil {
+			a.gatewayIngresses = map[string][]types.NamespacedName{}
+		}
+		gwKey := fmt.Sprintf("%s/%s", ingress.Namespace, ingressClass)
+		a.gatewayIngresses[gwKey] = append(a.gatewayIngresses[gwKey], types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name})
+	}
+	a.addGatewayIngressClass(fmt.Sprintf("%s/%s", ingress.Namespace, ingressClass), pointer.StringDeref(ingress.Spec.IngressClassName, ""))
+	for _, annotations := range []map[string]string{e.annotations, e.gatewayAnnotations} {
+		if len(annotations) == 0 {
+			continue
+		}
+		gwKey := fmt.Sprintf("%s/%s", ingress.Namespace, ingressClass)
+		if a.gatewayAnnotations == nil {
+			a.gatewayAnnotations = map[string]map[string]string{}
+		}
+		if a.gatewayAnnotations[gwKey] == nil {
+			a.gatewayAnnotations[gwKey] = map[string]string{}
+		}
+		a.warnings = append(a.warnings, mergeAnnotations(a.gatewayAnnotations[gwKey], annotations, types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, "Gateway "+gwKey)...)
+	}
+	for i, rule := range ingress.Spec.Rules {
+		hostPath := field.NewPath(ingress.Name).Child("spec", "rules").Index(i).Child("host")
+		if err := validateHost(rule.Host, hostPath); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Gateway API hostnames can't be a bare wildcard, rules matching all
+		// hosts are converted as rules without host.
+		if rule.Host == "*" {
+			rule.Host = ""
+			a.warnings = append(a.warnings, Warning{
+				Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+				Field:   hostPath,
+				Message: "the \"*\" host is not a valid HTTPRoute hostname, the rule is converted without hostname to match all hosts",
+			})
+		}
+		a.warnings = append(a.warnings, defaultPathTypes(types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, &rule, field.NewPath(ingress.Name).Child("spec", "rules").Index(i), e)...)
+		a.addIngressRule(types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, ingressClass, rule, ingress.Spec, e)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if providerEnabled(ProviderIngressNginx, ingress, a.providers) {
+		a.addTCPServices(ingress, ingressClass)
+	}
+	if e.backendTLS != nil {
+		a.addBackendTLS(ingress, e.backendTLS)
 	}
 	if ingress.Spec.DefaultBackend != nil {
 		a.defaultBackends = append(a.defaultBackends, ingressDefaultBackend{
@@ -117,58 +408,236 @@ func (a *ingressAggregator) addIngress(ingress networkingv1.Ingress) field.Error
 			namespace:    ingress.Namespace,
 			ingressClass: ingressClass,
 			backend:      *ingress.Spec.DefaultBackend,
+			extra:        e,
 		})
 	}
+	if len(ingress.Spec.Rules) == 0 && ingress.Spec.DefaultBackend == nil {
+		a.warnings = append(a.warnings, e.warnings...)
+		for _, pw := range e.policyWarnings {
+			a.warnings = append(a.warnings, a.unenforcedWarning(pw))
+		}
+	}
 	return nil
 }
 
-func (a *ingressAggregator) addIngressRule(namespace, ingressClass string, rule networkingv1.IngressRule, iSpec networkingv1.IngressSpec, e *extra) {
-	rgKey := ruleGroupKey(fmt.Sprintf("%s/%s/%s", namespace, ingressClass, rule.Host))
+func (a *ingressAggregator) addIngressRule(ingress types.NamespacedName, ingressClass string, rule networkingv1.IngressRule, iSpec networkingv1.IngressSpec, e *extra) {
+	rgKey := ruleGroupKey(fmt.Sprintf("%s/%s/%s", ingress.Namespace, ingressClass, rule.Host))
 	rg, ok := a.ruleGroups[rgKey]
 	if !ok {
 		rg = &ingressRuleGroup{
-			namespace:    namespace,
+			namespace:    ingress.Namespace,
 			ingressClass: ingressClass,
 			host:         rule.Host,
+			services:     a.services,
+
+			allowConflicts: a.allowConflicts,
 		}
 		a.ruleGroups[rgKey] = rg
 	}
-	if len(iSpec.TLS) > 0 {
-		rg.tls = append(rg.tls, iSpec.TLS...)
+	for _, tls := range iSpec.TLS {
+		// Rules are only served over HTTPS with the certificates of the TLS
+		// entries of their host, or of the entries without hosts. Rules without
+		// host match all hosts, so they only get the latter.
+		if !tlsCoversHost(tls, rule.Host) {
+			continue
+		}
+		rg.tls = append(rg.tls, tls)
+		rg.tlsIngresses = append(rg.tlsIngresses, ingress)
+	}
+	if len(e.annotations) > 0 {
+		if rg.annotations == nil {
+			rg.annotations = map[string]string{}
+		}
+		a.warnings = append(a.warnings, mergeAnnotations(rg.annotations, e.annotations, ingress, fmt.Sprintf("the HTTPRoute of host %q", rule.Host))...)
 	}
-	rg.rules = append(rg.rules, ingressRule{rule: rule, extra: e})
+	rg.rules = append(rg.rules, ingressRule{ingress: ingress, rule: rule, extra: e})
 }
 
-func (a *ingressAggregator) toHTTPRoutesAndGateways() ([]gatewayv1beta1.HTTPRoute, []gatewayv1beta1.Gateway, field.ErrorList) {
+func (a *ingressAggregator) toHTTPRoutesAndGateways() ([]gatewayv1beta1.HTTPRoute, []gatewayv1.GRPCRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
 	var httpRoutes []gatewayv1beta1.HTTPRoute
+	var grpcRoutes []gatewayv1.GRPCRoute
 	var errors field.ErrorList
+	warnings := append([]Warning{}, a.warnings...)
 	listenersByNamespacedGateway := map[string][]gatewayv1beta1.Listener{}
+	// seenListeners holds the HTTPS listeners shared by several rule groups, and
+	// the listeners of their listen ports, that were already added to their
+	// Gateway, by Gateway and listener name.
+	seenListeners := map[string]bool{}
+	addListener := func(gwKey string, listener gatewayv1beta1.Listener) {
+		if key := gwKey + "/" + string(listener.Name); !seenListeners[key] {
+			seenListeners[key] = true
+			listenersByNamespacedGateway[gwKey] = append(listenersByNamespacedGateway[gwKey], listener)
+		}
+	}
 
-	for _, rg := range a.ruleGroups {
-		listener := gatewayv1beta1.Listener{}
+	// Rule groups are converted in a stable order, so that the generated
+	// routes and listeners don't change between runs.
+	rgKeys := make([]string, 0, len(a.ruleGroups))
+	for rgKey := range a.ruleGroups {
+		rgKeys = append(rgKeys, string(rgKey))
+	}
+	sort.Strings(rgKeys)
+	warnings = append(warnings, a.setPassthrough(rgKeys)...)
+	warnings = append(warnings, a.setRouteNames(rgKeys)...)
+	// Rule groups passing TLS through are converted to TLSRoutes.
+	httpKeys := make([]string, 0, len(rgKeys))
+	for _, rgKey := range rgKeys {
+		if !a.ruleGroups[ruleGroupKey(rgKey)].passthrough {
+			httpKeys = append(httpKeys, rgKey)
+		}
+	}
+	httpsListeners, tlsErrs := a.toHTTPSListeners(httpKeys)
+	errors = append(errors, tlsErrs...)
+
+	for _, rgKey := range httpKeys {
+		rg := a.ruleGroups[ruleGroupKey(rgKey)]
+		listener := gatewayv1beta1.Listener{
+			Port:     80,
+			Protocol: gatewayv1.HTTPProtocolType,
+		}
+		// Rules without host are converted to listeners without hostname,
+		// matching all hosts.
 		if rg.host != "" {
 			listener.Hostname = (*gatewayv1beta1.Hostname)(&rg.host)
-		} else if len(rg.tls) == 1 && len(rg.tls[0].Hosts) == 1 {
-			listener.Hostname = (*gatewayv1beta1.Hostname)(&rg.tls[0].Hosts[0])
 		}
-		if len(rg.tls) > 0 {
-			listener.TLS = &gatewayv1beta1.GatewayTLSConfig{}
+		listener.Name = gatewayv1beta1.SectionName(listenerNamePrefix(listener.Hostname) + "http")
+		gwKey := fmt.Sprintf("%s/%s", rg.namespace, rg.ingressClass)
+		httpsListener := httpsListeners[ruleGroupKey(rgKey)]
+		httpSections := []gatewayv1beta1.SectionName{listener.Name}
+		var httpsSections []gatewayv1beta1.SectionName
+		if ports, portWarns := rg.listenPorts(); len(ports) > 0 {
+			warnings = append(warnings, portWarns...)
+			albHTTPListeners, albHTTPSListeners, ok := albListeners(listener, httpsListener, ports)
+			if !ok {
+				warnings = append(warnings, Warning{
+					Ingress: rg.rules[0].ingress,
+					Field:   field.NewPath(rg.rules[0].ingress.Name, "metadata", "annotations").Key(albListenPortsAnnotation),
+					Message: fmt.Sprintf("the HTTPS listen ports of host %q have no listener, as the host has no TLS Secret; the listeners and their certificateRefs must be configured manually", rg.host),
+				})
+			}
+			httpSections = nil
+			for _, l := range albHTTPListeners {
+				httpSections = append(httpSections, l.Name)
+				addListener(gwKey, l)
+			}
+			for _, l := range albHTTPSListeners {
+				httpsSections = append(httpsSections, l.Name)
+				addListener(gwKey, l)
+			}
+			if len(albHTTPSListeners) == 0 {
+				httpsListener = nil
+			}
+		} else {
+			// The hosts whose Ingresses all disable HTTP only get their
+			// HTTPS listener.
+			disabled, disabledWarns := rg.httpDisabled(httpsListener != nil)
+			warnings = append(warnings, disabledWarns...)
+			if disabled {
+				httpSections = nil
+			} else {
+				listenersByNamespacedGateway[gwKey] = append(listenersByNamespacedGateway[gwKey], listener)
+			}
+			if httpsListener != nil {
+				httpsSections = []gatewayv1beta1.SectionName{httpsListener.Name}
+				addListener(gwKey, *httpsListener)
+			}
 		}
-		for _, tls := range rg.tls {
-			listener.TLS.CertificateRefs = append(listener.TLS.CertificateRefs,
-				gatewayv1beta1.SecretObjectReference{Name: gatewayv1beta1.ObjectName(tls.SecretName)})
+		var httpsSection gatewayv1beta1.SectionName
+		if len(httpsSections) > 0 {
+			httpsSection = httpsSections[0]
 		}
-		gwKey := fmt.Sprintf("%s/%s", rg.namespace, rg.ingressClass)
-		listenersByNamespacedGateway[gwKey] = append(listenersByNamespacedGateway[gwKey], listener)
-		httpRoute, errs := rg.toHTTPRoute()
-		httpRoutes = append(httpRoutes, httpRoute)
+		httpRoute, routeWarns, errs := rg.toHTTPRoute()
+		if len(rg.annotations) > 0 {
+			httpRoute.Annotations = rg.annotations
+		}
+		a.annotateSources(&httpRoute, rg.ingresses()...)
+		warnings = append(warnings, rg.appProtocolWarnings()...)
+		grpcRoute, grpcWarns, grpcErrs, hasGRPC := rg.toGRPCRoute(httpsSection)
+		if hasGRPC {
+			if len(rg.annotations) > 0 {
+				grpcRoute.Annotations = rg.annotations
+			}
+			a.annotateSources(&grpcRoute, rg.ingresses()...)
+			grpcRoutes = append(grpcRoutes, grpcRoute)
+			warnings = append(warnings, grpcWarns...)
+			errors = append(errors, grpcErrs...)
+			// No HTTPRoute is generated when all the backends are gRPC services,
+			// and the warnings of the Ingresses aren't bound to any HTTPRoute.
+			if len(httpRoute.Spec.Rules) == 0 {
+				httpRoute = gatewayv1beta1.HTTPRoute{}
+			}
+		}
+		// Rules without paths, such as hosts listed for TLS termination only,
+		// still get the listeners of their host, but no HTTPRoute.
+		if !rg.hasPaths() {
+			httpRoute = gatewayv1beta1.HTTPRoute{}
+			routeWarns = append(routeWarns, Warning{
+				Ingress: rg.rules[0].ingress,
+				Field:   field.NewPath(rg.rules[0].ingress.Name, "spec", "rules"),
+				Message: fmt.Sprintf("no routing rules were found for host %q, only its Gateway listeners are generated", rg.host),
+			})
+		}
+		protocol, protocolWarns := rg.listenerProtocol()
+		routeWarns = append(routeWarns, protocolWarns...)
+		switch {
+		case httpRoute.Name == "":
+		case protocol == "":
+			// The routes of TLS rules are attached to both the HTTP and HTTPS
+			// listeners of their host, the routes of HTTP rules to the HTTP
+			// listeners only, rather than to every listener of the Gateway.
+			setSectionName(&httpRoute, append(append([]gatewayv1beta1.SectionName{}, httpSections...), httpsSections...)...)
+		case protocol == gatewayv1.HTTPSProtocolType && httpsListener == nil:
+			routeWarns = append(routeWarns, Warning{
+				Ingress: rg.rules[0].ingress,
+				Field:   field.NewPath(rg.rules[0].ingress.Name, "metadata", "annotations").Key(kongProtocolsAnnotation),
+				Message: fmt.Sprintf("the paths of host %q are restricted to HTTPS, but the host has no TLS; they are served over HTTP", rg.host),
+			})
+			setSectionName(&httpRoute, httpSections...)
+		case protocol == gatewayv1.HTTPSProtocolType:
+			setSectionName(&httpRoute, httpsSections...)
+		case protocol == gatewayv1.HTTPProtocolType:
+			setSectionName(&httpRoute, httpSections...)
+		}
+		var redirectRoute *gatewayv1beta1.HTTPRoute
+		if redirect, redirectWarns := rg.sslRedirect(); redirect != nil && httpRoute.Name != "" && len(httpSections) > 0 {
+			if httpsListener == nil {
+				routeWarns = append(routeWarns, Warning{
+					Ingress: rg.rules[0].ingress,
+					Field:   field.NewPath(rg.rules[0].ingress.Name, "spec", "tls"),
+					Message: fmt.Sprintf("HTTP requests to host %q cannot be redirected to HTTPS, as the host has no TLS", rg.host),
+				})
+			} else {
+				route := toSSLRedirectRoute(&httpRoute, httpSections, httpsSections, redirect)
+				redirectRoute = &route
+				routeWarns = append(routeWarns, redirectWarns...)
+			}
+		}
+		// The warnings of the listener selection are bound to the HTTPRoute
+		// as well.
+		for i := range routeWarns {
+			if routeWarns[i].HTTPRoute.Name == "" {
+				routeWarns[i].HTTPRoute = types.NamespacedName{Namespace: httpRoute.Namespace, Name: httpRoute.Name}
+			}
+		}
+		if httpRoute.Name != "" {
+			httpRoutes = append(httpRoutes, httpRoute)
+		}
+		if redirectRoute != nil {
+			httpRoutes = append(httpRoutes, *redirectRoute)
+		}
+		warnings = append(warnings, routeWarns...)
 		errors = append(errors, errs...)
+		var extras []*extra
+		for _, ir := range rg.rules {
+			extras = append(extras, ir.extra)
+		}
+		warnings = append(warnings, routeWarnings(httpRoute, extras...)...)
 	}
 
 	for i, db := range a.defaultBackends {
 		httpRoute := gatewayv1beta1.HTTPRoute{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-default-backend", db.name),
+				Name:      defaultBackendRouteName(db.name),
 				Namespace: db.namespace,
 			},
 			Spec: gatewayv1beta1.HTTPRouteSpec{
@@ -185,8 +654,17 @@ func (a *ingressAggregator) toHTTPRoutesAndGateways() ([]gatewayv1beta1.HTTPRout
 			},
 		}
 		httpRoute.SetGroupVersionKind(httpRouteGVK)
+		if db.extra != nil && len(db.extra.annotations) > 0 {
+			httpRoute.Annotations = db.extra.annotations
+		}
 
-		backendRef, err := toBackendRef(db.backend, field.NewPath(db.name, "paths", "backends").Index(i))
+		ingress := types.NamespacedName{Namespace: db.namespace, Name: db.name}
+		a.annotateSources(&httpRoute, ingress)
+		backendRef, warning, err := toBackendRef(db.backend, ingress, a.services, db.extra.serviceBackendKind(), field.NewPath(db.name, "paths", "backends").Index(i))
+		if warning != nil {
+			warning.HTTPRoute = types.NamespacedName{Namespace: httpRoute.Namespace, Name: httpRoute.Name}
+			warnings = append(warnings, *warning)
+		}
 		if err != nil {
 			errors = append(errors, err)
 		} else {
@@ -196,6 +674,7 @@ func (a *ingressAggregator) toHTTPRoutesAndGateways() ([]gatewayv1beta1.HTTPRout
 		}
 
 		httpRoutes = append(httpRoutes, httpRoute)
+		warnings = append(warnings, routeWarnings(httpRoute, db.extra)...)
 	}
 
 	gatewaysByKey := map[string]*gatewayv1beta1.Gateway{}
@@ -219,53 +698,226 @@ func (a *ingressAggregator) toHTTPRoutesAndGateways() ([]gatewayv1beta1.HTTPRout
 			gateway.SetGroupVersionKind(gatewayGVK)
 			gatewaysByKey[gwKey] = gateway
 		}
-		for _, listener := range listeners {
-			var listenerNamePrefix string
-			if listener.Hostname != nil && *listener.Hostname != "" {
-				listenerNamePrefix = fmt.Sprintf("%s-", nameFromHost(string(*listener.Hostname)))
-			}
-
-			gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
-				Name:     gatewayv1beta1.SectionName(fmt.Sprintf("%shttp", listenerNamePrefix)),
-				Hostname: listener.Hostname,
-				Port:     80,
-				Protocol: gatewayv1beta1.HTTPProtocolType,
-			})
-			if listener.TLS != nil {
-				gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
-					Name:     gatewayv1beta1.SectionName(fmt.Sprintf("%shttps", listenerNamePrefix)),
-					Hostname: listener.Hostname,
-					Port:     443,
-					Protocol: gatewayv1beta1.HTTPSProtocolType,
-					TLS:      listener.TLS,
-				})
-			}
-		}
+		gateway.Spec.Listeners = append(gateway.Spec.Listeners, listeners...)
 	}
 
+	// Gateways are sorted by namespace and name, so that they are generated
+	// in the same order between runs.
+	gwKeys := make([]string, 0, len(gatewaysByKey))
+	for gwKey := range gatewaysByKey {
+		gwKeys = append(gwKeys, gwKey)
+	}
+	sort.Strings(gwKeys)
 	var gateways []gatewayv1beta1.Gateway
-	for _, gw := range gatewaysByKey {
-		gateways = append(gateways, *gw)
+	for _, gwKey := range gwKeys {
+		gateways = append(gateways, *gatewaysByKey[gwKey])
+	}
+
+	return httpRoutes, grpcRoutes, gateways, warnings, errors
+}
+
+// listenerNamePrefix returns the prefix of the names of the listeners of the
+// hostname.
+func listenerNamePrefix(hostname *gatewayv1beta1.Hostname) string {
+	if hostname == nil || *hostname == "" {
+		return ""
 	}
+	return fmt.Sprintf("%s-", nameFromHost(string(*hostname)))
+}
 
-	return httpRoutes, gateways, errors
+// routeWarnings returns the warnings of the Ingresses an HTTPRoute was
+// generated from, bound to that HTTPRoute. Ingresses contributing several
+// rules to the route only have their warnings reported once.
+func routeWarnings(httpRoute gatewayv1beta1.HTTPRoute, extras ...*extra) []Warning {
+	var warnings []Warning
+	seen := map[*extra]bool{}
+	for _, e := range extras {
+		if e == nil || seen[e] {
+			continue
+		}
+		seen[e] = true
+		for _, w := range e.warnings {
+			w.HTTPRoute = types.NamespacedName{Namespace: httpRoute.Namespace, Name: httpRoute.Name}
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
 }
 
-func (rg *ingressRuleGroup) toHTTPRoute() (gatewayv1beta1.HTTPRoute, field.ErrorList) {
+// hasPaths reports whether the rules of the rule group have paths.
+func (rg *ingressRuleGroup) hasPaths() bool {
+	for _, ir := range rg.rules {
+		if ir.rule.HTTP != nil && len(ir.rule.HTTP.Paths) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// groupPaths groups the paths of the rule group by match, either the paths
+// with gRPC backends or the other ones. The path match keys are returned in
+// the order of the Ingress paths, so that rules are generated in a
+// deterministic order.
+func (rg *ingressRuleGroup) groupPaths(grpc bool) ([]pathMatchKey, map[pathMatchKey][]ingressPath) {
 	pathsByMatchGroup := map[pathMatchKey][]ingressPath{}
-	var errors field.ErrorList
+	var pmKeys []pathMatchKey
+	// neverHeaders are the canary headers of flag values, by key of the
+	// weighted rule of their canary.
+	neverHeaders := map[pathMatchKey][]string{}
 
+	addPath := func(ip ingressPath) {
+		pmKey := getPathMatchKey(ip)
+		if _, ok := pathsByMatchGroup[pmKey]; !ok {
+			pmKeys = append(pmKeys, pmKey)
+		}
+		pathsByMatchGroup[pmKey] = append(pathsByMatchGroup[pmKey], ip)
+	}
 	for i, ir := range rg.rules {
+		if ir.rule.HTTP == nil {
+			continue
+		}
 		for j, path := range ir.rule.HTTP.Paths {
-			ip := ingressPath{ruleIdx: i, pathIdx: j, ruleType: "http", path: path, extra: ir.extra}
-			pmKey := getPathMatchKey(ip)
-			pathsByMatchGroup[pmKey] = append(pathsByMatchGroup[pmKey], ip)
+			if rg.grpcPath(ir, path) != grpc {
+				continue
+			}
+			regex := isRegexPath(path, ir.extra)
+			if !regex {
+				path.Path = normalizePath(path)
+			}
+			appProtocol, _ := serviceAppProtocol(path.Backend, ir.ingress.Namespace, rg.services)
+			ip := ingressPath{ingress: ir.ingress, ruleIdx: i, pathIdx: j, ruleType: "http", path: path, regex: regex, appProtocol: appProtocol, extra: ir.extra}
+			c := ir.extra.canaryConfig()
+			if c == nil || c.headerKey == "" || c.weight == 0 {
+				addPath(ip)
+				continue
+			}
+			// ingress-nginx routes requests with the canary header to the canary,
+			// and other requests by weight. The canary backend is therefore part
+			// of both the header matching rule, unweighted, and of the weighted
+			// rule without header match.
+			byHeader, byWeight := *ir.extra, *ir.extra
+			headerCanary, weightCanary := *c, *c
+			headerCanary.weight, headerCanary.weighted, headerCanary.weightTotal = 0, false, 0
+			weightCanary.headerKey, weightCanary.headerValue, weightCanary.headerRegexMatch, weightCanary.headerFlag = "", "", false, false
+			byHeader.canary, byWeight.canary = &headerCanary, &weightCanary
+			headerPath, weightPath := ip, ip
+			headerPath.extra, weightPath.extra = &byHeader, &byWeight
+			addPath(headerPath)
+			addPath(weightPath)
+			if c.headerFlag {
+				weightKey := getPathMatchKey(weightPath)
+				neverHeaders[weightKey] = append(neverHeaders[weightKey], c.headerKey)
+			}
+		}
+	}
+
+	if len(neverHeaders) == 0 {
+		return pmKeys, pathsByMatchGroup
+	}
+	// Requests with a canary header set to never are routed to the primary
+	// backends only, instead of by weight: the primary paths are added to a
+	// rule matching the header, ahead of the weighted rule.
+	var ordered []pathMatchKey
+	for _, pmKey := range pmKeys {
+		for _, headerKey := range neverHeaders[pmKey] {
+			for _, ip := range pathsByMatchGroup[pmKey] {
+				if ip.extra.canaryConfig() != nil {
+					continue
+				}
+				var never extra
+				if ip.extra != nil {
+					never = *ip.extra
+				}
+				never.canary = &canary{headerKey: headerKey, headerValue: "never"}
+				ip.extra = &never
+				neverKey := getPathMatchKey(ip)
+				if _, ok := pathsByMatchGroup[neverKey]; !ok {
+					ordered = append(ordered, neverKey)
+				}
+				pathsByMatchGroup[neverKey] = append(pathsByMatchGroup[neverKey], ip)
+			}
 		}
+		ordered = append(ordered, pmKey)
 	}
+	return ordered, pathsByMatchGroup
+}
+
+// removeConflicts removes the paths routing the same match as a path of a
+// previous rule to a different backend, or redirecting it differently, as the
+// generated routing would be ambiguous. Canary paths are intended to share the
+// match of their primary path, so they never conflict. The conflicts are
+// reported as errors, or as warnings when conflicts are allowed, in which case
+// the previous path takes precedence, unless only the other path redirects:
+// the rule of a match either redirects or routes its requests to backends.
+func (rg *ingressRuleGroup) removeConflicts(pmKeys []pathMatchKey, pathsByMatchGroup map[pathMatchKey][]ingressPath) ([]Warning, field.ErrorList) {
+	var warnings []Warning
+	var errors field.ErrorList
+	for _, pmKey := range pmKeys {
+		var primary *ingressPath
+		var primaryIdx int
+		var kept []ingressPath
+		for _, ip := range pathsByMatchGroup[pmKey] {
+			if c := ip.extra.canaryConfig(); c != nil && c.enable {
+				kept = append(kept, ip)
+				continue
+			}
+			if primary == nil {
+				primary, primaryIdx = &ip, len(kept)
+				kept = append(kept, ip)
+				continue
+			}
+			redirect := primary.extra.requestRedirect()
+			if apiequality.Semantic.DeepEqual(primary.path.Backend, ip.path.Backend) && apiequality.Semantic.DeepEqual(redirect, ip.extra.requestRedirect()) {
+				continue
+			}
+			pathType := ""
+			if ip.path.PathType != nil {
+				pathType = string(*ip.path.PathType)
+			}
+			fieldPath := field.NewPath(ip.ingress.Name, "spec", "rules").Child("http", "paths").Key(ip.path.Path)
+			detail := fmt.Sprintf("%s path %q of host %q is already routed to a different backend by Ingress %s", pathType, ip.path.Path, rg.host, primary.ingress)
+			switch {
+			case redirect != nil:
+				detail = fmt.Sprintf("%s path %q of host %q is already redirected by Ingress %s", pathType, ip.path.Path, rg.host, primary.ingress)
+			case ip.extra.requestRedirect() != nil:
+				detail = fmt.Sprintf("%s path %q of host %q is already routed to a backend by Ingress %s instead of being redirected", pathType, ip.path.Path, rg.host, primary.ingress)
+			}
+			if !rg.allowConflicts {
+				errors = append(errors, field.Invalid(fieldPath, ip.ingress.String(), detail))
+				continue
+			}
+			// A redirect takes precedence over the backends of the path, so
+			// that none of its requests are served without being redirected.
+			if redirect == nil && ip.extra.requestRedirect() != nil {
+				warnings = append(warnings, Warning{
+					Ingress: primary.ingress,
+					Field:   field.NewPath(primary.ingress.Name, "spec", "rules").Child("http", "paths").Key(primary.path.Path),
+					Message: fmt.Sprintf("%s path %q of host %q is redirected by Ingress %s, which takes precedence over its backend", pathType, ip.path.Path, rg.host, ip.ingress),
+				})
+				kept[primaryIdx] = ip
+				primary = &kept[primaryIdx]
+				continue
+			}
+			warnings = append(warnings, Warning{
+				Ingress: ip.ingress,
+				Field:   fieldPath,
+				Message: detail + ", which takes precedence",
+			})
+		}
+		pathsByMatchGroup[pmKey] = kept
+	}
+	return warnings, errors
+}
+
+// toHTTPRoute converts the paths of the rule group which don't have gRPC
+// backends to an HTTPRoute.
+func (rg *ingressRuleGroup) toHTTPRoute() (gatewayv1beta1.HTTPRoute, []Warning, field.ErrorList) {
+	pmKeys, pathsByMatchGroup := rg.groupPaths(false)
+	warnings, errors := rg.removeConflicts(pmKeys, pathsByMatchGroup)
 
 	httpRoute := gatewayv1beta1.HTTPRoute{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      nameFromHost(rg.host),
+			Name:      rg.routeName(),
 			Namespace: rg.namespace,
 		},
 		Spec: gatewayv1beta1.HTTPRouteSpec{},
@@ -284,71 +936,307 @@ func (rg *ingressRuleGroup) toHTTPRoute() (gatewayv1beta1.HTTPRoute, field.Error
 		httpRoute.Spec.Hostnames = []gatewayv1beta1.Hostname{gatewayv1beta1.Hostname(rg.host)}
 	}
 
-	for _, paths := range pathsByMatchGroup {
+	for _, pmKey := range pmKeys {
+		paths := pathsByMatchGroup[pmKey]
 		path := paths[0]
-		fieldPath := field.NewPath("spec", "rules").Index(path.ruleIdx).Child(path.ruleType).Child("paths").Index(path.pathIdx)
+		fieldPath := field.NewPath(path.ingress.Name, "spec", "rules").Index(path.ruleIdx).Child(path.ruleType).Child("paths").Index(path.pathIdx)
+
+		var filters []gatewayv1beta1.HTTPRouteFilter
+		if path.extra != nil && path.extra.rewriteTarget != "" {
+			prefix, ok := strippedPrefix(path.path.Path, path.extra.rewriteTarget)
+			if ok {
+				pathType := networkingv1.PathTypePrefix
+				path.path.Path = prefix
+				path.path.PathType = &pathType
+				path.regex = false
+				filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+					Type: gatewayv1.HTTPRouteFilterURLRewrite,
+					URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{
+						Path: &gatewayv1beta1.HTTPPathModifier{
+							Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+							ReplacePrefixMatch: pointer.String("/"),
+						},
+					},
+				})
+			} else {
+				warnings = append(warnings, Warning{
+					Ingress: path.ingress,
+					Field:   field.NewPath(path.ingress.Name, "metadata", "annotations").Key("nginx.ingress.kubernetes.io/rewrite-target"),
+					Message: fmt.Sprintf("the prefix stripped by rewrite-target %q cannot be statically determined for path %q; the rewrite must be configured manually", path.extra.rewriteTarget, path.path.Path),
+				})
+			}
+		}
+		if path.extra != nil && path.extra.pathRewrite != "" {
+			if rewrite, ok := haproxyPathRewriteFilter(path.path, path.extra.pathRewrite); ok {
+				filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+					Type:       gatewayv1.HTTPRouteFilterURLRewrite,
+					URLRewrite: rewrite,
+				})
+			} else {
+				warnings = append(warnings, Warning{
+					Ingress: path.ingress,
+					Field:   field.NewPath(path.ingress.Name, "metadata", "annotations").Key(haproxyPathRewriteAnnotation),
+					Message: fmt.Sprintf("the path-rewrite %q cannot be converted to a URLRewrite filter for path %q; the rewrite must be configured manually", path.extra.pathRewrite, path.path.Path),
+				})
+			}
+		}
+
+		if path.extra != nil && path.extra.stripPath {
+			filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+				Type:       gatewayv1.HTTPRouteFilterURLRewrite,
+				URLRewrite: kongStripPathFilter(path.path),
+			})
+		}
+
+		filters = append(filters, path.extra.headerFilters()...)
+
 		match, err := toHTTPRouteMatch(path, fieldPath)
 		if err != nil {
 			errors = append(errors, err)
 			continue
 		}
 		hrRule := gatewayv1beta1.HTTPRouteRule{
-			Matches: []gatewayv1beta1.HTTPRouteMatch{*match},
+			Matches:            withMethods(*match, path.extra.matchMethods()),
+			Filters:            filters,
+			SessionPersistence: path.extra.sessionPersistence(),
+			Timeouts:           path.extra.routeTimeouts(),
 		}
 
-		backendRefs, errs := rg.calculateBackendRefWeight(paths)
+		// The paths routing to an ALB action have no backends.
+		if action := path.albAction(); action != nil {
+			if action.redirect != nil {
+				hrRule.Filters = append(hrRule.Filters, gatewayv1beta1.HTTPRouteFilter{
+					Type:            gatewayv1.HTTPRouteFilterRequestRedirect,
+					RequestRedirect: action.redirect,
+				})
+			}
+			httpRoute.Spec.Rules = append(httpRoute.Spec.Rules, hrRule)
+			rg.setRuleIngress(hrRule, paths)
+			continue
+		}
+		// The paths of Ingresses redirecting all their requests have no
+		// backends, and their requests aren't modified by other filters.
+		if path.extra != nil && path.extra.redirect != nil {
+			hrRule := gatewayv1beta1.HTTPRouteRule{
+				Matches: hrRule.Matches,
+				Filters: []gatewayv1beta1.HTTPRouteFilter{{
+					Type:            gatewayv1.HTTPRouteFilterRequestRedirect,
+					RequestRedirect: path.extra.redirect,
+				}},
+			}
+			httpRoute.Spec.Rules = append(httpRoute.Spec.Rules, hrRule)
+			rg.setRuleIngress(hrRule, paths)
+			continue
+		}
+
+		backendRefs, warns, errs := rg.calculateBackendRefWeight(paths)
+		warnings = append(warnings, warns...)
 		errors = append(errors, errs...)
 		hrRule.BackendRefs = backendRefs
 
 		httpRoute.Spec.Rules = append(httpRoute.Spec.Rules, hrRule)
+		rg.setRuleIngress(hrRule, paths)
+	}
+	warnings = append(warnings, rg.addAppRootRule(&httpRoute)...)
+
+	sortRules(httpRoute.Spec.Rules)
+
+	for i := range warnings {
+		warnings[i].HTTPRoute = types.NamespacedName{Namespace: httpRoute.Namespace, Name: httpRoute.Name}
+	}
+
+	return httpRoute, warnings, errors
+}
+
+// setRuleIngress records the Ingress of the rule converted from the paths,
+// unless a previous rule of its path was recorded.
+func (rg *ingressRuleGroup) setRuleIngress(rule gatewayv1beta1.HTTPRouteRule, paths []ingressPath) {
+	if rg.ruleIngresses == nil {
+		rg.ruleIngresses = map[string]types.NamespacedName{}
+	}
+	key := rulePathKey(rule)
+	if _, ok := rg.ruleIngresses[key]; ok {
+		return
+	}
+	ingress := paths[0].ingress
+	for _, path := range paths {
+		if path.extra.canaryConfig() == nil {
+			ingress = path.ingress
+			break
+		}
+	}
+	rg.ruleIngresses[key] = ingress
+}
+
+// rulePathKey returns the type and value of the path match of the rule, empty
+// when it doesn't match paths.
+func rulePathKey(rule gatewayv1beta1.HTTPRouteRule) string {
+	if len(rule.Matches) == 0 || rule.Matches[0].Path == nil || rule.Matches[0].Path.Value == nil {
+		return ""
+	}
+	path := rule.Matches[0].Path
+	return fmt.Sprintf("%v/%s", pointer.StringDeref((*string)(path.Type), ""), *path.Value)
+}
+
+// sortRules sorts the rules so that more specific paths come first, as the
+// longest Ingress path matching a request takes precedence: Exact paths come
+// before PathPrefix paths, then RegularExpression paths, and longer paths
+// come before the paths they extend, e.g. /api/v1 before /api. Rules of
+// equally specific paths keep the order the paths first appear. The rules
+// of a path matching headers, such as header based A/B testing variants, are
+// sorted by header and come before the rule without header match.
+func sortRules(rules []gatewayv1beta1.HTTPRouteRule) {
+	pathOf := func(rule gatewayv1beta1.HTTPRouteRule) *gatewayv1beta1.HTTPPathMatch {
+		if len(rule.Matches) == 0 || rule.Matches[0].Path == nil || rule.Matches[0].Path.Value == nil {
+			return nil
+		}
+		return rule.Matches[0].Path
+	}
+	keyOf := rulePathKey
+	typeRank := func(rule gatewayv1beta1.HTTPRouteRule) int {
+		path := pathOf(rule)
+		if path == nil {
+			return len(pathTypePrecedence)
+		}
+		for i, pathType := range pathTypePrecedence {
+			if pointer.StringDeref((*string)(path.Type), string(gatewayv1.PathMatchPathPrefix)) == string(pathType) {
+				return i
+			}
+		}
+		return len(pathTypePrecedence)
+	}
+	lengthOf := func(rule gatewayv1beta1.HTTPRouteRule) int {
+		if path := pathOf(rule); path != nil {
+			return len(*path.Value)
+		}
+		return 0
+	}
+	headersOf := func(rule gatewayv1beta1.HTTPRouteRule) []gatewayv1beta1.HTTPHeaderMatch {
+		if len(rule.Matches) == 0 {
+			return nil
+		}
+		return rule.Matches[0].Headers
 	}
 
-	return httpRoute, errors
+	pathRanks := map[string]int{}
+	for _, rule := range rules {
+		if _, ok := pathRanks[keyOf(rule)]; !ok {
+			pathRanks[keyOf(rule)] = len(pathRanks)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if ti, tj := typeRank(rules[i]), typeRank(rules[j]); ti != tj {
+			return ti < tj
+		}
+		if li, lj := lengthOf(rules[i]), lengthOf(rules[j]); li != lj {
+			return li > lj
+		}
+		if ri, rj := pathRanks[keyOf(rules[i])], pathRanks[keyOf(rules[j])]; ri != rj {
+			return ri < rj
+		}
+		hi, hj := headersOf(rules[i]), headersOf(rules[j])
+		if len(hi) == 0 || len(hj) == 0 {
+			return len(hi) > len(hj)
+		}
+		if hi[0].Name != hj[0].Name {
+			return hi[0].Name < hj[0].Name
+		}
+		return hi[0].Value < hj[0].Value
+	})
+}
+
+// pathTypePrecedence are the types of path matches from the most to the least
+// specific.
+var pathTypePrecedence = []gatewayv1.PathMatchType{
+	gatewayv1.PathMatchExact,
+	gatewayv1.PathMatchPathPrefix,
+	gatewayv1.PathMatchRegularExpression,
 }
 
-func (rg *ingressRuleGroup) calculateBackendRefWeight(paths []ingressPath) ([]gatewayv1beta1.HTTPBackendRef, field.ErrorList) {
+// calculateBackendRefWeight returns the backendRefs of the paths sharing a
+// match, weighted when canaries split the traffic. The canaries get their
+// weight, and the other backends share the rest of the weight total. Paths
+// of several Ingresses routing to the same service share a single
+// backendRef, their weights summed. Canaries without weight in a weighted
+// split, and weights over the total, are reported as errors naming the
+// participating Ingresses.
+func (rg *ingressRuleGroup) calculateBackendRefWeight(paths []ingressPath) ([]gatewayv1beta1.HTTPBackendRef, []Warning, field.ErrorList) {
+	var warnings []Warning
 	var errors field.ErrorList
 	var backendRefs []gatewayv1beta1.HTTPBackendRef
 
 	var numWeightedBackends, totalWeightSet int32
+	var ingresses, unweightedCanaries []string
+	var weightPath *field.Path
 
 	// This is the default value for nginx annotation nginx.ingress.kubernetes.io/canary-weight-total
 	var weightTotal = 100
 
 	for i, path := range paths {
-		backendRef, err := toBackendRef(path.path.Backend, field.NewPath("paths", "backends").Index(i))
+		backendRef, warning, err := toBackendRef(path.path.Backend, path.ingress, rg.services, path.extra.serviceBackendKind(), field.NewPath(path.ingress.Name, "paths", "backends").Index(i))
+		if warning != nil {
+			warnings = append(warnings, *warning)
+		}
 		if err != nil {
 			errors = append(errors, err)
 			continue
 		}
-		if path.extra != nil && path.extra.canary != nil && path.extra.canary.weight != 0 {
-			weight := int32(path.extra.canary.weight)
+		ingresses = append(ingresses, path.ingress.String())
+		// Canaries by header only get weighted traffic through the rule
+		// without header match.
+		if c := path.extra.canaryConfig(); c != nil && (c.weight != 0 || (c.weighted && c.headerKey == "")) {
+			weight := int32(c.weight)
 			backendRef.Weight = &weight
 			totalWeightSet += weight
 			numWeightedBackends++
-			if path.extra.canary.weightTotal > 0 {
-				weightTotal = path.extra.canary.weightTotal
+			if c.weightTotal > 0 {
+				weightTotal = c.weightTotal
 			}
+			if weightPath == nil {
+				weightPath = field.NewPath(path.ingress.Name, "metadata", "annotations").Key("nginx.ingress.kubernetes.io/canary-weight")
+			}
+		} else if c != nil && c.enable && !c.weighted && c.headerKey == "" {
+			unweightedCanaries = append(unweightedCanaries, path.ingress.String())
 		}
 		backendRefs = append(backendRefs, gatewayv1beta1.HTTPBackendRef{BackendRef: *backendRef})
 	}
-	if numWeightedBackends > 0 && numWeightedBackends < int32(len(backendRefs)) {
+	if numWeightedBackends == 0 {
+		return backendRefs, warnings, errors
+	}
+	if len(unweightedCanaries) > 0 {
+		errors = append(errors, field.Invalid(field.NewPath(paths[0].ingress.Name, "metadata", "annotations").Key("nginx.ingress.kubernetes.io/canary-weight"), strings.Join(unweightedCanaries, ", "),
+			fmt.Sprintf("canary Ingresses without canary-weight can't take part in the weighted traffic split of Ingresses %s", strings.Join(ingresses, ", "))))
+		return nil, warnings, errors
+	}
+	if totalWeightSet > int32(weightTotal) {
+		errors = append(errors, field.Invalid(weightPath, totalWeightSet,
+			fmt.Sprintf("the canary weights of Ingresses %s are over the canary-weight-total of %d", strings.Join(ingresses, ", "), weightTotal)))
+		return nil, warnings, errors
+	}
+	if numWeightedBackends < int32(len(backendRefs)) {
 		weightToSet := (int32(weightTotal) - totalWeightSet) / (int32(len(backendRefs)) - numWeightedBackends)
-		if weightToSet < 0 {
-			weightToSet = 0
-		}
 		for i := range backendRefs {
 			if backendRefs[i].Weight == nil {
-				backendRefs[i].Weight = &weightToSet
+				backendRefs[i].Weight = pointer.Int32(weightToSet)
 			}
-			if *backendRefs[i].Weight > int32(weightTotal) {
-				backendRefs[i].Weight = pointer.Int32(int32(weightTotal))
+		}
+	}
 
+	var merged []gatewayv1beta1.HTTPBackendRef
+	for _, backendRef := range backendRefs {
+		found := false
+		for i := range merged {
+			if apiequality.Semantic.DeepEqual(merged[i].BackendObjectReference, backendRef.BackendObjectReference) {
+				merged[i].Weight = pointer.Int32(*merged[i].Weight + *backendRef.Weight)
+				found = true
+				break
 			}
 		}
+		if !found {
+			merged = append(merged, backendRef)
+		}
 	}
-
-	return backendRefs, errors
+	return merged, warnings, errors
 }
 
 func getPathMatchKey(ip ingressPath) pathMatchKey {
@@ -356,34 +1244,47 @@ func getPathMatchKey(ip ingressPath) pathMatchKey {
 	if ip.path.PathType != nil {
 		pathType = string(*ip.path.PathType)
 	}
-	var canaryHeaderKey string
-	if ip.extra != nil && ip.extra.canary != nil && ip.extra.canary.headerKey != "" {
-		canaryHeaderKey = ip.extra.canary.headerKey
+	if ip.regex {
+		pathType = string(gatewayv1.PathMatchRegularExpression)
 	}
-	return pathMatchKey(fmt.Sprintf("%s/%s/%s", pathType, ip.path.Path, canaryHeaderKey))
+	var canaryHeader string
+	if c := ip.extra.canaryConfig(); c != nil && c.headerKey != "" {
+		canaryHeader = fmt.Sprintf("%s/%t/%s", c.headerKey, c.headerRegexMatch, c.headerValue)
+	}
+	// The paths restricted to different methods don't conflict.
+	methods := strings.Join(methodNames(ip.extra.matchMethods()), ",")
+	return pathMatchKey(fmt.Sprintf("%s/%s/%s/%s", pathType, ip.path.Path, canaryHeader, methods))
 }
 
 func toHTTPRouteMatch(ip ingressPath, path *field.Path) (*gatewayv1beta1.HTTPRouteMatch, *field.Error) {
-	pmPrefix := gatewayv1beta1.PathMatchPathPrefix
-	pmExact := gatewayv1beta1.PathMatchExact
-	hmExact := gatewayv1beta1.HeaderMatchExact
-	hmRegex := gatewayv1beta1.HeaderMatchRegularExpression
+	pmPrefix := gatewayv1.PathMatchPathPrefix
+	pmExact := gatewayv1.PathMatchExact
+	pmRegex := gatewayv1.PathMatchRegularExpression
+	hmExact := gatewayv1.HeaderMatchExact
+	hmRegex := gatewayv1.HeaderMatchRegularExpression
 
 	match := &gatewayv1beta1.HTTPRouteMatch{Path: &gatewayv1beta1.HTTPPathMatch{Value: &ip.path.Path}}
-	//exhaustive:ignore -explicit-exhaustive-switch
-	// networkingv1.PathTypeImplementationSpecific is not supported here, hence it goes into default case.
-	switch *ip.path.PathType {
-	case networkingv1.PathTypePrefix:
-		match.Path.Type = &pmPrefix
-	case networkingv1.PathTypeExact:
-		match.Path.Type = &pmExact
-	default:
-		return nil, field.Invalid(path.Child("pathType"), ip.path.PathType, fmt.Sprintf("unsupported path match type: %s", *ip.path.PathType))
+	if ip.regex {
+		match.Path.Type = &pmRegex
+	} else {
+		// ImplementationSpecific paths, including the paths without path type,
+		// are matched as prefixes, see defaultPathTypes.
+		switch *ip.path.PathType {
+		case networkingv1.PathTypePrefix, networkingv1.PathTypeImplementationSpecific:
+			match.Path.Type = &pmPrefix
+		case networkingv1.PathTypeExact:
+			match.Path.Type = &pmExact
+		default:
+			return nil, field.Invalid(path.Child("pathType"), ip.path.PathType, fmt.Sprintf("unsupported path match type: %s", *ip.path.PathType))
+		}
+		if msg := validatePathValue(ip.path.Path); msg != "" {
+			return nil, field.Invalid(path.Child("path"), ip.path.Path, msg)
+		}
 	}
 
 	if ip.extra != nil && ip.extra.canary != nil && ip.extra.canary.headerKey != "" {
 		headerMatch := gatewayv1beta1.HTTPHeaderMatch{
-			Name:  gatewayv1beta1.HTTPHeaderName(ip.extra.canary.headerKey),
+			Name:  gatewayv1.HTTPHeaderName(ip.extra.canary.headerKey),
 			Value: ip.extra.canary.headerValue,
 			Type:  &hmExact,
 		}
@@ -396,18 +1297,59 @@ func toHTTPRouteMatch(ip ingressPath, path *field.Path) (*gatewayv1beta1.HTTPRou
 	return match, nil
 }
 
-func toBackendRef(ib networkingv1.IngressBackend, path *field.Path) (*gatewayv1beta1.BackendRef, *field.Error) {
+// toBackendRef converts an Ingress backend to a BackendRef. Named Service
+// ports are resolved to their number using the given Services. When the
+// Service is unknown, the port is left unset and a warning is returned.
+// Resource backends are converted to a BackendRef of their group and kind,
+// whose support is implementation specific, along with a warning. Service
+// backends are converted to a BackendRef of the given kind, whose named
+// ports can only be resolved when it's a core Service.
+func toBackendRef(ib networkingv1.IngressBackend, ingress types.NamespacedName, services map[types.NamespacedName]corev1.Service, kind backendKind, path *field.Path) (*gatewayv1beta1.BackendRef, *Warning, *field.Error) {
 	if ib.Service != nil {
-		if ib.Service.Port.Name != "" {
-			fieldPath := path.Child("service", "port")
-			return nil, field.Invalid(fieldPath, "name", fmt.Sprintf("named ports not supported: %s", ib.Service.Port.Name))
-		}
-		return &gatewayv1beta1.BackendRef{
+		backendRef := &gatewayv1beta1.BackendRef{
 			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
 				Name: gatewayv1beta1.ObjectName(ib.Service.Name),
-				Port: (*gatewayv1beta1.PortNumber)(&ib.Service.Port.Number),
 			},
-		}, nil
+		}
+		if !kind.isService() {
+			backendRef.Group = (*gatewayv1beta1.Group)(&kind.group)
+			backendRef.Kind = (*gatewayv1beta1.Kind)(&kind.kind)
+		}
+		if ib.Service.Port.Name == "" {
+			backendRef.Port = (*gatewayv1beta1.PortNumber)(&ib.Service.Port.Number)
+			return backendRef, nil, nil
+		}
+
+		fieldPath := path.Child("service", "port", "name")
+		if !kind.isService() {
+			return backendRef, &Warning{
+				Ingress: ingress,
+				Field:   fieldPath,
+				Message: fmt.Sprintf("could not resolve named port %q of backend %s, which is converted to a backendRef of group %q and kind %s; the port of the backendRef must be set manually", ib.Service.Port.Name, ib.Service.Name, kind.group, kind.kind),
+			}, nil
+		}
+		svc, ok := services[types.NamespacedName{Namespace: ingress.Namespace, Name: ib.Service.Name}]
+		if !ok {
+			return backendRef, &Warning{
+				Ingress: ingress,
+				Field:   fieldPath,
+				Message: fmt.Sprintf("could not resolve named port %q, Service %s was not found; the port of the backendRef must be set manually", ib.Service.Port.Name, ib.Service.Name),
+			}, nil
+		}
+		for _, port := range svc.Spec.Ports {
+			if port.Name == ib.Service.Port.Name {
+				backendRef.Port = (*gatewayv1beta1.PortNumber)(&port.Port)
+				return backendRef, nil, nil
+			}
+		}
+		return nil, nil, field.NotFound(fieldPath, fmt.Sprintf("port %s of Service %s", ib.Service.Port.Name, ib.Service.Name))
+	}
+	if ib.Resource == nil {
+		return nil, nil, field.Required(path, "a service or resource backend is required")
+	}
+	group := ""
+	if ib.Resource.APIGroup != nil {
+		group = *ib.Resource.APIGroup
 	}
 	return &gatewayv1beta1.BackendRef{
 		BackendObjectReference: gatewayv1beta1.BackendObjectReference{
@@ -415,56 +1357,118 @@ func toBackendRef(ib networkingv1.IngressBackend, path *field.Path) (*gatewayv1b
 			Kind:  (*gatewayv1beta1.Kind)(&ib.Resource.Kind),
 			Name:  gatewayv1beta1.ObjectName(ib.Resource.Name),
 		},
+	}, &Warning{
+		Ingress: ingress,
+		Field:   path.Child("resource"),
+		Message: fmt.Sprintf("the %s backend is converted to a backendRef of group %q and kind %s, which must be supported by the Gateway API implementation", ib.Resource.Name, group, ib.Resource.Kind),
 	}, nil
 }
 
+// servicesByName indexes Services by their namespace/name.
+func servicesByName(services []corev1.Service) map[types.NamespacedName]corev1.Service {
+	byName := make(map[types.NamespacedName]corev1.Service, len(services))
+	for _, svc := range services {
+		byName[types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}] = svc
+	}
+	return byName
+}
+
+// stripPrefixPathRegex matches paths stripping their leading segments with
+// the ingress-nginx rewrite-target idiom, e.g. "/api/v1(/|$)(.*)" along with
+// a "/$2" rewrite-target strips the "/api/v1" prefix.
+var stripPrefixPathRegex = regexp.MustCompile(`^((?:/[A-Za-z0-9_~%-]+)+)\(/\|\$\)\(\.\*\)$`)
+
+// strippedPrefix returns the path prefix stripped by an ingress-nginx
+// rewrite-target, and whether it could be statically determined.
+func strippedPrefix(path, rewriteTarget string) (string, bool) {
+	if rewriteTarget != "/$2" {
+		return "", false
+	}
+	m := stripPrefixPathRegex.FindStringSubmatch(path)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// validateHost checks that the host of an Ingress rule can be used as an
+// HTTPRoute hostname: a DNS subdomain, optionally prefixed by a single "*."
+// wildcard label. IP addresses aren't allowed. Empty and bare "*" hosts match
+// all hosts and are accepted. Hosts are validated once normalized by
+// normalizeHosts.
+func validateHost(host string, fieldPath *field.Path) *field.Error {
+	if host == "" || host == "*" {
+		return nil
+	}
+	if net.ParseIP(host) != nil {
+		return field.Invalid(fieldPath, host, "IP addresses are not allowed as HTTPRoute hostnames")
+	}
+	var msgs []string
+	if strings.HasPrefix(host, "*.") {
+		msgs = validation.IsWildcardDNS1123Subdomain(host)
+	} else {
+		msgs = validation.IsDNS1123Subdomain(host)
+	}
+	if len(msgs) > 0 {
+		return field.Invalid(fieldPath, host, strings.Join(msgs, ", "))
+	}
+	return nil
+}
+
+// defaultBackendRouteName returns the name of the HTTPRoute of the default
+// backend of an Ingress.
+func defaultBackendRouteName(ingress string) string {
+	return ingress + "-default-backend"
+}
+
+// nameFromHost returns the name of the resources generated for a host.
+// Wildcard hosts are prefixed by "wildcard" so that their names don't collide
+// with the ones of the host without wildcard.
 func nameFromHost(host string) string {
+	if wildcard := strings.TrimPrefix(host, "*."); wildcard != host {
+		return "wildcard-" + nameFromHost(wildcard)
+	}
 	// replace all special chars with -
 	reg, _ := regexp.Compile("[^a-zA-Z0-9]+")
 	step1 := reg.ReplaceAllString(host, "-")
 	// remove all - at start of string
 	reg2, _ := regexp.Compile("^[^a-zA-Z0-9]+")
 	step2 := reg2.ReplaceAllString(step1, "")
-	// if nothing left, return "all-hosts"
-	if len(host) == 0 {
+	// if nothing left, such as for the "*" host, return "all-hosts"
+	if len(step2) == 0 {
 		return "all-hosts"
 	}
 	return step2
 }
 
-func getExtra(ingress networkingv1.Ingress) (*extra, field.ErrorList) {
+// getExtra reads the annotations of the Ingress, with the providers selected
+// by name, or the ones whose annotations the Ingress has when none is.
+func getExtra(ingress networkingv1.Ingress, configMaps map[types.NamespacedName]corev1.ConfigMap, selectedProviders []string) (*extra, field.ErrorList) {
 	var errs field.ErrorList
-	var err error
 
 	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
 
 	e := &extra{}
-	if c := ingress.Annotations["nginx.ingress.kubernetes.io/canary"]; c == "true" {
-		e.canary = &canary{enable: true}
-		if cHeader := ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header"]; cHeader != "" {
-			e.canary.headerKey = cHeader
-			e.canary.headerValue = "always"
-		}
-		if cHeaderVal := ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header-value"]; cHeaderVal != "" {
-			e.canary.headerValue = cHeaderVal
-		}
-		if cHeaderRegex := ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header-pattern"]; cHeaderRegex != "" {
-			e.canary.headerValue = cHeaderRegex
-			e.canary.headerRegexMatch = true
-		}
-		if cHeaderWeight := ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"]; cHeaderWeight != "" {
-			e.canary.weight, err = strconv.Atoi(cHeaderWeight)
-			if err != nil {
-				errs = append(errs, field.TypeInvalid(fieldPath, "nginx.ingress.kubernetes.io/canary-weight", err.Error()))
-			}
-			e.canary.weightTotal = 100
-		}
-		if cHeaderWeightTotal := ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight-total"]; cHeaderWeightTotal != "" {
-			e.canary.weightTotal, err = strconv.Atoi(cHeaderWeightTotal)
-			if err != nil {
-				errs = append(errs, field.TypeInvalid(fieldPath, "nginx.ingress.kubernetes.io/canary-weight-total", err.Error()))
-			}
+	// external-dns weighted records split traffic across clusters, which
+	// Gateway API can't express as backendRef weights only apply in-cluster.
+	var dnsWeights []string
+	for key, value := range ingress.Annotations {
+		if strings.HasPrefix(key, "external-dns.alpha.kubernetes.io/") && strings.HasSuffix(key, "-weight") {
+			dnsWeights = append(dnsWeights, fmt.Sprintf("%s=%s", strings.TrimPrefix(key, "external-dns.alpha.kubernetes.io/"), value))
 		}
 	}
+	if len(dnsWeights) > 0 {
+		sort.Strings(dnsWeights)
+		e.warnings = append(e.warnings, Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key("external-dns.alpha.kubernetes.io/set-identifier"),
+			Message: fmt.Sprintf("cross-cluster DNS weights cannot be represented by Gateway API in-cluster routing, the weighted DNS records must be kept: set-identifier %q, %s",
+				ingress.Annotations["external-dns.alpha.kubernetes.io/set-identifier"], strings.Join(dnsWeights, ", ")),
+		})
+	}
+	logAnnotations(ingress, selectedProviders)
+	for _, p := range enabledProviders(ingress, selectedProviders) {
+		errs = append(errs, p.addExtra(ingress, configMaps, e)...)
+	}
 	return e, errs
 }
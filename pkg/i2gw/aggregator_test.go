@@ -18,22 +18,29 @@ package i2gw
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 	iPrefix := networkingv1.PathTypePrefix
 	iExact := networkingv1.PathTypeExact
-	gPathPrefix := gatewayv1beta1.PathMatchPathPrefix
-	gExact := gatewayv1beta1.PathMatchExact
+	iImplementationSpecific := networkingv1.PathTypeImplementationSpecific
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+	gExact := gatewayv1.PathMatchExact
+	hmExact := gatewayv1.HeaderMatchExact
 
 	testCases := []struct {
 		name             string
@@ -80,7 +87,7 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 				Listeners: []gatewayv1beta1.Listener{{
 					Name:     "example-com-http",
 					Port:     80,
-					Protocol: gatewayv1beta1.HTTPProtocolType,
+					Protocol: gatewayv1.HTTPProtocolType,
 					Hostname: gatewayHostnamePtr("example.com"),
 				}},
 			},
@@ -90,7 +97,8 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 			Spec: gatewayv1beta1.HTTPRouteSpec{
 				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
 					ParentRefs: []gatewayv1beta1.ParentReference{{
-						Name: "example",
+						Name:        "example",
+						SectionName: gatewaySectionNamePtr("example-com-http"),
 					}},
 				},
 				Hostnames: []gatewayv1beta1.Hostname{"example.com"},
@@ -150,12 +158,12 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 				Listeners: []gatewayv1beta1.Listener{{
 					Name:     "example-com-http",
 					Port:     80,
-					Protocol: gatewayv1beta1.HTTPProtocolType,
+					Protocol: gatewayv1.HTTPProtocolType,
 					Hostname: gatewayHostnamePtr("example.com"),
 				}, {
 					Name:     "example-com-https",
 					Port:     443,
-					Protocol: gatewayv1beta1.HTTPSProtocolType,
+					Protocol: gatewayv1.HTTPSProtocolType,
 					Hostname: gatewayHostnamePtr("example.com"),
 					TLS: &gatewayv1beta1.GatewayTLSConfig{
 						CertificateRefs: []gatewayv1beta1.SecretObjectReference{{
@@ -170,7 +178,11 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 			Spec: gatewayv1beta1.HTTPRouteSpec{
 				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
 					ParentRefs: []gatewayv1beta1.ParentReference{{
-						Name: "example",
+						Name:        "example",
+						SectionName: gatewaySectionNamePtr("example-com-http"),
+					}, {
+						Name:        "example",
+						SectionName: gatewaySectionNamePtr("example-com-https"),
 					}},
 				},
 				Hostnames: []gatewayv1beta1.Hostname{"example.com"},
@@ -234,7 +246,7 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 				Listeners: []gatewayv1beta1.Listener{{
 					Name:     "example-net-http",
 					Port:     80,
-					Protocol: gatewayv1beta1.HTTPProtocolType,
+					Protocol: gatewayv1.HTTPProtocolType,
 					Hostname: gatewayHostnamePtr("example.net"),
 				}},
 			},
@@ -244,7 +256,8 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 			Spec: gatewayv1beta1.HTTPRouteSpec{
 				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
 					ParentRefs: []gatewayv1beta1.ParentReference{{
-						Name: "example-proxy",
+						Name:        "example-proxy",
+						SectionName: gatewaySectionNamePtr("example-net-http"),
 					}},
 				},
 				Hostnames: []gatewayv1beta1.Hostname{"example.net"},
@@ -286,6 +299,355 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 				},
 			},
 		}},
+	}, {
+		name: "ingress stripping a path prefix",
+		ingresses: []networkingv1.Ingress{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "api",
+				Namespace:   "test",
+				Annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$2"},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "api.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/api/v1(/|$)(.*)",
+								PathType: &iImplementationSpecific,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "api",
+										Port: networkingv1.ServiceBackendPort{
+											Number: 8080,
+										},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}},
+		expectGateways: []gatewayv1beta1.Gateway{{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			Spec: gatewayv1beta1.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []gatewayv1beta1.Listener{{
+					Name:     "api-example-com-http",
+					Port:     80,
+					Protocol: gatewayv1.HTTPProtocolType,
+					Hostname: gatewayHostnamePtr("api.example.com"),
+				}},
+			},
+		}},
+		expectHTTPRoutes: []gatewayv1beta1.HTTPRoute{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-example-com", Namespace: "test"},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+					ParentRefs: []gatewayv1beta1.ParentReference{{
+						Name:        "nginx",
+						SectionName: gatewaySectionNamePtr("api-example-com-http"),
+					}},
+				},
+				Hostnames: []gatewayv1beta1.Hostname{"api.example.com"},
+				Rules: []gatewayv1beta1.HTTPRouteRule{{
+					Matches: []gatewayv1beta1.HTTPRouteMatch{{
+						Path: &gatewayv1beta1.HTTPPathMatch{
+							Type:  &gPathPrefix,
+							Value: stringPtr("/api/v1"),
+						},
+					}},
+					Filters: []gatewayv1beta1.HTTPRouteFilter{{
+						Type: gatewayv1.HTTPRouteFilterURLRewrite,
+						URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{
+							Path: &gatewayv1beta1.HTTPPathModifier{
+								Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+								ReplacePrefixMatch: stringPtr("/"),
+							},
+						},
+					}},
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "api",
+								Port: portNumberPtr(8080),
+							},
+						},
+					}},
+				}},
+			},
+		}},
+	}, {
+		name: "canary by header and weight",
+		ingresses: []networkingv1.Ingress{{
+			ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "production",
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "canary",
+				Namespace: "test",
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/canary":                 "true",
+					"nginx.ingress.kubernetes.io/canary-by-header":       "X-Canary",
+					"nginx.ingress.kubernetes.io/canary-by-header-value": "yes",
+					"nginx.ingress.kubernetes.io/canary-weight":          "20",
+				},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "canary",
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}},
+		expectGateways: []gatewayv1beta1.Gateway{{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			Spec: gatewayv1beta1.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []gatewayv1beta1.Listener{{
+					Name:     "example-com-http",
+					Port:     80,
+					Protocol: gatewayv1.HTTPProtocolType,
+					Hostname: gatewayHostnamePtr("example.com"),
+				}},
+			},
+		}},
+		expectHTTPRoutes: []gatewayv1beta1.HTTPRoute{{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+					ParentRefs: []gatewayv1beta1.ParentReference{{
+						Name:        "nginx",
+						SectionName: gatewaySectionNamePtr("example-com-http"),
+					}},
+				},
+				Hostnames: []gatewayv1beta1.Hostname{"example.com"},
+				Rules: []gatewayv1beta1.HTTPRouteRule{{
+					Matches: []gatewayv1beta1.HTTPRouteMatch{{
+						Path: &gatewayv1beta1.HTTPPathMatch{
+							Type:  &gPathPrefix,
+							Value: stringPtr("/"),
+						},
+						Headers: []gatewayv1beta1.HTTPHeaderMatch{{
+							Type:  &hmExact,
+							Name:  "X-Canary",
+							Value: "yes",
+						}},
+					}},
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "canary",
+								Port: portNumberPtr(80),
+							},
+						},
+					}},
+				}, {
+					Matches: []gatewayv1beta1.HTTPRouteMatch{{
+						Path: &gatewayv1beta1.HTTPPathMatch{
+							Type:  &gPathPrefix,
+							Value: stringPtr("/"),
+						},
+					}},
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "production",
+								Port: portNumberPtr(80),
+							},
+							Weight: int32Ptr(80),
+						},
+					}, {
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "canary",
+								Port: portNumberPtr(80),
+							},
+							Weight: int32Ptr(20),
+						},
+					}},
+				}},
+			},
+		}},
+	}, {
+		name: "canary by header flag and weight",
+		ingresses: []networkingv1.Ingress{{
+			ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "production",
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "canary",
+				Namespace: "test",
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/canary":           "true",
+					"nginx.ingress.kubernetes.io/canary-by-header": "X-Canary",
+					"nginx.ingress.kubernetes.io/canary-weight":    "20",
+				},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "canary",
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}},
+		expectGateways: []gatewayv1beta1.Gateway{{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			Spec: gatewayv1beta1.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []gatewayv1beta1.Listener{{
+					Name:     "example-com-http",
+					Port:     80,
+					Protocol: gatewayv1.HTTPProtocolType,
+					Hostname: gatewayHostnamePtr("example.com"),
+				}},
+			},
+		}},
+		expectHTTPRoutes: []gatewayv1beta1.HTTPRoute{{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+					ParentRefs: []gatewayv1beta1.ParentReference{{
+						Name:        "nginx",
+						SectionName: gatewaySectionNamePtr("example-com-http"),
+					}},
+				},
+				Hostnames: []gatewayv1beta1.Hostname{"example.com"},
+				Rules: []gatewayv1beta1.HTTPRouteRule{{
+					Matches: []gatewayv1beta1.HTTPRouteMatch{{
+						Path: &gatewayv1beta1.HTTPPathMatch{
+							Type:  &gPathPrefix,
+							Value: stringPtr("/"),
+						},
+						Headers: []gatewayv1beta1.HTTPHeaderMatch{{
+							Type:  &hmExact,
+							Name:  "X-Canary",
+							Value: "always",
+						}},
+					}},
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "canary",
+								Port: portNumberPtr(80),
+							},
+						},
+					}},
+				}, {
+					Matches: []gatewayv1beta1.HTTPRouteMatch{{
+						Path: &gatewayv1beta1.HTTPPathMatch{
+							Type:  &gPathPrefix,
+							Value: stringPtr("/"),
+						},
+						Headers: []gatewayv1beta1.HTTPHeaderMatch{{
+							Type:  &hmExact,
+							Name:  "X-Canary",
+							Value: "never",
+						}},
+					}},
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "production",
+								Port: portNumberPtr(80),
+							},
+						},
+					}},
+				}, {
+					Matches: []gatewayv1beta1.HTTPRouteMatch{{
+						Path: &gatewayv1beta1.HTTPPathMatch{
+							Type:  &gPathPrefix,
+							Value: stringPtr("/"),
+						},
+					}},
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "production",
+								Port: portNumberPtr(80),
+							},
+							Weight: int32Ptr(80),
+						},
+					}, {
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: "canary",
+								Port: portNumberPtr(80),
+							},
+							Weight: int32Ptr(20),
+						},
+					}},
+				}},
+			},
+		}},
 	}}
 
 	for _, tc := range testCases {
@@ -296,7 +658,7 @@ func Test_ingresses2GatewaysAndHttpRoutes(t *testing.T) {
 				aggregator.addIngress(ingress)
 			}
 
-			httpRoutes, gateways, errs := aggregator.toHTTPRoutesAndGateways()
+			httpRoutes, _, gateways, _, errs := aggregator.toHTTPRoutesAndGateways()
 
 			if len(httpRoutes) != len(tc.expectHTTPRoutes) {
 				t.Errorf("Expected %d HTTPRoutes, got %d: %+v", len(tc.expectHTTPRoutes), len(httpRoutes), httpRoutes)
@@ -364,12 +726,173 @@ func gatewayHostnamePtr(s string) *gatewayv1beta1.Hostname {
 	return &h
 }
 
-func Test_getExtra(t *testing.T) {
+func gatewaySectionNamePtr(s string) *gatewayv1beta1.SectionName {
+	n := gatewayv1beta1.SectionName(s)
+	return &n
+}
+
+func objectNamePtr(s string) *gatewayv1beta1.ObjectName {
+	n := gatewayv1beta1.ObjectName(s)
+	return &n
+}
+
+// newNginxIngress returns an Ingress of the nginx class in namespace test,
+// routing the prefix path of host example.com to port 80 of the Service of its
+// name.
+func newNginxIngress(name, path string, annotations map[string]string) networkingv1.Ingress {
+	iPrefix := networkingv1.PathTypePrefix
+	return networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     path,
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func Test_headerVariantRules(t *testing.T) {
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+	hmExact := gatewayv1.HeaderMatchExact
+
+	newIngress := func(name string, annotations map[string]string) networkingv1.Ingress {
+		return newNginxIngress(name, "/", annotations)
+	}
+	newVariant := func(variant string) networkingv1.Ingress {
+		return newIngress("variant-"+variant, map[string]string{
+			"nginx.ingress.kubernetes.io/canary":                 "true",
+			"nginx.ingress.kubernetes.io/canary-by-header":       "X-Variant",
+			"nginx.ingress.kubernetes.io/canary-by-header-value": variant,
+		})
+	}
+	newRule := func(backend string, headers []gatewayv1beta1.HTTPHeaderMatch) gatewayv1beta1.HTTPRouteRule {
+		return gatewayv1beta1.HTTPRouteRule{
+			Matches: []gatewayv1beta1.HTTPRouteMatch{{
+				Path:    &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: stringPtr("/")},
+				Headers: headers,
+			}},
+			BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+				BackendRef: gatewayv1beta1.BackendRef{
+					BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+						Name: gatewayv1beta1.ObjectName(backend),
+						Port: portNumberPtr(80),
+					},
+				},
+			}},
+		}
+	}
+	variantHeader := func(variant string) []gatewayv1beta1.HTTPHeaderMatch {
+		return []gatewayv1beta1.HTTPHeaderMatch{{Type: &hmExact, Name: "X-Variant", Value: variant}}
+	}
+
+	ingresses := []networkingv1.Ingress{
+		newIngress("default", nil),
+		newVariant("c"),
+		newVariant("a"),
+		newVariant("b"),
+	}
+	expectedRules := []gatewayv1beta1.HTTPRouteRule{
+		newRule("variant-a", variantHeader("a")),
+		newRule("variant-b", variantHeader("b")),
+		newRule("variant-c", variantHeader("c")),
+		newRule("default", nil),
+	}
+
+	httpRoutes, _, _, _, _, errs := Ingresses2GatewaysAndHTTPRoutes(ingresses, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	if len(httpRoutes) != 1 {
+		t.Fatalf("Expected 1 HTTPRoute, got %d: %+v", len(httpRoutes), httpRoutes)
+	}
+	if diff := cmp.Diff(expectedRules, httpRoutes[0].Spec.Rules); diff != "" {
+		t.Errorf("Unexpected HTTPRoute rules (-want +got):\n%s", diff)
+	}
+}
+
+func Test_longestPathFirst(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+
+	newPath := func(path string, pathType networkingv1.PathType) networkingv1.HTTPIngressPath {
+		return networkingv1.HTTPIngressPath{
+			Path:     path,
+			PathType: &pathType,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "app",
+					Port: networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		}
+	}
+
 	testCases := []struct {
 		name          string
-		ingress       networkingv1.Ingress
-		expectedExtra *extra
-		expectedError field.ErrorList
+		paths         []networkingv1.HTTPIngressPath
+		expectedPaths []string
+	}{{
+		name:          "longer prefix first",
+		paths:         []networkingv1.HTTPIngressPath{newPath("/api", iPrefix), newPath("/api/v1", iPrefix)},
+		expectedPaths: []string{"PathPrefix /api/v1", "PathPrefix /api"},
+	}, {
+		name:          "exact paths first",
+		paths:         []networkingv1.HTTPIngressPath{newPath("/", iPrefix), newPath("/api/v1/users", iPrefix), newPath("/api", iExact)},
+		expectedPaths: []string{"Exact /api", "PathPrefix /api/v1/users", "PathPrefix /"},
+	}, {
+		name:          "equally long paths keep their order",
+		paths:         []networkingv1.HTTPIngressPath{newPath("/foo", iPrefix), newPath("/bar", iPrefix), newPath("/", iPrefix)},
+		expectedPaths: []string{"PathPrefix /foo", "PathPrefix /bar", "PathPrefix /"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: stringPtr("nginx"),
+					Rules: []networkingv1.IngressRule{{
+						Host: "example.com",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{Paths: tc.paths},
+						},
+					}},
+				},
+			}
+			httpRoutes, _, _, _, _, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			var paths []string
+			for _, rule := range httpRoutes[0].Spec.Rules {
+				paths = append(paths, fmt.Sprintf("%s %s", *rule.Matches[0].Path.Type, *rule.Matches[0].Path.Value))
+			}
+			if diff := cmp.Diff(tc.expectedPaths, paths); diff != "" {
+				t.Errorf("Unexpected order of the HTTPRoute paths (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_getExtra(t *testing.T) {
+	testCases := []struct {
+		name             string
+		ingress          networkingv1.Ingress
+		expectedExtra    *extra
+		expectedError    field.ErrorList
+		expectedWarnings []Warning
 	}{
 		{
 			name: "actually get weights",
@@ -386,6 +909,7 @@ func Test_getExtra(t *testing.T) {
 				canary: &canary{
 					enable:      true,
 					weight:      50,
+					weighted:    true,
 					weightTotal: 100,
 				},
 			},
@@ -404,6 +928,7 @@ func Test_getExtra(t *testing.T) {
 				canary: &canary{
 					enable:      true,
 					weight:      50,
+					weighted:    true,
 					weightTotal: 100,
 				},
 			},
@@ -422,12 +947,133 @@ func Test_getExtra(t *testing.T) {
 			expectedError: field.ErrorList{field.TypeInvalid(field.NewPath(""), "", "")},
 		},
 		{
-			name: "errors on non integer weight total",
+			name: "errors on non integer weight total",
+			ingress: networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"nginx.ingress.kubernetes.io/canary":              "true",
+						"nginx.ingress.kubernetes.io/canary-weight-total": "50.5",
+					},
+				},
+			},
+			expectedExtra: &extra{},
+			expectedError: field.ErrorList{field.TypeInvalid(field.NewPath(""), "", "")},
+		},
+		{
+			name: "canary by header value",
+			ingress: networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"nginx.ingress.kubernetes.io/canary":                 "true",
+						"nginx.ingress.kubernetes.io/canary-by-header":       "X-Canary",
+						"nginx.ingress.kubernetes.io/canary-by-header-value": "yes",
+						// Ignored as a header value is set.
+						"nginx.ingress.kubernetes.io/canary-by-header-pattern": "^y",
+					},
+				},
+			},
+			expectedExtra: &extra{
+				canary: &canary{
+					enable:      true,
+					headerKey:   "X-Canary",
+					headerValue: "yes",
+				},
+			},
+		},
+		{
+			name: "canary by header pattern",
+			ingress: networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pattern",
+					Namespace: "test",
+					Annotations: map[string]string{
+						"nginx.ingress.kubernetes.io/canary":                   "true",
+						"nginx.ingress.kubernetes.io/canary-by-header":         "X-Canary",
+						"nginx.ingress.kubernetes.io/canary-by-header-pattern": "^(yes|true)$",
+					},
+				},
+			},
+			expectedExtra: &extra{
+				canary: &canary{
+					enable:           true,
+					headerKey:        "X-Canary",
+					headerValue:      "^(yes|true)$",
+					headerRegexMatch: true,
+				},
+			},
+			expectedWarnings: []Warning{{
+				Ingress: types.NamespacedName{Namespace: "test", Name: "pattern"},
+				Field:   field.NewPath("pattern", "metadata", "annotations").Key("nginx.ingress.kubernetes.io/canary-by-header-pattern"),
+				Message: "converted to a RegularExpression header match, which is not supported by all Gateway API implementations and whose regular expression syntax is implementation specific: ^(yes|true)$",
+			}},
+		},
+		{
+			name: "warns on nginx snippets",
+			ingress: networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "snippets",
+					Namespace: "test",
+					Annotations: map[string]string{
+						"nginx.ingress.kubernetes.io/configuration-snippet": "rewrite ^/old/(.*)$ /new/$1 break;",
+						"nginx.ingress.kubernetes.io/server-snippet":        "location /internal { deny all; }",
+					},
+				},
+			},
+			expectedExtra: &extra{},
+			expectedWarnings: []Warning{{
+				Ingress: types.NamespacedName{Namespace: "test", Name: "snippets"},
+				Field:   field.NewPath("snippets", "metadata", "annotations").Key("nginx.ingress.kubernetes.io/configuration-snippet"),
+				Message: "nginx snippets cannot be represented in Gateway API and must be ported manually:\nrewrite ^/old/(.*)$ /new/$1 break;",
+			}, {
+				Ingress: types.NamespacedName{Namespace: "test", Name: "snippets"},
+				Field:   field.NewPath("snippets", "metadata", "annotations").Key("nginx.ingress.kubernetes.io/server-snippet"),
+				Message: "nginx snippets cannot be represented in Gateway API and must be ported manually:\nlocation /internal { deny all; }",
+			}},
+		},
+		{
+			name: "warns on cross-cluster dns weights",
+			ingress: networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "weighted",
+					Namespace: "test",
+					Annotations: map[string]string{
+						"external-dns.alpha.kubernetes.io/hostname":       "example.com",
+						"external-dns.alpha.kubernetes.io/set-identifier": "us-east-1",
+						"external-dns.alpha.kubernetes.io/aws-weight":     "20",
+					},
+				},
+			},
+			expectedExtra: &extra{},
+			expectedWarnings: []Warning{{
+				Ingress: types.NamespacedName{Namespace: "test", Name: "weighted"},
+				Field:   field.NewPath("weighted", "metadata", "annotations").Key("external-dns.alpha.kubernetes.io/set-identifier"),
+				Message: "cross-cluster DNS weights cannot be represented by Gateway API in-cluster routing, the weighted DNS records must be kept: set-identifier \"us-east-1\", aws-weight=20",
+			}},
+		},
+		{
+			name: "warns on websocket timeout",
+			ingress: networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "websocket",
+					Namespace: "test",
+					Annotations: map[string]string{
+						"haproxy.org/timeout-tunnel": "1h",
+					},
+				},
+			},
+			expectedExtra: &extra{},
+			expectedWarnings: []Warning{{
+				Ingress: types.NamespacedName{Namespace: "test", Name: "websocket"},
+				Field:   field.NewPath("websocket", "metadata", "annotations").Key("haproxy.org/timeout-tunnel"),
+				Message: "websocket idle timeout of 1h0m0s cannot be represented by HTTPRoute timeouts, which limit the duration of requests; configure an equivalent idle timeout on the Gateway implementation",
+			}},
+		},
+		{
+			name: "errors on invalid websocket timeout",
 			ingress: networkingv1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{
-						"nginx.ingress.kubernetes.io/canary":              "true",
-						"nginx.ingress.kubernetes.io/canary-weight-total": "50.5",
+						"haproxy.org/timeout-tunnel": "forever",
 					},
 				},
 			},
@@ -439,7 +1085,7 @@ func Test_getExtra(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 
-			actualExtra, errs := getExtra(tc.ingress)
+			actualExtra, errs := getExtra(tc.ingress, nil, nil)
 			if len(errs) != len(tc.expectedError) {
 				t.Fatalf("expected %d errors, got %d", len(tc.expectedError), len(errs))
 			}
@@ -448,8 +1094,15 @@ func Test_getExtra(t *testing.T) {
 				return
 			}
 
+			if diff := cmp.Diff(tc.expectedWarnings, actualExtra.warnings, cmpFieldPath); diff != "" {
+				t.Fatalf("getExtra() warnings mismatch (-want +got):\n%s", diff)
+			}
+
 			actualCanary := actualExtra.canary
 			expectedCanary := tc.expectedExtra.canary
+			if expectedCanary == nil {
+				return
+			}
 
 			if diff := cmp.Diff(*actualCanary, *expectedCanary, cmp.AllowUnexported(canary{})); diff != "" {
 				t.Fatalf("getExtra() mismatch (-want +got):\n%s", diff)
@@ -458,7 +1111,28 @@ func Test_getExtra(t *testing.T) {
 	}
 }
 
+// cmpFieldPath compares field paths by their string representation, as
+// field.Path has unexported fields.
+var cmpFieldPath = cmp.Comparer(func(a, b *field.Path) bool {
+	return a.String() == b.String()
+})
+
 func Test_ingressRuleGroup_calculateBackendRefWeight(t *testing.T) {
+	bucketPath := func(ingress, bucket string, c *canary) ingressPath {
+		return ingressPath{
+			ingress: types.NamespacedName{Namespace: "test", Name: ingress},
+			path: networkingv1.HTTPIngressPath{
+				Backend: networkingv1.IngressBackend{
+					Resource: &corev1.TypedLocalObjectReference{
+						Name:     bucket,
+						Kind:     "StorageBucket",
+						APIGroup: stringPtr("vendor.example.com"),
+					},
+				},
+			},
+			extra: &extra{canary: c},
+		}
+	}
 	testCases := []struct {
 		name                string
 		paths               []ingressPath
@@ -466,7 +1140,7 @@ func Test_ingressRuleGroup_calculateBackendRefWeight(t *testing.T) {
 		expectedErrors      field.ErrorList
 	}{
 		{
-			name: "respect weight boundaries",
+			name: "weight over the total",
 			paths: []ingressPath{
 				{
 					path: networkingv1.HTTPIngressPath{
@@ -494,10 +1168,7 @@ func Test_ingressRuleGroup_calculateBackendRefWeight(t *testing.T) {
 					},
 				},
 			},
-			expectedBackendRefs: []gatewayv1beta1.HTTPBackendRef{
-				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(100)}},
-				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(0)}},
-			},
+			expectedErrors: field.ErrorList{field.Invalid(field.NewPath(""), "", "")},
 		},
 		{
 			name: "default total weight",
@@ -568,15 +1239,78 @@ func Test_ingressRuleGroup_calculateBackendRefWeight(t *testing.T) {
 				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(150)}},
 			},
 		},
+		{
+			name: "three-way split",
+			paths: []ingressPath{
+				bucketPath("prod", "prod", nil),
+				bucketPath("canary-a", "canary-a", &canary{enable: true, weight: 20, weighted: true, weightTotal: 100}),
+				bucketPath("canary-b", "canary-b", &canary{enable: true, weight: 30, weighted: true, weightTotal: 100}),
+			},
+			expectedBackendRefs: []gatewayv1beta1.HTTPBackendRef{
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(50)}},
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(20)}},
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(30)}},
+			},
+		},
+		{
+			name: "canaries of the same service are summed",
+			paths: []ingressPath{
+				bucketPath("prod", "prod", nil),
+				bucketPath("canary-a", "canary", &canary{enable: true, weight: 10, weighted: true, weightTotal: 100}),
+				bucketPath("canary-b", "canary", &canary{enable: true, weight: 15, weighted: true, weightTotal: 100}),
+			},
+			expectedBackendRefs: []gatewayv1beta1.HTTPBackendRef{
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(75)}},
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(25)}},
+			},
+		},
+		{
+			name: "canary with a zero weight",
+			paths: []ingressPath{
+				bucketPath("prod", "prod", nil),
+				bucketPath("canary-a", "canary-a", &canary{enable: true, weight: 40, weighted: true, weightTotal: 100}),
+				bucketPath("canary-b", "canary-b", &canary{enable: true, weighted: true, weightTotal: 100}),
+			},
+			expectedBackendRefs: []gatewayv1beta1.HTTPBackendRef{
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(60)}},
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(40)}},
+				{BackendRef: gatewayv1beta1.BackendRef{Weight: int32Ptr(0)}},
+			},
+		},
+		{
+			name: "canaries over the total",
+			paths: []ingressPath{
+				bucketPath("prod", "prod", nil),
+				bucketPath("canary-a", "canary-a", &canary{enable: true, weight: 60, weighted: true, weightTotal: 100}),
+				bucketPath("canary-b", "canary-b", &canary{enable: true, weight: 50, weighted: true, weightTotal: 100}),
+			},
+			expectedErrors: field.ErrorList{field.Invalid(field.NewPath(""), "", "")},
+		},
+		{
+			name: "canary without weight",
+			paths: []ingressPath{
+				bucketPath("prod", "prod", nil),
+				bucketPath("canary-a", "canary-a", &canary{enable: true, weight: 20, weighted: true, weightTotal: 100}),
+				bucketPath("canary-b", "canary-b", &canary{enable: true}),
+			},
+			expectedErrors: field.ErrorList{field.Invalid(field.NewPath(""), "", "")},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 
 			var irg ingressRuleGroup
-			actualBackendRefs, errs := irg.calculateBackendRefWeight(tc.paths)
+			actualBackendRefs, _, errs := irg.calculateBackendRefWeight(tc.paths)
 			if len(errs) != len(tc.expectedErrors) {
 				t.Fatalf("expected %d errors, got %d", len(tc.expectedErrors), len(errs))
 			}
+			for _, err := range errs {
+				for _, ingress := range tc.paths {
+					if !strings.Contains(err.Error(), ingress.ingress.String()) {
+						t.Errorf("expected error %q to name Ingress %s", err, ingress.ingress)
+					}
+				}
+			}
 
 			if len(actualBackendRefs) != len(tc.expectedBackendRefs) {
 				t.Fatalf("expected %d backend refs, got %d", len(tc.expectedBackendRefs), len(actualBackendRefs))
@@ -590,3 +1324,563 @@ func Test_ingressRuleGroup_calculateBackendRefWeight(t *testing.T) {
 		})
 	}
 }
+
+func Test_strippedPrefix(t *testing.T) {
+	testCases := []struct {
+		path           string
+		rewriteTarget  string
+		expectedPrefix string
+		expectedOK     bool
+	}{
+		{path: "/api(/|$)(.*)", rewriteTarget: "/$2", expectedPrefix: "/api", expectedOK: true},
+		{path: "/api/v1/users(/|$)(.*)", rewriteTarget: "/$2", expectedPrefix: "/api/v1/users", expectedOK: true},
+		{path: "/api(/|$)(.*)", rewriteTarget: "/v2/$2"},
+		{path: "/(api|web)(/|$)(.*)", rewriteTarget: "/$2"},
+		{path: "/api", rewriteTarget: "/"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path+" "+tc.rewriteTarget, func(t *testing.T) {
+			prefix, ok := strippedPrefix(tc.path, tc.rewriteTarget)
+			if ok != tc.expectedOK || prefix != tc.expectedPrefix {
+				t.Errorf("strippedPrefix(%q, %q) = %q, %v, expected %q, %v", tc.path, tc.rewriteTarget, prefix, ok, tc.expectedPrefix, tc.expectedOK)
+			}
+		})
+	}
+}
+
+func Test_toBackendRef(t *testing.T) {
+	ingress := types.NamespacedName{Namespace: "test", Name: "example"}
+	services := map[types.NamespacedName]corev1.Service{
+		{Namespace: "test", Name: "named"}: {
+			ObjectMeta: metav1.ObjectMeta{Name: "named", Namespace: "test"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "metrics", Port: 9090}, {Name: "http", Port: 8080}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		backend            networkingv1.IngressBackend
+		expectedBackendRef *gatewayv1beta1.BackendRef
+		expectingWarning   bool
+		expectingError     bool
+	}{{
+		name: "numeric port",
+		backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: "numeric",
+				Port: networkingv1.ServiceBackendPort{Number: 3000},
+			},
+		},
+		expectedBackendRef: &gatewayv1beta1.BackendRef{
+			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+				Name: "numeric",
+				Port: portNumberPtr(3000),
+			},
+		},
+	}, {
+		name: "named port resolved from service",
+		backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: "named",
+				Port: networkingv1.ServiceBackendPort{Name: "http"},
+			},
+		},
+		expectedBackendRef: &gatewayv1beta1.BackendRef{
+			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+				Name: "named",
+				Port: portNumberPtr(8080),
+			},
+		},
+	}, {
+		name: "named port of unknown service",
+		backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: "unknown",
+				Port: networkingv1.ServiceBackendPort{Name: "http"},
+			},
+		},
+		expectedBackendRef: &gatewayv1beta1.BackendRef{
+			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+				Name: "unknown",
+			},
+		},
+		expectingWarning: true,
+	}, {
+		name: "named port missing from service",
+		backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: "named",
+				Port: networkingv1.ServiceBackendPort{Name: "grpc"},
+			},
+		},
+		expectingError: true,
+	}, {
+		name: "resource of a custom kind",
+		backend: networkingv1.IngressBackend{
+			Resource: &corev1.TypedLocalObjectReference{
+				APIGroup: stringPtr("storage.example.com"),
+				Kind:     "StorageBucket",
+				Name:     "static-assets",
+			},
+		},
+		expectedBackendRef: &gatewayv1beta1.BackendRef{
+			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+				Group: (*gatewayv1beta1.Group)(stringPtr("storage.example.com")),
+				Kind:  (*gatewayv1beta1.Kind)(stringPtr("StorageBucket")),
+				Name:  "static-assets",
+			},
+		},
+		expectingWarning: true,
+	}, {
+		name:           "neither service nor resource",
+		backend:        networkingv1.IngressBackend{},
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backendRef, warning, err := toBackendRef(tc.backend, ingress, services, backendKind{}, field.NewPath("paths", "backends").Index(0))
+			if tc.expectingError != (err != nil) {
+				t.Fatalf("toBackendRef() error = %v, expecting error: %v", err, tc.expectingError)
+			}
+			if tc.expectingWarning != (warning != nil) {
+				t.Fatalf("toBackendRef() warning = %v, expecting warning: %v", warning, tc.expectingWarning)
+			}
+			if diff := cmp.Diff(tc.expectedBackendRef, backendRef); diff != "" {
+				t.Errorf("toBackendRef() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_hostlessRules(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newRule := func(host, backend string) networkingv1.IngressRule {
+		return networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &iPrefix,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: backend,
+								Port: networkingv1.ServiceBackendPort{Number: 80},
+							},
+						},
+					}},
+				},
+			},
+		}
+	}
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules:            []networkingv1.IngressRule{newRule("foo.example.com", "foo"), newRule("", "fallback")},
+			TLS: []networkingv1.IngressTLS{{
+				Hosts:      []string{"foo.example.com"},
+				SecretName: "foo-cert",
+			}},
+		},
+	}
+
+	httpRoutes, _, _, gateways, _, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+
+	expectedListeners := []gatewayv1beta1.Listener{{
+		Name:     "http",
+		Port:     80,
+		Protocol: gatewayv1.HTTPProtocolType,
+	}, {
+		Name:     "foo-example-com-http",
+		Hostname: gatewayHostnamePtr("foo.example.com"),
+		Port:     80,
+		Protocol: gatewayv1.HTTPProtocolType,
+	}, {
+		Name:     "foo-example-com-https",
+		Hostname: gatewayHostnamePtr("foo.example.com"),
+		Port:     443,
+		Protocol: gatewayv1.HTTPSProtocolType,
+		TLS: &gatewayv1beta1.GatewayTLSConfig{
+			CertificateRefs: []gatewayv1beta1.SecretObjectReference{{Name: "foo-cert"}},
+		},
+	}}
+	if len(gateways) != 1 {
+		t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+	}
+	if diff := cmp.Diff(expectedListeners, gateways[0].Spec.Listeners); diff != "" {
+		t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+	}
+
+	if len(httpRoutes) != 2 {
+		t.Fatalf("Expected 2 HTTPRoutes, got %d: %+v", len(httpRoutes), httpRoutes)
+	}
+	if httpRoutes[0].Name != "all-hosts" || httpRoutes[0].Spec.Hostnames != nil {
+		t.Errorf("Expected HTTPRoute all-hosts without hostnames, got %s with hostnames %v", httpRoutes[0].Name, httpRoutes[0].Spec.Hostnames)
+	}
+	if httpRoutes[1].Name != "foo-example-com" || !cmp.Equal(httpRoutes[1].Spec.Hostnames, []gatewayv1beta1.Hostname{"foo.example.com"}) {
+		t.Errorf("Expected HTTPRoute foo-example-com with hostname foo.example.com, got %s with hostnames %v", httpRoutes[1].Name, httpRoutes[1].Spec.Hostnames)
+	}
+}
+
+func Test_parentRefSectionNames(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newRule := func(host string) networkingv1.IngressRule {
+		return networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &iPrefix,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: "app",
+								Port: networkingv1.ServiceBackendPort{Number: 80},
+							},
+						},
+					}},
+				},
+			},
+		}
+	}
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules:            []networkingv1.IngressRule{newRule("foo.example.com"), newRule("bar.example.com"), newRule("")},
+			TLS: []networkingv1.IngressTLS{{
+				Hosts:      []string{"foo.example.com"},
+				SecretName: "foo-cert",
+			}},
+		},
+	}
+
+	httpRoutes, _, _, _, _, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+
+	// The routes of HTTP rules aren't attached to the HTTPS listener.
+	expectedSectionNames := map[string][]string{
+		"all-hosts":       {"http"},
+		"bar-example-com": {"bar-example-com-http"},
+		"foo-example-com": {"foo-example-com-http", "foo-example-com-https"},
+	}
+	sectionNames := map[string][]string{}
+	for _, httpRoute := range httpRoutes {
+		for _, parentRef := range httpRoute.Spec.ParentRefs {
+			if parentRef.Name != "nginx" || parentRef.SectionName == nil {
+				t.Errorf("Expected HTTPRoute %s to be attached to a listener of Gateway nginx, got %+v", httpRoute.Name, parentRef)
+				continue
+			}
+			sectionNames[httpRoute.Name] = append(sectionNames[httpRoute.Name], string(*parentRef.SectionName))
+		}
+	}
+	if diff := cmp.Diff(expectedSectionNames, sectionNames); diff != "" {
+		t.Errorf("Unexpected sectionNames (-want +got):\n%s", diff)
+	}
+}
+
+func Test_hostOnlyRules(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "foo.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: "foo",
+									Port: networkingv1.ServiceBackendPort{Number: 80},
+								},
+							},
+						}},
+					},
+				},
+			}, {
+				Host: "tls-only.example.org",
+			}},
+			TLS: []networkingv1.IngressTLS{{
+				Hosts:      []string{"tls-only.example.org"},
+				SecretName: "tls-only-cert",
+			}},
+		},
+	}
+
+	httpRoutes, _, _, gateways, warnings, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+
+	if len(gateways) != 1 {
+		t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+	}
+	var listeners []string
+	for _, listener := range gateways[0].Spec.Listeners {
+		listeners = append(listeners, string(listener.Name))
+	}
+	expectedListeners := []string{"foo-example-com-http", "tls-only-example-org-http", "tls-only-example-org-https"}
+	if diff := cmp.Diff(expectedListeners, listeners); diff != "" {
+		t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+	}
+
+	if len(httpRoutes) != 1 || httpRoutes[0].Name != "foo-example-com" {
+		t.Errorf("Expected HTTPRoute foo-example-com only, got %+v", httpRoutes)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d: %+v", len(warnings), warnings)
+	}
+	if msg := `no routing rules were found for host "tls-only.example.org"`; !strings.Contains(warnings[0].Message, msg) || warnings[0].Field.String() != "example.spec.rules" {
+		t.Errorf("Expected a warning of example.spec.rules containing %q, got %s: %q", msg, warnings[0].Field, warnings[0].Message)
+	}
+}
+
+func Test_pathConflicts(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, backend string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/api",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: backend,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		ingresses         []networkingv1.Ingress
+		allowConflicts    bool
+		expectNumErrors   int
+		expectNumWarnings int
+		expectBackends    []gatewayv1beta1.ObjectName
+	}{{
+		name:            "different backends",
+		ingresses:       []networkingv1.Ingress{newIngress("first", "api-v1", nil), newIngress("second", "api-v2", nil)},
+		expectNumErrors: 1,
+	}, {
+		name:              "different backends with conflicts allowed",
+		ingresses:         []networkingv1.Ingress{newIngress("first", "api-v1", nil), newIngress("second", "api-v2", nil)},
+		allowConflicts:    true,
+		expectNumWarnings: 1,
+		expectBackends:    []gatewayv1beta1.ObjectName{"api-v1"},
+	}, {
+		name:           "same backend",
+		ingresses:      []networkingv1.Ingress{newIngress("first", "api", nil), newIngress("second", "api", nil)},
+		expectBackends: []gatewayv1beta1.ObjectName{"api"},
+	}, {
+		name: "canary",
+		ingresses: []networkingv1.Ingress{
+			newIngress("first", "api-v1", nil),
+			newIngress("second", "api-v2", map[string]string{"nginx.ingress.kubernetes.io/canary": "true"}),
+		},
+		expectBackends: []gatewayv1beta1.ObjectName{"api-v1", "api-v2"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, _, warnings, errs := Ingresses2GatewaysAndHTTPRoutes(tc.ingresses, nil, nil, nil, tc.allowConflicts)
+			if len(errs) != tc.expectNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if tc.expectNumErrors > 0 {
+				if !strings.Contains(errs[0].Error(), "test/first") || !strings.Contains(errs[0].Error(), "test/second") || !strings.Contains(errs[0].Error(), `"/api"`) {
+					t.Errorf("Expected error to name both Ingresses and the path, got %s", errs[0])
+				}
+				return
+			}
+
+			if len(httpRoutes) != 1 || len(httpRoutes[0].Spec.Rules) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute with 1 rule, got %+v", httpRoutes)
+			}
+			var backends []gatewayv1beta1.ObjectName
+			for _, backendRef := range httpRoutes[0].Spec.Rules[0].BackendRefs {
+				backends = append(backends, backendRef.Name)
+			}
+			if diff := cmp.Diff(tc.expectBackends, backends); diff != "" {
+				t.Errorf("Unexpected backends (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_normalizeIngressClass(t *testing.T) {
+	testCases := []struct {
+		name                string
+		ingressClassName    *string
+		annotations         map[string]string
+		expectedClassName   *string
+		expectedGatewayName string
+	}{{
+		name:                "annotation only",
+		annotations:         map[string]string{networkingv1beta1.AnnotationIngressClass: "nginx"},
+		expectedClassName:   stringPtr("nginx"),
+		expectedGatewayName: "nginx",
+	}, {
+		name:                "field only",
+		ingressClassName:    stringPtr("haproxy"),
+		expectedClassName:   stringPtr("haproxy"),
+		expectedGatewayName: "haproxy",
+	}, {
+		name:                "field preferred over annotation",
+		ingressClassName:    stringPtr("haproxy"),
+		annotations:         map[string]string{networkingv1beta1.AnnotationIngressClass: "nginx"},
+		expectedClassName:   stringPtr("haproxy"),
+		expectedGatewayName: "haproxy",
+	}, {
+		name:                "empty field",
+		ingressClassName:    stringPtr(""),
+		annotations:         map[string]string{networkingv1beta1.AnnotationIngressClass: "nginx"},
+		expectedClassName:   stringPtr("nginx"),
+		expectedGatewayName: "nginx",
+	}, {
+		name:                "no class",
+		expectedGatewayName: "example",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: tc.annotations},
+				Spec:       networkingv1.IngressSpec{IngressClassName: tc.ingressClassName},
+			}
+			normalized := *ingress.DeepCopy()
+			normalizeIngressClass(&normalized)
+			if diff := cmp.Diff(tc.expectedClassName, normalized.Spec.IngressClassName); diff != "" {
+				t.Errorf("Unexpected ingressClassName (-want +got):\n%s", diff)
+			}
+			if gatewayName := getIngressClass(normalized); gatewayName != tc.expectedGatewayName {
+				t.Errorf("getIngressClass() = %s, expected %s", gatewayName, tc.expectedGatewayName)
+			}
+		})
+	}
+}
+
+func Test_wildcardHosts(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newRule := func(host string) networkingv1.IngressRule {
+		return networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &iPrefix,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: "example",
+								Port: networkingv1.ServiceBackendPort{Number: 80},
+							},
+						},
+					}},
+				},
+			},
+		}
+	}
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules:            []networkingv1.IngressRule{newRule("*.example.com"), newRule("example.com"), newRule("*")},
+		},
+	}
+
+	httpRoutes, _, _, gateways, warnings, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	// The bare wildcard host is converted without hostname.
+	if len(warnings) != 1 {
+		t.Errorf("Expected 1 warning, got %d: %+v", len(warnings), warnings)
+	}
+
+	hostnames := map[string][]gatewayv1beta1.Hostname{}
+	for _, route := range httpRoutes {
+		hostnames[route.Name] = route.Spec.Hostnames
+	}
+	expectedHostnames := map[string][]gatewayv1beta1.Hostname{
+		"all-hosts":            nil,
+		"example-com":          {"example.com"},
+		"wildcard-example-com": {"*.example.com"},
+	}
+	if diff := cmp.Diff(expectedHostnames, hostnames); diff != "" {
+		t.Errorf("Unexpected HTTPRoute hostnames (-want +got):\n%s", diff)
+	}
+
+	if len(gateways) != 1 {
+		t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+	}
+	listeners := map[gatewayv1beta1.SectionName]*gatewayv1beta1.Hostname{}
+	for _, l := range gateways[0].Spec.Listeners {
+		listeners[l.Name] = l.Hostname
+	}
+	expectedListeners := map[gatewayv1beta1.SectionName]*gatewayv1beta1.Hostname{
+		"http":                      nil,
+		"example-com-http":          gatewayHostnamePtr("example.com"),
+		"wildcard-example-com-http": gatewayHostnamePtr("*.example.com"),
+	}
+	if diff := cmp.Diff(expectedListeners, listeners); diff != "" {
+		t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+	}
+}
+
+func Test_validateHost(t *testing.T) {
+	testCases := []struct {
+		host           string
+		expectingError bool
+	}{
+		{host: ""},
+		{host: "*"},
+		{host: "example.com"},
+		{host: "*.example.com"},
+		{host: "*.com"},
+		{host: "*.*.example.com", expectingError: true},
+		{host: "foo.*.example.com", expectingError: true},
+		{host: "*example.com", expectingError: true},
+		{host: "example.*", expectingError: true},
+		{host: "Example.com", expectingError: true},
+		{host: "10.0.0.1", expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.host, func(t *testing.T) {
+			err := validateHost(tc.host, field.NewPath("example", "spec", "rules").Index(0).Child("host"))
+			if tc.expectingError != (err != nil) {
+				t.Errorf("validateHost(%q) error = %v, expecting error: %v", tc.host, err, tc.expectingError)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	albListenPortsAnnotation     = "alb.ingress.kubernetes.io/listen-ports"
+	albSSLRedirectAnnotation     = "alb.ingress.kubernetes.io/ssl-redirect"
+	albHealthcheckPathAnnotation = "alb.ingress.kubernetes.io/healthcheck-path"
+	albActionsAnnotationPrefix   = "alb.ingress.kubernetes.io/actions."
+	albCertificateARNAnnotation  = "alb.ingress.kubernetes.io/certificate-arn"
+	// albUseAnnotationPort is the port name of the backends routing to the
+	// action of the actions annotation named after the backend Service.
+	albUseAnnotationPort = "use-annotation"
+)
+
+// albListenPort is a listener of the listen-ports annotation.
+type albListenPort struct {
+	protocol gatewayv1.ProtocolType
+	port     int32
+}
+
+// albAction is an action of an actions annotation: a redirect, converted to a
+// RequestRedirect filter, or a fixed response, which has no equivalent.
+type albAction struct {
+	redirect      *gatewayv1beta1.HTTPRequestRedirectFilter
+	fixedResponse bool
+}
+
+// albActionConfig is the JSON configuration of an actions annotation.
+type albActionConfig struct {
+	Type           string `json:"type"`
+	RedirectConfig *struct {
+		Protocol   string `json:"protocol"`
+		Port       string `json:"port"`
+		Host       string `json:"host"`
+		Path       string `json:"path"`
+		Query      string `json:"query"`
+		StatusCode string `json:"statusCode"`
+	} `json:"redirectConfig"`
+	FixedResponseConfig *struct {
+		StatusCode string `json:"statusCode"`
+	} `json:"fixedResponseConfig"`
+}
+
+// albProvider converts the alb.ingress.kubernetes.io annotations of the AWS
+// Load Balancer controller.
+type albProvider struct{}
+
+func (albProvider) annotationPrefix() string {
+	return "alb.ingress.kubernetes.io/"
+}
+
+func (p albProvider) detect(ingress networkingv1.Ingress) bool {
+	return hasAnnotationPrefix(ingress, p.annotationPrefix())
+}
+
+func (albProvider) inputKinds() []string {
+	return []string{"Ingress"}
+}
+
+func (albProvider) summary() string {
+	return "Listen ports, SSL redirects, actions and health checks of the AWS Load Balancer controller"
+}
+
+func (p albProvider) addExtra(ingress networkingv1.Ingress, _ map[types.NamespacedName]corev1.ConfigMap, e *extra) field.ErrorList {
+	var errs field.ErrorList
+
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+	ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+
+	if value, ok := ingress.Annotations[albListenPortsAnnotation]; ok {
+		ports, err := parseALBListenPorts(value, fieldPath.Key(albListenPortsAnnotation))
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			e.listenPorts = ports
+		}
+	}
+	if value, ok := ingress.Annotations[albSSLRedirectAnnotation]; ok {
+		port, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || port < 1 || port > 65535 {
+			errs = append(errs, field.Invalid(fieldPath.Key(albSSLRedirectAnnotation), value, "must be a port number"))
+		} else {
+			// The AWS Load Balancer controller always redirects with a 301.
+			e.sslRedirect = &sslRedirect{statusCode: 301}
+			if port != haproxyDefaultSSLRedirectPort {
+				e.sslRedirect.port = pointer.Int32(int32(port))
+			}
+		}
+	}
+	if path, ok := ingress.Annotations[albHealthcheckPathAnnotation]; ok {
+		e.warnings = append(e.warnings, Warning{
+			Ingress: ingressName,
+			Field:   fieldPath.Key(albHealthcheckPathAnnotation),
+			Message: fmt.Sprintf("the %s health check path cannot be represented in Gateway API; configure an equivalent health check on the Gateway implementation", path),
+		})
+	}
+
+	var keys []string
+	for key := range ingress.Annotations {
+		if strings.HasPrefix(key, albActionsAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		action, warnings, err := parseALBAction(ingress.Annotations[key], ingressName, fieldPath.Key(key))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		e.warnings = append(e.warnings, warnings...)
+		if e.albActions == nil {
+			e.albActions = map[string]*albAction{}
+		}
+		e.albActions[strings.TrimPrefix(key, albActionsAnnotationPrefix)] = action
+	}
+
+	keys = nil
+	for key := range ingress.Annotations {
+		switch {
+		case key == albListenPortsAnnotation, key == albSSLRedirectAnnotation, key == albHealthcheckPathAnnotation:
+		case strings.HasPrefix(key, albActionsAnnotationPrefix):
+		case strings.HasPrefix(key, p.annotationPrefix()):
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		message := "the annotation of the AWS Load Balancer controller is not converted; it must be configured on the Gateway implementation"
+		if key == albCertificateARNAnnotation {
+			message = fmt.Sprintf("the ACM certificates %s can't be referenced by the certificateRefs of a listener; the hosts of the Ingress get no HTTPS listener without a TLS Secret, which must be configured manually", ingress.Annotations[key])
+		}
+		e.warnings = append(e.warnings, Warning{
+			Ingress: ingressName,
+			Field:   fieldPath.Key(key),
+			Message: message,
+		})
+	}
+	return errs
+}
+
+// parseALBListenPorts parses a listen-ports annotation, a JSON list of
+// objects mapping a protocol to a port, e.g. [{"HTTP": 80}, {"HTTPS": 443}].
+func parseALBListenPorts(value string, path *field.Path) ([]albListenPort, *field.Error) {
+	var entries []map[string]int32
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return nil, field.Invalid(path, value, fmt.Sprintf("must be a JSON list of protocols and ports: %v", err))
+	}
+	var ports []albListenPort
+	seen := map[albListenPort]bool{}
+	for _, entry := range entries {
+		protocols := make([]string, 0, len(entry))
+		for protocol := range entry {
+			protocols = append(protocols, protocol)
+		}
+		sort.Strings(protocols)
+		for _, protocol := range protocols {
+			port := albListenPort{port: entry[protocol]}
+			switch protocol {
+			case "HTTP":
+				port.protocol = gatewayv1.HTTPProtocolType
+			case "HTTPS":
+				port.protocol = gatewayv1.HTTPSProtocolType
+			default:
+				return nil, field.NotSupported(path, protocol, []string{"HTTP", "HTTPS"})
+			}
+			if port.port < 1 || port.port > 65535 {
+				return nil, field.Invalid(path, value, fmt.Sprintf("%d is not a port number", port.port))
+			}
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports, nil
+}
+
+// parseALBAction parses an actions annotation. Fixed responses have no
+// equivalent and are reported as warnings. Redirects are converted to a
+// RequestRedirect filter, the #{protocol}, #{port}, #{host}, #{path} and
+// #{query} placeholders keeping the corresponding part of the request. Other
+// placeholders, and query changes, can't be converted and are reported as
+// warnings.
+func parseALBAction(value string, ingress types.NamespacedName, path *field.Path) (*albAction, []Warning, *field.Error) {
+	var config albActionConfig
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		return nil, nil, field.Invalid(path, value, fmt.Sprintf("must be a JSON action: %v", err))
+	}
+
+	switch config.Type {
+	case "fixed-response":
+		var statusCode string
+		if config.FixedResponseConfig != nil {
+			statusCode = config.FixedResponseConfig.StatusCode
+		}
+		return &albAction{fixedResponse: true}, []Warning{{
+			Ingress: ingress,
+			Field:   path,
+			Message: fmt.Sprintf("the fixed %s response has no Gateway API equivalent; the paths of the action are converted to rules without backends, which don't forward requests, and the response must be configured on the Gateway implementation", statusCode),
+		}}, nil
+	case "redirect":
+	default:
+		return nil, nil, field.NotSupported(path.Child("type"), config.Type, []string{"redirect", "fixed-response"})
+	}
+	if config.RedirectConfig == nil {
+		return nil, nil, field.Required(path.Child("redirectConfig"), "redirect actions must have a redirectConfig")
+	}
+
+	rc := config.RedirectConfig
+	redirect := &gatewayv1beta1.HTTPRequestRedirectFilter{}
+	var unconverted []string
+	switch rc.StatusCode {
+	case "HTTP_301":
+		redirect.StatusCode = pointer.Int(301)
+	case "HTTP_302":
+		redirect.StatusCode = pointer.Int(302)
+	default:
+		return nil, nil, field.NotSupported(path.Child("redirectConfig", "statusCode"), rc.StatusCode, []string{"HTTP_301", "HTTP_302"})
+	}
+	switch protocol := strings.ToLower(rc.Protocol); protocol {
+	case "", "#{protocol}":
+	case "http", "https":
+		redirect.Scheme = pointer.String(protocol)
+	default:
+		unconverted = append(unconverted, "protocol "+rc.Protocol)
+	}
+	if rc.Port != "" && rc.Port != "#{port}" {
+		port, err := strconv.ParseInt(rc.Port, 10, 32)
+		if err != nil || port < 1 || port > 65535 {
+			unconverted = append(unconverted, "port "+rc.Port)
+		} else {
+			redirect.Port = (*gatewayv1.PortNumber)(pointer.Int32(int32(port)))
+		}
+	}
+	if rc.Host != "" && rc.Host != "#{host}" {
+		if strings.Contains(rc.Host, "#{") {
+			unconverted = append(unconverted, "host "+rc.Host)
+		} else {
+			redirect.Hostname = (*gatewayv1.PreciseHostname)(pointer.String(rc.Host))
+		}
+	}
+	if rc.Path != "" && rc.Path != "/#{path}" {
+		if strings.Contains(rc.Path, "#{") {
+			unconverted = append(unconverted, "path "+rc.Path)
+		} else {
+			redirect.Path = &gatewayv1beta1.HTTPPathModifier{
+				Type:            gatewayv1.FullPathHTTPPathModifier,
+				ReplaceFullPath: pointer.String(rc.Path),
+			}
+		}
+	}
+	if rc.Query != "" && rc.Query != "#{query}" {
+		unconverted = append(unconverted, "query "+rc.Query)
+	}
+
+	var warnings []Warning
+	if len(unconverted) > 0 {
+		warnings = append(warnings, Warning{
+			Ingress: ingress,
+			Field:   path,
+			Message: fmt.Sprintf("the %s of the redirect cannot be represented in a RequestRedirect filter and are kept from the request; they must be configured manually", strings.Join(unconverted, ", ")),
+		})
+	}
+	return &albAction{redirect: redirect}, warnings, nil
+}
+
+// albAction returns the action of the actions annotation the backend of the
+// path routes to, or nil when it routes to a Service.
+func (ip ingressPath) albAction() *albAction {
+	backend := ip.path.Backend.Service
+	if ip.extra == nil || backend == nil || backend.Port.Name != albUseAnnotationPort {
+		return nil
+	}
+	return ip.extra.albActions[backend.Name]
+}
+
+// listenPorts returns the listen ports of the rule group, the ones of its
+// first Ingress with listen-ports, along with a warning for every Ingress of
+// the host with other listen ports, whose paths are served on the same ports.
+func (rg *ingressRuleGroup) listenPorts() ([]albListenPort, []Warning) {
+	var ports []albListenPort
+	for _, ir := range rg.rules {
+		if ir.extra != nil && len(ir.extra.listenPorts) > 0 {
+			ports = ir.extra.listenPorts
+			break
+		}
+	}
+	if ports == nil {
+		return nil, nil
+	}
+
+	var warnings []Warning
+	seen := map[types.NamespacedName]bool{}
+	for _, ir := range rg.rules {
+		if seen[ir.ingress] || (ir.extra != nil && sameListenPorts(ir.extra.listenPorts, ports)) {
+			continue
+		}
+		seen[ir.ingress] = true
+		warnings = append(warnings, Warning{
+			Ingress: ir.ingress,
+			Field:   field.NewPath(ir.ingress.Name, "metadata", "annotations").Key(albListenPortsAnnotation),
+			Message: fmt.Sprintf("the paths of host %q are served on the listen ports of other Ingresses of the host, including the paths of this Ingress", rg.host),
+		})
+	}
+	return ports, warnings
+}
+
+func sameListenPorts(a, b []albListenPort) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// albListeners returns the HTTP and HTTPS listeners of the listen ports,
+// copies of the HTTP listener and of the HTTPS listener of the rule group on
+// every port. Listeners on other ports than the default ones are named after
+// their port. It returns false when there are HTTPS listen ports but the host
+// has no TLS, in which case no HTTPS listener is returned.
+func albListeners(httpListener gatewayv1beta1.Listener, httpsListener *gatewayv1beta1.Listener, ports []albListenPort) ([]gatewayv1beta1.Listener, []gatewayv1beta1.Listener, bool) {
+	var httpListeners, httpsListeners []gatewayv1beta1.Listener
+	ok := true
+	for _, port := range ports {
+		var listener gatewayv1beta1.Listener
+		var defaultPort int32
+		switch port.protocol {
+		case gatewayv1.HTTPProtocolType:
+			listener, defaultPort = httpListener, 80
+		case gatewayv1.HTTPSProtocolType:
+			if httpsListener == nil {
+				ok = false
+				continue
+			}
+			listener, defaultPort = *httpsListener, 443
+		}
+		listener.Port = gatewayv1.PortNumber(port.port)
+		if port.port != defaultPort {
+			listener.Name = gatewayv1beta1.SectionName(fmt.Sprintf("%s-%d", listener.Name, port.port))
+		}
+		if port.protocol == gatewayv1.HTTPProtocolType {
+			httpListeners = append(httpListeners, listener)
+		} else {
+			httpsListeners = append(httpsListeners, listener)
+		}
+	}
+	return httpListeners, httpsListeners, ok
+}
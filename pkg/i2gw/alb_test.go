@@ -0,0 +1,270 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_parseALBAction(t *testing.T) {
+	testCases := []struct {
+		name              string
+		value             string
+		expected          *albAction
+		expectNumWarnings int
+		expectingError    bool
+	}{{
+		name:  "redirect to HTTPS",
+		value: `{"Type": "redirect", "RedirectConfig": {"Protocol": "HTTPS", "Port": "443", "StatusCode": "HTTP_301"}}`,
+		expected: &albAction{redirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Port:       (*gatewayv1.PortNumber)(pointer.Int32(443)),
+			StatusCode: pointer.Int(301),
+		}},
+	}, {
+		name:  "redirect to another host and path",
+		value: `{"type": "redirect", "redirectConfig": {"protocol": "#{protocol}", "port": "#{port}", "host": "example.org", "path": "/new", "query": "#{query}", "statusCode": "HTTP_302"}}`,
+		expected: &albAction{redirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Hostname: (*gatewayv1.PreciseHostname)(pointer.String("example.org")),
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:            gatewayv1.FullPathHTTPPathModifier,
+				ReplaceFullPath: pointer.String("/new"),
+			},
+			StatusCode: pointer.Int(302),
+		}},
+	}, {
+		name:  "redirect with placeholders in the path and a query",
+		value: `{"type": "redirect", "redirectConfig": {"path": "/v2/#{path}", "query": "source=old", "statusCode": "HTTP_301"}}`,
+		expected: &albAction{redirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			StatusCode: pointer.Int(301),
+		}},
+		expectNumWarnings: 1,
+	}, {
+		name:              "fixed response",
+		value:             `{"type": "fixed-response", "fixedResponseConfig": {"contentType": "text/plain", "statusCode": "503", "messageBody": "maintenance"}}`,
+		expected:          &albAction{fixedResponse: true},
+		expectNumWarnings: 1,
+	}, {
+		name:           "redirect without status code",
+		value:          `{"type": "redirect", "redirectConfig": {"protocol": "HTTPS"}}`,
+		expectingError: true,
+	}, {
+		name:           "forward",
+		value:          `{"type": "forward", "forwardConfig": {"targetGroups": [{"serviceName": "app", "servicePort": "80"}]}}`,
+		expectingError: true,
+	}, {
+		name:           "invalid JSON",
+		value:          `redirect`,
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := field.NewPath("app", "metadata", "annotations").Key(albActionsAnnotationPrefix + "redirect")
+			actual, warnings, err := parseALBAction(tc.value, types.NamespacedName{Namespace: "test", Name: "app"}, path)
+			if tc.expectingError {
+				if err == nil {
+					t.Fatalf("Expected an error, got %+v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(albAction{})); diff != "" {
+				t.Errorf("Unexpected action (-want +got):\n%s", diff)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func Test_albProvider(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(tls bool, annotations map[string]string, backends ...networkingv1.IngressServiceBackend) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("alb"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{},
+					},
+				}},
+			},
+		}
+		for i, backend := range backends {
+			backend := backend
+			ingress.Spec.Rules[0].HTTP.Paths = append(ingress.Spec.Rules[0].HTTP.Paths, networkingv1.HTTPIngressPath{
+				Path:     fmt.Sprintf("/%d", i),
+				PathType: &iPrefix,
+				Backend:  networkingv1.IngressBackend{Service: &backend},
+			})
+		}
+		if tls {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com"}}
+		}
+		return ingress
+	}
+	app := networkingv1.IngressServiceBackend{Name: "app", Port: networkingv1.ServiceBackendPort{Number: 80}}
+	action := func(name string) networkingv1.IngressServiceBackend {
+		return networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Name: albUseAnnotationPort}}
+	}
+
+	testCases := []struct {
+		name                 string
+		ingress              networkingv1.Ingress
+		expectedListeners    []string
+		expectedRouteNames   []string
+		expectedSectionNames []string
+		expectedNumBackends  []int
+		expectedNumFilters   []int
+		expectedNumWarnings  int
+		expectedNumErrors    int
+	}{{
+		name:                 "default listeners",
+		ingress:              newIngress(true, map[string]string{albHealthcheckPathAnnotation: "/healthz"}, app),
+		expectedListeners:    []string{"example-com-http:80", "example-com-https:443"},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http", "example-com-https"},
+		expectedNumBackends:  []int{1},
+		expectedNumFilters:   []int{0},
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "listen ports",
+		ingress:              newIngress(true, map[string]string{albListenPortsAnnotation: `[{"HTTP": 80}, {"HTTP": 8080}, {"HTTPS": 8443}]`}, app),
+		expectedListeners:    []string{"example-com-http:80", "example-com-http-8080:8080", "example-com-https-8443:8443"},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http", "example-com-http-8080", "example-com-https-8443"},
+		expectedNumBackends:  []int{1},
+		expectedNumFilters:   []int{0},
+	}, {
+		name:                 "HTTPS listen ports without TLS",
+		ingress:              newIngress(false, map[string]string{albListenPortsAnnotation: `[{"HTTP": 80}, {"HTTPS": 443}]`}, app),
+		expectedListeners:    []string{"example-com-http:80"},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumBackends:  []int{1},
+		expectedNumFilters:   []int{0},
+		expectedNumWarnings:  1,
+	}, {
+		name: "ssl-redirect",
+		ingress: newIngress(true, map[string]string{
+			albListenPortsAnnotation: `[{"HTTP": 80}, {"HTTPS": 443}]`,
+			albSSLRedirectAnnotation: "443",
+		}, app),
+		expectedListeners:    []string{"example-com-http:80", "example-com-https:443"},
+		expectedRouteNames:   []string{"example-com", "example-com-ssl-redirect"},
+		expectedSectionNames: []string{"example-com-https", "example-com-http"},
+		expectedNumBackends:  []int{1, 0},
+		expectedNumFilters:   []int{0, 1},
+	}, {
+		name: "redirect and fixed response actions",
+		ingress: newIngress(false, map[string]string{
+			albActionsAnnotationPrefix + "to-docs":     `{"type": "redirect", "redirectConfig": {"host": "docs.example.com", "statusCode": "HTTP_302"}}`,
+			albActionsAnnotationPrefix + "maintenance": `{"type": "fixed-response", "fixedResponseConfig": {"statusCode": "503"}}`,
+		}, app, action("to-docs"), action("maintenance")),
+		expectedListeners:    []string{"example-com-http:80"},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumBackends:  []int{1, 0, 0},
+		expectedNumFilters:   []int{0, 1, 0},
+		expectedNumWarnings:  1,
+	}, {
+		name: "unconverted annotations",
+		ingress: newIngress(false, map[string]string{
+			albCertificateARNAnnotation:            "arn:aws:acm:us-east-1:123456789012:certificate/example",
+			"alb.ingress.kubernetes.io/scheme":     "internet-facing",
+			"alb.ingress.kubernetes.io/group.name": "web",
+		}, app),
+		expectedListeners:    []string{"example-com-http:80"},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumBackends:  []int{1},
+		expectedNumFilters:   []int{0},
+		expectedNumWarnings:  3,
+	}, {
+		name:              "unsupported listen port protocol",
+		ingress:           newIngress(false, map[string]string{albListenPortsAnnotation: `[{"TCP": 22}]`}, app),
+		expectedNumErrors: 1,
+	}, {
+		name:              "invalid ssl-redirect",
+		ingress:           newIngress(true, map[string]string{albSSLRedirectAnnotation: "true"}, app),
+		expectedNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, gateways, warnings, errs := ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{tc.ingress}, Options{})
+			if len(errs) != tc.expectedNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectedNumErrors, len(errs), errs)
+			}
+			if len(errs) > 0 {
+				return
+			}
+			if len(warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(warnings), warnings)
+			}
+			var listeners []string
+			for _, listener := range gateways[0].Spec.Listeners {
+				listeners = append(listeners, fmt.Sprintf("%s:%d", listener.Name, listener.Port))
+			}
+			if diff := cmp.Diff(tc.expectedListeners, listeners); diff != "" {
+				t.Errorf("Unexpected listeners (-want +got):\n%s", diff)
+			}
+			var routeNames, sectionNames []string
+			var numBackends, numFilters []int
+			for _, route := range httpRoutes {
+				routeNames = append(routeNames, route.Name)
+				for _, parentRef := range route.Spec.ParentRefs {
+					if parentRef.SectionName != nil {
+						sectionNames = append(sectionNames, string(*parentRef.SectionName))
+					}
+				}
+				for _, rule := range route.Spec.Rules {
+					numBackends = append(numBackends, len(rule.BackendRefs))
+					numFilters = append(numFilters, len(rule.Filters))
+				}
+			}
+			if diff := cmp.Diff(tc.expectedRouteNames, routeNames); diff != "" {
+				t.Fatalf("Unexpected HTTPRoute names (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedSectionNames, sectionNames); diff != "" {
+				t.Errorf("Unexpected sectionNames (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedNumBackends, numBackends); diff != "" {
+				t.Errorf("Unexpected number of backends by rule (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedNumFilters, numFilters); diff != "" {
+				t.Errorf("Unexpected number of filters by rule (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// DefaultPreservedAnnotations are the Ingress annotations copied onto the
+// generated Gateways and HTTPRoutes by default, so that DNS automation keeps
+// working after the migration.
+var DefaultPreservedAnnotations = []string{"external-dns.alpha.kubernetes.io/"}
+
+// preservedAnnotations returns the annotations of the Ingress matching the
+// allowlist. Allowlist entries ending with a "/" match every annotation with
+// that prefix, other entries match a single annotation key.
+func preservedAnnotations(ingress networkingv1.Ingress, allowlist []string) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range ingress.Annotations {
+		for _, allowed := range allowlist {
+			if key == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(key, allowed)) {
+				annotations[key] = value
+				break
+			}
+		}
+	}
+	return annotations
+}
+
+// mergeAnnotations merges the annotations of the Ingress into dst, keeping the
+// values already set. A warning is returned for every annotation set to a
+// different value by a previous Ingress.
+func mergeAnnotations(dst, src map[string]string, ingress types.NamespacedName, resource string) []Warning {
+	var warnings []Warning
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = src[key]
+			continue
+		}
+		if existing != src[key] {
+			warnings = append(warnings, Warning{
+				Ingress: ingress,
+				Field:   field.NewPath(ingress.Name, "metadata", "annotations").Key(key),
+				Message: fmt.Sprintf("annotation is not copied onto %s, which already has it set to %q by another Ingress", resource, existing),
+			})
+		}
+	}
+	return warnings
+}
+
+// annotateGateways sets the annotations preserved from the Ingresses of every
+// Gateway, the Ingresses of the Gateway when the sources of the resources are
+// annotated, and the Ingress classes of the Gateway when they are recorded.
+func (a *ingressAggregator) annotateGateways(gateways []gatewayv1beta1.Gateway) {
+	for i := range gateways {
+		gwKey := fmt.Sprintf("%s/%s", gateways[i].Namespace, gateways[i].Name)
+		annotations := a.gatewayAnnotations[gwKey]
+		if len(annotations) > 0 {
+			gateways[i].Annotations = annotations
+		}
+		a.annotateSources(&gateways[i], a.gatewayIngresses[gwKey]...)
+		setIngressClasses(&gateways[i], a.gatewayIngressClasses[gwKey])
+	}
+}
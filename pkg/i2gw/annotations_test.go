@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_preserveAnnotations(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: name,
+										Port: networkingv1.ServiceBackendPort{Number: 8080},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name                string
+		ingresses           []networkingv1.Ingress
+		allowlist           []string
+		expectedAnnotations map[string]string
+		expectNumWarnings   int
+	}{{
+		name: "external-dns annotations preserved by default",
+		ingresses: []networkingv1.Ingress{newIngress("example", map[string]string{
+			"external-dns.alpha.kubernetes.io/hostname": "example.com",
+			"external-dns.alpha.kubernetes.io/ttl":      "60",
+			"nginx.ingress.kubernetes.io/ssl-redirect":  "false",
+		})},
+		allowlist: DefaultPreservedAnnotations,
+		expectedAnnotations: map[string]string{
+			"external-dns.alpha.kubernetes.io/hostname": "example.com",
+			"external-dns.alpha.kubernetes.io/ttl":      "60",
+		},
+	}, {
+		name: "allowlist extended with a key",
+		ingresses: []networkingv1.Ingress{newIngress("example", map[string]string{
+			"external-dns.alpha.kubernetes.io/hostname": "example.com",
+			"example.com/owner":                         "web",
+			"example.com/cost-center":                   "1234",
+		})},
+		allowlist: append(DefaultPreservedAnnotations, "example.com/owner"),
+		expectedAnnotations: map[string]string{
+			"external-dns.alpha.kubernetes.io/hostname": "example.com",
+			"example.com/owner":                         "web",
+		},
+	}, {
+		name: "conflicting annotations keep the first value",
+		ingresses: []networkingv1.Ingress{
+			newIngress("first", map[string]string{"external-dns.alpha.kubernetes.io/ttl": "60"}),
+			newIngress("second", map[string]string{"external-dns.alpha.kubernetes.io/ttl": "300"}),
+		},
+		allowlist:           DefaultPreservedAnnotations,
+		expectedAnnotations: map[string]string{"external-dns.alpha.kubernetes.io/ttl": "60"},
+		// One for the Gateway and one for the HTTPRoute.
+		expectNumWarnings: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, gateways, warnings, errs := Ingresses2GatewaysAndHTTPRoutes(tc.ingresses, nil, nil, tc.allowlist, false)
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if len(gateways) != 1 || len(httpRoutes) != 1 {
+				t.Fatalf("Expected 1 Gateway and 1 HTTPRoute, got %d and %d", len(gateways), len(httpRoutes))
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, gateways[0].Annotations); diff != "" {
+				t.Errorf("Unexpected Gateway annotations (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, httpRoutes[0].Annotations); diff != "" {
+				t.Errorf("Unexpected HTTPRoute annotations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const appRootAnnotation = "nginx.ingress.kubernetes.io/app-root"
+
+// getAppRoot reads the app-root annotation of ingress-nginx, the path the
+// requests of the root path of the host are redirected to. Values that aren't
+// a path other than the root path, without query or nginx variables, are
+// reported as a warning, with no redirect.
+func getAppRoot(ingress networkingv1.Ingress, fieldPath *field.Path) (string, *Warning) {
+	appRoot, ok := ingress.Annotations[appRootAnnotation]
+	if !ok {
+		return "", nil
+	}
+	appRoot = strings.TrimSpace(appRoot)
+	if !strings.HasPrefix(appRoot, "/") || appRoot == "/" || strings.ContainsAny(appRoot, "$?#") {
+		return "", &Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(appRootAnnotation),
+			Message: fmt.Sprintf("%q is not a path the root path can be redirected to, no redirect is generated", appRoot),
+		}
+	}
+	return appRoot, nil
+}
+
+// appRoot returns the app root of the host and its Ingress, the first one of
+// the Ingresses of the rule group, as ingress-nginx redirects the root path
+// of the whole host. The app roots of other Ingresses that differ are
+// reported as warnings.
+func (rg *ingressRuleGroup) appRoot() (string, types.NamespacedName, []Warning) {
+	var appRoot string
+	var ingress types.NamespacedName
+	var warnings []Warning
+	for _, ir := range rg.rules {
+		if ir.extra == nil || ir.extra.appRoot == "" {
+			continue
+		}
+		if appRoot == "" {
+			appRoot, ingress = ir.extra.appRoot, ir.ingress
+			continue
+		}
+		if ir.extra.appRoot != appRoot {
+			warnings = append(warnings, Warning{
+				Ingress: ir.ingress,
+				Field:   field.NewPath(ir.ingress.Name, "metadata", "annotations").Key(appRootAnnotation),
+				Message: fmt.Sprintf("ignored, the root path of host %q is redirected to the app-root %q of Ingress %s", rg.host, appRoot, ingress),
+			})
+		}
+	}
+	return appRoot, ingress, warnings
+}
+
+// addAppRootRule adds the rule redirecting the requests of the root path of
+// the host to its app root with a 302 status code, as ingress-nginx does,
+// along with the rules of the other paths. The redirect takes precedence over
+// the rule of an Exact root path, which is removed with a warning.
+func (rg *ingressRuleGroup) addAppRootRule(httpRoute *gatewayv1beta1.HTTPRoute) []Warning {
+	appRoot, ingress, warnings := rg.appRoot()
+	if appRoot == "" || len(httpRoute.Spec.Rules) == 0 {
+		return warnings
+	}
+
+	rule := gatewayv1beta1.HTTPRouteRule{
+		Matches: []gatewayv1beta1.HTTPRouteMatch{{
+			Path: &gatewayv1beta1.HTTPPathMatch{
+				Type:  (*gatewayv1.PathMatchType)(pointer.String(string(gatewayv1.PathMatchExact))),
+				Value: pointer.String("/"),
+			},
+		}},
+		Filters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterRequestRedirect,
+			RequestRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+				Path: &gatewayv1beta1.HTTPPathModifier{
+					Type:            gatewayv1.FullPathHTTPPathModifier,
+					ReplaceFullPath: pointer.String(appRoot),
+				},
+				StatusCode: pointer.Int(302),
+			},
+		}},
+	}
+	key := rulePathKey(rule)
+	rules := httpRoute.Spec.Rules[:0]
+	for _, r := range httpRoute.Spec.Rules {
+		if rulePathKey(r) == key && len(r.Matches) == 1 && len(r.Matches[0].Headers) == 0 && len(r.Matches[0].QueryParams) == 0 && r.Matches[0].Method == nil {
+			warnings = append(warnings, Warning{
+				Ingress: ingress,
+				Field:   field.NewPath(ingress.Name, "metadata", "annotations").Key(appRootAnnotation),
+				Message: fmt.Sprintf("the Exact path \"/\" of host %q is redirected to the app-root %q, as with ingress-nginx, instead of being routed to its backends", rg.host, appRoot),
+			})
+			continue
+		}
+		rules = append(rules, r)
+	}
+	httpRoute.Spec.Rules = append(rules, rule)
+	if rg.ruleIngresses == nil {
+		rg.ruleIngresses = map[string]types.NamespacedName{}
+	}
+	rg.ruleIngresses[key] = ingress
+	return warnings
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_getAppRoot(t *testing.T) {
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedAppRoot string
+		expectingWarn   bool
+	}{{
+		name: "no app root",
+	}, {
+		name:            "app root",
+		annotations:     map[string]string{appRootAnnotation: "/app"},
+		expectedAppRoot: "/app",
+	}, {
+		name:          "root path",
+		annotations:   map[string]string{appRootAnnotation: "/"},
+		expectingWarn: true,
+	}, {
+		name:          "relative path",
+		annotations:   map[string]string{appRootAnnotation: "app"},
+		expectingWarn: true,
+	}, {
+		name:          "nginx variable",
+		annotations:   map[string]string{appRootAnnotation: "/$host"},
+		expectingWarn: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: tc.annotations}}
+			appRoot, warning := getAppRoot(ingress, field.NewPath("app", "metadata", "annotations"))
+			if appRoot != tc.expectedAppRoot {
+				t.Errorf("Expected app root %q, got %q", tc.expectedAppRoot, appRoot)
+			}
+			if tc.expectingWarn != (warning != nil) {
+				t.Errorf("Expected warning: %v, got %+v", tc.expectingWarn, warning)
+			}
+		})
+	}
+}
+
+func Test_convertAppRoot(t *testing.T) {
+	iExact := networkingv1.PathTypeExact
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name string, annotations map[string]string, paths ...networkingv1.HTTPIngressPath) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host:             "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}},
+				}},
+			},
+		}
+	}
+	newPath := func(path string, pathType *networkingv1.PathType) networkingv1.HTTPIngressPath {
+		return networkingv1.HTTPIngressPath{
+			Path:     path,
+			PathType: pathType,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Number: 80}},
+			},
+		}
+	}
+	appRoot := map[string]string{appRootAnnotation: "/app"}
+	redirectRule := gatewayv1beta1.HTTPRouteRule{
+		Matches: []gatewayv1beta1.HTTPRouteMatch{{
+			Path: &gatewayv1beta1.HTTPPathMatch{
+				Type:  (*gatewayv1.PathMatchType)(pointer.String(string(gatewayv1.PathMatchExact))),
+				Value: pointer.String("/"),
+			},
+		}},
+		Filters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterRequestRedirect,
+			RequestRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+				Path: &gatewayv1beta1.HTTPPathModifier{
+					Type:            gatewayv1.FullPathHTTPPathModifier,
+					ReplaceFullPath: pointer.String("/app"),
+				},
+				StatusCode: pointer.Int(302),
+			},
+		}},
+	}
+
+	testCases := []struct {
+		name                string
+		ingresses           []networkingv1.Ingress
+		expectedPaths       []string
+		expectedNumWarnings int
+	}{{
+		name:          "redirect along with the other paths",
+		ingresses:     []networkingv1.Ingress{newIngress("web", appRoot, newPath("/", &iPrefix), newPath("/app", &iPrefix))},
+		expectedPaths: []string{"Exact /", "PathPrefix /app", "PathPrefix /"},
+	}, {
+		name:                "Exact root path redirected",
+		ingresses:           []networkingv1.Ingress{newIngress("web", appRoot, newPath("/", &iExact), newPath("/app", &iPrefix))},
+		expectedPaths:       []string{"Exact /", "PathPrefix /app"},
+		expectedNumWarnings: 1,
+	}, {
+		name: "app roots of several Ingresses",
+		ingresses: []networkingv1.Ingress{
+			newIngress("web", appRoot, newPath("/app", &iPrefix)),
+			newIngress("api", map[string]string{appRootAnnotation: "/api"}, newPath("/api", &iPrefix)),
+		},
+		expectedPaths:       []string{"Exact /", "PathPrefix /app", "PathPrefix /api"},
+		expectedNumWarnings: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, Options{})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if len(result.HTTPRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d", len(result.HTTPRoutes))
+			}
+			rules := result.HTTPRoutes[0].Spec.Rules
+			var paths []string
+			for _, rule := range rules {
+				paths = append(paths, string(*rule.Matches[0].Path.Type)+" "+*rule.Matches[0].Path.Value)
+			}
+			if diff := cmp.Diff(tc.expectedPaths, paths); diff != "" {
+				t.Fatalf("Unexpected paths (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(redirectRule, rules[0]); diff != "" {
+				t.Errorf("Unexpected redirect rule (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %+v", tc.expectedNumWarnings, result.Warnings)
+			}
+			if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+				t.Errorf("Expected valid resources, got %v", err)
+			}
+		})
+	}
+}
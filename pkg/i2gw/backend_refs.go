@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"regexp"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	backendRefGroupAnnotation = "ingress2gateway.kubernetes.io/backend-ref-group"
+	backendRefKindAnnotation  = "ingress2gateway.kubernetes.io/backend-ref-kind"
+)
+
+// kindRegex is the pattern of the Kind of Gateway API object references.
+var kindRegex = regexp.MustCompile(`^[a-zA-Z]([-a-zA-Z0-9]*[a-zA-Z0-9])?$`)
+
+// backendKind is the group and kind of the backendRefs converted from the
+// Service backends of an Ingress, its zero value being a core Service.
+type backendKind struct {
+	group string
+	kind  string
+}
+
+// isService indicates whether the backendRefs are core Services.
+func (k backendKind) isService() bool {
+	return k.group == "" && (k.kind == "" || k.kind == "Service")
+}
+
+// ValidateBackendRefKind checks that the group and kind overriding the core
+// Service of the backendRefs of Service backends are valid. A kind is
+// required along with a group, and empty group and kind keep core Services.
+func ValidateBackendRefKind(group, kind string) error {
+	return validateBackendKind(group, kind, field.NewPath("group"), field.NewPath("kind")).ToAggregate()
+}
+
+func validateBackendKind(group, kind string, groupPath, kindPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if group != "" && kind == "" {
+		errs = append(errs, field.Required(kindPath, "a kind is required along with group "+group))
+	}
+	if kind != "" && (len(kind) > 63 || !kindRegex.MatchString(kind)) {
+		errs = append(errs, field.Invalid(kindPath, kind, "must be an alphanumeric name of at most 63 characters, such as Service"))
+	}
+	if group != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(group) {
+			errs = append(errs, field.Invalid(groupPath, group, msg))
+		}
+	}
+	return errs
+}
+
+// getBackendKind returns the kind of the backendRefs of the Service backends
+// of the Ingress. The backend-ref annotations of the Ingress take precedence
+// over the default kind, set through the options, as a whole: a kind
+// annotation without group is a kind of the core group.
+func getBackendKind(ingress networkingv1.Ingress, defaultKind backendKind) (backendKind, field.ErrorList) {
+	group, hasGroup := ingress.Annotations[backendRefGroupAnnotation]
+	kind, hasKind := ingress.Annotations[backendRefKindAnnotation]
+	if !hasGroup && !hasKind {
+		return defaultKind, nil
+	}
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+	if hasGroup && !hasKind {
+		return backendKind{}, field.ErrorList{field.Required(fieldPath.Key(backendRefKindAnnotation), "a kind is required along with "+backendRefGroupAnnotation)}
+	}
+	if errs := validateBackendKind(group, kind, fieldPath.Key(backendRefGroupAnnotation), fieldPath.Key(backendRefKindAnnotation)); len(errs) > 0 {
+		return backendKind{}, errs
+	}
+	return backendKind{group: group, kind: kind}, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_ValidateBackendRefKind(t *testing.T) {
+	testCases := []struct {
+		name        string
+		group       string
+		kind        string
+		expectError bool
+	}{
+		{name: "core Service"},
+		{name: "custom kind", group: "proxy.example.com", kind: "S3Proxy"},
+		{name: "core group kind", kind: "Backend"},
+		{name: "group without kind", group: "proxy.example.com", expectError: true},
+		{name: "invalid kind", group: "proxy.example.com", kind: "s3_proxy", expectError: true},
+		{name: "invalid group", group: "Proxy_Example", kind: "S3Proxy", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateBackendRefKind(tc.group, tc.kind)
+			if (err != nil) != tc.expectError {
+				t.Errorf("ValidateBackendRefKind(%q, %q) = %v, expecting error: %v", tc.group, tc.kind, err, tc.expectError)
+			}
+		})
+	}
+}
+
+func Test_convertBackendRefKind(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	ingress := func(name string, annotations map[string]string, port networkingv1.ServiceBackendPort) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("ingress-nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: name + ".example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: "storage", Port: port},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		ingress           networkingv1.Ingress
+		opts              Options
+		expectedRef       gatewayv1.BackendObjectReference
+		expectNumWarnings int
+		expectError       bool
+	}{{
+		name:        "core Service by default",
+		ingress:     ingress("web", nil, networkingv1.ServiceBackendPort{Number: 80}),
+		expectedRef: gatewayv1.BackendObjectReference{Name: "storage", Port: portNumberPtr(80)},
+	}, {
+		name:    "kind of the options",
+		ingress: ingress("web", nil, networkingv1.ServiceBackendPort{Number: 80}),
+		opts:    Options{BackendRefGroup: "proxy.example.com", BackendRefKind: "S3Proxy"},
+		expectedRef: gatewayv1.BackendObjectReference{
+			Group: apiGroupPtr("proxy.example.com"),
+			Kind:  apiKindPtr("S3Proxy"),
+			Name:  "storage",
+			Port:  portNumberPtr(80),
+		},
+	}, {
+		name: "annotations take precedence over the options",
+		ingress: ingress("web", map[string]string{
+			backendRefGroupAnnotation: "storage.example.com",
+			backendRefKindAnnotation:  "Bucket",
+		}, networkingv1.ServiceBackendPort{Number: 80}),
+		opts: Options{BackendRefGroup: "proxy.example.com", BackendRefKind: "S3Proxy"},
+		expectedRef: gatewayv1.BackendObjectReference{
+			Group: apiGroupPtr("storage.example.com"),
+			Kind:  apiKindPtr("Bucket"),
+			Name:  "storage",
+			Port:  portNumberPtr(80),
+		},
+	}, {
+		name:        "Service annotation overriding the options",
+		ingress:     ingress("web", map[string]string{backendRefKindAnnotation: "Service"}, networkingv1.ServiceBackendPort{Number: 80}),
+		opts:        Options{BackendRefGroup: "proxy.example.com", BackendRefKind: "S3Proxy"},
+		expectedRef: gatewayv1.BackendObjectReference{Name: "storage", Port: portNumberPtr(80)},
+	}, {
+		name:    "named port of a custom kind",
+		ingress: ingress("web", map[string]string{backendRefKindAnnotation: "Backend"}, networkingv1.ServiceBackendPort{Name: "http"}),
+		expectedRef: gatewayv1.BackendObjectReference{
+			Group: apiGroupPtr(""),
+			Kind:  apiKindPtr("Backend"),
+			Name:  "storage",
+		},
+		expectNumWarnings: 1,
+	}, {
+		name:        "group annotation without kind",
+		ingress:     ingress("web", map[string]string{backendRefGroupAnnotation: "proxy.example.com"}, networkingv1.ServiceBackendPort{Number: 80}),
+		expectError: true,
+	}, {
+		name:        "invalid kind annotation",
+		ingress:     ingress("web", map[string]string{backendRefKindAnnotation: "s3_proxy"}, networkingv1.ServiceBackendPort{Number: 80}),
+		expectError: true,
+	}, {
+		name:        "invalid options",
+		ingress:     ingress("web", nil, networkingv1.ServiceBackendPort{Number: 80}),
+		opts:        Options{BackendRefGroup: "proxy.example.com"},
+		expectError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert([]networkingv1.Ingress{tc.ingress}, tc.opts)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("Convert() error = %v, expecting error: %v", err, tc.expectError)
+			}
+			if tc.expectError {
+				return
+			}
+			if len(result.HTTPRoutes) != 1 || len(result.HTTPRoutes[0].Spec.Rules) != 1 || len(result.HTTPRoutes[0].Spec.Rules[0].BackendRefs) != 1 {
+				t.Fatalf("Expected an HTTPRoute with a backendRef, got %+v", result.HTTPRoutes)
+			}
+			ref := result.HTTPRoutes[0].Spec.Rules[0].BackendRefs[0].BackendObjectReference
+			if diff := cmp.Diff(tc.expectedRef, ref); diff != "" {
+				t.Errorf("Unexpected backendRef (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
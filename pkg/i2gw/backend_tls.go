@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+)
+
+const (
+	proxySSLSecretAnnotation = "nginx.ingress.kubernetes.io/proxy-ssl-secret"
+	proxySSLNameAnnotation   = "nginx.ingress.kubernetes.io/proxy-ssl-name"
+	proxySSLVerifyAnnotation = "nginx.ingress.kubernetes.io/proxy-ssl-verify"
+	// backendTLSPolicyNameSuffix is appended to the name of the Service a
+	// BackendTLSPolicy targets.
+	backendTLSPolicyNameSuffix = "-backend-tls"
+)
+
+var backendTLSPolicyGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha3",
+	Kind:    "BackendTLSPolicy",
+}
+
+// backendTLS is the TLS configuration of the connections to the HTTPS
+// backends of an Ingress.
+type backendTLS struct {
+	// caSecret is the proxy-ssl-secret annotation, <namespace>/<name> of the
+	// Secret holding the CA certificate of the backends.
+	caSecret string
+	// hostname is the proxy-ssl-name annotation, the name of the backend
+	// certificates.
+	hostname string
+	// verify is whether the proxy-ssl-verify annotation is on.
+	verify bool
+}
+
+// backendTLSPolicy is the TLS configuration of a backend Service, from the
+// first Ingress using the Service as HTTPS backend.
+type backendTLSPolicy struct {
+	ingress types.NamespacedName
+	tls     backendTLS
+}
+
+// getBackendTLS reads the TLS configuration of the HTTPS backends of the
+// Ingress, or returns nil when its backends aren't HTTPS.
+func getBackendTLS(ingress networkingv1.Ingress, backendProtocol string) *backendTLS {
+	if backendProtocol != "HTTPS" {
+		return nil
+	}
+	return &backendTLS{
+		caSecret: strings.TrimSpace(ingress.Annotations[proxySSLSecretAnnotation]),
+		hostname: strings.TrimSpace(ingress.Annotations[proxySSLNameAnnotation]),
+		verify:   ingress.Annotations[proxySSLVerifyAnnotation] == "on",
+	}
+}
+
+// addBackendTLS records the TLS configuration of the backend Services of the
+// Ingress. A Service used as HTTPS backend by several Ingresses is configured
+// by the first one, the others configuring it differently are reported as
+// warnings.
+func (a *ingressAggregator) addBackendTLS(ingress networkingv1.Ingress, tls *backendTLS) {
+	ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	if a.backendTLSPolicies == nil {
+		a.backendTLSPolicies = map[types.NamespacedName]*backendTLSPolicy{}
+	}
+	for _, service := range backendServices(ingress) {
+		existing, ok := a.backendTLSPolicies[service]
+		if !ok {
+			a.backendTLSPolicies[service] = &backendTLSPolicy{ingress: ingressName, tls: *tls}
+			continue
+		}
+		if existing.tls != *tls && existing.ingress != ingressName {
+			a.warnings = append(a.warnings, Warning{
+				Ingress: ingressName,
+				Field:   field.NewPath(ingress.Name, "metadata", "annotations").Key(backendProtocolAnnotation),
+				Message: fmt.Sprintf("the TLS settings of Service %s differ from the ones of Ingress %s, which configure its BackendTLSPolicy", service, existing.ingress),
+			})
+		}
+	}
+}
+
+// toBackendTLSPolicies returns a BackendTLSPolicy for every Service used as
+// HTTPS backend, sorted by Service. The policy validates the backend
+// certificates with the CA certificate of proxy-ssl-secret and the hostname of
+// proxy-ssl-name. Settings that can't be derived from the annotations are
+// reported as warnings.
+func (a *ingressAggregator) toBackendTLSPolicies() ([]gatewayv1alpha3.BackendTLSPolicy, []Warning) {
+	services := make([]types.NamespacedName, 0, len(a.backendTLSPolicies))
+	for service := range a.backendTLSPolicies {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].String() < services[j].String()
+	})
+
+	var policies []gatewayv1alpha3.BackendTLSPolicy
+	var warnings []Warning
+	for _, service := range services {
+		p := a.backendTLSPolicies[service]
+		fieldPath := field.NewPath(p.ingress.Name, "metadata", "annotations")
+		policy := gatewayv1alpha3.BackendTLSPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      service.Name + backendTLSPolicyNameSuffix,
+				Namespace: service.Namespace,
+			},
+			Spec: gatewayv1alpha3.BackendTLSPolicySpec{
+				TargetRefs: []gatewayv1alpha2.LocalPolicyTargetReferenceWithSectionName{{
+					LocalPolicyTargetReference: gatewayv1alpha2.LocalPolicyTargetReference{
+						Group: "",
+						Kind:  "Service",
+						Name:  gatewayv1.ObjectName(service.Name),
+					},
+				}},
+				Validation: gatewayv1alpha3.BackendTLSPolicyValidation{
+					Hostname: gatewayv1.PreciseHostname(p.tls.hostname),
+				},
+			},
+			Status: gatewayv1alpha2.PolicyStatus{Ancestors: []gatewayv1alpha2.PolicyAncestorStatus{}},
+		}
+		policy.SetGroupVersionKind(backendTLSPolicyGVK)
+		a.annotateSources(&policy, p.ingress)
+
+		if p.tls.hostname == "" {
+			hostname := fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace)
+			policy.Spec.Validation.Hostname = gatewayv1.PreciseHostname(hostname)
+			warnings = append(warnings, Warning{
+				Ingress: p.ingress,
+				Field:   fieldPath.Key(proxySSLNameAnnotation),
+				Message: fmt.Sprintf("the hostname of the certificates of Service %s is not set, BackendTLSPolicy %s validates them for %s; set it to the name of the backend certificates", service, policy.Name, hostname),
+			})
+		}
+
+		var caNamespace, caName string
+		if parts := strings.SplitN(p.tls.caSecret, "/", 2); len(parts) == 2 {
+			caNamespace, caName = parts[0], parts[1]
+		} else {
+			caNamespace, caName = service.Namespace, p.tls.caSecret
+		}
+		switch {
+		case caName != "" && caNamespace == service.Namespace:
+			policy.Spec.Validation.CACertificateRefs = []gatewayv1.LocalObjectReference{{
+				Group: "",
+				Kind:  "Secret",
+				Name:  gatewayv1.ObjectName(caName),
+			}}
+			warnings = append(warnings, Warning{
+				Ingress: p.ingress,
+				Field:   fieldPath.Key(proxySSLSecretAnnotation),
+				Message: fmt.Sprintf("BackendTLSPolicy %s references the CA certificate of Secret %s, implementations are only required to support ConfigMaps; copy its ca.crt into a ConfigMap if the Secret isn't supported", policy.Name, caName),
+			})
+		default:
+			system := gatewayv1alpha3.WellKnownCACertificatesSystem
+			policy.Spec.Validation.WellKnownCACertificates = &system
+			message := fmt.Sprintf("no CA certificate of Service %s is set, BackendTLSPolicy %s validates the backend certificates with the system CA certificates", service, policy.Name)
+			if caName != "" {
+				message = fmt.Sprintf("the CA certificate Secret %s can't be referenced from namespace %s, BackendTLSPolicy %s validates the backend certificates with the system CA certificates", p.tls.caSecret, service.Namespace, policy.Name)
+			}
+			warnings = append(warnings, Warning{
+				Ingress: p.ingress,
+				Field:   fieldPath.Key(proxySSLSecretAnnotation),
+				Message: message,
+			})
+		}
+		if !p.tls.verify {
+			warnings = append(warnings, Warning{
+				Ingress: p.ingress,
+				Field:   fieldPath.Key(proxySSLVerifyAnnotation),
+				Message: fmt.Sprintf("ingress-nginx doesn't verify the certificates of Service %s as proxy-ssl-verify isn't on, BackendTLSPolicy %s always verifies them", service, policy.Name),
+			})
+		}
+		policies = append(policies, policy)
+	}
+	return policies, warnings
+}
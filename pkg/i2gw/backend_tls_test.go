@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+)
+
+func Test_toBackendTLSPolicies(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, path, service string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     path,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: service,
+										Port: networkingv1.ServiceBackendPort{Number: 8443},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	system := gatewayv1alpha3.WellKnownCACertificatesSystem
+
+	testCases := []struct {
+		name               string
+		ingresses          []networkingv1.Ingress
+		expectedValidation []gatewayv1alpha3.BackendTLSPolicyValidation
+		expectNumWarnings  int
+	}{{
+		name:      "HTTP backend",
+		ingresses: []networkingv1.Ingress{newIngress("web", "/", "web", nil)},
+	}, {
+		name: "CA certificate and hostname",
+		ingresses: []networkingv1.Ingress{newIngress("web", "/", "web", map[string]string{
+			"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+			"nginx.ingress.kubernetes.io/proxy-ssl-secret": "test/web-ca",
+			"nginx.ingress.kubernetes.io/proxy-ssl-name":   "web.example.com",
+			"nginx.ingress.kubernetes.io/proxy-ssl-verify": "on",
+		})},
+		expectedValidation: []gatewayv1alpha3.BackendTLSPolicyValidation{{
+			CACertificateRefs: []gatewayv1.LocalObjectReference{{Kind: "Secret", Name: "web-ca"}},
+			Hostname:          "web.example.com",
+		}},
+		// The Secret CA certificate reference.
+		expectNumWarnings: 1,
+	}, {
+		name: "nothing to derive the validation from",
+		ingresses: []networkingv1.Ingress{newIngress("web", "/", "web", map[string]string{
+			"nginx.ingress.kubernetes.io/backend-protocol": "https",
+		})},
+		expectedValidation: []gatewayv1alpha3.BackendTLSPolicyValidation{{
+			WellKnownCACertificates: &system,
+			Hostname:                "web.test.svc",
+		}},
+		// The hostname, the CA certificate and proxy-ssl-verify.
+		expectNumWarnings: 3,
+	}, {
+		name: "CA certificate of another namespace",
+		ingresses: []networkingv1.Ingress{newIngress("web", "/", "web", map[string]string{
+			"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+			"nginx.ingress.kubernetes.io/proxy-ssl-secret": "ingress-nginx/web-ca",
+			"nginx.ingress.kubernetes.io/proxy-ssl-name":   "web.example.com",
+			"nginx.ingress.kubernetes.io/proxy-ssl-verify": "on",
+		})},
+		expectedValidation: []gatewayv1alpha3.BackendTLSPolicyValidation{{
+			WellKnownCACertificates: &system,
+			Hostname:                "web.example.com",
+		}},
+		expectNumWarnings: 1,
+	}, {
+		name: "Service configured differently by several Ingresses",
+		ingresses: []networkingv1.Ingress{
+			newIngress("a", "/a", "web", map[string]string{
+				"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+				"nginx.ingress.kubernetes.io/proxy-ssl-secret": "web-ca",
+				"nginx.ingress.kubernetes.io/proxy-ssl-name":   "web.example.com",
+				"nginx.ingress.kubernetes.io/proxy-ssl-verify": "on",
+			}),
+			newIngress("b", "/b", "web", map[string]string{
+				"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+				"nginx.ingress.kubernetes.io/proxy-ssl-secret": "web-ca",
+				"nginx.ingress.kubernetes.io/proxy-ssl-name":   "other.example.com",
+				"nginx.ingress.kubernetes.io/proxy-ssl-verify": "on",
+			}),
+		},
+		expectedValidation: []gatewayv1alpha3.BackendTLSPolicyValidation{{
+			CACertificateRefs: []gatewayv1.LocalObjectReference{{Kind: "Secret", Name: "web-ca"}},
+			Hostname:          "web.example.com",
+		}},
+		expectNumWarnings: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %v", errs)
+			}
+			var validation []gatewayv1alpha3.BackendTLSPolicyValidation
+			for _, policy := range result.BackendTLSPolicies {
+				if policy.Name != "web-backend-tls" || policy.Namespace != "test" || policy.Spec.TargetRefs[0].Name != "web" {
+					t.Errorf("Expected BackendTLSPolicy test/web-backend-tls targeting Service web, got %s/%s targeting %s", policy.Namespace, policy.Name, policy.Spec.TargetRefs[0].Name)
+				}
+				validation = append(validation, policy.Spec.Validation)
+			}
+			if diff := cmp.Diff(tc.expectedValidation, validation); diff != "" {
+				t.Errorf("Unexpected BackendTLSPolicy validation (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	authTypeAnnotation       = "nginx.ingress.kubernetes.io/auth-type"
+	authSecretAnnotation     = "nginx.ingress.kubernetes.io/auth-secret"
+	authSecretTypeAnnotation = "nginx.ingress.kubernetes.io/auth-secret-type"
+
+	// authSecretTypeAuthMap is the auth-secret-type of the Secrets mapping
+	// every user to its password hash, instead of holding an htpasswd file
+	// in their auth key.
+	authSecretTypeAuthMap = "auth-map"
+)
+
+// basicAuth is the HTTP basic authentication of ingress-nginx, with the
+// credentials of a Secret.
+type basicAuth struct {
+	secretNamespace string
+	secretName      string
+	// authMap is set when the Secret maps every user to its password hash.
+	authMap bool
+}
+
+// getBasicAuth reads the HTTP authentication of ingress-nginx, the auth-type
+// annotation along with the Secret of the credentials of auth-secret, as
+// <namespace>/<name> or <name> in the namespace of the Ingress. Gateway API
+// routes don't authenticate their clients, so the authentication is reported
+// by a warning naming the Secret, and is never silently dropped. The basic
+// authentication is returned to be converted to the policies of a target
+// implementation, whose warning is only reported when no policy enforces it.
+func getBasicAuth(ingress networkingv1.Ingress, fieldPath *field.Path) (*basicAuth, *Warning) {
+	authType, ok := ingress.Annotations[authTypeAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	authType = strings.TrimSpace(authType)
+	warning := &Warning{
+		Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+		Field:   fieldPath.Key(authTypeAnnotation),
+	}
+
+	secret := strings.TrimSpace(ingress.Annotations[authSecretAnnotation])
+	if secret == "" {
+		warning.Message = fmt.Sprintf("SECURITY: the Ingress requires HTTP %s authentication without %s, which is not converted; Gateway API routes accept unauthenticated requests and the authentication must be configured on the Gateway implementation", authType, authSecretAnnotation)
+		return nil, warning
+	}
+	auth := &basicAuth{secretNamespace: ingress.Namespace, secretName: secret}
+	if namespace, name, found := strings.Cut(secret, "/"); found {
+		auth.secretNamespace, auth.secretName = namespace, name
+	}
+	auth.authMap = strings.TrimSpace(ingress.Annotations[authSecretTypeAnnotation]) == authSecretTypeAuthMap
+
+	if authType != "basic" {
+		warning.Message = fmt.Sprintf("SECURITY: the Ingress requires HTTP %s authentication with the credentials of the Secret %s/%s, which is not converted; Gateway API routes accept unauthenticated requests and the authentication must be configured on the Gateway implementation",
+			authType, auth.secretNamespace, auth.secretName)
+		return nil, warning
+	}
+	warning.Message = fmt.Sprintf("SECURITY: the Ingress requires HTTP basic authentication with the credentials of the Secret %s/%s, but Gateway API routes accept unauthenticated requests; the authentication must be enforced by the Gateway implementation",
+		auth.secretNamespace, auth.secretName)
+	return auth, warning
+}
+
+// basicAuthSecret returns the Secret of the basic authentication of the
+// paths, as <namespace>/<name>, empty when the requests aren't
+// authenticated.
+func (e *extra) basicAuthSecret() string {
+	if e == nil || e.basicAuth == nil {
+		return ""
+	}
+	return e.basicAuth.secretNamespace + "/" + e.basicAuth.secretName
+}
+
+// envoyGatewayBasicAuth returns the basicAuth of an Envoy Gateway
+// SecurityPolicy of namespace, referencing the Secret of the credentials.
+func envoyGatewayBasicAuth(namespace string, auth *basicAuth) map[string]interface{} {
+	users := map[string]interface{}{"name": auth.secretName}
+	if auth.secretNamespace != namespace {
+		users["namespace"] = auth.secretNamespace
+	}
+	return map[string]interface{}{"users": users}
+}
+
+// basicAuthSecretWarning returns the warning of the Envoy Gateway
+// SecurityPolicy authenticating the requests of the rule group. Envoy Gateway
+// reads an htpasswd file from the .htpasswd key of the Secret while
+// ingress-nginx reads it from the auth key, so the policy is a stub until the
+// Secret is updated.
+func basicAuthSecretWarning(rg *ingressRuleGroup, policyName string, auth *basicAuth) Warning {
+	format := "holds an htpasswd file in its auth key, which must be copied to a .htpasswd key"
+	if auth.authMap {
+		format = "maps every user to its password hash, which must be converted to an htpasswd file in a .htpasswd key"
+	}
+	message := fmt.Sprintf("the SecurityPolicy %s/%s authenticates the requests with the Secret %s/%s, read by Envoy Gateway from its .htpasswd key; the Secret of ingress-nginx %s",
+		rg.namespace, policyName, auth.secretNamespace, auth.secretName, format)
+	if auth.secretNamespace != rg.namespace {
+		message += fmt.Sprintf(", and a ReferenceGrant of namespace %s must allow the SecurityPolicies of namespace %s to reference it", auth.secretNamespace, rg.namespace)
+	}
+	return Warning{
+		Ingress: rg.rules[0].ingress,
+		Field:   field.NewPath(rg.rules[0].ingress.Name, "metadata", "annotations").Key(authSecretAnnotation),
+		Message: message,
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_getBasicAuth(t *testing.T) {
+	testCases := []struct {
+		name            string
+		annotations     map[string]string
+		expectedAuth    *basicAuth
+		expectedMessage string
+	}{{
+		name: "no authentication",
+	}, {
+		name:            "Secret of the namespace",
+		annotations:     map[string]string{authTypeAnnotation: "basic", authSecretAnnotation: "htpasswd"},
+		expectedAuth:    &basicAuth{secretNamespace: "test", secretName: "htpasswd"},
+		expectedMessage: "Secret test/htpasswd",
+	}, {
+		name:            "Secret of another namespace",
+		annotations:     map[string]string{authTypeAnnotation: "basic", authSecretAnnotation: "auth/htpasswd", authSecretTypeAnnotation: "auth-map"},
+		expectedAuth:    &basicAuth{secretNamespace: "auth", secretName: "htpasswd", authMap: true},
+		expectedMessage: "Secret auth/htpasswd",
+	}, {
+		name:            "digest authentication",
+		annotations:     map[string]string{authTypeAnnotation: "digest", authSecretAnnotation: "htdigest"},
+		expectedMessage: "HTTP digest authentication with the credentials of the Secret test/htdigest, which is not converted",
+	}, {
+		name:            "no Secret",
+		annotations:     map[string]string{authTypeAnnotation: "basic"},
+		expectedMessage: "without " + authSecretAnnotation,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: tc.annotations}}
+			auth, warning := getBasicAuth(ingress, field.NewPath("app", "metadata", "annotations"))
+			if diff := cmp.Diff(tc.expectedAuth, auth, cmp.AllowUnexported(basicAuth{})); diff != "" {
+				t.Errorf("Unexpected basic authentication (-want +got):\n%s", diff)
+			}
+			if tc.expectedMessage == "" {
+				if warning != nil {
+					t.Errorf("Expected no warning, got %+v", warning)
+				}
+				return
+			}
+			// The authentication must never be silently dropped.
+			if warning == nil || !strings.HasPrefix(warning.Message, "SECURITY: ") || !strings.Contains(warning.Message, tc.expectedMessage) {
+				t.Errorf("Expected a SECURITY warning containing %q, got %+v", tc.expectedMessage, warning)
+			}
+		})
+	}
+}
+
+func Test_envoyGatewayBasicAuthPolicies(t *testing.T) {
+	basic := map[string]string{authTypeAnnotation: "basic", authSecretAnnotation: "htpasswd"}
+
+	testCases := []struct {
+		name                 string
+		ingresses            []networkingv1.Ingress
+		targetImplementation string
+		expectedPolicies     []unstructured.Unstructured
+		expectedNumWarnings  int
+	}{{
+		name:                "warning only",
+		ingresses:           []networkingv1.Ingress{newNginxIngress("app", "/", basic)},
+		expectedNumWarnings: 1,
+	}, {
+		name:                 "Envoy Gateway SecurityPolicy",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", basic)},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedPolicies: []unstructured.Unstructured{
+			envoyGatewaySecurityPolicy("test", "example-com", []string{"HTTPRoute"}, nil, &basicAuth{secretNamespace: "test", secretName: "htpasswd"}),
+		},
+		expectedNumWarnings: 1,
+	}, {
+		name: "source IP allow-list and authentication",
+		ingresses: []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{
+			authTypeAnnotation:             "basic",
+			authSecretAnnotation:           "htpasswd",
+			whitelistSourceRangeAnnotation: "10.0.0.0/8",
+		})},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedPolicies: []unstructured.Unstructured{
+			envoyGatewaySecurityPolicy("test", "example-com", []string{"HTTPRoute"}, []string{"10.0.0.0/8"}, &basicAuth{secretNamespace: "test", secretName: "htpasswd"}),
+		},
+		expectedNumWarnings: 1,
+	}, {
+		name:                 "digest authentication",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{authTypeAnnotation: "digest", authSecretAnnotation: "htdigest"})},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "authentication on part of a host",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", nil), newNginxIngress("admin", "/admin", basic)},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedNumWarnings:  2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{TargetImplementation: tc.targetImplementation})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if diff := cmp.Diff(tc.expectedPolicies, result.Policies); diff != "" {
+				t.Errorf("Unexpected policies (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
+
+func Test_securityPolicyName(t *testing.T) {
+	auth := &basicAuth{secretNamespace: "test", secretName: "htpasswd"}
+	testCases := []struct {
+		cidrs    []string
+		auth     *basicAuth
+		expected string
+	}{
+		{cidrs: []string{"10.0.0.0/8"}, expected: "example-com-source-ranges"},
+		{auth: auth, expected: "example-com-basic-auth"},
+		{cidrs: []string{"10.0.0.0/8"}, auth: auth, expected: "example-com-security"},
+	}
+	for _, tc := range testCases {
+		if got := securityPolicyName("example-com", tc.cidrs, tc.auth); got != tc.expected {
+			t.Errorf("securityPolicyName(%v, %v) = %s, expected %s", tc.cidrs, tc.auth, got, tc.expected)
+		}
+	}
+}
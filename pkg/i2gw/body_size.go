@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	proxyBodySizeAnnotation = "nginx.ingress.kubernetes.io/proxy-body-size"
+
+	nginxGatewayAPIVersion = "gateway.nginx.org/v1alpha1"
+	// clientSettingsPolicyNameSuffix suffixes the name of the route a
+	// ClientSettingsPolicy configures.
+	clientSettingsPolicyNameSuffix = "-client-settings"
+)
+
+// nginxSizeRegexp matches the nginx sizes NGINX Gateway Fabric accepts.
+var nginxSizeRegexp = regexp.MustCompile(`^\d{1,4}(k|m|g)?$`)
+
+// getProxyBodySize reads the maximum size of the request bodies of
+// ingress-nginx, as an nginx size such as 8m. Sizes that NGINX Gateway Fabric
+// doesn't accept are reported as warnings and not converted.
+func getProxyBodySize(ingress networkingv1.Ingress, fieldPath *field.Path) (string, *Warning) {
+	value, ok := ingress.Annotations[proxyBodySizeAnnotation]
+	if !ok {
+		return "", nil
+	}
+	size := strings.ToLower(strings.TrimSpace(value))
+	if !nginxSizeRegexp.MatchString(size) {
+		return "", &Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(proxyBodySizeAnnotation),
+			Message: fmt.Sprintf("the body size %q is not converted, it must be a number of at most 4 digits with an optional k, m or g unit", value),
+		}
+	}
+	return size, nil
+}
+
+// clientBodySize returns the maximum size of the request bodies of the
+// paths, empty when it isn't set.
+func (e *extra) clientBodySize() string {
+	if e == nil {
+		return ""
+	}
+	return e.bodySize
+}
+
+// nginxGatewayFabricPolicies returns the ClientSettingsPolicies of the routes
+// of the rule group with a maximum request body size. A policy applies to all
+// the paths of its routes, so no policy is generated when the Ingresses of
+// the host have different sizes, and a warning is returned instead.
+func nginxGatewayFabricPolicies(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning) {
+	size, same := rg.commonValue((*extra).clientBodySize)
+	if !same {
+		return nil, []Warning{rg.differentValuesWarning(proxyBodySizeAnnotation,
+			fmt.Sprintf("the Ingresses of host %q have different body sizes, which a ClientSettingsPolicy of its routes can't represent; no ClientSettingsPolicy is generated", rg.host))}
+	}
+	if size == "" {
+		return nil, nil
+	}
+	var policies []unstructured.Unstructured
+	for _, kind := range kinds {
+		policyName := name + clientSettingsPolicyNameSuffix
+		// A ClientSettingsPolicy has a single targetRef, the GRPCRoute of the
+		// same name as an HTTPRoute gets its own policy.
+		if kind == "GRPCRoute" {
+			policyName = name + "-grpc" + clientSettingsPolicyNameSuffix
+		}
+		policies = append(policies, unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": nginxGatewayAPIVersion,
+			"kind":       "ClientSettingsPolicy",
+			"metadata": map[string]interface{}{
+				"name":      policyName,
+				"namespace": rg.namespace,
+			},
+			"spec": map[string]interface{}{
+				"targetRef": policyTargetRef(kind, name),
+				"body": map[string]interface{}{
+					"maxSize": size,
+				},
+			},
+		}})
+	}
+	return policies, nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_getProxyBodySize(t *testing.T) {
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		expectedSize  string
+		expectWarning bool
+	}{{
+		name: "no body size",
+	}, {
+		name:         "size with unit",
+		annotations:  map[string]string{proxyBodySizeAnnotation: "8M"},
+		expectedSize: "8m",
+	}, {
+		name:         "unlimited",
+		annotations:  map[string]string{proxyBodySizeAnnotation: "0"},
+		expectedSize: "0",
+	}, {
+		name:          "invalid size",
+		annotations:   map[string]string{proxyBodySizeAnnotation: "1.5m"},
+		expectWarning: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: tc.annotations}}
+			size, warning := getProxyBodySize(ingress, field.NewPath("app", "metadata", "annotations"))
+			if size != tc.expectedSize {
+				t.Errorf("Expected size %q, got %q", tc.expectedSize, size)
+			}
+			if (warning != nil) != tc.expectWarning {
+				t.Errorf("Expected warning: %v, got %+v", tc.expectWarning, warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Feature is a Gateway API feature the generated resources may rely on.
+type Feature string
+
+const (
+	FeatureHTTPRoute              Feature = "HTTPRoute"
+	FeatureGRPCRoute              Feature = "GRPCRoute"
+	FeatureTCPRoute               Feature = "TCPRoute"
+	FeatureTLSRoute               Feature = "TLSRoute"
+	FeatureTLSTermination         Feature = "HTTPS listener TLS termination"
+	FeatureExactPathMatch         Feature = "HTTPRoute Exact path match"
+	FeatureRegexPathMatch         Feature = "HTTPRoute RegularExpression path match"
+	FeatureHeaderMatch            Feature = "HTTPRoute Exact header match"
+	FeatureRegexHeaderMatch       Feature = "HTTPRoute RegularExpression header match"
+	FeatureMethodMatch            Feature = "HTTPRoute method match"
+	FeatureWeightedBackends       Feature = "weighted backendRefs"
+	FeatureCrossNamespaceBackends Feature = "cross namespace backendRefs"
+	FeatureQueryParamMatch        Feature = "HTTPRoute query param match"
+	FeatureRequestHeaderModifier  Feature = "RequestHeaderModifier filter"
+	FeatureResponseHeaderModifier Feature = "ResponseHeaderModifier filter"
+	FeatureRequestMirror          Feature = "HTTPRoute RequestMirror filter"
+	FeatureRequestRedirect        Feature = "HTTPRoute RequestRedirect filter"
+	FeatureURLRewrite             Feature = "HTTPRoute URLRewrite filter"
+	FeatureRouteTimeouts          Feature = "HTTPRoute timeouts"
+	FeatureSessionPersistence     Feature = "session persistence"
+	FeatureBackendTLSPolicy       Feature = "BackendTLSPolicy"
+)
+
+// implementationCapabilities is a best-effort table of the runtime support
+// of Gateway API implementations for the features generated by this tool.
+// Features missing from an implementation's entry are unsupported.
+var implementationCapabilities = map[string]map[Feature]bool{
+	"contour": {
+		FeatureHTTPRoute:              true,
+		FeatureGRPCRoute:              true,
+		FeatureTCPRoute:               true,
+		FeatureTLSRoute:               true,
+		FeatureTLSTermination:         true,
+		FeatureExactPathMatch:         true,
+		FeatureRegexPathMatch:         true,
+		FeatureHeaderMatch:            true,
+		FeatureRegexHeaderMatch:       true,
+		FeatureMethodMatch:            true,
+		FeatureWeightedBackends:       true,
+		FeatureCrossNamespaceBackends: true,
+		FeatureQueryParamMatch:        true,
+		FeatureRequestHeaderModifier:  true,
+		FeatureResponseHeaderModifier: true,
+		FeatureRequestMirror:          true,
+		FeatureRequestRedirect:        true,
+		FeatureURLRewrite:             true,
+		FeatureRouteTimeouts:          true,
+		FeatureBackendTLSPolicy:       true,
+	},
+	"envoy-gateway": {
+		FeatureHTTPRoute:              true,
+		FeatureGRPCRoute:              true,
+		FeatureTCPRoute:               true,
+		FeatureTLSRoute:               true,
+		FeatureTLSTermination:         true,
+		FeatureExactPathMatch:         true,
+		FeatureRegexPathMatch:         true,
+		FeatureHeaderMatch:            true,
+		FeatureRegexHeaderMatch:       true,
+		FeatureMethodMatch:            true,
+		FeatureWeightedBackends:       true,
+		FeatureCrossNamespaceBackends: true,
+		FeatureQueryParamMatch:        true,
+		FeatureRequestHeaderModifier:  true,
+		FeatureResponseHeaderModifier: true,
+		FeatureRequestMirror:          true,
+		FeatureRequestRedirect:        true,
+		FeatureURLRewrite:             true,
+		FeatureRouteTimeouts:          true,
+		FeatureSessionPersistence:     true,
+		FeatureBackendTLSPolicy:       true,
+	},
+	"istio": {
+		FeatureHTTPRoute:              true,
+		FeatureGRPCRoute:              true,
+		FeatureTCPRoute:               true,
+		FeatureTLSRoute:               true,
+		FeatureTLSTermination:         true,
+		FeatureExactPathMatch:         true,
+		FeatureRegexPathMatch:         true,
+		FeatureHeaderMatch:            true,
+		FeatureRegexHeaderMatch:       true,
+		FeatureMethodMatch:            true,
+		FeatureWeightedBackends:       true,
+		FeatureCrossNamespaceBackends: true,
+		FeatureQueryParamMatch:        true,
+		FeatureRequestHeaderModifier:  true,
+		FeatureResponseHeaderModifier: true,
+		FeatureRequestMirror:          true,
+		FeatureRequestRedirect:        true,
+		FeatureURLRewrite:             true,
+		FeatureRouteTimeouts:          true,
+	},
+	"kong": {
+		FeatureHTTPRoute:              true,
+		FeatureGRPCRoute:              true,
+		FeatureTCPRoute:               true,
+		FeatureTLSRoute:               true,
+		FeatureTLSTermination:         true,
+		FeatureExactPathMatch:         true,
+		FeatureRegexPathMatch:         true,
+		FeatureHeaderMatch:            true,
+		FeatureRegexHeaderMatch:       true,
+		FeatureMethodMatch:            true,
+		FeatureWeightedBackends:       true,
+		FeatureCrossNamespaceBackends: true,
+		FeatureQueryParamMatch:        true,
+		FeatureRequestHeaderModifier:  true,
+		FeatureResponseHeaderModifier: true,
+		FeatureRequestRedirect:        true,
+		FeatureURLRewrite:             true,
+	},
+	"nginx-gateway-fabric": {
+		FeatureHTTPRoute:              true,
+		FeatureGRPCRoute:              true,
+		FeatureTLSTermination:         true,
+		FeatureExactPathMatch:         true,
+		FeatureHeaderMatch:            true,
+		FeatureMethodMatch:            true,
+		FeatureWeightedBackends:       true,
+		FeatureCrossNamespaceBackends: true,
+		FeatureQueryParamMatch:        true,
+		FeatureRequestHeaderModifier:  true,
+		FeatureResponseHeaderModifier: true,
+		FeatureRequestRedirect:        true,
+		FeatureURLRewrite:             true,
+		FeatureBackendTLSPolicy:       true,
+	},
+}
+
+// httpFilterFeatures and grpcFilterFeatures are the features of the filters
+// of the generated routes, by filter type.
+var httpFilterFeatures = map[gatewayv1.HTTPRouteFilterType]Feature{
+	gatewayv1.HTTPRouteFilterRequestHeaderModifier:  FeatureRequestHeaderModifier,
+	gatewayv1.HTTPRouteFilterResponseHeaderModifier: FeatureResponseHeaderModifier,
+	gatewayv1.HTTPRouteFilterRequestMirror:          FeatureRequestMirror,
+	gatewayv1.HTTPRouteFilterRequestRedirect:        FeatureRequestRedirect,
+	gatewayv1.HTTPRouteFilterURLRewrite:             FeatureURLRewrite,
+}
+
+var grpcFilterFeatures = map[gatewayv1.GRPCRouteFilterType]Feature{
+	gatewayv1.GRPCRouteFilterRequestHeaderModifier:  FeatureRequestHeaderModifier,
+	gatewayv1.GRPCRouteFilterResponseHeaderModifier: FeatureResponseHeaderModifier,
+}
+
+// FeatureSupport reports whether an implementation supports a feature used
+// by the generated resources.
+type FeatureSupport struct {
+	Feature Feature
+	// Resources lists the generated resources relying on the feature, as
+	// <kind>/<namespace>/<name>.
+	Resources []string
+	Supported bool
+}
+
+// SupportedImplementations returns the implementations known to
+// CheckCompatibility.
+func SupportedImplementations() []string {
+	var names []string
+	for name := range implementationCapabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckCompatibility returns, for every feature used by the resources of the
+// conversion result, whether the implementation supports it at runtime.
+// Features are sorted by name.
+func CheckCompatibility(implementation string, result Result) ([]FeatureSupport, error) {
+	capabilities, ok := implementationCapabilities[implementation]
+	if !ok {
+		return nil, fmt.Errorf("unknown implementation %q, must be one of: %s", implementation, strings.Join(SupportedImplementations(), ", "))
+	}
+
+	used := usedFeatures(result)
+	var matrix []FeatureSupport
+	for feature, resources := range used {
+		matrix = append(matrix, FeatureSupport{
+			Feature:   feature,
+			Resources: resources,
+			Supported: capabilities[feature],
+		})
+	}
+	sort.Slice(matrix, func(i, j int) bool {
+		return matrix[i].Feature < matrix[j].Feature
+	})
+	return matrix, nil
+}
+
+// usedFeatures returns the features used by the resources of the conversion
+// result, along with the resources using them.
+func usedFeatures(result Result) map[Feature][]string {
+	used := map[Feature][]string{}
+	use := func(feature Feature, resource string) {
+		resources := used[feature]
+		if len(resources) > 0 && resources[len(resources)-1] == resource {
+			return
+		}
+		used[feature] = append(resources, resource)
+	}
+
+	for _, gw := range result.Gateways {
+		resource := fmt.Sprintf("Gateway/%s/%s", gw.Namespace, gw.Name)
+		for _, l := range gw.Spec.Listeners {
+			if l.Protocol == gatewayv1.HTTPSProtocolType && l.TLS != nil {
+				use(FeatureTLSTermination, resource)
+			}
+		}
+	}
+
+	for _, route := range result.HTTPRoutes {
+		resource := fmt.Sprintf("HTTPRoute/%s/%s", route.Namespace, route.Name)
+		use(FeatureHTTPRoute, resource)
+		for _, rule := range route.Spec.Rules {
+			for _, match := range rule.Matches {
+				if match.Path != nil && match.Path.Type != nil {
+					switch *match.Path.Type {
+					case gatewayv1.PathMatchExact:
+						use(FeatureExactPathMatch, resource)
+					case gatewayv1.PathMatchRegularExpression:
+						use(FeatureRegexPathMatch, resource)
+					}
+				}
+				for _, header := range match.Headers {
+					if header.Type != nil && *header.Type == gatewayv1.HeaderMatchRegularExpression {
+						use(FeatureRegexHeaderMatch, resource)
+					} else {
+						use(FeatureHeaderMatch, resource)
+					}
+				}
+				if match.Method != nil {
+					use(FeatureMethodMatch, resource)
+				}
+				if len(match.QueryParams) > 0 {
+					use(FeatureQueryParamMatch, resource)
+				}
+			}
+			for _, filter := range rule.Filters {
+				if feature, ok := httpFilterFeatures[filter.Type]; ok {
+					use(feature, resource)
+				}
+			}
+			for _, backendRef := range rule.BackendRefs {
+				if backendRef.Weight != nil && len(rule.BackendRefs) > 1 {
+					use(FeatureWeightedBackends, resource)
+				}
+				if backendRef.Namespace != nil && string(*backendRef.Namespace) != route.Namespace {
+					use(FeatureCrossNamespaceBackends, resource)
+				}
+				for _, filter := range backendRef.Filters {
+					if feature, ok := httpFilterFeatures[filter.Type]; ok {
+						use(feature, resource)
+					}
+				}
+			}
+			if rule.Timeouts != nil {
+				use(FeatureRouteTimeouts, resource)
+			}
+			if rule.SessionPersistence != nil {
+				use(FeatureSessionPersistence, resource)
+			}
+		}
+	}
+
+	for _, route := range result.GRPCRoutes {
+		resource := fmt.Sprintf("GRPCRoute/%s/%s", route.Namespace, route.Name)
+		use(FeatureGRPCRoute, resource)
+		for _, rule := range route.Spec.Rules {
+			for _, match := range rule.Matches {
+				for _, header := range match.Headers {
+					if header.Type != nil && *header.Type == gatewayv1.HeaderMatchRegularExpression {
+						use(FeatureRegexHeaderMatch, resource)
+					} else {
+						use(FeatureHeaderMatch, resource)
+					}
+				}
+			}
+			for _, filter := range rule.Filters {
+				if feature, ok := grpcFilterFeatures[filter.Type]; ok {
+					use(feature, resource)
+				}
+			}
+			for _, backendRef := range rule.BackendRefs {
+				if backendRef.Weight != nil && len(rule.BackendRefs) > 1 {
+					use(FeatureWeightedBackends, resource)
+				}
+				if backendRef.Namespace != nil && string(*backendRef.Namespace) != route.Namespace {
+					use(FeatureCrossNamespaceBackends, resource)
+				}
+				for _, filter := range backendRef.Filters {
+					if feature, ok := grpcFilterFeatures[filter.Type]; ok {
+						use(feature, resource)
+					}
+				}
+			}
+			if rule.SessionPersistence != nil {
+				use(FeatureSessionPersistence, resource)
+			}
+		}
+	}
+
+	for _, route := range result.TCPRoutes {
+		resource := fmt.Sprintf("TCPRoute/%s/%s", route.Namespace, route.Name)
+		use(FeatureTCPRoute, resource)
+		for _, rule := range route.Spec.Rules {
+			for _, backendRef := range rule.BackendRefs {
+				if backendRef.Namespace != nil && string(*backendRef.Namespace) != route.Namespace {
+					use(FeatureCrossNamespaceBackends, resource)
+				}
+			}
+		}
+	}
+
+	for _, route := range result.TLSRoutes {
+		resource := fmt.Sprintf("TLSRoute/%s/%s", route.Namespace, route.Name)
+		use(FeatureTLSRoute, resource)
+		for _, rule := range route.Spec.Rules {
+			for _, backendRef := range rule.BackendRefs {
+				if backendRef.Namespace != nil && string(*backendRef.Namespace) != route.Namespace {
+					use(FeatureCrossNamespaceBackends, resource)
+				}
+			}
+		}
+	}
+
+	for _, policy := range result.BackendTLSPolicies {
+		use(FeatureBackendTLSPolicy, fmt.Sprintf("BackendTLSPolicy/%s/%s", policy.Namespace, policy.Name))
+	}
+
+	return used
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_CheckCompatibility(t *testing.T) {
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+	hmRegex := gatewayv1.HeaderMatchRegularExpression
+
+	httpRoutes := []gatewayv1beta1.HTTPRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			Rules: []gatewayv1beta1.HTTPRouteRule{{
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{
+					Path: &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: stringPtr("/")},
+					Headers: []gatewayv1beta1.HTTPHeaderMatch{{
+						Type:  &hmRegex,
+						Name:  "X-Canary",
+						Value: "^always$",
+					}},
+				}},
+			}},
+		},
+	}}
+	tcpRoutes := []gatewayv1alpha2.TCPRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "tcp-services-9000", Namespace: "test"},
+	}}
+	tlsRoutes := []gatewayv1alpha2.TLSRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "secure-example-com", Namespace: "test"},
+	}}
+
+	testCases := []struct {
+		name           string
+		implementation string
+		expectedMatrix []FeatureSupport
+		expectingError bool
+	}{{
+		name:           "all features supported",
+		implementation: "envoy-gateway",
+		expectedMatrix: []FeatureSupport{{
+			Feature:   FeatureHTTPRoute,
+			Resources: []string{"HTTPRoute/test/example-com"},
+			Supported: true,
+		}, {
+			Feature:   FeatureRegexHeaderMatch,
+			Resources: []string{"HTTPRoute/test/example-com"},
+			Supported: true,
+		}, {
+			Feature:   FeatureTCPRoute,
+			Resources: []string{"TCPRoute/test/tcp-services-9000"},
+			Supported: true,
+		}, {
+			Feature:   FeatureTLSRoute,
+			Resources: []string{"TLSRoute/test/secure-example-com"},
+			Supported: true,
+		}},
+	}, {
+		name:           "unsupported features flagged",
+		implementation: "nginx-gateway-fabric",
+		expectedMatrix: []FeatureSupport{{
+			Feature:   FeatureHTTPRoute,
+			Resources: []string{"HTTPRoute/test/example-com"},
+			Supported: true,
+		}, {
+			Feature:   FeatureRegexHeaderMatch,
+			Resources: []string{"HTTPRoute/test/example-com"},
+			Supported: false,
+		}, {
+			Feature:   FeatureTCPRoute,
+			Resources: []string{"TCPRoute/test/tcp-services-9000"},
+			Supported: false,
+		}, {
+			Feature:   FeatureTLSRoute,
+			Resources: []string{"TLSRoute/test/secure-example-com"},
+			Supported: false,
+		}},
+	}, {
+		name:           "unknown implementation",
+		implementation: "unknown",
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matrix, err := CheckCompatibility(tc.implementation, Result{HTTPRoutes: httpRoutes, TCPRoutes: tcpRoutes, TLSRoutes: tlsRoutes})
+			if tc.expectingError != (err != nil) {
+				t.Fatalf("CheckCompatibility() error = %v, expecting error: %v", err, tc.expectingError)
+			}
+			if diff := cmp.Diff(tc.expectedMatrix, matrix); diff != "" {
+				t.Errorf("CheckCompatibility() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// compatibilityFields maps the fields of the generated resources to the
+// feature they rely on, or to no feature for the fields every implementation
+// of the resource kind supports. A field is covered by its longest prefix in
+// the table.
+var compatibilityFields = map[string]Feature{
+	"Gateway.spec.gatewayClassName":              "",
+	"Gateway.spec.listeners.name":                "",
+	"Gateway.spec.listeners.hostname":            "",
+	"Gateway.spec.listeners.port":                "",
+	"Gateway.spec.listeners.protocol":            "",
+	"Gateway.spec.listeners.allowedRoutes":       "",
+	"Gateway.spec.listeners.tls.mode":            "",
+	"Gateway.spec.listeners.tls.certificateRefs": FeatureTLSTermination,
+
+	"HTTPRoute.spec.hostnames":                            "",
+	"HTTPRoute.spec.parentRefs":                           "",
+	"HTTPRoute.spec.rules.matches.path":                   "",
+	"HTTPRoute.spec.rules.matches.headers":                FeatureHeaderMatch,
+	"HTTPRoute.spec.rules.matches.method":                 FeatureMethodMatch,
+	"HTTPRoute.spec.rules.matches.queryParams":            FeatureQueryParamMatch,
+	"HTTPRoute.spec.rules.filters.type":                   "",
+	"HTTPRoute.spec.rules.filters.requestHeaderModifier":  FeatureRequestHeaderModifier,
+	"HTTPRoute.spec.rules.filters.responseHeaderModifier": FeatureResponseHeaderModifier,
+	"HTTPRoute.spec.rules.filters.requestMirror":          FeatureRequestMirror,
+	"HTTPRoute.spec.rules.filters.requestRedirect":        FeatureRequestRedirect,
+	"HTTPRoute.spec.rules.filters.urlRewrite":             FeatureURLRewrite,
+	"HTTPRoute.spec.rules.backendRefs.name":               "",
+	"HTTPRoute.spec.rules.backendRefs.port":               "",
+	"HTTPRoute.spec.rules.backendRefs.namespace":          FeatureCrossNamespaceBackends,
+	"HTTPRoute.spec.rules.backendRefs.weight":             FeatureWeightedBackends,
+	"HTTPRoute.spec.rules.timeouts":                       FeatureRouteTimeouts,
+	"HTTPRoute.spec.rules.sessionPersistence":             FeatureSessionPersistence,
+
+	"GRPCRoute.spec.hostnames":                            "",
+	"GRPCRoute.spec.parentRefs":                           "",
+	"GRPCRoute.spec.rules.matches.method":                 "",
+	"GRPCRoute.spec.rules.matches.headers":                FeatureHeaderMatch,
+	"GRPCRoute.spec.rules.filters.type":                   "",
+	"GRPCRoute.spec.rules.filters.requestHeaderModifier":  FeatureRequestHeaderModifier,
+	"GRPCRoute.spec.rules.filters.responseHeaderModifier": FeatureResponseHeaderModifier,
+	"GRPCRoute.spec.rules.backendRefs.name":               "",
+	"GRPCRoute.spec.rules.backendRefs.port":               "",
+	"GRPCRoute.spec.rules.backendRefs.namespace":          FeatureCrossNamespaceBackends,
+	"GRPCRoute.spec.rules.backendRefs.weight":             FeatureWeightedBackends,
+	"GRPCRoute.spec.rules.sessionPersistence":             FeatureSessionPersistence,
+
+	"TCPRoute.spec.parentRefs":                  "",
+	"TCPRoute.spec.rules.backendRefs.name":      "",
+	"TCPRoute.spec.rules.backendRefs.port":      "",
+	"TCPRoute.spec.rules.backendRefs.namespace": FeatureCrossNamespaceBackends,
+
+	"TLSRoute.spec.hostnames":                   "",
+	"TLSRoute.spec.parentRefs":                  "",
+	"TLSRoute.spec.rules.backendRefs.name":      "",
+	"TLSRoute.spec.rules.backendRefs.port":      "",
+	"TLSRoute.spec.rules.backendRefs.namespace": FeatureCrossNamespaceBackends,
+
+	"BackendTLSPolicy.spec": FeatureBackendTLSPolicy,
+}
+
+func Test_compatibilityFields(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{name + ".example.com"}, SecretName: name}},
+				Rules: []networkingv1.IngressRule{{
+					Host: name + ".example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	ingresses := []networkingv1.Ingress{
+		newIngress("rewrite", map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/app"}),
+		newIngress("redirect", map[string]string{permanentRedirectAnnotation: "https://www.example.com/new"}),
+		newIngress("headers", map[string]string{upstreamVhostAnnotation: "internal.example.com"}),
+		newIngress("timeouts", map[string]string{proxyReadTimeoutAnnotation: "120"}),
+		newIngress("affinity", map[string]string{affinityAnnotation: "cookie"}),
+		newIngress("grpc", map[string]string{backendProtocolAnnotation: "GRPC", affinityAnnotation: "cookie"}),
+		newIngress("backend-tls", map[string]string{backendProtocolAnnotation: "HTTPS", proxySSLSecretAnnotation: "test/ca", proxySSLVerifyAnnotation: "on"}),
+		newIngress("passthrough", map[string]string{sslPassthroughAnnotation: "true"}),
+		newIngress("canary", map[string]string{"nginx.ingress.kubernetes.io/canary": "true", "nginx.ingress.kubernetes.io/canary-weight": "20"}),
+	}
+	canary := newIngress("canary", nil)
+	canary.Name = "stable"
+	ingresses = append(ingresses, canary)
+
+	var results []Result
+	result, err := Convert(ingresses, Options{APIVersion: APIVersionV1})
+	if err != nil {
+		t.Fatalf("Unexpected conversion error: %v", err)
+	}
+	results = append(results, result)
+	proxies, err := ConstructHTTPProxiesFromFile("testdata/httpproxy.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read the HTTPProxies: %v", err)
+	}
+	if result, err = ConvertHTTPProxies(proxies, Options{}); err != nil {
+		t.Fatalf("Unexpected HTTPProxy conversion error: %v", err)
+	}
+	results = append(results, result)
+	mappings, err := ConstructMappingsFromFile("testdata/mapping.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read the Mappings: %v", err)
+	}
+	if result, err = ConvertEmissary(mappings, Options{}); err != nil {
+		t.Fatalf("Unexpected Mapping conversion error: %v", err)
+	}
+	results = append(results, result)
+	gateways, virtualServices, err := ConstructIstioResourcesFromFile("testdata/istio.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read the Istio resources: %v", err)
+	}
+	if result, err = ConvertIstio(gateways, virtualServices, Options{}); err != nil {
+		t.Fatalf("Unexpected Istio conversion error: %v", err)
+	}
+	results = append(results, result)
+
+	for _, result := range results {
+		used := usedFeatures(result)
+		check := func(kind string, objs interface{}) {
+			v := reflect.ValueOf(objs)
+			for i := 0; i < v.Len(); i++ {
+				obj := v.Index(i)
+				resource := fmt.Sprintf("%s/%s/%s", kind, obj.FieldByName("Namespace").String(), obj.FieldByName("Name").String())
+				fields := map[string]bool{}
+				generatedFields(kind+".spec", obj.FieldByName("Spec"), fields)
+				for path := range fields {
+					feature, ok := compatibilityFeature(path)
+					if !ok {
+						t.Errorf("Field %s of %s has no entry in compatibilityFields", path, resource)
+						continue
+					}
+					if feature != "" && !slices.Contains(used[feature], resource) {
+						t.Errorf("Field %s of %s relies on %q, not reported by usedFeatures", path, resource, feature)
+					}
+				}
+			}
+		}
+		check("Gateway", result.Gateways)
+		check("HTTPRoute", result.HTTPRoutes)
+		check("GRPCRoute", result.GRPCRoutes)
+		check("TCPRoute", result.TCPRoutes)
+		check("TLSRoute", result.TLSRoutes)
+		check("BackendTLSPolicy", result.BackendTLSPolicies)
+	}
+}
+
+// compatibilityFeature returns the feature of the longest prefix of the
+// field path in compatibilityFields, and whether there is one.
+func compatibilityFeature(path string) (Feature, bool) {
+	for {
+		if feature, ok := compatibilityFields[path]; ok {
+			return feature, true
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return "", false
+		}
+		path = path[:i]
+	}
+}
+
+// generatedFields records the paths of the fields of v that are set and have
+// no fields of their own set, by the JSON names of the fields, without the
+// indexes of the lists.
+func generatedFields(path string, v reflect.Value, fields map[string]bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			generatedFields(path, v.Elem(), fields)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			generatedFields(path, v.Index(i), fields)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" || v.Field(i).IsZero() {
+				continue
+			}
+			if name == "" {
+				generatedFields(path, v.Field(i), fields)
+				continue
+			}
+			children := map[string]bool{}
+			generatedFields(path+"."+name, v.Field(i), children)
+			if len(children) == 0 {
+				children[path+"."+name] = true
+			}
+			for child := range children {
+				fields[child] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// SourceContextLabel is the label of the resources generated from the
+// Ingresses of a kubeconfig context, see MergeContextResults.
+const SourceContextLabel = "ingress2gateway.kubernetes.io/source-context"
+
+// ContextResult is the result of the conversion of the Ingresses of a
+// kubeconfig context.
+type ContextResult struct {
+	Context string
+	Result  Result
+}
+
+// resultObject is a resource of a Result, along with its kind.
+type resultObject struct {
+	kind string
+	obj  metav1.Object
+}
+
+func (o resultObject) key() string {
+	return fmt.Sprintf("%s/%s/%s", o.kind, o.obj.GetNamespace(), o.obj.GetName())
+}
+
+// resultObjects returns the resources of the Result.
+func resultObjects(result *Result) []resultObject {
+	var objs []resultObject
+	for i := range result.HTTPRoutes {
+		objs = append(objs, resultObject{kind: "HTTPRoute", obj: &result.HTTPRoutes[i]})
+	}
+	for i := range result.GRPCRoutes {
+		objs = append(objs, resultObject{kind: "GRPCRoute", obj: &result.GRPCRoutes[i]})
+	}
+	for i := range result.TCPRoutes {
+		objs = append(objs, resultObject{kind: "TCPRoute", obj: &result.TCPRoutes[i]})
+	}
+	for i := range result.TLSRoutes {
+		objs = append(objs, resultObject{kind: "TLSRoute", obj: &result.TLSRoutes[i]})
+	}
+	for i := range result.Gateways {
+		objs = append(objs, resultObject{kind: "Gateway", obj: &result.Gateways[i]})
+	}
+	for i := range result.BackendTLSPolicies {
+		objs = append(objs, resultObject{kind: "BackendTLSPolicy", obj: &result.BackendTLSPolicies[i]})
+	}
+	for i := range result.ReferenceGrants {
+		objs = append(objs, resultObject{kind: "ReferenceGrant", obj: &result.ReferenceGrants[i]})
+	}
+	for i := range result.Policies {
+		objs = append(objs, resultObject{kind: result.Policies[i].GetKind(), obj: &result.Policies[i]})
+	}
+	return objs
+}
+
+// MergeContextResults merges the results of the conversions of the Ingresses
+// of several kubeconfig contexts into a single Result. Every resource is
+// labeled with SourceContextLabel, whose value is the context made a DNS-1123
+// label, and the warnings are prefixed with their context. Resources of the
+// same kind, namespace and name generated from several contexts are suffixed
+// with their context, along with a warning, and the references of the routes
+// and policies of the context follow. Contexts that can't be told apart once
+// made DNS-1123 labels are reported as errors. The results are modified.
+func MergeContextResults(results []ContextResult) (Result, error) {
+	contexts := map[string]string{}
+	counts := map[string]int{}
+	for i := range results {
+		label, _ := toDNS1123Label(results[i].Context)
+		if other, ok := contexts[label]; ok {
+			return Result{}, fmt.Errorf("kubeconfig contexts %q and %q can't be told apart in the labels and names of the generated resources", other, results[i].Context)
+		}
+		contexts[label] = results[i].Context
+		for _, o := range resultObjects(&results[i].Result) {
+			counts[o.key()]++
+		}
+	}
+
+	var merged Result
+	for i := range results {
+		kubeContext := results[i].Context
+		result := &results[i].Result
+		label, _ := toDNS1123Label(kubeContext)
+		renamed := map[string]string{}
+		for _, o := range resultObjects(result) {
+			key := o.key()
+			if counts[key] > 1 {
+				name, _ := toDNS1123Label(o.obj.GetName() + "-" + label)
+				merged.Warnings = append(merged.Warnings, Warning{
+					Field:   field.NewPath(o.kind, o.obj.GetNamespace()+"/"+o.obj.GetName(), "metadata", "name"),
+					Message: fmt.Sprintf("the %s generated from kubeconfig context %s is named %q, as its name is shared by the resources generated from several contexts", o.kind, kubeContext, name),
+				})
+				renamed[key] = name
+				o.obj.SetName(name)
+			}
+			mergeLabels(o.obj, map[string]string{SourceContextLabel: label})
+		}
+		renameReferences(result, renamed)
+
+		merged.HTTPRoutes = append(merged.HTTPRoutes, result.HTTPRoutes...)
+		merged.GRPCRoutes = append(merged.GRPCRoutes, result.GRPCRoutes...)
+		merged.TCPRoutes = append(merged.TCPRoutes, result.TCPRoutes...)
+		merged.TLSRoutes = append(merged.TLSRoutes, result.TLSRoutes...)
+		merged.Gateways = append(merged.Gateways, result.Gateways...)
+		merged.BackendTLSPolicies = append(merged.BackendTLSPolicies, result.BackendTLSPolicies...)
+		merged.ReferenceGrants = append(merged.ReferenceGrants, result.ReferenceGrants...)
+		merged.Policies = append(merged.Policies, result.Policies...)
+		for _, w := range result.Warnings {
+			w.Message = fmt.Sprintf("kubeconfig context %s: %s", kubeContext, w.Message)
+			merged.Warnings = append(merged.Warnings, w)
+		}
+		merged.IngressErrors = append(merged.IngressErrors, result.IngressErrors...)
+	}
+	return merged, nil
+}
+
+// renameReferences updates the parentRefs of the routes, the targetRefs of
+// the policies and the routes of the warnings of the Result referencing the
+// renamed resources, by the <kind>/<namespace>/<name> of their former name.
+func renameReferences(result *Result, renamed map[string]string) {
+	if len(renamed) == 0 {
+		return
+	}
+	gateways := map[string]string{}
+	for key, name := range renamed {
+		if gateway, ok := strings.CutPrefix(key, "Gateway/"); ok {
+			gateways[gateway] = name
+		}
+	}
+	renameGatewayParentRefs(result, gateways)
+
+	for i := range result.Policies {
+		policy := &result.Policies[i]
+		spec, ok := policy.Object["spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var targetRefs []interface{}
+		if refs, ok := spec["targetRefs"].([]interface{}); ok {
+			targetRefs = refs
+		}
+		if ref, ok := spec["targetRef"]; ok {
+			targetRefs = append(targetRefs, ref)
+		}
+		for _, ref := range targetRefs {
+			targetRef, ok := ref.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, ok := renamed[fmt.Sprintf("%s/%s/%s", targetRef["kind"], policy.GetNamespace(), targetRef["name"])]; ok {
+				targetRef["name"] = name
+			}
+		}
+	}
+
+	for i := range result.Warnings {
+		route := result.Warnings[i].HTTPRoute
+		if name, ok := renamed[fmt.Sprintf("HTTPRoute/%s/%s", route.Namespace, route.Name)]; ok {
+			result.Warnings[i].HTTPRoute = types.NamespacedName{Namespace: route.Namespace, Name: name}
+		}
+	}
+}
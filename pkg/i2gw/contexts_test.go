@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_MergeContextResults(t *testing.T) {
+	contextResult := func(kubeContext string, routes ...string) ContextResult {
+		result := Result{
+			Gateways: []gatewayv1beta1.Gateway{{
+				ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			}},
+		}
+		for _, route := range routes {
+			result.HTTPRoutes = append(result.HTTPRoutes, gatewayv1beta1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: route, Namespace: "test"},
+				Spec: gatewayv1beta1.HTTPRouteSpec{
+					CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+						ParentRefs: []gatewayv1beta1.ParentReference{{Name: "nginx"}},
+					},
+				},
+			})
+		}
+		result.Policies = []unstructured.Unstructured{{Object: map[string]interface{}{
+			"apiVersion": "gateway.envoyproxy.io/v1alpha1",
+			"kind":       "SecurityPolicy",
+			"metadata":   map[string]interface{}{"name": routes[0], "namespace": "test"},
+			"spec": map[string]interface{}{
+				"targetRefs": []interface{}{policyTargetRef("HTTPRoute", routes[0])},
+			},
+		}}}
+		result.Warnings = []Warning{{
+			HTTPRoute: types.NamespacedName{Namespace: "test", Name: routes[0]},
+			Message:   "warning",
+		}}
+		return ContextResult{Context: kubeContext, Result: result}
+	}
+
+	merged, err := MergeContextResults([]ContextResult{
+		contextResult("prod-eu", "foo"),
+		contextResult("arn:aws:eks:us-east-1:1234:cluster/prod-us", "foo", "bar"),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	gatewayNames := []string{"nginx-prod-eu", "nginx-arn-aws-eks-us-east-1-1234-cluster-prod-us"}
+	if len(merged.Gateways) != 2 {
+		t.Fatalf("Expected 2 Gateways, got %+v", merged.Gateways)
+	}
+	for i, name := range gatewayNames {
+		if merged.Gateways[i].Name != name {
+			t.Errorf("Expected Gateway %d to be named %s, got %s", i, name, merged.Gateways[i].Name)
+		}
+	}
+	expectedRoutes := []struct {
+		name, gateway, context string
+	}{
+		{name: "foo-prod-eu", gateway: gatewayNames[0], context: "prod-eu"},
+		{name: "foo-arn-aws-eks-us-east-1-1234-cluster-prod-us", gateway: gatewayNames[1], context: "arn-aws-eks-us-east-1-1234-cluster-prod-us"},
+		{name: "bar", gateway: gatewayNames[1], context: "arn-aws-eks-us-east-1-1234-cluster-prod-us"},
+	}
+	if len(merged.HTTPRoutes) != len(expectedRoutes) {
+		t.Fatalf("Expected %d HTTPRoutes, got %+v", len(expectedRoutes), merged.HTTPRoutes)
+	}
+	for i, want := range expectedRoutes {
+		route := merged.HTTPRoutes[i]
+		if route.Name != want.name {
+			t.Errorf("Expected HTTPRoute %d to be named %s, got %s", i, want.name, route.Name)
+		}
+		if string(route.Spec.ParentRefs[0].Name) != want.gateway {
+			t.Errorf("Expected HTTPRoute %s to be attached to Gateway %s, got %s", route.Name, want.gateway, route.Spec.ParentRefs[0].Name)
+		}
+		if route.Labels[SourceContextLabel] != want.context {
+			t.Errorf("Expected HTTPRoute %s to be labeled with context %s, got %v", route.Name, want.context, route.Labels)
+		}
+	}
+
+	targetRef := merged.Policies[0].Object["spec"].(map[string]interface{})["targetRefs"].([]interface{})[0].(map[string]interface{})
+	if targetRef["name"] != "foo-prod-eu" {
+		t.Errorf("Expected the policy to target HTTPRoute foo-prod-eu, got %v", targetRef)
+	}
+	if merged.Policies[0].GetName() != "foo-prod-eu" {
+		t.Errorf("Expected the policy to be named foo-prod-eu, got %s", merged.Policies[0].GetName())
+	}
+
+	// A warning for every renamed Gateway, HTTPRoute and policy, along with
+	// the warnings of the contexts.
+	if len(merged.Warnings) != 8 {
+		t.Fatalf("Expected 8 warnings, got %d: %+v", len(merged.Warnings), merged.Warnings)
+	}
+	var contextWarnings []Warning
+	for _, w := range merged.Warnings {
+		if w.HTTPRoute.Name != "" {
+			contextWarnings = append(contextWarnings, w)
+		}
+	}
+	if len(contextWarnings) != 2 || contextWarnings[0].HTTPRoute.Name != "foo-prod-eu" || contextWarnings[0].Message != "kubeconfig context prod-eu: warning" {
+		t.Errorf("Expected the warnings of the contexts to follow their routes, got %+v", contextWarnings)
+	}
+}
+
+func Test_MergeContextResultsIndistinguishableContexts(t *testing.T) {
+	_, err := MergeContextResults([]ContextResult{{Context: "Prod"}, {Context: "prod"}})
+	if err == nil {
+		t.Errorf("Expected an error for contexts with the same label")
+	}
+}
@@ -0,0 +1,695 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// contourGatewayClass is the class of the Gateways generated from HTTPProxies
+// without ingressClassName.
+const contourGatewayClass = "contour"
+
+var httpProxyGVK = schema.GroupVersionKind{
+	Group:   "projectcontour.io",
+	Version: "v1",
+	Kind:    "HTTPProxy",
+}
+
+// HTTPProxy is the subset of the Contour projectcontour.io/v1 HTTPProxy
+// resource read by the conversion. Fields of features that can't be
+// converted are only read to report them.
+type HTTPProxy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              HTTPProxySpec `json:"spec,omitempty"`
+}
+
+// HTTPProxySpec is the spec of an HTTPProxy.
+type HTTPProxySpec struct {
+	VirtualHost      *ContourVirtualHost    `json:"virtualhost,omitempty"`
+	Routes           []ContourRoute         `json:"routes,omitempty"`
+	Includes         []ContourInclude       `json:"includes,omitempty"`
+	TCPProxy         map[string]interface{} `json:"tcpproxy,omitempty"`
+	IngressClassName string                 `json:"ingressClassName,omitempty"`
+}
+
+// ContourVirtualHost is the virtual host served by a root HTTPProxy.
+type ContourVirtualHost struct {
+	Fqdn            string                 `json:"fqdn"`
+	TLS             *ContourTLS            `json:"tls,omitempty"`
+	RateLimitPolicy map[string]interface{} `json:"rateLimitPolicy,omitempty"`
+	Authorization   map[string]interface{} `json:"authorization,omitempty"`
+	CORSPolicy      map[string]interface{} `json:"corsPolicy,omitempty"`
+	JWTProviders    []interface{}          `json:"jwtProviders,omitempty"`
+	IPAllowPolicy   []interface{}          `json:"ipAllowPolicy,omitempty"`
+	IPDenyPolicy    []interface{}          `json:"ipDenyPolicy,omitempty"`
+}
+
+// ContourTLS is the TLS configuration of a virtual host.
+type ContourTLS struct {
+	SecretName  string `json:"secretName,omitempty"`
+	Passthrough bool   `json:"passthrough,omitempty"`
+}
+
+// ContourInclude includes the routes of another HTTPProxy, under the
+// conditions of the include.
+type ContourInclude struct {
+	Name       string                  `json:"name"`
+	Namespace  string                  `json:"namespace,omitempty"`
+	Conditions []ContourMatchCondition `json:"conditions,omitempty"`
+}
+
+// ContourMatchCondition is a path, header or query parameter condition of
+// a route or an include.
+type ContourMatchCondition struct {
+	Prefix         string                       `json:"prefix,omitempty"`
+	Exact          string                       `json:"exact,omitempty"`
+	Header         *ContourHeaderMatchCondition `json:"header,omitempty"`
+	QueryParameter *ContourHeaderMatchCondition `json:"queryParameter,omitempty"`
+}
+
+// ContourHeaderMatchCondition matches a header or a query parameter.
+type ContourHeaderMatchCondition struct {
+	Name        string `json:"name"`
+	Present     bool   `json:"present,omitempty"`
+	NotPresent  bool   `json:"notpresent,omitempty"`
+	Contains    string `json:"contains,omitempty"`
+	NotContains string `json:"notcontains,omitempty"`
+	Exact       string `json:"exact,omitempty"`
+	NotExact    string `json:"notexact,omitempty"`
+	Regex       string `json:"regex,omitempty"`
+}
+
+// ContourRoute routes the requests matching its conditions to services.
+type ContourRoute struct {
+	Conditions            []ContourMatchCondition `json:"conditions,omitempty"`
+	Services              []ContourService        `json:"services,omitempty"`
+	RequestHeadersPolicy  *ContourHeadersPolicy   `json:"requestHeadersPolicy,omitempty"`
+	ResponseHeadersPolicy *ContourHeadersPolicy   `json:"responseHeadersPolicy,omitempty"`
+
+	TimeoutPolicy         map[string]interface{} `json:"timeoutPolicy,omitempty"`
+	RetryPolicy           map[string]interface{} `json:"retryPolicy,omitempty"`
+	RateLimitPolicy       map[string]interface{} `json:"rateLimitPolicy,omitempty"`
+	AuthPolicy            map[string]interface{} `json:"authPolicy,omitempty"`
+	JWTVerificationPolicy map[string]interface{} `json:"jwtVerificationPolicy,omitempty"`
+	PathRewritePolicy     map[string]interface{} `json:"pathRewritePolicy,omitempty"`
+	LoadBalancerPolicy    map[string]interface{} `json:"loadBalancerPolicy,omitempty"`
+	HealthCheckPolicy     map[string]interface{} `json:"healthCheckPolicy,omitempty"`
+	RequestRedirectPolicy map[string]interface{} `json:"requestRedirectPolicy,omitempty"`
+	DirectResponsePolicy  map[string]interface{} `json:"directResponsePolicy,omitempty"`
+	IPAllowPolicy         []interface{}          `json:"ipAllowPolicy,omitempty"`
+	IPDenyPolicy          []interface{}          `json:"ipDenyPolicy,omitempty"`
+}
+
+// ContourService is a backend Service of a route.
+type ContourService struct {
+	Name     string `json:"name"`
+	Port     int    `json:"port"`
+	Weight   int64  `json:"weight,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Mirror   bool   `json:"mirror,omitempty"`
+}
+
+// ContourHeadersPolicy sets and removes request or response headers.
+type ContourHeadersPolicy struct {
+	Set    []ContourHeaderValue `json:"set,omitempty"`
+	Remove []string             `json:"remove,omitempty"`
+}
+
+// ContourHeaderValue is a header set by a ContourHeadersPolicy.
+type ContourHeaderValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// unsupported returns the fields of the virtual host that can't be converted.
+func (vh *ContourVirtualHost) unsupported() []string {
+	var fields []string
+	for name, set := range map[string]bool{
+		"rateLimitPolicy": vh.RateLimitPolicy != nil,
+		"authorization":   vh.Authorization != nil,
+		"corsPolicy":      vh.CORSPolicy != nil,
+		"jwtProviders":    len(vh.JWTProviders) > 0,
+		"ipAllowPolicy":   len(vh.IPAllowPolicy) > 0,
+		"ipDenyPolicy":    len(vh.IPDenyPolicy) > 0,
+	} {
+		if set {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// unsupported returns the fields of the route that can't be converted.
+func (r *ContourRoute) unsupported() []string {
+	var fields []string
+	for name, set := range map[string]bool{
+		"timeoutPolicy":         r.TimeoutPolicy != nil,
+		"retryPolicy":           r.RetryPolicy != nil,
+		"rateLimitPolicy":       r.RateLimitPolicy != nil,
+		"authPolicy":            r.AuthPolicy != nil,
+		"jwtVerificationPolicy": r.JWTVerificationPolicy != nil,
+		"pathRewritePolicy":     r.PathRewritePolicy != nil,
+		"loadBalancerPolicy":    r.LoadBalancerPolicy != nil,
+		"healthCheckPolicy":     r.HealthCheckPolicy != nil,
+		"requestRedirectPolicy": r.RequestRedirectPolicy != nil,
+		"directResponsePolicy":  r.DirectResponsePolicy != nil,
+		"ipAllowPolicy":         len(r.IPAllowPolicy) > 0,
+		"ipDenyPolicy":          len(r.IPDenyPolicy) > 0,
+	} {
+		if set {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ConstructHTTPProxiesFromFile reads the inputFile in either json/yaml
+// formats, then deserialize the HTTPProxies it contains. When namespace is
+// set, only the HTTPProxies of that namespace are returned. The documents
+// failing to be parsed are returned as ManifestErrors along with the
+// HTTPProxies of the other documents.
+func ConstructHTTPProxiesFromFile(inputFile string, namespace string, format string) ([]HTTPProxy, error) {
+	objs, err := readObjectsFromFile(inputFile, format)
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) && err != nil {
+		return nil, err
+	}
+	proxies, err := httpProxiesFromUnstructured(objs, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifestErrs) > 0 {
+		return proxies, manifestErrs
+	}
+	return proxies, nil
+}
+
+// ConstructHTTPProxiesFromCluster lists the HTTPProxies of the namespace, or
+// of all namespaces when namespace is empty, from the cluster.
+func ConstructHTTPProxiesFromCluster(ctx context.Context, cl client.Client, namespace string) ([]HTTPProxy, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(httpProxyGVK.GroupVersion().WithKind(httpProxyGVK.Kind + "List"))
+	if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to get HTTPProxies from the cluster: %w", err)
+	}
+	var objs []*unstructured.Unstructured
+	for i := range list.Items {
+		objs = append(objs, &list.Items[i])
+	}
+	return httpProxiesFromUnstructured(objs, "")
+}
+
+func httpProxiesFromUnstructured(objs []*unstructured.Unstructured, namespace string) ([]HTTPProxy, error) {
+	var proxies []HTTPProxy
+	for _, obj := range objs {
+		if obj.GroupVersionKind().GroupKind() != httpProxyGVK.GroupKind() {
+			continue
+		}
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		var proxy HTTPProxy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &proxy); err != nil {
+			return nil, fmt.Errorf("failed to parse HTTPProxy %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
+
+// HTTPProxies2GatewaysAndHTTPRoutes converts the root HTTPProxies, the ones
+// with a virtual host, into HTTPRoutes along with the routes of the
+// HTTPProxies they include, and into the Gateway listeners of their virtual
+// hosts. Features of Contour that can't be represented in Gateway API are
+// reported through the returned warnings.
+func HTTPProxies2GatewaysAndHTTPRoutes(proxies []HTTPProxy) ([]gatewayv1beta1.HTTPRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	var httpRoutes []gatewayv1beta1.HTTPRoute
+	var warnings []Warning
+	var errors field.ErrorList
+
+	byName := map[types.NamespacedName]*HTTPProxy{}
+	for i := range proxies {
+		byName[types.NamespacedName{Namespace: proxies[i].Namespace, Name: proxies[i].Name}] = &proxies[i]
+	}
+
+	included := map[types.NamespacedName]bool{}
+	gatewaysByKey := map[string]*gatewayv1beta1.Gateway{}
+	var gwKeys []string
+	for i := range proxies {
+		proxy := &proxies[i]
+		key := types.NamespacedName{Namespace: proxy.Namespace, Name: proxy.Name}
+		if proxy.Spec.VirtualHost == nil {
+			continue
+		}
+		vh := proxy.Spec.VirtualHost
+		vhPath := field.NewPath(proxy.Name, "spec", "virtualhost")
+		if vh.Fqdn == "" {
+			errors = append(errors, field.Required(vhPath.Child("fqdn"), "root HTTPProxies must have a fqdn"))
+			continue
+		}
+		for _, name := range vh.unsupported() {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   vhPath.Child(name),
+				Message: fmt.Sprintf("%s is not supported by Gateway API and must be configured on the Gateway implementation", name),
+			})
+		}
+		if proxy.Spec.TCPProxy != nil {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   field.NewPath(proxy.Name, "spec", "tcpproxy"),
+				Message: "tcpproxy cannot be converted to an HTTPRoute and must be converted manually",
+			})
+		}
+
+		gatewayClass := proxy.Spec.IngressClassName
+		if gatewayClass == "" {
+			gatewayClass = contourGatewayClass
+		}
+		gwKey := fmt.Sprintf("%s/%s", proxy.Namespace, gatewayClass)
+		gateway, ok := gatewaysByKey[gwKey]
+		if !ok {
+			gateway = &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Namespace: proxy.Namespace, Name: gatewayClass},
+				Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: gatewayv1beta1.ObjectName(gatewayClass)},
+			}
+			gateway.SetGroupVersionKind(gatewayGVK)
+			gatewaysByKey[gwKey] = gateway
+			gwKeys = append(gwKeys, gwKey)
+		}
+		hostname := gatewayv1beta1.Hostname(vh.Fqdn)
+		prefix := listenerNamePrefix(&hostname)
+		gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
+			Name:     gatewayv1beta1.SectionName(prefix + "http"),
+			Hostname: &hostname,
+			Port:     80,
+			Protocol: gatewayv1.HTTPProtocolType,
+		})
+		if vh.TLS != nil && vh.TLS.Passthrough {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   vhPath.Child("tls", "passthrough"),
+				Message: "TLS passthrough requires a TLSRoute and must be converted manually",
+			})
+		} else if vh.TLS != nil && vh.TLS.SecretName != "" {
+			certRef := gatewayv1beta1.SecretObjectReference{Name: gatewayv1beta1.ObjectName(vh.TLS.SecretName)}
+			// Contour accepts Secrets of other namespaces as <namespace>/<name>.
+			if ns, name, ok := strings.Cut(vh.TLS.SecretName, "/"); ok {
+				certRef.Name = gatewayv1beta1.ObjectName(name)
+				certRef.Namespace = (*gatewayv1beta1.Namespace)(&ns)
+			}
+			gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1beta1.Listener{
+				Name:     gatewayv1beta1.SectionName(prefix + "https"),
+				Hostname: &hostname,
+				Port:     443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+				TLS: &gatewayv1beta1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1beta1.SecretObjectReference{certRef},
+				},
+			})
+		}
+
+		httpRoute := gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: proxy.Name, Namespace: proxy.Namespace},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+					ParentRefs: []gatewayv1beta1.ParentReference{{Name: gatewayv1beta1.ObjectName(gatewayClass)}},
+				},
+				Hostnames: []gatewayv1beta1.Hostname{hostname},
+			},
+			Status: gatewayv1beta1.HTTPRouteStatus{
+				RouteStatus: gatewayv1beta1.RouteStatus{
+					Parents: []gatewayv1beta1.RouteParentStatus{},
+				},
+			},
+		}
+		httpRoute.SetGroupVersionKind(httpRouteGVK)
+
+		c := &httpProxyConverter{
+			root:     proxy,
+			byName:   byName,
+			included: included,
+			visited:  map[types.NamespacedName]bool{},
+		}
+		c.convert(proxy, nil, field.NewPath(proxy.Name, "spec"))
+		httpRoute.Spec.Rules = c.rules
+		for i := range c.warnings {
+			c.warnings[i].HTTPRoute = types.NamespacedName{Namespace: httpRoute.Namespace, Name: httpRoute.Name}
+		}
+		warnings = append(warnings, c.warnings...)
+		errors = append(errors, c.errors...)
+		httpRoutes = append(httpRoutes, httpRoute)
+	}
+
+	for _, proxy := range proxies {
+		key := types.NamespacedName{Namespace: proxy.Namespace, Name: proxy.Name}
+		if proxy.Spec.VirtualHost == nil && !included[key] {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   field.NewPath(proxy.Name, "spec"),
+				Message: "HTTPProxy has no virtualhost and isn't included by any root HTTPProxy, so it isn't converted",
+			})
+		}
+	}
+
+	var gateways []gatewayv1beta1.Gateway
+	for _, gwKey := range gwKeys {
+		gateways = append(gateways, *gatewaysByKey[gwKey])
+	}
+	return httpRoutes, gateways, warnings, errors
+}
+
+// httpProxyConverter converts the routes of a root HTTPProxy, and of the
+// HTTPProxies it includes, into the rules of a single HTTPRoute.
+type httpProxyConverter struct {
+	root     *HTTPProxy
+	byName   map[types.NamespacedName]*HTTPProxy
+	included map[types.NamespacedName]bool
+	// visited holds the HTTPProxies of the current include chain, to detect
+	// include cycles.
+	visited map[types.NamespacedName]bool
+
+	rules    []gatewayv1beta1.HTTPRouteRule
+	warnings []Warning
+	errors   field.ErrorList
+}
+
+// convert converts the routes of the proxy, matching the conditions of the
+// includes leading to it in addition to their own, and recurses into the
+// HTTPProxies it includes.
+func (c *httpProxyConverter) convert(proxy *HTTPProxy, conditions []ContourMatchCondition, path *field.Path) {
+	key := types.NamespacedName{Namespace: proxy.Namespace, Name: proxy.Name}
+	c.visited[key] = true
+	defer delete(c.visited, key)
+
+	for i, route := range proxy.Spec.Routes {
+		routePath := path.Child("routes").Index(i)
+		for _, name := range route.unsupported() {
+			c.warnings = append(c.warnings, Warning{
+				Ingress: key,
+				Field:   routePath.Child(name),
+				Message: fmt.Sprintf("%s is not supported by Gateway API and must be configured on the Gateway implementation", name),
+			})
+		}
+		rule, ok := c.toHTTPRouteRule(proxy, route, append(append([]ContourMatchCondition{}, conditions...), route.Conditions...), routePath)
+		if ok {
+			c.rules = append(c.rules, rule)
+		}
+	}
+
+	for i, include := range proxy.Spec.Includes {
+		includePath := path.Child("includes").Index(i)
+		target := types.NamespacedName{Namespace: include.Namespace, Name: include.Name}
+		if target.Namespace == "" {
+			target.Namespace = proxy.Namespace
+		}
+		included, ok := c.byName[target]
+		if !ok {
+			c.errors = append(c.errors, field.NotFound(includePath, target.String()))
+			continue
+		}
+		if c.visited[target] {
+			c.errors = append(c.errors, field.Invalid(includePath, target.String(), "include cycle"))
+			continue
+		}
+		c.included[target] = true
+		c.convert(included, append(append([]ContourMatchCondition{}, conditions...), include.Conditions...), field.NewPath(included.Name, "spec"))
+	}
+}
+
+func (c *httpProxyConverter) toHTTPRouteRule(proxy *HTTPProxy, route ContourRoute, conditions []ContourMatchCondition, path *field.Path) (gatewayv1beta1.HTTPRouteRule, bool) {
+	key := types.NamespacedName{Namespace: proxy.Namespace, Name: proxy.Name}
+	var rule gatewayv1beta1.HTTPRouteRule
+
+	match := gatewayv1beta1.HTTPRouteMatch{}
+	pathValue := ""
+	pathType := gatewayv1.PathMatchPathPrefix
+	for _, condition := range conditions {
+		switch {
+		case condition.Prefix != "":
+			pathValue = joinContourPrefix(pathValue, condition.Prefix)
+		case condition.Exact != "":
+			pathValue = joinContourPrefix(pathValue, condition.Exact)
+			pathType = gatewayv1.PathMatchExact
+		case condition.Header != nil:
+			headerMatch, ok := toContourHeaderMatch(*condition.Header)
+			if !ok {
+				c.warnings = append(c.warnings, Warning{
+					Ingress: key,
+					Field:   path.Child("conditions"),
+					Message: fmt.Sprintf("header condition on %q can only be converted with exact or regex values, the route isn't converted", condition.Header.Name),
+				})
+				return rule, false
+			}
+			match.Headers = append(match.Headers, headerMatch)
+		case condition.QueryParameter != nil:
+			queryMatch, ok := toContourQueryParamMatch(*condition.QueryParameter)
+			if !ok {
+				c.warnings = append(c.warnings, Warning{
+					Ingress: key,
+					Field:   path.Child("conditions"),
+					Message: fmt.Sprintf("query parameter condition on %q can only be converted with exact or regex values, the route isn't converted", condition.QueryParameter.Name),
+				})
+				return rule, false
+			}
+			match.QueryParams = append(match.QueryParams, queryMatch)
+		}
+	}
+	if pathValue == "" {
+		pathValue = "/"
+	}
+	match.Path = &gatewayv1beta1.HTTPPathMatch{Type: &pathType, Value: pointer.String(pathValue)}
+	rule.Matches = []gatewayv1beta1.HTTPRouteMatch{match}
+
+	if route.RequestHeadersPolicy != nil {
+		rule.Filters = append(rule.Filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: toContourHeaderFilter(*route.RequestHeadersPolicy),
+		})
+	}
+	if route.ResponseHeadersPolicy != nil {
+		rule.Filters = append(rule.Filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                   gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: toContourHeaderFilter(*route.ResponseHeadersPolicy),
+		})
+	}
+
+	weighted := false
+	for _, svc := range route.Services {
+		weighted = weighted || svc.Weight > 0
+	}
+	for i, svc := range route.Services {
+		backendRef := gatewayv1beta1.BackendRef{
+			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+				Name: gatewayv1beta1.ObjectName(svc.Name),
+				Port: (*gatewayv1beta1.PortNumber)(pointer.Int32(int32(svc.Port))),
+			},
+		}
+		if proxy.Namespace != c.root.Namespace {
+			backendRef.Namespace = (*gatewayv1beta1.Namespace)(pointer.String(proxy.Namespace))
+		}
+		if svc.Protocol != "" {
+			c.warnings = append(c.warnings, Warning{
+				Ingress: key,
+				Field:   path.Child("services").Index(i).Child("protocol"),
+				Message: fmt.Sprintf("the %s protocol to the backend must be configured on the Gateway implementation", svc.Protocol),
+			})
+		}
+		if svc.Mirror {
+			rule.Filters = append(rule.Filters, gatewayv1beta1.HTTPRouteFilter{
+				Type:          gatewayv1.HTTPRouteFilterRequestMirror,
+				RequestMirror: &gatewayv1beta1.HTTPRequestMirrorFilter{BackendRef: backendRef.BackendObjectReference},
+			})
+			continue
+		}
+		// Contour doesn't route to the services without weight when others
+		// have one, while Gateway API defaults the weight to 1.
+		if weighted {
+			backendRef.Weight = pointer.Int32(int32(svc.Weight))
+		}
+		rule.BackendRefs = append(rule.BackendRefs, gatewayv1beta1.HTTPBackendRef{BackendRef: backendRef})
+	}
+	return rule, true
+}
+
+// contourReferenceGrants returns the ReferenceGrants allowing the references
+// of the HTTPRoutes and Gateways to other namespaces: the Services of the
+// HTTPProxies included from other namespaces, which Contour allows without
+// grant, and the TLS Secrets delegated by TLSCertificateDelegations.
+func contourReferenceGrants(httpRoutes []gatewayv1beta1.HTTPRoute, gateways []gatewayv1beta1.Gateway) []gatewayv1beta1.ReferenceGrant {
+	var grants []gatewayv1beta1.ReferenceGrant
+
+	// The Services of every namespace, by namespace of the HTTPRoutes
+	// referencing them.
+	services := map[string]map[string]sets.Set[string]{}
+	addService := func(routeNamespace string, ref gatewayv1beta1.BackendObjectReference) {
+		if ref.Namespace == nil || string(*ref.Namespace) == routeNamespace {
+			return
+		}
+		if services[routeNamespace] == nil {
+			services[routeNamespace] = map[string]sets.Set[string]{}
+		}
+		ns := string(*ref.Namespace)
+		if services[routeNamespace][ns] == nil {
+			services[routeNamespace][ns] = sets.New[string]()
+		}
+		services[routeNamespace][ns].Insert(string(ref.Name))
+	}
+	for _, route := range httpRoutes {
+		for _, rule := range route.Spec.Rules {
+			for _, backendRef := range rule.BackendRefs {
+				addService(route.Namespace, backendRef.BackendObjectReference)
+			}
+			for _, filter := range rule.Filters {
+				if filter.RequestMirror != nil {
+					addService(route.Namespace, filter.RequestMirror.BackendRef)
+				}
+			}
+		}
+	}
+	for _, routeNamespace := range sets.List(sets.KeySet(services)) {
+		for _, ns := range sets.List(sets.KeySet(services[routeNamespace])) {
+			grant := gatewayv1beta1.ReferenceGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "httproutes-" + routeNamespace, Namespace: ns},
+				Spec: gatewayv1beta1.ReferenceGrantSpec{
+					From: []gatewayv1beta1.ReferenceGrantFrom{{
+						Group:     gatewayv1.GroupName,
+						Kind:      "HTTPRoute",
+						Namespace: gatewayv1.Namespace(routeNamespace),
+					}},
+				},
+			}
+			grant.SetGroupVersionKind(referenceGrantGVK)
+			for _, service := range sets.List(services[routeNamespace][ns]) {
+				serviceName := gatewayv1.ObjectName(service)
+				grant.Spec.To = append(grant.Spec.To, gatewayv1beta1.ReferenceGrantTo{Group: "", Kind: "Service", Name: &serviceName})
+			}
+			grants = append(grants, grant)
+		}
+	}
+
+	// The Secrets of every namespace, by namespace of the Gateways
+	// referencing them.
+	secrets := map[string]map[string]sets.Set[string]{}
+	for _, gw := range gateways {
+		for _, l := range gw.Spec.Listeners {
+			if l.TLS == nil {
+				continue
+			}
+			for _, ref := range l.TLS.CertificateRefs {
+				if ref.Namespace == nil || string(*ref.Namespace) == gw.Namespace {
+					continue
+				}
+				if secrets[gw.Namespace] == nil {
+					secrets[gw.Namespace] = map[string]sets.Set[string]{}
+				}
+				ns := string(*ref.Namespace)
+				if secrets[gw.Namespace][ns] == nil {
+					secrets[gw.Namespace][ns] = sets.New[string]()
+				}
+				secrets[gw.Namespace][ns].Insert(string(ref.Name))
+			}
+		}
+	}
+	for _, gatewayNamespace := range sets.List(sets.KeySet(secrets)) {
+		grants = append(grants, secretReferenceGrants(gatewayNamespace, secrets[gatewayNamespace])...)
+	}
+	return grants
+}
+
+// joinContourPrefix appends a path condition to the prefix of the includes,
+// the way Contour does.
+func joinContourPrefix(prefix, path string) string {
+	if prefix == "" || prefix == "/" {
+		return path
+	}
+	if path == "/" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func toContourHeaderMatch(condition ContourHeaderMatchCondition) (gatewayv1beta1.HTTPHeaderMatch, bool) {
+	headerMatchExact := gatewayv1.HeaderMatchExact
+	headerMatchRegularExpression := gatewayv1.HeaderMatchRegularExpression
+	switch {
+	case condition.Exact != "":
+		return gatewayv1beta1.HTTPHeaderMatch{
+			Type:  &headerMatchExact,
+			Name:  gatewayv1.HTTPHeaderName(condition.Name),
+			Value: condition.Exact,
+		}, true
+	case condition.Regex != "":
+		return gatewayv1beta1.HTTPHeaderMatch{
+			Type:  &headerMatchRegularExpression,
+			Name:  gatewayv1.HTTPHeaderName(condition.Name),
+			Value: condition.Regex,
+		}, true
+	}
+	return gatewayv1beta1.HTTPHeaderMatch{}, false
+}
+
+func toContourQueryParamMatch(condition ContourHeaderMatchCondition) (gatewayv1beta1.HTTPQueryParamMatch, bool) {
+	queryParamMatchExact := gatewayv1.QueryParamMatchExact
+	queryParamMatchRegularExpression := gatewayv1.QueryParamMatchRegularExpression
+	switch {
+	case condition.Exact != "":
+		return gatewayv1beta1.HTTPQueryParamMatch{
+			Type:  &queryParamMatchExact,
+			Name:  gatewayv1.HTTPHeaderName(condition.Name),
+			Value: condition.Exact,
+		}, true
+	case condition.Regex != "":
+		return gatewayv1beta1.HTTPQueryParamMatch{
+			Type:  &queryParamMatchRegularExpression,
+			Name:  gatewayv1.HTTPHeaderName(condition.Name),
+			Value: condition.Regex,
+		}, true
+	}
+	return gatewayv1beta1.HTTPQueryParamMatch{}, false
+}
+
+func toContourHeaderFilter(policy ContourHeadersPolicy) *gatewayv1beta1.HTTPHeaderFilter {
+	filter := &gatewayv1beta1.HTTPHeaderFilter{Remove: policy.Remove}
+	for _, header := range policy.Set {
+		filter.Set = append(filter.Set, gatewayv1beta1.HTTPHeader{
+			Name:  gatewayv1.HTTPHeaderName(header.Name),
+			Value: header.Value,
+		})
+	}
+	return filter
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_HTTPProxies2GatewaysAndHTTPRoutes(t *testing.T) {
+	proxies, err := ConstructHTTPProxiesFromFile("testdata/httpproxy.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read HTTPProxies: %v", err)
+	}
+	if len(proxies) != 3 {
+		t.Fatalf("Expected 3 HTTPProxies, got %d", len(proxies))
+	}
+
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+	hmExact := gatewayv1.HeaderMatchExact
+	backendRef := func(name string, port int32, namespace string, weight *int32) gatewayv1beta1.HTTPBackendRef {
+		ref := gatewayv1beta1.HTTPBackendRef{
+			BackendRef: gatewayv1beta1.BackendRef{
+				BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+					Name: gatewayv1beta1.ObjectName(name),
+					Port: portNumberPtr(int(port)),
+				},
+				Weight: weight,
+			},
+		}
+		if namespace != "" {
+			ref.Namespace = (*gatewayv1beta1.Namespace)(&namespace)
+		}
+		return ref
+	}
+	prefixMatch := func(path string) []gatewayv1beta1.HTTPRouteMatch {
+		return []gatewayv1beta1.HTTPRouteMatch{{
+			Path: &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String(path)},
+		}}
+	}
+
+	expectedHTTPRoute := gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "root", Namespace: "web"},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+				ParentRefs: []gatewayv1beta1.ParentReference{{Name: "contour"}},
+			},
+			Hostnames: []gatewayv1beta1.Hostname{"www.example.com"},
+			Rules: []gatewayv1beta1.HTTPRouteRule{{
+				Matches:     prefixMatch("/"),
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("web", 80, "", int32Ptr(90)), backendRef("web-canary", 80, "", int32Ptr(10))},
+			}, {
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{
+					Path:    &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String("/admin")},
+					Headers: []gatewayv1beta1.HTTPHeaderMatch{{Type: &hmExact, Name: "x-admin", Value: "true"}},
+				}},
+				Filters: []gatewayv1beta1.HTTPRouteFilter{{
+					Type: gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+					RequestHeaderModifier: &gatewayv1beta1.HTTPHeaderFilter{
+						Set:    []gatewayv1beta1.HTTPHeader{{Name: "x-forwarded-admin", Value: "true"}},
+						Remove: []string{"x-debug"},
+					},
+				}},
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("admin", 8080, "", nil)},
+			}, {
+				Matches:     prefixMatch("/api/v1"),
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("api-v1", 8080, "api", nil)},
+			}, {
+				Matches:     prefixMatch("/api"),
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("api", 8080, "api", nil)},
+			}},
+		},
+		Status: gatewayv1beta1.HTTPRouteStatus{
+			RouteStatus: gatewayv1beta1.RouteStatus{
+				Parents: []gatewayv1beta1.RouteParentStatus{},
+			},
+		},
+	}
+	expectedHTTPRoute.SetGroupVersionKind(httpRouteGVK)
+
+	expectedListeners := []gatewayv1beta1.Listener{{
+		Name:     "www-example-com-http",
+		Hostname: gatewayHostnamePtr("www.example.com"),
+		Port:     80,
+		Protocol: gatewayv1.HTTPProtocolType,
+	}, {
+		Name:     "www-example-com-https",
+		Hostname: gatewayHostnamePtr("www.example.com"),
+		Port:     443,
+		Protocol: gatewayv1.HTTPSProtocolType,
+		TLS: &gatewayv1beta1.GatewayTLSConfig{
+			CertificateRefs: []gatewayv1beta1.SecretObjectReference{{Name: "www-cert"}},
+		},
+	}}
+
+	httpRoutes, gateways, warnings, errs := HTTPProxies2GatewaysAndHTTPRoutes(proxies)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	// Rate limiting, authorization and the orphan HTTPProxy.
+	if len(warnings) != 3 {
+		t.Errorf("Expected 3 warnings, got %d: %+v", len(warnings), warnings)
+	}
+	if len(httpRoutes) != 1 {
+		t.Fatalf("Expected 1 HTTPRoute, got %d: %+v", len(httpRoutes), httpRoutes)
+	}
+	if !apiequality.Semantic.DeepEqual(httpRoutes[0], expectedHTTPRoute) {
+		t.Errorf("Expected HTTPRoute to be %+v\n Got: %+v\n Diff: %s", expectedHTTPRoute, httpRoutes[0], cmp.Diff(expectedHTTPRoute, httpRoutes[0]))
+	}
+	if len(gateways) != 1 {
+		t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+	}
+	if gateways[0].Namespace != "web" || gateways[0].Name != "contour" {
+		t.Errorf("Expected Gateway web/contour, got %s/%s", gateways[0].Namespace, gateways[0].Name)
+	}
+	if diff := cmp.Diff(expectedListeners, gateways[0].Spec.Listeners); diff != "" {
+		t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+	}
+}
+
+func Test_ConvertHTTPProxiesReferenceGrants(t *testing.T) {
+	proxies, err := ConstructHTTPProxiesFromFile("testdata/httpproxy.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read HTTPProxies: %v", err)
+	}
+	// The certificate of the root HTTPProxy is delegated from the certs
+	// namespace.
+	proxies[0].Spec.VirtualHost.TLS.SecretName = "certs/www-cert"
+
+	result, err := ConvertHTTPProxies(proxies, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected conversion error: %v", err)
+	}
+	name := func(n string) *gatewayv1.ObjectName {
+		objName := gatewayv1.ObjectName(n)
+		return &objName
+	}
+	serviceGrant := gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "httproutes-web", Namespace: "api"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "HTTPRoute", Namespace: "web"}},
+			To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Service", Name: name("api")}, {Kind: "Service", Name: name("api-v1")}},
+		},
+	}
+	serviceGrant.SetGroupVersionKind(referenceGrantGVK)
+	secretGrant := gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateways-web", Namespace: "certs"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "Gateway", Namespace: "web"}},
+			To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Secret", Name: name("www-cert")}},
+		},
+	}
+	secretGrant.SetGroupVersionKind(referenceGrantGVK)
+
+	if diff := cmp.Diff([]gatewayv1beta1.ReferenceGrant{serviceGrant, secretGrant}, result.ReferenceGrants); diff != "" {
+		t.Errorf("Unexpected ReferenceGrants (-want +got):\n%s", diff)
+	}
+}
+
+func Test_HTTPProxyIncludeErrors(t *testing.T) {
+	newProxy := func(name string, includes ...string) HTTPProxy {
+		proxy := HTTPProxy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"}}
+		for _, include := range includes {
+			proxy.Spec.Includes = append(proxy.Spec.Includes, ContourInclude{Name: include})
+		}
+		return proxy
+	}
+	withVirtualHost := func(proxy HTTPProxy) HTTPProxy {
+		proxy.Spec.VirtualHost = &ContourVirtualHost{Fqdn: "example.com"}
+		return proxy
+	}
+
+	testCases := []struct {
+		name    string
+		proxies []HTTPProxy
+	}{{
+		name:    "missing include",
+		proxies: []HTTPProxy{withVirtualHost(newProxy("root", "missing"))},
+	}, {
+		name:    "include cycle",
+		proxies: []HTTPProxy{withVirtualHost(newProxy("root", "a")), newProxy("a", "b"), newProxy("b", "a")},
+	}, {
+		name:    "missing fqdn",
+		proxies: []HTTPProxy{{ObjectMeta: metav1.ObjectMeta{Name: "root", Namespace: "test"}, Spec: HTTPProxySpec{VirtualHost: &ContourVirtualHost{}}}},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, _, errs := HTTPProxies2GatewaysAndHTTPRoutes(tc.proxies)
+			if len(errs) != 1 {
+				t.Errorf("Expected 1 error, got %d: %+v", len(errs), errs)
+			}
+		})
+	}
+}
+
+func Test_joinContourPrefix(t *testing.T) {
+	testCases := []struct {
+		prefix   string
+		path     string
+		expected string
+	}{
+		{prefix: "", path: "/api", expected: "/api"},
+		{prefix: "/", path: "/api", expected: "/api"},
+		{prefix: "/api", path: "/", expected: "/api"},
+		{prefix: "/api", path: "/v1", expected: "/api/v1"},
+		{prefix: "/api/", path: "/v1", expected: "/api/v1"},
+	}
+	for _, tc := range testCases {
+		if got := joinContourPrefix(tc.prefix, tc.path); got != tc.expected {
+			t.Errorf("joinContourPrefix(%q, %q) = %q, expected %q", tc.prefix, tc.path, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,487 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// Options configures a conversion. The zero value converts the resources
+// with the default behavior.
+type Options struct {
+	// ConfigMaps are the ConfigMaps referenced by the Ingress annotations,
+	// such as the TCP services and custom headers ConfigMaps.
+	ConfigMaps []corev1.ConfigMap
+
+	// Services are the backend Services of the Ingresses, used to resolve
+	// named service ports.
+	Services []corev1.Service
+
+	// PreserveAnnotations extends DefaultPreservedAnnotations, the allowlist
+	// of Ingress annotations copied onto the generated Gateways and routes.
+	PreserveAnnotations []string
+
+	// AllowConflicts reports conflicting paths as warnings instead of errors.
+	AllowConflicts bool
+
+	// Labels are merged into the labels of every generated resource.
+	Labels map[string]string
+
+	// Providers are the names of the providers whose Ingress annotations are
+	// converted, see ProviderNames. When empty, the providers are detected
+	// from the annotations of every Ingress.
+	Providers []string
+
+	// AnnotationPrefix restricts the converted provider annotations to the
+	// ones of the provider with the prefix, such as
+	// nginx.ingress.kubernetes.io, ignoring the annotations of the other
+	// providers, such as the ones of another controller of the cluster. When
+	// empty, the annotations of all the enabled providers are converted.
+	AnnotationPrefix string
+
+	// HTTPListenerPort and HTTPSListenerPort are the ports of the generated
+	// HTTP and HTTPS listeners, DefaultHTTPListenerPort and
+	// DefaultHTTPSListenerPort when zero.
+	HTTPListenerPort  int32
+	HTTPSListenerPort int32
+
+	// MergeCertificateRefs serves the HTTPS hosts of every Gateway with
+	// different Secrets by a single listener, whose certificateRefs are all
+	// their Secrets, instead of a listener per host. Hosts served with several
+	// Secrets are reported as errors.
+	MergeCertificateRefs bool
+
+	// SingleGatewayPerNamespace converts the Ingresses of every namespace to
+	// a single Gateway aggregating all their listeners, instead of a Gateway
+	// per Ingress class. Listeners of a Gateway that can't be served together
+	// are reported as errors.
+	SingleGatewayPerNamespace bool
+
+	// SharedGatewayNamespace, when set, merges all the generated Gateways
+	// into a single Gateway of this namespace, shared by the routes of all
+	// the namespaces through cross-namespace parentRefs. The listeners allow
+	// the routes of their namespaces, and ReferenceGrants allow the Gateway to
+	// reference the TLS Secrets of the other namespaces. Listeners of the
+	// Gateway that can't be served together are reported as errors.
+	SharedGatewayNamespace string
+
+	// APIVersion is the version of the generated Gateways and HTTPRoutes,
+	// APIVersionV1Beta1 when empty. Session affinity is only converted to
+	// the session persistence of APIVersionV1 routes.
+	APIVersion string
+
+	// NameTemplate is the template of the names of the routes generated for
+	// every host, with the {ingress}, {namespace} and {host} placeholders,
+	// see ValidateNameTemplate. The routes are named after their host when
+	// empty. Templated names are made DNS-1123 labels.
+	NameTemplate string
+
+	// GatewayNameTemplate is the template of the names of the generated
+	// Gateways, with the {class} and {namespace} placeholders, see
+	// ValidateGatewayNameTemplate. The Gateways are named after their class
+	// when empty. Templated names are made DNS-1123 labels.
+	GatewayNameTemplate string
+
+	// RouteGranularity is the granularity of the generated HTTPRoutes, one of
+	// the RouteGranularity constants, RouteGranularityHost when empty.
+	RouteGranularity string
+
+	// TargetImplementation is the Gateway API implementation whose policy
+	// attachments are generated for the annotations Gateway API can't
+	// represent, see TargetImplementations. Only the core resources are
+	// generated when empty or unknown, and the annotations are reported as
+	// warnings.
+	TargetImplementation string
+
+	// BackendRefGroup and BackendRefKind are the group and kind of the
+	// backendRefs of Service backends, such as a custom resource proxying
+	// traffic, instead of a core Service, see ValidateBackendRefKind. The
+	// ingress2gateway.kubernetes.io/backend-ref-group and backend-ref-kind
+	// annotations of an Ingress take precedence over them.
+	BackendRefGroup string
+	BackendRefKind  string
+
+	// ContinueOnError leaves the Ingresses failing to be converted out of the
+	// conversion, instead of failing it, and returns their errors as the
+	// IngressErrors of the Result.
+	ContinueOnError bool
+
+	// PruneUnusedListeners removes the listeners of the generated Gateways
+	// that no generated route is attached to, keeping at least one listener
+	// per Gateway. The listeners terminating the TLS of a host are kept, as
+	// they express its TLS intent, unless PruneTLSListeners is set too.
+	PruneUnusedListeners bool
+	PruneTLSListeners    bool
+
+	// AnnotateSourceIngresses sets the SourceIngressAnnotation of every
+	// generated resource to the Ingresses it is generated from, recording
+	// its provenance.
+	AnnotateSourceIngresses bool
+
+	// RecordIngressClasses sets the IngressClassAnnotation of the
+	// infrastructure of every generated Gateway to the Ingress classes of its
+	// Ingresses. Gateway infrastructure is an experimental Gateway API field.
+	RecordIngressClasses bool
+}
+
+const (
+	// DefaultHTTPListenerPort is the port of the generated HTTP listeners.
+	DefaultHTTPListenerPort = 80
+	// DefaultHTTPSListenerPort is the port of the generated HTTPS listeners.
+	DefaultHTTPSListenerPort = 443
+
+	// APIVersionV1Beta1 and APIVersionV1 are the supported versions of the
+	// generated Gateways and HTTPRoutes.
+	APIVersionV1Beta1 = "v1beta1"
+	APIVersionV1      = "v1"
+)
+
+// Result holds the resources generated by a conversion.
+type Result struct {
+	HTTPRoutes []gatewayv1beta1.HTTPRoute
+	GRPCRoutes []gatewayv1.GRPCRoute
+	TCPRoutes  []gatewayv1alpha2.TCPRoute
+	Gateways   []gatewayv1beta1.Gateway
+
+	// TLSRoutes pass the TLS connections of the hosts of Ingresses with SSL
+	// passthrough through to their backends.
+	TLSRoutes []gatewayv1alpha2.TLSRoute
+
+	// BackendTLSPolicies configure TLS to the backend Services of Ingresses
+	// with HTTPS backends.
+	BackendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy
+
+	// ReferenceGrants allow the Gateway shared across namespaces to
+	// reference the TLS Secrets of the other namespaces, see
+	// Options.SharedGatewayNamespace.
+	ReferenceGrants []gatewayv1beta1.ReferenceGrant
+
+	// Policies are the policy attachments of the target implementation, such
+	// as the Envoy Gateway SecurityPolicies of the source IP allow-lists.
+	Policies []unstructured.Unstructured
+
+	// Warnings describe the configuration that could not be converted, or
+	// was converted with a loss of fidelity.
+	Warnings []Warning
+
+	// IngressErrors are the errors of the Ingresses left out of a
+	// conversion continuing on errors, see Options.ContinueOnError.
+	IngressErrors []IngressError
+}
+
+// Convert converts Ingresses into Gateway API resources. It doesn't access a
+// cluster, the resources referenced by the Ingresses are given through the
+// options. The returned error aggregates all the conversion errors, in which
+// case the Result is empty. With ContinueOnError, only the errors that can't
+// be attributed to an Ingress are returned.
+func Convert(ingresses []networkingv1.Ingress, opts Options) (Result, error) {
+	if err := ValidateProviders(opts.Providers); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateAnnotationPrefix(opts.AnnotationPrefix); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateListenerPorts(opts.HTTPListenerPort, opts.HTTPSListenerPort); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateAPIVersion(opts.APIVersion); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateBackendRefKind(opts.BackendRefGroup, opts.BackendRefKind); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateRouteGranularity(opts.RouteGranularity); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateSharedGatewayNamespace(opts.SharedGatewayNamespace); err != nil {
+		return Result{}, err
+	}
+	opts.PreserveAnnotations = append(append([]string{}, DefaultPreservedAnnotations...), opts.PreserveAnnotations...)
+	convert := convertIngresses
+	if opts.ContinueOnError {
+		convert = convertIngressesContinuingOnError
+	}
+	result, errs := convert(ingresses, opts)
+	if len(errs) > 0 {
+		return Result{}, errs.ToAggregate()
+	}
+	setListenerPorts(result.Gateways, opts.HTTPListenerPort, opts.HTTPSListenerPort)
+	if opts.PruneUnusedListeners {
+		pruneUnusedListeners(result.Gateways, result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, opts.PruneTLSListeners)
+	}
+	if opts.SingleGatewayPerNamespace || opts.SharedGatewayNamespace != "" {
+		if errs := validateListeners(result.Gateways); len(errs) > 0 {
+			return Result{}, errs.ToAggregate()
+		}
+	}
+	setAPIVersion(result.HTTPRoutes, result.Gateways, opts.APIVersion)
+	setBackendRefWeights(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes)
+	AddLabels(opts.Labels, result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.Gateways)
+	for i := range result.TLSRoutes {
+		if len(opts.Labels) > 0 {
+			mergeLabels(&result.TLSRoutes[i], opts.Labels)
+		}
+	}
+	for i := range result.BackendTLSPolicies {
+		if len(opts.Labels) > 0 {
+			mergeLabels(&result.BackendTLSPolicies[i], opts.Labels)
+		}
+	}
+	for i := range result.ReferenceGrants {
+		if len(opts.Labels) > 0 {
+			mergeLabels(&result.ReferenceGrants[i], opts.Labels)
+		}
+	}
+	for i := range result.Policies {
+		if len(opts.Labels) > 0 {
+			mergeLabels(&result.Policies[i], opts.Labels)
+		}
+	}
+	return result, nil
+}
+
+// ConvertHTTPProxies converts Contour HTTPProxies into Gateway API resources,
+// as Convert does for Ingresses. Only the Labels, listener port, API
+// version and listener pruning options apply. ReferenceGrants allow the
+// references of the generated resources to other namespaces.
+func ConvertHTTPProxies(proxies []HTTPProxy, opts Options) (Result, error) {
+	if err := ValidateListenerPorts(opts.HTTPListenerPort, opts.HTTPSListenerPort); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateAPIVersion(opts.APIVersion); err != nil {
+		return Result{}, err
+	}
+	httpRoutes, gateways, warnings, errs := HTTPProxies2GatewaysAndHTTPRoutes(proxies)
+	if len(errs) > 0 {
+		return Result{}, errs.ToAggregate()
+	}
+	setListenerPorts(gateways, opts.HTTPListenerPort, opts.HTTPSListenerPort)
+	if opts.PruneUnusedListeners {
+		pruneUnusedListeners(gateways, httpRoutes, nil, nil, nil, opts.PruneTLSListeners)
+	}
+	setAPIVersion(httpRoutes, gateways, opts.APIVersion)
+	setBackendRefWeights(httpRoutes, nil, nil, nil)
+	AddLabels(opts.Labels, httpRoutes, nil, nil, gateways)
+	referenceGrants := contourReferenceGrants(httpRoutes, gateways)
+	for i := range referenceGrants {
+		if len(opts.Labels) > 0 {
+			mergeLabels(&referenceGrants[i], opts.Labels)
+		}
+	}
+	return Result{
+		HTTPRoutes:      httpRoutes,
+		Gateways:        gateways,
+		ReferenceGrants: referenceGrants,
+		Warnings:        warnings,
+	}, nil
+}
+
+// ConvertEmissary converts Emissary-ingress Mappings into Gateway API
+// resources, as Convert does for Ingresses. Only the Labels, listener port,
+// API version and listener pruning options apply.
+func ConvertEmissary(mappings []EmissaryMapping, opts Options) (Result, error) {
+	if err := ValidateListenerPorts(opts.HTTPListenerPort, opts.HTTPSListenerPort); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateAPIVersion(opts.APIVersion); err != nil {
+		return Result{}, err
+	}
+	httpRoutes, gateways, warnings, errs := Mappings2GatewaysAndHTTPRoutes(mappings)
+	if len(errs) > 0 {
+		return Result{}, errs.ToAggregate()
+	}
+	setListenerPorts(gateways, opts.HTTPListenerPort, opts.HTTPSListenerPort)
+	if opts.PruneUnusedListeners {
+		pruneUnusedListeners(gateways, httpRoutes, nil, nil, nil, opts.PruneTLSListeners)
+	}
+	setAPIVersion(httpRoutes, gateways, opts.APIVersion)
+	setBackendRefWeights(httpRoutes, nil, nil, nil)
+	AddLabels(opts.Labels, httpRoutes, nil, nil, gateways)
+	return Result{
+		HTTPRoutes: httpRoutes,
+		Gateways:   gateways,
+		Warnings:   warnings,
+	}, nil
+}
+
+// ConvertIstio converts Istio Gateways and VirtualServices into Gateway API
+// resources, as Convert does for Ingresses. Only the Labels, listener port,
+// API version and listener pruning options apply.
+func ConvertIstio(gateways []IstioGateway, virtualServices []IstioVirtualService, opts Options) (Result, error) {
+	if err := ValidateListenerPorts(opts.HTTPListenerPort, opts.HTTPSListenerPort); err != nil {
+		return Result{}, err
+	}
+	if err := ValidateAPIVersion(opts.APIVersion); err != nil {
+		return Result{}, err
+	}
+	httpRoutes, gws, warnings, errs := IstioToGatewaysAndHTTPRoutes(gateways, virtualServices)
+	if len(errs) > 0 {
+		return Result{}, errs.ToAggregate()
+	}
+	setListenerPorts(gws, opts.HTTPListenerPort, opts.HTTPSListenerPort)
+	if opts.PruneUnusedListeners {
+		pruneUnusedListeners(gws, httpRoutes, nil, nil, nil, opts.PruneTLSListeners)
+	}
+	setAPIVersion(httpRoutes, gws, opts.APIVersion)
+	setBackendRefWeights(httpRoutes, nil, nil, nil)
+	AddLabels(opts.Labels, httpRoutes, nil, nil, gws)
+	return Result{
+		HTTPRoutes: httpRoutes,
+		Gateways:   gws,
+		Warnings:   warnings,
+	}, nil
+}
+
+// ValidateListenerPorts returns an error when the ports of the HTTP and HTTPS
+// listeners aren't valid port numbers, or are the same port. Zero ports are
+// the default ports.
+func ValidateListenerPorts(httpPort, httpsPort int32) error {
+	httpPort, httpsPort = listenerPorts(httpPort, httpsPort)
+	for _, port := range []int32{httpPort, httpsPort} {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%d is not a valid listener port, must be between 1 and 65535", port)
+		}
+	}
+	if httpPort == httpsPort {
+		return fmt.Errorf("the HTTP and HTTPS listeners can't both use port %d", httpPort)
+	}
+	return nil
+}
+
+// listenerPorts returns the ports of the HTTP and HTTPS listeners, replacing
+// zero ports with the default ports.
+func listenerPorts(httpPort, httpsPort int32) (int32, int32) {
+	if httpPort == 0 {
+		httpPort = DefaultHTTPListenerPort
+	}
+	if httpsPort == 0 {
+		httpsPort = DefaultHTTPSListenerPort
+	}
+	return httpPort, httpsPort
+}
+
+// setListenerPorts sets the ports of the HTTP and HTTPS listeners of the
+// generated Gateways on the default ports, the TLS passthrough listeners
+// getting the port of the HTTPS listeners. Listeners on other ports, such as
+// the listen ports of ALB Ingresses, keep their port.
+func setListenerPorts(gateways []gatewayv1beta1.Gateway, httpPort, httpsPort int32) {
+	httpPort, httpsPort = listenerPorts(httpPort, httpsPort)
+	for i := range gateways {
+		for j := range gateways[i].Spec.Listeners {
+			listener := &gateways[i].Spec.Listeners[j]
+			switch {
+			case listener.Protocol == gatewayv1.HTTPProtocolType && listener.Port == 80:
+				listener.Port = gatewayv1.PortNumber(httpPort)
+			case listener.Protocol == gatewayv1.HTTPSProtocolType && listener.Port == 443:
+				listener.Port = gatewayv1.PortNumber(httpsPort)
+			case listener.Protocol == gatewayv1.TLSProtocolType && listener.Port == 443 && listener.TLS != nil && listener.TLS.Mode != nil && *listener.TLS.Mode == gatewayv1.TLSModePassthrough:
+				listener.Port = gatewayv1.PortNumber(httpsPort)
+			}
+		}
+	}
+}
+
+// ValidateAPIVersion returns an error when the version isn't a supported
+// version of the generated Gateways and HTTPRoutes. An empty version is the
+// default version.
+func ValidateAPIVersion(version string) error {
+	switch version {
+	case "", APIVersionV1Beta1, APIVersionV1:
+		return nil
+	}
+	return fmt.Errorf("%s is not a supported API version, must be one of: %s, %s", version, APIVersionV1Beta1, APIVersionV1)
+}
+
+// setAPIVersion sets the version of the generated Gateways and HTTPRoutes.
+// The v1beta1 types are aliases of the v1 types, so only the version of the
+// objects changes.
+func setAPIVersion(httpRoutes []gatewayv1beta1.HTTPRoute, gateways []gatewayv1beta1.Gateway, version string) {
+	if version == "" || version == APIVersionV1Beta1 {
+		return
+	}
+	for i := range httpRoutes {
+		gvk := httpRouteGVK
+		gvk.Version = version
+		httpRoutes[i].SetGroupVersionKind(gvk)
+	}
+	for i := range gateways {
+		gvk := gatewayGVK
+		gvk.Version = version
+		gateways[i].SetGroupVersionKind(gvk)
+	}
+}
+
+// setBackendRefWeights makes the weights of the backendRefs of the generated
+// routes explicit only when they split the traffic of a rule: the weights of
+// the rules with multiple backendRefs are all set, the unset ones to the
+// default weight of 1, and the weight of the single backendRef of a rule is
+// removed, as it receives all the traffic whatever its weight. A zero weight
+// is kept, the rule then has no backend to route to.
+func setBackendRefWeights(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute) {
+	for i := range httpRoutes {
+		for j := range httpRoutes[i].Spec.Rules {
+			rule := &httpRoutes[i].Spec.Rules[j]
+			for k := range rule.BackendRefs {
+				setBackendRefWeight(&rule.BackendRefs[k].BackendRef, len(rule.BackendRefs))
+			}
+		}
+	}
+	for i := range grpcRoutes {
+		for j := range grpcRoutes[i].Spec.Rules {
+			rule := &grpcRoutes[i].Spec.Rules[j]
+			for k := range rule.BackendRefs {
+				setBackendRefWeight(&rule.BackendRefs[k].BackendRef, len(rule.BackendRefs))
+			}
+		}
+	}
+	for i := range tcpRoutes {
+		for j := range tcpRoutes[i].Spec.Rules {
+			rule := &tcpRoutes[i].Spec.Rules[j]
+			for k := range rule.BackendRefs {
+				setBackendRefWeight(&rule.BackendRefs[k], len(rule.BackendRefs))
+			}
+		}
+	}
+	for i := range tlsRoutes {
+		for j := range tlsRoutes[i].Spec.Rules {
+			rule := &tlsRoutes[i].Spec.Rules[j]
+			for k := range rule.BackendRefs {
+				setBackendRefWeight(&rule.BackendRefs[k], len(rule.BackendRefs))
+			}
+		}
+	}
+}
+
+// setBackendRefWeight sets the weight of a backendRef of a rule with
+// numBackendRefs backendRefs, see setBackendRefWeights.
+func setBackendRefWeight(backendRef *gatewayv1.BackendRef, numBackendRefs int) {
+	switch {
+	case numBackendRefs > 1 && backendRef.Weight == nil:
+		backendRef.Weight = pointer.Int32(1)
+	case numBackendRefs == 1 && backendRef.Weight != nil && *backendRef.Weight != 0:
+		backendRef.Weight = nil
+	}
+}
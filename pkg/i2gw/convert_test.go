@@ -0,0 +1,465 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func Test_Convert(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, path, service string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "test",
+				Annotations: map[string]string{"example.com/owner": "web"},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     path,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: service,
+										Port: networkingv1.ServiceBackendPort{Number: 8080},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		ingresses         []networkingv1.Ingress
+		opts              Options
+		expectNumGateways int
+		expectNumRoutes   int
+		expectNumWarnings int
+		expectNumErrors   int
+		expectLabels      map[string]string
+		expectAnnotations map[string]string
+	}{{
+		name:              "default options",
+		ingresses:         []networkingv1.Ingress{newIngress("example", "/", "example")},
+		expectNumGateways: 1,
+		expectNumRoutes:   1,
+	}, {
+		name:      "labels and preserved annotations",
+		ingresses: []networkingv1.Ingress{newIngress("example", "/", "example")},
+		opts: Options{
+			Labels:              map[string]string{"app.kubernetes.io/managed-by": "ingress2gateway"},
+			PreserveAnnotations: []string{"example.com/owner"},
+		},
+		expectNumGateways: 1,
+		expectNumRoutes:   1,
+		expectLabels:      map[string]string{"app.kubernetes.io/managed-by": "ingress2gateway"},
+		expectAnnotations: map[string]string{"example.com/owner": "web"},
+	}, {
+		name:            "conflicting paths",
+		ingresses:       []networkingv1.Ingress{newIngress("a", "/", "a"), newIngress("b", "/", "b")},
+		expectNumErrors: 1,
+	}, {
+		name:              "allowed conflicting paths",
+		ingresses:         []networkingv1.Ingress{newIngress("a", "/", "a"), newIngress("b", "/", "b")},
+		opts:              Options{AllowConflicts: true},
+		expectNumGateways: 1,
+		expectNumRoutes:   1,
+		expectNumWarnings: 1,
+	}, {
+		name: "single Gateway per namespace",
+		ingresses: []networkingv1.Ingress{newIngress("a", "/a", "a"), func() networkingv1.Ingress {
+			ingress := newIngress("b", "/b", "b")
+			ingress.Spec.IngressClassName = nil
+			return ingress
+		}()},
+		opts:              Options{SingleGatewayPerNamespace: true},
+		expectNumGateways: 1,
+		expectNumRoutes:   1,
+	}, {
+		name: "single Gateway per namespace with different classes",
+		ingresses: []networkingv1.Ingress{newIngress("a", "/a", "a"), func() networkingv1.Ingress {
+			ingress := newIngress("b", "/b", "b")
+			ingress.Spec.IngressClassName = stringPtr("haproxy")
+			return ingress
+		}()},
+		opts:            Options{SingleGatewayPerNamespace: true},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, tc.opts)
+			if tc.expectNumErrors > 0 {
+				agg, ok := err.(utilerrors.Aggregate)
+				if !ok || len(agg.Errors()) != tc.expectNumErrors {
+					t.Fatalf("Expected %d aggregated errors, got %v", tc.expectNumErrors, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected conversion error: %v", err)
+			}
+			if len(result.Gateways) != tc.expectNumGateways {
+				t.Errorf("Expected %d Gateways, got %d", tc.expectNumGateways, len(result.Gateways))
+			}
+			if len(result.HTTPRoutes) != tc.expectNumRoutes {
+				t.Fatalf("Expected %d HTTPRoutes, got %d", tc.expectNumRoutes, len(result.HTTPRoutes))
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+			if diff := cmp.Diff(tc.expectLabels, result.HTTPRoutes[0].Labels); diff != "" {
+				t.Errorf("Unexpected HTTPRoute labels (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectAnnotations, result.HTTPRoutes[0].Annotations); diff != "" {
+				t.Errorf("Unexpected HTTPRoute annotations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ConvertListenerPorts(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			TLS:              []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com"}},
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: "example",
+									Port: networkingv1.ServiceBackendPort{Number: 8080},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		opts           Options
+		expectedPorts  map[gatewayv1.SectionName]gatewayv1.PortNumber
+		expectingError bool
+	}{{
+		name:          "default ports",
+		expectedPorts: map[gatewayv1.SectionName]gatewayv1.PortNumber{"example-com-http": 80, "example-com-https": 443},
+	}, {
+		name:          "custom ports",
+		opts:          Options{HTTPListenerPort: 8080, HTTPSListenerPort: 8443},
+		expectedPorts: map[gatewayv1.SectionName]gatewayv1.PortNumber{"example-com-http": 8080, "example-com-https": 8443},
+	}, {
+		name:           "out of range port",
+		opts:           Options{HTTPListenerPort: 70000},
+		expectingError: true,
+	}, {
+		name:           "negative port",
+		opts:           Options{HTTPSListenerPort: -443},
+		expectingError: true,
+	}, {
+		name:           "same ports",
+		opts:           Options{HTTPListenerPort: 8080, HTTPSListenerPort: 8080},
+		expectingError: true,
+	}, {
+		name:           "HTTP listener on the default HTTPS port",
+		opts:           Options{HTTPListenerPort: 443},
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert([]networkingv1.Ingress{ingress}, tc.opts)
+			if tc.expectingError {
+				if err == nil {
+					t.Fatalf("Expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected conversion error: %v", err)
+			}
+			ports := map[gatewayv1.SectionName]gatewayv1.PortNumber{}
+			for _, listener := range result.Gateways[0].Spec.Listeners {
+				ports[listener.Name] = listener.Port
+			}
+			if diff := cmp.Diff(tc.expectedPorts, ports); diff != "" {
+				t.Errorf("Unexpected listener ports (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ConvertAPIVersion(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "test",
+			Annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/affinity": "cookie",
+				proxyReadTimeoutAnnotation:             "30",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: "example",
+									Port: networkingv1.ServiceBackendPort{Number: 8080},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	testCases := []struct {
+		name                     string
+		apiVersion               string
+		expectedVersion          string
+		expectSessionPersistence bool
+		expectTimeouts           bool
+		expectNumWarnings        int
+		expectingError           bool
+	}{{
+		name:              "default version",
+		expectedVersion:   "gateway.networking.k8s.io/v1beta1",
+		expectNumWarnings: 2,
+	}, {
+		name:                     "v1",
+		apiVersion:               APIVersionV1,
+		expectedVersion:          "gateway.networking.k8s.io/v1",
+		expectSessionPersistence: true,
+		expectTimeouts:           true,
+	}, {
+		name:           "unsupported version",
+		apiVersion:     "v1alpha2",
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert([]networkingv1.Ingress{ingress}, Options{APIVersion: tc.apiVersion})
+			if tc.expectingError {
+				if err == nil {
+					t.Fatalf("Expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected conversion error: %v", err)
+			}
+			if v := result.HTTPRoutes[0].APIVersion; v != tc.expectedVersion {
+				t.Errorf("Expected HTTPRoute version %s, got %s", tc.expectedVersion, v)
+			}
+			if v := result.Gateways[0].APIVersion; v != tc.expectedVersion {
+				t.Errorf("Expected Gateway version %s, got %s", tc.expectedVersion, v)
+			}
+			if got := result.HTTPRoutes[0].Spec.Rules[0].SessionPersistence != nil; got != tc.expectSessionPersistence {
+				t.Errorf("Expected session persistence %t, got %t", tc.expectSessionPersistence, got)
+			}
+			if got := result.HTTPRoutes[0].Spec.Rules[0].Timeouts != nil; got != tc.expectTimeouts {
+				t.Errorf("Expected timeouts %t, got %t", tc.expectTimeouts, got)
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
+
+func Test_ConvertBackendRefWeights(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, service string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "test",
+				Annotations: annotations,
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: service,
+										Port: networkingv1.ServiceBackendPort{Number: 8080},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	canary := func(weight string) map[string]string {
+		return map[string]string{
+			"nginx.ingress.kubernetes.io/canary":        "true",
+			"nginx.ingress.kubernetes.io/canary-weight": weight,
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		ingresses       []networkingv1.Ingress
+		expectedWeights []*int32
+	}{{
+		name:            "single backend",
+		ingresses:       []networkingv1.Ingress{newIngress("example", "example", nil)},
+		expectedWeights: []*int32{nil},
+	}, {
+		name: "canary split",
+		ingresses: []networkingv1.Ingress{
+			newIngress("example", "example", nil),
+			newIngress("example-canary", "example-canary", canary("20")),
+		},
+		expectedWeights: []*int32{int32Ptr(80), int32Ptr(20)},
+	}, {
+		name: "canary of the primary backend",
+		ingresses: []networkingv1.Ingress{
+			newIngress("example", "example", nil),
+			newIngress("example-canary", "example", canary("20")),
+		},
+		expectedWeights: []*int32{nil},
+	}, {
+		name: "canary taking all the traffic",
+		ingresses: []networkingv1.Ingress{
+			newIngress("example", "example", nil),
+			newIngress("example-canary", "example-canary", canary("100")),
+		},
+		expectedWeights: []*int32{int32Ptr(0), int32Ptr(100)},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, Options{})
+			if err != nil {
+				t.Fatalf("Unexpected conversion error: %v", err)
+			}
+			if len(result.HTTPRoutes) != 1 || len(result.HTTPRoutes[0].Spec.Rules) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute with 1 rule, got %+v", result.HTTPRoutes)
+			}
+			var weights []*int32
+			for _, backendRef := range result.HTTPRoutes[0].Spec.Rules[0].BackendRefs {
+				weights = append(weights, backendRef.Weight)
+			}
+			if diff := cmp.Diff(tc.expectedWeights, weights); diff != "" {
+				t.Errorf("Unexpected backendRef weights (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_setBackendRefWeights(t *testing.T) {
+	backendRefs := func(weights ...*int32) []gatewayv1.BackendRef {
+		var refs []gatewayv1.BackendRef
+		for i, weight := range weights {
+			refs = append(refs, gatewayv1.BackendRef{
+				BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(fmt.Sprintf("backend-%d", i))},
+				Weight:                 weight,
+			})
+		}
+		return refs
+	}
+
+	testCases := []struct {
+		name            string
+		weights         []*int32
+		expectedWeights []*int32
+	}{{
+		name:            "single unweighted backend",
+		weights:         []*int32{nil},
+		expectedWeights: []*int32{nil},
+	}, {
+		name:            "single weighted backend",
+		weights:         []*int32{int32Ptr(100)},
+		expectedWeights: []*int32{nil},
+	}, {
+		name:            "single zero weighted backend",
+		weights:         []*int32{int32Ptr(0)},
+		expectedWeights: []*int32{int32Ptr(0)},
+	}, {
+		name:            "multiple unweighted backends",
+		weights:         []*int32{nil, nil},
+		expectedWeights: []*int32{int32Ptr(1), int32Ptr(1)},
+	}, {
+		name:            "multiple partially weighted backends",
+		weights:         []*int32{int32Ptr(3), nil},
+		expectedWeights: []*int32{int32Ptr(3), int32Ptr(1)},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tcpRoutes := []gatewayv1alpha2.TCPRoute{{
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					Rules: []gatewayv1alpha2.TCPRouteRule{{BackendRefs: backendRefs(tc.weights...)}},
+				},
+			}}
+			setBackendRefWeights(nil, nil, tcpRoutes, nil)
+			var weights []*int32
+			for _, backendRef := range tcpRoutes[0].Spec.Rules[0].BackendRefs {
+				weights = append(weights, backendRef.Weight)
+			}
+			if diff := cmp.Diff(tc.expectedWeights, weights); diff != "" {
+				t.Errorf("Unexpected backendRef weights (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	enableCORSAnnotation           = "nginx.ingress.kubernetes.io/enable-cors"
+	corsAllowOriginAnnotation      = "nginx.ingress.kubernetes.io/cors-allow-origin"
+	corsAllowMethodsAnnotation     = "nginx.ingress.kubernetes.io/cors-allow-methods"
+	corsAllowHeadersAnnotation     = "nginx.ingress.kubernetes.io/cors-allow-headers"
+	corsExposeHeadersAnnotation    = "nginx.ingress.kubernetes.io/cors-expose-headers"
+	corsAllowCredentialsAnnotation = "nginx.ingress.kubernetes.io/cors-allow-credentials"
+	corsMaxAgeAnnotation           = "nginx.ingress.kubernetes.io/cors-max-age"
+)
+
+// corsHeaders are the CORS response headers of ingress-nginx, with the
+// annotation setting them and their ingress-nginx default, empty when the
+// header isn't set by default.
+var corsHeaders = []struct {
+	header       string
+	annotation   string
+	defaultValue string
+}{
+	{header: "Access-Control-Allow-Origin", annotation: corsAllowOriginAnnotation, defaultValue: "*"},
+	{header: "Access-Control-Allow-Methods", annotation: corsAllowMethodsAnnotation, defaultValue: "GET, PUT, POST, DELETE, PATCH, OPTIONS"},
+	{header: "Access-Control-Allow-Headers", annotation: corsAllowHeadersAnnotation, defaultValue: "DNT,Keep-Alive,User-Agent,X-Requested-With,If-Modified-Since,Cache-Control,Content-Type,Range,Authorization"},
+	{header: "Access-Control-Expose-Headers", annotation: corsExposeHeadersAnnotation},
+	{header: "Access-Control-Allow-Credentials", annotation: corsAllowCredentialsAnnotation, defaultValue: "true"},
+	{header: "Access-Control-Max-Age", annotation: corsMaxAgeAnnotation, defaultValue: "1728000"},
+}
+
+// getCORSHeaders converts the CORS annotations of ingress-nginx to the
+// Access-Control-Allow-* response headers it sets, with its defaults for the
+// annotations that aren't set. Only the static part of CORS is converted:
+// ingress-nginx answers the preflight requests itself and echoes the origin of
+// the request when several origins are allowed, neither of which an HTTPRoute
+// can express, so both are reported as warnings.
+func getCORSHeaders(ingress networkingv1.Ingress, fieldPath *field.Path) (*headerModifier, []Warning) {
+	if ingress.Annotations[enableCORSAnnotation] != "true" {
+		return nil, nil
+	}
+	key := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	response := &headerModifier{}
+	warnings := []Warning{{
+		Ingress: key,
+		Field:   fieldPath.Key(enableCORSAnnotation),
+		Message: "the CORS headers are set on the responses of the backends, but ingress-nginx answers the OPTIONS preflight requests itself, which an HTTPRoute can't express; the backends must answer them or CORS must be configured on the Gateway implementation",
+	}}
+	for _, ch := range corsHeaders {
+		value, ok := ingress.Annotations[ch.annotation]
+		if !ok {
+			value = ch.defaultValue
+		}
+		value = strings.TrimSpace(value)
+		// ingress-nginx doesn't set Access-Control-Allow-Credentials when
+		// credentials aren't allowed.
+		if value == "" || (ch.annotation == corsAllowCredentialsAnnotation && value == "false") {
+			continue
+		}
+		if ch.annotation == corsAllowOriginAnnotation {
+			if origins := splitCORSOrigins(value); len(origins) > 1 || strings.Contains(value, "*.") {
+				warnings = append(warnings, Warning{
+					Ingress: key,
+					Field:   fieldPath.Key(corsAllowOriginAnnotation),
+					Message: fmt.Sprintf("ingress-nginx allows the origins %q by echoing the origin of every request, which a static header can't represent; Access-Control-Allow-Origin is not set", value),
+				})
+				continue
+			}
+		}
+		response.setHeader(ch.header, value)
+	}
+	return response, warnings
+}
+
+// splitCORSOrigins returns the comma separated origins of the
+// cors-allow-origin annotation.
+func splitCORSOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
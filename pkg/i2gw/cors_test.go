@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_getCORSHeaders(t *testing.T) {
+	testCases := []struct {
+		name              string
+		annotations       map[string]string
+		expectedResponse  *gatewayv1.HTTPHeaderFilter
+		expectNumWarnings int
+	}{{
+		name:        "CORS disabled",
+		annotations: map[string]string{corsAllowOriginAnnotation: "https://example.com"},
+	}, {
+		name:        "ingress-nginx defaults",
+		annotations: map[string]string{enableCORSAnnotation: "true"},
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Set: []gatewayv1.HTTPHeader{
+				{Name: "Access-Control-Allow-Credentials", Value: "true"},
+				{Name: "Access-Control-Allow-Headers", Value: "DNT,Keep-Alive,User-Agent,X-Requested-With,If-Modified-Since,Cache-Control,Content-Type,Range,Authorization"},
+				{Name: "Access-Control-Allow-Methods", Value: "GET, PUT, POST, DELETE, PATCH, OPTIONS"},
+				{Name: "Access-Control-Allow-Origin", Value: "*"},
+				{Name: "Access-Control-Max-Age", Value: "1728000"},
+			},
+		},
+		expectNumWarnings: 1,
+	}, {
+		name: "allow origin, methods and headers",
+		annotations: map[string]string{
+			enableCORSAnnotation:           "true",
+			corsAllowOriginAnnotation:      "https://example.com",
+			corsAllowMethodsAnnotation:     "GET, POST",
+			corsAllowHeadersAnnotation:     "X-Forwarded-For, X-App-Token",
+			corsExposeHeadersAnnotation:    "X-Request-Id",
+			corsAllowCredentialsAnnotation: "false",
+			corsMaxAgeAnnotation:           "600",
+		},
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Set: []gatewayv1.HTTPHeader{
+				{Name: "Access-Control-Allow-Headers", Value: "X-Forwarded-For, X-App-Token"},
+				{Name: "Access-Control-Allow-Methods", Value: "GET, POST"},
+				{Name: "Access-Control-Allow-Origin", Value: "https://example.com"},
+				{Name: "Access-Control-Expose-Headers", Value: "X-Request-Id"},
+				{Name: "Access-Control-Max-Age", Value: "600"},
+			},
+		},
+		expectNumWarnings: 1,
+	}, {
+		name: "several origins",
+		annotations: map[string]string{
+			enableCORSAnnotation:           "true",
+			corsAllowOriginAnnotation:      "https://example.com, https://*.example.org",
+			corsAllowMethodsAnnotation:     "GET",
+			corsAllowHeadersAnnotation:     "X-App-Token",
+			corsAllowCredentialsAnnotation: "false",
+			corsMaxAgeAnnotation:           "",
+		},
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Set: []gatewayv1.HTTPHeader{
+				{Name: "Access-Control-Allow-Headers", Value: "X-App-Token"},
+				{Name: "Access-Control-Allow-Methods", Value: "GET"},
+			},
+		},
+		expectNumWarnings: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: tc.annotations},
+			}
+			response, warnings := getCORSHeaders(ingress, field.NewPath("example", "metadata", "annotations"))
+			if diff := cmp.Diff(tc.expectedResponse, response.toFilter()); diff != "" {
+				t.Errorf("Unexpected response headers (-want +got):\n%s", diff)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func Test_getHeaderModifiersCORS(t *testing.T) {
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: map[string]string{
+			enableCORSAnnotation:           "true",
+			corsAllowOriginAnnotation:      "https://example.com",
+			corsAllowCredentialsAnnotation: "false",
+			corsMaxAgeAnnotation:           "",
+			corsAllowMethodsAnnotation:     "GET",
+			corsAllowHeadersAnnotation:     "",
+			configurationSnippetAnnotation: `more_set_headers "Access-Control-Allow-Origin: https://app.example.com";`,
+		}},
+	}
+	_, response, warnings, errs := getHeaderModifiers(ingress, nil)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %+v", errs)
+	}
+	expected := &gatewayv1.HTTPHeaderFilter{
+		Set: []gatewayv1.HTTPHeader{
+			{Name: "Access-Control-Allow-Methods", Value: "GET"},
+			{Name: "Access-Control-Allow-Origin", Value: "https://app.example.com"},
+		},
+	}
+	if diff := cmp.Diff(expected, response.toFilter()); diff != "" {
+		t.Errorf("Unexpected response headers (-want +got):\n%s", diff)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Expected the preflight warning, got %+v", warnings)
+	}
+}
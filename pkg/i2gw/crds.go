@@ -0,0 +1,281 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+	apiservervalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+var crdGroupKind = schema.GroupKind{Group: apiextensionsv1.GroupName, Kind: "CustomResourceDefinition"}
+
+// kustomizationFileNames are the names of the kustomization files, which
+// aren't Kubernetes objects, that CRD bundles such as the Gateway API ones
+// ship along with the CRDs.
+var kustomizationFileNames = map[string]bool{
+	"kustomization.yaml": true,
+	"kustomization.yml":  true,
+	"Kustomization":      true,
+}
+
+// bundledCRDs are the experimental channel CRDs of the Gateway API version
+// ingress2gateway is built with, copied from the config/crd/experimental
+// directory of its module, as TCPRoutes, TLSRoutes and BackendTLSPolicies are
+// only part of the experimental channel.
+//
+//go:embed crds/*.yaml
+var bundledCRDs embed.FS
+
+// bundledCRDsSource names the bundle of the bundled CRDs, see the
+// gateway.networking.k8s.io/bundle-version annotation of the CRDs.
+const bundledCRDsSource = "Gateway API v1.1.0"
+
+// CRDSchemas are the OpenAPI schemas of the served versions of the
+// CustomResourceDefinitions of a Gateway API CRD bundle, loaded with
+// LoadCRDSchemas.
+type CRDSchemas struct {
+	// source is the file or directory the CRDs are loaded from, or the
+	// Gateway API version of the bundled CRDs.
+	source  string
+	schemas map[schema.GroupVersionKind]crdSchema
+}
+
+// crdSchema is the schema of a served version of a CRD.
+type crdSchema struct {
+	validator  apiservervalidation.SchemaValidator
+	structural *structuralschema.Structural
+	// celValidator evaluates the x-kubernetes-validations rules of the
+	// schema, it is nil when the schema has none.
+	celValidator *cel.Validator
+}
+
+// LoadCRDSchemas reads the CustomResourceDefinitions of the YAML or JSON
+// manifest at path, or of the manifests of the directory at path, and returns
+// the schemas of their served versions. The other objects of the manifests, and
+// the kustomization files of the directory, are ignored. It fails when a
+// manifest can't be parsed, when a served version has no schema, or when no
+// CRD is found.
+func LoadCRDSchemas(path string) (*CRDSchemas, error) {
+	objs, err := readCRDManifests(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CRDs of %s: %w", path, err)
+	}
+
+	return newCRDSchemas(path, objs)
+}
+
+// bundledCRDSchemas returns the schemas of the bundled CRDs, which are only
+// loaded once.
+var bundledCRDSchemas = sync.OnceValues(func() (*CRDSchemas, error) {
+	entries, err := fs.ReadDir(bundledCRDs, "crds")
+	if err != nil {
+		return nil, err
+	}
+	var objs []*unstructured.Unstructured
+	for _, entry := range entries {
+		data, err := bundledCRDs.ReadFile(path.Join("crds", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fileObjs, docErrs, err := decodeYAMLManifest(data)
+		if err == nil && len(docErrs) > 0 {
+			err = docErrs[0]
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the bundled CRDs of %s: %w", entry.Name(), err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return newCRDSchemas(bundledCRDsSource, objs)
+})
+
+// newCRDSchemas returns the schemas of the served versions of the CRDs
+// among objs, loaded from source. It fails when a served version has no
+// schema, or when no CRD is found.
+func newCRDSchemas(source string, objs []*unstructured.Unstructured) (*CRDSchemas, error) {
+	crds := &CRDSchemas{source: source, schemas: map[schema.GroupVersionKind]crdSchema{}}
+	for _, obj := range objs {
+		if obj.GroupVersionKind().GroupKind() != crdGroupKind {
+			continue
+		}
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &crd); err != nil {
+			return nil, fmt.Errorf("failed to read the CRD %s of %s: %w", obj.GetName(), source, err)
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served {
+				continue
+			}
+			s, err := newCRDSchema(version.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the schema of version %s of the CRD %s of %s: %w", version.Name, crd.Name, source, err)
+			}
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			crds.schemas[gvk] = s
+		}
+	}
+	if len(crds.schemas) == 0 {
+		return nil, fmt.Errorf("no CustomResourceDefinition found in %s", source)
+	}
+	return crds, nil
+}
+
+// readCRDManifests reads the objects of the manifest at path, or of the
+// .json, .yaml and .yml manifests of the directory at path and its
+// subdirectories, in lexical order, skipping the kustomization files.
+func readCRDManifests(path string) ([]*unstructured.Unstructured, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readManifest(path, InputFormatAuto)
+	}
+
+	var objs []*unstructured.Unstructured
+	err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || kustomizationFileNames[d.Name()] {
+			return nil
+		}
+		if _, ok := manifestExtensions[strings.ToLower(filepath.Ext(path))]; !ok {
+			return nil
+		}
+		fileObjs, err := readManifest(path, InputFormatAuto)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, fileObjs...)
+		return nil
+	})
+	return objs, err
+}
+
+// newCRDSchema returns the validators of the OpenAPI schema of a CRD version,
+// along with its structural schema used to find the unknown fields and to
+// default the resources.
+func newCRDSchema(validation *apiextensionsv1.CustomResourceValidation) (crdSchema, error) {
+	if validation == nil || validation.OpenAPIV3Schema == nil {
+		return crdSchema{}, fmt.Errorf("the version has no openAPIV3Schema")
+	}
+	var props apiextensions.JSONSchemaProps
+	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(validation.OpenAPIV3Schema, &props, nil); err != nil {
+		return crdSchema{}, err
+	}
+	validator, _, err := apiservervalidation.NewSchemaValidator(&props)
+	if err != nil {
+		return crdSchema{}, err
+	}
+	structural, err := structuralschema.NewStructural(&props)
+	if err != nil {
+		return crdSchema{}, err
+	}
+	return crdSchema{
+		validator:    validator,
+		structural:   structural,
+		celValidator: cel.NewValidator(structural, true, celconfig.PerCallLimit),
+	}, nil
+}
+
+// ValidateResourcesWithCRDs checks the generated resources locally against
+// the OpenAPI schemas of the given CRDs instead of the bundled ones, including
+// the fields unknown to the schemas, which the API server would drop, and
+// their CEL validation rules. As with the API server, the resources are validated once defaulted.
+// Resources of a version the CRDs don't serve are reported as errors.
+func ValidateResourcesWithCRDs(crds *CRDSchemas, httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant) error {
+	var errs field.ErrorList
+	for i := range gateways {
+		errs = append(errs, crds.validate(&gateways[i], gateways[i].ObjectMeta)...)
+	}
+	for i := range httpRoutes {
+		errs = append(errs, crds.validate(&httpRoutes[i], httpRoutes[i].ObjectMeta)...)
+	}
+	for i := range grpcRoutes {
+		errs = append(errs, crds.validate(&grpcRoutes[i], grpcRoutes[i].ObjectMeta)...)
+	}
+	for i := range tcpRoutes {
+		errs = append(errs, crds.validate(&tcpRoutes[i], tcpRoutes[i].ObjectMeta)...)
+	}
+	for i := range tlsRoutes {
+		errs = append(errs, crds.validate(&tlsRoutes[i], tlsRoutes[i].ObjectMeta)...)
+	}
+	for i := range backendTLSPolicies {
+		errs = append(errs, crds.validate(&backendTLSPolicies[i], backendTLSPolicies[i].ObjectMeta)...)
+	}
+	for i := range referenceGrants {
+		errs = append(errs, crds.validate(&referenceGrants[i], referenceGrants[i].ObjectMeta)...)
+	}
+	return errs.ToAggregate()
+}
+
+// validate checks obj against the schema of the CRD of its group, version and
+// kind.
+func (crds *CRDSchemas) validate(obj runtime.Object, meta metav1.ObjectMeta) field.ErrorList {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	path, errs := validateObjectMeta(gvk.Kind, meta)
+	s, ok := crds.schemas[gvk]
+	if !ok {
+		return append(errs, field.Invalid(path.Child("apiVersion"), gvk.GroupVersion().String(), fmt.Sprintf("%s isn't served by the CRDs of %s", gvk.Kind, crds.source)))
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return append(errs, field.InternalError(path, err))
+	}
+	// The generated resources have no status, the empty status of the typed
+	// resources isn't part of the output.
+	delete(content, "status")
+
+	unknownFields := pruning.PruneWithOptions(content, s.structural, true, structuralschema.UnknownFieldPathOptions{TrackUnknownFieldPaths: true})
+	for _, unknownField := range unknownFields {
+		errs = append(errs, field.Forbidden(path.Child(unknownField), "field not declared in the schema"))
+	}
+	defaulting.Default(content, s.structural)
+	errs = append(errs, apiservervalidation.ValidateCustomResource(path, content, s.validator)...)
+	if s.celValidator != nil {
+		celErrs, _ := s.celValidator.Validate(context.Background(), path, s.structural, content, nil, celconfig.RuntimeCELCostBudget)
+		errs = append(errs, celErrs...)
+	}
+	return errs
+}
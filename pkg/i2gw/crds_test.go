@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_LoadCRDSchemas(t *testing.T) {
+	dir := t.TempDir()
+	noSchema := filepath.Join(dir, "no-schema.yaml")
+	if err := os.WriteFile(noSchema, []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: httproutes.gateway.networking.k8s.io
+spec:
+  group: gateway.networking.k8s.io
+  names:
+    kind: HTTPRoute
+    plural: httproutes
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		path          string
+		expectedKinds int
+		expectError   bool
+	}{{
+		name:          "directory with a kustomization file",
+		path:          "testdata/crds",
+		expectedKinds: 1,
+	}, {
+		name:          "file",
+		path:          "testdata/crds/httproutes.yaml",
+		expectedKinds: 1,
+	}, {
+		name:        "missing path",
+		path:        "testdata/crds/missing.yaml",
+		expectError: true,
+	}, {
+		name:        "no CRD",
+		path:        "testdata/mapping.yaml",
+		expectError: true,
+	}, {
+		name:        "served version without schema",
+		path:        noSchema,
+		expectError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			crds, err := LoadCRDSchemas(tc.path)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(crds.schemas) != tc.expectedKinds {
+				t.Errorf("Expected %d served versions, got %d", tc.expectedKinds, len(crds.schemas))
+			}
+		})
+	}
+}
+
+func Test_ValidateResourcesWithCRDs(t *testing.T) {
+	crds, err := LoadCRDSchemas("testdata/crds")
+	if err != nil {
+		t.Fatalf("Failed to load the CRDs: %v", err)
+	}
+
+	newHTTPRoute := func(rule gatewayv1.HTTPRouteRule) gatewayv1beta1.HTTPRoute {
+		httpRoute := gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{Name: "nginx"}}},
+				Hostnames:       []gatewayv1.Hostname{"example.com"},
+				Rules:           []gatewayv1.HTTPRouteRule{rule},
+			},
+		}
+		httpRoute.SetGroupVersionKind(httpRouteGVK)
+		return httpRoute
+	}
+	backendRef := gatewayv1.HTTPBackendRef{BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{
+		Name: "app",
+		Port: (*gatewayv1.PortNumber)(pointer.Int32(80)),
+	}}}
+
+	testCases := []struct {
+		name            string
+		httpRoutes      []gatewayv1beta1.HTTPRoute
+		gateways        []gatewayv1beta1.Gateway
+		expectNumErrors int
+	}{{
+		name:       "valid HTTPRoute",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{BackendRefs: []gatewayv1.HTTPBackendRef{backendRef}})},
+	}, {
+		name: "too many hostnames",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{func() gatewayv1beta1.HTTPRoute {
+			httpRoute := newHTTPRoute(gatewayv1.HTTPRouteRule{BackendRefs: []gatewayv1.HTTPBackendRef{backendRef}})
+			for i := 0; i < 16; i++ {
+				httpRoute.Spec.Hostnames = append(httpRoute.Spec.Hostnames, "example.com")
+			}
+			return httpRoute
+		}()},
+		expectNumErrors: 1,
+	}, {
+		name: "unsupported filter type",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			Filters: []gatewayv1.HTTPRouteFilter{{Type: gatewayv1.HTTPRouteFilterURLRewrite}},
+		})},
+		expectNumErrors: 1,
+	}, {
+		name: "validation rule of the schema",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			Filters: []gatewayv1.HTTPRouteFilter{{Type: gatewayv1.HTTPRouteFilterRequestRedirect}},
+		})},
+		expectNumErrors: 1,
+	}, {
+		name: "field unknown to the schema",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			BackendRefs: []gatewayv1.HTTPBackendRef{backendRef},
+			Timeouts:    &gatewayv1.HTTPRouteTimeouts{Request: (*gatewayv1.Duration)(pointer.String("10s"))},
+		})},
+		expectNumErrors: 1,
+	}, {
+		name: "version not served",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{func() gatewayv1beta1.HTTPRoute {
+			httpRoute := newHTTPRoute(gatewayv1.HTTPRouteRule{BackendRefs: []gatewayv1.HTTPBackendRef{backendRef}})
+			httpRoute.SetGroupVersionKind(gatewayv1.SchemeGroupVersion.WithKind("HTTPRoute"))
+			return httpRoute
+		}()},
+		expectNumErrors: 1,
+	}, {
+		name: "kind without CRD",
+		gateways: []gatewayv1beta1.Gateway{func() gatewayv1beta1.Gateway {
+			gateway := gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+				Spec:       gatewayv1.GatewaySpec{GatewayClassName: "nginx"},
+			}
+			gateway.SetGroupVersionKind(gatewayGVK)
+			return gateway
+		}()},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateResourcesWithCRDs(crds, tc.httpRoutes, nil, nil, nil, tc.gateways, nil, nil)
+			numErrors := 0
+			if err != nil {
+				numErrors = len(err.(utilerrors.Aggregate).Errors())
+			}
+			if numErrors != tc.expectNumErrors {
+				t.Errorf("Expected %d errors, got %d: %v", tc.expectNumErrors, numErrors, err)
+			}
+		})
+	}
+}
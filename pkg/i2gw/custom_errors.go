@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	customHTTPErrorsAnnotation    = "nginx.ingress.kubernetes.io/custom-http-errors"
+	nginxDefaultBackendAnnotation = "nginx.ingress.kubernetes.io/default-backend"
+)
+
+// errorBackendHeaders are the headers ingress-nginx sets on the requests it
+// sends to the backend of the custom error pages.
+var errorBackendHeaders = []string{"X-Code", "X-Format", "X-Original-URI", "X-Namespace", "X-Ingress-Name", "X-Service-Name", "X-Service-Port", "X-Request-ID"}
+
+// getCustomHTTPErrors reads the custom error pages of ingress-nginx, the
+// status codes of the custom-http-errors annotation served by the Service of
+// the default-backend annotation, or by the default backend of the controller
+// when it isn't set. Gateway API can't express them, so they are returned as
+// a warning describing the equivalent to configure in the Gateway
+// implementation. The Service of the error pages isn't a backend of the
+// generated routes.
+func getCustomHTTPErrors(ingress networkingv1.Ingress, fieldPath *field.Path) *Warning {
+	errorBackend := strings.TrimSpace(ingress.Annotations[nginxDefaultBackendAnnotation])
+	value, ok := ingress.Annotations[customHTTPErrorsAnnotation]
+	if !ok {
+		if errorBackend == "" {
+			return nil
+		}
+		return &Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(nginxDefaultBackendAnnotation),
+			Message: fmt.Sprintf("ingress-nginx routes the requests of the Ingress to the Service %s/%s when its backends have no available endpoints, which Gateway API can't express, so the routes are generated without this fallback", ingress.Namespace, errorBackend),
+		}
+	}
+
+	var codes, invalidCodes []string
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(code); err != nil || n < 300 || n > 599 {
+			invalidCodes = append(invalidCodes, code)
+			continue
+		}
+		codes = append(codes, code)
+	}
+
+	handler := "the default backend of the ingress-nginx controller"
+	if errorBackend != "" {
+		handler = fmt.Sprintf("the Service %s/%s", ingress.Namespace, errorBackend)
+	}
+	var msg strings.Builder
+	if len(codes) > 0 {
+		fmt.Fprintf(&msg, "ingress-nginx replaces the responses with status codes %s of the backends of the Ingress by the error pages of %s, which Gateway API can't express, so the routes are generated without them. ", strings.Join(codes, ", "), handler)
+		fmt.Fprintf(&msg, "Configure the custom error responses of the Gateway implementation instead, e.g. a response override of an Envoy Gateway BackendTrafficPolicy or the error pages of the gateway proxy, so that these status codes are served by %s. ", handler)
+		fmt.Fprintf(&msg, "ingress-nginx describes the original request to it with the %s headers", strings.Join(errorBackendHeaders, ", "))
+	} else {
+		msg.WriteString("no status code of the Ingress is replaced by custom error pages")
+	}
+	if len(invalidCodes) > 0 {
+		fmt.Fprintf(&msg, "; the invalid status codes %s are ignored, they must be between 300 and 599", strings.Join(invalidCodes, ", "))
+	}
+	return &Warning{
+		Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+		Field:   fieldPath.Key(customHTTPErrorsAnnotation),
+		Message: msg.String(),
+	}
+}
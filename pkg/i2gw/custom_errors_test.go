@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_getCustomHTTPErrors(t *testing.T) {
+	testCases := []struct {
+		name             string
+		annotations      map[string]string
+		expectWarning    bool
+		expectedField    string
+		expectedMessages []string
+	}{{
+		name: "no custom errors",
+	}, {
+		name: "error codes served by a Service",
+		annotations: map[string]string{
+			customHTTPErrorsAnnotation:    "404, 503",
+			nginxDefaultBackendAnnotation: "error-pages",
+		},
+		expectWarning:    true,
+		expectedField:    customHTTPErrorsAnnotation,
+		expectedMessages: []string{"status codes 404, 503", "the Service test/error-pages", "X-Code"},
+	}, {
+		name:             "error codes served by the default backend of the controller",
+		annotations:      map[string]string{customHTTPErrorsAnnotation: "500"},
+		expectWarning:    true,
+		expectedField:    customHTTPErrorsAnnotation,
+		expectedMessages: []string{"status codes 500", "the default backend of the ingress-nginx controller"},
+	}, {
+		name:             "invalid error codes",
+		annotations:      map[string]string{customHTTPErrorsAnnotation: "404,abc,200"},
+		expectWarning:    true,
+		expectedField:    customHTTPErrorsAnnotation,
+		expectedMessages: []string{"status codes 404 ", "invalid status codes abc, 200"},
+	}, {
+		name:             "no valid error code",
+		annotations:      map[string]string{customHTTPErrorsAnnotation: "abc"},
+		expectWarning:    true,
+		expectedField:    customHTTPErrorsAnnotation,
+		expectedMessages: []string{"no status code", "invalid status codes abc"},
+	}, {
+		name:             "default backend without error codes",
+		annotations:      map[string]string{nginxDefaultBackendAnnotation: "fallback"},
+		expectWarning:    true,
+		expectedField:    nginxDefaultBackendAnnotation,
+		expectedMessages: []string{"the Service test/fallback", "no available endpoints"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: tc.annotations},
+			}
+			fieldPath := field.NewPath("example", "metadata", "annotations")
+			warning := getCustomHTTPErrors(ingress, fieldPath)
+			if !tc.expectWarning {
+				if warning != nil {
+					t.Fatalf("Expected no warning, got %+v", warning)
+				}
+				return
+			}
+			if warning == nil {
+				t.Fatalf("Expected a warning, got none")
+			}
+			if warning.Field.String() != fieldPath.Key(tc.expectedField).String() {
+				t.Errorf("Expected the warning of %s, got %s", fieldPath.Key(tc.expectedField), warning.Field)
+			}
+			for _, msg := range tc.expectedMessages {
+				if !strings.Contains(warning.Message, msg) {
+					t.Errorf("Expected the warning %q to contain %q", warning.Message, msg)
+				}
+			}
+		})
+	}
+}
+
+func Test_convertCustomHTTPErrors(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: map[string]string{
+			customHTTPErrorsAnnotation:    "404,503",
+			nginxDefaultBackendAnnotation: "error-pages",
+		}},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("ingress-nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "app.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: "app", Port: networkingv1.ServiceBackendPort{Number: 80}},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	result, err := Convert([]networkingv1.Ingress{ingress}, Options{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(result.HTTPRoutes) != 1 {
+		t.Fatalf("Expected a single HTTPRoute, got %+v", result.HTTPRoutes)
+	}
+	for _, rule := range result.HTTPRoutes[0].Spec.Rules {
+		if len(rule.BackendRefs) != 1 || rule.BackendRefs[0].Name != "app" {
+			t.Errorf("Expected the rule to only route to the app Service, got %+v", rule.BackendRefs)
+		}
+	}
+	numWarnings := 0
+	for _, w := range result.Warnings {
+		if w.Field != nil && strings.Contains(w.Field.String(), customHTTPErrorsAnnotation) {
+			numWarnings++
+		}
+	}
+	if numWarnings != 1 {
+		t.Errorf("Expected a warning about the custom error pages, got %+v", result.Warnings)
+	}
+	if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+		t.Errorf("Expected valid resources, got %v", err)
+	}
+}
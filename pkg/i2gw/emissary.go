@@ -0,0 +1,613 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// emissaryGatewayClass is the class, and the name, of the Gateways generated
+// from Mappings.
+const emissaryGatewayClass = "emissary"
+
+// emissaryDefaultRewrite is the rewrite of the Mappings without rewrite:
+// Emissary replaces the matched prefix with /.
+const emissaryDefaultRewrite = "/"
+
+var emissaryMappingGVK = schema.GroupVersionKind{
+	Group:   "getambassador.io",
+	Version: "v3alpha1",
+	Kind:    "Mapping",
+}
+
+// EmissaryMapping is the subset of the Emissary-ingress getambassador.io
+// Mapping resource read by the conversion. Fields of features that can't be
+// converted are only read to report them.
+type EmissaryMapping struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              EmissaryMappingSpec `json:"spec,omitempty"`
+}
+
+// EmissaryMappingSpec is the spec of a Mapping.
+type EmissaryMappingSpec struct {
+	Prefix      string  `json:"prefix,omitempty"`
+	PrefixRegex bool    `json:"prefix_regex,omitempty"`
+	PrefixExact bool    `json:"prefix_exact,omitempty"`
+	Rewrite     *string `json:"rewrite,omitempty"`
+	// Hostname supersedes the deprecated Host and HostRegex fields.
+	Hostname  string `json:"hostname,omitempty"`
+	Host      string `json:"host,omitempty"`
+	HostRegex bool   `json:"host_regex,omitempty"`
+	Service   string `json:"service,omitempty"`
+	Weight    int64  `json:"weight,omitempty"`
+
+	Method               string                 `json:"method,omitempty"`
+	MethodRegex          bool                   `json:"method_regex,omitempty"`
+	Headers              map[string]interface{} `json:"headers,omitempty"`
+	RegexHeaders         map[string]string      `json:"regex_headers,omitempty"`
+	QueryParameters      map[string]interface{} `json:"query_parameters,omitempty"`
+	RegexQueryParameters map[string]string      `json:"regex_query_parameters,omitempty"`
+
+	// AddRequestHeaders and AddResponseHeaders map the header names to
+	// either their value or to an object with a value and whether it is
+	// appended, true by default.
+	AddRequestHeaders     map[string]interface{} `json:"add_request_headers,omitempty"`
+	AddResponseHeaders    map[string]interface{} `json:"add_response_headers,omitempty"`
+	RemoveRequestHeaders  []string               `json:"remove_request_headers,omitempty"`
+	RemoveResponseHeaders []string               `json:"remove_response_headers,omitempty"`
+
+	LoadBalancer     map[string]interface{} `json:"load_balancer,omitempty"`
+	RetryPolicy      map[string]interface{} `json:"retry_policy,omitempty"`
+	CORS             map[string]interface{} `json:"cors,omitempty"`
+	CircuitBreakers  []interface{}          `json:"circuit_breakers,omitempty"`
+	Labels           map[string]interface{} `json:"labels,omitempty"`
+	RegexRewrite     map[string]interface{} `json:"regex_rewrite,omitempty"`
+	TimeoutMs        *int64                 `json:"timeout_ms,omitempty"`
+	IdleTimeoutMs    *int64                 `json:"idle_timeout_ms,omitempty"`
+	ConnectTimeoutMs *int64                 `json:"connect_timeout_ms,omitempty"`
+	Precedence       *int64                 `json:"precedence,omitempty"`
+	CaseSensitive    *bool                  `json:"case_sensitive,omitempty"`
+	HostRedirect     bool                   `json:"host_redirect,omitempty"`
+	Shadow           bool                   `json:"shadow,omitempty"`
+	BypassAuth       bool                   `json:"bypass_auth,omitempty"`
+	GRPC             bool                   `json:"grpc,omitempty"`
+	TLS              interface{}            `json:"tls,omitempty"`
+	Resolver         string                 `json:"resolver,omitempty"`
+}
+
+// unsupported returns the fields of the Mapping that can't be converted.
+func (s *EmissaryMappingSpec) unsupported() []string {
+	var fields []string
+	for name, set := range map[string]bool{
+		"load_balancer":      s.LoadBalancer != nil,
+		"retry_policy":       s.RetryPolicy != nil,
+		"cors":               s.CORS != nil,
+		"circuit_breakers":   len(s.CircuitBreakers) > 0,
+		"labels":             s.Labels != nil,
+		"timeout_ms":         s.TimeoutMs != nil,
+		"idle_timeout_ms":    s.IdleTimeoutMs != nil,
+		"connect_timeout_ms": s.ConnectTimeoutMs != nil,
+		"case_sensitive":     s.CaseSensitive != nil && !*s.CaseSensitive,
+		"bypass_auth":        s.BypassAuth,
+		"grpc":               s.GRPC,
+		"tls":                s.TLS != nil,
+		"resolver":           s.Resolver != "",
+	} {
+		if set {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ConstructMappingsFromFile reads the inputFile in either json/yaml formats,
+// then deserialize the Mappings it contains. When namespace is set, only the
+// Mappings of that namespace are returned. The documents failing to be
+// parsed are returned as ManifestErrors along with the Mappings of the other
+// documents.
+func ConstructMappingsFromFile(inputFile string, namespace string, format string) ([]EmissaryMapping, error) {
+	objs, err := readObjectsFromFile(inputFile, format)
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) && err != nil {
+		return nil, err
+	}
+	mappings, err := mappingsFromUnstructured(objs, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifestErrs) > 0 {
+		return mappings, manifestErrs
+	}
+	return mappings, nil
+}
+
+// ConstructMappingsFromCluster lists the Mappings of the namespace, or of all
+// namespaces when namespace is empty, from the cluster.
+func ConstructMappingsFromCluster(ctx context.Context, cl client.Client, namespace string) ([]EmissaryMapping, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(emissaryMappingGVK.GroupVersion().WithKind(emissaryMappingGVK.Kind + "List"))
+	if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to get Mappings from the cluster: %w", err)
+	}
+	var objs []*unstructured.Unstructured
+	for i := range list.Items {
+		objs = append(objs, &list.Items[i])
+	}
+	return mappingsFromUnstructured(objs, "")
+}
+
+func mappingsFromUnstructured(objs []*unstructured.Unstructured, namespace string) ([]EmissaryMapping, error) {
+	var mappings []EmissaryMapping
+	for _, obj := range objs {
+		// The Mappings of every version of the getambassador.io API are
+		// read, their fields read by the conversion are the same.
+		if obj.GroupVersionKind().GroupKind() != emissaryMappingGVK.GroupKind() {
+			continue
+		}
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		var mapping EmissaryMapping
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse Mapping %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// emissaryRoute holds the rules of the HTTPRoute generated for a hostname of
+// a namespace.
+type emissaryRoute struct {
+	namespace string
+	hostname  string
+	rules     []*emissaryRule
+	// ruleKeys indexes the rules by their match.
+	ruleKeys map[string]*emissaryRule
+}
+
+// emissaryRule is the rule of the Mappings with the same match, splitting
+// the traffic across their services.
+type emissaryRule struct {
+	rule gatewayv1beta1.HTTPRouteRule
+	// weights are the weights of the backendRefs, zero when the Mapping has
+	// no weight.
+	weights []int64
+}
+
+// Mappings2GatewaysAndHTTPRoutes converts Emissary-ingress Mappings into an
+// HTTPRoute per hostname and namespace, and into the listeners of these
+// hostnames on a Gateway per namespace. Mappings with the same match are
+// converted to a single rule, their services weighted as with Emissary.
+// Features of Emissary that can't be represented in Gateway API are
+// reported through the returned warnings.
+func Mappings2GatewaysAndHTTPRoutes(mappings []EmissaryMapping) ([]gatewayv1beta1.HTTPRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	var warnings []Warning
+	var errors field.ErrorList
+
+	routesByKey := map[string]*emissaryRoute{}
+	var routeKeys []string
+	// routeWarnings holds the warnings of the Mappings converted to the
+	// rules of every route.
+	routeWarnings := map[string][]Warning{}
+	for _, mapping := range mappings {
+		key := types.NamespacedName{Namespace: mapping.Namespace, Name: mapping.Name}
+		specPath := field.NewPath(mapping.Name, "spec")
+		hostname, ok, warning := mappingHostname(mapping, specPath)
+		if !ok {
+			warnings = append(warnings, warning)
+			continue
+		}
+		rule, weight, mappingWarnings, errs := toMappingRule(mapping, specPath)
+		if len(errs) > 0 {
+			errors = append(errors, errs...)
+			continue
+		}
+
+		routeKey := fmt.Sprintf("%s/%s", mapping.Namespace, hostname)
+		route, ok := routesByKey[routeKey]
+		if !ok {
+			route = &emissaryRoute{namespace: mapping.Namespace, hostname: hostname, ruleKeys: map[string]*emissaryRule{}}
+			routesByKey[routeKey] = route
+			routeKeys = append(routeKeys, routeKey)
+		}
+		if rule == nil {
+			routeWarnings[routeKey] = append(routeWarnings[routeKey], mappingWarnings...)
+			continue
+		}
+		// The matches hold pointers, they are compared by their JSON.
+		matches, _ := json.Marshal(rule.Matches)
+		matchKey := string(matches)
+		if existing, ok := route.ruleKeys[matchKey]; ok {
+			if !apiequality.Semantic.DeepEqual(existing.rule.Filters, rule.Filters) {
+				mappingWarnings = append(mappingWarnings, Warning{
+					Ingress: key,
+					Field:   specPath,
+					Message: "the Mapping splits the traffic of other Mappings with different rewrites or headers, the filters of the first Mapping apply to all the services",
+				})
+			}
+			existing.rule.BackendRefs = append(existing.rule.BackendRefs, rule.BackendRefs...)
+			existing.weights = append(existing.weights, weight)
+		} else {
+			r := &emissaryRule{rule: *rule, weights: []int64{weight}}
+			route.ruleKeys[matchKey] = r
+			route.rules = append(route.rules, r)
+		}
+		routeWarnings[routeKey] = append(routeWarnings[routeKey], mappingWarnings...)
+	}
+
+	var httpRoutes []gatewayv1beta1.HTTPRoute
+	gatewaysByNamespace := map[string]*gatewayv1beta1.Gateway{}
+	var namespaces []string
+	for _, routeKey := range routeKeys {
+		route := routesByKey[routeKey]
+		gateway, ok := gatewaysByNamespace[route.namespace]
+		if !ok {
+			gateway = &gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Namespace: route.namespace, Name: emissaryGatewayClass},
+				Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: emissaryGatewayClass},
+			}
+			gateway.SetGroupVersionKind(gatewayGVK)
+			gatewaysByNamespace[route.namespace] = gateway
+			namespaces = append(namespaces, route.namespace)
+		}
+		listener := gatewayv1beta1.Listener{
+			Name:     "http",
+			Port:     80,
+			Protocol: gatewayv1.HTTPProtocolType,
+		}
+		httpRoute := gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: nameFromHost(route.hostname), Namespace: route.namespace},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+					ParentRefs: []gatewayv1beta1.ParentReference{{Name: emissaryGatewayClass}},
+				},
+			},
+			Status: gatewayv1beta1.HTTPRouteStatus{
+				RouteStatus: gatewayv1beta1.RouteStatus{
+					Parents: []gatewayv1beta1.RouteParentStatus{},
+				},
+			},
+		}
+		httpRoute.SetGroupVersionKind(httpRouteGVK)
+		if route.hostname != "" {
+			hostname := gatewayv1beta1.Hostname(route.hostname)
+			listener.Hostname = &hostname
+			listener.Name = gatewayv1beta1.SectionName(listenerNamePrefix(&hostname) + "http")
+			httpRoute.Spec.Hostnames = []gatewayv1beta1.Hostname{hostname}
+		}
+		gateway.Spec.Listeners = append(gateway.Spec.Listeners, listener)
+
+		for _, r := range route.rules {
+			setMappingWeights(r)
+			httpRoute.Spec.Rules = append(httpRoute.Spec.Rules, r.rule)
+		}
+		for _, warning := range routeWarnings[routeKey] {
+			warning.HTTPRoute = types.NamespacedName{Namespace: httpRoute.Namespace, Name: httpRoute.Name}
+			warnings = append(warnings, warning)
+		}
+		if len(httpRoute.Spec.Rules) > 0 {
+			httpRoutes = append(httpRoutes, httpRoute)
+		}
+	}
+
+	var gateways []gatewayv1beta1.Gateway
+	for _, namespace := range namespaces {
+		gateways = append(gateways, *gatewaysByNamespace[namespace])
+	}
+	return httpRoutes, gateways, warnings, errors
+}
+
+// mappingHostname returns the hostname of the Mapping, empty for all the
+// hostnames, and whether it can be converted. Emissary matches the Mappings
+// without hostname, or with the * hostname, on all the hostnames.
+func mappingHostname(mapping EmissaryMapping, specPath *field.Path) (string, bool, Warning) {
+	key := types.NamespacedName{Namespace: mapping.Namespace, Name: mapping.Name}
+	hostname, hostnamePath := mapping.Spec.Hostname, specPath.Child("hostname")
+	if hostname == "" {
+		hostname, hostnamePath = mapping.Spec.Host, specPath.Child("host")
+		if mapping.Spec.HostRegex {
+			return "", false, Warning{
+				Ingress: key,
+				Field:   specPath.Child("host_regex"),
+				Message: "hostnames can't be matched by regular expression in Gateway API, the Mapping isn't converted",
+			}
+		}
+	}
+	if hostname == "" || hostname == "*" {
+		return "", true, Warning{}
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(hostname, "*.")); len(errs) > 0 {
+		return "", false, Warning{
+			Ingress: key,
+			Field:   hostnamePath,
+			Message: fmt.Sprintf("%q isn't a hostname or a wildcard hostname Gateway API can match, the Mapping isn't converted: %s", hostname, strings.Join(errs, ", ")),
+		}
+	}
+	return hostname, true, Warning{}
+}
+
+// toMappingRule converts the Mapping into the rule routing the requests it
+// matches to its service, along with the weight of the service. Mappings
+// whose conditions or service can't be converted return no rule, with a
+// warning.
+func toMappingRule(mapping EmissaryMapping, specPath *field.Path) (*gatewayv1beta1.HTTPRouteRule, int64, []Warning, field.ErrorList) {
+	key := types.NamespacedName{Namespace: mapping.Namespace, Name: mapping.Name}
+	spec := mapping.Spec
+	var warnings []Warning
+	warn := func(path *field.Path, message string) {
+		warnings = append(warnings, Warning{Ingress: key, Field: path, Message: message})
+	}
+	if spec.Prefix == "" {
+		return nil, 0, nil, field.ErrorList{field.Required(specPath.Child("prefix"), "Mappings must have a prefix")}
+	}
+	if spec.Service == "" {
+		return nil, 0, nil, field.ErrorList{field.Required(specPath.Child("service"), "Mappings must have a service")}
+	}
+	for _, name := range spec.unsupported() {
+		warn(specPath.Child(name), fmt.Sprintf("%s is not supported by Gateway API and must be configured on the Gateway implementation", name))
+	}
+	if spec.Precedence != nil {
+		warn(specPath.Child("precedence"), "Gateway API orders the rules by the specificity of their matches, the precedence of the Mapping isn't converted")
+	}
+	for name, set := range map[string]bool{
+		"host_redirect": spec.HostRedirect,
+		"shadow":        spec.Shadow,
+		"regex_rewrite": spec.RegexRewrite != nil,
+	} {
+		if set {
+			warn(specPath.Child(name), fmt.Sprintf("%s can't be converted, the Mapping isn't converted and must be converted manually", name))
+		}
+	}
+	if spec.HostRedirect || spec.Shadow || spec.RegexRewrite != nil {
+		return nil, 0, warnings, nil
+	}
+
+	match := gatewayv1beta1.HTTPRouteMatch{}
+	pathType := gatewayv1.PathMatchPathPrefix
+	switch {
+	case spec.PrefixRegex:
+		pathType = gatewayv1.PathMatchRegularExpression
+	case spec.PrefixExact:
+		pathType = gatewayv1.PathMatchExact
+	}
+	match.Path = &gatewayv1beta1.HTTPPathMatch{Type: &pathType, Value: pointer.String(spec.Prefix)}
+
+	if spec.Method != "" {
+		if spec.MethodRegex {
+			warn(specPath.Child("method_regex"), "methods can't be matched by regular expression in Gateway API, the Mapping isn't converted")
+			return nil, 0, warnings, nil
+		}
+		method := gatewayv1.HTTPMethod(strings.ToUpper(spec.Method))
+		match.Method = &method
+	}
+	headerMatchExact := gatewayv1.HeaderMatchExact
+	headerMatchRegularExpression := gatewayv1.HeaderMatchRegularExpression
+	for _, name := range sortedMappingKeys(spec.Headers) {
+		value, ok := spec.Headers[name].(string)
+		if !ok {
+			warn(specPath.Child("headers").Key(name), "only header conditions on a value can be converted, the Mapping isn't converted")
+			return nil, 0, warnings, nil
+		}
+		match.Headers = append(match.Headers, gatewayv1beta1.HTTPHeaderMatch{Type: &headerMatchExact, Name: gatewayv1.HTTPHeaderName(name), Value: value})
+	}
+	for _, name := range sortedMappingKeys(spec.RegexHeaders) {
+		match.Headers = append(match.Headers, gatewayv1beta1.HTTPHeaderMatch{Type: &headerMatchRegularExpression, Name: gatewayv1.HTTPHeaderName(name), Value: spec.RegexHeaders[name]})
+	}
+	queryParamMatchExact := gatewayv1.QueryParamMatchExact
+	queryParamMatchRegularExpression := gatewayv1.QueryParamMatchRegularExpression
+	for _, name := range sortedMappingKeys(spec.QueryParameters) {
+		value, ok := spec.QueryParameters[name].(string)
+		if !ok {
+			warn(specPath.Child("query_parameters").Key(name), "only query parameter conditions on a value can be converted, the Mapping isn't converted")
+			return nil, 0, warnings, nil
+		}
+		match.QueryParams = append(match.QueryParams, gatewayv1beta1.HTTPQueryParamMatch{Type: &queryParamMatchExact, Name: gatewayv1.HTTPHeaderName(name), Value: value})
+	}
+	for _, name := range sortedMappingKeys(spec.RegexQueryParameters) {
+		match.QueryParams = append(match.QueryParams, gatewayv1beta1.HTTPQueryParamMatch{Type: &queryParamMatchRegularExpression, Name: gatewayv1.HTTPHeaderName(name), Value: spec.RegexQueryParameters[name]})
+	}
+	rule := &gatewayv1beta1.HTTPRouteRule{Matches: []gatewayv1beta1.HTTPRouteMatch{match}}
+
+	backendRef, ok, message := toMappingBackendRef(spec.Service, mapping.Namespace)
+	if !ok {
+		warn(specPath.Child("service"), message+", the Mapping isn't converted")
+		return nil, 0, warnings, nil
+	}
+	if message != "" {
+		warn(specPath.Child("service"), message)
+	}
+	rule.BackendRefs = []gatewayv1beta1.HTTPBackendRef{{BackendRef: backendRef}}
+
+	rewrite := emissaryDefaultRewrite
+	if spec.Rewrite != nil {
+		rewrite = *spec.Rewrite
+	}
+	switch {
+	case rewrite == "" || (rewrite == spec.Prefix && !spec.PrefixRegex):
+	case spec.PrefixRegex:
+		if spec.Rewrite != nil {
+			warn(specPath.Child("rewrite"), "the rewrite of a prefix_regex can't be represented in Gateway API, the path isn't rewritten")
+		}
+	default:
+		modifier := &gatewayv1beta1.HTTPPathModifier{Type: gatewayv1.PrefixMatchHTTPPathModifier, ReplacePrefixMatch: pointer.String(rewrite)}
+		if spec.PrefixExact {
+			modifier = &gatewayv1beta1.HTTPPathModifier{Type: gatewayv1.FullPathHTTPPathModifier, ReplaceFullPath: pointer.String(rewrite)}
+		}
+		rule.Filters = append(rule.Filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:       gatewayv1.HTTPRouteFilterURLRewrite,
+			URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{Path: modifier},
+		})
+	}
+
+	if filter := toMappingHeaderFilter(spec.AddRequestHeaders, spec.RemoveRequestHeaders); filter != nil {
+		rule.Filters = append(rule.Filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: filter,
+		})
+	}
+	if filter := toMappingHeaderFilter(spec.AddResponseHeaders, spec.RemoveResponseHeaders); filter != nil {
+		rule.Filters = append(rule.Filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                   gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: filter,
+		})
+	}
+	return rule, spec.Weight, warnings, nil
+}
+
+// toMappingBackendRef converts the service of a Mapping, as
+// [scheme://]name[.namespace[.svc[.cluster.local]]][:port], into a
+// backendRef to the Service, on port 80, or 443 for https, by default.
+// Services of other namespaces than the Mapping's are referenced with their
+// namespace, which requires a ReferenceGrant. It returns whether the service
+// can be converted, along with a message explaining why not, or warning
+// about its scheme.
+func toMappingBackendRef(service, mappingNamespace string) (gatewayv1beta1.BackendRef, bool, string) {
+	var message string
+	port := int64(80)
+	scheme, address, ok := strings.Cut(service, "://")
+	if !ok {
+		address, scheme = service, "http"
+	}
+	switch scheme {
+	case "http":
+	case "https":
+		port = 443
+		message = "the TLS origination to the service must be configured on the Gateway implementation"
+	default:
+		return gatewayv1beta1.BackendRef{}, false, fmt.Sprintf("the %s scheme of service %q isn't supported", scheme, service)
+	}
+	if host, portValue, ok := strings.Cut(address, ":"); ok {
+		var err error
+		port, err = strconv.ParseInt(portValue, 10, 32)
+		if err != nil || port < 1 || port > 65535 {
+			return gatewayv1beta1.BackendRef{}, false, fmt.Sprintf("the port of service %q isn't a valid port number", service)
+		}
+		address = host
+	}
+	parts := strings.Split(address, ".")
+	name, namespace := parts[0], mappingNamespace
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 2, parts[2] == "svc" && (len(parts) == 3 || strings.Join(parts[3:], ".") == "cluster.local"):
+		namespace = parts[1]
+	default:
+		return gatewayv1beta1.BackendRef{}, false, fmt.Sprintf("service %q isn't a Kubernetes Service, external services must be routed to through the Gateway implementation", service)
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return gatewayv1beta1.BackendRef{}, false, fmt.Sprintf("service %q isn't a Kubernetes Service: %s", service, strings.Join(errs, ", "))
+	}
+	backendRef := gatewayv1beta1.BackendRef{
+		BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+			Name: gatewayv1beta1.ObjectName(name),
+			Port: (*gatewayv1beta1.PortNumber)(pointer.Int32(int32(port))),
+		},
+	}
+	if namespace != mappingNamespace {
+		backendRef.Namespace = (*gatewayv1beta1.Namespace)(pointer.String(namespace))
+	}
+	return backendRef, true, message
+}
+
+// toMappingHeaderFilter converts the added and removed headers of a Mapping
+// into a header filter, nil when there are none. The headers are appended to
+// the existing ones unless their append is false, as with Emissary.
+func toMappingHeaderFilter(added map[string]interface{}, removed []string) *gatewayv1beta1.HTTPHeaderFilter {
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	filter := &gatewayv1beta1.HTTPHeaderFilter{Remove: removed}
+	for _, name := range sortedMappingKeys(added) {
+		header := gatewayv1beta1.HTTPHeader{Name: gatewayv1.HTTPHeaderName(name)}
+		appended := true
+		switch value := added[name].(type) {
+		case string:
+			header.Value = value
+		case map[string]interface{}:
+			header.Value = fmt.Sprint(value["value"])
+			if appendValue, ok := value["append"].(bool); ok {
+				appended = appendValue
+			}
+		default:
+			header.Value = fmt.Sprint(value)
+		}
+		if appended {
+			filter.Add = append(filter.Add, header)
+		} else {
+			filter.Set = append(filter.Set, header)
+		}
+	}
+	return filter
+}
+
+// setMappingWeights sets the weights of the backendRefs of a rule splitting
+// the traffic of several Mappings. As with Emissary, the weights are
+// percentages, and the services without weight share the rest of the
+// traffic.
+func setMappingWeights(r *emissaryRule) {
+	if len(r.rule.BackendRefs) < 2 {
+		return
+	}
+	var total int64
+	var unweighted int64
+	for _, weight := range r.weights {
+		total += weight
+		if weight == 0 {
+			unweighted++
+		}
+	}
+	var rest int64
+	if unweighted > 0 && total < 100 {
+		rest = (100 - total) / unweighted
+	}
+	for i, weight := range r.weights {
+		if weight == 0 {
+			weight = rest
+		}
+		r.rule.BackendRefs[i].Weight = pointer.Int32(int32(weight))
+	}
+}
+
+func sortedMappingKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_Mappings2GatewaysAndHTTPRoutes(t *testing.T) {
+	mappings, err := ConstructMappingsFromFile("testdata/mapping.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read Mappings: %v", err)
+	}
+	if len(mappings) != 6 {
+		t.Fatalf("Expected 6 Mappings, got %d", len(mappings))
+	}
+
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+	gRegex := gatewayv1.PathMatchRegularExpression
+	hmExact := gatewayv1.HeaderMatchExact
+	get := gatewayv1.HTTPMethodGet
+	backendRef := func(name string, port int, namespace string, weight *int32) gatewayv1beta1.HTTPBackendRef {
+		ref := gatewayv1beta1.HTTPBackendRef{
+			BackendRef: gatewayv1beta1.BackendRef{
+				BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+					Name: gatewayv1beta1.ObjectName(name),
+					Port: portNumberPtr(port),
+				},
+				Weight: weight,
+			},
+		}
+		if namespace != "" {
+			ref.Namespace = (*gatewayv1beta1.Namespace)(&namespace)
+		}
+		return ref
+	}
+	prefixRewrite := func(prefix string) gatewayv1beta1.HTTPRouteFilter {
+		return gatewayv1beta1.HTTPRouteFilter{
+			Type: gatewayv1.HTTPRouteFilterURLRewrite,
+			URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{
+				Path: &gatewayv1beta1.HTTPPathModifier{Type: gatewayv1.PrefixMatchHTTPPathModifier, ReplacePrefixMatch: pointer.String(prefix)},
+			},
+		}
+	}
+
+	expectedRules := map[string][]gatewayv1beta1.HTTPRouteRule{
+		"www-example-com": {{
+			Matches:     []gatewayv1beta1.HTTPRouteMatch{{Path: &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String("/")}}},
+			BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("web", 8080, "", nil)},
+		}, {
+			Matches: []gatewayv1beta1.HTTPRouteMatch{{Path: &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String("/api/")}}},
+			Filters: []gatewayv1beta1.HTTPRouteFilter{prefixRewrite("/v1/"), {
+				Type: gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+				RequestHeaderModifier: &gatewayv1beta1.HTTPHeaderFilter{
+					Add: []gatewayv1beta1.HTTPHeader{{Name: "x-team", Value: "web"}},
+					Set: []gatewayv1beta1.HTTPHeader{{Name: "x-version", Value: "1"}},
+				},
+			}, {
+				Type:                   gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+				ResponseHeaderModifier: &gatewayv1beta1.HTTPHeaderFilter{Remove: []string{"server"}},
+			}},
+			BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("api", 80, "backend", int32Ptr(90)), backendRef("api-canary", 80, "backend", int32Ptr(10))},
+		}, {
+			Matches: []gatewayv1beta1.HTTPRouteMatch{{
+				Path:   &gatewayv1beta1.HTTPPathMatch{Type: &gRegex, Value: pointer.String("/search/[a-z]+")},
+				Method: &get,
+			}},
+			BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("search", 80, "", nil)},
+		}},
+		"all-hosts": {{
+			Matches: []gatewayv1beta1.HTTPRouteMatch{{
+				Path:    &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String("/legacy/")},
+				Headers: []gatewayv1beta1.HTTPHeaderMatch{{Type: &hmExact, Name: "x-legacy", Value: "true"}},
+			}},
+			Filters:     []gatewayv1beta1.HTTPRouteFilter{prefixRewrite("/")},
+			BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("legacy", 443, "", nil)},
+		}},
+	}
+	expectedListeners := []gatewayv1beta1.Listener{{
+		Name:     "www-example-com-http",
+		Hostname: gatewayHostnamePtr("www.example.com"),
+		Port:     80,
+		Protocol: gatewayv1.HTTPProtocolType,
+	}, {
+		Name:     "http",
+		Port:     80,
+		Protocol: gatewayv1.HTTPProtocolType,
+	}}
+
+	httpRoutes, gateways, warnings, errs := Mappings2GatewaysAndHTTPRoutes(mappings)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	// The load balancers of the api Mappings, the external service and the
+	// TLS origination to the legacy service.
+	if len(warnings) != 4 {
+		t.Errorf("Expected 4 warnings, got %d: %+v", len(warnings), warnings)
+	}
+	rules := map[string][]gatewayv1beta1.HTTPRouteRule{}
+	for _, httpRoute := range httpRoutes {
+		rules[httpRoute.Name] = httpRoute.Spec.Rules
+		if diff := cmp.Diff([]gatewayv1beta1.ParentReference{{Name: "emissary"}}, httpRoute.Spec.ParentRefs); diff != "" {
+			t.Errorf("Unexpected parentRefs of HTTPRoute %s (-want +got):\n%s", httpRoute.Name, diff)
+		}
+	}
+	if diff := cmp.Diff(expectedRules, rules); diff != "" {
+		t.Errorf("Unexpected HTTPRoute rules (-want +got):\n%s", diff)
+	}
+	if len(gateways) != 1 {
+		t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+	}
+	if gateways[0].Namespace != "web" || gateways[0].Name != "emissary" || gateways[0].Spec.GatewayClassName != "emissary" {
+		t.Errorf("Expected Gateway web/emissary of the emissary class, got %s/%s of the %s class", gateways[0].Namespace, gateways[0].Name, gateways[0].Spec.GatewayClassName)
+	}
+	if diff := cmp.Diff(expectedListeners, gateways[0].Spec.Listeners); diff != "" {
+		t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+	}
+}
+
+func Test_MappingErrorsAndWarnings(t *testing.T) {
+	newMapping := func(spec EmissaryMappingSpec) EmissaryMapping {
+		return EmissaryMapping{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"}, Spec: spec}
+	}
+
+	testCases := []struct {
+		name              string
+		mapping           EmissaryMapping
+		expectNumRoutes   int
+		expectNumWarnings int
+		expectNumErrors   int
+	}{{
+		name:            "missing prefix",
+		mapping:         newMapping(EmissaryMappingSpec{Service: "web"}),
+		expectNumErrors: 1,
+	}, {
+		name:            "missing service",
+		mapping:         newMapping(EmissaryMappingSpec{Prefix: "/"}),
+		expectNumErrors: 1,
+	}, {
+		name:              "host regex",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "web", Host: ".*\\.example\\.com", HostRegex: true}),
+		expectNumWarnings: 1,
+	}, {
+		name:              "invalid hostname",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "web", Hostname: "www.example.com:8080"}),
+		expectNumWarnings: 1,
+	}, {
+		name:              "method regex",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "web", Method: "GET|POST", MethodRegex: true}),
+		expectNumWarnings: 1,
+	}, {
+		name:              "presence header",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "web", Headers: map[string]interface{}{"x-debug": true}}),
+		expectNumWarnings: 1,
+	}, {
+		name:              "invalid port",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "web:http"}),
+		expectNumWarnings: 1,
+	}, {
+		name:              "host redirect",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "www.example.com", HostRedirect: true}),
+		expectNumWarnings: 1,
+	}, {
+		name:              "precedence and timeout",
+		mapping:           newMapping(EmissaryMappingSpec{Prefix: "/", Service: "web", Precedence: pointer.Int64(10), TimeoutMs: pointer.Int64(3000)}),
+		expectNumRoutes:   1,
+		expectNumWarnings: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, warnings, errs := Mappings2GatewaysAndHTTPRoutes([]EmissaryMapping{tc.mapping})
+			if len(errs) != tc.expectNumErrors {
+				t.Errorf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if len(httpRoutes) != tc.expectNumRoutes {
+				t.Errorf("Expected %d HTTPRoutes, got %d: %+v", tc.expectNumRoutes, len(httpRoutes), httpRoutes)
+			}
+		})
+	}
+}
+
+func Test_toMappingBackendRef(t *testing.T) {
+	testCases := []struct {
+		service           string
+		expectedName      string
+		expectedNamespace string
+		expectedPort      int32
+		expectConverted   bool
+	}{
+		{service: "web", expectedName: "web", expectedPort: 80, expectConverted: true},
+		{service: "web:8080", expectedName: "web", expectedPort: 8080, expectConverted: true},
+		{service: "https://web", expectedName: "web", expectedPort: 443, expectConverted: true},
+		{service: "web.test", expectedName: "web", expectedPort: 80, expectConverted: true},
+		{service: "web.other:8080", expectedName: "web", expectedNamespace: "other", expectedPort: 8080, expectConverted: true},
+		{service: "web.other.svc.cluster.local", expectedName: "web", expectedNamespace: "other", expectedPort: 80, expectConverted: true},
+		{service: "www.example.com"},
+		{service: "10.0.0.1:8080"},
+		{service: "grpc://web"},
+		{service: "web:70000"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.service, func(t *testing.T) {
+			backendRef, converted, _ := toMappingBackendRef(tc.service, "test")
+			if converted != tc.expectConverted {
+				t.Fatalf("toMappingBackendRef(%q) converted = %t, expected %t", tc.service, converted, tc.expectConverted)
+			}
+			if !converted {
+				return
+			}
+			namespace := ""
+			if backendRef.Namespace != nil {
+				namespace = string(*backendRef.Namespace)
+			}
+			if string(backendRef.Name) != tc.expectedName || namespace != tc.expectedNamespace || int32(*backendRef.Port) != tc.expectedPort {
+				t.Errorf("toMappingBackendRef(%q) = %s/%s:%d, expected %s/%s:%d", tc.service, namespace, backendRef.Name, *backendRef.Port, tc.expectedNamespace, tc.expectedName, tc.expectedPort)
+			}
+		})
+	}
+}
+
+func Test_ConvertEmissary(t *testing.T) {
+	mappings, err := ConstructMappingsFromFile("testdata/mapping.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read Mappings: %v", err)
+	}
+	result, err := ConvertEmissary(mappings, Options{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+		t.Errorf("Expected valid resources, got %v", err)
+	}
+}
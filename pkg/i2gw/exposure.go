@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// anyHost is the host reported for Ingress rules and Gateway listeners
+// matching all hosts.
+const anyHost = "*"
+
+// PortExposure is a port, along with its protocol, a host is exposed on.
+type PortExposure struct {
+	Port     int32
+	Protocol gatewayv1beta1.ProtocolType
+}
+
+func (pe PortExposure) String() string {
+	return fmt.Sprintf("%s/%d", pe.Protocol, pe.Port)
+}
+
+// HostExposure compares the ports a host is exposed on by the original
+// Ingresses and by the generated Gateway listeners.
+type HostExposure struct {
+	Host      string
+	Original  []PortExposure
+	Generated []PortExposure
+	// Added holds the ports exposed by the generated listeners only.
+	Added []PortExposure
+	// Removed holds the ports exposed by the original Ingresses only.
+	Removed []PortExposure
+}
+
+// Changed returns whether the host exposure differs after the conversion.
+func (he HostExposure) Changed() bool {
+	return len(he.Added) > 0 || len(he.Removed) > 0
+}
+
+// ExposureReport compares, per host, the HTTP and HTTPS ports exposed by the
+// Ingresses with the ones exposed by the listeners of the Gateways. Following
+// ingress-nginx, HTTP requests to hosts with TLS are considered redirected to
+// HTTPS unless the nginx.ingress.kubernetes.io/ssl-redirect annotation is
+// false, so those hosts aren't considered exposed over HTTP. Hosts are
+// sorted by name.
+func ExposureReport(ingresses []networkingv1.Ingress, gateways []gatewayv1beta1.Gateway) []HostExposure {
+	original := map[string]map[PortExposure]bool{}
+	expose := func(exposures map[string]map[PortExposure]bool, host string, pe PortExposure) {
+		if exposures[host] == nil {
+			exposures[host] = map[PortExposure]bool{}
+		}
+		exposures[host][pe] = true
+	}
+
+	httpExposure := PortExposure{Port: 80, Protocol: gatewayv1.HTTPProtocolType}
+	httpsExposure := PortExposure{Port: 443, Protocol: gatewayv1.HTTPSProtocolType}
+	for _, ingress := range ingresses {
+		sslRedirect := ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] != "false"
+		tlsHosts := map[string]bool{}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+				expose(original, host, httpsExposure)
+			}
+		}
+		for _, rule := range ingress.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = anyHost
+			}
+			if !tlsHosts[rule.Host] || !sslRedirect {
+				expose(original, host, httpExposure)
+			}
+		}
+		if ingress.Spec.DefaultBackend != nil {
+			expose(original, anyHost, httpExposure)
+		}
+	}
+
+	generated := map[string]map[PortExposure]bool{}
+	for _, gw := range gateways {
+		for _, l := range gw.Spec.Listeners {
+			if l.Protocol != gatewayv1.HTTPProtocolType && l.Protocol != gatewayv1.HTTPSProtocolType {
+				continue
+			}
+			host := anyHost
+			if l.Hostname != nil && *l.Hostname != "" {
+				host = string(*l.Hostname)
+			}
+			expose(generated, host, PortExposure{Port: int32(l.Port), Protocol: l.Protocol})
+		}
+	}
+
+	hosts := map[string]bool{}
+	for host := range original {
+		hosts[host] = true
+	}
+	for host := range generated {
+		hosts[host] = true
+	}
+	var report []HostExposure
+	for host := range hosts {
+		report = append(report, HostExposure{
+			Host:      host,
+			Original:  sortedExposures(original[host], nil),
+			Generated: sortedExposures(generated[host], nil),
+			Added:     sortedExposures(generated[host], original[host]),
+			Removed:   sortedExposures(original[host], generated[host]),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Host < report[j].Host
+	})
+	return report
+}
+
+// sortedExposures returns the exposures missing from excluded, sorted by
+// port.
+func sortedExposures(exposures, excluded map[PortExposure]bool) []PortExposure {
+	var sorted []PortExposure
+	for pe := range exposures {
+		if !excluded[pe] {
+			sorted = append(sorted, pe)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Port != sorted[j].Port {
+			return sorted[i].Port < sorted[j].Port
+		}
+		return sorted[i].Protocol < sorted[j].Protocol
+	})
+	return sorted
+}
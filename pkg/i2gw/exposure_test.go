@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_ExposureReport(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	http := PortExposure{Port: 80, Protocol: gatewayv1.HTTPProtocolType}
+	https := PortExposure{Port: 443, Protocol: gatewayv1.HTTPSProtocolType}
+
+	newIngress := func(host string, tls bool, annotations map[string]string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "example",
+										Port: networkingv1.ServiceBackendPort{Number: 8080},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tls {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: "example-cert"}}
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name           string
+		ingress        networkingv1.Ingress
+		expectedReport []HostExposure
+	}{{
+		name:    "http only",
+		ingress: newIngress("example.com", false, nil),
+		expectedReport: []HostExposure{{
+			Host:      "example.com",
+			Original:  []PortExposure{http},
+			Generated: []PortExposure{http},
+		}},
+	}, {
+		name:    "extra http listener for tls host",
+		ingress: newIngress("example.com", true, nil),
+		expectedReport: []HostExposure{{
+			Host:      "example.com",
+			Original:  []PortExposure{https},
+			Generated: []PortExposure{http, https},
+			Added:     []PortExposure{http},
+		}},
+	}, {
+		name:    "tls host without ssl redirect",
+		ingress: newIngress("example.com", true, map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "false"}),
+		expectedReport: []HostExposure{{
+			Host:      "example.com",
+			Original:  []PortExposure{http, https},
+			Generated: []PortExposure{http, https},
+		}},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingresses := []networkingv1.Ingress{tc.ingress}
+			_, _, _, gateways, _, errs := Ingresses2GatewaysAndHTTPRoutes(ingresses, nil, nil, nil, false)
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+
+			report := ExposureReport(ingresses, gateways)
+			if diff := cmp.Diff(tc.expectedReport, report); diff != "" {
+				t.Errorf("ExposureReport() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// GatewayClassResult holds the resources of a Result belonging to the
+// Gateways of a GatewayClass.
+type GatewayClassResult struct {
+	// GatewayClassName is the GatewayClass of the Gateways, empty for the
+	// resources that belong to no generated Gateway.
+	GatewayClassName string
+	Result           Result
+}
+
+// GroupByGatewayClass groups the resources of the Result by the
+// gatewayClassName of their Gateway, sorted by class, followed by the
+// resources that belong to no generated Gateway. Routes belong to the Gateway
+// of their first parentRef that was generated, BackendTLSPolicies to the
+// routes of their Services, ReferenceGrants to the resources of the first
+// namespace they allow, and policies to the resources they target. The
+// warnings bound to an HTTPRoute follow the route, the other warnings are
+// all held by the first group. The IngressErrors aren't grouped.
+func GroupByGatewayClass(result Result) []GatewayClassResult {
+	gatewayClasses := map[types.NamespacedName]string{}
+	for _, gateway := range result.Gateways {
+		gatewayClasses[types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}] = string(gateway.Spec.GatewayClassName)
+	}
+	// The class of the first generated Gateway of the parentRefs.
+	parentClass := func(routeNamespace string, parentRefs []gatewayv1.ParentReference) string {
+		for _, ref := range parentRefs {
+			if (ref.Group != nil && *ref.Group != gatewayv1.GroupName) || (ref.Kind != nil && *ref.Kind != "Gateway") {
+				continue
+			}
+			key := types.NamespacedName{Namespace: routeNamespace, Name: string(ref.Name)}
+			if ref.Namespace != nil {
+				key.Namespace = string(*ref.Namespace)
+			}
+			if class, ok := gatewayClasses[key]; ok {
+				return class
+			}
+		}
+		return ""
+	}
+
+	// The classes of the resources, by "<kind>/<namespace>/<name>", and of
+	// the first resource of every kind and namespace, by "<kind>/<namespace>".
+	classes := map[string]string{}
+	setClass := func(kind, namespace, name, class string) {
+		classes[fmt.Sprintf("%s/%s/%s", kind, namespace, name)] = class
+		if _, ok := classes[kind+"/"+namespace]; !ok {
+			classes[kind+"/"+namespace] = class
+		}
+	}
+	// The class of the first route of every backend Service.
+	serviceClasses := map[types.NamespacedName]string{}
+	addBackends := func(routeNamespace, class string, refs []gatewayv1.BackendObjectReference) {
+		for _, ref := range refs {
+			if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Service") {
+				continue
+			}
+			key := types.NamespacedName{Namespace: routeNamespace, Name: string(ref.Name)}
+			if ref.Namespace != nil {
+				key.Namespace = string(*ref.Namespace)
+			}
+			if _, ok := serviceClasses[key]; !ok {
+				serviceClasses[key] = class
+			}
+		}
+	}
+
+	for _, gateway := range result.Gateways {
+		setClass("Gateway", gateway.Namespace, gateway.Name, string(gateway.Spec.GatewayClassName))
+	}
+	for _, route := range result.HTTPRoutes {
+		class := parentClass(route.Namespace, route.Spec.ParentRefs)
+		setClass("HTTPRoute", route.Namespace, route.Name, class)
+		for _, rule := range route.Spec.Rules {
+			for _, ref := range rule.BackendRefs {
+				addBackends(route.Namespace, class, []gatewayv1.BackendObjectReference{ref.BackendObjectReference})
+			}
+		}
+	}
+	for _, route := range result.GRPCRoutes {
+		class := parentClass(route.Namespace, route.Spec.ParentRefs)
+		setClass("GRPCRoute", route.Namespace, route.Name, class)
+		for _, rule := range route.Spec.Rules {
+			for _, ref := range rule.BackendRefs {
+				addBackends(route.Namespace, class, []gatewayv1.BackendObjectReference{ref.BackendObjectReference})
+			}
+		}
+	}
+	for _, route := range result.TCPRoutes {
+		class := parentClass(route.Namespace, route.Spec.ParentRefs)
+		setClass("TCPRoute", route.Namespace, route.Name, class)
+		for _, rule := range route.Spec.Rules {
+			for _, ref := range rule.BackendRefs {
+				addBackends(route.Namespace, class, []gatewayv1.BackendObjectReference{ref.BackendObjectReference})
+			}
+		}
+	}
+	for _, route := range result.TLSRoutes {
+		class := parentClass(route.Namespace, route.Spec.ParentRefs)
+		setClass("TLSRoute", route.Namespace, route.Name, class)
+		for _, rule := range route.Spec.Rules {
+			for _, ref := range rule.BackendRefs {
+				addBackends(route.Namespace, class, []gatewayv1.BackendObjectReference{ref.BackendObjectReference})
+			}
+		}
+	}
+
+	groups := map[string]*Result{}
+	group := func(class string) *Result {
+		if groups[class] == nil {
+			groups[class] = &Result{}
+		}
+		return groups[class]
+	}
+	for _, gateway := range result.Gateways {
+		g := group(string(gateway.Spec.GatewayClassName))
+		g.Gateways = append(g.Gateways, gateway)
+	}
+	for _, route := range result.HTTPRoutes {
+		g := group(classes[fmt.Sprintf("HTTPRoute/%s/%s", route.Namespace, route.Name)])
+		g.HTTPRoutes = append(g.HTTPRoutes, route)
+	}
+	for _, route := range result.GRPCRoutes {
+		g := group(classes[fmt.Sprintf("GRPCRoute/%s/%s", route.Namespace, route.Name)])
+		g.GRPCRoutes = append(g.GRPCRoutes, route)
+	}
+	for _, route := range result.TCPRoutes {
+		g := group(classes[fmt.Sprintf("TCPRoute/%s/%s", route.Namespace, route.Name)])
+		g.TCPRoutes = append(g.TCPRoutes, route)
+	}
+	for _, route := range result.TLSRoutes {
+		g := group(classes[fmt.Sprintf("TLSRoute/%s/%s", route.Namespace, route.Name)])
+		g.TLSRoutes = append(g.TLSRoutes, route)
+	}
+	for _, policy := range result.BackendTLSPolicies {
+		var class string
+		for _, ref := range policy.Spec.TargetRefs {
+			if ref.Group == "" && ref.Kind == "Service" {
+				if c, ok := serviceClasses[types.NamespacedName{Namespace: policy.Namespace, Name: string(ref.Name)}]; ok {
+					class = c
+					break
+				}
+			}
+		}
+		g := group(class)
+		g.BackendTLSPolicies = append(g.BackendTLSPolicies, policy)
+	}
+	for _, grant := range result.ReferenceGrants {
+		var class string
+		for _, from := range grant.Spec.From {
+			if c, ok := classes[fmt.Sprintf("%s/%s", from.Kind, from.Namespace)]; ok {
+				class = c
+				break
+			}
+		}
+		g := group(class)
+		g.ReferenceGrants = append(g.ReferenceGrants, grant)
+	}
+	for _, policy := range result.Policies {
+		g := group(policyClass(policy, classes))
+		g.Policies = append(g.Policies, policy)
+	}
+
+	routeClasses := map[types.NamespacedName]string{}
+	for _, route := range result.HTTPRoutes {
+		routeClasses[types.NamespacedName{Namespace: route.Namespace, Name: route.Name}] = classes[fmt.Sprintf("HTTPRoute/%s/%s", route.Namespace, route.Name)]
+	}
+	var unbound []Warning
+	for _, warning := range result.Warnings {
+		if class, ok := routeClasses[warning.HTTPRoute]; ok && warning.HTTPRoute.Name != "" {
+			g := group(class)
+			g.Warnings = append(g.Warnings, warning)
+			continue
+		}
+		unbound = append(unbound, warning)
+	}
+	if len(unbound) > 0 && len(groups) == 0 {
+		group("")
+	}
+
+	names := make([]string, 0, len(groups))
+	for class := range groups {
+		names = append(names, class)
+	}
+	// The resources without class come last.
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "") != (names[j] == "") {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
+	classResults := make([]GatewayClassResult, 0, len(names))
+	for _, class := range names {
+		classResults = append(classResults, GatewayClassResult{GatewayClassName: class, Result: *groups[class]})
+	}
+	if len(unbound) > 0 {
+		classResults[0].Result.Warnings = append(unbound, classResults[0].Result.Warnings...)
+	}
+	return classResults
+}
+
+// policyClass returns the class of the first resource a policy targets with
+// its targetRef or targetRefs, by "<kind>/<namespace>/<name>".
+func policyClass(policy unstructured.Unstructured, classes map[string]string) string {
+	spec, _ := policy.Object["spec"].(map[string]interface{})
+	refs, _ := spec["targetRefs"].([]interface{})
+	if ref, ok := spec["targetRef"]; ok {
+		refs = append([]interface{}{ref}, refs...)
+	}
+	for _, ref := range refs {
+		targetRef, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _ := targetRef["kind"].(string)
+		name, _ := targetRef["name"].(string)
+		if class, ok := classes[fmt.Sprintf("%s/%s/%s", kind, policy.GetNamespace(), name)]; ok {
+			return class
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_GroupByGatewayClass(t *testing.T) {
+	newGateway := func(name string) gatewayv1beta1.Gateway {
+		return gatewayv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: gatewayv1beta1.ObjectName(name)},
+		}
+	}
+	newRoute := func(name, gateway, service string) gatewayv1beta1.HTTPRoute {
+		return gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{Name: gatewayv1.ObjectName(gateway)}}},
+				Rules: []gatewayv1beta1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(service)}}}},
+				}},
+			},
+		}
+	}
+	policy := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "SecurityPolicy",
+		"metadata": map[string]interface{}{"name": "admin-source-ranges", "namespace": "test"},
+		"spec":     map[string]interface{}{"targetRefs": []interface{}{policyTargetRef("HTTPRoute", "admin")}},
+	}}
+	backendTLSPolicy := gatewayv1alpha3.BackendTLSPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+		Spec: gatewayv1alpha3.BackendTLSPolicySpec{
+			TargetRefs: []gatewayv1alpha2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gatewayv1alpha2.LocalPolicyTargetReference{Kind: "Service", Name: "web"},
+			}},
+		},
+	}
+	routeWarning := Warning{HTTPRoute: types.NamespacedName{Namespace: "test", Name: "admin"}, Message: "admin"}
+	unboundWarning := Warning{Ingress: types.NamespacedName{Namespace: "test", Name: "web"}, Message: "unbound"}
+
+	result := Result{
+		Gateways:           []gatewayv1beta1.Gateway{newGateway("internal"), newGateway("external")},
+		HTTPRoutes:         []gatewayv1beta1.HTTPRoute{newRoute("web", "external", "web"), newRoute("admin", "internal", "admin"), newRoute("orphan", "other", "orphan")},
+		BackendTLSPolicies: []gatewayv1alpha3.BackendTLSPolicy{backendTLSPolicy},
+		Policies:           []unstructured.Unstructured{policy},
+		Warnings:           []Warning{unboundWarning, routeWarning},
+	}
+	expected := []GatewayClassResult{{
+		GatewayClassName: "external",
+		Result: Result{
+			Gateways:           []gatewayv1beta1.Gateway{newGateway("external")},
+			HTTPRoutes:         []gatewayv1beta1.HTTPRoute{newRoute("web", "external", "web")},
+			BackendTLSPolicies: []gatewayv1alpha3.BackendTLSPolicy{backendTLSPolicy},
+			Warnings:           []Warning{unboundWarning},
+		},
+	}, {
+		GatewayClassName: "internal",
+		Result: Result{
+			Gateways:   []gatewayv1beta1.Gateway{newGateway("internal")},
+			HTTPRoutes: []gatewayv1beta1.HTTPRoute{newRoute("admin", "internal", "admin")},
+			Policies:   []unstructured.Unstructured{policy},
+			Warnings:   []Warning{routeWarning},
+		},
+	}, {
+		Result: Result{
+			HTTPRoutes: []gatewayv1beta1.HTTPRoute{newRoute("orphan", "other", "orphan")},
+		},
+	}}
+
+	if diff := cmp.Diff(expected, GroupByGatewayClass(result)); diff != "" {
+		t.Errorf("Unexpected groups (-want +got):\n%s", diff)
+	}
+}
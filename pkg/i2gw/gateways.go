@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// namespaceGatewayNames returns the name of the single Gateway of every
+// namespace, by namespace. The Gateway is named after the class of the
+// Ingresses of the namespace, which the Ingresses without class join, or
+// after the first Ingress when none of them has a class. A Gateway has a
+// single class, so Ingresses of different classes in the same namespace are
+// reported as errors.
+func namespaceGatewayNames(ingresses []networkingv1.Ingress) (map[string]string, field.ErrorList) {
+	var errs field.ErrorList
+	names := map[string]string{}
+	for _, ingress := range ingresses {
+		normalizeIngressClass(&ingress)
+		if ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName == "" {
+			continue
+		}
+		class := *ingress.Spec.IngressClassName
+		if name, ok := names[ingress.Namespace]; ok && name != class {
+			errs = append(errs, field.Invalid(field.NewPath(ingress.Name, "spec", "ingressClassName"), class,
+				fmt.Sprintf("the Ingresses of namespace %s are converted to a single Gateway, which can't have both classes %s and %s", ingress.Namespace, name, class)))
+			continue
+		}
+		names[ingress.Namespace] = class
+	}
+	for _, ingress := range ingresses {
+		if _, ok := names[ingress.Namespace]; !ok {
+			names[ingress.Namespace] = ingress.Name
+		}
+	}
+	return names, errs
+}
+
+// validateListeners returns an error for every listener of the Gateways that
+// can't be served along with a previous listener of its Gateway: listeners
+// with the same name, listeners of different protocols on the same port, and
+// TCP listeners sharing a port. HTTPS and TLS listeners can share a port when
+// their hostnames differ, as the SNI of the connections selects the listener.
+func validateListeners(gateways []gatewayv1beta1.Gateway) field.ErrorList {
+	var errs field.ErrorList
+	for _, gateway := range gateways {
+		listenersPath := field.NewPath("Gateway", gateway.Namespace+"/"+gateway.Name, "spec", "listeners")
+		names := map[gatewayv1beta1.SectionName]bool{}
+		listenersByPort := map[gatewayv1beta1.PortNumber][]gatewayv1beta1.Listener{}
+		for i, listener := range gateway.Spec.Listeners {
+			if names[listener.Name] {
+				errs = append(errs, field.Duplicate(listenersPath.Index(i).Child("name"), listener.Name))
+				continue
+			}
+			names[listener.Name] = true
+			for _, previous := range listenersByPort[listener.Port] {
+				if previous.Protocol == listener.Protocol {
+					if listener.Protocol == gatewayv1.TCPProtocolType {
+						errs = append(errs, field.Invalid(listenersPath.Index(i).Child("port"), listener.Port, "port is used by several TCP listeners"))
+						break
+					}
+					continue
+				}
+				if !sniListeners(previous, listener) {
+					errs = append(errs, field.Invalid(listenersPath.Index(i).Child("port"), listener.Port,
+						fmt.Sprintf("port is used by both %s and %s listeners", previous.Protocol, listener.Protocol)))
+					break
+				}
+			}
+			listenersByPort[listener.Port] = append(listenersByPort[listener.Port], listener)
+		}
+	}
+	return errs
+}
+
+// sniListeners returns whether the listeners are an HTTPS and a TLS listener
+// that can share their port: both have a hostname, and the hostnames differ.
+func sniListeners(a, b gatewayv1beta1.Listener) bool {
+	switch {
+	case a.Protocol == gatewayv1.HTTPSProtocolType && b.Protocol == gatewayv1.TLSProtocolType:
+	case a.Protocol == gatewayv1.TLSProtocolType && b.Protocol == gatewayv1.HTTPSProtocolType:
+	default:
+		return false
+	}
+	return a.Hostname != nil && b.Hostname != nil && *a.Hostname != *b.Hostname
+}
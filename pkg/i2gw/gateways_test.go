@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_namespaceGatewayNames(t *testing.T) {
+	newIngress := func(namespace, name, class string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		if class != "" {
+			ingress.Spec.IngressClassName = stringPtr(class)
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name            string
+		ingresses       []networkingv1.Ingress
+		expectedNames   map[string]string
+		expectNumErrors int
+	}{{
+		name: "class of every namespace",
+		ingresses: []networkingv1.Ingress{
+			newIngress("a", "web", "nginx"),
+			newIngress("a", "api", "nginx"),
+			newIngress("b", "web", "haproxy"),
+		},
+		expectedNames: map[string]string{"a": "nginx", "b": "haproxy"},
+	}, {
+		name: "Ingresses without class join the class of the namespace",
+		ingresses: []networkingv1.Ingress{
+			newIngress("a", "web", ""),
+			newIngress("a", "api", "nginx"),
+		},
+		expectedNames: map[string]string{"a": "nginx"},
+	}, {
+		name: "namespace without class",
+		ingresses: []networkingv1.Ingress{
+			newIngress("a", "web", ""),
+			newIngress("a", "api", ""),
+		},
+		expectedNames: map[string]string{"a": "web"},
+	}, {
+		name: "legacy class annotation",
+		ingresses: []networkingv1.Ingress{func() networkingv1.Ingress {
+			ingress := newIngress("a", "web", "")
+			ingress.Annotations = map[string]string{"kubernetes.io/ingress.class": "nginx"}
+			return ingress
+		}()},
+		expectedNames: map[string]string{"a": "nginx"},
+	}, {
+		name: "different classes in a namespace",
+		ingresses: []networkingv1.Ingress{
+			newIngress("a", "web", "nginx"),
+			newIngress("a", "api", "haproxy"),
+		},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, errs := namespaceGatewayNames(tc.ingresses)
+			if len(errs) != tc.expectNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+			if tc.expectNumErrors > 0 {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedNames, names); diff != "" {
+				t.Errorf("Unexpected Gateway names (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_validateListeners(t *testing.T) {
+	newGateway := func(listeners ...gatewayv1beta1.Listener) []gatewayv1beta1.Gateway {
+		return []gatewayv1beta1.Gateway{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "nginx"},
+			Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: "nginx", Listeners: listeners},
+		}}
+	}
+	listener := func(name string, port gatewayv1.PortNumber, protocol gatewayv1.ProtocolType) gatewayv1beta1.Listener {
+		return gatewayv1beta1.Listener{Name: gatewayv1.SectionName(name), Port: port, Protocol: protocol}
+	}
+	hostListener := func(name, hostname string, port gatewayv1.PortNumber, protocol gatewayv1.ProtocolType) gatewayv1beta1.Listener {
+		l := listener(name, port, protocol)
+		l.Hostname = gatewayHostnamePtr(hostname)
+		return l
+	}
+
+	testCases := []struct {
+		name            string
+		gateways        []gatewayv1beta1.Gateway
+		expectNumErrors int
+	}{{
+		name: "compatible listeners",
+		gateways: newGateway(
+			listener("a-http", 80, gatewayv1.HTTPProtocolType),
+			listener("b-http", 80, gatewayv1.HTTPProtocolType),
+			listener("a-https", 443, gatewayv1.HTTPSProtocolType),
+			listener("tcp-9000", 9000, gatewayv1.TCPProtocolType),
+		),
+	}, {
+		name: "duplicate listener names",
+		gateways: newGateway(
+			listener("a-http", 80, gatewayv1.HTTPProtocolType),
+			listener("a-http", 8080, gatewayv1.HTTPProtocolType),
+		),
+		expectNumErrors: 1,
+	}, {
+		name: "different protocols on the same port",
+		gateways: newGateway(
+			listener("a-http", 80, gatewayv1.HTTPProtocolType),
+			listener("tcp-80", 80, gatewayv1.TCPProtocolType),
+		),
+		expectNumErrors: 1,
+	}, {
+		name: "HTTPS and TLS listeners of different hosts on the same port",
+		gateways: newGateway(
+			hostListener("a-https", "a.example.com", 443, gatewayv1.HTTPSProtocolType),
+			hostListener("b-tls-passthrough", "b.example.com", 443, gatewayv1.TLSProtocolType),
+		),
+	}, {
+		name: "HTTPS and TLS listeners of the same host on the same port",
+		gateways: newGateway(
+			hostListener("a-https", "a.example.com", 443, gatewayv1.HTTPSProtocolType),
+			hostListener("a-tls-passthrough", "a.example.com", 443, gatewayv1.TLSProtocolType),
+		),
+		expectNumErrors: 1,
+	}, {
+		name: "HTTPS listener without hostname and TLS listener on the same port",
+		gateways: newGateway(
+			listener("https", 443, gatewayv1.HTTPSProtocolType),
+			hostListener("b-tls-passthrough", "b.example.com", 443, gatewayv1.TLSProtocolType),
+		),
+		expectNumErrors: 1,
+	}, {
+		name: "TCP listeners on the same port",
+		gateways: newGateway(
+			listener("tcp-9000", 9000, gatewayv1.TCPProtocolType),
+			listener("tcp-9000-b", 9000, gatewayv1.TCPProtocolType),
+		),
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateListeners(tc.gateways)
+			if len(errs) != tc.expectNumErrors {
+				t.Errorf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	gceStaticIPAnnotation            = "kubernetes.io/ingress.global-static-ip-name"
+	gceAllowHTTPAnnotation           = "kubernetes.io/ingress.allow-http"
+	gceManagedCertificatesAnnotation = "networking.gke.io/managed-certificates"
+	// gceManagedCertificateGroup and gceManagedCertificateKind are the group
+	// and kind of the ManagedCertificates of the GKE Ingress controller.
+	gceManagedCertificateGroup = "networking.gke.io"
+	gceManagedCertificateKind  = "ManagedCertificate"
+)
+
+// gceAnnotationPrefixes are the prefixes of the annotations of the GKE Ingress
+// controller, other than its annotation prefix.
+var gceAnnotationPrefixes = []string{"kubernetes.io/ingress.", "ingress.gcp.kubernetes.io/"}
+
+// gceIngressClasses are the classes of the Ingresses of the GKE Ingress
+// controller, external and internal.
+var gceIngressClasses = []string{"gce", "gce-internal"}
+
+// gceProvider converts the annotations of the GKE Ingress controller. As the
+// annotations are read from Ingresses, it is a provider selected with
+// Options.Providers, not an input kind of its own.
+type gceProvider struct{}
+
+func (gceProvider) annotationPrefix() string {
+	return "networking.gke.io/"
+}
+
+// readsAnnotation returns whether the annotation is an annotation of the GKE
+// Ingress controller without its annotation prefix, such as the
+// kubernetes.io/ingress annotations other than the class.
+func (gceProvider) readsAnnotation(key string) bool {
+	if key == networkingv1beta1.AnnotationIngressClass {
+		return false
+	}
+	for _, prefix := range gceAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p gceProvider) detect(ingress networkingv1.Ingress) bool {
+	for key := range ingress.Annotations {
+		if strings.HasPrefix(key, p.annotationPrefix()) || p.readsAnnotation(key) {
+			return true
+		}
+	}
+	class := ingress.Annotations[networkingv1beta1.AnnotationIngressClass]
+	if ingress.Spec.IngressClassName != nil {
+		class = *ingress.Spec.IngressClassName
+	}
+	for _, c := range gceIngressClasses {
+		if class == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (gceProvider) inputKinds() []string {
+	return []string{"Ingress"}
+}
+
+func (gceProvider) summary() string {
+	return "Static IPs, managed certificates and HTTP disabling of the GKE Ingress controller"
+}
+
+func (p gceProvider) addExtra(ingress networkingv1.Ingress, _ map[types.NamespacedName]corev1.ConfigMap, e *extra) field.ErrorList {
+	var errs field.ErrorList
+
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+	ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+
+	// The static IP of the load balancer of the Ingress is an address of its
+	// Gateway, recorded on the Gateway.
+	if name, ok := ingress.Annotations[gceStaticIPAnnotation]; ok && name != "" {
+		if e.gatewayAnnotations == nil {
+			e.gatewayAnnotations = map[string]string{}
+		}
+		e.gatewayAnnotations[gceStaticIPAnnotation] = name
+	}
+	if value, ok := ingress.Annotations[gceAllowHTTPAnnotation]; ok {
+		allowed, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, field.Invalid(fieldPath.Key(gceAllowHTTPAnnotation), value, "must be true or false"))
+		} else {
+			e.httpDisabled = !allowed
+		}
+	}
+	if value, ok := ingress.Annotations[gceManagedCertificatesAnnotation]; ok {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				e.managedCertificates = append(e.managedCertificates, name)
+			}
+		}
+		if len(e.managedCertificates) > 0 {
+			e.warnings = append(e.warnings, Warning{
+				Ingress: ingressName,
+				Field:   fieldPath.Key(gceManagedCertificatesAnnotation),
+				Message: fmt.Sprintf("the HTTPS listeners of all the hosts of the Ingress reference all the ManagedCertificates %s, whose domains may not cover every host; this requires the %s CRD and a Gateway implementation supporting them as certificateRefs", strings.Join(e.managedCertificates, ", "), gceManagedCertificateGroup),
+			})
+		}
+	}
+
+	var keys []string
+	for key := range ingress.Annotations {
+		switch key {
+		case gceStaticIPAnnotation, gceAllowHTTPAnnotation, gceManagedCertificatesAnnotation:
+			continue
+		}
+		if strings.HasPrefix(key, p.annotationPrefix()) || p.readsAnnotation(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		e.warnings = append(e.warnings, Warning{
+			Ingress: ingressName,
+			Field:   fieldPath.Key(key),
+			Message: "the annotation of the GKE Ingress controller is not converted; it must be configured on the Gateway implementation",
+		})
+	}
+	return errs
+}
+
+// managedCertificateRefs returns the ManagedCertificates of the Ingresses of
+// the rule group, without duplicates, in the order of the Ingresses.
+func (rg *ingressRuleGroup) managedCertificateRefs() []gatewayv1beta1.SecretObjectReference {
+	var refs []gatewayv1beta1.SecretObjectReference
+	seen := map[string]bool{}
+	group := gatewayv1beta1.Group(gceManagedCertificateGroup)
+	kind := gatewayv1beta1.Kind(gceManagedCertificateKind)
+	for _, ir := range rg.rules {
+		if ir.extra == nil {
+			continue
+		}
+		for _, name := range ir.extra.managedCertificates {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			refs = append(refs, gatewayv1beta1.SecretObjectReference{Group: &group, Kind: &kind, Name: gatewayv1beta1.ObjectName(name)})
+		}
+	}
+	return refs
+}
+
+// httpDisabled returns whether the host is only served over HTTPS, as all its
+// Ingresses disable HTTP, along with a warning for every Ingress disabling
+// HTTP when the host is still served over HTTP: the host has no TLS, or other
+// Ingresses of the host allow HTTP.
+func (rg *ingressRuleGroup) httpDisabled(hasTLS bool) (bool, []Warning) {
+	var disabling []types.NamespacedName
+	seen := map[types.NamespacedName]bool{}
+	allowed := false
+	for _, ir := range rg.rules {
+		if ir.extra == nil || !ir.extra.httpDisabled {
+			allowed = true
+			continue
+		}
+		if !seen[ir.ingress] {
+			seen[ir.ingress] = true
+			disabling = append(disabling, ir.ingress)
+		}
+	}
+	if len(disabling) == 0 {
+		return false, nil
+	}
+	if hasTLS && !allowed {
+		return true, nil
+	}
+
+	message := fmt.Sprintf("HTTP can't be disabled for host %q, as the host has no TLS; its paths are served over HTTP", rg.host)
+	if hasTLS {
+		message = fmt.Sprintf("the paths of host %q are served over HTTP, as other Ingresses of the host allow HTTP", rg.host)
+	}
+	var warnings []Warning
+	for _, ingress := range disabling {
+		warnings = append(warnings, Warning{
+			Ingress: ingress,
+			Field:   field.NewPath(ingress.Name, "metadata", "annotations").Key(gceAllowHTTPAnnotation),
+			Message: message,
+		})
+	}
+	return false, warnings
+}
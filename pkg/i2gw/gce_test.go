@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_gceProvider(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name string, tls bool, annotations map[string]string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("gce"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tls {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com"}}
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name                    string
+		ingresses               []networkingv1.Ingress
+		expectedListeners       []string
+		expectedSectionNames    []string
+		expectedAnnotations     map[string]string
+		expectedNumWarnings     int
+		expectedNumErrors       int
+		expectedCertificateRefs []string
+	}{{
+		name:                 "static IP",
+		ingresses:            []networkingv1.Ingress{newIngress("app", true, map[string]string{gceStaticIPAnnotation: "web-ip"})},
+		expectedListeners:    []string{"example-com-http", "example-com-https"},
+		expectedSectionNames: []string{"example-com-http", "example-com-https"},
+		expectedAnnotations:  map[string]string{gceStaticIPAnnotation: "web-ip"},
+		expectedCertificateRefs: []string{
+			"Secret/example-com",
+		},
+	}, {
+		name:                 "HTTP disabled",
+		ingresses:            []networkingv1.Ingress{newIngress("app", true, map[string]string{gceAllowHTTPAnnotation: "false"})},
+		expectedListeners:    []string{"example-com-https"},
+		expectedSectionNames: []string{"example-com-https"},
+		expectedCertificateRefs: []string{
+			"Secret/example-com",
+		},
+	}, {
+		name:                 "HTTP disabled without TLS",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{gceAllowHTTPAnnotation: "false"})},
+		expectedListeners:    []string{"example-com-http"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}, {
+		name: "HTTP allowed by another Ingress of the host",
+		ingresses: []networkingv1.Ingress{
+			newIngress("app", true, map[string]string{gceAllowHTTPAnnotation: "false"}),
+			newIngress("web", false, map[string]string{gceAllowHTTPAnnotation: "true"}),
+		},
+		expectedListeners:    []string{"example-com-http", "example-com-https"},
+		expectedSectionNames: []string{"example-com-http", "example-com-https"},
+		expectedNumWarnings:  1,
+		expectedCertificateRefs: []string{
+			"Secret/example-com",
+		},
+	}, {
+		name:                 "managed certificates",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{gceManagedCertificatesAnnotation: "example-com, www-example-com", gceAllowHTTPAnnotation: "false"})},
+		expectedListeners:    []string{"example-com-https"},
+		expectedSectionNames: []string{"example-com-https"},
+		expectedNumWarnings:  1,
+		expectedCertificateRefs: []string{
+			"ManagedCertificate/example-com",
+			"ManagedCertificate/www-example-com",
+		},
+	}, {
+		name:                 "managed certificates and TLS Secret",
+		ingresses:            []networkingv1.Ingress{newIngress("app", true, map[string]string{gceManagedCertificatesAnnotation: "example-com"})},
+		expectedListeners:    []string{"example-com-http", "example-com-https"},
+		expectedSectionNames: []string{"example-com-http", "example-com-https"},
+		expectedNumWarnings:  1,
+		expectedCertificateRefs: []string{
+			"Secret/example-com",
+			"ManagedCertificate/example-com",
+		},
+	}, {
+		name: "unmapped annotations",
+		ingresses: []networkingv1.Ingress{newIngress("app", false, map[string]string{
+			"ingress.gcp.kubernetes.io/pre-shared-cert": "legacy-cert",
+			"networking.gke.io/v1beta1.FrontendConfig":  "frontend",
+		})},
+		expectedListeners:    []string{"example-com-http"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  2,
+	}, {
+		name:              "invalid allow-http",
+		ingresses:         []networkingv1.Ingress{newIngress("app", true, map[string]string{gceAllowHTTPAnnotation: "no"})},
+		expectedNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, gateways, warnings, errs := ingresses2GatewaysAndHTTPRoutes(tc.ingresses, Options{})
+			if len(errs) != tc.expectedNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectedNumErrors, len(errs), errs)
+			}
+			if len(errs) > 0 {
+				return
+			}
+			if len(warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(warnings), warnings)
+			}
+			if len(gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d", len(gateways))
+			}
+			gateway := gateways[0]
+			var listeners, certificateRefs []string
+			for _, listener := range gateway.Spec.Listeners {
+				listeners = append(listeners, string(listener.Name))
+				if listener.TLS == nil {
+					continue
+				}
+				for _, ref := range listener.TLS.CertificateRefs {
+					kind := "Secret"
+					if ref.Kind != nil {
+						kind = string(*ref.Kind)
+					}
+					certificateRefs = append(certificateRefs, fmt.Sprintf("%s/%s", kind, ref.Name))
+				}
+			}
+			if diff := cmp.Diff(tc.expectedListeners, listeners); diff != "" {
+				t.Errorf("Unexpected listeners (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedCertificateRefs, certificateRefs); diff != "" {
+				t.Errorf("Unexpected certificateRefs (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedAnnotations, gateway.Annotations); diff != "" {
+				t.Errorf("Unexpected Gateway annotations (-want +got):\n%s", diff)
+			}
+			for _, route := range httpRoutes {
+				if _, ok := route.Annotations[gceStaticIPAnnotation]; ok {
+					t.Errorf("Expected the static IP annotation on the Gateway only, found on HTTPRoute %s", route.Name)
+				}
+			}
+			var sectionNames []string
+			for _, route := range httpRoutes {
+				for _, parentRef := range route.Spec.ParentRefs {
+					if parentRef.SectionName != nil {
+						sectionNames = append(sectionNames, string(*parentRef.SectionName))
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.expectedSectionNames, sectionNames); diff != "" {
+				t.Errorf("Unexpected sectionNames (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_gceProviderDetect(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		class       string
+		expected    bool
+	}{{
+		name:        "static IP annotation",
+		annotations: map[string]string{gceStaticIPAnnotation: "web-ip"},
+		expected:    true,
+	}, {
+		name:        "managed certificates annotation",
+		annotations: map[string]string{gceManagedCertificatesAnnotation: "example-com"},
+		expected:    true,
+	}, {
+		name:     "gce-internal class",
+		class:    "gce-internal",
+		expected: true,
+	}, {
+		name:        "class annotation of another controller",
+		annotations: map[string]string{"kubernetes.io/ingress.class": "nginx"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if tc.class != "" {
+				ingress.Spec.IngressClassName = stringPtr(tc.class)
+			}
+			if detected := (gceProvider{}).detect(ingress); detected != tc.expected {
+				t.Errorf("Expected detect() = %t, got %t", tc.expected, detected)
+			}
+			if tc.expected && len(tc.annotations) > 0 {
+				for key := range tc.annotations {
+					if name := annotationProvider(key); name != ProviderGCE {
+						t.Errorf("Expected annotation %s to be read by the %s provider, got %q", key, ProviderGCE, name)
+					}
+				}
+			}
+		})
+	}
+}
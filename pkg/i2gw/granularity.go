@@ -0,0 +1,220 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	// RouteGranularityHost generates an HTTPRoute per host, the default.
+	RouteGranularityHost = "host"
+	// RouteGranularityIngress generates an HTTPRoute per host and Ingress.
+	RouteGranularityIngress = "ingress"
+	// RouteGranularityPath generates an HTTPRoute per host and path.
+	RouteGranularityPath = "path"
+)
+
+// ValidateRouteGranularity returns an error when the granularity isn't a
+// supported granularity of the generated HTTPRoutes. An empty granularity is
+// RouteGranularityHost.
+func ValidateRouteGranularity(granularity string) error {
+	switch granularity {
+	case "", RouteGranularityHost, RouteGranularityIngress, RouteGranularityPath:
+		return nil
+	}
+	return fmt.Errorf("%s is not a supported route granularity, must be one of: %s, %s, %s", granularity, RouteGranularityHost, RouteGranularityIngress, RouteGranularityPath)
+}
+
+// routePart is the part of the rules of an HTTPRoute split into its own
+// HTTPRoute.
+type routePart struct {
+	// suffix is the suffix of the name of the HTTPRoute of the part.
+	suffix string
+	rules  []gatewayv1beta1.HTTPRouteRule
+}
+
+// splitHTTPRoutes splits the HTTPRoutes of the hosts of the rule groups into
+// an HTTPRoute per Ingress or per path, as given by the granularity. The
+// parts keep the hostnames and parentRefs of their HTTPRoute, so that the
+// routing is the same: the rules of a host are matched across all its
+// HTTPRoutes with the same precedence as within one. The parts are named
+// after the HTTPRoute, suffixed with their Ingress or path, and the policies
+// and warnings of the HTTPRoute follow. HTTPRoutes with a single part, such
+// as the routes of default backends and HTTPS redirects, are kept as is, and
+// GRPCRoutes are never split.
+func (a *ingressAggregator) splitHTTPRoutes(granularity string, result *Result) {
+	if granularity == "" || granularity == RouteGranularityHost {
+		return
+	}
+	ruleGroups := map[types.NamespacedName]*ingressRuleGroup{}
+	for _, rg := range a.ruleGroups {
+		if !rg.passthrough {
+			ruleGroups[types.NamespacedName{Namespace: rg.namespace, Name: rg.routeName()}] = rg
+		}
+	}
+	usedNames := map[types.NamespacedName]bool{}
+	for _, route := range result.HTTPRoutes {
+		usedNames[types.NamespacedName{Namespace: route.Namespace, Name: route.Name}] = true
+	}
+
+	// split holds the names of the parts of every split HTTPRoute.
+	split := map[types.NamespacedName][]string{}
+	var httpRoutes []gatewayv1beta1.HTTPRoute
+	for _, route := range result.HTTPRoutes {
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		rg, ok := ruleGroups[key]
+		if !ok {
+			httpRoutes = append(httpRoutes, route)
+			continue
+		}
+		parts := splitRules(route.Spec.Rules, granularity, rg.ruleIngresses)
+		if len(parts) < 2 {
+			httpRoutes = append(httpRoutes, route)
+			continue
+		}
+		for _, part := range parts {
+			partRoute := *route.DeepCopy()
+			partRoute.Name = uniqueRouteName(route.Namespace, route.Name+"-"+part.suffix, usedNames)
+			partRoute.Spec.Rules = part.rules
+			setSourceIngresses(&partRoute, partIngresses(part.rules, rg.ruleIngresses)...)
+			httpRoutes = append(httpRoutes, partRoute)
+			split[key] = append(split[key], partRoute.Name)
+		}
+	}
+	result.HTTPRoutes = httpRoutes
+	if len(split) == 0 {
+		return
+	}
+
+	result.Policies = splitPolicies(result.Policies, split)
+	// The warnings of a split HTTPRoute are bound to its first part.
+	for i := range result.Warnings {
+		if names, ok := split[result.Warnings[i].HTTPRoute]; ok {
+			result.Warnings[i].HTTPRoute.Name = names[0]
+		}
+	}
+}
+
+// splitRules splits the rules by Ingress, as given by the Ingresses of their
+// paths, or by path, in the order of their first rule. The rules of a path,
+// such as the rules of its header variants, are never split apart.
+func splitRules(rules []gatewayv1beta1.HTTPRouteRule, granularity string, ruleIngresses map[string]types.NamespacedName) []routePart {
+	var parts []routePart
+	partIndexes := map[string]int{}
+	for _, rule := range rules {
+		pathKey := rulePathKey(rule)
+		partKey := pathKey
+		suffix := pathSuffix(rule)
+		if granularity == RouteGranularityIngress {
+			partKey = ruleIngresses[pathKey].Name
+			suffix = partKey
+		}
+		i, ok := partIndexes[partKey]
+		if !ok {
+			i = len(parts)
+			partIndexes[partKey] = i
+			parts = append(parts, routePart{suffix: suffix})
+		}
+		parts[i].rules = append(parts[i].rules, rule)
+	}
+	return parts
+}
+
+// pathSuffix returns the suffix of the name of the HTTPRoute of the path of
+// the rule, "root" for the root path.
+func pathSuffix(rule gatewayv1beta1.HTTPRouteRule) string {
+	path := ""
+	if len(rule.Matches) > 0 && rule.Matches[0].Path != nil && rule.Matches[0].Path.Value != nil {
+		path = *rule.Matches[0].Path.Value
+	}
+	suffix := strings.Trim(invalidNameRegex.ReplaceAllString(strings.ToLower(path), "-"), "-")
+	if suffix == "" {
+		return "root"
+	}
+	return suffix
+}
+
+// uniqueRouteName returns the name made a DNS-1123 label, suffixed with an
+// index when it is already used by another HTTPRoute of the namespace, and
+// records it as used.
+func uniqueRouteName(namespace, name string, usedNames map[types.NamespacedName]bool) string {
+	label, _ := toDNS1123Label(name)
+	candidate := label
+	for i := 2; usedNames[types.NamespacedName{Namespace: namespace, Name: candidate}]; i++ {
+		candidate, _ = toDNS1123Label(fmt.Sprintf("%s-%d", label, i))
+	}
+	usedNames[types.NamespacedName{Namespace: namespace, Name: candidate}] = true
+	return candidate
+}
+
+// splitPolicies attaches the policies of the split HTTPRoutes to all their
+// parts: their targetRefs to a split HTTPRoute are replaced with targetRefs
+// to its parts, and policies with a single targetRef are copied for every
+// part, named after it.
+func splitPolicies(policies []unstructured.Unstructured, split map[types.NamespacedName][]string) []unstructured.Unstructured {
+	partsOf := func(policy unstructured.Unstructured, ref interface{}) ([]string, string, bool) {
+		targetRef, ok := ref.(map[string]interface{})
+		if !ok || targetRef["kind"] != "HTTPRoute" {
+			return nil, "", false
+		}
+		name, _ := targetRef["name"].(string)
+		names, ok := split[types.NamespacedName{Namespace: policy.GetNamespace(), Name: name}]
+		return names, name, ok
+	}
+
+	var splitPolicies []unstructured.Unstructured
+	for _, policy := range policies {
+		spec, _ := policy.Object["spec"].(map[string]interface{})
+		if ref, ok := spec["targetRef"]; ok {
+			names, routeName, ok := partsOf(policy, ref)
+			if !ok {
+				splitPolicies = append(splitPolicies, policy)
+				continue
+			}
+			for _, name := range names {
+				partPolicy := *policy.DeepCopy()
+				partPolicy.SetName(name + strings.TrimPrefix(policy.GetName(), routeName))
+				partSpec := partPolicy.Object["spec"].(map[string]interface{})
+				partSpec["targetRef"] = policyTargetRef("HTTPRoute", name)
+				splitPolicies = append(splitPolicies, partPolicy)
+			}
+			continue
+		}
+		if refs, ok := spec["targetRefs"].([]interface{}); ok {
+			var targetRefs []interface{}
+			for _, ref := range refs {
+				names, _, ok := partsOf(policy, ref)
+				if !ok {
+					targetRefs = append(targetRefs, ref)
+					continue
+				}
+				for _, name := range names {
+					targetRefs = append(targetRefs, policyTargetRef("HTTPRoute", name))
+				}
+			}
+			spec["targetRefs"] = targetRefs
+		}
+		splitPolicies = append(splitPolicies, policy)
+	}
+	return splitPolicies
+}
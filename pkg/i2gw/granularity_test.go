@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_ValidateRouteGranularity(t *testing.T) {
+	for _, granularity := range []string{"", RouteGranularityHost, RouteGranularityIngress, RouteGranularityPath} {
+		if err := ValidateRouteGranularity(granularity); err != nil {
+			t.Errorf("ValidateRouteGranularity(%q) = %v, expecting no error", granularity, err)
+		}
+	}
+	if err := ValidateRouteGranularity("rule"); err == nil {
+		t.Errorf("ValidateRouteGranularity(%q) expecting an error", "rule")
+	}
+}
+
+func Test_routeGranularity(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	ingress := func(name, host string, annotations map[string]string, paths ...string) networkingv1.Ingress {
+		var httpPaths []networkingv1.HTTPIngressPath
+		for _, path := range paths {
+			httpPaths = append(httpPaths, networkingv1.HTTPIngressPath{
+				Path:     path,
+				PathType: &pathType,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+				},
+			})
+		}
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("ingress-nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: httpPaths},
+					},
+				}},
+			},
+		}
+	}
+	ingresses := []networkingv1.Ingress{
+		ingress("web", "foo.example.com", nil, "/", "/api"),
+		ingress("admin", "foo.example.com", nil, "/admin"),
+		ingress("api-canary", "foo.example.com", map[string]string{
+			"nginx.ingress.kubernetes.io/canary":        "true",
+			"nginx.ingress.kubernetes.io/canary-weight": "20",
+		}, "/api"),
+		ingress("bar", "bar.example.com", nil, "/"),
+	}
+
+	testCases := []struct {
+		granularity string
+		// expectedRoutes are the names of the HTTPRoutes, along with their
+		// number of rules.
+		expectedRoutes map[string]int
+	}{{
+		granularity:    RouteGranularityHost,
+		expectedRoutes: map[string]int{"foo-example-com": 3, "bar-example-com": 1},
+	}, {
+		granularity:    RouteGranularityIngress,
+		expectedRoutes: map[string]int{"foo-example-com-web": 2, "foo-example-com-admin": 1, "bar-example-com": 1},
+	}, {
+		granularity:    RouteGranularityPath,
+		expectedRoutes: map[string]int{"foo-example-com-admin": 1, "foo-example-com-api": 1, "foo-example-com-root": 1, "bar-example-com": 1},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.granularity, func(t *testing.T) {
+			result, err := Convert(ingresses, Options{RouteGranularity: tc.granularity})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			routes := map[string]int{}
+			for _, route := range result.HTTPRoutes {
+				routes[route.Name] = len(route.Spec.Rules)
+				if diff := cmp.Diff(result.Gateways[0].Name, string(route.Spec.ParentRefs[0].Name)); diff != "" {
+					t.Errorf("Expected HTTPRoute %s to be attached to the Gateway (-want +got):\n%s", route.Name, diff)
+				}
+				if len(route.Spec.Hostnames) != 1 {
+					t.Errorf("Expected HTTPRoute %s to keep the hostname of its host, got %v", route.Name, route.Spec.Hostnames)
+				}
+				// The canary is kept with the path it splits the traffic of.
+				for _, rule := range route.Spec.Rules {
+					if *rule.Matches[0].Path.Value == "/api" && len(rule.BackendRefs) != 2 {
+						t.Errorf("Expected the /api rule of HTTPRoute %s to have the canary backend, got %+v", route.Name, rule.BackendRefs)
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.expectedRoutes, routes); diff != "" {
+				t.Errorf("Unexpected HTTPRoutes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_splitPolicies(t *testing.T) {
+	policies := []unstructured.Unstructured{{Object: map[string]interface{}{
+		"kind":     "SecurityPolicy",
+		"metadata": map[string]interface{}{"name": "foo", "namespace": "test"},
+		"spec": map[string]interface{}{
+			"targetRefs": []interface{}{policyTargetRef("HTTPRoute", "foo"), policyTargetRef("GRPCRoute", "foo")},
+		},
+	}}, {Object: map[string]interface{}{
+		"kind":     "ClientSettingsPolicy",
+		"metadata": map[string]interface{}{"name": "foo-client-settings", "namespace": "test"},
+		"spec": map[string]interface{}{
+			"targetRef": policyTargetRef("HTTPRoute", "foo"),
+		},
+	}}}
+	split := map[types.NamespacedName][]string{{Namespace: "test", Name: "foo"}: {"foo-web", "foo-admin"}}
+
+	got := splitPolicies(policies, split)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 policies, got %+v", got)
+	}
+	expectedTargetRefs := []interface{}{policyTargetRef("HTTPRoute", "foo-web"), policyTargetRef("HTTPRoute", "foo-admin"), policyTargetRef("GRPCRoute", "foo")}
+	if diff := cmp.Diff(expectedTargetRefs, got[0].Object["spec"].(map[string]interface{})["targetRefs"]); diff != "" {
+		t.Errorf("Unexpected targetRefs (-want +got):\n%s", diff)
+	}
+	for i, name := range []string{"foo-web", "foo-admin"} {
+		policy := got[i+1]
+		if policy.GetName() != name+"-client-settings" {
+			t.Errorf("Expected policy %s-client-settings, got %s", name, policy.GetName())
+		}
+		if diff := cmp.Diff(policyTargetRef("HTTPRoute", name), policy.Object["spec"].(map[string]interface{})["targetRef"]); diff != "" {
+			t.Errorf("Unexpected targetRef (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const backendProtocolAnnotation = "nginx.ingress.kubernetes.io/backend-protocol"
+
+var grpcRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1",
+	Kind:    "GRPCRoute",
+}
+
+// grpcPathRegex matches the paths of gRPC requests, /<service>/<method>, where
+// the method may be omitted to match all the methods of a service.
+var grpcPathRegex = regexp.MustCompile(`^/((?:[A-Za-z_][A-Za-z_0-9]*)(?:\.[A-Za-z_][A-Za-z_0-9]*)*)(?:/([A-Za-z_][A-Za-z_0-9]*)?)?$`)
+
+// grpcBackend returns whether the backends are gRPC services.
+func (e *extra) grpcBackend() bool {
+	return e != nil && (e.backendProtocol == "GRPC" || e.backendProtocol == "GRPCS")
+}
+
+// serviceAppProtocol returns the appProtocol of the Service port of the
+// backend, and false when the Service or its port can't be found.
+func serviceAppProtocol(backend networkingv1.IngressBackend, namespace string, services map[types.NamespacedName]corev1.Service) (string, bool) {
+	if backend.Service == nil {
+		return "", false
+	}
+	svc, ok := services[types.NamespacedName{Namespace: namespace, Name: backend.Service.Name}]
+	if !ok {
+		return "", false
+	}
+	for _, port := range svc.Spec.Ports {
+		if (backend.Service.Port.Name != "" && port.Name == backend.Service.Port.Name) ||
+			(backend.Service.Port.Name == "" && port.Port == backend.Service.Port.Number) {
+			if port.AppProtocol == nil || *port.AppProtocol == "" {
+				return "", false
+			}
+			return *port.AppProtocol, true
+		}
+	}
+	return "", false
+}
+
+// grpcPath returns whether the backend of the path is a gRPC service. The
+// appProtocol of the backend Service port, when set, takes precedence over the
+// backend-protocol annotation: grpc backends are gRPC services, and backends
+// with any other appProtocol are not.
+func (rg *ingressRuleGroup) grpcPath(ir ingressRule, path networkingv1.HTTPIngressPath) bool {
+	if appProtocol, ok := serviceAppProtocol(path.Backend, ir.ingress.Namespace, rg.services); ok {
+		return strings.EqualFold(appProtocol, "grpc")
+	}
+	return ir.extra.grpcBackend()
+}
+
+// appProtocolWarnings returns a warning for every path whose backend-protocol
+// annotation disagrees with the appProtocol of its backend Service port, as
+// the route type follows the appProtocol.
+func (rg *ingressRuleGroup) appProtocolWarnings() []Warning {
+	var warnings []Warning
+	for i, ir := range rg.rules {
+		if ir.extra == nil || ir.extra.backendProtocol == "" || ir.rule.HTTP == nil {
+			continue
+		}
+		for j, path := range ir.rule.HTTP.Paths {
+			appProtocol, ok := serviceAppProtocol(path.Backend, ir.ingress.Namespace, rg.services)
+			if !ok || rg.grpcPath(ir, path) == ir.extra.grpcBackend() {
+				continue
+			}
+			kind := "an HTTPRoute"
+			if rg.grpcPath(ir, path) {
+				kind = "a GRPCRoute"
+			}
+			warnings = append(warnings, Warning{
+				Ingress: ir.ingress,
+				Field:   field.NewPath(ir.ingress.Name, "spec", "rules").Index(i).Child("http", "paths").Index(j).Child("backend"),
+				Message: fmt.Sprintf("the %s backend protocol of the Ingress disagrees with appProtocol %q of the port of Service %s; the path is converted to %s following the appProtocol", ir.extra.backendProtocol, appProtocol, path.Backend.Service.Name, kind),
+			})
+		}
+	}
+	return warnings
+}
+
+// toGRPCRoute converts the paths of the rule group with gRPC backends to a
+// GRPCRoute. It returns false when the rule group has no gRPC backends. With
+// TLS, the GRPCRoute is attached to the HTTPS listener of the host, where
+// HTTP/2 is negotiated. Otherwise, it is attached to the HTTP listener, which
+// requires cleartext HTTP/2 support by the implementation.
+func (rg *ingressRuleGroup) toGRPCRoute(listenerName gatewayv1beta1.SectionName) (gatewayv1.GRPCRoute, []Warning, field.ErrorList, bool) {
+	pmKeys, pathsByMatchGroup := rg.groupPaths(true)
+	if len(pmKeys) == 0 {
+		return gatewayv1.GRPCRoute{}, nil, nil, false
+	}
+	warnings, errors := rg.removeConflicts(pmKeys, pathsByMatchGroup)
+
+	grpcRoute := gatewayv1.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rg.routeName(),
+			Namespace: rg.namespace,
+		},
+		Spec: gatewayv1.GRPCRouteSpec{},
+		Status: gatewayv1.GRPCRouteStatus{
+			RouteStatus: gatewayv1.RouteStatus{
+				Parents: []gatewayv1.RouteParentStatus{},
+			},
+		},
+	}
+	grpcRoute.SetGroupVersionKind(grpcRouteGVK)
+
+	if rg.ingressClass != "" {
+		parentRef := gatewayv1.ParentReference{Name: gatewayv1.ObjectName(rg.ingressClass)}
+		if listenerName != "" {
+			parentRef.SectionName = &listenerName
+		}
+		grpcRoute.Spec.ParentRefs = []gatewayv1.ParentReference{parentRef}
+	}
+	if rg.host != "" {
+		grpcRoute.Spec.Hostnames = []gatewayv1.Hostname{gatewayv1.Hostname(rg.host)}
+	}
+
+	seen := map[types.NamespacedName]bool{}
+	for _, pmKey := range pmKeys {
+		paths := pathsByMatchGroup[pmKey]
+		path := paths[0]
+		fieldPath := field.NewPath(path.ingress.Name, "spec", "rules").Index(path.ruleIdx).Child(path.ruleType).Child("paths").Index(path.pathIdx)
+
+		if !seen[path.ingress] {
+			seen[path.ingress] = true
+			annotationPath := field.NewPath(path.ingress.Name, "metadata", "annotations").Key(backendProtocolAnnotation)
+			// The route type is chosen by the appProtocol of the backend
+			// Service port when set, otherwise by the annotation.
+			grpcPath := annotationPath
+			if path.appProtocol != "" {
+				grpcPath = fieldPath.Child("backend", "service", "port")
+			}
+			if len(rg.certificateRefs()) == 0 {
+				warnings = append(warnings, Warning{
+					Ingress: path.ingress,
+					Field:   grpcPath,
+					Message: fmt.Sprintf("GRPCRoute %s/%s is attached to an HTTP listener, which requires cleartext HTTP/2 (h2c) support by the Gateway implementation", grpcRoute.Namespace, grpcRoute.Name),
+				})
+			}
+			if path.extra.backendProtocol == "GRPCS" {
+				warnings = append(warnings, Warning{
+					Ingress: path.ingress,
+					Field:   annotationPath,
+					Message: "TLS connections to the gRPC backends cannot be represented in GRPCRoute and must be configured on the Gateway implementation",
+				})
+			}
+			if path.extra.routeTimeouts() != nil {
+				warnings = append(warnings, Warning{
+					Ingress: path.ingress,
+					Field:   field.NewPath(path.ingress.Name, "metadata", "annotations").Key(proxyReadTimeoutAnnotation),
+					Message: "GRPCRoute rules have no timeouts, the proxy timeouts must be configured on the Gateway implementation",
+				})
+			}
+		}
+
+		match, err := toGRPCRouteMatch(path, fieldPath)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		grpcRule := gatewayv1.GRPCRouteRule{
+			Filters:            path.extra.grpcHeaderFilters(),
+			SessionPersistence: path.extra.sessionPersistence(),
+		}
+		if match != nil {
+			grpcRule.Matches = []gatewayv1.GRPCRouteMatch{*match}
+		}
+
+		backendRefs, warns, errs := rg.calculateBackendRefWeight(paths)
+		warnings = append(warnings, warns...)
+		errors = append(errors, errs...)
+		for _, backendRef := range backendRefs {
+			grpcRule.BackendRefs = append(grpcRule.BackendRefs, gatewayv1.GRPCBackendRef{BackendRef: backendRef.BackendRef})
+		}
+
+		grpcRoute.Spec.Rules = append(grpcRoute.Spec.Rules, grpcRule)
+	}
+
+	return grpcRoute, warnings, errors, true
+}
+
+// toGRPCRouteMatch converts the path of an Ingress to a gRPC service and
+// method match. The returned match is nil when the path matches all services.
+func toGRPCRouteMatch(ip ingressPath, path *field.Path) (*gatewayv1.GRPCRouteMatch, *field.Error) {
+	mmExact := gatewayv1.GRPCMethodMatchExact
+	hmExact := gatewayv1.HeaderMatchExact
+	hmRegex := gatewayv1.HeaderMatchRegularExpression
+
+	match := &gatewayv1.GRPCRouteMatch{}
+	isPrefix := ip.path.PathType != nil && *ip.path.PathType == networkingv1.PathTypePrefix
+	if !isPrefix || (ip.path.Path != "" && ip.path.Path != "/") {
+		m := grpcPathRegex.FindStringSubmatch(ip.path.Path)
+		// Without method, only prefix paths match requests.
+		if m == nil || (m[2] == "" && !isPrefix) {
+			return nil, field.Invalid(path.Child("path"), ip.path.Path, "path cannot be converted to a gRPC service and method match, must be /<service>/<method>, or /<service> with a Prefix path type")
+		}
+		match.Method = &gatewayv1.GRPCMethodMatch{Type: &mmExact, Service: pointer.String(m[1])}
+		if m[2] != "" {
+			match.Method.Method = pointer.String(m[2])
+		}
+	}
+
+	if c := ip.extra.canaryConfig(); c != nil && c.headerKey != "" {
+		headerMatch := gatewayv1.GRPCHeaderMatch{
+			Name:  gatewayv1.GRPCHeaderName(c.headerKey),
+			Value: c.headerValue,
+			Type:  &hmExact,
+		}
+		if c.headerRegexMatch {
+			headerMatch.Type = &hmRegex
+		}
+		match.Headers = []gatewayv1.GRPCHeaderMatch{headerMatch}
+	}
+
+	if match.Method == nil && len(match.Headers) == 0 {
+		return nil, nil
+	}
+	return match, nil
+}
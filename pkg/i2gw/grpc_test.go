@@ -0,0 +1,441 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_ingresses2GRPCRoutes(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+	mmExact := gatewayv1.GRPCMethodMatchExact
+
+	newIngress := func(tls bool) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "greeter",
+				Namespace:   "test",
+				Annotations: map[string]string{backendProtocolAnnotation: "GRPC"},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "grpc.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/helloworld.Greeter/SayHello",
+								PathType: &iExact,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "hello",
+										Port: networkingv1.ServiceBackendPort{Number: 50051},
+									},
+								},
+							}, {
+								Path:     "/helloworld.Greeter",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "greeter",
+										Port: networkingv1.ServiceBackendPort{Number: 50051},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tls {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{
+				Hosts:      []string{"grpc.example.com"},
+				SecretName: "grpc-cert",
+			}}
+		}
+		return ingress
+	}
+	newGRPCRoute := func(sectionName *gatewayv1.SectionName) gatewayv1.GRPCRoute {
+		return gatewayv1.GRPCRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "grpc-example-com", Namespace: "test"},
+			Spec: gatewayv1.GRPCRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{
+					ParentRefs: []gatewayv1.ParentReference{{Name: "nginx", SectionName: sectionName}},
+				},
+				Hostnames: []gatewayv1.Hostname{"grpc.example.com"},
+				Rules: []gatewayv1.GRPCRouteRule{{
+					Matches: []gatewayv1.GRPCRouteMatch{{
+						Method: &gatewayv1.GRPCMethodMatch{
+							Type:    &mmExact,
+							Service: pointer.String("helloworld.Greeter"),
+							Method:  pointer.String("SayHello"),
+						},
+					}},
+					BackendRefs: []gatewayv1.GRPCBackendRef{{
+						BackendRef: gatewayv1.BackendRef{
+							BackendObjectReference: gatewayv1.BackendObjectReference{
+								Name: "hello",
+								Port: portNumberPtr(50051),
+							},
+						},
+					}},
+				}, {
+					Matches: []gatewayv1.GRPCRouteMatch{{
+						Method: &gatewayv1.GRPCMethodMatch{
+							Type:    &mmExact,
+							Service: pointer.String("helloworld.Greeter"),
+						},
+					}},
+					BackendRefs: []gatewayv1.GRPCBackendRef{{
+						BackendRef: gatewayv1.BackendRef{
+							BackendObjectReference: gatewayv1.BackendObjectReference{
+								Name: "greeter",
+								Port: portNumberPtr(50051),
+							},
+						},
+					}},
+				}},
+			},
+			Status: gatewayv1.GRPCRouteStatus{
+				RouteStatus: gatewayv1.RouteStatus{
+					Parents: []gatewayv1.RouteParentStatus{},
+				},
+			},
+		}
+	}
+	httpsSectionName := gatewayv1.SectionName("grpc-example-com-https")
+
+	testCases := []struct {
+		name              string
+		ingress           networkingv1.Ingress
+		expectGRPCRoute   gatewayv1.GRPCRoute
+		expectNumWarnings int
+	}{{
+		name:            "with tls",
+		ingress:         newIngress(true),
+		expectGRPCRoute: newGRPCRoute(&httpsSectionName),
+	}, {
+		name:            "without tls",
+		ingress:         newIngress(false),
+		expectGRPCRoute: newGRPCRoute(nil),
+		// Cleartext HTTP/2.
+		expectNumWarnings: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, grpcRoutes, _, gateways, warnings, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{tc.ingress}, nil, nil, nil, false)
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if len(httpRoutes) != 0 {
+				t.Errorf("Expected no HTTPRoutes, got %d: %+v", len(httpRoutes), httpRoutes)
+			}
+			if len(gateways) != 1 {
+				t.Errorf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+			}
+			if len(grpcRoutes) != 1 {
+				t.Fatalf("Expected 1 GRPCRoute, got %d: %+v", len(grpcRoutes), grpcRoutes)
+			}
+			want := tc.expectGRPCRoute
+			want.SetGroupVersionKind(grpcRouteGVK)
+			if !apiequality.Semantic.DeepEqual(grpcRoutes[0], want) {
+				t.Errorf("Expected GRPCRoute to be %+v\n Got: %+v\n Diff: %s", want, grpcRoutes[0], cmp.Diff(want, grpcRoutes[0]))
+			}
+		})
+	}
+}
+
+func Test_toGRPCRouteMatch(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+	iImplementationSpecific := networkingv1.PathTypeImplementationSpecific
+	mmExact := gatewayv1.GRPCMethodMatchExact
+	hmExact := gatewayv1.HeaderMatchExact
+
+	testCases := []struct {
+		name          string
+		path          string
+		pathType      *networkingv1.PathType
+		extra         *extra
+		expectedMatch *gatewayv1.GRPCRouteMatch
+		expectError   bool
+	}{{
+		name:     "all services",
+		path:     "/",
+		pathType: &iPrefix,
+	}, {
+		name:     "service",
+		path:     "/helloworld.Greeter",
+		pathType: &iPrefix,
+		expectedMatch: &gatewayv1.GRPCRouteMatch{
+			Method: &gatewayv1.GRPCMethodMatch{Type: &mmExact, Service: pointer.String("helloworld.Greeter")},
+		},
+	}, {
+		name:     "service and method",
+		path:     "/helloworld.Greeter/SayHello",
+		pathType: &iExact,
+		expectedMatch: &gatewayv1.GRPCRouteMatch{
+			Method: &gatewayv1.GRPCMethodMatch{Type: &mmExact, Service: pointer.String("helloworld.Greeter"), Method: pointer.String("SayHello")},
+		},
+	}, {
+		name:     "canary header",
+		path:     "/",
+		pathType: &iPrefix,
+		extra:    &extra{canary: &canary{headerKey: "x-canary", headerValue: "always"}},
+		expectedMatch: &gatewayv1.GRPCRouteMatch{
+			Headers: []gatewayv1.GRPCHeaderMatch{{Type: &hmExact, Name: "x-canary", Value: "always"}},
+		},
+	}, {
+		name:        "exact service",
+		path:        "/helloworld.Greeter",
+		pathType:    &iExact,
+		expectError: true,
+	}, {
+		name:        "implementation specific path",
+		path:        "/",
+		pathType:    &iImplementationSpecific,
+		expectError: true,
+	}, {
+		name:        "nested path",
+		path:        "/helloworld.Greeter/SayHello/again",
+		pathType:    &iPrefix,
+		expectError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ip := ingressPath{
+				path:  networkingv1.HTTPIngressPath{Path: tc.path, PathType: tc.pathType},
+				extra: tc.extra,
+			}
+			match, err := toGRPCRouteMatch(ip, field.NewPath("spec"))
+			if tc.expectError != (err != nil) {
+				t.Fatalf("toGRPCRouteMatch() error = %v, expecting error: %v", err, tc.expectError)
+			}
+			if diff := cmp.Diff(tc.expectedMatch, match); diff != "" {
+				t.Errorf("Unexpected match (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_appProtocol(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(backendProtocol string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "greeter", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{"grpc.example.com"}, SecretName: "grpc-cert"}},
+				Rules: []networkingv1.IngressRule{{
+					Host: "grpc.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "greeter",
+										Port: networkingv1.ServiceBackendPort{Name: "api"},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if backendProtocol != "" {
+			ingress.Annotations = map[string]string{backendProtocolAnnotation: backendProtocol}
+		}
+		return ingress
+	}
+	newService := func(appProtocol *string) corev1.Service {
+		return corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "greeter", Namespace: "test"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "api", Port: 50051, AppProtocol: appProtocol}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		ingress           networkingv1.Ingress
+		services          []corev1.Service
+		expectGRPC        bool
+		expectNumWarnings int
+	}{{
+		name:       "grpc appProtocol",
+		ingress:    newIngress(""),
+		services:   []corev1.Service{newService(pointer.String("grpc"))},
+		expectGRPC: true,
+	}, {
+		name:       "upper case grpc appProtocol",
+		ingress:    newIngress(""),
+		services:   []corev1.Service{newService(pointer.String("GRPC"))},
+		expectGRPC: true,
+	}, {
+		name:     "http appProtocol",
+		ingress:  newIngress(""),
+		services: []corev1.Service{newService(pointer.String("http"))},
+	}, {
+		name:     "h2c appProtocol",
+		ingress:  newIngress(""),
+		services: []corev1.Service{newService(pointer.String("kubernetes.io/h2c"))},
+	}, {
+		name:       "no appProtocol with grpc backend protocol",
+		ingress:    newIngress("GRPC"),
+		services:   []corev1.Service{newService(nil)},
+		expectGRPC: true,
+	}, {
+		name:     "no appProtocol without backend protocol",
+		ingress:  newIngress(""),
+		services: []corev1.Service{newService(nil)},
+	}, {
+		name:       "grpc appProtocol with agreeing backend protocol",
+		ingress:    newIngress("GRPC"),
+		services:   []corev1.Service{newService(pointer.String("grpc"))},
+		expectGRPC: true,
+	}, {
+		name:              "grpc appProtocol with disagreeing backend protocol",
+		ingress:           newIngress("HTTP"),
+		services:          []corev1.Service{newService(pointer.String("grpc"))},
+		expectGRPC:        true,
+		expectNumWarnings: 1,
+	}, {
+		name:              "http appProtocol with disagreeing backend protocol",
+		ingress:           newIngress("GRPC"),
+		services:          []corev1.Service{newService(pointer.String("http"))},
+		expectNumWarnings: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert([]networkingv1.Ingress{tc.ingress}, Options{Services: tc.services})
+			if err != nil {
+				t.Fatalf("Unexpected conversion error: %v", err)
+			}
+			expectGRPCRoutes, expectHTTPRoutes := 0, 1
+			if tc.expectGRPC {
+				expectGRPCRoutes, expectHTTPRoutes = 1, 0
+			}
+			if len(result.GRPCRoutes) != expectGRPCRoutes {
+				t.Errorf("Expected %d GRPCRoutes, got %d: %+v", expectGRPCRoutes, len(result.GRPCRoutes), result.GRPCRoutes)
+			}
+			if len(result.HTTPRoutes) != expectHTTPRoutes {
+				t.Errorf("Expected %d HTTPRoutes, got %d: %+v", expectHTTPRoutes, len(result.HTTPRoutes), result.HTTPRoutes)
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
+
+func Test_grpcWarningFields(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "greeter", Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: "grpc.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "greeter",
+										Port: networkingv1.ServiceBackendPort{Name: "api"},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	grpcService := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "greeter", Namespace: "test"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "api", Port: 50051, AppProtocol: pointer.String("grpc")}},
+		},
+	}
+
+	plainService := *grpcService.DeepCopy()
+	plainService.Spec.Ports[0].AppProtocol = nil
+
+	testCases := []struct {
+		name           string
+		ingress        networkingv1.Ingress
+		services       []corev1.Service
+		expectedFields []string
+	}{{
+		name:           "h2c with the backend protocol annotation",
+		ingress:        newIngress(map[string]string{backendProtocolAnnotation: "GRPC"}),
+		services:       []corev1.Service{plainService},
+		expectedFields: []string{"greeter.metadata.annotations[nginx.ingress.kubernetes.io/backend-protocol]"},
+	}, {
+		name:           "h2c with the appProtocol of the Service port",
+		ingress:        newIngress(nil),
+		services:       []corev1.Service{grpcService},
+		expectedFields: []string{"greeter.spec.rules[0].http.paths[0].backend.service.port"},
+	}, {
+		name:           "HTTPS listener of ManagedCertificates",
+		ingress:        newIngress(map[string]string{gceManagedCertificatesAnnotation: "grpc-example-com"}),
+		services:       []corev1.Service{grpcService},
+		expectedFields: []string{"greeter.metadata.annotations[networking.gke.io/managed-certificates]"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert([]networkingv1.Ingress{tc.ingress}, Options{Services: tc.services})
+			if err != nil {
+				t.Fatalf("Unexpected conversion error: %v", err)
+			}
+			if len(result.GRPCRoutes) != 1 {
+				t.Fatalf("Expected 1 GRPCRoute, got %d", len(result.GRPCRoutes))
+			}
+			var fields []string
+			for _, warning := range result.Warnings {
+				fields = append(fields, warning.Field.String())
+			}
+			if diff := cmp.Diff(tc.expectedFields, fields); diff != "" {
+				t.Errorf("Unexpected warning fields (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
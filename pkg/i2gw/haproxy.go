@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	haproxyPathRewriteAnnotation      = "haproxy.org/path-rewrite"
+	haproxySSLRedirectAnnotation      = "haproxy.org/ssl-redirect"
+	haproxySSLRedirectCodeAnnotation  = "haproxy.org/ssl-redirect-code"
+	haproxySSLRedirectPortAnnotation  = "haproxy.org/ssl-redirect-port"
+	haproxyLoadBalanceAnnotation      = "haproxy.org/load-balance"
+	haproxyTimeoutTunnelAnnotation    = "haproxy.org/timeout-tunnel"
+	haproxyDefaultSSLRedirectCode     = 302
+	haproxyDefaultSSLRedirectPort     = 443
+	haproxySSLRedirectRouteNameSuffix = "-ssl-redirect"
+)
+
+var (
+	// haproxyPrefixRegex matches path-rewrite regular expressions capturing
+	// everything after a literal prefix, such as "/foo/(.*)".
+	haproxyPrefixRegex = regexp.MustCompile(`^\^?([^()*+?.\[\]{}|\\$^]*)\(\.\*\)\$?$`)
+	// haproxyPrefixReplacement matches path-rewrite replacements appending the
+	// first capture to a literal prefix, such as "/bar\1".
+	haproxyPrefixReplacement = regexp.MustCompile(`^([^\\]*)\\1$`)
+)
+
+// sslRedirect is the HTTP to HTTPS redirect of the paths of an Ingress.
+type sslRedirect struct {
+	statusCode int
+	port       *int32
+}
+
+// haproxyProvider converts the haproxy.org annotations of the HAProxy Ingress
+// controller.
+type haproxyProvider struct{}
+
+func (haproxyProvider) annotationPrefix() string {
+	return "haproxy.org/"
+}
+
+func (p haproxyProvider) detect(ingress networkingv1.Ingress) bool {
+	return hasAnnotationPrefix(ingress, p.annotationPrefix())
+}
+
+func (haproxyProvider) inputKinds() []string {
+	return []string{"Ingress"}
+}
+
+func (haproxyProvider) summary() string {
+	return "Path rewrites, SSL redirects, load balancing and tunnel timeouts of the HAProxy Ingress controller"
+}
+
+func (haproxyProvider) addExtra(ingress networkingv1.Ingress, _ map[types.NamespacedName]corev1.ConfigMap, e *extra) field.ErrorList {
+	var errs field.ErrorList
+
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+
+	if t, ok := ingress.Annotations[haproxyTimeoutTunnelAnnotation]; ok {
+		timeout, err := parseHAProxyDuration(t)
+		if err != nil {
+			errs = append(errs, field.TypeInvalid(fieldPath, haproxyTimeoutTunnelAnnotation, err.Error()))
+		} else {
+			// The tunnel timeout is an idle timeout of the websocket connections,
+			// while the HTTPRoute timeouts limit the duration of the requests, so
+			// it can only be preserved through implementation specific
+			// configuration.
+			e.warnings = append(e.warnings, Warning{
+				Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+				Field:   fieldPath.Key(haproxyTimeoutTunnelAnnotation),
+				Message: fmt.Sprintf("websocket idle timeout of %s cannot be represented by HTTPRoute timeouts, which limit the duration of requests; configure an equivalent idle timeout on the Gateway implementation", timeout),
+			})
+		}
+	}
+	if rewrite := strings.TrimSpace(ingress.Annotations[haproxyPathRewriteAnnotation]); rewrite != "" {
+		e.pathRewrite = rewrite
+	}
+	if ingress.Annotations[haproxySSLRedirectAnnotation] == "true" {
+		e.sslRedirect = &sslRedirect{statusCode: haproxyDefaultSSLRedirectCode}
+		if code, ok := ingress.Annotations[haproxySSLRedirectCodeAnnotation]; ok {
+			statusCode, err := strconv.Atoi(code)
+			switch {
+			case err != nil:
+				errs = append(errs, field.TypeInvalid(fieldPath.Key(haproxySSLRedirectCodeAnnotation), code, err.Error()))
+			case statusCode != 301 && statusCode != 302:
+				errs = append(errs, field.NotSupported(fieldPath.Key(haproxySSLRedirectCodeAnnotation), code, []string{"301", "302"}))
+			default:
+				e.sslRedirect.statusCode = statusCode
+			}
+		}
+		if p, ok := ingress.Annotations[haproxySSLRedirectPortAnnotation]; ok {
+			port, err := strconv.ParseInt(p, 10, 32)
+			if err != nil || port < 1 || port > 65535 {
+				errs = append(errs, field.Invalid(fieldPath.Key(haproxySSLRedirectPortAnnotation), p, "must be a port number"))
+			} else if port != haproxyDefaultSSLRedirectPort {
+				e.sslRedirect.port = pointer.Int32(int32(port))
+			}
+		}
+	}
+	if algorithm, ok := ingress.Annotations[haproxyLoadBalanceAnnotation]; ok {
+		e.warnings = append(e.warnings, Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(haproxyLoadBalanceAnnotation),
+			Message: fmt.Sprintf("the %q load balancing algorithm cannot be represented in HTTPRoute; configure it on the Gateway implementation", algorithm),
+		})
+	}
+	return errs
+}
+
+// haproxyPathRewriteFilter converts a path-rewrite annotation to a URLRewrite
+// filter for the path. A single replacement path replaces the full path, and a
+// regular expression stripping or replacing the prefix of the path replaces
+// the prefix match. It returns false when the rewrite can't be converted.
+func haproxyPathRewriteFilter(path networkingv1.HTTPIngressPath, rewrite string) (*gatewayv1beta1.HTTPURLRewriteFilter, bool) {
+	fields := strings.Fields(rewrite)
+	switch len(fields) {
+	case 1:
+		return &gatewayv1beta1.HTTPURLRewriteFilter{
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:            gatewayv1.FullPathHTTPPathModifier,
+				ReplaceFullPath: pointer.String(fields[0]),
+			},
+		}, true
+	case 2:
+		prefix := haproxyPrefixRegex.FindStringSubmatch(fields[0])
+		replacement := haproxyPrefixReplacement.FindStringSubmatch(fields[1])
+		if prefix == nil || replacement == nil || path.PathType == nil || *path.PathType != networkingv1.PathTypePrefix {
+			return nil, false
+		}
+		if strings.TrimSuffix(prefix[1], "/") != strings.TrimSuffix(path.Path, "/") {
+			return nil, false
+		}
+		replacePrefix := replacement[1]
+		if replacePrefix == "" {
+			replacePrefix = "/"
+		}
+		return &gatewayv1beta1.HTTPURLRewriteFilter{
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+				ReplacePrefixMatch: pointer.String(replacePrefix),
+			},
+		}, true
+	}
+	return nil, false
+}
+
+// sslRedirect returns the HTTP to HTTPS redirect of the rule group, if one of
+// its Ingresses has one, along with a warning for every Ingress without
+// redirect whose paths are redirected as well.
+func (rg *ingressRuleGroup) sslRedirect() (*sslRedirect, []Warning) {
+	var redirect *sslRedirect
+	for _, ir := range rg.rules {
+		if ir.extra != nil && ir.extra.sslRedirect != nil {
+			redirect = ir.extra.sslRedirect
+			break
+		}
+	}
+	if redirect == nil {
+		return nil, nil
+	}
+
+	var warnings []Warning
+	seen := map[types.NamespacedName]bool{}
+	for _, ir := range rg.rules {
+		if (ir.extra != nil && ir.extra.sslRedirect != nil) || seen[ir.ingress] {
+			continue
+		}
+		seen[ir.ingress] = true
+		warnings = append(warnings, Warning{
+			Ingress: ir.ingress,
+			Field:   field.NewPath(ir.ingress.Name, "spec", "rules"),
+			Message: fmt.Sprintf("HTTP requests to host %q are redirected to HTTPS by the ssl-redirect of other Ingresses, including the paths of this Ingress", rg.host),
+		})
+	}
+	return redirect, warnings
+}
+
+// toSSLRedirectRoute attaches the HTTPRoute of the rule group to its HTTPS
+// listeners, and returns an HTTPRoute redirecting the requests of its HTTP
+// listeners to HTTPS.
+func toSSLRedirectRoute(httpRoute *gatewayv1beta1.HTTPRoute, httpSections, httpsSections []gatewayv1beta1.SectionName, redirect *sslRedirect) gatewayv1beta1.HTTPRoute {
+	parentRefs := httpRoute.Spec.ParentRefs
+	setSectionName(httpRoute, httpsSections...)
+
+	redirectRoute := gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        httpRoute.Name + haproxySSLRedirectRouteNameSuffix,
+			Namespace:   httpRoute.Namespace,
+			Annotations: httpRoute.Annotations,
+		},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			Hostnames: httpRoute.Spec.Hostnames,
+		},
+		Status: gatewayv1beta1.HTTPRouteStatus{
+			RouteStatus: gatewayv1beta1.RouteStatus{
+				Parents: []gatewayv1beta1.RouteParentStatus{},
+			},
+		},
+	}
+	redirectRoute.SetGroupVersionKind(httpRouteGVK)
+	redirectRoute.Spec.ParentRefs = parentRefs
+	setSectionName(&redirectRoute, httpSections...)
+	statusCode := redirect.statusCode
+	redirectRoute.Spec.Rules = []gatewayv1beta1.HTTPRouteRule{{
+		Filters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterRequestRedirect,
+			RequestRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+				Scheme:     pointer.String("https"),
+				Port:       (*gatewayv1.PortNumber)(redirect.port),
+				StatusCode: &statusCode,
+			},
+		}},
+	}}
+	return redirectRoute
+}
+
+// parseHAProxyDuration parses a duration in HAProxy format. Values without a
+// unit are milliseconds, and the "d" unit is supported for days in addition
+// to the units understood by time.ParseDuration.
+func parseHAProxyDuration(value string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(value); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		d, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(d) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_haproxyPathRewriteFilter(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+
+	testCases := []struct {
+		name     string
+		path     networkingv1.HTTPIngressPath
+		rewrite  string
+		expected *gatewayv1beta1.HTTPURLRewriteFilter
+	}{{
+		name:    "full path",
+		path:    networkingv1.HTTPIngressPath{Path: "/foo", PathType: &iExact},
+		rewrite: "/bar",
+		expected: &gatewayv1beta1.HTTPURLRewriteFilter{
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:            gatewayv1.FullPathHTTPPathModifier,
+				ReplaceFullPath: pointer.String("/bar"),
+			},
+		},
+	}, {
+		name:    "strip prefix",
+		path:    networkingv1.HTTPIngressPath{Path: "/foo", PathType: &iPrefix},
+		rewrite: `^/foo/(.*)$ /\1`,
+		expected: &gatewayv1beta1.HTTPURLRewriteFilter{
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+				ReplacePrefixMatch: pointer.String("/"),
+			},
+		},
+	}, {
+		name:    "replace prefix",
+		path:    networkingv1.HTTPIngressPath{Path: "/foo/", PathType: &iPrefix},
+		rewrite: `/foo/(.*) /bar/\1`,
+		expected: &gatewayv1beta1.HTTPURLRewriteFilter{
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+				ReplacePrefixMatch: pointer.String("/bar/"),
+			},
+		},
+	}, {
+		name:    "prefix of another path",
+		path:    networkingv1.HTTPIngressPath{Path: "/baz", PathType: &iPrefix},
+		rewrite: `^/foo/(.*)$ /\1`,
+	}, {
+		name:    "exact path",
+		path:    networkingv1.HTTPIngressPath{Path: "/foo", PathType: &iExact},
+		rewrite: `^/foo/(.*)$ /\1`,
+	}, {
+		name:    "regular expression",
+		path:    networkingv1.HTTPIngressPath{Path: "/foo", PathType: &iPrefix},
+		rewrite: `^/foo/([a-z]+)/(.*)$ /\2/\1`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := haproxyPathRewriteFilter(tc.path, tc.rewrite)
+			if ok != (tc.expected != nil) {
+				t.Fatalf("Expected conversion to be %t, got %t", tc.expected != nil, ok)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("Unexpected URLRewrite filter (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_haproxyProvider(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name string, tls bool, annotations map[string]string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("haproxy"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: name,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tls {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com"}}
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name                 string
+		ingresses            []networkingv1.Ingress
+		providers            []string
+		expectedRouteNames   []string
+		expectedSectionNames []string
+		expectedRedirect     *gatewayv1beta1.HTTPRequestRedirectFilter
+		expectedNumFilters   int
+		expectedNumWarnings  int
+		expectedNumErrors    int
+	}{{
+		name: "ssl-redirect",
+		ingresses: []networkingv1.Ingress{newIngress("app", true, map[string]string{
+			haproxySSLRedirectAnnotation:     "true",
+			haproxySSLRedirectCodeAnnotation: "301",
+			haproxySSLRedirectPortAnnotation: "8443",
+		})},
+		expectedRouteNames:   []string{"example-com", "example-com-ssl-redirect"},
+		expectedSectionNames: []string{"example-com-https", "example-com-http"},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Port:       (*gatewayv1.PortNumber)(pointer.Int32(8443)),
+			StatusCode: pointer.Int(301),
+		},
+	}, {
+		name: "ssl-redirect shared with another Ingress",
+		ingresses: []networkingv1.Ingress{
+			newIngress("app", true, map[string]string{haproxySSLRedirectAnnotation: "true"}),
+			newIngress("other", false, nil),
+		},
+		expectedRouteNames:   []string{"example-com", "example-com-ssl-redirect"},
+		expectedSectionNames: []string{"example-com-https", "example-com-http"},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			StatusCode: pointer.Int(302),
+		},
+		expectedNumWarnings: 1,
+	}, {
+		name:                 "ssl-redirect without TLS",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{haproxySSLRedirectAnnotation: "true"})},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}, {
+		name:              "unsupported ssl-redirect-code",
+		ingresses:         []networkingv1.Ingress{newIngress("app", true, map[string]string{haproxySSLRedirectAnnotation: "true", haproxySSLRedirectCodeAnnotation: "307"})},
+		expectedNumErrors: 1,
+	}, {
+		name:                 "path-rewrite",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{haproxyPathRewriteAnnotation: `/app/(.*) /\1`})},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumFilters:   1,
+	}, {
+		name:                 "unconvertible path-rewrite",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{haproxyPathRewriteAnnotation: `/(.*)/app /\1`})},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "load-balance",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{haproxyLoadBalanceAnnotation: "leastconn"})},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "provider not selected",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, map[string]string{haproxyPathRewriteAnnotation: "/", haproxyLoadBalanceAnnotation: "leastconn"})},
+		providers:            []string{ProviderIngressNginx},
+		expectedRouteNames:   []string{"example-com"},
+		expectedSectionNames: []string{"example-com-http"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, _, warnings, errs := ingresses2GatewaysAndHTTPRoutes(tc.ingresses, Options{Providers: tc.providers})
+			if len(errs) != tc.expectedNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectedNumErrors, len(errs), errs)
+			}
+			if len(warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(warnings), warnings)
+			}
+			var routeNames, sectionNames []string
+			for _, route := range httpRoutes {
+				routeNames = append(routeNames, route.Name)
+				for _, parentRef := range route.Spec.ParentRefs {
+					if parentRef.SectionName != nil {
+						sectionNames = append(sectionNames, string(*parentRef.SectionName))
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.expectedRouteNames, routeNames); diff != "" {
+				t.Fatalf("Unexpected HTTPRoute names (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedSectionNames, sectionNames); diff != "" {
+				t.Errorf("Unexpected sectionNames (-want +got):\n%s", diff)
+			}
+			if len(httpRoutes) == 0 {
+				return
+			}
+			if len(httpRoutes[0].Spec.Rules[0].Filters) != tc.expectedNumFilters {
+				t.Errorf("Expected %d filters, got %+v", tc.expectedNumFilters, httpRoutes[0].Spec.Rules[0].Filters)
+			}
+			if tc.expectedRedirect != nil {
+				if diff := cmp.Diff(tc.expectedRedirect, httpRoutes[1].Spec.Rules[0].Filters[0].RequestRedirect); diff != "" {
+					t.Errorf("Unexpected RequestRedirect filter (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func Test_parseHAProxyDuration(t *testing.T) {
+	testCases := []struct {
+		value          string
+		expected       time.Duration
+		expectingError bool
+	}{
+		{value: "5000", expected: 5 * time.Second},
+		{value: "30s", expected: 30 * time.Second},
+		{value: "1h30m", expected: 90 * time.Minute},
+		{value: "2d", expected: 48 * time.Hour},
+		{value: "xd", expectingError: true},
+		{value: "forever", expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := parseHAProxyDuration(tc.value)
+			if tc.expectingError {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("parseHAProxyDuration(%q) = %s, expected %s", tc.value, actual, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	configurationSnippetAnnotation = "nginx.ingress.kubernetes.io/configuration-snippet"
+	customHeadersAnnotation        = "nginx.ingress.kubernetes.io/custom-headers"
+	upstreamVhostAnnotation        = "nginx.ingress.kubernetes.io/upstream-vhost"
+	xForwardedPrefixAnnotation     = "nginx.ingress.kubernetes.io/x-forwarded-prefix"
+)
+
+// headerModifier accumulates the header mutations of a request or a response.
+// Header names are case insensitive, a later mutation of a header replaces
+// the earlier ones.
+type headerModifier struct {
+	set    map[string]gatewayv1.HTTPHeader
+	add    map[string]gatewayv1.HTTPHeader
+	remove map[string]string
+}
+
+func (m *headerModifier) setHeader(name, value string) {
+	key := strings.ToLower(name)
+	m.forget(key)
+	if m.set == nil {
+		m.set = map[string]gatewayv1.HTTPHeader{}
+	}
+	m.set[key] = gatewayv1.HTTPHeader{Name: gatewayv1.HTTPHeaderName(name), Value: value}
+}
+
+func (m *headerModifier) addHeader(name, value string) {
+	key := strings.ToLower(name)
+	m.forget(key)
+	if m.add == nil {
+		m.add = map[string]gatewayv1.HTTPHeader{}
+	}
+	m.add[key] = gatewayv1.HTTPHeader{Name: gatewayv1.HTTPHeaderName(name), Value: value}
+}
+
+func (m *headerModifier) removeHeader(name string) {
+	key := strings.ToLower(name)
+	m.forget(key)
+	if m.remove == nil {
+		m.remove = map[string]string{}
+	}
+	m.remove[key] = name
+}
+
+func (m *headerModifier) forget(key string) {
+	delete(m.set, key)
+	delete(m.add, key)
+	delete(m.remove, key)
+}
+
+// merge applies the mutations of other on top of m.
+func (m *headerModifier) merge(other *headerModifier) {
+	for _, h := range other.set {
+		m.setHeader(string(h.Name), h.Value)
+	}
+	for _, h := range other.add {
+		m.addHeader(string(h.Name), h.Value)
+	}
+	for _, name := range other.remove {
+		m.removeHeader(name)
+	}
+}
+
+// toFilter returns the header filter, or nil when there is no mutation.
+// Headers are sorted by name so that the output is stable.
+func (m *headerModifier) toFilter() *gatewayv1.HTTPHeaderFilter {
+	if m == nil || len(m.set)+len(m.add)+len(m.remove) == 0 {
+		return nil
+	}
+	sortedHeaders := func(headers map[string]gatewayv1.HTTPHeader) []gatewayv1.HTTPHeader {
+		var result []gatewayv1.HTTPHeader
+		for _, h := range headers {
+			result = append(result, h)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return strings.ToLower(string(result[i].Name)) < strings.ToLower(string(result[j].Name))
+		})
+		return result
+	}
+	filter := &gatewayv1.HTTPHeaderFilter{
+		Set: sortedHeaders(m.set),
+		Add: sortedHeaders(m.add),
+	}
+	for _, name := range m.remove {
+		filter.Remove = append(filter.Remove, name)
+	}
+	sort.Slice(filter.Remove, func(i, j int) bool {
+		return strings.ToLower(filter.Remove[i]) < strings.ToLower(filter.Remove[j])
+	})
+	return filter
+}
+
+// customHeadersConfigMapRef returns the namespace/name of the ConfigMap
+// holding the custom response headers of the Ingress, if any. As for the TCP
+// services ConfigMap, a reference without namespace is in the namespace of the
+// Ingress.
+func customHeadersConfigMapRef(ingress networkingv1.Ingress) (types.NamespacedName, bool) {
+	ref := ingress.Annotations[customHeadersAnnotation]
+	if ref == "" {
+		return types.NamespacedName{}, false
+	}
+	if parts := strings.SplitN(ref, "/", 2); len(parts) == 2 {
+		return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+	}
+	return types.NamespacedName{Namespace: ingress.Namespace, Name: ref}, true
+}
+
+// getHeaderModifiers converts the header annotations of the Ingress, and its
+// CORS annotations, to request and response header mutations. The
+// configuration-snippet header directives take precedence over the other
+// annotations, and the custom headers over the CORS headers. Snippet directives
+// that couldn't be parsed are reported as a warning.
+func getHeaderModifiers(ingress networkingv1.Ingress, configMaps map[types.NamespacedName]corev1.ConfigMap) (*headerModifier, *headerModifier, []Warning, field.ErrorList) {
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+	request, response := &headerModifier{}, &headerModifier{}
+	var warnings []Warning
+	var errs field.ErrorList
+
+	if cors, corsWarnings := getCORSHeaders(ingress, fieldPath); cors != nil {
+		response.merge(cors)
+		warnings = append(warnings, corsWarnings...)
+	}
+	if cmRef, ok := customHeadersConfigMapRef(ingress); ok {
+		cm, ok := configMaps[cmRef]
+		if !ok {
+			errs = append(errs, field.NotFound(fieldPath.Key(customHeadersAnnotation), cmRef.String()))
+		}
+		for name, value := range cm.Data {
+			response.setHeader(name, value)
+		}
+	}
+	if host := ingress.Annotations[upstreamVhostAnnotation]; host != "" {
+		request.setHeader("Host", host)
+	}
+	if prefix := ingress.Annotations[xForwardedPrefixAnnotation]; prefix != "" {
+		request.setHeader("X-Forwarded-Prefix", prefix)
+	}
+
+	// The limit_except block of the snippet is converted to method matches,
+	// see getSnippetMethods.
+	snippet, ok := ingress.Annotations[configurationSnippetAnnotation]
+	if _, rest, parsed := parseSnippetMethods(snippet); ok && parsed && rest != snippet {
+		snippet, ok = rest, strings.TrimSpace(rest) != ""
+	}
+	if ok {
+		snippetRequest, snippetResponse, unparsed := parseSnippetHeaders(snippet)
+		request.merge(snippetRequest)
+		response.merge(snippetResponse)
+
+		converted := snippetRequest.toFilter() != nil || snippetResponse.toFilter() != nil
+		switch {
+		case !converted:
+			warnings = append(warnings, Warning{
+				Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+				Field:   fieldPath.Key(configurationSnippetAnnotation),
+				Message: fmt.Sprintf("nginx snippets cannot be represented in Gateway API and must be ported manually:\n%s", snippet),
+			})
+		case len(unparsed) > 0:
+			warnings = append(warnings, Warning{
+				Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+				Field:   fieldPath.Key(configurationSnippetAnnotation),
+				Message: fmt.Sprintf("the header directives of the snippet were converted to header modifier filters, the other directives cannot be represented in Gateway API and must be ported manually:\n%s;", strings.Join(unparsed, ";\n")),
+			})
+		}
+	}
+
+	return request, response, warnings, errs
+}
+
+// parseSnippetHeaders extracts the header mutations of an nginx snippet. The
+// more_set_headers, more_clear_headers, more_set_input_headers,
+// more_clear_input_headers, proxy_set_header and add_header directives are
+// converted when their values are static. All other directives are returned
+// as unparsed. Snippets with blocks are not parsed at all.
+func parseSnippetHeaders(snippet string) (*headerModifier, *headerModifier, []string) {
+	request, response := &headerModifier{}, &headerModifier{}
+	directives, ok := splitSnippet(snippet)
+	if !ok {
+		return request, response, []string{strings.TrimSpace(snippet)}
+	}
+
+	var unparsed []string
+	for _, d := range directives {
+		if !applyHeaderDirective(d.tokens, request, response) {
+			unparsed = append(unparsed, d.raw)
+		}
+	}
+	return request, response, unparsed
+}
+
+// applyHeaderDirective applies a header directive to the request or response
+// mutations. It returns false, leaving the mutations untouched, when the
+// directive isn't a header directive or can't be statically converted.
+func applyHeaderDirective(tokens []string, request, response *headerModifier) bool {
+	if len(tokens) < 2 {
+		return false
+	}
+	for _, arg := range tokens[1:] {
+		// Options such as -s and -t of headers-more, and nginx variables,
+		// depend on the request being processed.
+		if strings.HasPrefix(arg, "-") || strings.Contains(arg, "$") {
+			return false
+		}
+	}
+
+	pending := &headerModifier{}
+	var target *headerModifier
+	switch directive, args := tokens[0], tokens[1:]; directive {
+	case "more_set_headers", "more_set_input_headers":
+		target = response
+		if directive == "more_set_input_headers" {
+			target = request
+		}
+		for _, arg := range args {
+			name, value, found := strings.Cut(arg, ":")
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if !found || name == "" || strings.Contains(name, "*") {
+				return false
+			}
+			if value == "" {
+				pending.removeHeader(name)
+			} else {
+				pending.setHeader(name, value)
+			}
+		}
+	case "more_clear_headers", "more_clear_input_headers":
+		target = response
+		if directive == "more_clear_input_headers" {
+			target = request
+		}
+		for _, name := range args {
+			if strings.Contains(name, "*") {
+				return false
+			}
+			pending.removeHeader(name)
+		}
+	case "proxy_set_header":
+		target = request
+		if len(args) != 2 {
+			return false
+		}
+		// An empty value prevents the header from being passed upstream.
+		if args[1] == "" {
+			pending.removeHeader(args[0])
+		} else {
+			pending.setHeader(args[0], args[1])
+		}
+	case "add_header":
+		target = response
+		if len(args) != 2 && (len(args) != 3 || args[2] != "always") {
+			return false
+		}
+		pending.addHeader(args[0], args[1])
+	default:
+		return false
+	}
+	target.merge(pending)
+	return true
+}
+
+type snippetDirective struct {
+	raw    string
+	tokens []string
+}
+
+// splitSnippet splits an nginx snippet into directives and their quote
+// stripped tokens. Comments are skipped. It returns false when the snippet has
+// blocks or unterminated quotes or directives.
+func splitSnippet(snippet string) ([]snippetDirective, bool) {
+	var directives []snippetDirective
+	var tokens []string
+	var token, raw strings.Builder
+	var quote rune
+	inToken, inComment := false, false
+
+	endToken := func() {
+		if inToken {
+			tokens = append(tokens, token.String())
+			token.Reset()
+			inToken = false
+		}
+	}
+
+	for _, r := range snippet {
+		switch {
+		case inComment:
+			if r == '\n' {
+				inComment = false
+			}
+			continue
+		case quote != 0:
+			raw.WriteRune(r)
+			if r == quote {
+				quote = 0
+				continue
+			}
+			token.WriteRune(r)
+			continue
+		}
+
+		switch r {
+		case '#':
+			endToken()
+			inComment = true
+		case '"', '\'':
+			raw.WriteRune(r)
+			quote = r
+			inToken = true
+		case '{', '}':
+			return nil, false
+		case ';':
+			endToken()
+			if len(tokens) > 0 {
+				directives = append(directives, snippetDirective{raw: strings.TrimSpace(raw.String()), tokens: tokens})
+			}
+			tokens = nil
+			raw.Reset()
+		case ' ', '\t', '\n', '\r':
+			endToken()
+			raw.WriteRune(r)
+		default:
+			raw.WriteRune(r)
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+	endToken()
+	if quote != 0 || len(tokens) > 0 {
+		return nil, false
+	}
+	return directives, true
+}
+
+// headerFilters returns the header modifier filters of the Ingress paths.
+func (e *extra) headerFilters() []gatewayv1beta1.HTTPRouteFilter {
+	if e == nil {
+		return nil
+	}
+	var filters []gatewayv1beta1.HTTPRouteFilter
+	if e.requestHeaders != nil {
+		filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: e.requestHeaders,
+		})
+	}
+	if e.responseHeaders != nil {
+		filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                   gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: e.responseHeaders,
+		})
+	}
+	return filters
+}
+
+// grpcHeaderFilters returns the header modifier filters of the Ingress paths
+// for a GRPCRoute.
+func (e *extra) grpcHeaderFilters() []gatewayv1.GRPCRouteFilter {
+	if e == nil {
+		return nil
+	}
+	var filters []gatewayv1.GRPCRouteFilter
+	if e.requestHeaders != nil {
+		filters = append(filters, gatewayv1.GRPCRouteFilter{
+			Type:                  gatewayv1.GRPCRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: e.requestHeaders,
+		})
+	}
+	if e.responseHeaders != nil {
+		filters = append(filters, gatewayv1.GRPCRouteFilter{
+			Type:                   gatewayv1.GRPCRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: e.responseHeaders,
+		})
+	}
+	return filters
+}
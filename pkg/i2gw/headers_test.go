@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_parseSnippetHeaders(t *testing.T) {
+	testCases := []struct {
+		name             string
+		snippet          string
+		expectedRequest  *gatewayv1.HTTPHeaderFilter
+		expectedResponse *gatewayv1.HTTPHeaderFilter
+		expectedUnparsed []string
+	}{{
+		name:    "response headers",
+		snippet: "more_set_headers \"X-Frame-Options: DENY\" 'X-Served-By: nginx';\nadd_header Cache-Control no-cache always;\nmore_clear_headers Server;",
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Set:    []gatewayv1.HTTPHeader{{Name: "X-Frame-Options", Value: "DENY"}, {Name: "X-Served-By", Value: "nginx"}},
+			Add:    []gatewayv1.HTTPHeader{{Name: "Cache-Control", Value: "no-cache"}},
+			Remove: []string{"Server"},
+		},
+	}, {
+		name:    "request headers",
+		snippet: "proxy_set_header X-Tenant acme;\nproxy_set_header Accept-Encoding \"\";\nmore_set_input_headers \"X-Debug: 1\";",
+		expectedRequest: &gatewayv1.HTTPHeaderFilter{
+			Set:    []gatewayv1.HTTPHeader{{Name: "X-Debug", Value: "1"}, {Name: "X-Tenant", Value: "acme"}},
+			Remove: []string{"Accept-Encoding"},
+		},
+	}, {
+		name:    "later directives win",
+		snippet: "more_set_headers \"X-Version: 1\"; more_set_headers \"x-version: 2\";",
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Set: []gatewayv1.HTTPHeader{{Name: "x-version", Value: "2"}},
+		},
+	}, {
+		name:    "comments are skipped",
+		snippet: "# security headers\nmore_set_headers \"X-Frame-Options: DENY\";",
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Set: []gatewayv1.HTTPHeader{{Name: "X-Frame-Options", Value: "DENY"}},
+		},
+	}, {
+		name:    "dynamic values are not parsed",
+		snippet: "proxy_set_header X-Real-IP $remote_addr;\nmore_set_headers -s 404 \"X-Missing: true\";\nadd_header X-Static yes;",
+		expectedResponse: &gatewayv1.HTTPHeaderFilter{
+			Add: []gatewayv1.HTTPHeader{{Name: "X-Static", Value: "yes"}},
+		},
+		expectedUnparsed: []string{"proxy_set_header X-Real-IP $remote_addr", "more_set_headers -s 404 \"X-Missing: true\""},
+	}, {
+		name:             "other directives are not parsed",
+		snippet:          "rewrite ^/old/(.*)$ /new/$1 break;",
+		expectedUnparsed: []string{"rewrite ^/old/(.*)$ /new/$1 break"},
+	}, {
+		name:             "blocks are not parsed",
+		snippet:          "if ($http_x_debug) { more_set_headers \"X-Debug: 1\"; }",
+		expectedUnparsed: []string{"if ($http_x_debug) { more_set_headers \"X-Debug: 1\"; }"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request, response, unparsed := parseSnippetHeaders(tc.snippet)
+			if diff := cmp.Diff(tc.expectedRequest, request.toFilter()); diff != "" {
+				t.Errorf("Unexpected request headers (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedResponse, response.toFilter()); diff != "" {
+				t.Errorf("Unexpected response headers (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedUnparsed, unparsed); diff != "" {
+				t.Errorf("Unexpected unparsed directives (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_headerModifierFilters(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	customHeaders := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom-headers", Namespace: "ingress-nginx"},
+		Data: map[string]string{
+			"X-Frame-Options": "SAMEORIGIN",
+			"X-Content-Type":  "nosniff",
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		annotations       map[string]string
+		configMaps        []corev1.ConfigMap
+		expectedFilters   []gatewayv1beta1.HTTPRouteFilter
+		expectNumWarnings int
+		expectNumErrors   int
+	}{{
+		name: "custom headers and upstream vhost",
+		annotations: map[string]string{
+			customHeadersAnnotation: "ingress-nginx/custom-headers",
+			upstreamVhostAnnotation: "internal.example.com",
+		},
+		configMaps: []corev1.ConfigMap{customHeaders},
+		expectedFilters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{
+				Set: []gatewayv1.HTTPHeader{{Name: "Host", Value: "internal.example.com"}},
+			},
+		}, {
+			Type: gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: &gatewayv1.HTTPHeaderFilter{
+				Set: []gatewayv1.HTTPHeader{{Name: "X-Content-Type", Value: "nosniff"}, {Name: "X-Frame-Options", Value: "SAMEORIGIN"}},
+			},
+		}},
+	}, {
+		name: "snippet overrides custom headers",
+		annotations: map[string]string{
+			customHeadersAnnotation:        "ingress-nginx/custom-headers",
+			configurationSnippetAnnotation: "more_set_headers \"X-Frame-Options: DENY\";\nproxy_buffering off;",
+		},
+		configMaps: []corev1.ConfigMap{customHeaders},
+		expectedFilters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: &gatewayv1.HTTPHeaderFilter{
+				Set: []gatewayv1.HTTPHeader{{Name: "X-Content-Type", Value: "nosniff"}, {Name: "X-Frame-Options", Value: "DENY"}},
+			},
+		}},
+		// proxy_buffering can't be converted.
+		expectNumWarnings: 1,
+	}, {
+		name:              "unparsed snippet",
+		annotations:       map[string]string{configurationSnippetAnnotation: "proxy_set_header X-Real-IP $remote_addr;"},
+		expectNumWarnings: 1,
+	}, {
+		name:            "missing custom headers configmap",
+		annotations:     map[string]string{customHeadersAnnotation: "custom-headers"},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: tc.annotations},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: stringPtr("nginx"),
+					Rules: []networkingv1.IngressRule{{
+						Host: "example.com",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{{
+									Path:     "/",
+									PathType: &iPrefix,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "example",
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			}
+
+			httpRoutes, _, _, _, warnings, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, tc.configMaps, nil, nil, false)
+			if len(errs) != tc.expectNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+			if tc.expectNumErrors > 0 {
+				return
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if len(httpRoutes) != 1 || len(httpRoutes[0].Spec.Rules) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute with 1 rule, got %+v", httpRoutes)
+			}
+			if diff := cmp.Diff(tc.expectedFilters, httpRoutes[0].Spec.Rules[0].Filters); diff != "" {
+				t.Errorf("Unexpected filters (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// HelmValuesVersion is the version of the HelmValues structure. It must be
+// bumped on incompatible changes of the structure.
+const HelmValuesVersion = "v1alpha1"
+
+// HelmValues is a values.yaml structure describing the generated resources,
+// consumable by a generic Gateway API Helm chart.
+type HelmValues struct {
+	// Version is the version of the structure, see HelmValuesVersion.
+	Version    string          `json:"version"`
+	Gateways   []HelmGateway   `json:"gateways"`
+	HTTPRoutes []HelmHTTPRoute `json:"httpRoutes"`
+	GRPCRoutes []HelmGRPCRoute `json:"grpcRoutes"`
+	TCPRoutes  []HelmTCPRoute  `json:"tcpRoutes"`
+	TLSRoutes  []HelmTLSRoute  `json:"tlsRoutes"`
+
+	BackendTLSPolicies []HelmBackendTLSPolicy `json:"backendTLSPolicies"`
+	ReferenceGrants    []HelmReferenceGrant   `json:"referenceGrants"`
+
+	// Policies are the policy attachments of the target implementation, as
+	// complete objects, since their kinds vary between implementations.
+	Policies []map[string]interface{} `json:"policies"`
+}
+
+// HelmMetadata is the metadata of a resource of the HelmValues.
+type HelmMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// HelmGateway is a Gateway of the HelmValues.
+type HelmGateway struct {
+	HelmMetadata     `json:",inline"`
+	GatewayClassName string                    `json:"gatewayClassName"`
+	Listeners        []gatewayv1beta1.Listener `json:"listeners"`
+}
+
+// HelmHTTPRoute is an HTTPRoute of the HelmValues.
+type HelmHTTPRoute struct {
+	HelmMetadata `json:",inline"`
+	ParentRefs   []gatewayv1beta1.ParentReference `json:"parentRefs,omitempty"`
+	Hostnames    []gatewayv1beta1.Hostname        `json:"hostnames,omitempty"`
+	Rules        []gatewayv1beta1.HTTPRouteRule   `json:"rules"`
+}
+
+// HelmGRPCRoute is a GRPCRoute of the HelmValues.
+type HelmGRPCRoute struct {
+	HelmMetadata `json:",inline"`
+	ParentRefs   []gatewayv1.ParentReference `json:"parentRefs,omitempty"`
+	Hostnames    []gatewayv1.Hostname        `json:"hostnames,omitempty"`
+	Rules        []gatewayv1.GRPCRouteRule   `json:"rules"`
+}
+
+// HelmTCPRoute is a TCPRoute of the HelmValues.
+type HelmTCPRoute struct {
+	HelmMetadata `json:",inline"`
+	ParentRefs   []gatewayv1alpha2.ParentReference `json:"parentRefs,omitempty"`
+	Rules        []gatewayv1alpha2.TCPRouteRule    `json:"rules"`
+}
+
+// HelmTLSRoute is a TLSRoute of the HelmValues.
+type HelmTLSRoute struct {
+	HelmMetadata `json:",inline"`
+	ParentRefs   []gatewayv1alpha2.ParentReference `json:"parentRefs,omitempty"`
+	Hostnames    []gatewayv1alpha2.Hostname        `json:"hostnames,omitempty"`
+	Rules        []gatewayv1alpha2.TLSRouteRule    `json:"rules"`
+}
+
+// HelmBackendTLSPolicy is a BackendTLSPolicy of the HelmValues.
+type HelmBackendTLSPolicy struct {
+	HelmMetadata `json:",inline"`
+	TargetRefs   []gatewayv1alpha2.LocalPolicyTargetReferenceWithSectionName `json:"targetRefs"`
+	Validation   gatewayv1alpha3.BackendTLSPolicyValidation                  `json:"validation"`
+}
+
+// HelmReferenceGrant is a ReferenceGrant of the HelmValues.
+type HelmReferenceGrant struct {
+	HelmMetadata `json:",inline"`
+	From         []gatewayv1beta1.ReferenceGrantFrom `json:"from"`
+	To           []gatewayv1beta1.ReferenceGrantTo   `json:"to"`
+}
+
+// ToHelmValues returns the HelmValues describing the resources of the
+// conversion result.
+func ToHelmValues(result Result) HelmValues {
+	values := HelmValues{
+		Version:            HelmValuesVersion,
+		Gateways:           []HelmGateway{},
+		HTTPRoutes:         []HelmHTTPRoute{},
+		GRPCRoutes:         []HelmGRPCRoute{},
+		TCPRoutes:          []HelmTCPRoute{},
+		TLSRoutes:          []HelmTLSRoute{},
+		BackendTLSPolicies: []HelmBackendTLSPolicy{},
+		ReferenceGrants:    []HelmReferenceGrant{},
+		Policies:           []map[string]interface{}{},
+	}
+	for _, gw := range result.Gateways {
+		values.Gateways = append(values.Gateways, HelmGateway{
+			HelmMetadata:     helmMetadata(gw.Name, gw.Namespace, gw.Labels, gw.Annotations),
+			GatewayClassName: string(gw.Spec.GatewayClassName),
+			Listeners:        gw.Spec.Listeners,
+		})
+	}
+	for _, route := range result.HTTPRoutes {
+		values.HTTPRoutes = append(values.HTTPRoutes, HelmHTTPRoute{
+			HelmMetadata: helmMetadata(route.Name, route.Namespace, route.Labels, route.Annotations),
+			ParentRefs:   route.Spec.ParentRefs,
+			Hostnames:    route.Spec.Hostnames,
+			Rules:        route.Spec.Rules,
+		})
+	}
+	for _, route := range result.GRPCRoutes {
+		values.GRPCRoutes = append(values.GRPCRoutes, HelmGRPCRoute{
+			HelmMetadata: helmMetadata(route.Name, route.Namespace, route.Labels, route.Annotations),
+			ParentRefs:   route.Spec.ParentRefs,
+			Hostnames:    route.Spec.Hostnames,
+			Rules:        route.Spec.Rules,
+		})
+	}
+	for _, route := range result.TCPRoutes {
+		values.TCPRoutes = append(values.TCPRoutes, HelmTCPRoute{
+			HelmMetadata: helmMetadata(route.Name, route.Namespace, route.Labels, route.Annotations),
+			ParentRefs:   route.Spec.ParentRefs,
+			Rules:        route.Spec.Rules,
+		})
+	}
+	for _, route := range result.TLSRoutes {
+		values.TLSRoutes = append(values.TLSRoutes, HelmTLSRoute{
+			HelmMetadata: helmMetadata(route.Name, route.Namespace, route.Labels, route.Annotations),
+			ParentRefs:   route.Spec.ParentRefs,
+			Hostnames:    route.Spec.Hostnames,
+			Rules:        route.Spec.Rules,
+		})
+	}
+	for _, policy := range result.BackendTLSPolicies {
+		values.BackendTLSPolicies = append(values.BackendTLSPolicies, HelmBackendTLSPolicy{
+			HelmMetadata: helmMetadata(policy.Name, policy.Namespace, policy.Labels, policy.Annotations),
+			TargetRefs:   policy.Spec.TargetRefs,
+			Validation:   policy.Spec.Validation,
+		})
+	}
+	for _, grant := range result.ReferenceGrants {
+		values.ReferenceGrants = append(values.ReferenceGrants, HelmReferenceGrant{
+			HelmMetadata: helmMetadata(grant.Name, grant.Namespace, grant.Labels, grant.Annotations),
+			From:         grant.Spec.From,
+			To:           grant.Spec.To,
+		})
+	}
+	for _, policy := range result.Policies {
+		values.Policies = append(values.Policies, policy.Object)
+	}
+	return values
+}
+
+func helmMetadata(name, namespace string, labels, annotations map[string]string) HelmMetadata {
+	return HelmMetadata{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_ToHelmValues(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: "example",
+									Port: networkingv1.ServiceBackendPort{Number: 8080},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	httpRoutes, grpcRoutes, tcpRoutes, gateways, _, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	tlsRoute := gatewayv1alpha2.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "secure-example-com", Namespace: "test"},
+		Spec: gatewayv1alpha2.TLSRouteSpec{
+			CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+				ParentRefs: []gatewayv1alpha2.ParentReference{{Name: "nginx", SectionName: sectionNamePtr("secure-example-com-tls-passthrough")}},
+			},
+			Hostnames: []gatewayv1alpha2.Hostname{"secure.example.com"},
+			Rules: []gatewayv1alpha2.TLSRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{{
+					BackendObjectReference: gatewayv1alpha2.BackendObjectReference{Name: "secure", Port: portNumberPtr(443)},
+				}},
+			}},
+		},
+	}
+	referenceGrant := gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateways-infra", Namespace: "test"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "Gateway", Namespace: "infra"}},
+			To:   []gatewayv1beta1.ReferenceGrantTo{{Kind: "Secret"}},
+		},
+	}
+	result := Result{
+		HTTPRoutes:      httpRoutes,
+		GRPCRoutes:      grpcRoutes,
+		TCPRoutes:       tcpRoutes,
+		TLSRoutes:       []gatewayv1alpha2.TLSRoute{tlsRoute},
+		Gateways:        gateways,
+		ReferenceGrants: []gatewayv1beta1.ReferenceGrant{referenceGrant},
+	}
+
+	expectedValues := HelmValues{
+		Version: HelmValuesVersion,
+		Gateways: []HelmGateway{{
+			HelmMetadata:     HelmMetadata{Name: "nginx", Namespace: "test"},
+			GatewayClassName: "nginx",
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:     "example-com-http",
+				Hostname: gatewayHostnamePtr("example.com"),
+				Port:     80,
+				Protocol: gatewayv1.HTTPProtocolType,
+			}},
+		}},
+		HTTPRoutes: []HelmHTTPRoute{{
+			HelmMetadata: HelmMetadata{Name: "example-com", Namespace: "test"},
+			ParentRefs:   []gatewayv1beta1.ParentReference{{Name: "nginx", SectionName: gatewaySectionNamePtr("example-com-http")}},
+			Hostnames:    []gatewayv1beta1.Hostname{"example.com"},
+			Rules: []gatewayv1beta1.HTTPRouteRule{{
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{
+					Path: &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: stringPtr("/")},
+				}},
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+					BackendRef: gatewayv1beta1.BackendRef{
+						BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+							Name: "example",
+							Port: portNumberPtr(8080),
+						},
+					},
+				}},
+			}},
+		}},
+		GRPCRoutes: []HelmGRPCRoute{},
+		TCPRoutes:  []HelmTCPRoute{},
+		TLSRoutes: []HelmTLSRoute{{
+			HelmMetadata: HelmMetadata{Name: "secure-example-com", Namespace: "test"},
+			ParentRefs:   tlsRoute.Spec.ParentRefs,
+			Hostnames:    tlsRoute.Spec.Hostnames,
+			Rules:        tlsRoute.Spec.Rules,
+		}},
+		BackendTLSPolicies: []HelmBackendTLSPolicy{},
+		ReferenceGrants: []HelmReferenceGrant{{
+			HelmMetadata: HelmMetadata{Name: "gateways-infra", Namespace: "test"},
+			From:         referenceGrant.Spec.From,
+			To:           referenceGrant.Spec.To,
+		}},
+		Policies: []map[string]interface{}{},
+	}
+
+	values := ToHelmValues(result)
+	if diff := cmp.Diff(expectedValues, values); diff != "" {
+		t.Errorf("ToHelmValues() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// normalizeHosts normalizes the hosts of the rules and TLS entries of the
+// Ingress into HTTPRoute hostnames, reporting every change by a warning:
+// hosts are lowercased, as hostnames are case-insensitive, and their port
+// suffix is removed, as Gateway API hostnames have no port. The hosts still
+// invalid are left to validateHost. The rules and TLS entries are copied, as
+// the Ingress shares them with its caller.
+func normalizeHosts(ingress *networkingv1.Ingress) []Warning {
+	var warnings []Warning
+	ingressName := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	normalize := func(host string, fieldPath *field.Path) string {
+		normalized, port := splitHostPort(host)
+		normalized = strings.ToLower(normalized)
+		switch {
+		case port != "":
+			warnings = append(warnings, Warning{
+				Ingress: ingressName,
+				Field:   fieldPath,
+				Message: fmt.Sprintf("the port %s of host %q is removed, as Gateway API hostnames have no port; the host %q is served on the ports of the Gateway listeners, set by --listener-port and --tls-listener-port", port, host, normalized),
+			})
+		case normalized != host:
+			warnings = append(warnings, Warning{
+				Ingress: ingressName,
+				Field:   fieldPath,
+				Message: fmt.Sprintf("the host %q is converted to the lowercase hostname %q, as hostnames are case-insensitive", host, normalized),
+			})
+		}
+		return normalized
+	}
+
+	rules := make([]networkingv1.IngressRule, len(ingress.Spec.Rules))
+	for i, rule := range ingress.Spec.Rules {
+		rule.Host = normalize(rule.Host, field.NewPath(ingress.Name).Child("spec", "rules").Index(i).Child("host"))
+		rules[i] = rule
+	}
+	tls := make([]networkingv1.IngressTLS, len(ingress.Spec.TLS))
+	for i, entry := range ingress.Spec.TLS {
+		hosts := make([]string, len(entry.Hosts))
+		for j, host := range entry.Hosts {
+			hosts[j] = normalize(host, field.NewPath(ingress.Name).Child("spec", "tls").Index(i).Child("hosts").Index(j))
+		}
+		entry.Hosts = hosts
+		tls[i] = entry
+	}
+	if ingress.Spec.Rules != nil {
+		ingress.Spec.Rules = rules
+	}
+	if ingress.Spec.TLS != nil {
+		ingress.Spec.TLS = tls
+	}
+	return warnings
+}
+
+// splitHostPort splits the host of an Ingress rule from its port suffix,
+// empty when the host has no port.
+func splitHostPort(host string) (string, string) {
+	i := strings.LastIndex(host, ":")
+	if i == -1 {
+		return host, ""
+	}
+	if _, err := strconv.ParseUint(host[i+1:], 10, 16); err != nil {
+		return host, ""
+	}
+	return host[:i], host[i+1:]
+}
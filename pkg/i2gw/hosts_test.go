@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_normalizeHosts(t *testing.T) {
+	testCases := []struct {
+		name            string
+		host            string
+		expectedHost    string
+		expectedMessage string
+	}{{
+		name:         "valid host",
+		host:         "example.com",
+		expectedHost: "example.com",
+	}, {
+		name:            "host with port",
+		host:            "example.com:8080",
+		expectedHost:    "example.com",
+		expectedMessage: "the port 8080 of host \"example.com:8080\" is removed",
+	}, {
+		name:            "wildcard host with port",
+		host:            "*.example.com:8443",
+		expectedHost:    "*.example.com",
+		expectedMessage: "the port 8443",
+	}, {
+		name:            "uppercase host",
+		host:            "App.Example.COM",
+		expectedHost:    "app.example.com",
+		expectedMessage: "lowercase hostname \"app.example.com\"",
+	}, {
+		name:         "invalid port left to the validation",
+		host:         "example.com:http",
+		expectedHost: "example.com:http",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := []networkingv1.IngressRule{{Host: tc.host}}
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+				Spec: networkingv1.IngressSpec{
+					Rules: rules,
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{tc.host}, SecretName: "app-tls"}},
+				},
+			}
+			warnings := normalizeHosts(&ingress)
+			if ingress.Spec.Rules[0].Host != tc.expectedHost || ingress.Spec.TLS[0].Hosts[0] != tc.expectedHost {
+				t.Errorf("Expected the host %q, got %q and TLS host %q", tc.expectedHost, ingress.Spec.Rules[0].Host, ingress.Spec.TLS[0].Hosts[0])
+			}
+			// The rules shared with the caller are left unchanged.
+			if rules[0].Host != tc.host {
+				t.Errorf("Expected the rules of the caller to be unchanged, got %q", rules[0].Host)
+			}
+			if tc.expectedMessage == "" {
+				if len(warnings) > 0 {
+					t.Errorf("Expected no warnings, got %+v", warnings)
+				}
+				return
+			}
+			if len(warnings) != 2 || !strings.Contains(warnings[0].Message, tc.expectedMessage) {
+				t.Errorf("Expected 2 warnings containing %q, got %+v", tc.expectedMessage, warnings)
+			}
+		})
+	}
+}
+
+func Test_convertHostsWithPorts(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(host string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: "app", Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		host              string
+		expectedHostnames []gatewayv1beta1.Hostname
+		expectingError    bool
+	}{{
+		name:              "host:8080",
+		host:              "example.com:8080",
+		expectedHostnames: []gatewayv1beta1.Hostname{"example.com"},
+	}, {
+		name:              "uppercase host",
+		host:              "Example.com",
+		expectedHostnames: []gatewayv1beta1.Hostname{"example.com"},
+	}, {
+		name:           "invalid host",
+		host:           "example_app.com:8080",
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses([]networkingv1.Ingress{newIngress(tc.host)}, Options{})
+			if tc.expectingError {
+				if len(errs) == 0 {
+					t.Errorf("Expected an error for host %q", tc.host)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if len(result.HTTPRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d", len(result.HTTPRoutes))
+			}
+			if diff := cmp.Diff(tc.expectedHostnames, result.HTTPRoutes[0].Spec.Hostnames); diff != "" {
+				t.Errorf("Unexpected hostnames (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != 1 {
+				t.Errorf("Expected 1 warning, got %+v", result.Warnings)
+			}
+		})
+	}
+}
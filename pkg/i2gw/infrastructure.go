@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// IngressClassAnnotation records the Ingress classes of the Ingresses of the
+// generated Gateways, separated by commas, in the annotations of their
+// infrastructure, see Options.RecordIngressClasses. The class of a Gateway
+// named by a template, or after the first Ingress of its namespace, can still
+// be mapped to the GatewayClass of its controller.
+const IngressClassAnnotation = "ingress2gateway.kubernetes.io/ingress-class"
+
+// addGatewayIngressClass records the Ingress class of an Ingress of the
+// Gateway, when the classes of the Gateways are recorded.
+func (a *ingressAggregator) addGatewayIngressClass(gwKey, class string) {
+	if !a.recordIngressClasses || class == "" {
+		return
+	}
+	if a.gatewayIngressClasses == nil {
+		a.gatewayIngressClasses = map[string]sets.Set[string]{}
+	}
+	if a.gatewayIngressClasses[gwKey] == nil {
+		a.gatewayIngressClasses[gwKey] = sets.New[string]()
+	}
+	a.gatewayIngressClasses[gwKey].Insert(class)
+}
+
+// setIngressClasses sets the IngressClassAnnotation of the infrastructure of
+// the Gateway to the classes. The Gateway is left unchanged without classes.
+func setIngressClasses(gateway *gatewayv1beta1.Gateway, classes sets.Set[string]) {
+	if classes.Len() == 0 {
+		return
+	}
+	infrastructure := &gatewayv1.GatewayInfrastructure{}
+	if gateway.Spec.Infrastructure != nil {
+		infrastructure = gateway.Spec.Infrastructure.DeepCopy()
+	}
+	if infrastructure.Annotations == nil {
+		infrastructure.Annotations = map[gatewayv1.AnnotationKey]gatewayv1.AnnotationValue{}
+	}
+	infrastructure.Annotations[IngressClassAnnotation] = gatewayv1.AnnotationValue(strings.Join(sets.List(classes), ","))
+	gateway.Spec.Infrastructure = infrastructure
+}
+
+// ingressClasses returns the classes of the IngressClassAnnotation of the
+// infrastructure of the Gateway.
+func ingressClasses(gateway *gatewayv1beta1.Gateway) sets.Set[string] {
+	classes := sets.New[string]()
+	if gateway.Spec.Infrastructure == nil {
+		return classes
+	}
+	value, ok := gateway.Spec.Infrastructure.Annotations[IngressClassAnnotation]
+	if !ok {
+		return classes
+	}
+	for _, class := range strings.Split(string(value), ",") {
+		if class != "" {
+			classes.Insert(class)
+		}
+	}
+	return classes
+}
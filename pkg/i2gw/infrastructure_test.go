@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_recordIngressClasses(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(namespace, name, class string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr(class),
+				Rules: []networkingv1.IngressRule{{
+					Host: name + ".example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	ingresses := []networkingv1.Ingress{
+		newIngress("test", "web", "nginx"),
+		newIngress("test", "api", "haproxy"),
+		newIngress("prod", "shop", "nginx"),
+	}
+	unclassified := newIngress("test", "blog", "")
+	unclassified.Spec.IngressClassName = nil
+
+	testCases := []struct {
+		name      string
+		ingresses []networkingv1.Ingress
+		opts      Options
+		expected  map[string]string
+	}{{
+		name:      "Gateways of the Ingress classes",
+		ingresses: ingresses,
+		opts:      Options{RecordIngressClasses: true},
+		expected: map[string]string{
+			"prod/nginx":   "nginx",
+			"test/haproxy": "haproxy",
+			"test/nginx":   "nginx",
+		},
+	}, {
+		name:      "Gateway named by a template",
+		ingresses: ingresses,
+		opts:      Options{RecordIngressClasses: true, GatewayNameTemplate: "gateway-{namespace}-{class}"},
+		expected: map[string]string{
+			"prod/gateway-prod-nginx":   "nginx",
+			"test/gateway-test-haproxy": "haproxy",
+			"test/gateway-test-nginx":   "nginx",
+		},
+	}, {
+		name:      "shared Gateway",
+		ingresses: []networkingv1.Ingress{ingresses[0], ingresses[2]},
+		opts:      Options{RecordIngressClasses: true, SharedGatewayNamespace: "gateways"},
+		expected: map[string]string{
+			"gateways/nginx": "nginx",
+		},
+	}, {
+		name:      "Ingress without class",
+		ingresses: []networkingv1.Ingress{unclassified},
+		opts:      Options{RecordIngressClasses: true},
+		expected: map[string]string{
+			"test/blog": "",
+		},
+	}, {
+		name:      "not recorded",
+		ingresses: ingresses,
+		expected: map[string]string{
+			"prod/nginx":   "",
+			"test/haproxy": "",
+			"test/nginx":   "",
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, tc.opts)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			classes := map[string]string{}
+			for _, gateway := range result.Gateways {
+				var class string
+				if gateway.Spec.Infrastructure != nil {
+					class = string(gateway.Spec.Infrastructure.Annotations[IngressClassAnnotation])
+				}
+				classes[gateway.Namespace+"/"+gateway.Name] = class
+			}
+			if diff := cmp.Diff(tc.expected, classes); diff != "" {
+				t.Errorf("Unexpected Ingress classes of the Gateways (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,100 +17,294 @@ limitations under the License.
 package i2gw
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"os"
+	"sort"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
-	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
-func ConstructIngressesFromCluster(cl client.Client, ingressList *networkingv1.IngressList) error {
-	err := cl.List(context.Background(), ingressList)
-	if err != nil {
-		return fmt.Errorf("failed to get ingresses from the cluster: %w", err)
+// ingressListPageSize is the number of Ingresses listed from the cluster per
+// request, so that listing the Ingresses of large clusters stays within the
+// limits of the API server.
+const ingressListPageSize = 500
+
+// ConstructIngressesFromCluster lists the Ingresses of the cluster, and pushes
+// them into the supplied IngressList. The Ingresses are listed by pages of
+// ingressListPageSize, following the continue token of every page.
+func ConstructIngressesFromCluster(ctx context.Context, cl client.Client, ingressList *networkingv1.IngressList) error {
+	var items []networkingv1.Ingress
+	var continueToken string
+	for {
+		var page networkingv1.IngressList
+		err := cl.List(ctx, &page, client.Limit(ingressListPageSize), client.Continue(continueToken))
+		if err != nil {
+			return fmt.Errorf("failed to get ingresses from the cluster: %w", err)
+		}
+		items = append(items, page.Items...)
+		continueToken = page.Continue
+		if continueToken == "" {
+			page.ListMeta.DeepCopyInto(&ingressList.ListMeta)
+			break
+		}
+	}
+	ingressList.Items = items
+	for i := range ingressList.Items {
+		normalizeIngressClass(&ingressList.Items[i])
 	}
 	return nil
 }
 
-func Ingresses2GatewaysAndHTTPRoutes(ingresses []networkingv1.Ingress) ([]gatewayv1beta1.HTTPRoute, []gatewayv1beta1.Gateway, field.ErrorList) {
-	aggregator := ingressAggregator{ruleGroups: map[ruleGroupKey]*ingressRuleGroup{}}
+// ConstructConfigMapsFromCluster fetches the TCP services and custom headers
+// ConfigMaps referenced by the given Ingresses, and pushes them into the
+// supplied ConfigMapList.
+func ConstructConfigMapsFromCluster(ctx context.Context, cl client.Client, ingresses []networkingv1.Ingress, l *corev1.ConfigMapList) error {
+	seen := map[types.NamespacedName]bool{}
+	get := func(ref types.NamespacedName, kind string) error {
+		if seen[ref] {
+			return nil
+		}
+		seen[ref] = true
+		var cm corev1.ConfigMap
+		if err := cl.Get(ctx, ref, &cm); err != nil {
+			return fmt.Errorf("failed to get %s ConfigMap %s from the cluster: %w", kind, ref, err)
+		}
+		l.Items = append(l.Items, cm)
+		return nil
+	}
+	for _, ingress := range ingresses {
+		if ref, ok := TCPServicesConfigMapRef(ingress); ok {
+			if err := get(ref, "TCP services"); err != nil {
+				return err
+			}
+		}
+		if ref, ok := customHeadersConfigMapRef(ingress); ok {
+			if err := get(ref, "custom headers"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ConstructServicesFromCluster fetches the Services used as backends by the
+// given Ingresses, and pushes them into the supplied ServiceList. Services
+// that don't exist are skipped.
+func ConstructServicesFromCluster(ctx context.Context, cl client.Client, ingresses []networkingv1.Ingress, l *corev1.ServiceList) error {
+	seen := map[types.NamespacedName]bool{}
+	for _, ingress := range ingresses {
+		for _, ref := range backendServices(ingress) {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			var svc corev1.Service
+			if err := cl.Get(ctx, ref, &svc); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return fmt.Errorf("failed to get Service %s from the cluster: %w", ref, err)
+			}
+			l.Items = append(l.Items, svc)
+		}
+	}
+	return nil
+}
+
+// backendServices returns the Services referenced as backends by the Ingress.
+func backendServices(ingress networkingv1.Ingress) []types.NamespacedName {
+	var refs []types.NamespacedName
+	addBackend := func(backend networkingv1.IngressBackend) {
+		if backend.Service != nil {
+			refs = append(refs, types.NamespacedName{Namespace: ingress.Namespace, Name: backend.Service.Name})
+		}
+	}
+	if ingress.Spec.DefaultBackend != nil {
+		addBackend(*ingress.Spec.DefaultBackend)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			addBackend(path.Backend)
+		}
+	}
+	return refs
+}
+
+// Ingresses2GatewaysAndHTTPRoutes converts the given Ingresses into HTTPRoutes,
+// GRPCRoutes, TCPRoutes and Gateways. Ingresses with gRPC backends are
+// converted to GRPCRoutes. The ConfigMaps are used to resolve TCP services
+// referenced by the Ingresses, and the Services to resolve named backend
+// ports. Configuration that can't be faithfully converted is reported through
+// the returned warnings, which don't prevent the conversion. Ingress
+// annotations matching the preservedAnnotations allowlist are copied onto the
+// generated Gateways and HTTPRoutes. Paths of a host routed to different
+// backends by several rules are reported as errors, or as warnings when
+// allowConflicts is set. The annotations of the providers detected on every
+// Ingress are converted.
+func Ingresses2GatewaysAndHTTPRoutes(ingresses []networkingv1.Ingress, configMaps []corev1.ConfigMap, services []corev1.Service, preservedAnnotations []string, allowConflicts bool) ([]gatewayv1beta1.HTTPRoute, []gatewayv1.GRPCRoute, []gatewayv1alpha2.TCPRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	return ingresses2GatewaysAndHTTPRoutes(ingresses, Options{
+		ConfigMaps:          configMaps,
+		Services:            services,
+		PreserveAnnotations: preservedAnnotations,
+		AllowConflicts:      allowConflicts,
+	})
+}
+
+// ingresses2GatewaysAndHTTPRoutes implements Ingresses2GatewaysAndHTTPRoutes
+// with the conversion options. Unlike Convert, opts.PreserveAnnotations is the
+// complete allowlist of preserved annotations.
+func ingresses2GatewaysAndHTTPRoutes(ingresses []networkingv1.Ingress, opts Options) ([]gatewayv1beta1.HTTPRoute, []gatewayv1.GRPCRoute, []gatewayv1alpha2.TCPRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	result, errs := convertIngresses(ingresses, opts)
+	return result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.Gateways, result.Warnings, errs
+}
+
+// convertIngresses converts the Ingresses into all the generated resources,
+// as ingresses2GatewaysAndHTTPRoutes does.
+func convertIngresses(ingresses []networkingv1.Ingress, opts Options) (Result, field.ErrorList) {
+	aggregator := ingressAggregator{
+		ruleGroups:  map[ruleGroupKey]*ingressRuleGroup{},
+		tcpServices: map[string]tcpServices{},
+		configMaps:  configMapsByName(opts.ConfigMaps),
+		services:    servicesByName(opts.Services),
+
+		preservedAnnotations: opts.PreserveAnnotations,
+		allowConflicts:       opts.AllowConflicts,
+		providers:            opts.Providers,
+		apiV1:                opts.APIVersion == APIVersionV1,
+		nameTemplate:         opts.NameTemplate,
+		targetImplementation: opts.TargetImplementation,
+		backendKind:          backendKind{group: opts.BackendRefGroup, kind: opts.BackendRefKind},
+
+		annotateSourceIngresses: opts.AnnotateSourceIngresses,
+		annotationPrefix:        opts.AnnotationPrefix,
+		mergeCertificateRefs:    opts.MergeCertificateRefs,
+		recordIngressClasses:    opts.RecordIngressClasses,
+	}
 
 	var errs field.ErrorList
+	switch {
+	case opts.SharedGatewayNamespace != "":
+		aggregator.namespaceGateways, errs = sharedGatewayNames(opts.SharedGatewayNamespace, ingresses)
+	case opts.SingleGatewayPerNamespace:
+		aggregator.namespaceGateways, errs = namespaceGatewayNames(ingresses)
+	}
+	if len(errs) > 0 {
+		return Result{}, errs
+	}
 	for _, ingress := range ingresses {
-		errs = append(errs, aggregator.addIngress(ingress)...)
+		klog.V(2).Infof("Converting Ingress %s/%s", ingress.Namespace, ingress.Name)
+		ingressErrs := aggregator.addIngress(ingress)
+		if len(ingressErrs) > 0 {
+			klog.V(2).Infof("Ingress %s/%s failed to be converted: %v", ingress.Namespace, ingress.Name, ingressErrs.ToAggregate())
+		}
+		errs = append(errs, ingressErrs...)
 	}
 	if len(errs) > 0 {
-		return nil, nil, errs
+		return Result{}, errs
 	}
 
-	return aggregator.toHTTPRoutesAndGateways()
+	httpRoutes, grpcRoutes, gateways, warnings, errs := aggregator.toHTTPRoutesAndGateways()
+	tcpRoutes, gateways, tcpWarnings, tcpErrs := aggregator.toTCPRoutes(gateways)
+	tlsRoutes, gateways, tlsWarnings, tlsErrs := aggregator.toTLSRoutes(gateways)
+	backendTLSPolicies, policyWarnings := aggregator.toBackendTLSPolicies()
+	policies, targetWarnings := aggregator.toPolicies(httpRoutes, grpcRoutes)
+	aggregator.annotateGateways(gateways)
+	result := Result{
+		HTTPRoutes:         httpRoutes,
+		GRPCRoutes:         grpcRoutes,
+		TCPRoutes:          tcpRoutes,
+		TLSRoutes:          tlsRoutes,
+		Gateways:           gateways,
+		BackendTLSPolicies: backendTLSPolicies,
+		Policies:           policies,
+		Warnings:           append(append(append(append(warnings, tcpWarnings...), tlsWarnings...), policyWarnings...), targetWarnings...),
+	}
+	aggregator.splitHTTPRoutes(opts.RouteGranularity, &result)
+	sharedErrs := aggregator.shareGateways(opts.SharedGatewayNamespace, &result)
+	result.Warnings = append(result.Warnings, setGatewayNames(opts.GatewayNameTemplate, &result)...)
+	return result, append(append(append(errs, tcpErrs...), tlsErrs...), sharedErrs...)
 }
 
-// extractObjectsFromReader extracts all objects from a reader,
-// which is created from YAML or JSON input files.
-// It retrieves all objects, including nested ones if they are contained within a list.
-func extractObjectsFromReader(reader io.Reader) ([]*unstructured.Unstructured, error) {
-	d := kubeyaml.NewYAMLOrJSONDecoder(reader, 4096)
-	var objs []*unstructured.Unstructured
-	for {
-		u := &unstructured.Unstructured{}
-		if err := d.Decode(&u); err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return objs, fmt.Errorf("failed to unmarshal manifest: %w", err)
+// AddLabels merges labels into the metadata labels of the given generated
+// resources. Labels already set on a resource are not overwritten.
+func AddLabels(labels map[string]string, httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, gateways []gatewayv1beta1.Gateway) {
+	if len(labels) == 0 {
+		return
+	}
+	for i := range httpRoutes {
+		mergeLabels(&httpRoutes[i], labels)
+	}
+	for i := range grpcRoutes {
+		mergeLabels(&grpcRoutes[i], labels)
+	}
+	for i := range tcpRoutes {
+		mergeLabels(&tcpRoutes[i], labels)
+	}
+	for i := range gateways {
+		mergeLabels(&gateways[i], labels)
+	}
+}
+
+// ValidateLabels returns an error when the key of a label isn't a qualified
+// name, or its value isn't a valid label value.
+func ValidateLabels(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("%q is not a valid label key: %s", key, strings.Join(errs, ", "))
 		}
-		if u == nil {
-			continue
+		if errs := validation.IsValidLabelValue(labels[key]); len(errs) > 0 {
+			return fmt.Errorf("%q is not a valid value for label %q: %s", labels[key], key, strings.Join(errs, ", "))
 		}
-		objs = append(objs, u)
 	}
+	return nil
+}
 
-	finalObjs := []*unstructured.Unstructured{}
-	for _, obj := range objs {
-		tmpObjs := []*unstructured.Unstructured{}
-		if obj.IsList() {
-			err := obj.EachListItem(func(object runtime.Object) error {
-				unstructuredObj, ok := object.(*unstructured.Unstructured)
-				if ok {
-					tmpObjs = append(tmpObjs, unstructuredObj)
-					return nil
-				}
-				return fmt.Errorf("resource list item has unexpected type")
-			})
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			tmpObjs = append(tmpObjs, obj)
+func mergeLabels(obj metav1.Object, labels map[string]string) {
+	merged := obj.GetLabels()
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	for k, v := range labels {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
 		}
-		finalObjs = append(finalObjs, tmpObjs...)
 	}
-
-	return finalObjs, nil
+	obj.SetLabels(merged)
 }
 
 // ConstructIngressesFromFile reads the inputFile in either json/yaml formats,
-// then deserialize the file into Ingresses resources.
-// All ingresses will be pushed into the supplied IngressList for return.
-func ConstructIngressesFromFile(l *networkingv1.IngressList, inputFile string, namespace string) error {
-	stream, err := os.ReadFile(inputFile)
-	if err != nil {
-		return err
-	}
-
-	reader := bytes.NewReader(stream)
-	objs, err := extractObjectsFromReader(reader)
-	if err != nil {
+// as given by format, one of the InputFormat constants,
+// then deserialize the file into Ingresses resources. Objects of other kinds
+// are skipped.
+// All ingresses will be pushed into the supplied IngressList for return. The
+// documents and Ingresses failing to be parsed are returned as ManifestErrors,
+// once the other Ingresses are pushed.
+func ConstructIngressesFromFile(l *networkingv1.IngressList, inputFile string, namespace string, format string) error {
+	objs, err := readObjectsFromFile(inputFile, format)
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) && err != nil {
 		return err
 	}
 
@@ -123,11 +317,71 @@ func ConstructIngressesFromFile(l *networkingv1.IngressList, inputFile string, n
 			err = runtime.DefaultUnstructuredConverter.
 				FromUnstructured(f.UnstructuredContent(), &i)
 			if err != nil {
-				return err
+				manifestErrs = append(manifestErrs, fmt.Errorf("failed to parse Ingress %s/%s: %w", f.GetNamespace(), f.GetName(), err))
+				continue
 			}
+			normalizeIngressClass(&i)
 			l.Items = append(l.Items, i)
 		}
 
 	}
+	if len(manifestErrs) > 0 {
+		return manifestErrs
+	}
+	return nil
+}
+
+// ConstructConfigMapsFromFile reads the inputFile in either json/yaml formats,
+// then deserialize the ConfigMaps it contains. ConfigMaps are not filtered by
+// namespace, as Ingresses may reference ConfigMaps of the controller namespace.
+// All ConfigMaps will be pushed into the supplied ConfigMapList for return.
+// The documents failing to be parsed are skipped, they are reported when
+// reading the Ingresses.
+func ConstructConfigMapsFromFile(l *corev1.ConfigMapList, inputFile string, format string) error {
+	objs, err := readObjectsFromFile(inputFile, format)
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) && err != nil {
+		return err
+	}
+
+	for _, f := range objs {
+		if f.GroupVersionKind().Kind != "ConfigMap" {
+			continue
+		}
+		var cm corev1.ConfigMap
+		err = runtime.DefaultUnstructuredConverter.
+			FromUnstructured(f.UnstructuredContent(), &cm)
+		if err != nil {
+			return err
+		}
+		l.Items = append(l.Items, cm)
+	}
+	return nil
+}
+
+// ConstructServicesFromFile reads the inputFile in either json/yaml formats,
+// then deserialize the Services it contains.
+// All Services will be pushed into the supplied ServiceList for return.
+// The documents failing to be parsed are skipped, they are reported when
+// reading the Ingresses.
+func ConstructServicesFromFile(l *corev1.ServiceList, inputFile string, format string) error {
+	objs, err := readObjectsFromFile(inputFile, format)
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) && err != nil {
+		return err
+	}
+
+	for _, f := range objs {
+		if f.GroupVersionKind().Kind != "Service" {
+			continue
+		}
+		var svc corev1.Service
+		err = runtime.DefaultUnstructuredConverter.
+			FromUnstructured(f.UnstructuredContent(), &svc)
+		if err != nil {
+			return err
+		}
+		l.Items = append(l.Items, svc)
+	}
 	return nil
 }
@@ -17,12 +17,21 @@ limitations under the License.
 package i2gw
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	networkingv1 "k8s.io/api/networking/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 func Test_constructIngressesFromFile(t *testing.T) {
@@ -141,7 +150,7 @@ func Test_constructIngressesFromFile(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			gotIngressList := &networkingv1.IngressList{}
-			err := ConstructIngressesFromFile(gotIngressList, tc.filePath, tc.namespace)
+			err := ConstructIngressesFromFile(gotIngressList, tc.filePath, tc.namespace, "")
 			if err != nil {
 				t.Errorf("Failed to open test file: %v", err)
 			}
@@ -150,6 +159,57 @@ func Test_constructIngressesFromFile(t *testing.T) {
 	}
 }
 
+func Test_AddLabels(t *testing.T) {
+	gateways := []gatewayv1beta1.Gateway{{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Labels: map[string]string{"team": "web"}},
+	}}
+	httpRoutes := []gatewayv1beta1.HTTPRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+	}}
+
+	AddLabels(map[string]string{"app.kubernetes.io/managed-by": "ingress2gateway", "team": "platform"}, httpRoutes, nil, nil, gateways)
+
+	wantGatewayLabels := map[string]string{"app.kubernetes.io/managed-by": "ingress2gateway", "team": "web"}
+	if diff := cmp.Diff(wantGatewayLabels, gateways[0].Labels); diff != "" {
+		t.Errorf("Unexpected Gateway labels (-want +got):\n%s", diff)
+	}
+	wantHTTPRouteLabels := map[string]string{"app.kubernetes.io/managed-by": "ingress2gateway", "team": "platform"}
+	if diff := cmp.Diff(wantHTTPRouteLabels, httpRoutes[0].Labels); diff != "" {
+		t.Errorf("Unexpected HTTPRoute labels (-want +got):\n%s", diff)
+	}
+}
+
+func Test_ValidateLabels(t *testing.T) {
+	testCases := []struct {
+		name          string
+		labels        map[string]string
+		expectedError bool
+	}{{
+		name:   "qualified key and valid value",
+		labels: map[string]string{"app.kubernetes.io/managed-by": "ingress2gateway", "team": ""},
+	}, {
+		name:          "invalid key",
+		labels:        map[string]string{"team/web/api": "platform"},
+		expectedError: true,
+	}, {
+		name:          "invalid value",
+		labels:        map[string]string{"team": "web platform"},
+		expectedError: true,
+	}, {
+		name:          "value too long",
+		labels:        map[string]string{"team": strings.Repeat("a", 64)},
+		expectedError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidateLabels(tc.labels); (err != nil) != tc.expectedError {
+				t.Errorf("ValidateLabels() = %v, expected error %t", err, tc.expectedError)
+			}
+		})
+	}
+}
+
 func compareIngressLists(t *testing.T, gotIngressList *networkingv1.IngressList, wantIngressList []networkingv1.Ingress) {
 	for i, got := range gotIngressList.Items {
 		want := wantIngressList[i]
@@ -158,3 +218,60 @@ func compareIngressLists(t *testing.T, gotIngressList *networkingv1.IngressList,
 		}
 	}
 }
+
+func Test_constructIngressesFromClusterPages(t *testing.T) {
+	var objs []client.Object
+	var expected []string
+	for i := 0; i < 2*ingressListPageSize+1; i++ {
+		name := fmt.Sprintf("ingress-%04d", i)
+		objs = append(objs, &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"}})
+		expected = append(expected, name)
+	}
+	// The fake client doesn't paginate, the pages are served by the
+	// interceptor, along with the continue token of the next page.
+	var requests int
+	cl := interceptor.NewClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build(), interceptor.Funcs{
+		List: func(ctx context.Context, cl client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			requests++
+			listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+			if listOpts.Limit != ingressListPageSize {
+				return fmt.Errorf("expected a limit of %d, got %d", ingressListPageSize, listOpts.Limit)
+			}
+			var all networkingv1.IngressList
+			if err := cl.List(ctx, &all); err != nil {
+				return err
+			}
+			start := 0
+			if listOpts.Continue != "" {
+				start, _ = strconv.Atoi(listOpts.Continue)
+			}
+			end := start + int(listOpts.Limit)
+			page := list.(*networkingv1.IngressList)
+			if end < len(all.Items) {
+				page.Continue = strconv.Itoa(end)
+			} else {
+				end = len(all.Items)
+			}
+			page.Items = all.Items[start:end]
+			return nil
+		},
+	})
+
+	var ingressList networkingv1.IngressList
+	if err := ConstructIngressesFromCluster(context.Background(), cl, &ingressList); err != nil {
+		t.Fatalf("ConstructIngressesFromCluster() error = %v", err)
+	}
+	var names []string
+	for _, ingress := range ingressList.Items {
+		names = append(names, ingress.Name)
+	}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("Unexpected Ingresses (-want +got):\n%s", diff)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 pages to be listed, got %d", requests)
+	}
+	if ingressList.Continue != "" {
+		t.Errorf("Expected no continue token, got %q", ingressList.Continue)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// IngressError holds the errors of an Ingress that failed to be converted,
+// and was left out of a conversion continuing on errors.
+type IngressError struct {
+	Ingress types.NamespacedName
+	Errors  field.ErrorList
+}
+
+func (e IngressError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Ingress, e.Errors.ToAggregate())
+}
+
+// convertIngressesContinuingOnError converts the Ingresses, leaving out the
+// Ingresses with conversion errors, which are returned as IngressErrors of
+// the Result. Errors that can't be attributed to an Ingress are returned, in
+// which case the Result is empty.
+func convertIngressesContinuingOnError(ingresses []networkingv1.Ingress, opts Options) (Result, field.ErrorList) {
+	var ingressErrs []IngressError
+	for {
+		result, errs := convertIngresses(ingresses, opts)
+		if len(errs) == 0 {
+			sortIngressErrors(ingressErrs)
+			result.IngressErrors = ingressErrs
+			return result, nil
+		}
+		failed := attributeErrors(ingresses, opts, errs)
+		if len(failed) == 0 {
+			return Result{}, errs
+		}
+		ingressErrs = append(ingressErrs, failed...)
+
+		skipped := map[types.NamespacedName]bool{}
+		for _, f := range failed {
+			skipped[f.Ingress] = true
+		}
+		var remaining []networkingv1.Ingress
+		for _, ingress := range ingresses {
+			if !skipped[types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}] {
+				remaining = append(remaining, ingress)
+			}
+		}
+		ingresses = remaining
+	}
+}
+
+// attributeErrors returns the errors of the Ingresses failing to be
+// converted. The field paths of the errors start with the name of their
+// Ingress, and resources are only generated from the Ingresses of a
+// namespace, so the errors are attributed by converting the Ingresses of
+// every namespace on their own. The errors of Ingresses of several
+// namespaces, such as TCP services conflicting on a port, are attributed to
+// the Ingress of their name when it is unique.
+func attributeErrors(ingresses []networkingv1.Ingress, opts Options, errs field.ErrorList) []IngressError {
+	ingressesByNamespace := map[string][]networkingv1.Ingress{}
+	for _, ingress := range ingresses {
+		ingressesByNamespace[ingress.Namespace] = append(ingressesByNamespace[ingress.Namespace], ingress)
+	}
+
+	errsByIngress := map[types.NamespacedName]field.ErrorList{}
+	for _, nsIngresses := range ingressesByNamespace {
+		_, nsErrs := convertIngresses(nsIngresses, opts)
+		for _, err := range nsErrs {
+			if ingress, ok := ingressOfError(nsIngresses, err); ok {
+				errsByIngress[ingress] = append(errsByIngress[ingress], err)
+			}
+		}
+	}
+	if len(errsByIngress) == 0 {
+		for _, err := range errs {
+			if ingress, ok := ingressOfError(ingresses, err); ok {
+				errsByIngress[ingress] = append(errsByIngress[ingress], err)
+			}
+		}
+	}
+
+	var failed []IngressError
+	for ingress, ingressErrs := range errsByIngress {
+		failed = append(failed, IngressError{Ingress: ingress, Errors: ingressErrs})
+	}
+	return failed
+}
+
+// sortIngressErrors sorts the errors by Ingress.
+func sortIngressErrors(ingressErrs []IngressError) {
+	sort.Slice(ingressErrs, func(i, j int) bool {
+		return ingressErrs[i].Ingress.String() < ingressErrs[j].Ingress.String()
+	})
+}
+
+// ingressOfError returns the Ingress whose name starts the field path of the
+// error, the longest one as Ingress names may contain dots. It returns false
+// when no Ingress, or several Ingresses of different namespaces, have that
+// name.
+func ingressOfError(ingresses []networkingv1.Ingress, err *field.Error) (types.NamespacedName, bool) {
+	var match types.NamespacedName
+	ambiguous := false
+	for _, ingress := range ingresses {
+		name := ingress.Name
+		if err.Field != name && !strings.HasPrefix(err.Field, name+".") && !strings.HasPrefix(err.Field, name+"[") {
+			continue
+		}
+		switch {
+		case len(name) > len(match.Name):
+			match = types.NamespacedName{Namespace: ingress.Namespace, Name: name}
+			ambiguous = false
+		case name == match.Name:
+			ambiguous = true
+		}
+	}
+	return match, match.Name != "" && !ambiguous
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_ConvertContinueOnError(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(namespace, name, host string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	invalidPath := func(ingress networkingv1.Ingress) networkingv1.Ingress {
+		ingress.Spec.Rules[0].HTTP.Paths[0].Path = "relative"
+		return ingress
+	}
+
+	testCases := []struct {
+		name                  string
+		ingresses             []networkingv1.Ingress
+		expectedRoutes        []string
+		expectedFailedIngress []types.NamespacedName
+	}{{
+		name:           "no errors",
+		ingresses:      []networkingv1.Ingress{newIngress("test", "app", "example.com")},
+		expectedRoutes: []string{"test/example-com"},
+	}, {
+		name: "invalid host",
+		ingresses: []networkingv1.Ingress{
+			newIngress("test", "app", "example.com"),
+			newIngress("test", "ip", "10.0.0.1"),
+		},
+		expectedRoutes:        []string{"test/example-com"},
+		expectedFailedIngress: []types.NamespacedName{{Namespace: "test", Name: "ip"}},
+	}, {
+		name: "invalid path of an Ingress named as Ingresses of other namespaces",
+		ingresses: []networkingv1.Ingress{
+			newIngress("prod", "app", "example.com"),
+			invalidPath(newIngress("staging", "app", "staging.example.com")),
+			newIngress("staging", "api", "api.staging.example.com"),
+		},
+		expectedRoutes:        []string{"prod/example-com", "staging/api-staging-example-com"},
+		expectedFailedIngress: []types.NamespacedName{{Namespace: "staging", Name: "app"}},
+	}, {
+		name: "all Ingresses failing",
+		ingresses: []networkingv1.Ingress{
+			newIngress("test", "ip", "10.0.0.1"),
+			invalidPath(newIngress("test", "app", "example.com")),
+		},
+		expectedFailedIngress: []types.NamespacedName{{Namespace: "test", Name: "app"}, {Namespace: "test", Name: "ip"}},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, Options{ContinueOnError: true})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var routes []string
+			for _, route := range result.HTTPRoutes {
+				routes = append(routes, route.Namespace+"/"+route.Name)
+			}
+			if diff := cmp.Diff(tc.expectedRoutes, routes); diff != "" {
+				t.Errorf("Unexpected HTTPRoutes (-want +got):\n%s", diff)
+			}
+			var failed []types.NamespacedName
+			for _, ingressErr := range result.IngressErrors {
+				if len(ingressErr.Errors) == 0 {
+					t.Errorf("Expected errors for Ingress %s", ingressErr.Ingress)
+				}
+				failed = append(failed, ingressErr.Ingress)
+			}
+			if diff := cmp.Diff(tc.expectedFailedIngress, failed); diff != "" {
+				t.Errorf("Unexpected failed Ingresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("errors without the option", func(t *testing.T) {
+		result, err := Convert([]networkingv1.Ingress{newIngress("test", "app", "example.com"), newIngress("test", "ip", "10.0.0.1")}, Options{})
+		if err == nil {
+			t.Fatalf("Expected an error, got %+v", result)
+		}
+	})
+}
+
+func Test_ingressOfError(t *testing.T) {
+	ingresses := []networkingv1.Ingress{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "prod"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app.v2", Namespace: "prod"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "staging"}},
+	}
+	testCases := []struct {
+		name            string
+		path            *field.Path
+		expectedIngress types.NamespacedName
+		expectedOk      bool
+	}{{
+		name:            "Ingress name",
+		path:            field.NewPath("app", "spec", "rules").Index(0).Child("host"),
+		expectedIngress: types.NamespacedName{Namespace: "prod", Name: "app"},
+		expectedOk:      true,
+	}, {
+		name:            "longest name with dots",
+		path:            field.NewPath("app.v2", "metadata", "annotations").Key("nginx.ingress.kubernetes.io/canary-weight"),
+		expectedIngress: types.NamespacedName{Namespace: "prod", Name: "app.v2"},
+		expectedOk:      true,
+	}, {
+		name: "name of several namespaces",
+		path: field.NewPath("api", "spec"),
+	}, {
+		name: "unknown name",
+		path: field.NewPath("Gateway", "prod/nginx"),
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress, ok := ingressOfError(ingresses, field.Invalid(tc.path, "", "invalid"))
+			if ok != tc.expectedOk {
+				t.Fatalf("Expected ok: %v, got %v for %v", tc.expectedOk, ok, ingress)
+			}
+			if ok && ingress != tc.expectedIngress {
+				t.Errorf("Expected Ingress %s, got %s", tc.expectedIngress, ingress)
+			}
+		})
+	}
+}
@@ -0,0 +1,1047 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// istioGatewayClass is the class of the Gateways generated from Istio
+// Gateways.
+const istioGatewayClass = "istio"
+
+// istioMeshGateway is the reserved gateway name of the VirtualServices
+// routing the traffic of the sidecars.
+const istioMeshGateway = "mesh"
+
+var (
+	istioGatewayGVK = schema.GroupVersionKind{
+		Group:   "networking.istio.io",
+		Version: "v1beta1",
+		Kind:    "Gateway",
+	}
+	istioVirtualServiceGVK = schema.GroupVersionKind{
+		Group:   "networking.istio.io",
+		Version: "v1beta1",
+		Kind:    "VirtualService",
+	}
+)
+
+// IstioGateway is the subset of the Istio networking.istio.io Gateway
+// resource read by the conversion.
+type IstioGateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              IstioGatewaySpec `json:"spec,omitempty"`
+}
+
+// IstioGatewaySpec is the spec of an Istio Gateway.
+type IstioGatewaySpec struct {
+	Selector map[string]string `json:"selector,omitempty"`
+	Servers  []IstioServer     `json:"servers,omitempty"`
+}
+
+// IstioServer is a port exposed by an Istio Gateway for a set of hosts.
+type IstioServer struct {
+	Port  IstioPort       `json:"port"`
+	Hosts []string        `json:"hosts,omitempty"`
+	TLS   *IstioServerTLS `json:"tls,omitempty"`
+	Name  string          `json:"name,omitempty"`
+}
+
+// IstioPort is the port of an IstioServer.
+type IstioPort struct {
+	Number   uint32 `json:"number"`
+	Protocol string `json:"protocol"`
+	Name     string `json:"name,omitempty"`
+}
+
+// IstioServerTLS is the TLS configuration of an IstioServer.
+type IstioServerTLS struct {
+	HTTPSRedirect     bool   `json:"httpsRedirect,omitempty"`
+	Mode              string `json:"mode,omitempty"`
+	CredentialName    string `json:"credentialName,omitempty"`
+	ServerCertificate string `json:"serverCertificate,omitempty"`
+}
+
+// IstioVirtualService is the subset of the Istio networking.istio.io
+// VirtualService resource read by the conversion. Fields of features that
+// can't be converted are only read to report them.
+type IstioVirtualService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              IstioVirtualServiceSpec `json:"spec,omitempty"`
+}
+
+// IstioVirtualServiceSpec is the spec of a VirtualService.
+type IstioVirtualServiceSpec struct {
+	Hosts    []string         `json:"hosts,omitempty"`
+	Gateways []string         `json:"gateways,omitempty"`
+	HTTP     []IstioHTTPRoute `json:"http,omitempty"`
+	TLS      []interface{}    `json:"tls,omitempty"`
+	TCP      []interface{}    `json:"tcp,omitempty"`
+}
+
+// IstioHTTPRoute routes the requests matching any of its matches.
+type IstioHTTPRoute struct {
+	Name             string                      `json:"name,omitempty"`
+	Match            []IstioHTTPMatchRequest     `json:"match,omitempty"`
+	Route            []IstioHTTPRouteDestination `json:"route,omitempty"`
+	Redirect         *IstioHTTPRedirect          `json:"redirect,omitempty"`
+	Rewrite          *IstioHTTPRewrite           `json:"rewrite,omitempty"`
+	Timeout          string                      `json:"timeout,omitempty"`
+	Headers          *IstioHeaders               `json:"headers,omitempty"`
+	Mirror           *IstioDestination           `json:"mirror,omitempty"`
+	MirrorPercentage map[string]interface{}      `json:"mirrorPercentage,omitempty"`
+	Mirrors          []IstioHTTPMirrorPolicy     `json:"mirrors,omitempty"`
+
+	Delegate       map[string]interface{} `json:"delegate,omitempty"`
+	DirectResponse map[string]interface{} `json:"directResponse,omitempty"`
+	Fault          map[string]interface{} `json:"fault,omitempty"`
+	Retries        map[string]interface{} `json:"retries,omitempty"`
+	CorsPolicy     map[string]interface{} `json:"corsPolicy,omitempty"`
+}
+
+// IstioHTTPMatchRequest holds the conditions of a match of an
+// IstioHTTPRoute.
+type IstioHTTPMatchRequest struct {
+	URI         *IstioStringMatch           `json:"uri,omitempty"`
+	Method      *IstioStringMatch           `json:"method,omitempty"`
+	Headers     map[string]IstioStringMatch `json:"headers,omitempty"`
+	QueryParams map[string]IstioStringMatch `json:"queryParams,omitempty"`
+
+	Authority       *IstioStringMatch           `json:"authority,omitempty"`
+	Scheme          *IstioStringMatch           `json:"scheme,omitempty"`
+	Port            uint32                      `json:"port,omitempty"`
+	IgnoreURICase   bool                        `json:"ignoreUriCase,omitempty"`
+	WithoutHeaders  map[string]IstioStringMatch `json:"withoutHeaders,omitempty"`
+	SourceLabels    map[string]string           `json:"sourceLabels,omitempty"`
+	SourceNamespace string                      `json:"sourceNamespace,omitempty"`
+	Gateways        []string                    `json:"gateways,omitempty"`
+}
+
+// IstioStringMatch matches a string exactly, by prefix or by regex.
+type IstioStringMatch struct {
+	Exact  string `json:"exact,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+}
+
+// IstioHTTPRouteDestination is a weighted destination of an IstioHTTPRoute.
+type IstioHTTPRouteDestination struct {
+	Destination IstioDestination `json:"destination"`
+	Weight      int32            `json:"weight,omitempty"`
+	Headers     *IstioHeaders    `json:"headers,omitempty"`
+}
+
+// IstioDestination is a service of the mesh.
+type IstioDestination struct {
+	Host   string             `json:"host"`
+	Subset string             `json:"subset,omitempty"`
+	Port   *IstioPortSelector `json:"port,omitempty"`
+}
+
+// IstioPortSelector selects the port of a destination.
+type IstioPortSelector struct {
+	Number uint32 `json:"number,omitempty"`
+}
+
+// IstioHTTPMirrorPolicy mirrors the requests to a destination.
+type IstioHTTPMirrorPolicy struct {
+	Destination IstioDestination       `json:"destination"`
+	Percentage  map[string]interface{} `json:"percentage,omitempty"`
+}
+
+// IstioHTTPRedirect redirects the requests of an IstioHTTPRoute.
+type IstioHTTPRedirect struct {
+	URI          string `json:"uri,omitempty"`
+	Authority    string `json:"authority,omitempty"`
+	Port         uint32 `json:"port,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	RedirectCode int    `json:"redirectCode,omitempty"`
+
+	DerivePort string `json:"derivePort,omitempty"`
+}
+
+// IstioHTTPRewrite rewrites the requests of an IstioHTTPRoute before they
+// are forwarded.
+type IstioHTTPRewrite struct {
+	URI       string `json:"uri,omitempty"`
+	Authority string `json:"authority,omitempty"`
+
+	URIRegexRewrite map[string]interface{} `json:"uriRegexRewrite,omitempty"`
+}
+
+// IstioHeaders sets, adds and removes request and response headers.
+type IstioHeaders struct {
+	Request  *IstioHeaderOperations `json:"request,omitempty"`
+	Response *IstioHeaderOperations `json:"response,omitempty"`
+}
+
+// IstioHeaderOperations are the changes of request or response headers.
+type IstioHeaderOperations struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Add    map[string]string `json:"add,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// unsupported returns the fields of the route that can't be converted and
+// are left out.
+func (r *IstioHTTPRoute) unsupported() []string {
+	var fields []string
+	for name, set := range map[string]bool{
+		"fault":            r.Fault != nil,
+		"retries":          r.Retries != nil,
+		"corsPolicy":       r.CorsPolicy != nil,
+		"mirrorPercentage": r.MirrorPercentage != nil,
+	} {
+		if set {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// unsupported returns the conditions of the match that can't be converted.
+// Leaving them out would match more requests, so the routes with such
+// matches aren't converted.
+func (m *IstioHTTPMatchRequest) unsupported() []string {
+	var fields []string
+	for name, set := range map[string]bool{
+		"authority":       m.Authority != nil,
+		"scheme":          m.Scheme != nil,
+		"port":            m.Port != 0,
+		"ignoreUriCase":   m.IgnoreURICase,
+		"withoutHeaders":  len(m.WithoutHeaders) > 0,
+		"sourceLabels":    len(m.SourceLabels) > 0,
+		"sourceNamespace": m.SourceNamespace != "",
+		"gateways":        len(m.Gateways) > 0,
+	} {
+		if set {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ConstructIstioResourcesFromFile reads the inputFile in either json/yaml
+// formats, then deserialize the Istio Gateways and VirtualServices it
+// contains. When namespace is set, only the resources of that namespace are
+// returned. The documents failing to be parsed are returned as
+// ManifestErrors along with the resources of the other documents.
+func ConstructIstioResourcesFromFile(inputFile string, namespace string, format string) ([]IstioGateway, []IstioVirtualService, error) {
+	objs, err := readObjectsFromFile(inputFile, format)
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) && err != nil {
+		return nil, nil, err
+	}
+	gateways, virtualServices, err := istioResourcesFromUnstructured(objs, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(manifestErrs) > 0 {
+		return gateways, virtualServices, manifestErrs
+	}
+	return gateways, virtualServices, nil
+}
+
+// ConstructIstioResourcesFromCluster lists the Istio Gateways and
+// VirtualServices of the namespace, or of all namespaces when namespace is
+// empty, from the cluster.
+func ConstructIstioResourcesFromCluster(ctx context.Context, cl client.Client, namespace string) ([]IstioGateway, []IstioVirtualService, error) {
+	var objs []*unstructured.Unstructured
+	for _, gvk := range []schema.GroupVersionKind{istioGatewayGVK, istioVirtualServiceGVK} {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, nil, fmt.Errorf("failed to get Istio %ss from the cluster: %w", gvk.Kind, err)
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	}
+	return istioResourcesFromUnstructured(objs, "")
+}
+
+func istioResourcesFromUnstructured(objs []*unstructured.Unstructured, namespace string) ([]IstioGateway, []IstioVirtualService, error) {
+	var gateways []IstioGateway
+	var virtualServices []IstioVirtualService
+	for _, obj := range objs {
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		// The resources of every version of the Istio API are read, their
+		// fields read by the conversion are the same.
+		switch obj.GroupVersionKind().GroupKind() {
+		case istioGatewayGVK.GroupKind():
+			var gateway IstioGateway
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &gateway); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse Istio Gateway %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+			}
+			gateways = append(gateways, gateway)
+		case istioVirtualServiceGVK.GroupKind():
+			var vs IstioVirtualService
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &vs); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse VirtualService %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+			}
+			virtualServices = append(virtualServices, vs)
+		}
+	}
+	return gateways, virtualServices, nil
+}
+
+// IstioToGatewaysAndHTTPRoutes converts the Istio Gateways into Gateways
+// with a listener per server and host, and the HTTP routes of the
+// VirtualServices bound to gateways into HTTPRoutes attached to the Gateways
+// of the same name. Features of Istio that can't be represented in Gateway
+// API are reported through the returned warnings.
+func IstioToGatewaysAndHTTPRoutes(istioGateways []IstioGateway, virtualServices []IstioVirtualService) ([]gatewayv1beta1.HTTPRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	var warnings []Warning
+	var errs field.ErrorList
+
+	var gateways []gatewayv1beta1.Gateway
+	for _, istioGateway := range istioGateways {
+		gateway, gwWarnings, gwErrs := toIstioGateway(istioGateway)
+		warnings = append(warnings, gwWarnings...)
+		errs = append(errs, gwErrs...)
+		gateways = append(gateways, gateway)
+	}
+
+	var httpRoutes []gatewayv1beta1.HTTPRoute
+	for _, vs := range virtualServices {
+		httpRoute, ok, vsWarnings := toIstioHTTPRoute(vs)
+		warnings = append(warnings, vsWarnings...)
+		if ok {
+			httpRoutes = append(httpRoutes, httpRoute)
+		}
+	}
+	return httpRoutes, gateways, warnings, errs
+}
+
+// toIstioGateway converts the servers of the Istio Gateway into the
+// listeners of a Gateway of the same name.
+func toIstioGateway(istioGateway IstioGateway) (gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	key := types.NamespacedName{Namespace: istioGateway.Namespace, Name: istioGateway.Name}
+	var warnings []Warning
+	var errs field.ErrorList
+
+	gateway := gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: istioGateway.Namespace, Name: istioGateway.Name},
+		Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: istioGatewayClass},
+	}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	if len(istioGateway.Spec.Selector) > 0 {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   field.NewPath(istioGateway.Name, "spec", "selector"),
+			Message: "the Gateway is deployed by the implementation of its GatewayClass, the selected gateway workloads aren't reused",
+		})
+	}
+
+	names := map[gatewayv1beta1.SectionName]bool{}
+	for i, server := range istioGateway.Spec.Servers {
+		serverPath := field.NewPath(istioGateway.Name, "spec", "servers").Index(i)
+		protocol, tls, ok := toIstioListenerProtocol(server)
+		if !ok {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   serverPath,
+				Message: fmt.Sprintf("servers of protocol %s and TLS mode %s can't be converted", server.Port.Protocol, istioTLSMode(server)),
+			})
+			continue
+		}
+		if server.Port.Number < 1 || server.Port.Number > 65535 {
+			errs = append(errs, field.Invalid(serverPath.Child("port", "number"), server.Port.Number, "must be between 1 and 65535"))
+			continue
+		}
+		if tls != nil && *tls.Mode == gatewayv1.TLSModeTerminate {
+			if server.TLS.CredentialName == "" {
+				warnings = append(warnings, Warning{
+					Ingress: key,
+					Field:   serverPath.Child("tls"),
+					Message: "only TLS certificates of credentialName Secrets can be converted, the certificate files must be converted to a Secret manually",
+				})
+			} else {
+				tls.CertificateRefs = []gatewayv1beta1.SecretObjectReference{{Name: gatewayv1beta1.ObjectName(server.TLS.CredentialName)}}
+			}
+			if mode := istioTLSMode(server); strings.HasSuffix(mode, "MUTUAL") {
+				warnings = append(warnings, Warning{
+					Ingress: key,
+					Field:   serverPath.Child("tls", "mode"),
+					Message: fmt.Sprintf("the client certificate validation of the %s TLS mode is not converted and must be configured on the Gateway implementation", mode),
+				})
+			}
+		}
+		if server.TLS != nil && server.TLS.HTTPSRedirect {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   serverPath.Child("tls", "httpsRedirect"),
+				Message: "the redirect to HTTPS is not converted, it requires an HTTPRoute with a RequestRedirect filter attached to the listeners of the server",
+			})
+		}
+
+		for j, host := range server.Hosts {
+			listener := gatewayv1beta1.Listener{
+				Port:     gatewayv1.PortNumber(server.Port.Number),
+				Protocol: protocol,
+				TLS:      tls,
+			}
+			namespace, hostname := splitIstioHost(host)
+			switch namespace {
+			case "*":
+				all := gatewayv1.NamespacesFromAll
+				listener.AllowedRoutes = &gatewayv1beta1.AllowedRoutes{Namespaces: &gatewayv1beta1.RouteNamespaces{From: &all}}
+			case ".", istioGateway.Namespace:
+			default:
+				selector := gatewayv1.NamespacesFromSelector
+				listener.AllowedRoutes = &gatewayv1beta1.AllowedRoutes{Namespaces: &gatewayv1beta1.RouteNamespaces{
+					From:     &selector,
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace}},
+				}}
+			}
+			if hostname != "*" {
+				h := gatewayv1beta1.Hostname(hostname)
+				listener.Hostname = &h
+			}
+			listener.Name = istioListenerName(listener)
+			if names[listener.Name] {
+				warnings = append(warnings, Warning{
+					Ingress: key,
+					Field:   serverPath.Child("hosts").Index(j),
+					Message: fmt.Sprintf("the host is already exposed on port %d, it isn't converted again", server.Port.Number),
+				})
+				continue
+			}
+			names[listener.Name] = true
+			gateway.Spec.Listeners = append(gateway.Spec.Listeners, listener)
+		}
+	}
+	return gateway, warnings, errs
+}
+
+// toIstioListenerProtocol returns the protocol and TLS configuration of the
+// listeners of the server. It returns false for the servers that can't be
+// converted.
+func toIstioListenerProtocol(server IstioServer) (gatewayv1.ProtocolType, *gatewayv1beta1.GatewayTLSConfig, bool) {
+	mode := istioTLSMode(server)
+	terminate := gatewayv1.TLSModeTerminate
+	passthrough := gatewayv1.TLSModePassthrough
+	switch strings.ToUpper(server.Port.Protocol) {
+	case "HTTP", "HTTP2", "GRPC":
+		return gatewayv1.HTTPProtocolType, nil, true
+	case "HTTPS":
+		switch mode {
+		case "SIMPLE", "MUTUAL", "OPTIONAL_MUTUAL", "ISTIO_MUTUAL":
+			return gatewayv1.HTTPSProtocolType, &gatewayv1beta1.GatewayTLSConfig{Mode: &terminate}, true
+		case "PASSTHROUGH":
+			return gatewayv1.TLSProtocolType, &gatewayv1beta1.GatewayTLSConfig{Mode: &passthrough}, true
+		}
+	case "TLS":
+		switch mode {
+		case "SIMPLE", "MUTUAL", "OPTIONAL_MUTUAL", "ISTIO_MUTUAL":
+			return gatewayv1.TLSProtocolType, &gatewayv1beta1.GatewayTLSConfig{Mode: &terminate}, true
+		case "PASSTHROUGH":
+			return gatewayv1.TLSProtocolType, &gatewayv1beta1.GatewayTLSConfig{Mode: &passthrough}, true
+		}
+	case "TCP", "MONGO":
+		return gatewayv1.TCPProtocolType, nil, true
+	}
+	return "", nil, false
+}
+
+// istioTLSMode returns the TLS mode of the server, SIMPLE when it has TLS
+// settings without mode, as Istio does.
+func istioTLSMode(server IstioServer) string {
+	if server.TLS == nil {
+		return ""
+	}
+	if server.TLS.Mode == "" && !server.TLS.HTTPSRedirect {
+		return "SIMPLE"
+	}
+	return strings.ToUpper(server.TLS.Mode)
+}
+
+// splitIstioHost splits a host of a server into the namespace of the
+// VirtualServices allowed to bind to it, "." when unset as Istio does, and
+// the hostname.
+func splitIstioHost(host string) (string, string) {
+	if namespace, hostname, ok := strings.Cut(host, "/"); ok {
+		return namespace, hostname
+	}
+	return ".", host
+}
+
+// istioListenerName returns the name of the listener, after its hostname
+// and protocol, with its port unless it is the default port of its
+// protocol.
+func istioListenerName(listener gatewayv1beta1.Listener) gatewayv1beta1.SectionName {
+	name := listenerNamePrefix(listener.Hostname)
+	if name == "" {
+		name = "wildcard-"
+	}
+	name += strings.ToLower(string(listener.Protocol))
+	switch {
+	case listener.Protocol == gatewayv1.HTTPProtocolType && listener.Port == 80:
+	case listener.Protocol == gatewayv1.HTTPSProtocolType && listener.Port == 443:
+	default:
+		name += fmt.Sprintf("-%d", listener.Port)
+	}
+	return gatewayv1beta1.SectionName(name)
+}
+
+// toIstioHTTPRoute converts the HTTP routes of the VirtualService into the
+// rules of an HTTPRoute of the same name. It returns false when the
+// VirtualService isn't bound to any gateway.
+func toIstioHTTPRoute(vs IstioVirtualService) (gatewayv1beta1.HTTPRoute, bool, []Warning) {
+	key := types.NamespacedName{Namespace: vs.Namespace, Name: vs.Name}
+	specPath := field.NewPath(vs.Name, "spec")
+	var warnings []Warning
+
+	if len(vs.Spec.TCP) > 0 || len(vs.Spec.TLS) > 0 {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   specPath,
+			Message: "tcp and tls routes cannot be converted to an HTTPRoute and must be converted manually",
+		})
+	}
+
+	var parentRefs []gatewayv1beta1.ParentReference
+	for i, gateway := range vs.Spec.Gateways {
+		if gateway == istioMeshGateway {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   specPath.Child("gateways").Index(i),
+				Message: "the routing of the mesh sidecars is not converted",
+			})
+			continue
+		}
+		parentRef := gatewayv1beta1.ParentReference{Name: gatewayv1beta1.ObjectName(gateway)}
+		if namespace, name, ok := strings.Cut(gateway, "/"); ok {
+			parentRef.Name = gatewayv1beta1.ObjectName(name)
+			if namespace != vs.Namespace {
+				parentRef.Namespace = (*gatewayv1beta1.Namespace)(pointer.String(namespace))
+			}
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+	if len(parentRefs) == 0 || len(vs.Spec.HTTP) == 0 {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   specPath,
+			Message: "VirtualService has no HTTP routes bound to a gateway, so it isn't converted",
+		})
+		return gatewayv1beta1.HTTPRoute{}, false, warnings
+	}
+
+	httpRoute := gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: vs.Name, Namespace: vs.Namespace},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{ParentRefs: parentRefs},
+		},
+		Status: gatewayv1beta1.HTTPRouteStatus{
+			RouteStatus: gatewayv1beta1.RouteStatus{
+				Parents: []gatewayv1beta1.RouteParentStatus{},
+			},
+		},
+	}
+	httpRoute.SetGroupVersionKind(httpRouteGVK)
+	for _, host := range vs.Spec.Hosts {
+		// A wildcard host matches the hostnames of every listener.
+		if host == "*" {
+			httpRoute.Spec.Hostnames = nil
+			break
+		}
+		httpRoute.Spec.Hostnames = append(httpRoute.Spec.Hostnames, gatewayv1beta1.Hostname(host))
+	}
+
+	c := &istioConverter{vs: vs, key: key}
+	for i, route := range vs.Spec.HTTP {
+		routePath := specPath.Child("http").Index(i)
+		rule, ok := c.toHTTPRouteRule(route, routePath)
+		if !ok {
+			continue
+		}
+		if rule, ok = c.dropShadowedMatches(rule, len(route.Match) > 0, routePath); ok {
+			httpRoute.Spec.Rules = append(httpRoute.Spec.Rules, rule)
+		}
+	}
+	for i := range c.warnings {
+		c.warnings[i].HTTPRoute = key
+	}
+	return httpRoute, true, append(warnings, c.warnings...)
+}
+
+// istioConverter converts the HTTP routes of a VirtualService into the rules
+// of an HTTPRoute.
+type istioConverter struct {
+	vs       IstioVirtualService
+	key      types.NamespacedName
+	warnings []Warning
+
+	// matches are the matches of the rules converted so far, along with
+	// their paths, in the order Istio evaluates them.
+	matches []istioMatch
+}
+
+// istioMatch is a converted match of a route, along with its path in the
+// VirtualService.
+type istioMatch struct {
+	match gatewayv1beta1.HTTPRouteMatch
+	path  *field.Path
+}
+
+// dropShadowedMatches removes the matches of the rule covered by a match of
+// an earlier rule. Istio routes a request to the first route matching it,
+// while Gateway API prefers the most specific match, so the requests of such
+// matches, which never reach the route in Istio, would be routed to it. It
+// returns false when all the matches of the rule are removed.
+func (c *istioConverter) dropShadowedMatches(rule gatewayv1beta1.HTTPRouteRule, explicitMatches bool, path *field.Path) (gatewayv1beta1.HTTPRouteRule, bool) {
+	var matches []gatewayv1beta1.HTTPRouteMatch
+	var converted []istioMatch
+	for i, match := range rule.Matches {
+		matchPath := path
+		if explicitMatches {
+			matchPath = path.Child("match").Index(i)
+		}
+		shadowed := false
+		for _, earlier := range c.matches {
+			if istioMatchCovers(earlier.match, match) {
+				c.warn(matchPath, "the requests of the match are routed by %s, which Istio evaluates first, while Gateway API prefers the most specific match; the match isn't converted", earlier.path)
+				shadowed = true
+				break
+			}
+		}
+		if !shadowed {
+			matches = append(matches, match)
+			converted = append(converted, istioMatch{match: match, path: matchPath})
+		}
+	}
+	c.matches = append(c.matches, converted...)
+	rule.Matches = matches
+	return rule, len(matches) > 0
+}
+
+// istioMatchCovers returns whether every request matching b matches a: the
+// path of a is the path of b or a prefix of it, and the other conditions of
+// a are conditions of b.
+func istioMatchCovers(a, b gatewayv1beta1.HTTPRouteMatch) bool {
+	if !istioPathCovers(a.Path, b.Path) {
+		return false
+	}
+	if a.Method != nil && (b.Method == nil || *a.Method != *b.Method) {
+		return false
+	}
+	for _, ah := range a.Headers {
+		found := false
+		for _, bh := range b.Headers {
+			if strings.EqualFold(string(ah.Name), string(bh.Name)) && pointer.StringDeref((*string)(ah.Type), "") == pointer.StringDeref((*string)(bh.Type), "") && ah.Value == bh.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, aq := range a.QueryParams {
+		found := false
+		for _, bq := range b.QueryParams {
+			if aq.Name == bq.Name && pointer.StringDeref((*string)(aq.Type), "") == pointer.StringDeref((*string)(bq.Type), "") && aq.Value == bq.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// istioPathCovers returns whether every path matching b matches a. Regular
+// expressions only cover the same regular expression.
+func istioPathCovers(a, b *gatewayv1beta1.HTTPPathMatch) bool {
+	aType, aValue := *a.Type, pointer.StringDeref(a.Value, "/")
+	bType, bValue := *b.Type, pointer.StringDeref(b.Value, "/")
+	if aType == bType && aValue == bValue {
+		return true
+	}
+	if aType != gatewayv1.PathMatchPathPrefix || bType == gatewayv1.PathMatchRegularExpression {
+		return false
+	}
+	prefix := strings.TrimSuffix(aValue, "/")
+	return prefix == "" || bValue == prefix || strings.HasPrefix(bValue, prefix+"/")
+}
+
+func (c *istioConverter) warn(path *field.Path, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, Warning{
+		Ingress: c.key,
+		Field:   path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *istioConverter) toHTTPRouteRule(route IstioHTTPRoute, path *field.Path) (gatewayv1beta1.HTTPRouteRule, bool) {
+	var rule gatewayv1beta1.HTTPRouteRule
+	switch {
+	case route.Delegate != nil:
+		c.warn(path.Child("delegate"), "delegated routes can't be represented in Gateway API, the route isn't converted")
+		return rule, false
+	case route.DirectResponse != nil:
+		c.warn(path.Child("directResponse"), "direct responses can't be represented in Gateway API, the route isn't converted")
+		return rule, false
+	}
+	for _, name := range route.unsupported() {
+		c.warn(path.Child(name), "%s is not supported by Gateway API and must be configured on the Gateway implementation", name)
+	}
+
+	for i, m := range route.Match {
+		match, ok := c.toHTTPRouteMatch(m, path.Child("match").Index(i))
+		if !ok {
+			return rule, false
+		}
+		rule.Matches = append(rule.Matches, match)
+	}
+	if len(rule.Matches) == 0 {
+		pathPrefix := gatewayv1.PathMatchPathPrefix
+		rule.Matches = []gatewayv1beta1.HTTPRouteMatch{{
+			Path: &gatewayv1beta1.HTTPPathMatch{Type: &pathPrefix, Value: pointer.String("/")},
+		}}
+	}
+
+	if route.Headers != nil {
+		rule.Filters = append(rule.Filters, toIstioHeaderFilters(*route.Headers)...)
+	}
+	if route.Redirect != nil {
+		filter, ok := c.toRedirectFilter(*route.Redirect, path.Child("redirect"))
+		if !ok {
+			return rule, false
+		}
+		rule.Filters = append(rule.Filters, filter)
+	}
+	if route.Rewrite != nil {
+		if filter, ok := c.toRewriteFilter(*route.Rewrite, rule.Matches, path.Child("rewrite")); ok {
+			rule.Filters = append(rule.Filters, filter)
+		}
+	}
+	if route.Mirror != nil {
+		rule.Filters = append(rule.Filters, c.toMirrorFilters(*route.Mirror, path.Child("mirror"))...)
+	}
+	for i, mirror := range route.Mirrors {
+		mirrorPath := path.Child("mirrors").Index(i)
+		if mirror.Percentage != nil {
+			c.warn(mirrorPath.Child("percentage"), "the percentage of mirrored requests can't be represented in Gateway API, all requests are mirrored")
+		}
+		rule.Filters = append(rule.Filters, c.toMirrorFilters(mirror.Destination, mirrorPath.Child("destination"))...)
+	}
+
+	if route.Timeout != "" {
+		if d, err := toIstioGatewayDuration(route.Timeout); err != nil {
+			c.warn(path.Child("timeout"), "the timeout %q is not converted, it %v", route.Timeout, err)
+		} else {
+			rule.Timeouts = &gatewayv1.HTTPRouteTimeouts{Request: &d}
+		}
+	}
+
+	// The requests of a redirected route aren't routed to its destinations,
+	// which a rule with a RequestRedirect filter cannot have.
+	if route.Redirect != nil && len(route.Route) > 0 {
+		c.warn(path.Child("route"), "the requests of the route are redirected, its destinations aren't converted")
+		return rule, true
+	}
+	for i, destination := range route.Route {
+		destinationPath := path.Child("route").Index(i)
+		objRef, ok := c.toBackendObjectReference(destination.Destination, destinationPath.Child("destination"))
+		if !ok {
+			continue
+		}
+		backendRef := gatewayv1beta1.HTTPBackendRef{BackendRef: gatewayv1beta1.BackendRef{BackendObjectReference: objRef}}
+		// A single destination gets all the requests whatever its weight.
+		if len(route.Route) > 1 {
+			backendRef.Weight = pointer.Int32(destination.Weight)
+		}
+		if destination.Headers != nil {
+			backendRef.Filters = toIstioHeaderFilters(*destination.Headers)
+		}
+		rule.BackendRefs = append(rule.BackendRefs, backendRef)
+	}
+	return rule, true
+}
+
+// toHTTPRouteMatch converts a match of a route. It returns false when the
+// match has conditions that can't be converted.
+func (c *istioConverter) toHTTPRouteMatch(m IstioHTTPMatchRequest, path *field.Path) (gatewayv1beta1.HTTPRouteMatch, bool) {
+	var match gatewayv1beta1.HTTPRouteMatch
+	if fields := m.unsupported(); len(fields) > 0 {
+		c.warn(path, "the %s conditions can't be represented in Gateway API, the route isn't converted", strings.Join(fields, ", "))
+		return match, false
+	}
+
+	if m.URI != nil {
+		pathExact := gatewayv1.PathMatchExact
+		pathPrefix := gatewayv1.PathMatchPathPrefix
+		pathRegex := gatewayv1.PathMatchRegularExpression
+		switch {
+		case m.URI.Exact != "":
+			match.Path = &gatewayv1beta1.HTTPPathMatch{Type: &pathExact, Value: pointer.String(m.URI.Exact)}
+		case m.URI.Prefix != "":
+			match.Path = &gatewayv1beta1.HTTPPathMatch{Type: &pathPrefix, Value: pointer.String(m.URI.Prefix)}
+		case m.URI.Regex != "":
+			match.Path = &gatewayv1beta1.HTTPPathMatch{Type: &pathRegex, Value: pointer.String(m.URI.Regex)}
+		}
+	}
+	if match.Path == nil {
+		pathPrefix := gatewayv1.PathMatchPathPrefix
+		match.Path = &gatewayv1beta1.HTTPPathMatch{Type: &pathPrefix, Value: pointer.String("/")}
+	}
+
+	if m.Method != nil {
+		if m.Method.Exact == "" {
+			c.warn(path.Child("method"), "only exact method conditions can be converted, the route isn't converted")
+			return match, false
+		}
+		method := gatewayv1.HTTPMethod(strings.ToUpper(m.Method.Exact))
+		match.Method = &method
+	}
+
+	for _, name := range sortedIstioKeys(m.Headers) {
+		headerMatch := gatewayv1beta1.HTTPHeaderMatch{Name: gatewayv1.HTTPHeaderName(name)}
+		matchType, value, ok := istioStringMatchValue(m.Headers[name])
+		if !ok {
+			c.warn(path.Child("headers").Key(name), "only exact and regex header conditions can be converted, the route isn't converted")
+			return match, false
+		}
+		headerType := gatewayv1.HeaderMatchType(matchType)
+		headerMatch.Type, headerMatch.Value = &headerType, value
+		match.Headers = append(match.Headers, headerMatch)
+	}
+	for _, name := range sortedIstioKeys(m.QueryParams) {
+		queryMatch := gatewayv1beta1.HTTPQueryParamMatch{Name: gatewayv1.HTTPHeaderName(name)}
+		matchType, value, ok := istioStringMatchValue(m.QueryParams[name])
+		if !ok {
+			c.warn(path.Child("queryParams").Key(name), "only exact and regex query parameter conditions can be converted, the route isn't converted")
+			return match, false
+		}
+		queryType := gatewayv1.QueryParamMatchType(matchType)
+		queryMatch.Type, queryMatch.Value = &queryType, value
+		match.QueryParams = append(match.QueryParams, queryMatch)
+	}
+	return match, true
+}
+
+// istioStringMatchValue returns the Gateway API match type and value of an
+// exact or regex string match.
+func istioStringMatchValue(m IstioStringMatch) (string, string, bool) {
+	switch {
+	case m.Exact != "":
+		return string(gatewayv1.HeaderMatchExact), m.Exact, true
+	case m.Regex != "":
+		return string(gatewayv1.HeaderMatchRegularExpression), m.Regex, true
+	}
+	return "", "", false
+}
+
+func sortedIstioKeys(m map[string]IstioStringMatch) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *istioConverter) toRedirectFilter(redirect IstioHTTPRedirect, path *field.Path) (gatewayv1beta1.HTTPRouteFilter, bool) {
+	filter := &gatewayv1beta1.HTTPRequestRedirectFilter{}
+	if redirect.URI != "" {
+		filter.Path = &gatewayv1.HTTPPathModifier{Type: gatewayv1.FullPathHTTPPathModifier, ReplaceFullPath: pointer.String(redirect.URI)}
+	}
+	if redirect.Authority != "" {
+		hostname := gatewayv1.PreciseHostname(redirect.Authority)
+		filter.Hostname = &hostname
+	}
+	if redirect.Port != 0 {
+		port := gatewayv1.PortNumber(redirect.Port)
+		filter.Port = &port
+	}
+	if redirect.DerivePort != "" {
+		c.warn(path.Child("derivePort"), "the port of the redirect can't be derived from the request in Gateway API, it is left unset")
+	}
+	if redirect.Scheme != "" {
+		filter.Scheme = pointer.String(strings.ToLower(redirect.Scheme))
+	}
+	switch redirect.RedirectCode {
+	// Istio redirects with a 301 by default, as Gateway API does.
+	case 0:
+	case 301, 302:
+		filter.StatusCode = pointer.Int(redirect.RedirectCode)
+	default:
+		c.warn(path.Child("redirectCode"), "Gateway API only redirects with a 301 or 302 status code, the route isn't converted")
+		return gatewayv1beta1.HTTPRouteFilter{}, false
+	}
+	return gatewayv1beta1.HTTPRouteFilter{
+		Type:            gatewayv1.HTTPRouteFilterRequestRedirect,
+		RequestRedirect: filter,
+	}, true
+}
+
+// toRewriteFilter converts the rewrite of a route. Istio replaces the part of
+// the path matched by the prefix or exact uri conditions, so the path is only
+// converted when all the matches of the route are of the same type.
+func (c *istioConverter) toRewriteFilter(rewrite IstioHTTPRewrite, matches []gatewayv1beta1.HTTPRouteMatch, path *field.Path) (gatewayv1beta1.HTTPRouteFilter, bool) {
+	filter := &gatewayv1beta1.HTTPURLRewriteFilter{}
+	if rewrite.URIRegexRewrite != nil {
+		c.warn(path.Child("uriRegexRewrite"), "regex rewrites can't be represented in Gateway API and must be configured on the Gateway implementation")
+	}
+	if rewrite.URI != "" {
+		pathType := *matches[0].Path.Type
+		for _, match := range matches[1:] {
+			if *match.Path.Type != pathType {
+				pathType = ""
+			}
+		}
+		switch pathType {
+		case gatewayv1.PathMatchPathPrefix:
+			filter.Path = &gatewayv1.HTTPPathModifier{Type: gatewayv1.PrefixMatchHTTPPathModifier, ReplacePrefixMatch: pointer.String(rewrite.URI)}
+		case gatewayv1.PathMatchExact:
+			filter.Path = &gatewayv1.HTTPPathModifier{Type: gatewayv1.FullPathHTTPPathModifier, ReplaceFullPath: pointer.String(rewrite.URI)}
+		default:
+			c.warn(path.Child("uri"), "the uri rewrite can only be converted for routes matching uri prefixes or exact uris only, it isn't converted")
+		}
+	}
+	if rewrite.Authority != "" {
+		hostname := gatewayv1.PreciseHostname(rewrite.Authority)
+		filter.Hostname = &hostname
+	}
+	if filter.Path == nil && filter.Hostname == nil {
+		return gatewayv1beta1.HTTPRouteFilter{}, false
+	}
+	return gatewayv1beta1.HTTPRouteFilter{
+		Type:       gatewayv1.HTTPRouteFilterURLRewrite,
+		URLRewrite: filter,
+	}, true
+}
+
+// toMirrorFilters returns the RequestMirror filter of a mirror destination,
+// none when the destination isn't converted.
+func (c *istioConverter) toMirrorFilters(destination IstioDestination, path *field.Path) []gatewayv1beta1.HTTPRouteFilter {
+	backendRef, ok := c.toBackendObjectReference(destination, path)
+	if !ok {
+		return nil
+	}
+	return []gatewayv1beta1.HTTPRouteFilter{{
+		Type:          gatewayv1.HTTPRouteFilterRequestMirror,
+		RequestMirror: &gatewayv1beta1.HTTPRequestMirrorFilter{BackendRef: backendRef},
+	}}
+}
+
+// toBackendObjectReference converts a destination into a reference to its
+// Service. The hosts of Services, short or fully qualified, are converted,
+// while the hosts of ServiceEntries can't be told apart from other hosts and
+// aren't converted.
+func (c *istioConverter) toBackendObjectReference(destination IstioDestination, path *field.Path) (gatewayv1beta1.BackendObjectReference, bool) {
+	var ref gatewayv1beta1.BackendObjectReference
+	host := strings.TrimSuffix(strings.TrimSuffix(destination.Host, ".cluster.local"), ".svc")
+	parts := strings.Split(host, ".")
+	switch len(parts) {
+	case 1:
+		ref.Name = gatewayv1beta1.ObjectName(parts[0])
+	case 2:
+		ref.Name = gatewayv1beta1.ObjectName(parts[0])
+		if parts[1] != c.vs.Namespace {
+			ref.Namespace = (*gatewayv1beta1.Namespace)(pointer.String(parts[1]))
+		}
+	default:
+		c.warn(path.Child("host"), "%q isn't the host of a Service, it isn't converted", destination.Host)
+		return ref, false
+	}
+	if destination.Port != nil && destination.Port.Number != 0 {
+		ref.Port = (*gatewayv1beta1.PortNumber)(pointer.Int32(int32(destination.Port.Number)))
+	} else {
+		c.warn(path.Child("port"), "Gateway API requires the port of the Service %s, it must be set manually", destination.Host)
+	}
+	if destination.Subset != "" {
+		c.warn(path.Child("subset"), "the subset %s of the DestinationRule can't be represented in Gateway API, the requests are routed to all the endpoints of the Service", destination.Subset)
+	}
+	return ref, true
+}
+
+// toIstioHeaderFilters converts the header operations into header modifier
+// filters.
+func toIstioHeaderFilters(headers IstioHeaders) []gatewayv1beta1.HTTPRouteFilter {
+	var filters []gatewayv1beta1.HTTPRouteFilter
+	if headers.Request != nil {
+		filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                  gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: toIstioHeaderFilter(*headers.Request),
+		})
+	}
+	if headers.Response != nil {
+		filters = append(filters, gatewayv1beta1.HTTPRouteFilter{
+			Type:                   gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+			ResponseHeaderModifier: toIstioHeaderFilter(*headers.Response),
+		})
+	}
+	return filters
+}
+
+func toIstioHeaderFilter(operations IstioHeaderOperations) *gatewayv1beta1.HTTPHeaderFilter {
+	filter := &gatewayv1beta1.HTTPHeaderFilter{Remove: operations.Remove}
+	toHeaders := func(values map[string]string) []gatewayv1beta1.HTTPHeader {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var headers []gatewayv1beta1.HTTPHeader
+		for _, name := range names {
+			headers = append(headers, gatewayv1beta1.HTTPHeader{Name: gatewayv1.HTTPHeaderName(name), Value: values[name]})
+		}
+		return headers
+	}
+	filter.Set = toHeaders(operations.Set)
+	filter.Add = toHeaders(operations.Add)
+	return filter
+}
+
+// toIstioGatewayDuration converts the duration of an Istio timeout. Only
+// whole seconds are converted, as with the timeouts of Ingresses.
+func toIstioGatewayDuration(value string) (gatewayv1.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 || d%time.Second != 0 {
+		return "", fmt.Errorf("must be a positive number of seconds")
+	}
+	return toGatewayDuration(strconv.Itoa(int(d / time.Second)))
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_IstioToGatewaysAndHTTPRoutes(t *testing.T) {
+	gateways, virtualServices, err := ConstructIstioResourcesFromFile("testdata/istio.yaml", "", "")
+	if err != nil {
+		t.Fatalf("Failed to read Istio resources: %v", err)
+	}
+	if len(gateways) != 1 || len(virtualServices) != 2 {
+		t.Fatalf("Expected 1 Gateway and 2 VirtualServices, got %d and %d", len(gateways), len(virtualServices))
+	}
+
+	gPathPrefix := gatewayv1.PathMatchPathPrefix
+	gExact := gatewayv1.PathMatchExact
+	hmExact := gatewayv1.HeaderMatchExact
+	backendRef := func(name string, port int32, namespace string, weight *int32) gatewayv1beta1.HTTPBackendRef {
+		ref := gatewayv1beta1.HTTPBackendRef{
+			BackendRef: gatewayv1beta1.BackendRef{
+				BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+					Name: gatewayv1beta1.ObjectName(name),
+					Port: portNumberPtr(int(port)),
+				},
+				Weight: weight,
+			},
+		}
+		if namespace != "" {
+			ref.Namespace = (*gatewayv1beta1.Namespace)(&namespace)
+		}
+		return ref
+	}
+	timeout := gatewayv1.Duration("10s")
+
+	expectedHTTPRoute := gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "web"},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+				ParentRefs: []gatewayv1beta1.ParentReference{{Name: "public", Namespace: (*gatewayv1beta1.Namespace)(pointer.String("istio-system"))}},
+			},
+			Hostnames: []gatewayv1beta1.Hostname{"www.example.com"},
+			Rules: []gatewayv1beta1.HTTPRouteRule{{
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{
+					Path:    &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String("/api")},
+					Headers: []gatewayv1beta1.HTTPHeaderMatch{{Type: &hmExact, Name: "x-version", Value: "v2"}},
+				}},
+				Filters: []gatewayv1beta1.HTTPRouteFilter{{
+					Type: gatewayv1.HTTPRouteFilterURLRewrite,
+					URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{
+						Path: &gatewayv1.HTTPPathModifier{Type: gatewayv1.PrefixMatchHTTPPathModifier, ReplacePrefixMatch: pointer.String("/v2")},
+					},
+				}},
+				Timeouts:    &gatewayv1.HTTPRouteTimeouts{Request: &timeout},
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("api", 8080, "backend", nil)},
+			}, {
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{
+					Path: &gatewayv1beta1.HTTPPathMatch{Type: &gExact, Value: pointer.String("/old")},
+				}},
+				Filters: []gatewayv1beta1.HTTPRouteFilter{{
+					Type: gatewayv1.HTTPRouteFilterRequestRedirect,
+					RequestRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+						Path:       &gatewayv1.HTTPPathModifier{Type: gatewayv1.FullPathHTTPPathModifier, ReplaceFullPath: pointer.String("/new")},
+						StatusCode: pointer.Int(302),
+					},
+				}},
+			}, {
+				Matches: []gatewayv1beta1.HTTPRouteMatch{{
+					Path: &gatewayv1beta1.HTTPPathMatch{Type: &gPathPrefix, Value: pointer.String("/")},
+				}},
+				Filters: []gatewayv1beta1.HTTPRouteFilter{{
+					Type: gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+					ResponseHeaderModifier: &gatewayv1beta1.HTTPHeaderFilter{
+						Set: []gatewayv1beta1.HTTPHeader{{Name: "x-served-by", Value: "istio"}},
+					},
+				}, {
+					Type: gatewayv1.HTTPRouteFilterRequestMirror,
+					RequestMirror: &gatewayv1beta1.HTTPRequestMirrorFilter{
+						BackendRef: gatewayv1beta1.BackendObjectReference{Name: "web-shadow", Port: portNumberPtr(80)},
+					},
+				}},
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{backendRef("web", 80, "", int32Ptr(90)), backendRef("web-canary", 80, "", int32Ptr(10))},
+			}},
+		},
+		Status: gatewayv1beta1.HTTPRouteStatus{
+			RouteStatus: gatewayv1beta1.RouteStatus{
+				Parents: []gatewayv1beta1.RouteParentStatus{},
+			},
+		},
+	}
+	expectedHTTPRoute.SetGroupVersionKind(httpRouteGVK)
+
+	all := gatewayv1.NamespacesFromAll
+	selector := gatewayv1.NamespacesFromSelector
+	terminate := gatewayv1.TLSModeTerminate
+	expectedListeners := []gatewayv1beta1.Listener{{
+		Name:          "www-example-com-http",
+		Hostname:      gatewayHostnamePtr("www.example.com"),
+		Port:          80,
+		Protocol:      gatewayv1.HTTPProtocolType,
+		AllowedRoutes: &gatewayv1beta1.AllowedRoutes{Namespaces: &gatewayv1beta1.RouteNamespaces{From: &all}},
+	}, {
+		Name:     "www-example-com-https",
+		Hostname: gatewayHostnamePtr("www.example.com"),
+		Port:     443,
+		Protocol: gatewayv1.HTTPSProtocolType,
+		TLS: &gatewayv1beta1.GatewayTLSConfig{
+			Mode:            &terminate,
+			CertificateRefs: []gatewayv1beta1.SecretObjectReference{{Name: "www-cert"}},
+		},
+		AllowedRoutes: &gatewayv1beta1.AllowedRoutes{Namespaces: &gatewayv1beta1.RouteNamespaces{
+			From:     &selector,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "web"}},
+		}},
+	}}
+
+	httpRoutes, gws, warnings, errs := IstioToGatewaysAndHTTPRoutes(gateways, virtualServices)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	// The selector, the HTTPS redirect, the mesh gateway, the fault
+	// injection and the VirtualService without gateways.
+	if len(warnings) != 5 {
+		t.Errorf("Expected 5 warnings, got %d: %+v", len(warnings), warnings)
+	}
+	if len(httpRoutes) != 1 {
+		t.Fatalf("Expected 1 HTTPRoute, got %d: %+v", len(httpRoutes), httpRoutes)
+	}
+	if !apiequality.Semantic.DeepEqual(httpRoutes[0], expectedHTTPRoute) {
+		t.Errorf("Expected HTTPRoute to be %+v\n Got: %+v\n Diff: %s", expectedHTTPRoute, httpRoutes[0], cmp.Diff(expectedHTTPRoute, httpRoutes[0]))
+	}
+	if len(gws) != 1 {
+		t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gws), gws)
+	}
+	if gws[0].Namespace != "istio-system" || gws[0].Name != "public" || gws[0].Spec.GatewayClassName != istioGatewayClass {
+		t.Errorf("Expected Gateway istio-system/public of class %s, got %s/%s of class %s", istioGatewayClass, gws[0].Namespace, gws[0].Name, gws[0].Spec.GatewayClassName)
+	}
+	if diff := cmp.Diff(expectedListeners, gws[0].Spec.Listeners); diff != "" {
+		t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+	}
+}
+
+func Test_istioUnsupportedRoutes(t *testing.T) {
+	newVirtualService := func(route IstioHTTPRoute) IstioVirtualService {
+		return IstioVirtualService{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+			Spec: IstioVirtualServiceSpec{
+				Hosts:    []string{"example.com"},
+				Gateways: []string{"public"},
+				HTTP:     []IstioHTTPRoute{route},
+			},
+		}
+	}
+	destination := []IstioHTTPRouteDestination{{Destination: IstioDestination{Host: "app", Port: &IstioPortSelector{Number: 80}}}}
+
+	testCases := []struct {
+		name                string
+		route               IstioHTTPRoute
+		expectedNumRules    int
+		expectedNumWarnings int
+	}{{
+		name:             "supported route",
+		route:            IstioHTTPRoute{Route: destination},
+		expectedNumRules: 1,
+	}, {
+		name:                "authority match",
+		route:               IstioHTTPRoute{Match: []IstioHTTPMatchRequest{{Authority: &IstioStringMatch{Exact: "example.com"}}}, Route: destination},
+		expectedNumWarnings: 1,
+	}, {
+		name:                "header prefix match",
+		route:               IstioHTTPRoute{Match: []IstioHTTPMatchRequest{{Headers: map[string]IstioStringMatch{"x-user": {Prefix: "admin"}}}}, Route: destination},
+		expectedNumWarnings: 1,
+	}, {
+		name:                "direct response",
+		route:               IstioHTTPRoute{DirectResponse: map[string]interface{}{"status": 503}},
+		expectedNumWarnings: 1,
+	}, {
+		name:                "retries and subset",
+		route:               IstioHTTPRoute{Retries: map[string]interface{}{"attempts": 3}, Route: []IstioHTTPRouteDestination{{Destination: IstioDestination{Host: "app", Subset: "v1", Port: &IstioPortSelector{Number: 80}}}}},
+		expectedNumRules:    1,
+		expectedNumWarnings: 2,
+	}, {
+		name:                "ServiceEntry host",
+		route:               IstioHTTPRoute{Route: []IstioHTTPRouteDestination{{Destination: IstioDestination{Host: "api.example.com", Port: &IstioPortSelector{Number: 443}}}}},
+		expectedNumRules:    1,
+		expectedNumWarnings: 1,
+	}, {
+		name:                "regex rewrite",
+		route:               IstioHTTPRoute{Match: []IstioHTTPMatchRequest{{URI: &IstioStringMatch{Regex: "/v[0-9]+"}}}, Rewrite: &IstioHTTPRewrite{URI: "/"}, Route: destination},
+		expectedNumRules:    1,
+		expectedNumWarnings: 1,
+	}, {
+		name:                "redirect with destinations",
+		route:               IstioHTTPRoute{Redirect: &IstioHTTPRedirect{Scheme: "https"}, Route: destination},
+		expectedNumRules:    1,
+		expectedNumWarnings: 1,
+	}, {
+		name:                "sub-second timeout",
+		route:               IstioHTTPRoute{Timeout: "0.5s", Route: destination},
+		expectedNumRules:    1,
+		expectedNumWarnings: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, warnings, errs := IstioToGatewaysAndHTTPRoutes(nil, []IstioVirtualService{newVirtualService(tc.route)})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if len(httpRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d", len(httpRoutes))
+			}
+			if len(httpRoutes[0].Spec.Rules) != tc.expectedNumRules {
+				t.Errorf("Expected %d rules, got %d: %+v", tc.expectedNumRules, len(httpRoutes[0].Spec.Rules), httpRoutes[0].Spec.Rules)
+			}
+			if len(warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func Test_istioShadowedRoutes(t *testing.T) {
+	destination := []IstioHTTPRouteDestination{{Destination: IstioDestination{Host: "app", Port: &IstioPortSelector{Number: 80}}}}
+	prefixRoute := func(prefix string, headers map[string]IstioStringMatch) IstioHTTPRoute {
+		return IstioHTTPRoute{
+			Match: []IstioHTTPMatchRequest{{URI: &IstioStringMatch{Prefix: prefix}, Headers: headers}},
+			Route: destination,
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		routes           []IstioHTTPRoute
+		expectedNumRules int
+		expectedWarnings []string
+	}{{
+		name:             "more specific route first",
+		routes:           []IstioHTTPRoute{prefixRoute("/api", nil), prefixRoute("/", nil)},
+		expectedNumRules: 2,
+	}, {
+		name:             "catch-all route first",
+		routes:           []IstioHTTPRoute{{Route: destination}, prefixRoute("/api", nil)},
+		expectedNumRules: 1,
+		expectedWarnings: []string{"app.spec.http[1].match[0]"},
+	}, {
+		name:             "prefix route shadowing a longer prefix",
+		routes:           []IstioHTTPRoute{prefixRoute("/api", nil), prefixRoute("/api/v1", nil)},
+		expectedNumRules: 1,
+		expectedWarnings: []string{"app.spec.http[1].match[0]"},
+	}, {
+		name:             "prefix route not shadowing another segment",
+		routes:           []IstioHTTPRoute{prefixRoute("/api", nil), prefixRoute("/apis", nil)},
+		expectedNumRules: 2,
+	}, {
+		name:             "header route shadowing a route with more headers",
+		routes:           []IstioHTTPRoute{prefixRoute("/", map[string]IstioStringMatch{"x-canary": {Exact: "true"}}), prefixRoute("/api", map[string]IstioStringMatch{"x-canary": {Exact: "true"}, "x-user": {Exact: "admin"}})},
+		expectedNumRules: 1,
+		expectedWarnings: []string{"app.spec.http[1].match[0]"},
+	}, {
+		name:             "header route not shadowing a route without the header",
+		routes:           []IstioHTTPRoute{prefixRoute("/", map[string]IstioStringMatch{"x-canary": {Exact: "true"}}), prefixRoute("/api", nil)},
+		expectedNumRules: 2,
+	}, {
+		name: "shadowed match of a route with other matches",
+		routes: []IstioHTTPRoute{prefixRoute("/api", nil), {
+			Match: []IstioHTTPMatchRequest{{URI: &IstioStringMatch{Exact: "/api/health"}}, {URI: &IstioStringMatch{Prefix: "/web"}}},
+			Route: destination,
+		}},
+		expectedNumRules: 2,
+		expectedWarnings: []string{"app.spec.http[1].match[0]"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vs := IstioVirtualService{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+				Spec: IstioVirtualServiceSpec{
+					Hosts:    []string{"example.com"},
+					Gateways: []string{"public"},
+					HTTP:     tc.routes,
+				},
+			}
+			httpRoutes, _, warnings, errs := IstioToGatewaysAndHTTPRoutes(nil, []IstioVirtualService{vs})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if len(httpRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d", len(httpRoutes))
+			}
+			if len(httpRoutes[0].Spec.Rules) != tc.expectedNumRules {
+				t.Errorf("Expected %d rules, got %d: %+v", tc.expectedNumRules, len(httpRoutes[0].Spec.Rules), httpRoutes[0].Spec.Rules)
+			}
+			var fields []string
+			for _, w := range warnings {
+				fields = append(fields, w.Field.String())
+			}
+			if diff := cmp.Diff(tc.expectedWarnings, fields); diff != "" {
+				t.Errorf("Unexpected warnings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_istioListenerName(t *testing.T) {
+	testCases := []struct {
+		hostname string
+		protocol gatewayv1.ProtocolType
+		port     gatewayv1.PortNumber
+		expected gatewayv1beta1.SectionName
+	}{
+		{hostname: "example.com", protocol: gatewayv1.HTTPProtocolType, port: 80, expected: "example-com-http"},
+		{hostname: "example.com", protocol: gatewayv1.HTTPSProtocolType, port: 8443, expected: "example-com-https-8443"},
+		{protocol: gatewayv1.TCPProtocolType, port: 9000, expected: "wildcard-tcp-9000"},
+	}
+	for _, tc := range testCases {
+		listener := gatewayv1beta1.Listener{Protocol: tc.protocol, Port: tc.port}
+		if tc.hostname != "" {
+			listener.Hostname = gatewayHostnamePtr(tc.hostname)
+		}
+		if got := istioListenerName(listener); got != tc.expected {
+			t.Errorf("istioListenerName(%s %s:%d) = %q, expected %q", tc.protocol, tc.hostname, tc.port, got, tc.expected)
+		}
+	}
+}
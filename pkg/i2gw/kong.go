@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	kongStripPathAnnotation = "konghq.com/strip-path"
+	kongPluginsAnnotation   = "konghq.com/plugins"
+	kongProtocolsAnnotation = "konghq.com/protocols"
+)
+
+// kongProvider converts the konghq.com annotations of the Kong Ingress
+// controller.
+type kongProvider struct{}
+
+func (kongProvider) annotationPrefix() string {
+	return "konghq.com/"
+}
+
+func (p kongProvider) detect(ingress networkingv1.Ingress) bool {
+	return hasAnnotationPrefix(ingress, p.annotationPrefix())
+}
+
+func (kongProvider) inputKinds() []string {
+	return []string{"Ingress"}
+}
+
+func (kongProvider) summary() string {
+	return "Path stripping, protocols, methods and plugins of the Kong Ingress controller"
+}
+
+func (kongProvider) addExtra(ingress networkingv1.Ingress, _ map[types.NamespacedName]corev1.ConfigMap, e *extra) field.ErrorList {
+	var errs field.ErrorList
+
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+
+	e.stripPath = ingress.Annotations[kongStripPathAnnotation] == "true"
+	methods, methodsErrs := getKongMethods(ingress, fieldPath)
+	e.methods = methods
+	errs = append(errs, methodsErrs...)
+	if plugins := strings.TrimSpace(ingress.Annotations[kongPluginsAnnotation]); plugins != "" {
+		e.warnings = append(e.warnings, Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(kongPluginsAnnotation),
+			Message: fmt.Sprintf("the KongPlugins %s cannot be converted and must be migrated manually, e.g. to ExtensionRef filters of the HTTPRoute", plugins),
+		})
+	}
+	if protocols, ok := ingress.Annotations[kongProtocolsAnnotation]; ok {
+		seen := map[string]bool{}
+		for _, protocol := range strings.Split(protocols, ",") {
+			protocol = strings.ToLower(strings.TrimSpace(protocol))
+			if seen[protocol] {
+				continue
+			}
+			seen[protocol] = true
+			switch protocol {
+			case "http":
+				e.protocols = append(e.protocols, gatewayv1.HTTPProtocolType)
+			case "https":
+				e.protocols = append(e.protocols, gatewayv1.HTTPSProtocolType)
+			default:
+				errs = append(errs, field.NotSupported(fieldPath.Key(kongProtocolsAnnotation), protocol, []string{"http", "https"}))
+			}
+		}
+	}
+	return errs
+}
+
+// kongStripPathFilter returns the URLRewrite filter stripping the path of the
+// Ingress from the requests, as konghq.com/strip-path does. Exact paths are
+// replaced with "/", as is the matched prefix of other paths.
+func kongStripPathFilter(path networkingv1.HTTPIngressPath) *gatewayv1beta1.HTTPURLRewriteFilter {
+	if path.PathType != nil && *path.PathType == networkingv1.PathTypeExact {
+		return &gatewayv1beta1.HTTPURLRewriteFilter{
+			Path: &gatewayv1beta1.HTTPPathModifier{
+				Type:            gatewayv1.FullPathHTTPPathModifier,
+				ReplaceFullPath: pointer.String("/"),
+			},
+		}
+	}
+	return &gatewayv1beta1.HTTPURLRewriteFilter{
+		Path: &gatewayv1beta1.HTTPPathModifier{
+			Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+			ReplacePrefixMatch: pointer.String("/"),
+		},
+	}
+}
+
+// listenerProtocol returns the protocol of the listener the HTTPRoute of the
+// rule group is restricted to by the konghq.com/protocols of its Ingresses,
+// empty when the route is served by all the listeners of its host. The route
+// is only restricted when all the Ingresses of the host are restricted to the
+// same protocol, the restrictions of the others are reported as warnings.
+func (rg *ingressRuleGroup) listenerProtocol() (gatewayv1.ProtocolType, []Warning) {
+	var restricted []ingressRule
+	protocols := map[gatewayv1.ProtocolType]bool{}
+	unrestricted := false
+	for _, ir := range rg.rules {
+		if ir.extra == nil || len(ir.extra.protocols) != 1 {
+			unrestricted = true
+			continue
+		}
+		restricted = append(restricted, ir)
+		protocols[ir.extra.protocols[0]] = true
+	}
+	if len(restricted) == 0 {
+		return "", nil
+	}
+	if !unrestricted && len(protocols) == 1 {
+		return restricted[0].extra.protocols[0], nil
+	}
+
+	var warnings []Warning
+	seen := map[types.NamespacedName]bool{}
+	for _, ir := range restricted {
+		if seen[ir.ingress] {
+			continue
+		}
+		seen[ir.ingress] = true
+		warnings = append(warnings, Warning{
+			Ingress: ir.ingress,
+			Field:   field.NewPath(ir.ingress.Name, "metadata", "annotations").Key(kongProtocolsAnnotation),
+			Message: fmt.Sprintf("the paths of host %q are served over both HTTP and HTTPS, as its Ingresses don't all allow the same protocol", rg.host),
+		})
+	}
+	return "", warnings
+}
+
+// setSectionName attaches the HTTPRoute to the listeners with the given names
+// of its parent Gateways, replacing the listeners it was attached to. The
+// HTTPRoute is left unchanged without names.
+func setSectionName(httpRoute *gatewayv1beta1.HTTPRoute, sectionNames ...gatewayv1beta1.SectionName) {
+	if len(sectionNames) == 0 {
+		return
+	}
+	var parentRefs []gatewayv1beta1.ParentReference
+	seen := map[string]bool{}
+	for _, parentRef := range httpRoute.Spec.ParentRefs {
+		parentRef.SectionName = nil
+		key := string(parentRef.Name)
+		if parentRef.Namespace != nil {
+			key = string(*parentRef.Namespace) + "/" + key
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for _, sectionName := range sectionNames {
+			section := sectionName
+			parentRef.SectionName = &section
+			parentRefs = append(parentRefs, parentRef)
+		}
+	}
+	httpRoute.Spec.ParentRefs = parentRefs
+}
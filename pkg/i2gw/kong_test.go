@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_kongProvider(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+	newIngress := func(name string, tls bool, pathType *networkingv1.PathType, annotations map[string]string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("kong"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: name,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if tls {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com"}}
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name                 string
+		ingresses            []networkingv1.Ingress
+		providers            []string
+		expectedSectionNames []string
+		expectedFilters      []gatewayv1beta1.HTTPRouteFilter
+		expectedNumWarnings  int
+		expectedNumErrors    int
+	}{{
+		name:                 "strip-path",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, &iPrefix, map[string]string{kongStripPathAnnotation: "true"})},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedFilters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterURLRewrite,
+			URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{
+				Path: &gatewayv1beta1.HTTPPathModifier{
+					Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+					ReplacePrefixMatch: pointer.String("/"),
+				},
+			},
+		}},
+	}, {
+		name:                 "strip-path of an exact path",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, &iExact, map[string]string{kongStripPathAnnotation: "true"})},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedFilters: []gatewayv1beta1.HTTPRouteFilter{{
+			Type: gatewayv1.HTTPRouteFilterURLRewrite,
+			URLRewrite: &gatewayv1beta1.HTTPURLRewriteFilter{
+				Path: &gatewayv1beta1.HTTPPathModifier{
+					Type:            gatewayv1.FullPathHTTPPathModifier,
+					ReplaceFullPath: pointer.String("/"),
+				},
+			},
+		}},
+	}, {
+		name:                 "plugins",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, &iPrefix, map[string]string{kongPluginsAnnotation: "rate-limit,key-auth"})},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "protocols restricted to HTTPS",
+		ingresses:            []networkingv1.Ingress{newIngress("app", true, &iPrefix, map[string]string{kongProtocolsAnnotation: "https"})},
+		expectedSectionNames: []string{"example-com-https"},
+	}, {
+		name:                 "protocols restricted to HTTP",
+		ingresses:            []networkingv1.Ingress{newIngress("app", true, &iPrefix, map[string]string{kongProtocolsAnnotation: "http"})},
+		expectedSectionNames: []string{"example-com-http"},
+	}, {
+		name:                 "all protocols",
+		ingresses:            []networkingv1.Ingress{newIngress("app", true, &iPrefix, map[string]string{kongProtocolsAnnotation: "http, https"})},
+		expectedSectionNames: []string{"example-com-http", "example-com-https"},
+	}, {
+		name:                 "protocols restricted to HTTPS without TLS",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, &iPrefix, map[string]string{kongProtocolsAnnotation: "https"})},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}, {
+		name: "protocols of other Ingresses of the host",
+		ingresses: []networkingv1.Ingress{
+			newIngress("app", true, &iPrefix, map[string]string{kongProtocolsAnnotation: "https"}),
+			newIngress("other", true, &iPrefix, nil),
+		},
+		expectedSectionNames: []string{"example-com-http", "example-com-https"},
+		expectedNumWarnings:  1,
+	}, {
+		name:              "unsupported protocol",
+		ingresses:         []networkingv1.Ingress{newIngress("app", false, &iPrefix, map[string]string{kongProtocolsAnnotation: "grpc"})},
+		expectedNumErrors: 1,
+	}, {
+		name:                 "provider not selected",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, &iPrefix, map[string]string{kongStripPathAnnotation: "true", kongPluginsAnnotation: "key-auth"})},
+		providers:            []string{ProviderHAProxy},
+		expectedSectionNames: []string{"example-com-http"},
+	}, {
+		name:                 "provider selected",
+		ingresses:            []networkingv1.Ingress{newIngress("app", false, &iPrefix, map[string]string{kongPluginsAnnotation: "key-auth"})},
+		providers:            []string{ProviderKong},
+		expectedSectionNames: []string{"example-com-http"},
+		expectedNumWarnings:  1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, _, warnings, errs := ingresses2GatewaysAndHTTPRoutes(tc.ingresses, Options{Providers: tc.providers})
+			if len(errs) != tc.expectedNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectedNumErrors, len(errs), errs)
+			}
+			if len(warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(warnings), warnings)
+			}
+			if tc.expectedNumErrors > 0 {
+				return
+			}
+			if len(httpRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d: %+v", len(httpRoutes), httpRoutes)
+			}
+			var sectionNames []string
+			for _, parentRef := range httpRoutes[0].Spec.ParentRefs {
+				if parentRef.SectionName != nil {
+					sectionNames = append(sectionNames, string(*parentRef.SectionName))
+				}
+			}
+			if diff := cmp.Diff(tc.expectedSectionNames, sectionNames); diff != "" {
+				t.Errorf("Unexpected sectionNames (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedFilters, httpRoutes[0].Spec.Rules[0].Filters); diff != "" {
+				t.Errorf("Unexpected filters (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
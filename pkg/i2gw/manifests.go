@@ -0,0 +1,313 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// InputFormatAuto reads the manifests in the format of the extension of
+	// their file, or in the format guessed from their first non-whitespace
+	// character for other extensions: JSON for { and [, YAML otherwise.
+	InputFormatAuto = "auto"
+	// InputFormatYAML and InputFormatJSON read every manifest in the given
+	// format, whatever the extension of its file.
+	InputFormatYAML = "yaml"
+	InputFormatJSON = "json"
+
+	formatJSON = InputFormatJSON
+	formatYAML = InputFormatYAML
+)
+
+// manifestExtensions are the extensions of the files read from input
+// directories, by format.
+var manifestExtensions = map[string]string{
+	".json": formatJSON,
+	".yaml": formatYAML,
+	".yml":  formatYAML,
+}
+
+// ManifestErrors are the errors of the documents of manifests that failed to
+// be parsed. The documents are skipped and the other documents are still
+// read, so ManifestErrors are returned along with the objects of the other
+// documents.
+type ManifestErrors []error
+
+// ValidateInputFormat returns an error when the format of the input manifests
+// isn't supported. The empty format is InputFormatAuto.
+func ValidateInputFormat(format string) error {
+	switch format {
+	case "", InputFormatAuto, InputFormatYAML, InputFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("%s is not a supported input format, must be one of: %s, %s, %s", format, InputFormatAuto, InputFormatYAML, InputFormatJSON)
+}
+
+func (errs ManifestErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// readObjectsFromFile reads all objects of the inputFile, in either json/yaml
+// formats. When inputFile is a directory, the manifests of its files and
+// subdirectories with a .json, .yaml or .yml extension are read, in lexical
+// order, each in its own format unless format forces one. The documents
+// failing to be parsed are returned as ManifestErrors, along with the objects
+// of the other documents.
+func readObjectsFromFile(inputFile string, format string) ([]*unstructured.Unstructured, error) {
+	info, err := os.Stat(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readManifest(inputFile, format)
+	}
+
+	var objs []*unstructured.Unstructured
+	var manifestErrs ManifestErrors
+	err = filepath.WalkDir(inputFile, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := manifestExtensions[strings.ToLower(filepath.Ext(path))]; !ok {
+			return nil
+		}
+		fileObjs, err := readManifest(path, format)
+		var fileErrs ManifestErrors
+		if errors.As(err, &fileErrs) {
+			manifestErrs = append(manifestErrs, fileErrs...)
+		} else if err != nil {
+			return err
+		}
+		objs = append(objs, fileObjs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(manifestErrs) > 0 {
+		return objs, manifestErrs
+	}
+	return objs, nil
+}
+
+// readManifest reads the objects of a manifest file, including the items of
+// lists. The format is forced by format, or with InputFormatAuto given by the
+// extension of the file, or guessed from its first non-whitespace character
+// for other extensions. Parsing errors name the file and the line
+// where the failing object starts. The YAML documents, and JSON objects, that
+// fail to be parsed are returned as ManifestErrors along with the other
+// objects, while a JSON syntax error fails the whole file.
+func readManifest(path string, format string) ([]*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" || format == InputFormatAuto {
+		format = manifestFormat(path, data)
+	}
+
+	var objs []*unstructured.Unstructured
+	var docErrs []error
+	if format == formatJSON {
+		objs, docErrs, err = decodeJSONManifest(data)
+	} else {
+		objs, docErrs, err = decodeYAMLManifest(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	objs, err = expandLists(objs)
+	if err != nil {
+		return nil, err
+	}
+	if len(docErrs) > 0 {
+		manifestErrs := make(ManifestErrors, 0, len(docErrs))
+		for _, docErr := range docErrs {
+			manifestErrs = append(manifestErrs, fmt.Errorf("failed to parse %s: %w", path, docErr))
+		}
+		return objs, manifestErrs
+	}
+	return objs, nil
+}
+
+// manifestFormat returns the format of the extension of the manifest file,
+// or JSON when its content starts with { or [ for other extensions, YAML
+// otherwise.
+func manifestFormat(path string, data []byte) string {
+	if format, ok := manifestExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return formatJSON
+	}
+	return formatYAML
+}
+
+// decodeJSONManifest decodes the stream of JSON objects of data. It returns
+// the errors of the objects that aren't Kubernetes objects, which are
+// skipped, and fails on syntax errors, after which the objects can't be
+// told apart.
+func decodeJSONManifest(data []byte) ([]*unstructured.Unstructured, []error, error) {
+	var objs []*unstructured.Unstructured
+	var objErrs []error
+	d := json.NewDecoder(bytes.NewReader(data))
+	for {
+		// The object starts after the whitespace following the previous one.
+		start := d.InputOffset()
+		start += int64(len(data[start:]) - len(bytes.TrimLeft(data[start:], " \t\r\n")))
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return nil, nil, fmt.Errorf("line %d: %w", lineOf(data, syntaxErr.Offset), err)
+			}
+			return nil, nil, fmt.Errorf("line %d: %w", lineOf(data, start), err)
+		}
+		// The objects of a JSON array are read as the items of a list.
+		raws := []json.RawMessage{raw}
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			raws = nil
+			if err := json.Unmarshal(raw, &raws); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", lineOf(data, start), err)
+			}
+		}
+		for _, raw := range raws {
+			obj, err := decodeObject(raw)
+			if err != nil {
+				objErrs = append(objErrs, fmt.Errorf("line %d: %w", lineOf(data, start), err))
+				continue
+			}
+			if obj != nil {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	return objs, objErrs, nil
+}
+
+// decodeYAMLManifest decodes the YAML documents of data, separated by "---"
+// lines. It returns the errors of the documents failing to be parsed, which
+// are skipped.
+func decodeYAMLManifest(data []byte) ([]*unstructured.Unstructured, []error, error) {
+	var objs []*unstructured.Unstructured
+	var docErrs []error
+	var doc bytes.Buffer
+	docLine, line := 1, 0
+	decodeDoc := func() {
+		defer doc.Reset()
+		content, err := yaml.YAMLToJSON(doc.Bytes())
+		if err != nil {
+			docErrs = append(docErrs, fmt.Errorf("document starting at line %d: %w", docLine, err))
+			return
+		}
+		obj, err := decodeObject(content)
+		if err != nil {
+			docErrs = append(docErrs, fmt.Errorf("document starting at line %d: %w", docLine, err))
+			return
+		}
+		if obj != nil {
+			objs = append(objs, obj)
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "---" || strings.HasPrefix(text, "--- ") {
+			decodeDoc()
+			docLine = line + 1
+			continue
+		}
+		doc.WriteString(text)
+		doc.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	decodeDoc()
+	return objs, docErrs, nil
+}
+
+// decodeObject decodes a JSON object, returning nil for empty documents.
+func decodeObject(data []byte) (*unstructured.Unstructured, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || string(trimmed) == "null" {
+		return nil, nil
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// expandLists replaces the lists of objs with their items.
+func expandLists(objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	finalObjs := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if !obj.IsList() {
+			finalObjs = append(finalObjs, obj)
+			continue
+		}
+		err := obj.EachListItem(func(object runtime.Object) error {
+			unstructuredObj, ok := object.(*unstructured.Unstructured)
+			if !ok {
+				return fmt.Errorf("resource list item has unexpected type")
+			}
+			finalObjs = append(finalObjs, unstructuredObj)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return finalObjs, nil
+}
+
+// lineOf returns the line of the byte at offset in data.
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
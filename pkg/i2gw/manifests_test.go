@@ -0,0 +1,267 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const (
+	yamlIngress = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: from-yaml
+  namespace: test
+---
+# comment only document
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+  namespace: test
+`
+	jsonIngress = `{
+  "apiVersion": "networking.k8s.io/v1",
+  "kind": "Ingress",
+  "metadata": {"name": "from-json", "namespace": "test"}
+}
+`
+)
+
+func writeManifests(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_readObjectsFromDirectory(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"ingress.yaml":      yamlIngress,
+		"ingress.json":      jsonIngress,
+		"nested/other.yml":  strings.ReplaceAll(yamlIngress, "from-yaml", "from-yml"),
+		"README.md":         "# not a manifest",
+		"nested/notes.txt":  "not: [a manifest",
+		"nested/empty.yaml": "",
+	})
+
+	ingressList := &networkingv1.IngressList{}
+	if err := ConstructIngressesFromFile(ingressList, dir, "", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, ingress := range ingressList.Items {
+		names = append(names, ingress.Name)
+	}
+	if diff := cmp.Diff([]string{"from-json", "from-yaml", "from-yml"}, names); diff != "" {
+		t.Errorf("Unexpected Ingresses (-want +got):\n%s", diff)
+	}
+}
+
+func Test_ConstructIngressesFromMixedManifest(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: test
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: test
+---
+apiVersion: networking.k8s.io/v1
+kind: IngressList
+items:
+- apiVersion: networking.k8s.io/v1
+  kind: Ingress
+  metadata:
+    name: from-list
+    namespace: test
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: invalid-spec
+  namespace: test
+spec:
+  rules: not-a-list
+---
+kind: [Ingress
+---
+` + yamlIngress
+
+	ingressList := &networkingv1.IngressList{}
+	err := ConstructIngressesFromFile(ingressList, filepath.Join(writeManifests(t, map[string]string{"app.yaml": manifest}), "app.yaml"), "", "")
+	var manifestErrs ManifestErrors
+	if !errors.As(err, &manifestErrs) {
+		t.Fatalf("Expected ManifestErrors, got %v", err)
+	}
+	if len(manifestErrs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", manifestErrs)
+	}
+	if !strings.Contains(manifestErrs[0].Error(), "app.yaml: document starting at line 30") {
+		t.Errorf("Expected the error of the invalid document, got %v", manifestErrs[0])
+	}
+	if !strings.Contains(manifestErrs[1].Error(), "Ingress test/invalid-spec") {
+		t.Errorf("Expected the error of the invalid Ingress, got %v", manifestErrs[1])
+	}
+
+	var names []string
+	for _, ingress := range ingressList.Items {
+		names = append(names, ingress.Name)
+	}
+	if diff := cmp.Diff([]string{"from-list", "from-yaml"}, names); diff != "" {
+		t.Errorf("Unexpected Ingresses (-want +got):\n%s", diff)
+	}
+}
+
+func Test_readManifest(t *testing.T) {
+	testCases := []struct {
+		name          string
+		file          string
+		format        string
+		content       string
+		expectedNames []string
+		expectedError string
+	}{{
+		name:          "yaml",
+		file:          "ingress.yaml",
+		content:       yamlIngress,
+		expectedNames: []string{"from-yaml", "ignored"},
+	}, {
+		name:          "json",
+		file:          "ingress.json",
+		content:       jsonIngress + jsonIngress,
+		expectedNames: []string{"from-json", "from-json"},
+	}, {
+		name:          "json without extension",
+		file:          "ingress",
+		content:       jsonIngress,
+		expectedNames: []string{"from-json"},
+	}, {
+		name:          "yaml without extension",
+		file:          "ingress",
+		content:       yamlIngress,
+		expectedNames: []string{"from-yaml", "ignored"},
+	}, {
+		name:          "json array",
+		file:          "ingress",
+		content:       "\n[" + jsonIngress + "," + jsonIngress + "]\n",
+		expectedNames: []string{"from-json", "from-json"},
+	}, {
+		name:          "yaml format forced",
+		file:          "ingress.json",
+		format:        InputFormatYAML,
+		content:       yamlIngress,
+		expectedNames: []string{"from-yaml", "ignored"},
+	}, {
+		name:          "json format forced",
+		file:          "ingress.txt",
+		format:        InputFormatJSON,
+		content:       "# not yaml\n" + jsonIngress,
+		expectedError: "ingress.txt: line 1",
+	}, {
+		name:          "json format forced for a yaml file",
+		file:          "ingress.yaml",
+		format:        InputFormatJSON,
+		content:       jsonIngress,
+		expectedNames: []string{"from-json"},
+	}, {
+		name:          "auto format",
+		file:          "ingress.yml",
+		format:        InputFormatAuto,
+		content:       yamlIngress,
+		expectedNames: []string{"from-yaml", "ignored"},
+	}, {
+		name:          "invalid yaml document",
+		file:          "ingress.yaml",
+		content:       yamlIngress + "---\nkind: [Ingress\n---\n" + strings.ReplaceAll(yamlIngress, "from-yaml", "after-invalid"),
+		expectedNames: []string{"from-yaml", "ignored", "after-invalid", "ignored"},
+		expectedError: "ingress.yaml: document starting at line 15",
+	}, {
+		name:          "yaml document without kind",
+		file:          "ingress.yaml",
+		content:       "metadata:\n  name: missing-kind\n---\n" + yamlIngress,
+		expectedNames: []string{"from-yaml", "ignored"},
+		expectedError: "ingress.yaml: document starting at line 1",
+	}, {
+		name:          "invalid json",
+		file:          "ingress.json",
+		content:       jsonIngress + "{\n  \"kind\": \"Ingress\",\n  \"metadata\": {,}\n}\n",
+		expectedError: "ingress.json: line 8",
+	}, {
+		name:          "truncated json",
+		file:          "ingress.json",
+		content:       jsonIngress + "{\n  \"kind\": \"Ingress\",\n  \"metadata\": {\n}\n",
+		expectedError: "ingress.json: line 6",
+	}, {
+		name:          "json object without kind",
+		file:          "ingress.json",
+		content:       jsonIngress + "\n{\"metadata\": {}}\n" + jsonIngress,
+		expectedNames: []string{"from-json", "from-json"},
+		expectedError: "ingress.json: line 7",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(writeManifests(t, map[string]string{tc.file: tc.content}), tc.file)
+			objs, err := readManifest(path, tc.format)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tc.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.GetName())
+			}
+			if diff := cmp.Diff(tc.expectedNames, names); diff != "" {
+				t.Errorf("Unexpected objects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ValidateInputFormat(t *testing.T) {
+	for _, format := range []string{"", InputFormatAuto, InputFormatYAML, InputFormatJSON} {
+		if err := ValidateInputFormat(format); err != nil {
+			t.Errorf("Unexpected error for format %q: %v", format, err)
+		}
+	}
+	if err := ValidateInputFormat("toml"); err == nil {
+		t.Errorf("Expected an error for format toml")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"regexp"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const kongMethodsAnnotation = "konghq.com/methods"
+
+// httpMethods are the methods HTTPRoute matches support.
+var httpMethods = []gatewayv1.HTTPMethod{
+	gatewayv1.HTTPMethodGet,
+	gatewayv1.HTTPMethodHead,
+	gatewayv1.HTTPMethodPost,
+	gatewayv1.HTTPMethodPut,
+	gatewayv1.HTTPMethodDelete,
+	gatewayv1.HTTPMethodConnect,
+	gatewayv1.HTTPMethodOptions,
+	gatewayv1.HTTPMethodTrace,
+	gatewayv1.HTTPMethodPatch,
+}
+
+// limitExceptRegex matches the limit_except blocks of nginx snippets denying
+// all the requests with other methods than the listed ones.
+var limitExceptRegex = regexp.MustCompile(`limit_except\s+([^{;]+?)\s*\{\s*deny\s+all\s*;\s*\}\s*;?`)
+
+// toHTTPMethod returns the HTTPRoute match method of an HTTP method, false
+// when matches don't support it.
+func toHTTPMethod(method string) (gatewayv1.HTTPMethod, bool) {
+	m := gatewayv1.HTTPMethod(strings.ToUpper(strings.TrimSpace(method)))
+	for _, supported := range httpMethods {
+		if m == supported {
+			return m, true
+		}
+	}
+	return m, false
+}
+
+// getKongMethods converts the konghq.com/methods annotation, the comma
+// separated methods the paths of the Ingress are restricted to.
+func getKongMethods(ingress networkingv1.Ingress, fieldPath *field.Path) ([]gatewayv1.HTTPMethod, field.ErrorList) {
+	value, ok := ingress.Annotations[kongMethodsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var methods []gatewayv1.HTTPMethod
+	var errs field.ErrorList
+	for _, method := range strings.Split(value, ",") {
+		if strings.TrimSpace(method) == "" {
+			continue
+		}
+		m, ok := toHTTPMethod(method)
+		if !ok {
+			errs = append(errs, field.NotSupported(fieldPath.Key(kongMethodsAnnotation), method, methodNames(httpMethods)))
+			continue
+		}
+		methods = appendMethod(methods, m)
+	}
+	return methods, errs
+}
+
+// getSnippetMethods converts the limit_except block of the
+// configuration-snippet of ingress-nginx, which denies the requests of the
+// Ingress paths with other methods, to the methods the paths are restricted
+// to. As with nginx, allowing GET also allows HEAD. Snippets restricting the
+// methods otherwise, e.g. depending on the client address, are reported as a
+// warning, with no restriction.
+func getSnippetMethods(ingress networkingv1.Ingress, fieldPath *field.Path) ([]gatewayv1.HTTPMethod, *Warning) {
+	snippet, ok := ingress.Annotations[configurationSnippetAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	methods, _, parsed := parseSnippetMethods(snippet)
+	if !parsed {
+		return nil, &Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(configurationSnippetAnnotation),
+			Message: "the method restriction of the snippet cannot be converted to method matches, the paths match all the methods; it must be configured manually",
+		}
+	}
+	return methods, nil
+}
+
+// parseSnippetMethods extracts the methods allowed by the limit_except block
+// of an nginx snippet, and returns the snippet without the block. It returns
+// false when the snippet restricts the methods otherwise, with a request
+// method condition or several blocks.
+func parseSnippetMethods(snippet string) ([]gatewayv1.HTTPMethod, string, bool) {
+	blocks := limitExceptRegex.FindAllStringSubmatchIndex(snippet, -1)
+	rest := limitExceptRegex.ReplaceAllString(snippet, "")
+	if len(blocks) > 1 || strings.Contains(rest, "limit_except") || strings.Contains(rest, "$request_method") {
+		return nil, snippet, false
+	}
+	if len(blocks) == 0 {
+		return nil, snippet, true
+	}
+
+	var methods []gatewayv1.HTTPMethod
+	for _, method := range strings.Fields(snippet[blocks[0][2]:blocks[0][3]]) {
+		m, ok := toHTTPMethod(method)
+		if !ok {
+			return nil, snippet, false
+		}
+		methods = appendMethod(methods, m)
+		if m == gatewayv1.HTTPMethodGet {
+			methods = appendMethod(methods, gatewayv1.HTTPMethodHead)
+		}
+	}
+	return methods, rest, true
+}
+
+// appendMethod appends the method unless it's already in the methods.
+func appendMethod(methods []gatewayv1.HTTPMethod, method gatewayv1.HTTPMethod) []gatewayv1.HTTPMethod {
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	return append(methods, method)
+}
+
+func methodNames(methods []gatewayv1.HTTPMethod) []string {
+	var names []string
+	for _, m := range methods {
+		names = append(names, string(m))
+	}
+	return names
+}
+
+// matchMethods returns the methods the paths are restricted to, nil when they
+// match all the methods.
+func (e *extra) matchMethods() []gatewayv1.HTTPMethod {
+	if e == nil {
+		return nil
+	}
+	return e.methods
+}
+
+// withMethods returns a match of the path for every method the paths are
+// restricted to, the match itself when they aren't restricted or allow all
+// the methods.
+func withMethods(match gatewayv1.HTTPRouteMatch, methods []gatewayv1.HTTPMethod) []gatewayv1.HTTPRouteMatch {
+	if len(methods) == 0 || len(methods) == len(httpMethods) {
+		return []gatewayv1.HTTPRouteMatch{match}
+	}
+	var matches []gatewayv1.HTTPRouteMatch
+	for _, method := range methods {
+		m := match
+		m.Method = &method
+		matches = append(matches, m)
+	}
+	return matches
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_parseSnippetMethods(t *testing.T) {
+	testCases := []struct {
+		name            string
+		snippet         string
+		expectedMethods []gatewayv1.HTTPMethod
+		expectedRest    string
+		expectedParsed  bool
+	}{{
+		name:           "no method restriction",
+		snippet:        "more_set_headers \"X-Frame-Options: DENY\";",
+		expectedRest:   "more_set_headers \"X-Frame-Options: DENY\";",
+		expectedParsed: true,
+	}, {
+		name:            "limit_except",
+		snippet:         "limit_except POST put { deny all; }",
+		expectedMethods: []gatewayv1.HTTPMethod{gatewayv1.HTTPMethodPost, gatewayv1.HTTPMethodPut},
+		expectedParsed:  true,
+	}, {
+		name:            "GET allows HEAD",
+		snippet:         "limit_except GET {\n  deny all;\n}\nmore_set_headers \"X-Frame-Options: DENY\";",
+		expectedMethods: []gatewayv1.HTTPMethod{gatewayv1.HTTPMethodGet, gatewayv1.HTTPMethodHead},
+		expectedRest:    "more_set_headers \"X-Frame-Options: DENY\";",
+		expectedParsed:  true,
+	}, {
+		name:         "allowed addresses",
+		snippet:      "limit_except GET { allow 10.0.0.0/8; deny all; }",
+		expectedRest: "limit_except GET { allow 10.0.0.0/8; deny all; }",
+	}, {
+		name:         "request method condition",
+		snippet:      "if ($request_method !~ ^(GET|POST)$) { return 405; }",
+		expectedRest: "if ($request_method !~ ^(GET|POST)$) { return 405; }",
+	}, {
+		name:         "unsupported method",
+		snippet:      "limit_except PROPFIND { deny all; }",
+		expectedRest: "limit_except PROPFIND { deny all; }",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			methods, rest, parsed := parseSnippetMethods(tc.snippet)
+			if diff := cmp.Diff(tc.expectedMethods, methods); diff != "" {
+				t.Errorf("Unexpected methods (-want +got):\n%s", diff)
+			}
+			if rest != tc.expectedRest {
+				t.Errorf("Expected the rest of the snippet to be %q, got %q", tc.expectedRest, rest)
+			}
+			if parsed != tc.expectedParsed {
+				t.Errorf("Expected parsed %v, got %v", tc.expectedParsed, parsed)
+			}
+		})
+	}
+}
+
+func Test_getKongMethods(t *testing.T) {
+	testCases := []struct {
+		name            string
+		value           string
+		expectedMethods []gatewayv1.HTTPMethod
+		expectedErrors  int
+	}{{
+		name:            "methods",
+		value:           "get, POST,get",
+		expectedMethods: []gatewayv1.HTTPMethod{gatewayv1.HTTPMethodGet, gatewayv1.HTTPMethodPost},
+	}, {
+		name:            "unsupported method",
+		value:           "GET,PURGE",
+		expectedMethods: []gatewayv1.HTTPMethod{gatewayv1.HTTPMethodGet},
+		expectedErrors:  1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web", Annotations: map[string]string{kongMethodsAnnotation: tc.value}}}
+			methods, errs := getKongMethods(ingress, field.NewPath("web", "metadata", "annotations"))
+			if diff := cmp.Diff(tc.expectedMethods, methods); diff != "" {
+				t.Errorf("Unexpected methods (-want +got):\n%s", diff)
+			}
+			if len(errs) != tc.expectedErrors {
+				t.Errorf("Expected %d errors, got %v", tc.expectedErrors, errs)
+			}
+		})
+	}
+}
+
+func Test_convertMethods(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, class, service string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr(class),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/api",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: service, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	type ruleMethods struct {
+		Service string
+		Methods []string
+	}
+
+	testCases := []struct {
+		name                string
+		ingresses           []networkingv1.Ingress
+		expectedRules       []ruleMethods
+		expectedNumWarnings int
+	}{{
+		name: "ingress-nginx limit_except",
+		ingresses: []networkingv1.Ingress{newIngress("web", "nginx", "web", map[string]string{
+			configurationSnippetAnnotation: "limit_except GET POST { deny all; }",
+		})},
+		expectedRules: []ruleMethods{{Service: "web", Methods: []string{"GET", "HEAD", "POST"}}},
+	}, {
+		name: "kong methods",
+		ingresses: []networkingv1.Ingress{newIngress("web", "kong", "web", map[string]string{
+			kongMethodsAnnotation: "DELETE",
+		})},
+		expectedRules: []ruleMethods{{Service: "web", Methods: []string{"DELETE"}}},
+	}, {
+		name: "paths of different methods",
+		ingresses: []networkingv1.Ingress{
+			newIngress("read", "kong", "read", map[string]string{kongMethodsAnnotation: "GET"}),
+			newIngress("write", "kong", "write", map[string]string{kongMethodsAnnotation: "POST,PUT"}),
+		},
+		expectedRules: []ruleMethods{
+			{Service: "read", Methods: []string{"GET"}},
+			{Service: "write", Methods: []string{"POST", "PUT"}},
+		},
+	}, {
+		name: "unparseable snippet",
+		ingresses: []networkingv1.Ingress{newIngress("web", "nginx", "web", map[string]string{
+			configurationSnippetAnnotation: "limit_except GET { allow 10.0.0.0/8; deny all; }",
+		})},
+		expectedRules: []ruleMethods{{Service: "web", Methods: []string{""}}},
+		// The snippet isn't converted at all, nor are its methods.
+		expectedNumWarnings: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, Options{})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if len(result.HTTPRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d", len(result.HTTPRoutes))
+			}
+			var rules []ruleMethods
+			for _, rule := range result.HTTPRoutes[0].Spec.Rules {
+				r := ruleMethods{Service: string(rule.BackendRefs[0].Name)}
+				for _, match := range rule.Matches {
+					method := ""
+					if match.Method != nil {
+						method = string(*match.Method)
+					}
+					r.Methods = append(r.Methods, method)
+				}
+				rules = append(rules, r)
+			}
+			if diff := cmp.Diff(tc.expectedRules, rules); diff != "" {
+				t.Errorf("Unexpected rules (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %+v", tc.expectedNumWarnings, result.Warnings)
+			}
+			if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+				t.Errorf("Expected valid resources, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// ParseNamespaceMapping parses old=new namespace pairs. Pairs with a
+// duplicated old namespace are rejected, as the namespace they map to would
+// be ambiguous.
+func ParseNamespaceMapping(pairs []string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid namespace mapping %q, must be old=new", pair)
+		}
+		for _, ns := range []string{from, to} {
+			if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid namespace %q in namespace mapping %q: %s", ns, pair, strings.Join(errs, ", "))
+			}
+		}
+		if _, ok := mapping[from]; ok {
+			return nil, fmt.Errorf("namespace %q is mapped more than once", from)
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+// MapNamespaces rewrites the namespaces of the given generated resources, and
+// the namespaces they reference, according to the mapping. Namespaces that
+// aren't mapped are left unchanged. Warnings bound to an HTTPRoute follow the
+// route.
+func MapNamespaces(mapping map[string]string, httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant, policies []unstructured.Unstructured, warnings []Warning) {
+	if len(mapping) == 0 {
+		return
+	}
+	mapName := func(ns string) string {
+		return mapNamespace(mapping, ns)
+	}
+	// References get a new namespace pointer, as pointers may be shared
+	// between resources.
+	mapRef := func(ns **gatewayv1.Namespace) {
+		if *ns != nil {
+			mapped := gatewayv1.Namespace(mapName(string(**ns)))
+			*ns = &mapped
+		}
+	}
+	mapParentRefs := func(parentRefs []gatewayv1.ParentReference) {
+		for i := range parentRefs {
+			mapRef(&parentRefs[i].Namespace)
+		}
+	}
+
+	for i := range gateways {
+		gateways[i].Namespace = mapName(gateways[i].Namespace)
+		for _, listener := range gateways[i].Spec.Listeners {
+			if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil {
+				mapNamespaceSelector(listener.AllowedRoutes.Namespaces.Selector, mapName)
+			}
+			if listener.TLS == nil {
+				continue
+			}
+			for j := range listener.TLS.CertificateRefs {
+				mapRef(&listener.TLS.CertificateRefs[j].Namespace)
+			}
+		}
+	}
+	for i := range httpRoutes {
+		route := &httpRoutes[i]
+		route.Namespace = mapName(route.Namespace)
+		mapParentRefs(route.Spec.ParentRefs)
+		for _, rule := range route.Spec.Rules {
+			for j := range rule.BackendRefs {
+				mapRef(&rule.BackendRefs[j].Namespace)
+			}
+			for _, filter := range rule.Filters {
+				if filter.RequestMirror != nil {
+					mapRef(&filter.RequestMirror.BackendRef.Namespace)
+				}
+			}
+		}
+	}
+	for i := range grpcRoutes {
+		route := &grpcRoutes[i]
+		route.Namespace = mapName(route.Namespace)
+		mapParentRefs(route.Spec.ParentRefs)
+		for _, rule := range route.Spec.Rules {
+			for j := range rule.BackendRefs {
+				mapRef(&rule.BackendRefs[j].Namespace)
+			}
+		}
+	}
+	for i := range tcpRoutes {
+		route := &tcpRoutes[i]
+		route.Namespace = mapName(route.Namespace)
+		mapParentRefs(route.Spec.ParentRefs)
+		for _, rule := range route.Spec.Rules {
+			for j := range rule.BackendRefs {
+				mapRef(&rule.BackendRefs[j].Namespace)
+			}
+		}
+	}
+	for i := range tlsRoutes {
+		route := &tlsRoutes[i]
+		route.Namespace = mapName(route.Namespace)
+		mapParentRefs(route.Spec.ParentRefs)
+		for _, rule := range route.Spec.Rules {
+			for j := range rule.BackendRefs {
+				mapRef(&rule.BackendRefs[j].Namespace)
+			}
+		}
+	}
+	for i := range backendTLSPolicies {
+		backendTLSPolicies[i].Namespace = mapName(backendTLSPolicies[i].Namespace)
+	}
+	for i := range referenceGrants {
+		grant := &referenceGrants[i]
+		grant.Namespace = mapName(grant.Namespace)
+		for j := range grant.Spec.From {
+			grant.Spec.From[j].Namespace = gatewayv1.Namespace(mapName(string(grant.Spec.From[j].Namespace)))
+		}
+	}
+	for i := range policies {
+		policies[i].SetNamespace(mapName(policies[i].GetNamespace()))
+		// The Secret of the basic authentication of a SecurityPolicy.
+		if ns, ok, _ := unstructured.NestedString(policies[i].Object, "spec", "basicAuth", "users", "namespace"); ok {
+			_ = unstructured.SetNestedField(policies[i].Object, mapName(ns), "spec", "basicAuth", "users", "namespace")
+		}
+	}
+	for i := range warnings {
+		if warnings[i].HTTPRoute.Name != "" {
+			warnings[i].HTTPRoute.Namespace = mapName(warnings[i].HTTPRoute.Namespace)
+		}
+	}
+}
+
+// mapNamespace returns the namespace ns is mapped to, or ns when it isn't
+// mapped.
+func mapNamespace(mapping map[string]string, ns string) string {
+	if to, ok := mapping[ns]; ok {
+		return to
+	}
+	return ns
+}
+
+// mapNamespaceSelector rewrites the namespace names selected by the
+// kubernetes.io/metadata.name label of the selector of the namespaces of the
+// routes allowed by a listener.
+func mapNamespaceSelector(selector *metav1.LabelSelector, mapName func(string) string) {
+	if selector == nil {
+		return
+	}
+	if ns, ok := selector.MatchLabels[namespaceNameLabel]; ok {
+		selector.MatchLabels[namespaceNameLabel] = mapName(ns)
+	}
+	for i := range selector.MatchExpressions {
+		expr := &selector.MatchExpressions[i]
+		if expr.Key != namespaceNameLabel {
+			continue
+		}
+		values := make([]string, len(expr.Values))
+		for j, ns := range expr.Values {
+			values[j] = mapName(ns)
+		}
+		expr.Values = values
+	}
+}
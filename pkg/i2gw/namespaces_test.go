@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_ParseNamespaceMapping(t *testing.T) {
+	testCases := []struct {
+		name            string
+		pairs           []string
+		expectedMapping map[string]string
+		expectError     bool
+	}{{
+		name:            "empty",
+		expectedMapping: map[string]string{},
+	}, {
+		name:            "pairs",
+		pairs:           []string{"staging=prod", "foo=bar"},
+		expectedMapping: map[string]string{"staging": "prod", "foo": "bar"},
+	}, {
+		name:        "duplicate namespace",
+		pairs:       []string{"staging=prod", "staging=qa"},
+		expectError: true,
+	}, {
+		name:        "missing separator",
+		pairs:       []string{"staging"},
+		expectError: true,
+	}, {
+		name:        "invalid namespace",
+		pairs:       []string{"staging=Prod"},
+		expectError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mapping, err := ParseNamespaceMapping(tc.pairs)
+			if tc.expectError != (err != nil) {
+				t.Fatalf("ParseNamespaceMapping(%v) error = %v, expecting error: %v", tc.pairs, err, tc.expectError)
+			}
+			if diff := cmp.Diff(tc.expectedMapping, mapping); diff != "" {
+				t.Errorf("Unexpected mapping (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_MapNamespaces(t *testing.T) {
+	httpRoutes := []gatewayv1beta1.HTTPRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "staging"},
+		Spec: gatewayv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+				ParentRefs: []gatewayv1beta1.ParentReference{{Name: "nginx"}},
+			},
+			Rules: []gatewayv1beta1.HTTPRouteRule{{
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+					BackendRef: gatewayv1beta1.BackendRef{
+						BackendObjectReference: gatewayv1beta1.BackendObjectReference{Name: "api", Namespace: namespacePtr("backends")},
+					},
+				}, {
+					BackendRef: gatewayv1beta1.BackendRef{
+						BackendObjectReference: gatewayv1beta1.BackendObjectReference{Name: "legacy", Namespace: namespacePtr("other")},
+					},
+				}},
+			}},
+		},
+	}}
+	tcpRoutes := []gatewayv1alpha2.TCPRoute{{
+		ObjectMeta: metav1.ObjectMeta{Name: "tcp-services-5432", Namespace: "staging"},
+		Spec: gatewayv1alpha2.TCPRouteSpec{
+			Rules: []gatewayv1alpha2.TCPRouteRule{{
+				BackendRefs: []gatewayv1alpha2.BackendRef{{
+					BackendObjectReference: gatewayv1alpha2.BackendObjectReference{Name: "postgres", Namespace: namespacePtr("backends")},
+				}},
+			}},
+		},
+	}}
+	gateways := []gatewayv1beta1.Gateway{{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "staging"},
+	}}
+	backendTLSPolicies := []gatewayv1alpha3.BackendTLSPolicy{{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-backend-tls", Namespace: "backends"},
+	}}
+	policies := []unstructured.Unstructured{
+		envoyGatewaySecurityPolicy("staging", "example-com", []string{"HTTPRoute"}, []string{"10.0.0.0/8"}, nil),
+		envoyGatewaySecurityPolicy("staging", "admin-example-com", []string{"HTTPRoute"}, nil, &basicAuth{secretNamespace: "backends", secretName: "htpasswd"}),
+	}
+	warnings := []Warning{{HTTPRoute: types.NamespacedName{Namespace: "staging", Name: "example-com"}}}
+
+	MapNamespaces(map[string]string{"staging": "prod", "backends": "prod-backends"}, httpRoutes, nil, tcpRoutes, nil, gateways, backendTLSPolicies, nil, policies, warnings)
+
+	if gateways[0].Namespace != "prod" {
+		t.Errorf("Expected Gateway namespace prod, got %s", gateways[0].Namespace)
+	}
+	if httpRoutes[0].Namespace != "prod" {
+		t.Errorf("Expected HTTPRoute namespace prod, got %s", httpRoutes[0].Namespace)
+	}
+	if httpRoutes[0].Spec.ParentRefs[0].Namespace != nil {
+		t.Errorf("Expected HTTPRoute parentRef without namespace, got %s", *httpRoutes[0].Spec.ParentRefs[0].Namespace)
+	}
+	backendRefs := httpRoutes[0].Spec.Rules[0].BackendRefs
+	if *backendRefs[0].Namespace != "prod-backends" || *backendRefs[1].Namespace != "other" {
+		t.Errorf("Expected HTTPRoute backendRef namespaces prod-backends and other, got %s and %s", *backendRefs[0].Namespace, *backendRefs[1].Namespace)
+	}
+	if tcpRoutes[0].Namespace != "prod" || *tcpRoutes[0].Spec.Rules[0].BackendRefs[0].Namespace != "prod-backends" {
+		t.Errorf("Expected TCPRoute in prod referencing prod-backends, got %s referencing %s", tcpRoutes[0].Namespace, *tcpRoutes[0].Spec.Rules[0].BackendRefs[0].Namespace)
+	}
+	if backendTLSPolicies[0].Namespace != "prod-backends" {
+		t.Errorf("Expected BackendTLSPolicy namespace prod-backends, got %s", backendTLSPolicies[0].Namespace)
+	}
+	if policies[0].GetNamespace() != "prod" {
+		t.Errorf("Expected SecurityPolicy namespace prod, got %s", policies[0].GetNamespace())
+	}
+	if ns, _, _ := unstructured.NestedString(policies[1].Object, "spec", "basicAuth", "users", "namespace"); ns != "prod-backends" {
+		t.Errorf("Expected SecurityPolicy referencing a Secret of prod-backends, got %s", ns)
+	}
+	if warnings[0].HTTPRoute.Namespace != "prod" {
+		t.Errorf("Expected warning bound to HTTPRoute in prod, got %s", warnings[0].HTTPRoute)
+	}
+}
+
+func Test_MapNamespacesSharedGateway(t *testing.T) {
+	from := gatewayv1.NamespacesFromSelector
+	gateways := []gatewayv1beta1.Gateway{{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "infra"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			Listeners: []gatewayv1beta1.Listener{{
+				Name: "example-com-http",
+				AllowedRoutes: &gatewayv1beta1.AllowedRoutes{Namespaces: &gatewayv1beta1.RouteNamespaces{
+					From: &from,
+					Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      namespaceNameLabel,
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"other", "staging"},
+					}}},
+				}},
+			}},
+		},
+	}}
+	referenceGrants := []gatewayv1beta1.ReferenceGrant{{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateways-infra", Namespace: "staging"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "Gateway", Namespace: "infra"}},
+		},
+	}}
+
+	MapNamespaces(map[string]string{"staging": "prod", "infra": "gateway-system"}, nil, nil, nil, nil, gateways, nil, referenceGrants, nil, nil)
+
+	values := gateways[0].Spec.Listeners[0].AllowedRoutes.Namespaces.Selector.MatchExpressions[0].Values
+	if diff := cmp.Diff([]string{"other", "prod"}, values); diff != "" {
+		t.Errorf("Unexpected selected namespaces (-want +got):\n%s", diff)
+	}
+	if referenceGrants[0].Namespace != "prod" || referenceGrants[0].Spec.From[0].Namespace != "gateway-system" {
+		t.Errorf("Expected ReferenceGrant in prod from gateway-system, got %s from %s", referenceGrants[0].Namespace, referenceGrants[0].Spec.From[0].Namespace)
+	}
+}
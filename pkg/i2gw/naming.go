@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	// KeepIngressNameTemplate is the name template naming the routes after
+	// their Ingress.
+	KeepIngressNameTemplate = "{ingress}"
+
+	// maxTemplatedNameLength is the maximum length of templated names, the
+	// length of DNS-1123 labels.
+	maxTemplatedNameLength = 63
+	// nameHashLength is the length of the hash suffixing truncated names.
+	nameHashLength = 8
+)
+
+// namePlaceholders are the placeholders of name templates.
+var namePlaceholders = []string{"{host}", "{ingress}", "{namespace}"}
+
+// gatewayNamePlaceholders are the placeholders of Gateway name templates.
+var gatewayNamePlaceholders = []string{"{class}", "{namespace}"}
+
+var (
+	placeholderRegex    = regexp.MustCompile(`\{[^{}]*\}`)
+	invalidNameRegex    = regexp.MustCompile(`[^a-z0-9-]+`)
+	nameSeparatorsRegex = regexp.MustCompile(`-{2,}`)
+)
+
+// ValidateNameTemplate checks that the name template only has known
+// placeholders and at least one of them, so that routes have distinct names.
+func ValidateNameTemplate(template string) error {
+	return validateTemplate("name template", template, namePlaceholders)
+}
+
+// ValidateGatewayNameTemplate checks that the Gateway name template only has
+// the {class} and {namespace} placeholders, and at least one of them.
+func ValidateGatewayNameTemplate(template string) error {
+	return validateTemplate("Gateway name template", template, gatewayNamePlaceholders)
+}
+
+func validateTemplate(description, template string, known []string) error {
+	placeholders := placeholderRegex.FindAllString(template, -1)
+	if len(placeholders) == 0 {
+		return fmt.Errorf("%s %q must have at least one of the placeholders %s", description, template, strings.Join(known, ", "))
+	}
+	for _, placeholder := range placeholders {
+		if !isPlaceholder(placeholder, known) {
+			return fmt.Errorf("unknown placeholder %s in %s %q, must be one of %s", placeholder, description, template, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+func isPlaceholder(placeholder string, known []string) bool {
+	for _, p := range known {
+		if p == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// expandNameTemplate replaces the placeholders of the template with the name
+// and namespace of the first Ingress of the rule group and its host.
+func (rg *ingressRuleGroup) expandNameTemplate(template string) string {
+	return strings.NewReplacer(
+		"{host}", nameFromHost(rg.host),
+		"{ingress}", rg.rules[0].ingress.Name,
+		"{namespace}", rg.namespace,
+	).Replace(template)
+}
+
+// toDNS1123Label returns the name as a DNS-1123 label: lowercased, with the
+// invalid characters replaced by "-". Longer names are truncated and suffixed
+// with a hash of the name, so that truncated names remain distinct, and
+// whether the name was truncated is returned.
+func toDNS1123Label(name string) (string, bool) {
+	label := invalidNameRegex.ReplaceAllString(strings.ToLower(name), "-")
+	label = strings.Trim(nameSeparatorsRegex.ReplaceAllString(label, "-"), "-")
+	if label == "" {
+		label = "route"
+	}
+	if len(label) <= maxTemplatedNameLength {
+		return label, false
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:nameHashLength]
+	prefix := strings.TrimRight(label[:maxTemplatedNameLength-nameHashLength-1], "-")
+	return prefix + "-" + hash, true
+}
+
+// setRouteNames names the routes of the rule groups after the name template,
+// or their host when it is empty. Templated names colliding with the name of
+// another rule group of the namespace are suffixed with their host, so that
+// every route has a distinct name.
+func (a *ingressAggregator) setRouteNames(rgKeys []string) []Warning {
+	if a.nameTemplate == "" {
+		return nil
+	}
+	var warnings []Warning
+	taken := map[string]bool{}
+	for _, rgKey := range rgKeys {
+		rg := a.ruleGroups[ruleGroupKey(rgKey)]
+		templated := rg.expandNameTemplate(a.nameTemplate)
+		name, truncated := toDNS1123Label(templated)
+		fieldPath := field.NewPath(rg.rules[0].ingress.Name, "metadata", "name")
+		if taken[rg.namespace+"/"+name] {
+			collision := name
+			name, truncated = toDNS1123Label(templated + "-" + nameFromHost(rg.host))
+			for i := 2; taken[rg.namespace+"/"+name]; i++ {
+				name, truncated = toDNS1123Label(fmt.Sprintf("%s-%s-%d", templated, nameFromHost(rg.host), i))
+			}
+			warnings = append(warnings, Warning{
+				Ingress: rg.rules[0].ingress,
+				Field:   fieldPath,
+				Message: fmt.Sprintf("the routes of host %q are named %q, as the name %q of the name template %q is already used in the namespace", rg.host, name, collision, a.nameTemplate),
+			})
+		}
+		if truncated {
+			warnings = append(warnings, Warning{
+				Ingress: rg.rules[0].ingress,
+				Field:   fieldPath,
+				Message: fmt.Sprintf("the name %q of the routes of host %q is longer than %d characters and is truncated to %q", templated, rg.host, maxTemplatedNameLength, name),
+			})
+		}
+		taken[rg.namespace+"/"+name] = true
+		rg.name = name
+	}
+	return warnings
+}
+
+// routeName returns the name of the routes of the rule group.
+func (rg *ingressRuleGroup) routeName() string {
+	if rg.name != "" {
+		return rg.name
+	}
+	return nameFromHost(rg.host)
+}
+
+// setGatewayNames names the Gateways after the Gateway name template, with
+// their class and namespace, and updates the parentRefs of the routes
+// attached to them. Templated names shared by several Gateways of a
+// namespace are suffixed with a hash of the class and listeners of every
+// Gateway, so that the names are distinct and only depend on the input.
+func setGatewayNames(template string, result *Result) []Warning {
+	if template == "" {
+		return nil
+	}
+	var warnings []Warning
+	templated := make([]string, len(result.Gateways))
+	counts := map[string]int{}
+	for i, gateway := range result.Gateways {
+		templated[i] = strings.NewReplacer(
+			"{class}", string(gateway.Spec.GatewayClassName),
+			"{namespace}", gateway.Namespace,
+		).Replace(template)
+		name, _ := toDNS1123Label(templated[i])
+		counts[gateway.Namespace+"/"+name]++
+	}
+
+	renamed := map[string]string{}
+	for i := range result.Gateways {
+		gateway := &result.Gateways[i]
+		fieldPath := field.NewPath("Gateway", gateway.Namespace+"/"+gateway.Name, "metadata", "name")
+		name, truncated := toDNS1123Label(templated[i])
+		if counts[gateway.Namespace+"/"+name] > 1 {
+			collision := name
+			name, truncated = toDNS1123Label(templated[i] + "-" + listenersHash(*gateway))
+			warnings = append(warnings, Warning{
+				Field:   fieldPath,
+				Message: fmt.Sprintf("the Gateway is named %q, as the name %q of the Gateway name template %q is shared by several Gateways of the namespace", name, collision, template),
+			})
+		}
+		if truncated {
+			warnings = append(warnings, Warning{
+				Field:   fieldPath,
+				Message: fmt.Sprintf("the name %q of the Gateway is longer than %d characters and is truncated to %q", templated[i], maxTemplatedNameLength, name),
+			})
+		}
+		renamed[gateway.Namespace+"/"+gateway.Name] = name
+		gateway.Name = name
+	}
+
+	renameGatewayParentRefs(result, renamed)
+	return warnings
+}
+
+// renameGatewayParentRefs updates the parentRefs of the routes of the Result
+// attached to the renamed Gateways, by the <namespace>/<name> of their former
+// name.
+func renameGatewayParentRefs(result *Result, renamed map[string]string) {
+	renameParentRefs := func(namespace string, parentRefs []gatewayv1.ParentReference) {
+		for i := range parentRefs {
+			ref := &parentRefs[i]
+			if ref.Kind != nil && *ref.Kind != "Gateway" {
+				continue
+			}
+			ns := namespace
+			if ref.Namespace != nil {
+				ns = string(*ref.Namespace)
+			}
+			if name, ok := renamed[ns+"/"+string(ref.Name)]; ok {
+				ref.Name = gatewayv1.ObjectName(name)
+			}
+		}
+	}
+	for i := range result.HTTPRoutes {
+		renameParentRefs(result.HTTPRoutes[i].Namespace, result.HTTPRoutes[i].Spec.ParentRefs)
+	}
+	for i := range result.GRPCRoutes {
+		renameParentRefs(result.GRPCRoutes[i].Namespace, result.GRPCRoutes[i].Spec.ParentRefs)
+	}
+	for i := range result.TCPRoutes {
+		renameParentRefs(result.TCPRoutes[i].Namespace, result.TCPRoutes[i].Spec.ParentRefs)
+	}
+	for i := range result.TLSRoutes {
+		renameParentRefs(result.TLSRoutes[i].Namespace, result.TLSRoutes[i].Spec.ParentRefs)
+	}
+}
+
+// listenersHash returns a hash of the class and listeners of the Gateway,
+// independent of the order of the listeners.
+func listenersHash(gateway gatewayv1beta1.Gateway) string {
+	listeners := make([]string, 0, len(gateway.Spec.Listeners))
+	for _, listener := range gateway.Spec.Listeners {
+		hostname := ""
+		if listener.Hostname != nil {
+			hostname = string(*listener.Hostname)
+		}
+		listeners = append(listeners, fmt.Sprintf("%s/%s/%d/%s", listener.Name, listener.Protocol, listener.Port, hostname))
+	}
+	sort.Strings(listeners)
+	data := string(gateway.Spec.GatewayClassName) + "\n" + strings.Join(listeners, "\n")
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))[:nameHashLength]
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func Test_ValidateNameTemplate(t *testing.T) {
+	testCases := []struct {
+		template       string
+		expectingError bool
+	}{
+		{template: "{ingress}"},
+		{template: "{namespace}-{ingress}-{host}"},
+		{template: "route-{host}"},
+		{template: "route", expectingError: true},
+		{template: "{ingress}-{class}", expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			err := ValidateNameTemplate(tc.template)
+			if tc.expectingError != (err != nil) {
+				t.Errorf("ValidateNameTemplate(%q) error = %v, expecting error: %v", tc.template, err, tc.expectingError)
+			}
+		})
+	}
+}
+
+func Test_ValidateGatewayNameTemplate(t *testing.T) {
+	testCases := []struct {
+		template       string
+		expectingError bool
+	}{
+		{template: "{class}"},
+		{template: "{namespace}-{class}"},
+		{template: "gateway", expectingError: true},
+		{template: "{class}-{ingress}", expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			err := ValidateGatewayNameTemplate(tc.template)
+			if tc.expectingError != (err != nil) {
+				t.Errorf("ValidateGatewayNameTemplate(%q) error = %v, expecting error: %v", tc.template, err, tc.expectingError)
+			}
+		})
+	}
+}
+
+func Test_toDNS1123Label(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	testCases := []struct {
+		name              string
+		input             string
+		expected          string
+		expectedTruncated bool
+	}{
+		{name: "valid", input: "test-app", expected: "test-app"},
+		{name: "invalid characters", input: "Test_App.v2", expected: "test-app-v2"},
+		{name: "leading and repeated separators", input: "-a--b-", expected: "a-b"},
+		{name: "no valid character", input: "__", expected: "route"},
+		{name: "long", input: long, expected: strings.Repeat("a", 54) + "-", expectedTruncated: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, truncated := toDNS1123Label(tc.input)
+			if truncated != tc.expectedTruncated {
+				t.Errorf("Expected truncated to be %t, got %t", tc.expectedTruncated, truncated)
+			}
+			if truncated {
+				if !strings.HasPrefix(actual, tc.expected) || len(actual) != maxTemplatedNameLength {
+					t.Errorf("Expected a %d characters name prefixed with %q, got %q", maxTemplatedNameLength, tc.expected, actual)
+				}
+			} else if actual != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, actual)
+			}
+			if msgs := validation.IsDNS1123Label(actual); len(msgs) > 0 {
+				t.Errorf("Expected a DNS-1123 label, got %q: %v", actual, msgs)
+			}
+		})
+	}
+}
+
+func Test_nameTemplate(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name string, hosts ...string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       networkingv1.IngressSpec{IngressClassName: stringPtr("nginx")},
+		}
+		for _, host := range hosts {
+			ingress.Spec.Rules = append(ingress.Spec.Rules, networkingv1.IngressRule{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: name,
+									Port: networkingv1.ServiceBackendPort{Number: 80},
+								},
+							},
+						}},
+					},
+				},
+			})
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name               string
+		template           string
+		ingresses          []networkingv1.Ingress
+		expectedRouteNames []string
+		expectNumWarnings  int
+	}{{
+		name:               "named after the host without template",
+		ingresses:          []networkingv1.Ingress{newIngress("app", "example.com")},
+		expectedRouteNames: []string{"example-com"},
+	}, {
+		name:               "keep Ingress name",
+		template:           KeepIngressNameTemplate,
+		ingresses:          []networkingv1.Ingress{newIngress("app", "example.com"), newIngress("api", "api.example.com")},
+		expectedRouteNames: []string{"api", "app"},
+	}, {
+		name:               "all placeholders",
+		template:           "{namespace}-{ingress}-{host}",
+		ingresses:          []networkingv1.Ingress{newIngress("app", "example.com")},
+		expectedRouteNames: []string{"test-app-example-com"},
+	}, {
+		name:               "colliding names are suffixed with the host",
+		template:           KeepIngressNameTemplate,
+		ingresses:          []networkingv1.Ingress{newIngress("app", "a.example.com", "b.example.com")},
+		expectedRouteNames: []string{"app", "app-b-example-com"},
+		expectNumWarnings:  1,
+	}, {
+		name:               "long names are truncated",
+		template:           "{ingress}-{host}",
+		ingresses:          []networkingv1.Ingress{newIngress("app", strings.Repeat("a", 60)+".example.com")},
+		expectedRouteNames: []string{"app-" + strings.Repeat("a", 50) + "-69544c51"},
+		expectNumWarnings:  1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpRoutes, _, _, _, warnings, errs := ingresses2GatewaysAndHTTPRoutes(tc.ingresses, Options{NameTemplate: tc.template})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			var routeNames []string
+			for _, route := range httpRoutes {
+				routeNames = append(routeNames, route.Name)
+			}
+			if diff := cmp.Diff(tc.expectedRouteNames, routeNames); diff != "" {
+				t.Errorf("Unexpected HTTPRoute names (-want +got):\n%s", diff)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func Test_gatewayNameTemplate(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, class, host string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr(class),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: name,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name                 string
+		template             string
+		ingresses            []networkingv1.Ingress
+		expectedGatewayNames []string
+		expectNumWarnings    int
+	}{{
+		name:                 "named after the class without template",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "nginx", "example.com")},
+		expectedGatewayNames: []string{"nginx"},
+	}, {
+		name:                 "all placeholders",
+		template:             "{namespace}-{class}",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "nginx", "example.com")},
+		expectedGatewayNames: []string{"test-nginx"},
+	}, {
+		name:                 "invalid characters",
+		template:             "{class}_gateway",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "nginx", "example.com")},
+		expectedGatewayNames: []string{"nginx-gateway"},
+	}, {
+		name:                 "shared names are suffixed with a hash",
+		template:             "{namespace}",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "nginx", "example.com"), newIngress("api", "internal", "api.example.com")},
+		expectedGatewayNames: []string{"test-9f2a50a3", "test-d0cde7b0"},
+		expectNumWarnings:    2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{GatewayNameTemplate: tc.template})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			gatewayNames := map[string]bool{}
+			var names []string
+			for _, gateway := range result.Gateways {
+				gatewayNames[gateway.Name] = true
+				names = append(names, gateway.Name)
+			}
+			if diff := cmp.Diff(tc.expectedGatewayNames, names); diff != "" {
+				t.Errorf("Unexpected Gateway names (-want +got):\n%s", diff)
+			}
+			for _, route := range result.HTTPRoutes {
+				for _, parentRef := range route.Spec.ParentRefs {
+					if !gatewayNames[string(parentRef.Name)] {
+						t.Errorf("HTTPRoute %s references Gateway %s, which isn't generated", route.Name, parentRef.Name)
+					}
+				}
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
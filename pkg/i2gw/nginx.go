@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const useRegexAnnotation = "nginx.ingress.kubernetes.io/use-regex"
+
+// ingressNginxProvider converts the nginx.ingress.kubernetes.io annotations.
+type ingressNginxProvider struct{}
+
+func (ingressNginxProvider) annotationPrefix() string {
+	return "nginx.ingress.kubernetes.io/"
+}
+
+func (p ingressNginxProvider) detect(ingress networkingv1.Ingress) bool {
+	return hasAnnotationPrefix(ingress, p.annotationPrefix())
+}
+
+func (ingressNginxProvider) inputKinds() []string {
+	return []string{"Ingress", "ConfigMap"}
+}
+
+func (ingressNginxProvider) summary() string {
+	return "Canaries, affinity, timeouts, TCP services, SSL passthrough, redirects, app roots, allowed methods, rewrites, regex paths, source ranges, basic authentication, rate limits, headers, CORS and backend protocols of ingress-nginx"
+}
+
+func (ingressNginxProvider) addExtra(ingress networkingv1.Ingress, configMaps map[types.NamespacedName]corev1.ConfigMap, e *extra) field.ErrorList {
+	var errs field.ErrorList
+	var err error
+
+	fieldPath := field.NewPath(ingress.Name).Child("metadata").Child("annotations")
+
+	request, response, warnings, headerErrs := getHeaderModifiers(ingress, configMaps)
+	e.requestHeaders = request.toFilter()
+	e.responseHeaders = response.toFilter()
+	e.warnings = append(e.warnings, warnings...)
+	errs = append(errs, headerErrs...)
+	if snippet, ok := ingress.Annotations["nginx.ingress.kubernetes.io/server-snippet"]; ok {
+		e.warnings = append(e.warnings, Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key("nginx.ingress.kubernetes.io/server-snippet"),
+			Message: fmt.Sprintf("nginx snippets cannot be represented in Gateway API and must be ported manually:\n%s", snippet),
+		})
+	}
+	if protocol := ingress.Annotations[backendProtocolAnnotation]; protocol != "" {
+		e.backendProtocol = strings.ToUpper(protocol)
+		e.backendTLS = getBackendTLS(ingress, e.backendProtocol)
+	}
+	if target := ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"]; target != "" {
+		e.rewriteTarget = target
+	}
+	affinity, affinityErrs := getSessionAffinity(ingress, fieldPath)
+	e.affinity = affinity
+	errs = append(errs, affinityErrs...)
+	if ingress.Annotations[useRegexAnnotation] == "true" {
+		e.useRegex = true
+		e.warnings = append(e.warnings, Warning{
+			Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			Field:   fieldPath.Key(useRegexAnnotation),
+			Message: "the Prefix and ImplementationSpecific paths are converted to RegularExpression path matches, which are not supported by all Gateway API implementations and whose regular expression syntax is implementation specific. ingress-nginx matches them case-insensitively",
+		})
+	}
+	e.sslPassthrough = ingress.Annotations[sslPassthroughAnnotation] == "true"
+	redirect, redirectWarns := getRedirect(ingress, fieldPath)
+	e.redirect = redirect
+	e.warnings = append(e.warnings, redirectWarns...)
+	appRoot, appRootWarn := getAppRoot(ingress, fieldPath)
+	e.appRoot = appRoot
+	if appRootWarn != nil {
+		e.warnings = append(e.warnings, *appRootWarn)
+	}
+	methods, methodsWarn := getSnippetMethods(ingress, fieldPath)
+	e.methods = methods
+	if methodsWarn != nil {
+		e.warnings = append(e.warnings, *methodsWarn)
+	}
+	sourceRanges, sourceRangesWarn, sourceRangesErr := getSourceRanges(ingress, fieldPath)
+	if sourceRangesErr != nil {
+		errs = append(errs, sourceRangesErr)
+	}
+	if sourceRangesWarn != nil {
+		e.sourceRanges = sourceRanges
+		e.policyWarnings = append(e.policyWarnings, policyWarning{annotation: whitelistSourceRangeAnnotation, warning: *sourceRangesWarn})
+	}
+	bodySize, bodySizeWarn := getProxyBodySize(ingress, fieldPath)
+	e.bodySize = bodySize
+	if bodySizeWarn != nil {
+		e.warnings = append(e.warnings, *bodySizeWarn)
+	}
+	if customErrorsWarn := getCustomHTTPErrors(ingress, fieldPath); customErrorsWarn != nil {
+		e.warnings = append(e.warnings, *customErrorsWarn)
+	}
+	basicAuth, basicAuthWarn := getBasicAuth(ingress, fieldPath)
+	e.basicAuth = basicAuth
+	switch {
+	case basicAuth != nil:
+		e.policyWarnings = append(e.policyWarnings, policyWarning{annotation: authTypeAnnotation, warning: *basicAuthWarn})
+	case basicAuthWarn != nil:
+		e.warnings = append(e.warnings, *basicAuthWarn)
+	}
+	rateLimits, rateLimitPolicyWarns, rateLimitWarns := getRateLimits(ingress, fieldPath)
+	e.rateLimits = rateLimits
+	e.policyWarnings = append(e.policyWarnings, rateLimitPolicyWarns...)
+	e.warnings = append(e.warnings, rateLimitWarns...)
+	timeouts, timeoutWarns := getProxyTimeouts(ingress, fieldPath)
+	e.timeouts = timeouts
+	e.warnings = append(e.warnings, timeoutWarns...)
+	if c := ingress.Annotations["nginx.ingress.kubernetes.io/canary"]; c == "true" {
+		e.canary = &canary{enable: true}
+		if cHeader := ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header"]; cHeader != "" {
+			e.canary.headerKey = cHeader
+			e.canary.headerValue = "always"
+			e.canary.headerFlag = true
+		}
+		if cHeaderVal := ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header-value"]; cHeaderVal != "" {
+			e.canary.headerValue = cHeaderVal
+			e.canary.headerFlag = false
+		}
+		// As with ingress-nginx, the header pattern is ignored when a header value
+		// is set.
+		if cHeaderRegex := ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header-pattern"]; cHeaderRegex != "" && e.canary.headerKey != "" && ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header-value"] == "" {
+			e.canary.headerValue = cHeaderRegex
+			e.canary.headerRegexMatch = true
+			e.canary.headerFlag = false
+			e.warnings = append(e.warnings, Warning{
+				Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+				Field:   fieldPath.Key("nginx.ingress.kubernetes.io/canary-by-header-pattern"),
+				Message: fmt.Sprintf("converted to a RegularExpression header match, which is not supported by all Gateway API implementations and whose regular expression syntax is implementation specific: %s", cHeaderRegex),
+			})
+		}
+		if cHeaderWeight := ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"]; cHeaderWeight != "" {
+			e.canary.weight, err = strconv.Atoi(cHeaderWeight)
+			if err != nil {
+				errs = append(errs, field.TypeInvalid(fieldPath, "nginx.ingress.kubernetes.io/canary-weight", err.Error()))
+			}
+			e.canary.weighted = true
+			e.canary.weightTotal = 100
+		}
+		if cHeaderWeightTotal := ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight-total"]; cHeaderWeightTotal != "" {
+			e.canary.weightTotal, err = strconv.Atoi(cHeaderWeightTotal)
+			if err != nil {
+				errs = append(errs, field.TypeInvalid(fieldPath, "nginx.ingress.kubernetes.io/canary-weight-total", err.Error()))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// pathValueRegex matches the characters allowed in the value of Exact and
+// PathPrefix HTTPRoute path matches.
+var pathValueRegex = regexp.MustCompile(`^(?:[-A-Za-z0-9/._~!$&'()*+,;=:@]|[%][0-9a-fA-F]{2})+$`)
+
+// normalizePath returns the path of an Ingress path as the value of the
+// equivalent HTTPRoute path match. Ingress Prefix paths match by path element
+// and ignore a trailing slash, whereas the PathPrefix match of "/foo/" doesn't
+// match "/foo", so the trailing slash is removed. An empty Prefix or
+// ImplementationSpecific path matches all paths.
+func normalizePath(path networkingv1.HTTPIngressPath) string {
+	if path.PathType != nil && *path.PathType == networkingv1.PathTypeImplementationSpecific && path.Path == "" {
+		return "/"
+	}
+	if path.PathType == nil || *path.PathType != networkingv1.PathTypePrefix {
+		return path.Path
+	}
+	if trimmed := strings.TrimRight(path.Path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
+
+// defaultPathTypes sets the ImplementationSpecific path type, the default of
+// the Kubernetes API, on the paths of the rule without path type, which older
+// Ingresses omit. The paths are copied, leaving the Ingress unchanged. The
+// ImplementationSpecific paths that aren't regular expressions are converted to
+// PathPrefix matches, which is returned as a warning for every path.
+func defaultPathTypes(ingress types.NamespacedName, rule *networkingv1.IngressRule, rulePath *field.Path, e *extra) []Warning {
+	if rule.HTTP == nil {
+		return nil
+	}
+	var warnings []Warning
+	paths := make([]networkingv1.HTTPIngressPath, len(rule.HTTP.Paths))
+	for i, path := range rule.HTTP.Paths {
+		defaulted := path.PathType == nil || *path.PathType == ""
+		if defaulted {
+			pathType := networkingv1.PathTypeImplementationSpecific
+			path.PathType = &pathType
+		}
+		paths[i] = path
+		if *path.PathType != networkingv1.PathTypeImplementationSpecific || isRegexPath(path, e) {
+			continue
+		}
+		msg := "the ImplementationSpecific path is converted to a PathPrefix match"
+		if defaulted {
+			msg = "the path has no pathType, it defaults to ImplementationSpecific and is converted to a PathPrefix match"
+		}
+		warnings = append(warnings, Warning{
+			Ingress: ingress,
+			Field:   rulePath.Child("http", "paths").Index(i).Child("pathType"),
+			Message: msg + ". PathPrefix matches whole path elements, whereas ingress controllers such as ingress-nginx match ImplementationSpecific paths as string prefixes; set the Prefix or Exact pathType to make the intent explicit",
+		})
+	}
+	http := *rule.HTTP
+	http.Paths = paths
+	rule.HTTP = &http
+	return warnings
+}
+
+// isRegexPath returns whether the path is a regular expression. With the
+// ingress-nginx use-regex annotation, Prefix and ImplementationSpecific paths
+// are regular expressions whereas Exact paths are still matched exactly.
+func isRegexPath(path networkingv1.HTTPIngressPath, e *extra) bool {
+	if e == nil || !e.useRegex {
+		return false
+	}
+	return path.PathType == nil || *path.PathType != networkingv1.PathTypeExact
+}
+
+// validatePathValue returns why the value can't be the value of an Exact or
+// PathPrefix HTTPRoute path match, following the validation of the Gateway
+// API, or an empty string when it can.
+func validatePathValue(value string) string {
+	if !strings.HasPrefix(value, "/") {
+		return "must be an absolute path"
+	}
+	for _, invalid := range []string{"//", "/./", "/../", "%2f", "%2F", "#"} {
+		if strings.Contains(value, invalid) {
+			return fmt.Sprintf("must not contain %q", invalid)
+		}
+	}
+	for _, invalid := range []string{"/.", "/.."} {
+		if strings.HasSuffix(value, invalid) {
+			return fmt.Sprintf("must not end with %q", invalid)
+		}
+	}
+	if !pathValueRegex.MatchString(value) {
+		return "must only contain valid URI path characters, regular expressions can't be represented as Exact or PathPrefix matches"
+	}
+	return ""
+}
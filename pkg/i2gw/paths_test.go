@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_pathNormalization(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+
+	testCases := []struct {
+		name              string
+		path              string
+		pathType          *networkingv1.PathType
+		expectedValue     string
+		expectedMatchType gatewayv1.PathMatchType
+		expectingError    bool
+	}{
+		{name: "prefix", path: "/foo", pathType: &iPrefix, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "prefix with trailing slash", path: "/foo/", pathType: &iPrefix, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "prefix with trailing slashes", path: "/foo//", pathType: &iPrefix, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "root prefix", path: "/", pathType: &iPrefix, expectedValue: "/", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "empty prefix", path: "", pathType: &iPrefix, expectedValue: "/", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "exact with trailing slash", path: "/foo/", pathType: &iExact, expectedValue: "/foo/", expectedMatchType: gatewayv1.PathMatchExact},
+		{name: "encoded characters", path: "/caf%C3%A9", pathType: &iExact, expectedValue: "/caf%C3%A9", expectedMatchType: gatewayv1.PathMatchExact},
+		{name: "relative path", path: "foo", pathType: &iExact, expectingError: true},
+		{name: "regular expression", path: "/foo/[0-9]+", pathType: &iPrefix, expectingError: true},
+		{name: "double slash", path: "/foo//bar", pathType: &iPrefix, expectingError: true},
+		{name: "dot segment", path: "/foo/../bar", pathType: &iPrefix, expectingError: true},
+		{name: "trailing dot segment", path: "/foo/.", pathType: &iExact, expectingError: true},
+		{name: "encoded slash", path: "/foo%2Fbar", pathType: &iExact, expectingError: true},
+		{name: "fragment", path: "/foo#bar", pathType: &iExact, expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: stringPtr("nginx"),
+					Rules: []networkingv1.IngressRule{{
+						Host: "example.com",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{{
+									Path:     tc.path,
+									PathType: tc.pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "example",
+											Port: networkingv1.ServiceBackendPort{Number: 80},
+										},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			}
+
+			httpRoutes, _, _, _, _, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+			if tc.expectingError {
+				if len(errs) == 0 {
+					t.Fatalf("Expected an error for path %q, got HTTPRoutes %+v", tc.path, httpRoutes)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			match := httpRoutes[0].Spec.Rules[0].Matches[0].Path
+			if *match.Value != tc.expectedValue || *match.Type != tc.expectedMatchType {
+				t.Errorf("Expected %s match on %q, got %s match on %q", tc.expectedMatchType, tc.expectedValue, *match.Type, *match.Value)
+			}
+		})
+	}
+}
+
+func Test_pathNormalizationMergesEquivalentPaths(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newPath := func(path string) networkingv1.HTTPIngressPath {
+		return networkingv1.HTTPIngressPath{
+			Path:     path,
+			PathType: &iPrefix,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "example",
+					Port: networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		}
+	}
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{newPath("/foo"), newPath("/foo/")},
+					},
+				},
+			}},
+		},
+	}
+
+	httpRoutes, _, _, _, _, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, nil, nil, nil, false)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected conversion errors: %+v", errs)
+	}
+	if len(httpRoutes) != 1 || len(httpRoutes[0].Spec.Rules) != 1 {
+		t.Fatalf("Expected an HTTPRoute with a single rule, got %+v", httpRoutes)
+	}
+}
+
+func Test_useRegexPaths(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	iExact := networkingv1.PathTypeExact
+	iImplementationSpecific := networkingv1.PathTypeImplementationSpecific
+	iEmpty := networkingv1.PathType("")
+
+	testCases := []struct {
+		name              string
+		useRegex          string
+		path              string
+		pathType          *networkingv1.PathType
+		expectedValue     string
+		expectedMatchType gatewayv1.PathMatchType
+		expectNumWarnings int
+		expectingError    bool
+	}{
+		{name: "regex prefix", useRegex: "true", path: "/foo/[0-9]+", pathType: &iPrefix, expectedValue: "/foo/[0-9]+", expectedMatchType: gatewayv1.PathMatchRegularExpression, expectNumWarnings: 1},
+		{name: "regex implementation specific", useRegex: "true", path: "/api/v[12]/.*", pathType: &iImplementationSpecific, expectedValue: "/api/v[12]/.*", expectedMatchType: gatewayv1.PathMatchRegularExpression, expectNumWarnings: 1},
+		{name: "trailing slash kept", useRegex: "true", path: "/foo/", pathType: &iPrefix, expectedValue: "/foo/", expectedMatchType: gatewayv1.PathMatchRegularExpression, expectNumWarnings: 1},
+		{name: "exact path with regex", useRegex: "true", path: "/foo", pathType: &iExact, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchExact, expectNumWarnings: 1},
+		{name: "regex disabled", useRegex: "false", path: "/foo/", pathType: &iPrefix, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "no annotation", path: "/foo/", pathType: &iPrefix, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix},
+		{name: "regex without annotation", path: "/foo/[0-9]+", pathType: &iPrefix, expectingError: true},
+		{name: "no path type", path: "/foo", expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix, expectNumWarnings: 1},
+		{name: "empty path type", path: "/foo/", pathType: &iEmpty, expectedValue: "/foo/", expectedMatchType: gatewayv1.PathMatchPathPrefix, expectNumWarnings: 1},
+		{name: "implementation specific", path: "/foo", pathType: &iImplementationSpecific, expectedValue: "/foo", expectedMatchType: gatewayv1.PathMatchPathPrefix, expectNumWarnings: 1},
+		{name: "empty implementation specific path", pathType: &iImplementationSpecific, expectedValue: "/", expectedMatchType: gatewayv1.PathMatchPathPrefix, expectNumWarnings: 1},
+		{name: "regex without path type", useRegex: "true", path: "/foo/[0-9]+", expectedValue: "/foo/[0-9]+", expectedMatchType: gatewayv1.PathMatchRegularExpression, expectNumWarnings: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: stringPtr("nginx"),
+					Rules: []networkingv1.IngressRule{{
+						Host: "example.com",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{{
+									Path:     tc.path,
+									PathType: tc.pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "example",
+											Port: networkingv1.ServiceBackendPort{Number: 80},
+										},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			}
+			if tc.useRegex != "" {
+				ingress.Annotations = map[string]string{useRegexAnnotation: tc.useRegex}
+			}
+
+			httpRoutes, _, _, _, warnings, errs := ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, Options{})
+			if tc.expectingError {
+				if len(errs) == 0 {
+					t.Fatalf("Expected an error for path %q, got HTTPRoutes %+v", tc.path, httpRoutes)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			match := httpRoutes[0].Spec.Rules[0].Matches[0].Path
+			if *match.Value != tc.expectedValue || *match.Type != tc.expectedMatchType {
+				t.Errorf("Expected %s match on %q, got %s match on %q", tc.expectedMatchType, tc.expectedValue, *match.Type, *match.Value)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func Test_defaultPathTypes(t *testing.T) {
+	iExact := networkingv1.PathTypeExact
+	rule := networkingv1.IngressRule{
+		IngressRuleValue: networkingv1.IngressRuleValue{
+			HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{Path: "/legacy"}, {Path: "/exact", PathType: &iExact}},
+			},
+		},
+	}
+	original := rule.HTTP
+
+	warnings := defaultPathTypes(types.NamespacedName{Namespace: "test", Name: "example"}, &rule, field.NewPath("example", "spec", "rules").Index(0), nil)
+	if len(warnings) != 1 || warnings[0].Field.String() != "example.spec.rules[0].http.paths[0].pathType" {
+		t.Errorf("Expected a warning for the path without pathType, got %+v", warnings)
+	}
+	if pathType := rule.HTTP.Paths[0].PathType; pathType == nil || *pathType != networkingv1.PathTypeImplementationSpecific {
+		t.Errorf("Expected the ImplementationSpecific pathType, got %v", pathType)
+	}
+	if *rule.HTTP.Paths[1].PathType != networkingv1.PathTypeExact {
+		t.Errorf("Expected the Exact pathType to be kept, got %v", *rule.HTTP.Paths[1].PathType)
+	}
+	if original.Paths[0].PathType != nil {
+		t.Errorf("Expected the paths of the Ingress to be left unchanged, got %v", *original.Paths[0].PathType)
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	// TargetImplementationEnvoyGateway generates Envoy Gateway
+	// SecurityPolicies for the source IP allow-lists and the basic
+	// authentication of the Ingresses, and BackendTrafficPolicies for their
+	// request rate limits.
+	TargetImplementationEnvoyGateway = "envoy-gateway"
+	// TargetImplementationNGINXGatewayFabric generates NGINX Gateway Fabric
+	// ClientSettingsPolicies for the proxy body sizes of the Ingresses.
+	TargetImplementationNGINXGatewayFabric = "nginx-gateway-fabric"
+)
+
+// targetImplementation converts the annotations that Gateway API can't
+// represent to the policy attachments of a Gateway API implementation.
+type targetImplementation struct {
+	// annotations are the annotations converted to policies.
+	annotations []string
+	// policies returns the policies of the routes of the rule group, named
+	// name, of the given kinds.
+	policies func(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning)
+}
+
+var targetImplementations = map[string]targetImplementation{
+	TargetImplementationEnvoyGateway: {
+		annotations: []string{whitelistSourceRangeAnnotation, authTypeAnnotation, limitRPSAnnotation, limitRPMAnnotation},
+		policies:    combinePolicies(envoyGatewaySecurityPolicies, envoyGatewayRateLimitPolicies),
+	},
+	TargetImplementationNGINXGatewayFabric: {
+		annotations: []string{proxyBodySizeAnnotation},
+		policies:    nginxGatewayFabricPolicies,
+	},
+}
+
+// combinePolicies returns the policies of every function.
+func combinePolicies(fns ...func(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning)) func(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning) {
+	return func(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning) {
+		var policies []unstructured.Unstructured
+		var warnings []Warning
+		for _, fn := range fns {
+			fnPolicies, fnWarnings := fn(rg, name, kinds)
+			policies = append(policies, fnPolicies...)
+			warnings = append(warnings, fnWarnings...)
+		}
+		return policies, warnings
+	}
+}
+
+// policyAnnotations are the annotations only converted to policies of some
+// target implementations, which are reported as warnings otherwise. The
+// source IP allow-lists, basic authentications and rate limits are left out,
+// as they have a policyWarning of their own.
+var policyAnnotations = []struct {
+	annotation string
+	// value returns the converted value of the annotation, empty when the
+	// annotation isn't set.
+	value func(e *extra) string
+}{
+	{annotation: proxyBodySizeAnnotation, value: (*extra).clientBodySize},
+}
+
+// policyWarning is the warning of an annotation converted to the policies of
+// some target implementations, reported unless the policies of the routes
+// enforce the annotation.
+type policyWarning struct {
+	annotation string
+	warning    Warning
+}
+
+// unenforcedWarning returns the warning of an annotation no policy enforces,
+// naming the target implementations converting it when the target
+// implementation doesn't.
+func (a *ingressAggregator) unenforcedWarning(pw policyWarning) Warning {
+	w := pw.warning
+	for _, annotation := range targetImplementations[a.targetImplementation].annotations {
+		if annotation == pw.annotation {
+			return w
+		}
+	}
+	w.Message += targetsHint(pw.annotation)
+	return w
+}
+
+// enforce records that the policies generated for the routes of the rule
+// group enforce the annotations.
+func (rg *ingressRuleGroup) enforce(annotations ...string) {
+	if rg.enforced == nil {
+		rg.enforced = map[string]bool{}
+	}
+	for _, annotation := range annotations {
+		rg.enforced[annotation] = true
+	}
+}
+
+// TargetImplementations returns the implementations whose policies are
+// generated for the annotations Gateway API can't represent.
+func TargetImplementations() []string {
+	var names []string
+	for name := range targetImplementations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsKnownTargetImplementation reports whether policies are generated for the
+// target implementation. Only core resources are generated for other targets.
+func IsKnownTargetImplementation(name string) bool {
+	_, ok := targetImplementations[name]
+	return ok
+}
+
+// toPolicies returns the policies of the target implementation for the
+// routes of every rule group, along with warnings for the annotations the
+// target implementation doesn't convert, and for the annotations no
+// generated policy enforces.
+func (a *ingressAggregator) toPolicies(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute) ([]unstructured.Unstructured, []Warning) {
+	target := targetImplementations[a.targetImplementation]
+	mapped := map[string]bool{}
+	for _, annotation := range target.annotations {
+		mapped[annotation] = true
+	}
+
+	routeKinds := map[types.NamespacedName][]string{}
+	for _, route := range httpRoutes {
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		routeKinds[key] = append(routeKinds[key], "HTTPRoute")
+	}
+	for _, route := range grpcRoutes {
+		key := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+		routeKinds[key] = append(routeKinds[key], "GRPCRoute")
+	}
+
+	rgKeys := make([]string, 0, len(a.ruleGroups))
+	for rgKey := range a.ruleGroups {
+		rgKeys = append(rgKeys, string(rgKey))
+	}
+	sort.Strings(rgKeys)
+
+	var policies []unstructured.Unstructured
+	var warnings []Warning
+	// The rules of an Ingress share its extra, it is only reported once.
+	reported := map[*extra]bool{}
+	for _, rgKey := range rgKeys {
+		rg := a.ruleGroups[ruleGroupKey(rgKey)]
+		for _, ir := range rg.rules {
+			if ir.extra == nil || reported[ir.extra] {
+				continue
+			}
+			reported[ir.extra] = true
+			for _, pa := range policyAnnotations {
+				if value := pa.value(ir.extra); value != "" && !mapped[pa.annotation] {
+					warnings = append(warnings, Warning{
+						Ingress: ir.ingress,
+						Field:   field.NewPath(ir.ingress.Name, "metadata", "annotations").Key(pa.annotation),
+						Message: fmt.Sprintf("%q is not converted, Gateway API can't represent it and it must be configured on the Gateway implementation%s", value, targetsHint(pa.annotation)),
+					})
+				}
+			}
+		}
+
+		kinds := routeKinds[types.NamespacedName{Namespace: rg.namespace, Name: rg.routeName()}]
+		if target.policies != nil && len(kinds) > 0 {
+			rgPolicies, rgWarnings := target.policies(rg, rg.routeName(), kinds)
+			for i := range rgPolicies {
+				a.annotateSources(&rgPolicies[i], rg.ingresses()...)
+			}
+			policies = append(policies, rgPolicies...)
+			warnings = append(warnings, rgWarnings...)
+		}
+
+		// The policy warnings are bound to the HTTPRoute of the rule group, as
+		// the other warnings of its Ingresses.
+		var route types.NamespacedName
+		for _, kind := range kinds {
+			if kind == "HTTPRoute" {
+				route = types.NamespacedName{Namespace: rg.namespace, Name: rg.routeName()}
+			}
+		}
+		seen := map[*extra]bool{}
+		for _, ir := range rg.rules {
+			if ir.extra == nil || seen[ir.extra] {
+				continue
+			}
+			seen[ir.extra] = true
+			for _, pw := range ir.extra.policyWarnings {
+				if rg.enforced[pw.annotation] {
+					continue
+				}
+				w := a.unenforcedWarning(pw)
+				w.HTTPRoute = route
+				warnings = append(warnings, w)
+			}
+		}
+	}
+	// No policy is generated for the routes of the default backends.
+	for _, db := range a.defaultBackends {
+		if db.extra == nil {
+			continue
+		}
+		for _, pw := range db.extra.policyWarnings {
+			w := a.unenforcedWarning(pw)
+			w.HTTPRoute = types.NamespacedName{Namespace: db.namespace, Name: defaultBackendRouteName(db.name)}
+			warnings = append(warnings, w)
+		}
+	}
+	return policies, warnings
+}
+
+// targetsHint returns the hint of the warning of an annotation the target
+// implementation doesn't convert, naming the target implementations that do.
+func targetsHint(annotation string) string {
+	for _, name := range TargetImplementations() {
+		for _, a := range targetImplementations[name].annotations {
+			if a == annotation {
+				return fmt.Sprintf(", e.g. with --target-implementation=%s", name)
+			}
+		}
+	}
+	return ""
+}
+
+// commonValue returns the value of the rules of the rule group, and whether
+// they all have the same value. Policies apply to all the paths of their
+// routes, so they can't represent different values.
+func (rg *ingressRuleGroup) commonValue(value func(e *extra) string) (string, bool) {
+	var common string
+	for i, ir := range rg.rules {
+		v := value(ir.extra)
+		if i == 0 {
+			common = v
+		} else if v != common {
+			return "", false
+		}
+	}
+	return common, true
+}
+
+// differentValuesWarning returns the warning of an annotation whose values
+// differ between the Ingresses of the rule group.
+func (rg *ingressRuleGroup) differentValuesWarning(annotation, message string) Warning {
+	return Warning{
+		Ingress: rg.rules[0].ingress,
+		Field:   field.NewPath(rg.rules[0].ingress.Name, "metadata", "annotations").Key(annotation),
+		Message: message,
+	}
+}
+
+// policyTargetRef returns the targetRef of a policy attached to a route.
+func policyTargetRef(kind, name string) map[string]interface{} {
+	return map[string]interface{}{
+		"group": gatewayv1.GroupName,
+		"kind":  kind,
+		"name":  name,
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_toPolicies(t *testing.T) {
+	clientSettingsPolicy := func(maxSize string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.nginx.org/v1alpha1",
+			"kind":       "ClientSettingsPolicy",
+			"metadata": map[string]interface{}{
+				"name":      "example-com-client-settings",
+				"namespace": "test",
+			},
+			"spec": map[string]interface{}{
+				"targetRef": map[string]interface{}{
+					"group": "gateway.networking.k8s.io",
+					"kind":  "HTTPRoute",
+					"name":  "example-com",
+				},
+				"body": map[string]interface{}{
+					"maxSize": maxSize,
+				},
+			},
+		}}
+	}
+	bodySize := func(size string) map[string]string {
+		return map[string]string{proxyBodySizeAnnotation: size}
+	}
+
+	testCases := []struct {
+		name                 string
+		ingresses            []networkingv1.Ingress
+		targetImplementation string
+		expectedPolicies     []unstructured.Unstructured
+		expectedNumWarnings  int
+	}{{
+		name:                "core only",
+		ingresses:           []networkingv1.Ingress{newNginxIngress("app", "/", bodySize("8m"))},
+		expectedNumWarnings: 1,
+	}, {
+		name:                 "unknown target",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", bodySize("8m"))},
+		targetImplementation: "cilium",
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "target without the annotation",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", bodySize("8m"))},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "NGINX Gateway Fabric ClientSettingsPolicy",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", bodySize("8m")), newNginxIngress("api", "/api", bodySize("8m"))},
+		targetImplementation: TargetImplementationNGINXGatewayFabric,
+		expectedPolicies:     []unstructured.Unstructured{clientSettingsPolicy("8m")},
+	}, {
+		name:                 "different body sizes on a host",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", bodySize("8m")), newNginxIngress("upload", "/upload", bodySize("1g"))},
+		targetImplementation: TargetImplementationNGINXGatewayFabric,
+		expectedNumWarnings:  1,
+	}, {
+		name:                 "source ranges unmapped by the target",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{whitelistSourceRangeAnnotation: "10.0.0.0/8"})},
+		targetImplementation: TargetImplementationNGINXGatewayFabric,
+		expectedNumWarnings:  1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{TargetImplementation: tc.targetImplementation})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if diff := cmp.Diff(tc.expectedPolicies, result.Policies); diff != "" {
+				t.Errorf("Unexpected policies (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
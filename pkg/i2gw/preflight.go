@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// routeBackendRef is a backend of a generated route, along with the path of
+// the backend in the route.
+type routeBackendRef struct {
+	ref  gatewayv1.BackendObjectReference
+	path *field.Path
+}
+
+// Preflight verifies that the Services referenced as backends by the
+// routes of the conversion result exist in the cluster, expose the referenced ports and have
+// ready endpoints. A warning is returned for every backend that would not
+// serve traffic after the migration. Warnings for HTTPRoutes are bound to the
+// route.
+func Preflight(ctx context.Context, cl client.Client, result Result) ([]Warning, error) {
+	var warnings []Warning
+	check := func(kind string, route types.NamespacedName, backendRefs []routeBackendRef) error {
+		for _, backendRef := range backendRefs {
+			warning, err := checkBackend(ctx, cl, route, backendRef.ref)
+			if err != nil {
+				return err
+			}
+			if warning == "" {
+				continue
+			}
+			w := Warning{
+				Field:   backendRef.path,
+				Message: fmt.Sprintf("%s %s: %s", kind, route, warning),
+			}
+			if kind == "HTTPRoute" {
+				w.HTTPRoute = route
+			}
+			warnings = append(warnings, w)
+		}
+		return nil
+	}
+
+	for _, route := range result.HTTPRoutes {
+		var backendRefs []routeBackendRef
+		for i, rule := range route.Spec.Rules {
+			for j, backendRef := range rule.BackendRefs {
+				backendRefs = append(backendRefs, routeBackendRef{backendRef.BackendObjectReference, field.NewPath("spec", "rules").Index(i).Child("backendRefs").Index(j)})
+			}
+		}
+		if err := check("HTTPRoute", types.NamespacedName{Namespace: route.Namespace, Name: route.Name}, backendRefs); err != nil {
+			return nil, err
+		}
+	}
+	for _, route := range result.GRPCRoutes {
+		var backendRefs []routeBackendRef
+		for i, rule := range route.Spec.Rules {
+			for j, backendRef := range rule.BackendRefs {
+				backendRefs = append(backendRefs, routeBackendRef{backendRef.BackendObjectReference, field.NewPath("spec", "rules").Index(i).Child("backendRefs").Index(j)})
+			}
+		}
+		if err := check("GRPCRoute", types.NamespacedName{Namespace: route.Namespace, Name: route.Name}, backendRefs); err != nil {
+			return nil, err
+		}
+	}
+	for _, route := range result.TCPRoutes {
+		var backendRefs []routeBackendRef
+		for i, rule := range route.Spec.Rules {
+			for j, backendRef := range rule.BackendRefs {
+				backendRefs = append(backendRefs, routeBackendRef{backendRef.BackendObjectReference, field.NewPath("spec", "rules").Index(i).Child("backendRefs").Index(j)})
+			}
+		}
+		if err := check("TCPRoute", types.NamespacedName{Namespace: route.Namespace, Name: route.Name}, backendRefs); err != nil {
+			return nil, err
+		}
+	}
+	for _, route := range result.TLSRoutes {
+		var backendRefs []routeBackendRef
+		for i, rule := range route.Spec.Rules {
+			for j, backendRef := range rule.BackendRefs {
+				backendRefs = append(backendRefs, routeBackendRef{backendRef.BackendObjectReference, field.NewPath("spec", "rules").Index(i).Child("backendRefs").Index(j)})
+			}
+		}
+		if err := check("TLSRoute", types.NamespacedName{Namespace: route.Namespace, Name: route.Name}, backendRefs); err != nil {
+			return nil, err
+		}
+	}
+	return warnings, nil
+}
+
+// checkBackend returns why the Service referenced by a backend of the route
+// would not serve traffic, or an empty string when it would. Backends other
+// than Services are not checked.
+func checkBackend(ctx context.Context, cl client.Client, route types.NamespacedName, ref gatewayv1.BackendObjectReference) (string, error) {
+	if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Service") {
+		return "", nil
+	}
+	key := types.NamespacedName{Namespace: route.Namespace, Name: string(ref.Name)}
+	if ref.Namespace != nil {
+		key.Namespace = string(*ref.Namespace)
+	}
+
+	var svc corev1.Service
+	if err := cl.Get(ctx, key, &svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("backend Service %s not found", key), nil
+		}
+		return "", fmt.Errorf("failed to get Service %s from the cluster: %w", key, err)
+	}
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return "", nil
+	}
+
+	var svcPort *corev1.ServicePort
+	for i, port := range svc.Spec.Ports {
+		if ref.Port != nil && port.Port == int32(*ref.Port) {
+			svcPort = &svc.Spec.Ports[i]
+		}
+	}
+	if ref.Port != nil && svcPort == nil {
+		return fmt.Sprintf("backend Service %s has no port %d", key, *ref.Port), nil
+	}
+
+	var endpoints corev1.Endpoints
+	if err := cl.Get(ctx, key, &endpoints); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("backend Service %s has no endpoints", key), nil
+		}
+		return "", fmt.Errorf("failed to get Endpoints %s from the cluster: %w", key, err)
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) == 0 {
+			continue
+		}
+		if svcPort == nil {
+			return "", nil
+		}
+		for _, port := range subset.Ports {
+			if port.Name == svcPort.Name {
+				return "", nil
+			}
+		}
+	}
+	if svcPort == nil {
+		return fmt.Sprintf("backend Service %s has no ready endpoints", key), nil
+	}
+	return fmt.Sprintf("backend Service %s has no ready endpoints for port %d", key, svcPort.Port), nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_Preflight(t *testing.T) {
+	newService := func(name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 8080}},
+			},
+		}
+	}
+	newEndpoints := func(name string, ready bool) *corev1.Endpoints {
+		subset := corev1.EndpointSubset{
+			Ports: []corev1.EndpointPort{{Name: "http", Port: 8080}},
+		}
+		address := corev1.EndpointAddress{IP: "10.0.0.1"}
+		if ready {
+			subset.Addresses = []corev1.EndpointAddress{address}
+		} else {
+			subset.NotReadyAddresses = []corev1.EndpointAddress{address}
+		}
+		return &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Subsets:    []corev1.EndpointSubset{subset},
+		}
+	}
+	newHTTPRoute := func(backend string, port int) gatewayv1beta1.HTTPRoute {
+		return gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+			Spec: gatewayv1beta1.HTTPRouteSpec{
+				Rules: []gatewayv1beta1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1beta1.HTTPBackendRef{{
+						BackendRef: gatewayv1beta1.BackendRef{
+							BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+								Name: gatewayv1beta1.ObjectName(backend),
+								Port: portNumberPtr(port),
+							},
+						},
+					}},
+				}},
+			},
+		}
+	}
+
+	newTLSRoute := func(backend string, port int) gatewayv1alpha2.TLSRoute {
+		return gatewayv1alpha2.TLSRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "secure-example-com", Namespace: "test"},
+			Spec: gatewayv1alpha2.TLSRouteSpec{
+				Rules: []gatewayv1alpha2.TLSRouteRule{{
+					BackendRefs: []gatewayv1alpha2.BackendRef{{
+						BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+							Name: gatewayv1alpha2.ObjectName(backend),
+							Port: portNumberPtr(port),
+						},
+					}},
+				}},
+			},
+		}
+	}
+	httpRoutes := func(routes ...gatewayv1beta1.HTTPRoute) Result {
+		return Result{HTTPRoutes: routes}
+	}
+
+	testCases := []struct {
+		name           string
+		objects        []client.Object
+		result         Result
+		expectMessages []string
+	}{{
+		name:    "ready backend",
+		objects: []client.Object{newService("example"), newEndpoints("example", true)},
+		result:  httpRoutes(newHTTPRoute("example", 8080)),
+	}, {
+		name:           "missing backend",
+		result:         httpRoutes(newHTTPRoute("example", 8080)),
+		expectMessages: []string{"HTTPRoute test/example-com: backend Service test/example not found"},
+	}, {
+		name:           "missing port",
+		objects:        []client.Object{newService("example"), newEndpoints("example", true)},
+		result:         httpRoutes(newHTTPRoute("example", 9090)),
+		expectMessages: []string{"HTTPRoute test/example-com: backend Service test/example has no port 9090"},
+	}, {
+		name:           "unready backend",
+		objects:        []client.Object{newService("example"), newEndpoints("example", false)},
+		result:         httpRoutes(newHTTPRoute("example", 8080)),
+		expectMessages: []string{"HTTPRoute test/example-com: backend Service test/example has no ready endpoints for port 8080"},
+	}, {
+		name:           "backend without endpoints",
+		objects:        []client.Object{newService("example")},
+		result:         httpRoutes(newHTTPRoute("example", 8080)),
+		expectMessages: []string{"HTTPRoute test/example-com: backend Service test/example has no endpoints"},
+	}, {
+		name:           "missing TLSRoute backend",
+		result:         Result{TLSRoutes: []gatewayv1alpha2.TLSRoute{newTLSRoute("secure", 443)}},
+		expectMessages: []string{"TLSRoute test/secure-example-com: backend Service test/secure not found"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(tc.objects...).Build()
+
+			warnings, err := Preflight(context.Background(), cl, tc.result)
+			if err != nil {
+				t.Fatalf("Preflight() failed: %v", err)
+			}
+			var messages []string
+			for _, w := range warnings {
+				messages = append(messages, w.Message)
+				if len(tc.result.HTTPRoutes) > 0 && w.HTTPRoute != (types.NamespacedName{Namespace: "test", Name: "example-com"}) {
+					t.Errorf("Expected warning to be bound to HTTPRoute test/example-com, got %s", w.HTTPRoute)
+				}
+			}
+			if diff := cmp.Diff(tc.expectMessages, messages); diff != "" {
+				t.Errorf("Unexpected warnings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ProviderIngressNginx converts the annotations of ingress-nginx.
+	ProviderIngressNginx = "ingress-nginx"
+	// ProviderHAProxy converts the annotations of the HAProxy Ingress
+	// controller.
+	ProviderHAProxy = "haproxy"
+	// ProviderKong converts the annotations of the Kong Ingress controller.
+	ProviderKong = "kong"
+	// ProviderALB converts the annotations of the AWS Load Balancer
+	// controller.
+	ProviderALB = "alb"
+	// ProviderGCE converts the annotations of the GKE Ingress controller.
+	ProviderGCE = "gce"
+)
+
+// provider converts the annotations specific to an Ingress controller into
+// the configuration of the routes generated from the Ingress.
+type provider interface {
+	// annotationPrefix returns the prefix of the annotations of the
+	// provider.
+	annotationPrefix() string
+
+	// detect returns whether the Ingress has annotations of the provider.
+	detect(ingress networkingv1.Ingress) bool
+
+	// inputKinds returns the kinds of the resources the provider reads.
+	inputKinds() []string
+
+	// summary returns a one-line description of what the provider converts.
+	summary() string
+
+	// addExtra reads the annotations of the provider into e.
+	addExtra(ingress networkingv1.Ingress, configMaps map[types.NamespacedName]corev1.ConfigMap, e *extra) field.ErrorList
+}
+
+var providers = map[string]provider{
+	ProviderIngressNginx: ingressNginxProvider{},
+	ProviderHAProxy:      haproxyProvider{},
+	ProviderKong:         kongProvider{},
+	ProviderALB:          albProvider{},
+	ProviderGCE:          gceProvider{},
+}
+
+// annotationMatcher is implemented by the providers reading annotations
+// outside of their annotation prefix.
+type annotationMatcher interface {
+	// readsAnnotation returns whether the provider reads the annotation,
+	// whatever its prefix.
+	readsAnnotation(key string) bool
+}
+
+// ProviderNames returns the sorted names of the supported providers.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderInfo describes a supported provider.
+type ProviderInfo struct {
+	// Name is the name of the provider, as selected with the Providers
+	// option.
+	Name string
+	// AnnotationPrefix is the prefix of the Ingress annotations the provider
+	// converts.
+	AnnotationPrefix string
+	// InputKinds are the kinds of the resources the provider reads.
+	InputKinds []string
+	// Summary is a one-line description of what the provider converts.
+	Summary string
+}
+
+// ListProviders returns the supported providers, sorted by name.
+func ListProviders() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(providers))
+	for _, name := range ProviderNames() {
+		p := providers[name]
+		infos = append(infos, ProviderInfo{
+			Name:             name,
+			AnnotationPrefix: p.annotationPrefix(),
+			InputKinds:       p.inputKinds(),
+			Summary:          p.summary(),
+		})
+	}
+	return infos
+}
+
+// ValidateProviders returns an error when one of the names isn't a supported
+// provider.
+func ValidateProviders(names []string) error {
+	for _, name := range names {
+		if _, ok := providers[name]; !ok {
+			return fmt.Errorf("%s is not a supported provider, must be one of: %s", name, strings.Join(ProviderNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// ValidateAnnotationPrefix returns an error when the prefix, with or without
+// its trailing slash, isn't the annotation prefix of a supported provider.
+func ValidateAnnotationPrefix(prefix string) error {
+	if prefix == "" || providerOfPrefix(prefix) != "" {
+		return nil
+	}
+	prefixes := make([]string, 0, len(providers))
+	for _, name := range ProviderNames() {
+		prefixes = append(prefixes, strings.TrimSuffix(providers[name].annotationPrefix(), "/"))
+	}
+	return fmt.Errorf("%s is not the annotation prefix of a supported provider, must be one of: %s", prefix, strings.Join(prefixes, ", "))
+}
+
+// providerOfPrefix returns the name of the provider of the annotation prefix,
+// empty when no provider has the prefix.
+func providerOfPrefix(prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	for _, name := range ProviderNames() {
+		if providers[name].annotationPrefix() == prefix {
+			return name
+		}
+	}
+	return ""
+}
+
+// scopeAnnotations removes the annotations of the providers other than the
+// provider of the annotation prefix from the Ingress, so that the annotations
+// of another controller are never converted. The annotations are copied, as
+// the Ingress shares them with its caller.
+func scopeAnnotations(ingress *networkingv1.Ingress, prefix string) {
+	name := providerOfPrefix(prefix)
+	if name == "" {
+		return
+	}
+	annotations := make(map[string]string, len(ingress.Annotations))
+	for key, value := range ingress.Annotations {
+		if other := annotationProvider(key); other != "" && other != name {
+			klog.V(3).Infof("Ingress %s/%s: annotation %s is ignored, only the %s annotations are converted", ingress.Namespace, ingress.Name, key, strings.TrimSuffix(providers[name].annotationPrefix(), "/"))
+			continue
+		}
+		annotations[key] = value
+	}
+	ingress.Annotations = annotations
+}
+
+// annotationProvider returns the name of the provider of the annotation,
+// empty when no provider reads it.
+func annotationProvider(key string) string {
+	for _, name := range ProviderNames() {
+		p := providers[name]
+		if strings.HasPrefix(key, p.annotationPrefix()) {
+			return name
+		}
+		if m, ok := p.(annotationMatcher); ok && m.readsAnnotation(key) {
+			return name
+		}
+	}
+	return ""
+}
+
+// enabledProviders returns the selected providers, or the providers whose
+// annotations the Ingress has when none is selected, sorted by name.
+func enabledProviders(ingress networkingv1.Ingress, selected []string) []provider {
+	var enabled []provider
+	for _, name := range ProviderNames() {
+		if providerEnabled(name, ingress, selected) {
+			enabled = append(enabled, providers[name])
+		}
+	}
+	return enabled
+}
+
+// providerEnabled returns whether the named provider is selected, or detected
+// on the Ingress when no provider is selected.
+func providerEnabled(name string, ingress networkingv1.Ingress, selected []string) bool {
+	if len(selected) == 0 {
+		return providers[name].detect(ingress)
+	}
+	for _, s := range selected {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// coreAnnotationPrefixes are the prefixes of the annotations read by the
+// conversion whatever the providers.
+var coreAnnotationPrefixes = []string{"kubernetes.io/ingress.class", "ingress2gateway.kubernetes.io/", "external-dns.alpha.kubernetes.io/"}
+
+// logAnnotations logs, at verbosity 3, whether every annotation of the
+// Ingress is converted by an enabled provider, skipped because its provider
+// isn't enabled, or isn't known to the conversion.
+func logAnnotations(ingress networkingv1.Ingress, selected []string) {
+	if !klog.V(3).Enabled() {
+		return
+	}
+	keys := make([]string, 0, len(ingress.Annotations))
+	for key := range ingress.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		klog.V(3).Infof("Ingress %s/%s: annotation %s %s", ingress.Namespace, ingress.Name, key, annotationStatus(key, ingress, selected))
+	}
+}
+
+// annotationStatus describes how the annotation of the Ingress is handled by
+// the conversion.
+func annotationStatus(key string, ingress networkingv1.Ingress, selected []string) string {
+	for _, prefix := range coreAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return "is read by the conversion"
+		}
+	}
+	name := annotationProvider(key)
+	if name == "" {
+		return "is skipped, no provider reads it"
+	}
+	if providerEnabled(name, ingress, selected) {
+		return fmt.Sprintf("is matched by the %s provider", name)
+	}
+	return fmt.Sprintf("is skipped, the %s provider isn't enabled", name)
+}
+
+// hasAnnotationPrefix returns whether the Ingress has an annotation with the
+// prefix.
+func hasAnnotationPrefix(ingress networkingv1.Ingress, prefix string) bool {
+	for key := range ingress.Annotations {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
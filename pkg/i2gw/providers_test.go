@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_providerEnabled(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		selected    []string
+		expected    map[string]bool
+	}{{
+		name:     "no annotations",
+		expected: map[string]bool{ProviderIngressNginx: false, ProviderHAProxy: false},
+	}, {
+		name:        "detected",
+		annotations: map[string]string{"haproxy.org/load-balance": "leastconn"},
+		expected:    map[string]bool{ProviderIngressNginx: false, ProviderHAProxy: true},
+	}, {
+		name:        "selected",
+		annotations: map[string]string{"haproxy.org/load-balance": "leastconn"},
+		selected:    []string{ProviderIngressNginx},
+		expected:    map[string]bool{ProviderIngressNginx: true, ProviderHAProxy: false},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: tc.annotations}}
+			for name, expected := range tc.expected {
+				if actual := providerEnabled(name, ingress, tc.selected); actual != expected {
+					t.Errorf("providerEnabled(%q) = %t, expected %t", name, actual, expected)
+				}
+			}
+		})
+	}
+}
+
+func Test_annotationStatus(t *testing.T) {
+	ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: map[string]string{
+		"nginx.ingress.kubernetes.io/rewrite-target": "/",
+	}}}
+	testCases := []struct {
+		key      string
+		selected []string
+		expected string
+	}{
+		{key: "nginx.ingress.kubernetes.io/rewrite-target", expected: "is matched by the ingress-nginx provider"},
+		{key: "nginx.ingress.kubernetes.io/rewrite-target", selected: []string{ProviderHAProxy}, expected: "is skipped, the ingress-nginx provider isn't enabled"},
+		{key: "konghq.com/strip-path", expected: "is skipped, the kong provider isn't enabled"},
+		{key: "kubernetes.io/ingress.class", expected: "is read by the conversion"},
+		{key: "ingress2gateway.kubernetes.io/backend-ref-kind", expected: "is read by the conversion"},
+		{key: "example.com/owner", expected: "is skipped, no provider reads it"},
+	}
+	for _, tc := range testCases {
+		if actual := annotationStatus(tc.key, ingress, tc.selected); actual != tc.expected {
+			t.Errorf("annotationStatus(%q, %v) = %q, expected %q", tc.key, tc.selected, actual, tc.expected)
+		}
+	}
+}
+
+func Test_ValidateProviders(t *testing.T) {
+	if err := ValidateProviders([]string{ProviderHAProxy, ProviderIngressNginx}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := ValidateProviders([]string{"traefik"}); err == nil {
+		t.Errorf("Expected an error for an unsupported provider")
+	}
+}
+
+func Test_ListProviders(t *testing.T) {
+	infos := ListProviders()
+	names := ProviderNames()
+	if len(infos) != len(names) {
+		t.Fatalf("Expected %d providers, got %d", len(names), len(infos))
+	}
+	for i, info := range infos {
+		if info.Name != names[i] {
+			t.Errorf("Expected provider %d to be %s, got %s", i, names[i], info.Name)
+		}
+		if info.AnnotationPrefix != providers[info.Name].annotationPrefix() {
+			t.Errorf("Expected the annotation prefix of %s to be %s, got %s", info.Name, providers[info.Name].annotationPrefix(), info.AnnotationPrefix)
+		}
+		if len(info.InputKinds) == 0 || info.InputKinds[0] != "Ingress" {
+			t.Errorf("Expected %s to read Ingresses first, got %v", info.Name, info.InputKinds)
+		}
+		if info.Summary == "" || strings.Contains(info.Summary, "\n") {
+			t.Errorf("Expected a one-line summary of %s, got %q", info.Name, info.Summary)
+		}
+	}
+}
+
+func Test_ValidateAnnotationPrefix(t *testing.T) {
+	testCases := []struct {
+		prefix         string
+		expectingError bool
+	}{
+		{prefix: ""},
+		{prefix: "nginx.ingress.kubernetes.io"},
+		{prefix: "haproxy.org/"},
+		{prefix: "example.com", expectingError: true},
+		{prefix: "nginx.ingress.kubernetes.io/rewrite-target", expectingError: true},
+	}
+	for _, tc := range testCases {
+		if err := ValidateAnnotationPrefix(tc.prefix); tc.expectingError != (err != nil) {
+			t.Errorf("ValidateAnnotationPrefix(%q) error = %v, expecting error: %v", tc.prefix, err, tc.expectingError)
+		}
+	}
+}
+
+func Test_scopeAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		"nginx.ingress.kubernetes.io/rewrite-target": "/",
+		"haproxy.org/path-rewrite":                   "/",
+		"kubernetes.io/ingress.class":                "nginx",
+		"example.com/owner":                          "web",
+	}
+	ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: annotations}}
+	scopeAnnotations(&ingress, "nginx.ingress.kubernetes.io")
+
+	expected := map[string]string{
+		"nginx.ingress.kubernetes.io/rewrite-target": "/",
+		"kubernetes.io/ingress.class":                "nginx",
+		"example.com/owner":                          "web",
+	}
+	if diff := cmp.Diff(expected, ingress.Annotations); diff != "" {
+		t.Errorf("Unexpected annotations (-want +got):\n%s", diff)
+	}
+	// The annotations shared with the caller are left unchanged.
+	if _, ok := annotations["haproxy.org/path-rewrite"]; !ok {
+		t.Errorf("Expected the annotations of the caller to be unchanged, got %v", annotations)
+	}
+}
+
+func Test_convertAnnotationPrefix(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test", Annotations: map[string]string{
+			appRootAnnotation:          "/api",
+			"haproxy.org/path-rewrite": "/v2",
+		}},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/api",
+							PathType: &iPrefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Number: 80}},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		prefix          string
+		expectedFilters []gatewayv1.HTTPRouteFilterType
+	}{
+		{
+			name:            "ingress-nginx annotations",
+			prefix:          "nginx.ingress.kubernetes.io",
+			expectedFilters: []gatewayv1.HTTPRouteFilterType{gatewayv1.HTTPRouteFilterRequestRedirect},
+		},
+		{
+			name:            "haproxy annotations",
+			prefix:          "haproxy.org",
+			expectedFilters: []gatewayv1.HTTPRouteFilterType{gatewayv1.HTTPRouteFilterURLRewrite},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert([]networkingv1.Ingress{ingress}, Options{AnnotationPrefix: tc.prefix})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if len(result.HTTPRoutes) != 1 {
+				t.Fatalf("Expected 1 HTTPRoute, got %d", len(result.HTTPRoutes))
+			}
+			var filters []gatewayv1.HTTPRouteFilterType
+			for _, rule := range result.HTTPRoutes[0].Spec.Rules {
+				for _, filter := range rule.Filters {
+					filters = append(filters, filter.Type)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedFilters, filters); diff != "" {
+				t.Errorf("Unexpected filters (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
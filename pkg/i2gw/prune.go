@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// pruneUnusedListeners removes the listeners of the Gateways that no route
+// is attached to, such as the listeners of a host whose routes were merged
+// into other Gateways. A parentRef without sectionName attaches a route to
+// all the listeners of the Gateway, or of its port. The TLS listeners
+// terminating the TLS of a host with certificates are kept unless pruneTLS is
+// set, as they express the TLS intent of the host even without routes. A
+// Gateway keeps at least its first listener, as it must have one.
+func pruneUnusedListeners(gateways []gatewayv1beta1.Gateway, httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, pruneTLS bool) {
+	// The parentRefs of the routes, by Gateway.
+	parentRefs := map[types.NamespacedName][]gatewayv1.ParentReference{}
+	addParentRefs := func(routeNamespace string, refs []gatewayv1.ParentReference) {
+		for _, ref := range refs {
+			if (ref.Group != nil && *ref.Group != gatewayv1.GroupName) || (ref.Kind != nil && *ref.Kind != "Gateway") {
+				continue
+			}
+			key := types.NamespacedName{Namespace: routeNamespace, Name: string(ref.Name)}
+			if ref.Namespace != nil {
+				key.Namespace = string(*ref.Namespace)
+			}
+			parentRefs[key] = append(parentRefs[key], ref)
+		}
+	}
+	for _, route := range httpRoutes {
+		addParentRefs(route.Namespace, route.Spec.ParentRefs)
+	}
+	for _, route := range grpcRoutes {
+		addParentRefs(route.Namespace, route.Spec.ParentRefs)
+	}
+	for _, route := range tcpRoutes {
+		addParentRefs(route.Namespace, route.Spec.ParentRefs)
+	}
+	for _, route := range tlsRoutes {
+		addParentRefs(route.Namespace, route.Spec.ParentRefs)
+	}
+
+	for i := range gateways {
+		gateway := &gateways[i]
+		refs := parentRefs[types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name}]
+		var listeners []gatewayv1beta1.Listener
+		for _, listener := range gateway.Spec.Listeners {
+			if listenerAttached(listener, refs) || (!pruneTLS && terminatesTLS(listener)) {
+				listeners = append(listeners, listener)
+			}
+		}
+		if len(listeners) == 0 && len(gateway.Spec.Listeners) > 0 {
+			listeners = gateway.Spec.Listeners[:1]
+		}
+		gateway.Spec.Listeners = listeners
+	}
+}
+
+// listenerAttached returns whether a parentRef attaches a route to the
+// listener.
+func listenerAttached(listener gatewayv1beta1.Listener, refs []gatewayv1.ParentReference) bool {
+	for _, ref := range refs {
+		if ref.SectionName != nil && *ref.SectionName != listener.Name {
+			continue
+		}
+		if ref.Port != nil && *ref.Port != listener.Port {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// terminatesTLS returns whether the listener terminates TLS with
+// certificates.
+func terminatesTLS(listener gatewayv1beta1.Listener) bool {
+	if listener.TLS == nil || len(listener.TLS.CertificateRefs) == 0 {
+		return false
+	}
+	return listener.TLS.Mode == nil || *listener.TLS.Mode == gatewayv1.TLSModeTerminate
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_pruneUnusedListeners(t *testing.T) {
+	passthrough := gatewayv1.TLSModePassthrough
+	gateway := gatewayv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+		Spec: gatewayv1beta1.GatewaySpec{
+			Listeners: []gatewayv1beta1.Listener{{
+				Name:     "app-example-com-http",
+				Port:     80,
+				Protocol: gatewayv1.HTTPProtocolType,
+			}, {
+				Name:     "app-example-com-https",
+				Port:     443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+				TLS: &gatewayv1beta1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1beta1.SecretObjectReference{{Name: "app-tls"}},
+				},
+			}, {
+				Name:     "db-example-com-tls-passthrough",
+				Port:     443,
+				Protocol: gatewayv1.TLSProtocolType,
+				TLS:      &gatewayv1beta1.GatewayTLSConfig{Mode: &passthrough},
+			}, {
+				Name:     "tcp-5432",
+				Port:     5432,
+				Protocol: gatewayv1.TCPProtocolType,
+			}},
+		},
+	}
+	httpRoute := func(refs ...gatewayv1.ParentReference) gatewayv1beta1.HTTPRoute {
+		return gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-example-com", Namespace: "test"},
+			Spec:       gatewayv1beta1.HTTPRouteSpec{CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: refs}},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		httpRoutes        []gatewayv1beta1.HTTPRoute
+		tcpRoutes         []gatewayv1alpha2.TCPRoute
+		pruneTLS          bool
+		expectedListeners []gatewayv1beta1.SectionName
+	}{{
+		name:              "listeners of sections",
+		httpRoutes:        []gatewayv1beta1.HTTPRoute{httpRoute(gatewayv1.ParentReference{Name: "nginx", SectionName: gatewaySectionNamePtr("app-example-com-http")})},
+		expectedListeners: []gatewayv1beta1.SectionName{"app-example-com-http", "app-example-com-https"},
+	}, {
+		name:              "TLS listeners pruned",
+		httpRoutes:        []gatewayv1beta1.HTTPRoute{httpRoute(gatewayv1.ParentReference{Name: "nginx", SectionName: gatewaySectionNamePtr("app-example-com-http")})},
+		pruneTLS:          true,
+		expectedListeners: []gatewayv1beta1.SectionName{"app-example-com-http"},
+	}, {
+		name:              "all the listeners of the Gateway",
+		httpRoutes:        []gatewayv1beta1.HTTPRoute{httpRoute(gatewayv1.ParentReference{Name: "nginx"})},
+		pruneTLS:          true,
+		expectedListeners: []gatewayv1beta1.SectionName{"app-example-com-http", "app-example-com-https", "db-example-com-tls-passthrough", "tcp-5432"},
+	}, {
+		name:              "listeners of a port",
+		httpRoutes:        []gatewayv1beta1.HTTPRoute{httpRoute(gatewayv1.ParentReference{Name: "nginx", Port: portNumberPtr(443)})},
+		pruneTLS:          true,
+		expectedListeners: []gatewayv1beta1.SectionName{"app-example-com-https", "db-example-com-tls-passthrough"},
+	}, {
+		name: "TCPRoute",
+		tcpRoutes: []gatewayv1alpha2.TCPRoute{{
+			ObjectMeta: metav1.ObjectMeta{Name: "tcp-services-5432", Namespace: "test"},
+			Spec: gatewayv1alpha2.TCPRouteSpec{CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Name: "nginx", SectionName: gatewaySectionNamePtr("tcp-5432")}},
+			}},
+		}},
+		pruneTLS:          true,
+		expectedListeners: []gatewayv1beta1.SectionName{"tcp-5432"},
+	}, {
+		name:              "Gateway of another namespace",
+		httpRoutes:        []gatewayv1beta1.HTTPRoute{httpRoute(gatewayv1.ParentReference{Name: "nginx", Namespace: namespacePtr("other")})},
+		pruneTLS:          true,
+		expectedListeners: []gatewayv1beta1.SectionName{"app-example-com-http"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateways := []gatewayv1beta1.Gateway{*gateway.DeepCopy()}
+			pruneUnusedListeners(gateways, tc.httpRoutes, nil, tc.tcpRoutes, nil, tc.pruneTLS)
+			var listeners []gatewayv1beta1.SectionName
+			for _, listener := range gateways[0].Spec.Listeners {
+				listeners = append(listeners, listener.Name)
+			}
+			if diff := cmp.Diff(tc.expectedListeners, listeners); diff != "" {
+				t.Errorf("Unexpected listeners (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	limitRPSAnnotation             = "nginx.ingress.kubernetes.io/limit-rps"
+	limitRPMAnnotation             = "nginx.ingress.kubernetes.io/limit-rpm"
+	limitConnectionsAnnotation     = "nginx.ingress.kubernetes.io/limit-connections"
+	limitBurstMultiplierAnnotation = "nginx.ingress.kubernetes.io/limit-burst-multiplier"
+	limitRateAnnotation            = "nginx.ingress.kubernetes.io/limit-rate"
+	limitRateAfterAnnotation       = "nginx.ingress.kubernetes.io/limit-rate-after"
+	limitWhitelistAnnotation       = "nginx.ingress.kubernetes.io/limit-whitelist"
+	limitAllowlistAnnotation       = "nginx.ingress.kubernetes.io/limit-allowlist"
+	// rateLimitAnnotationPrefix prefixes the annotations of every limit of
+	// ingress-nginx.
+	rateLimitAnnotationPrefix = "nginx.ingress.kubernetes.io/limit-"
+
+	// rateLimitPolicyNameSuffix suffixes the name of the route a
+	// BackendTrafficPolicy rate limits.
+	rateLimitPolicyNameSuffix = "-rate-limit"
+)
+
+// rateLimits are the request rate limits of ingress-nginx, per client IP.
+type rateLimits struct {
+	rps int
+	rpm int
+}
+
+// rateLimitAnnotations describe the annotations limiting the requests,
+// connections or bandwidth of the clients, in the order they are reported.
+var rateLimitAnnotations = []struct {
+	annotation string
+	// limit describes the limit of the annotation value.
+	limit string
+	// count is set for the annotations holding a positive number.
+	count bool
+}{
+	{annotation: limitRPSAnnotation, limit: "%s requests per second per client IP", count: true},
+	{annotation: limitRPMAnnotation, limit: "%s requests per minute per client IP", count: true},
+	{annotation: limitConnectionsAnnotation, limit: "%s concurrent connections per client IP", count: true},
+	{annotation: limitBurstMultiplierAnnotation, limit: "bursts of %s times the request rate limits", count: true},
+	{annotation: limitRateAnnotation, limit: "a response rate of %s kilobytes per second per request"},
+	{annotation: limitRateAfterAnnotation, limit: "a response rate limited after %s kilobytes"},
+	{annotation: limitWhitelistAnnotation, limit: "no limits for the clients of %s"},
+	{annotation: limitAllowlistAnnotation, limit: "no limits for the clients of %s"},
+}
+
+// getRateLimits reads the rate limits of ingress-nginx. Gateway API can't
+// limit the clients of routes, so every limit is reported by a warning naming
+// its value, and invalid values by a warning too, so that no limit is
+// silently dropped. The request rate limits are returned to be converted to
+// the policies of a target implementation, along with their policy warnings.
+func getRateLimits(ingress networkingv1.Ingress, fieldPath *field.Path) (*rateLimits, []policyWarning, []Warning) {
+	key := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	var limits rateLimits
+	var policyWarnings []policyWarning
+	var warnings []Warning
+	for _, rla := range rateLimitAnnotations {
+		value, ok := ingress.Annotations[rla.annotation]
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		n, err := strconv.Atoi(value)
+		if rla.count && (err != nil || n <= 0) {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   fieldPath.Key(rla.annotation),
+				Message: fmt.Sprintf("the rate limit %q is not converted, it must be a positive number", value),
+			})
+			continue
+		}
+		warning := Warning{
+			Ingress: key,
+			Field:   fieldPath.Key(rla.annotation),
+			Message: fmt.Sprintf("the Ingress allows %s, but Gateway API can't represent rate limits; the limit must be configured on the Gateway implementation",
+				fmt.Sprintf(rla.limit, value)),
+		}
+		switch rla.annotation {
+		case limitRPSAnnotation:
+			limits.rps = n
+			policyWarnings = append(policyWarnings, policyWarning{annotation: rla.annotation, warning: warning})
+		case limitRPMAnnotation:
+			limits.rpm = n
+			policyWarnings = append(policyWarnings, policyWarning{annotation: rla.annotation, warning: warning})
+		default:
+			warnings = append(warnings, warning)
+		}
+	}
+	// Other limits of later ingress-nginx versions are reported too.
+	var others []string
+	for annotation := range ingress.Annotations {
+		if strings.HasPrefix(annotation, rateLimitAnnotationPrefix) && !isRateLimitAnnotation(annotation) {
+			others = append(others, annotation)
+		}
+	}
+	sort.Strings(others)
+	for _, annotation := range others {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   fieldPath.Key(annotation),
+			Message: fmt.Sprintf("the limit %q is not converted, Gateway API can't represent rate limits and it must be configured on the Gateway implementation", ingress.Annotations[annotation]),
+		})
+	}
+	if limits.rps == 0 && limits.rpm == 0 {
+		return nil, policyWarnings, warnings
+	}
+	return &limits, policyWarnings, warnings
+}
+
+// isRateLimitAnnotation reports whether the annotation is one of the
+// rateLimitAnnotations.
+func isRateLimitAnnotation(annotation string) bool {
+	for _, rla := range rateLimitAnnotations {
+		if rla.annotation == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+// requestRateLimits returns the request rate limits of the paths, as
+// rps,rpm, empty when the requests aren't limited.
+func (e *extra) requestRateLimits() string {
+	if e == nil || e.rateLimits == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d,%d", e.rateLimits.rps, e.rateLimits.rpm)
+}
+
+// envoyGatewayRateLimitPolicies returns the BackendTrafficPolicy of the
+// routes of the rule group with request rate limits, limiting every client IP
+// as ingress-nginx does. A policy applies to all the paths of its routes, so
+// no policy is generated when the Ingresses of the host have different
+// limits, and a warning is returned instead.
+func envoyGatewayRateLimitPolicies(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning) {
+	value, same := rg.commonValue((*extra).requestRateLimits)
+	if !same {
+		return nil, []Warning{rg.differentValuesWarning(limitRPSAnnotation,
+			fmt.Sprintf("the Ingresses of host %q have different rate limits, which a BackendTrafficPolicy of its routes can't represent; no BackendTrafficPolicy is generated and the limits must be configured manually", rg.host))}
+	}
+	if value == "" {
+		return nil, nil
+	}
+	limits := rg.rules[0].extra.rateLimits
+	if limits.rps > 0 {
+		rg.enforce(limitRPSAnnotation)
+	}
+	if limits.rpm > 0 {
+		rg.enforce(limitRPMAnnotation)
+	}
+
+	var targetRefs []interface{}
+	for _, kind := range kinds {
+		targetRefs = append(targetRefs, policyTargetRef(kind, name))
+	}
+	var rules []interface{}
+	for _, limit := range []struct {
+		requests int
+		unit     string
+	}{{requests: limits.rps, unit: "Second"}, {requests: limits.rpm, unit: "Minute"}} {
+		if limit.requests == 0 {
+			continue
+		}
+		rules = append(rules, map[string]interface{}{
+			"clientSelectors": []interface{}{map[string]interface{}{
+				"sourceCIDR": map[string]interface{}{
+					"type":  "Distinct",
+					"value": "0.0.0.0/0",
+				},
+			}},
+			"limit": map[string]interface{}{
+				"requests": int64(limit.requests),
+				"unit":     limit.unit,
+			},
+		})
+	}
+	return []unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": envoyGatewayAPIVersion,
+		"kind":       "BackendTrafficPolicy",
+		"metadata": map[string]interface{}{
+			"name":      name + rateLimitPolicyNameSuffix,
+			"namespace": rg.namespace,
+		},
+		"spec": map[string]interface{}{
+			"targetRefs": targetRefs,
+			"rateLimit": map[string]interface{}{
+				"type": "Global",
+				"global": map[string]interface{}{
+					"rules": rules,
+				},
+			},
+		},
+	}}}, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_getRateLimits(t *testing.T) {
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedLimits *rateLimits
+		// expectedWarnings are the annotations of the expected warnings,
+		// along with a value the warning must name.
+		expectedWarnings map[string]string
+	}{{
+		name: "no limits",
+	}, {
+		name: "request and connection limits",
+		annotations: map[string]string{
+			limitRPSAnnotation:             "10",
+			limitConnectionsAnnotation:     "5",
+			limitBurstMultiplierAnnotation: "3",
+		},
+		expectedLimits: &rateLimits{rps: 10},
+		expectedWarnings: map[string]string{
+			limitRPSAnnotation:             "10 requests per second",
+			limitConnectionsAnnotation:     "5 concurrent connections",
+			limitBurstMultiplierAnnotation: "3 times",
+		},
+	}, {
+		name:             "requests per minute",
+		annotations:      map[string]string{limitRPMAnnotation: "600"},
+		expectedLimits:   &rateLimits{rpm: 600},
+		expectedWarnings: map[string]string{limitRPMAnnotation: "600 requests per minute"},
+	}, {
+		name:             "invalid limit",
+		annotations:      map[string]string{limitRPSAnnotation: "ten"},
+		expectedWarnings: map[string]string{limitRPSAnnotation: `"ten"`},
+	}, {
+		name: "bandwidth limits and allow-list",
+		annotations: map[string]string{
+			limitRateAnnotation:      "100",
+			limitWhitelistAnnotation: "10.0.0.0/8",
+		},
+		expectedWarnings: map[string]string{
+			limitRateAnnotation:      "100 kilobytes",
+			limitWhitelistAnnotation: "10.0.0.0/8",
+		},
+	}, {
+		name:             "unknown limit",
+		annotations:      map[string]string{rateLimitAnnotationPrefix + "req-status-code": "429"},
+		expectedWarnings: map[string]string{rateLimitAnnotationPrefix + "req-status-code": "429"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: tc.annotations}}
+			limits, policyWarnings, warnings := getRateLimits(ingress, field.NewPath("app", "metadata", "annotations"))
+			for _, pw := range policyWarnings {
+				warnings = append(warnings, pw.warning)
+			}
+			if diff := cmp.Diff(tc.expectedLimits, limits, cmp.AllowUnexported(rateLimits{})); diff != "" {
+				t.Errorf("Unexpected rate limits (-want +got):\n%s", diff)
+			}
+			if len(warnings) != len(tc.expectedWarnings) {
+				t.Fatalf("Expected %d warnings, got %d: %+v", len(tc.expectedWarnings), len(warnings), warnings)
+			}
+			for _, warning := range warnings {
+				var found bool
+				for annotation, value := range tc.expectedWarnings {
+					if warning.Field.String() == field.NewPath("app", "metadata", "annotations").Key(annotation).String() {
+						found = true
+						if !strings.Contains(warning.Message, value) {
+							t.Errorf("Expected the warning of %s to name %q, got %q", annotation, value, warning.Message)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("Unexpected warning %+v", warning)
+				}
+			}
+		})
+	}
+}
+
+func Test_envoyGatewayRateLimitPolicies(t *testing.T) {
+	rule := func(requests int64, unit string) interface{} {
+		return map[string]interface{}{
+			"clientSelectors": []interface{}{map[string]interface{}{
+				"sourceCIDR": map[string]interface{}{"type": "Distinct", "value": "0.0.0.0/0"},
+			}},
+			"limit": map[string]interface{}{"requests": requests, "unit": unit},
+		}
+	}
+	backendTrafficPolicy := func(rules ...interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.envoyproxy.io/v1alpha1",
+			"kind":       "BackendTrafficPolicy",
+			"metadata": map[string]interface{}{
+				"name":      "example-com-rate-limit",
+				"namespace": "test",
+			},
+			"spec": map[string]interface{}{
+				"targetRefs": []interface{}{map[string]interface{}{
+					"group": "gateway.networking.k8s.io",
+					"kind":  "HTTPRoute",
+					"name":  "example-com",
+				}},
+				"rateLimit": map[string]interface{}{
+					"type":   "Global",
+					"global": map[string]interface{}{"rules": rules},
+				},
+			},
+		}}
+	}
+
+	testCases := []struct {
+		name                 string
+		ingresses            []networkingv1.Ingress
+		targetImplementation string
+		expectedPolicies     []unstructured.Unstructured
+		expectedNumWarnings  int
+	}{{
+		name:                "warning only",
+		ingresses:           []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{limitRPSAnnotation: "10"})},
+		expectedNumWarnings: 1,
+	}, {
+		name:                 "Envoy Gateway BackendTrafficPolicy",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{limitRPSAnnotation: "10", limitRPMAnnotation: "300"})},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedPolicies:     []unstructured.Unstructured{backendTrafficPolicy(rule(10, "Second"), rule(300, "Minute"))},
+	}, {
+		name:                 "different limits on a host",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{limitRPSAnnotation: "10"}), newNginxIngress("api", "/api", map[string]string{limitRPSAnnotation: "100"})},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedNumWarnings:  3,
+	}, {
+		name:                 "limits unmapped by the target",
+		ingresses:            []networkingv1.Ingress{newNginxIngress("app", "/", map[string]string{limitRPSAnnotation: "10"})},
+		targetImplementation: TargetImplementationNGINXGatewayFabric,
+		expectedNumWarnings:  1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{TargetImplementation: tc.targetImplementation})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if diff := cmp.Diff(tc.expectedPolicies, result.Policies); diff != "" {
+				t.Errorf("Unexpected policies (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(result.Warnings), result.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	permanentRedirectAnnotation     = "nginx.ingress.kubernetes.io/permanent-redirect"
+	permanentRedirectCodeAnnotation = "nginx.ingress.kubernetes.io/permanent-redirect-code"
+	temporalRedirectAnnotation      = "nginx.ingress.kubernetes.io/temporal-redirect"
+	temporalRedirectCodeAnnotation  = "nginx.ingress.kubernetes.io/temporal-redirect-code"
+
+	// requestURIVariable is the nginx variable of the path and query of the
+	// request, which RequestRedirect filters keep when their path is unset.
+	requestURIVariable = "$request_uri"
+)
+
+// getRedirect converts the permanent-redirect and temporal-redirect
+// annotations of ingress-nginx, which redirect all the requests of the
+// Ingress to a URL, to a RequestRedirect filter to the scheme, hostname, port
+// and path of the URL. As with ingress-nginx, temporal-redirect takes
+// precedence over permanent-redirect. URLs that can't be represented by a
+// RequestRedirect filter are reported as warnings, with no filter.
+func getRedirect(ingress networkingv1.Ingress, fieldPath *field.Path) (*gatewayv1beta1.HTTPRequestRedirectFilter, []Warning) {
+	key := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	annotation, codeAnnotation, defaultCode := temporalRedirectAnnotation, temporalRedirectCodeAnnotation, 302
+	target := ingress.Annotations[temporalRedirectAnnotation]
+	var warnings []Warning
+	if target == "" {
+		annotation, codeAnnotation, defaultCode = permanentRedirectAnnotation, permanentRedirectCodeAnnotation, 301
+		target = ingress.Annotations[permanentRedirectAnnotation]
+	} else if ingress.Annotations[permanentRedirectAnnotation] != "" {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   fieldPath.Key(permanentRedirectAnnotation),
+			Message: fmt.Sprintf("ignored, as with ingress-nginx, %s takes precedence", temporalRedirectAnnotation),
+		})
+	}
+	if target == "" {
+		return nil, nil
+	}
+
+	redirect, err := toRedirectFilter(target)
+	if err != nil {
+		return nil, append(warnings, Warning{
+			Ingress: key,
+			Field:   fieldPath.Key(annotation),
+			Message: fmt.Sprintf("%q can't be represented by a RequestRedirect filter, %v; no redirect is generated, it must be configured manually", target, err),
+		})
+	}
+	redirect.StatusCode = pointer.Int(defaultCode)
+	if value, ok := ingress.Annotations[codeAnnotation]; ok {
+		code, err := strconv.Atoi(value)
+		if err == nil && (code == 301 || code == 302) {
+			redirect.StatusCode = pointer.Int(code)
+		} else {
+			warnings = append(warnings, Warning{
+				Ingress: key,
+				Field:   fieldPath.Key(codeAnnotation),
+				Message: fmt.Sprintf("RequestRedirect filters only support the 301 and 302 status codes, the redirect uses %d instead of %s", defaultCode, value),
+			})
+		}
+	}
+	return redirect, warnings
+}
+
+// toRedirectFilter returns the RequestRedirect filter to the URL, or the
+// reason it can't represent it: the URL must be an absolute http or https
+// URL, without user information, query, fragment or nginx variables, except a
+// trailing $request_uri keeping the path and query of the request. Other
+// paths replace the full path of the request, and a URL without path
+// redirects to the root path.
+func toRedirectFilter(target string) (*gatewayv1beta1.HTTPRequestRedirectFilter, error) {
+	keepPath := strings.HasSuffix(target, requestURIVariable)
+	target = strings.TrimSuffix(target, requestURIVariable)
+	if strings.Contains(target, "$") {
+		return nil, fmt.Errorf("as nginx variables other than a trailing %s are evaluated by ingress-nginx only", requestURIVariable)
+	}
+	u, err := url.Parse(target)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("as it is not a valid URL: %v", err)
+	case u.Scheme == "" || u.Host == "":
+		return nil, fmt.Errorf("as it is not an absolute URL, and the filter only redirects to the scheme and hostname of a URL")
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, fmt.Errorf("as the filter only redirects to http and https URLs")
+	case u.Hostname() == "":
+		return nil, fmt.Errorf("as the URL has no hostname")
+	case u.User != nil:
+		return nil, fmt.Errorf("as user information is unsupported by RequestRedirect")
+	case u.RawQuery != "" || u.ForceQuery:
+		return nil, fmt.Errorf("as query strings are unsupported by RequestRedirect")
+	case u.Fragment != "":
+		return nil, fmt.Errorf("as fragments are unsupported by RequestRedirect")
+	case keepPath && strings.Trim(u.Path, "/") != "":
+		return nil, fmt.Errorf("as RequestRedirect either keeps the path of the request, as %s following the hostname, or replaces it, not both", requestURIVariable)
+	}
+
+	redirect := &gatewayv1beta1.HTTPRequestRedirectFilter{
+		Scheme:   pointer.String(u.Scheme),
+		Hostname: (*gatewayv1.PreciseHostname)(pointer.String(u.Hostname())),
+	}
+	if u.Port() != "" {
+		port, err := strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("as port %s is not between 1 and 65535", u.Port())
+		}
+		redirect.Port = (*gatewayv1.PortNumber)(pointer.Int32(int32(port)))
+	}
+	if !keepPath {
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		redirect.Path = &gatewayv1beta1.HTTPPathModifier{
+			Type:            gatewayv1.FullPathHTTPPathModifier,
+			ReplaceFullPath: pointer.String(path),
+		}
+	}
+	return redirect, nil
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_getRedirect(t *testing.T) {
+	fullPath := func(path string) *gatewayv1beta1.HTTPPathModifier {
+		return &gatewayv1beta1.HTTPPathModifier{Type: gatewayv1.FullPathHTTPPathModifier, ReplaceFullPath: pointer.String(path)}
+	}
+	testCases := []struct {
+		name              string
+		annotations       map[string]string
+		expectedRedirect  *gatewayv1beta1.HTTPRequestRedirectFilter
+		expectNumWarnings int
+		// expectedWarning is the reason of the warning of a URL a
+		// RequestRedirect filter can't represent.
+		expectedWarning string
+	}{{
+		name: "no redirect",
+	}, {
+		name:        "permanent redirect",
+		annotations: map[string]string{permanentRedirectAnnotation: "https://www.example.com/new"},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Hostname:   (*gatewayv1.PreciseHostname)(pointer.String("www.example.com")),
+			Path:       fullPath("/new"),
+			StatusCode: pointer.Int(301),
+		},
+	}, {
+		name: "permanent redirect with a 302 code",
+		annotations: map[string]string{
+			permanentRedirectAnnotation:     "http://www.example.com",
+			permanentRedirectCodeAnnotation: "302",
+		},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("http"),
+			Hostname:   (*gatewayv1.PreciseHostname)(pointer.String("www.example.com")),
+			Path:       fullPath("/"),
+			StatusCode: pointer.Int(302),
+		},
+	}, {
+		name:        "temporal redirect with a port",
+		annotations: map[string]string{temporalRedirectAnnotation: "https://www.example.com:8443/maintenance"},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Hostname:   (*gatewayv1.PreciseHostname)(pointer.String("www.example.com")),
+			Port:       (*gatewayv1.PortNumber)(pointer.Int32(8443)),
+			Path:       fullPath("/maintenance"),
+			StatusCode: pointer.Int(302),
+		},
+	}, {
+		name: "temporal redirect takes precedence",
+		annotations: map[string]string{
+			temporalRedirectAnnotation:  "https://maintenance.example.com/",
+			permanentRedirectAnnotation: "https://www.example.com/new",
+		},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Hostname:   (*gatewayv1.PreciseHostname)(pointer.String("maintenance.example.com")),
+			Path:       fullPath("/"),
+			StatusCode: pointer.Int(302),
+		},
+		expectNumWarnings: 1,
+	}, {
+		name:        "request URI kept",
+		annotations: map[string]string{permanentRedirectAnnotation: "https://www.example.com$request_uri"},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Hostname:   (*gatewayv1.PreciseHostname)(pointer.String("www.example.com")),
+			StatusCode: pointer.Int(301),
+		},
+	}, {
+		name: "unsupported code",
+		annotations: map[string]string{
+			permanentRedirectAnnotation:     "https://www.example.com/new",
+			permanentRedirectCodeAnnotation: "308",
+		},
+		expectedRedirect: &gatewayv1beta1.HTTPRequestRedirectFilter{
+			Scheme:     pointer.String("https"),
+			Hostname:   (*gatewayv1.PreciseHostname)(pointer.String("www.example.com")),
+			Path:       fullPath("/new"),
+			StatusCode: pointer.Int(301),
+		},
+		expectNumWarnings: 1,
+	}, {
+		name:              "relative URL",
+		annotations:       map[string]string{permanentRedirectAnnotation: "/new"},
+		expectNumWarnings: 1,
+		expectedWarning:   "it is not an absolute URL",
+	}, {
+		name:              "URL of another scheme",
+		annotations:       map[string]string{permanentRedirectAnnotation: "ftp://www.example.com/new"},
+		expectNumWarnings: 1,
+		expectedWarning:   "only redirects to http and https URLs",
+	}, {
+		name:              "URL with a query",
+		annotations:       map[string]string{temporalRedirectAnnotation: "https://www.example.com/new?from=old"},
+		expectNumWarnings: 1,
+		expectedWarning:   "query strings are unsupported by RequestRedirect",
+	}, {
+		name:              "URL with a fragment",
+		annotations:       map[string]string{temporalRedirectAnnotation: "https://www.example.com/new#top"},
+		expectNumWarnings: 1,
+		expectedWarning:   "fragments are unsupported by RequestRedirect",
+	}, {
+		name:              "URL with user information",
+		annotations:       map[string]string{permanentRedirectAnnotation: "https://admin@www.example.com/new"},
+		expectNumWarnings: 1,
+		expectedWarning:   "user information is unsupported by RequestRedirect",
+	}, {
+		name:              "URL with nginx variables",
+		annotations:       map[string]string{permanentRedirectAnnotation: "https://$host/new"},
+		expectNumWarnings: 1,
+		expectedWarning:   "nginx variables other than a trailing $request_uri",
+	}, {
+		name:              "request URI after a path",
+		annotations:       map[string]string{permanentRedirectAnnotation: "https://www.example.com/new$request_uri"},
+		expectNumWarnings: 1,
+		expectedWarning:   "either keeps the path of the request",
+	}, {
+		name:              "port out of range",
+		annotations:       map[string]string{permanentRedirectAnnotation: "https://www.example.com:70000/new"},
+		expectNumWarnings: 1,
+		expectedWarning:   "port 70000 is not between 1 and 65535",
+	}, {
+		name:              "malformed URL",
+		annotations:       map[string]string{permanentRedirectAnnotation: "https://www.example.com:port/new"},
+		expectNumWarnings: 1,
+		expectedWarning:   "it is not a valid URL",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test", Annotations: tc.annotations},
+			}
+			redirect, warnings := getRedirect(ingress, field.NewPath("example", "metadata", "annotations"))
+			if diff := cmp.Diff(tc.expectedRedirect, redirect); diff != "" {
+				t.Errorf("Unexpected redirect (-want +got):\n%s", diff)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if tc.expectedWarning != "" && (len(warnings) == 0 || !strings.Contains(warnings[len(warnings)-1].Message, tc.expectedWarning)) {
+				t.Errorf("Expected a warning containing %q, got %+v", tc.expectedWarning, warnings)
+			}
+		})
+	}
+}
+
+func Test_convertRedirect(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: "test", Annotations: map[string]string{
+			permanentRedirectAnnotation:                  "https://www.example.com/new",
+			"nginx.ingress.kubernetes.io/rewrite-target": "/",
+		}},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("ingress-nginx"),
+			Rules: []networkingv1.IngressRule{{
+				Host: "old.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: "old", Port: networkingv1.ServiceBackendPort{Number: 80}},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	result, err := Convert([]networkingv1.Ingress{ingress}, Options{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(result.HTTPRoutes) != 1 || len(result.HTTPRoutes[0].Spec.Rules) != 1 {
+		t.Fatalf("Expected an HTTPRoute with a rule, got %+v", result.HTTPRoutes)
+	}
+	rule := result.HTTPRoutes[0].Spec.Rules[0]
+	if len(rule.BackendRefs) != 0 {
+		t.Errorf("Expected the redirected path to have no backends, got %+v", rule.BackendRefs)
+	}
+	if len(rule.Filters) != 1 || rule.Filters[0].RequestRedirect == nil || *rule.Filters[0].RequestRedirect.Hostname != "www.example.com" {
+		t.Errorf("Expected a single RequestRedirect filter to www.example.com, got %+v", rule.Filters)
+	}
+	if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+		t.Errorf("Expected valid resources, got %v", err)
+	}
+}
+
+func Test_redirectConflicts(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	newIngress := func(name string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("ingress-nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	web := newIngress("web", nil)
+	moved := newIngress("moved", map[string]string{permanentRedirectAnnotation: "https://www.example.com"})
+
+	testCases := []struct {
+		name           string
+		ingresses      []networkingv1.Ingress
+		allowConflicts bool
+		expectError    bool
+	}{{
+		name:        "conflict",
+		ingresses:   []networkingv1.Ingress{web, moved},
+		expectError: true,
+	}, {
+		name:           "redirect after the backend",
+		ingresses:      []networkingv1.Ingress{web, moved},
+		allowConflicts: true,
+	}, {
+		name:           "redirect before the backend",
+		ingresses:      []networkingv1.Ingress{moved, web},
+		allowConflicts: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, Options{AllowConflicts: tc.allowConflicts})
+			if tc.expectError {
+				if err == nil || !strings.Contains(err.Error(), "is already routed to a backend by Ingress test/web instead of being redirected") {
+					t.Errorf("Expected a conflict with the redirect, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if len(result.HTTPRoutes) != 1 || len(result.HTTPRoutes[0].Spec.Rules) != 1 {
+				t.Fatalf("Expected an HTTPRoute with a rule, got %+v", result.HTTPRoutes)
+			}
+			rule := result.HTTPRoutes[0].Spec.Rules[0]
+			if len(rule.BackendRefs) != 0 || len(rule.Filters) != 1 || rule.Filters[0].RequestRedirect == nil {
+				t.Errorf("Expected the path to be redirected without backends, got %+v", rule)
+			}
+			if len(result.Warnings) != 1 || result.Warnings[0].Ingress.Name != "web" {
+				t.Errorf("Expected a warning for the Ingress web, got %+v", result.Warnings)
+			}
+			if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+				t.Errorf("Expected valid resources, got %v", err)
+			}
+		})
+	}
+}
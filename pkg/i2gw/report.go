@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ConversionReport maps every converted Ingress to the resources generated
+// from it, for auditing purposes.
+type ConversionReport struct {
+	Ingresses []IngressReport `json:"ingresses"`
+}
+
+// IngressReport lists the resources and warnings generated from an Ingress.
+// Resources are referenced as <namespace>/<name>. Resources merged from
+// several Ingresses, such as Gateways, are listed for each of them.
+type IngressReport struct {
+	Ingress    string   `json:"ingress"`
+	Gateways   []string `json:"gateways"`
+	HTTPRoutes []string `json:"httpRoutes"`
+	GRPCRoutes []string `json:"grpcRoutes"`
+	TCPRoutes  []string `json:"tcpRoutes"`
+	TLSRoutes  []string `json:"tlsRoutes"`
+
+	BackendTLSPolicies []string `json:"backendTLSPolicies"`
+	ReferenceGrants    []string `json:"referenceGrants"`
+	// Policies are referenced as <kind>/<namespace>/<name>, as their kinds
+	// vary between target implementations.
+	Policies []string `json:"policies"`
+
+	Warnings []string `json:"warnings"`
+}
+
+// NewConversionReport returns the report of the conversion of the Ingresses
+// to the resources of the conversion result, in the order of the Ingresses.
+// The namespace mapping is the one the result was moved with MapNamespaces,
+// if any: the resources are reported in their final namespaces.
+func NewConversionReport(ingresses []networkingv1.Ingress, result Result, namespaceMapping map[string]string) ConversionReport {
+	generated := func(kind, namespace, name string) string {
+		return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+	}
+	key := func(namespace, name string) string {
+		return types.NamespacedName{Namespace: namespace, Name: name}.String()
+	}
+	exists := map[string]bool{}
+	for _, gw := range result.Gateways {
+		exists[generated("Gateway", gw.Namespace, gw.Name)] = true
+	}
+	// The routes of the default backends are the only routes named after
+	// their Ingress.
+	defaultBackendRoutes := map[string]bool{}
+	for _, ingress := range ingresses {
+		if ingress.Spec.DefaultBackend != nil {
+			defaultBackendRoutes[key(mapNamespace(namespaceMapping, ingress.Namespace), defaultBackendRouteName(ingress.Name))] = true
+		}
+	}
+
+	report := ConversionReport{Ingresses: []IngressReport{}}
+	for _, ingress := range ingresses {
+		ir := IngressReport{
+			Ingress:    types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}.String(),
+			Gateways:   []string{},
+			HTTPRoutes: []string{},
+			GRPCRoutes: []string{},
+			TCPRoutes:  []string{},
+			TLSRoutes:  []string{},
+
+			BackendTLSPolicies: []string{},
+			ReferenceGrants:    []string{},
+			Policies:           []string{},
+
+			Warnings: []string{},
+		}
+		normalizeIngressClass(&ingress)
+		ingressClass := getIngressClass(ingress)
+		namespace := mapNamespace(namespaceMapping, ingress.Namespace)
+
+		if exists[generated("Gateway", namespace, ingressClass)] {
+			ir.Gateways = append(ir.Gateways, key(namespace, ingressClass))
+		}
+		// Routes are generated for the hosts of the Ingress rules, whatever
+		// their name, and for the default backend.
+		var hosts []string
+		for _, rule := range ingress.Spec.Rules {
+			hosts = append(hosts, rule.Host)
+		}
+		ownRoute := func(routeNamespace, name string, hostnames []gatewayv1.Hostname) bool {
+			if routeNamespace != namespace {
+				return false
+			}
+			routeKey := key(routeNamespace, name)
+			if defaultBackendRoutes[routeKey] {
+				return ingress.Spec.DefaultBackend != nil && name == defaultBackendRouteName(ingress.Name)
+			}
+			for _, host := range hosts {
+				if routeHasHost(hostnames, host) {
+					return true
+				}
+			}
+			return false
+		}
+		// Policies attach to the routes of the Ingress.
+		ownRoutes := map[string]bool{}
+		for _, route := range result.HTTPRoutes {
+			if ownRoute(route.Namespace, route.Name, route.Spec.Hostnames) {
+				ir.HTTPRoutes = append(ir.HTTPRoutes, key(route.Namespace, route.Name))
+				ownRoutes[generated("HTTPRoute", route.Namespace, route.Name)] = true
+			}
+		}
+		for _, route := range result.GRPCRoutes {
+			if ownRoute(route.Namespace, route.Name, route.Spec.Hostnames) {
+				ir.GRPCRoutes = append(ir.GRPCRoutes, key(route.Namespace, route.Name))
+				ownRoutes[generated("GRPCRoute", route.Namespace, route.Name)] = true
+			}
+		}
+		for _, route := range result.TLSRoutes {
+			if ownRoute(route.Namespace, route.Name, route.Spec.Hostnames) {
+				ir.TLSRoutes = append(ir.TLSRoutes, key(route.Namespace, route.Name))
+			}
+		}
+		sort.Strings(ir.HTTPRoutes)
+		sort.Strings(ir.GRPCRoutes)
+		sort.Strings(ir.TLSRoutes)
+		if cmRef, ok := TCPServicesConfigMapRef(ingress); ok {
+			for _, route := range result.TCPRoutes {
+				if route.Namespace == namespace && strings.HasPrefix(route.Name, cmRef.Name+"-") &&
+					len(route.Spec.ParentRefs) > 0 && string(route.Spec.ParentRefs[0].Name) == ingressClass {
+					ir.TCPRoutes = append(ir.TCPRoutes, key(route.Namespace, route.Name))
+				}
+			}
+		}
+
+		// BackendTLSPolicies attach to the backend Services of the Ingress,
+		// and ReferenceGrants allow references to its Services and Secrets.
+		services, secrets := ingressServicesAndSecrets(ingress)
+		for _, policy := range result.BackendTLSPolicies {
+			if policy.Namespace != namespace {
+				continue
+			}
+			for _, targetRef := range policy.Spec.TargetRefs {
+				if targetRef.Kind == "Service" && services[string(targetRef.Name)] {
+					ir.BackendTLSPolicies = append(ir.BackendTLSPolicies, key(policy.Namespace, policy.Name))
+					break
+				}
+			}
+		}
+		for _, grant := range result.ReferenceGrants {
+			if grant.Namespace != namespace {
+				continue
+			}
+			for _, to := range grant.Spec.To {
+				names := secrets
+				if to.Kind == "Service" {
+					names = services
+				}
+				if (to.Kind == "Secret" || to.Kind == "Service") && (to.Name == nil || names[string(*to.Name)]) {
+					ir.ReferenceGrants = append(ir.ReferenceGrants, key(grant.Namespace, grant.Name))
+					break
+				}
+			}
+		}
+		for _, policy := range result.Policies {
+			if policy.GetNamespace() != namespace {
+				continue
+			}
+			for _, targetRef := range policyTargetRefs(policy) {
+				kind, _ := targetRef["kind"].(string)
+				name, _ := targetRef["name"].(string)
+				if ownRoutes[generated(kind, policy.GetNamespace(), name)] {
+					ir.Policies = append(ir.Policies, generated(policy.GetKind(), policy.GetNamespace(), policy.GetName()))
+					break
+				}
+			}
+		}
+
+		for _, w := range result.Warnings {
+			if w.Ingress == (types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}) {
+				ir.Warnings = append(ir.Warnings, fmt.Sprintf("%s: %s", w.Field, w.Message))
+			}
+		}
+		report.Ingresses = append(report.Ingresses, ir)
+	}
+	return report
+}
+
+// routeHasHost returns whether the route with the given hostnames is the
+// route of the host of an Ingress rule. Rules without host are converted to
+// routes without hostnames.
+func routeHasHost(hostnames []gatewayv1.Hostname, host string) bool {
+	if host == "" {
+		return len(hostnames) == 0
+	}
+	for _, hostname := range hostnames {
+		if string(hostname) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressServicesAndSecrets returns the names of the backend Services and of
+// the TLS Secrets of the Ingress.
+func ingressServicesAndSecrets(ingress networkingv1.Ingress) (map[string]bool, map[string]bool) {
+	services := map[string]bool{}
+	if backend := ingress.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		services[backend.Service.Name] = true
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				services[path.Backend.Service.Name] = true
+			}
+		}
+	}
+	secrets := map[string]bool{}
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName != "" {
+			secrets[tls.SecretName] = true
+		}
+	}
+	return services, secrets
+}
+
+// policyTargetRefs returns the targetRef, or the targetRefs, of a policy
+// attachment.
+func policyTargetRefs(policy unstructured.Unstructured) []map[string]interface{} {
+	if targetRef, ok, _ := unstructured.NestedMap(policy.Object, "spec", "targetRef"); ok {
+		return []map[string]interface{}{targetRef}
+	}
+	targetRefs, _, _ := unstructured.NestedSlice(policy.Object, "spec", "targetRefs")
+	var refs []map[string]interface{}
+	for _, targetRef := range targetRefs {
+		if ref, ok := targetRef.(map[string]interface{}); ok {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
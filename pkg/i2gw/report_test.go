@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_NewConversionReport(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	backend := networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{
+			Name: "example",
+			Port: networkingv1.ServiceBackendPort{Number: 8080},
+		},
+	}
+	newIngress := func(name, host string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{Path: "/", PathType: &iPrefix, Backend: backend}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	withDefaultBackend := newIngress("default", "bar.example.com", nil)
+	withDefaultBackend.Spec.DefaultBackend = &backend
+
+	ingresses := []networkingv1.Ingress{
+		newIngress("foo", "foo.example.com", map[string]string{"nginx.ingress.kubernetes.io/configuration-snippet": "rewrite ^/old/(.*)$ /new/$1 break;"}),
+		newIngress("foo-api", "foo.example.com", nil),
+		withDefaultBackend,
+		newIngress("secure", "secure.example.com", map[string]string{sslPassthroughAnnotation: "true"}),
+		newIngress("internal", "internal.example.com", map[string]string{whitelistSourceRangeAnnotation: "10.0.0.0/8"}),
+	}
+	result, err := Convert(ingresses, Options{TargetImplementation: "envoy-gateway"})
+	if err != nil {
+		t.Fatalf("Unexpected conversion error: %v", err)
+	}
+
+	expected := ConversionReport{Ingresses: []IngressReport{{
+		Ingress:    "test/foo",
+		Gateways:   []string{"test/nginx"},
+		HTTPRoutes: []string{"test/foo-example-com"},
+		GRPCRoutes: []string{},
+		TCPRoutes:  []string{},
+		TLSRoutes:  []string{},
+
+		BackendTLSPolicies: []string{},
+		ReferenceGrants:    []string{},
+		Policies:           []string{},
+
+		Warnings: []string{"foo.metadata.annotations[nginx.ingress.kubernetes.io/configuration-snippet]: nginx snippets cannot be represented in Gateway API and must be ported manually:\nrewrite ^/old/(.*)$ /new/$1 break;"},
+	}, {
+		Ingress:    "test/foo-api",
+		Gateways:   []string{"test/nginx"},
+		HTTPRoutes: []string{"test/foo-example-com"},
+		GRPCRoutes: []string{},
+		TCPRoutes:  []string{},
+		TLSRoutes:  []string{},
+
+		BackendTLSPolicies: []string{},
+		ReferenceGrants:    []string{},
+		Policies:           []string{},
+
+		Warnings: []string{},
+	}, {
+		Ingress:    "test/default",
+		Gateways:   []string{"test/nginx"},
+		HTTPRoutes: []string{"test/bar-example-com", "test/default-default-backend"},
+		GRPCRoutes: []string{},
+		TCPRoutes:  []string{},
+		TLSRoutes:  []string{},
+
+		BackendTLSPolicies: []string{},
+		ReferenceGrants:    []string{},
+		Policies:           []string{},
+
+		Warnings: []string{},
+	}, {
+		Ingress:    "test/secure",
+		Gateways:   []string{"test/nginx"},
+		HTTPRoutes: []string{},
+		GRPCRoutes: []string{},
+		TCPRoutes:  []string{},
+		TLSRoutes:  []string{"test/secure-example-com"},
+
+		BackendTLSPolicies: []string{},
+		ReferenceGrants:    []string{},
+		Policies:           []string{},
+
+		Warnings: []string{},
+	}, {
+		Ingress:    "test/internal",
+		Gateways:   []string{"test/nginx"},
+		HTTPRoutes: []string{"test/internal-example-com"},
+		GRPCRoutes: []string{},
+		TCPRoutes:  []string{},
+		TLSRoutes:  []string{},
+
+		BackendTLSPolicies: []string{},
+		ReferenceGrants:    []string{},
+		Policies:           []string{"SecurityPolicy/test/internal-example-com-source-ranges"},
+
+		Warnings: []string{},
+	}}}
+
+	report := NewConversionReport(ingresses, result, nil)
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("Unexpected conversion report (-want +got):\n%s", diff)
+	}
+
+	// With the resources moved to another namespace, the Ingresses are still
+	// reported in theirs, along with the resources in the namespace they were
+	// moved to.
+	mapping := map[string]string{"test": "prod"}
+	MapNamespaces(mapping, result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants, result.Policies, result.Warnings)
+	mapped := NewConversionReport(ingresses, result, mapping)
+	for i := range expected.Ingresses {
+		for _, resources := range []*[]string{&expected.Ingresses[i].Gateways, &expected.Ingresses[i].HTTPRoutes, &expected.Ingresses[i].TLSRoutes} {
+			for j, resource := range *resources {
+				(*resources)[j] = "prod/" + strings.TrimPrefix(resource, "test/")
+			}
+		}
+		for j, policy := range expected.Ingresses[i].Policies {
+			expected.Ingresses[i].Policies[j] = strings.Replace(policy, "/test/", "/prod/", 1)
+		}
+	}
+	if diff := cmp.Diff(expected, mapped); diff != "" {
+		t.Errorf("Unexpected conversion report with mapped namespaces (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// namespaceNameLabel is the label the API server sets on every namespace to
+// its name, with which the listeners of a shared Gateway select the
+// namespaces of their routes.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+var referenceGrantGVK = gatewayv1beta1.SchemeGroupVersion.WithKind("ReferenceGrant")
+
+// ValidateSharedGatewayNamespace returns an error when the namespace of the
+// shared Gateway isn't a valid namespace name. An empty namespace doesn't
+// share Gateways.
+func ValidateSharedGatewayNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("%s is not a valid namespace for the shared Gateway: %v", namespace, errs)
+	}
+	return nil
+}
+
+// sharedGatewayNames returns the name of the Gateway shared across
+// namespaces, by namespace, so that the Ingresses of every namespace are
+// converted to a Gateway of this name that shareGateways merges. As with
+// namespaceGatewayNames, the Gateway is named after the class of the
+// Ingresses, which the Ingresses without class join, or after the first
+// Ingress when none of them has a class. A Gateway has a single class, so
+// Ingresses of different classes are reported as errors.
+func sharedGatewayNames(namespace string, ingresses []networkingv1.Ingress) (map[string]string, field.ErrorList) {
+	var errs field.ErrorList
+	var name string
+	for _, ingress := range ingresses {
+		normalizeIngressClass(&ingress)
+		if ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName == "" {
+			continue
+		}
+		class := *ingress.Spec.IngressClassName
+		if name != "" && name != class {
+			errs = append(errs, field.Invalid(field.NewPath(ingress.Name, "spec", "ingressClassName"), class,
+				fmt.Sprintf("the Ingresses of all the namespaces are converted to a single Gateway of namespace %s, which can't have both classes %s and %s", namespace, name, class)))
+			continue
+		}
+		name = class
+	}
+	if name == "" && len(ingresses) > 0 {
+		name = ingresses[0].Name
+	}
+	names := map[string]string{}
+	for _, ingress := range ingresses {
+		names[ingress.Namespace] = name
+	}
+	return names, errs
+}
+
+// shareGateways merges the generated Gateways, named by sharedGatewayNames,
+// into a single Gateway of the namespace, shared by the routes of all the
+// namespaces.
+//
+// The listeners of the Gateways are merged, identical listeners of several
+// Gateways, such as the HTTP listener of a host served in several namespaces,
+// being merged into one. Other listeners sharing a name, such as the HTTPS
+// listeners of a host served with the TLS Secrets of several namespaces, are
+// reported as errors naming the Ingresses of both namespaces. The parentRefs of the routes reference the shared
+// Gateway in its namespace, and its listeners allow the routes of the
+// namespaces attached to them through a namespace selector. The TLS Secrets of
+// the listeners are referenced in their namespace, which ReferenceGrants of
+// these namespaces allow.
+func (a *ingressAggregator) shareGateways(namespace string, result *Result) field.ErrorList {
+	if namespace == "" || len(result.Gateways) == 0 {
+		return nil
+	}
+
+	name := result.Gateways[0].Name
+	shared := gatewayv1beta1.Gateway{
+		TypeMeta:   result.Gateways[0].TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       gatewayv1beta1.GatewaySpec{GatewayClassName: result.Gateways[0].Spec.GatewayClassName},
+	}
+	merged := sets.New[string]()
+	secrets := map[string]sets.Set[string]{}
+	sources := sets.New[string]()
+	classes := sets.New[string]()
+	// listenerNamespaces are the namespaces of the Gateways the listeners of
+	// the shared Gateway are merged from, by name.
+	listenerNamespaces := map[gatewayv1.SectionName]string{}
+	var errs field.ErrorList
+	for _, gateway := range result.Gateways {
+		merged.Insert(gateway.Namespace + "/" + gateway.Name)
+		shared.Labels = mergeMissing(shared.Labels, gateway.Labels)
+		shared.Annotations = mergeMissing(shared.Annotations, gateway.Annotations)
+		sources = sources.Union(sourceIngresses(&gateway))
+		classes = classes.Union(ingressClasses(&gateway))
+		for _, listener := range gateway.Spec.Listeners {
+			listener = *listener.DeepCopy()
+			if listener.TLS != nil {
+				for i := range listener.TLS.CertificateRefs {
+					ref := &listener.TLS.CertificateRefs[i]
+					ns := gateway.Namespace
+					if ref.Namespace != nil {
+						ns = string(*ref.Namespace)
+					}
+					if ns == namespace {
+						continue
+					}
+					refNamespace := gatewayv1.Namespace(ns)
+					ref.Namespace = &refNamespace
+					if (ref.Group == nil || *ref.Group == "") && (ref.Kind == nil || *ref.Kind == "Secret") {
+						if secrets[ns] == nil {
+							secrets[ns] = sets.New[string]()
+						}
+						secrets[ns].Insert(string(ref.Name))
+					}
+				}
+			}
+			if hasListener(shared.Spec.Listeners, listener) {
+				continue
+			}
+			if ns, ok := listenerNamespaces[listener.Name]; ok && ns != gateway.Namespace {
+				if err := a.listenerConflict(namespace, listener, ns, gateway.Namespace); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			listenerNamespaces[listener.Name] = gateway.Namespace
+			shared.Spec.Listeners = append(shared.Spec.Listeners, listener)
+		}
+	}
+
+	// The namespaces of the routes attached to every listener of the shared
+	// Gateway. parentRefs without section attach to all the listeners.
+	routeNamespaces := map[gatewayv1.SectionName]sets.Set[string]{}
+	attach := func(routeNamespace string, parentRefs []gatewayv1.ParentReference) {
+		for i := range parentRefs {
+			ref := &parentRefs[i]
+			if (ref.Group != nil && *ref.Group != gatewayv1.GroupName) || (ref.Kind != nil && *ref.Kind != "Gateway") {
+				continue
+			}
+			ns := routeNamespace
+			if ref.Namespace != nil {
+				ns = string(*ref.Namespace)
+			}
+			if !merged.Has(ns + "/" + string(ref.Name)) {
+				continue
+			}
+			ref.Name = gatewayv1.ObjectName(name)
+			ref.Namespace = nil
+			if routeNamespace != namespace {
+				refNamespace := gatewayv1.Namespace(namespace)
+				ref.Namespace = &refNamespace
+			}
+			for _, listener := range shared.Spec.Listeners {
+				if ref.SectionName != nil && *ref.SectionName != listener.Name {
+					continue
+				}
+				if routeNamespaces[listener.Name] == nil {
+					routeNamespaces[listener.Name] = sets.New[string]()
+				}
+				routeNamespaces[listener.Name].Insert(routeNamespace)
+			}
+		}
+	}
+	for i := range result.HTTPRoutes {
+		attach(result.HTTPRoutes[i].Namespace, result.HTTPRoutes[i].Spec.ParentRefs)
+	}
+	for i := range result.GRPCRoutes {
+		attach(result.GRPCRoutes[i].Namespace, result.GRPCRoutes[i].Spec.ParentRefs)
+	}
+	for i := range result.TCPRoutes {
+		attach(result.TCPRoutes[i].Namespace, result.TCPRoutes[i].Spec.ParentRefs)
+	}
+	for i := range result.TLSRoutes {
+		attach(result.TLSRoutes[i].Namespace, result.TLSRoutes[i].Spec.ParentRefs)
+	}
+
+	for i := range shared.Spec.Listeners {
+		listener := &shared.Spec.Listeners[i]
+		namespaces := routeNamespaces[listener.Name]
+		if namespaces.Len() == 0 || (namespaces.Len() == 1 && namespaces.Has(namespace)) {
+			continue
+		}
+		if listener.AllowedRoutes == nil {
+			listener.AllowedRoutes = &gatewayv1beta1.AllowedRoutes{}
+		}
+		from := gatewayv1.NamespacesFromSelector
+		listener.AllowedRoutes.Namespaces = &gatewayv1beta1.RouteNamespaces{
+			From: &from,
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      namespaceNameLabel,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   sets.List(namespaces),
+			}}},
+		}
+	}
+
+	setIngressClasses(&shared, classes)
+
+	// The shared Gateway is generated from the Ingresses of all the merged
+	// Gateways, and its ReferenceGrants from the ones of their namespace.
+	grants := secretReferenceGrants(namespace, secrets)
+	if sources.Len() > 0 {
+		ingresses := namespacedNames(sources)
+		setSourceIngresses(&shared, ingresses...)
+		for i := range grants {
+			var namespaceSources []types.NamespacedName
+			for _, source := range ingresses {
+				if source.Namespace == grants[i].Namespace {
+					namespaceSources = append(namespaceSources, source)
+				}
+			}
+			if len(namespaceSources) == 0 {
+				namespaceSources = ingresses
+			}
+			addSourceIngresses(&grants[i], namespaceSources...)
+		}
+	}
+
+	result.Gateways = []gatewayv1beta1.Gateway{shared}
+	result.ReferenceGrants = append(result.ReferenceGrants, grants...)
+	return errs
+}
+
+// listenerConflict returns the error of a listener of the Gateway shared in
+// the namespace merged from the Gateways of two namespaces with different
+// settings, such as the HTTPS listener of a host served with the TLS Secrets
+// of both namespaces. The error is reported on the Ingress of the second
+// namespace serving the host, naming the Ingress of the first one. It returns
+// nil when the Ingresses of the listener aren't known, such as for the
+// listeners of TCP services, leaving the listeners to validateListeners.
+func (a *ingressAggregator) listenerConflict(namespace string, listener gatewayv1beta1.Listener, firstNamespace, secondNamespace string) *field.Error {
+	first, ok := a.listenerIngress(firstNamespace, listener)
+	if !ok {
+		return nil
+	}
+	second, ok := a.listenerIngress(secondNamespace, listener)
+	if !ok {
+		return nil
+	}
+	var hostname string
+	if listener.Hostname != nil {
+		hostname = string(*listener.Hostname)
+	}
+	fieldPath := field.NewPath(second.Name, "spec", "rules")
+	if listener.TLS != nil {
+		fieldPath = field.NewPath(second.Name, "spec", "tls")
+	}
+	return field.Invalid(fieldPath, hostname, fmt.Sprintf("host is also served by Ingress %s, and listener %s of the Gateway shared in namespace %s can't serve it with the settings of both namespaces, such as their TLS Secrets", first, listener.Name, namespace))
+}
+
+// listenerIngress returns the first Ingress of the namespace, by name, serving
+// the host of the listener: an Ingress with TLS for the host for listeners
+// with TLS.
+func (a *ingressAggregator) listenerIngress(namespace string, listener gatewayv1beta1.Listener) (types.NamespacedName, bool) {
+	var host string
+	if listener.Hostname != nil {
+		host = string(*listener.Hostname)
+	}
+	var ingresses []types.NamespacedName
+	for _, rg := range a.ruleGroups {
+		if rg.namespace != namespace || rg.host != host {
+			continue
+		}
+		if listener.TLS != nil {
+			ingresses = append(ingresses, rg.tlsIngresses...)
+			continue
+		}
+		for _, ir := range rg.rules {
+			ingresses = append(ingresses, ir.ingress)
+		}
+	}
+	if len(ingresses) == 0 {
+		return types.NamespacedName{}, false
+	}
+	sort.Slice(ingresses, func(i, j int) bool {
+		return ingresses[i].String() < ingresses[j].String()
+	})
+	return ingresses[0], true
+}
+
+// hasListener returns whether the listeners already have the listener.
+func hasListener(listeners []gatewayv1beta1.Listener, listener gatewayv1beta1.Listener) bool {
+	for _, l := range listeners {
+		if reflect.DeepEqual(l, listener) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeMissing returns the entries of to along with the entries of from whose
+// key to doesn't have.
+func mergeMissing(to, from map[string]string) map[string]string {
+	for k, v := range from {
+		if to == nil {
+			to = map[string]string{}
+		}
+		if _, ok := to[k]; !ok {
+			to[k] = v
+		}
+	}
+	return to
+}
+
+// secretReferenceGrants returns a ReferenceGrant in every namespace of the
+// secrets, by namespace, allowing the Gateways of gatewayNamespace to
+// reference them.
+func secretReferenceGrants(gatewayNamespace string, secrets map[string]sets.Set[string]) []gatewayv1beta1.ReferenceGrant {
+	namespaces := make([]string, 0, len(secrets))
+	for ns := range secrets {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var grants []gatewayv1beta1.ReferenceGrant
+	for _, ns := range namespaces {
+		grant := gatewayv1beta1.ReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "gateways-" + gatewayNamespace, Namespace: ns},
+			Spec: gatewayv1beta1.ReferenceGrantSpec{
+				From: []gatewayv1beta1.ReferenceGrantFrom{{
+					Group:     gatewayv1.GroupName,
+					Kind:      "Gateway",
+					Namespace: gatewayv1.Namespace(gatewayNamespace),
+				}},
+			},
+		}
+		grant.SetGroupVersionKind(referenceGrantGVK)
+		for _, secret := range sets.List(secrets[ns]) {
+			secretName := gatewayv1.ObjectName(secret)
+			grant.Spec.To = append(grant.Spec.To, gatewayv1beta1.ReferenceGrantTo{Group: "", Kind: "Secret", Name: &secretName})
+		}
+		grants = append(grants, grant)
+	}
+	return grants
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_shareGateways(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(namespace, name, class, host, secret string) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: name,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if class != "" {
+			ingress.Spec.IngressClassName = stringPtr(class)
+		}
+		if secret != "" {
+			ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: secret}}
+		}
+		return ingress
+	}
+	selector := func(namespaces ...string) *gatewayv1beta1.AllowedRoutes {
+		from := gatewayv1.NamespacesFromSelector
+		return &gatewayv1beta1.AllowedRoutes{Namespaces: &gatewayv1beta1.RouteNamespaces{
+			From: &from,
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      namespaceNameLabel,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   namespaces,
+			}}},
+		}}
+	}
+
+	testCases := []struct {
+		name                   string
+		ingresses              []networkingv1.Ingress
+		expectedGateway        string
+		expectedAllowedRoutes  map[gatewayv1.SectionName]*gatewayv1beta1.AllowedRoutes
+		expectedParentRefNames map[string]*gatewayv1.Namespace
+		expectedGrants         []gatewayv1beta1.ReferenceGrant
+		expectNumErrors        int
+	}{{
+		name: "listeners of several namespaces",
+		ingresses: []networkingv1.Ingress{
+			newIngress("team-a", "shop", "nginx", "shop.example.com", "shop-tls"),
+			newIngress("team-b", "blog", "", "shop.example.com", ""),
+		},
+		expectedGateway: "nginx",
+		expectedAllowedRoutes: map[gatewayv1.SectionName]*gatewayv1beta1.AllowedRoutes{
+			"shop-example-com-http":  selector("team-a", "team-b"),
+			"shop-example-com-https": selector("team-a"),
+		},
+		expectedParentRefNames: map[string]*gatewayv1.Namespace{
+			"team-a/shop-example-com": namespacePtr("infra"),
+			"team-b/shop-example-com": namespacePtr("infra"),
+		},
+		expectedGrants: []gatewayv1beta1.ReferenceGrant{{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "gateway.networking.k8s.io/v1beta1", Kind: "ReferenceGrant"},
+			ObjectMeta: metav1.ObjectMeta{Name: "gateways-infra", Namespace: "team-a"},
+			Spec: gatewayv1beta1.ReferenceGrantSpec{
+				From: []gatewayv1beta1.ReferenceGrantFrom{{Group: gatewayv1.GroupName, Kind: "Gateway", Namespace: "infra"}},
+				To:   []gatewayv1beta1.ReferenceGrantTo{{Group: "", Kind: "Secret", Name: objectNamePtr("shop-tls")}},
+			},
+		}},
+	}, {
+		name: "routes of the shared namespace",
+		ingresses: []networkingv1.Ingress{
+			newIngress("infra", "shop", "nginx", "shop.example.com", "shop-tls"),
+		},
+		expectedGateway: "nginx",
+		expectedAllowedRoutes: map[gatewayv1.SectionName]*gatewayv1beta1.AllowedRoutes{
+			"shop-example-com-http":  nil,
+			"shop-example-com-https": nil,
+		},
+		expectedParentRefNames: map[string]*gatewayv1.Namespace{
+			"infra/shop-example-com": nil,
+		},
+	}, {
+		name: "Gateways without class",
+		ingresses: []networkingv1.Ingress{
+			newIngress("team-a", "shop", "", "shop.example.com", ""),
+			newIngress("team-b", "blog", "", "blog.example.com", ""),
+		},
+		expectedGateway: "shop",
+		expectedAllowedRoutes: map[gatewayv1.SectionName]*gatewayv1beta1.AllowedRoutes{
+			"shop-example-com-http": selector("team-a"),
+			"blog-example-com-http": selector("team-b"),
+		},
+		expectedParentRefNames: map[string]*gatewayv1.Namespace{
+			"team-a/shop-example-com": namespacePtr("infra"),
+			"team-b/blog-example-com": namespacePtr("infra"),
+		},
+	}, {
+		name: "host served with the TLS Secrets of several namespaces",
+		ingresses: []networkingv1.Ingress{
+			newIngress("team-a", "shop", "nginx", "shop.example.com", "shop-tls"),
+			newIngress("team-b", "blog", "nginx", "shop.example.com", "shop-tls"),
+		},
+		expectNumErrors: 1,
+	}, {
+		name: "Gateways of different classes",
+		ingresses: []networkingv1.Ingress{
+			newIngress("team-a", "shop", "nginx", "shop.example.com", ""),
+			newIngress("team-b", "blog", "haproxy", "blog.example.com", ""),
+		},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{SharedGatewayNamespace: "infra"})
+			if len(errs) != tc.expectNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %v", tc.expectNumErrors, len(errs), errs)
+			}
+			if tc.expectNumErrors > 0 {
+				return
+			}
+
+			if len(result.Gateways) != 1 {
+				t.Fatalf("Expected a single Gateway, got %d", len(result.Gateways))
+			}
+			gateway := result.Gateways[0]
+			if gateway.Namespace != "infra" || gateway.Name != tc.expectedGateway {
+				t.Errorf("Expected Gateway infra/%s, got %s/%s", tc.expectedGateway, gateway.Namespace, gateway.Name)
+			}
+			allowedRoutes := map[gatewayv1.SectionName]*gatewayv1beta1.AllowedRoutes{}
+			for _, listener := range gateway.Spec.Listeners {
+				allowedRoutes[listener.Name] = listener.AllowedRoutes
+			}
+			if diff := cmp.Diff(tc.expectedAllowedRoutes, allowedRoutes); diff != "" {
+				t.Errorf("Unexpected allowedRoutes of the listeners (-want +got):\n%s", diff)
+			}
+
+			parentRefNamespaces := map[string]*gatewayv1.Namespace{}
+			for _, route := range result.HTTPRoutes {
+				for _, ref := range route.Spec.ParentRefs {
+					if ref.Name != gatewayv1.ObjectName(tc.expectedGateway) {
+						t.Errorf("Expected HTTPRoute %s/%s to reference Gateway %s, got %s", route.Namespace, route.Name, tc.expectedGateway, ref.Name)
+					}
+					parentRefNamespaces[route.Namespace+"/"+route.Name] = ref.Namespace
+				}
+			}
+			if diff := cmp.Diff(tc.expectedParentRefNames, parentRefNamespaces); diff != "" {
+				t.Errorf("Unexpected parentRef namespaces (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedGrants, result.ReferenceGrants); diff != "" {
+				t.Errorf("Unexpected ReferenceGrants (-want +got):\n%s", diff)
+			}
+			if errs := validateListeners(result.Gateways); len(errs) > 0 {
+				t.Errorf("Unexpected listener errors: %v", errs)
+			}
+		})
+	}
+}
+
+func Test_shareGatewaysListenerConflict(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(namespace, name string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}},
+				Rules: []networkingv1.IngressRule{{
+					Host: "shop.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	_, errs := convertIngresses([]networkingv1.Ingress{newIngress("team-a", "shop"), newIngress("team-b", "blog")}, Options{SharedGatewayNamespace: "infra"})
+	expected := field.ErrorList{field.Invalid(field.NewPath("blog", "spec", "tls"), "shop.example.com",
+		"host is also served by Ingress team-a/shop, and listener shop-example-com-https of the Gateway shared in namespace infra can't serve it with the settings of both namespaces, such as their TLS Secrets")}
+	if diff := cmp.Diff(expected, errs); diff != "" {
+		t.Errorf("Unexpected errors (-want +got):\n%s", diff)
+	}
+}
+
+func Test_ValidateSharedGatewayNamespace(t *testing.T) {
+	for namespace, valid := range map[string]bool{"": true, "infra": true, "Infra": false, "infra.example": false} {
+		if err := ValidateSharedGatewayNamespace(namespace); (err == nil) != valid {
+			t.Errorf("ValidateSharedGatewayNamespace(%q) = %v, expected valid %t", namespace, err, valid)
+		}
+	}
+}
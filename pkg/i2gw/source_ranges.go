@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	whitelistSourceRangeAnnotation = "nginx.ingress.kubernetes.io/whitelist-source-range"
+
+	envoyGatewayAPIVersion = "gateway.envoyproxy.io/v1alpha1"
+	// sourceRangesPolicyNameSuffix suffixes the name of the route a
+	// SecurityPolicy restricts to the allowed source ranges.
+	sourceRangesPolicyNameSuffix = "-source-ranges"
+	// basicAuthPolicyNameSuffix suffixes the name of the route a
+	// SecurityPolicy authenticates the requests of.
+	basicAuthPolicyNameSuffix = "-basic-auth"
+	// securityPolicyNameSuffix suffixes the name of the route a
+	// SecurityPolicy both restricts to the allowed source ranges and
+	// authenticates the requests of.
+	securityPolicyNameSuffix = "-security"
+)
+
+// getSourceRanges reads the source IP allow-list of ingress-nginx, as CIDRs
+// separated by commas. IP addresses are single address CIDRs. As Gateway API
+// doesn't restrict the clients of routes, the allow-list is reported by a
+// warning, so it isn't silently dropped, unless a policy of the target
+// implementation enforces it.
+func getSourceRanges(ingress networkingv1.Ingress, fieldPath *field.Path) ([]string, *Warning, *field.Error) {
+	value, ok := ingress.Annotations[whitelistSourceRangeAnnotation]
+	if !ok {
+		return nil, nil, nil
+	}
+	var cidrs []string
+	for _, r := range strings.Split(value, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if ip := net.ParseIP(r); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			r = fmt.Sprintf("%s/%d", r, bits)
+		}
+		if _, _, err := net.ParseCIDR(r); err != nil {
+			return nil, nil, field.Invalid(fieldPath.Key(whitelistSourceRangeAnnotation), value, fmt.Sprintf("invalid source range %q, must be a CIDR or an IP address", r))
+		}
+		cidrs = append(cidrs, r)
+	}
+	if len(cidrs) == 0 {
+		return nil, nil, nil
+	}
+	return cidrs, &Warning{
+		Ingress: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+		Field:   fieldPath.Key(whitelistSourceRangeAnnotation),
+		Message: fmt.Sprintf("SECURITY: the Ingress only accepts requests from %s, but Gateway API routes accept requests from all clients; the allow-list must be enforced by the Gateway implementation",
+			strings.Join(cidrs, ", ")),
+	}, nil
+}
+
+// allowedSourceRanges returns the source IP allow-list of the paths, nil
+// when all clients are allowed.
+func (e *extra) allowedSourceRanges() []string {
+	if e == nil {
+		return nil
+	}
+	return e.sourceRanges
+}
+
+// envoyGatewaySecurityPolicies returns the SecurityPolicy of the routes of
+// the rule group with a source IP allow-list, denying requests from other
+// clients, or with basic authentication. Envoy Gateway applies a single
+// SecurityPolicy to a route, so the policy has both when the routes have
+// both. A policy applies to all the paths of its routes, so no policy is
+// generated when the Ingresses of the host have different allow-lists or
+// authentications, and a warning is returned instead.
+func envoyGatewaySecurityPolicies(rg *ingressRuleGroup, name string, kinds []string) ([]unstructured.Unstructured, []Warning) {
+	cidrs, same := rg.commonValue(func(e *extra) string {
+		return strings.Join(e.allowedSourceRanges(), ",")
+	})
+	if !same {
+		return nil, []Warning{rg.differentValuesWarning(whitelistSourceRangeAnnotation,
+			fmt.Sprintf("SECURITY: the Ingresses of host %q have different source IP allow-lists, which a SecurityPolicy of its routes can't represent; no SecurityPolicy is generated and the allow-lists must be enforced manually", rg.host))}
+	}
+	secret, same := rg.commonValue((*extra).basicAuthSecret)
+	if !same {
+		return nil, []Warning{rg.differentValuesWarning(authSecretAnnotation,
+			fmt.Sprintf("SECURITY: the Ingresses of host %q have different basic authentications, which a SecurityPolicy of its routes can't represent; no SecurityPolicy is generated and the authentication must be enforced manually", rg.host))}
+	}
+	if cidrs == "" && secret == "" {
+		return nil, nil
+	}
+	if cidrs != "" {
+		rg.enforce(whitelistSourceRangeAnnotation)
+	}
+	if secret != "" {
+		rg.enforce(authTypeAnnotation)
+	}
+
+	var ranges []string
+	if cidrs != "" {
+		ranges = strings.Split(cidrs, ",")
+	}
+	var auth *basicAuth
+	var warnings []Warning
+	if secret != "" {
+		auth = rg.rules[0].extra.basicAuth
+		warnings = append(warnings, basicAuthSecretWarning(rg, securityPolicyName(name, ranges, auth), auth))
+	}
+	return []unstructured.Unstructured{envoyGatewaySecurityPolicy(rg.namespace, name, kinds, ranges, auth)}, warnings
+}
+
+// securityPolicyName returns the name of the SecurityPolicy of the route
+// named name, after the source IP allow-list or the basic authentication it
+// enforces, or both.
+func securityPolicyName(name string, cidrs []string, auth *basicAuth) string {
+	switch {
+	case len(cidrs) > 0 && auth != nil:
+		return name + securityPolicyNameSuffix
+	case auth != nil:
+		return name + basicAuthPolicyNameSuffix
+	default:
+		return name + sourceRangesPolicyNameSuffix
+	}
+}
+
+// envoyGatewaySecurityPolicy returns an Envoy Gateway SecurityPolicy of the
+// routes of the given kinds named name, only allowing requests from the
+// CIDRs when there are any, and authenticating them when auth is set.
+func envoyGatewaySecurityPolicy(namespace, name string, kinds, cidrs []string, auth *basicAuth) unstructured.Unstructured {
+	var targetRefs []interface{}
+	for _, kind := range kinds {
+		targetRefs = append(targetRefs, policyTargetRef(kind, name))
+	}
+	spec := map[string]interface{}{
+		"targetRefs": targetRefs,
+	}
+	if len(cidrs) > 0 {
+		var clientCIDRs []interface{}
+		for _, cidr := range cidrs {
+			clientCIDRs = append(clientCIDRs, cidr)
+		}
+		spec["authorization"] = map[string]interface{}{
+			"defaultAction": "Deny",
+			"rules": []interface{}{map[string]interface{}{
+				"name":   "allow-source-ranges",
+				"action": "Allow",
+				"principal": map[string]interface{}{
+					"clientCIDRs": clientCIDRs,
+				},
+			}},
+		}
+	}
+	if auth != nil {
+		spec["basicAuth"] = envoyGatewayBasicAuth(namespace, auth)
+	}
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": envoyGatewayAPIVersion,
+		"kind":       "SecurityPolicy",
+		"metadata": map[string]interface{}{
+			"name":      securityPolicyName(name, cidrs, auth),
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+}
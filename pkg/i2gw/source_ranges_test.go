@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_getSourceRanges(t *testing.T) {
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedRanges []string
+		expectWarning  bool
+		expectingError bool
+	}{{
+		name: "no allow-list",
+	}, {
+		name:           "CIDRs and IP addresses",
+		annotations:    map[string]string{whitelistSourceRangeAnnotation: "10.0.0.0/8, 192.168.1.10,2001:db8::1"},
+		expectedRanges: []string{"10.0.0.0/8", "192.168.1.10/32", "2001:db8::1/128"},
+		expectWarning:  true,
+	}, {
+		name:        "empty allow-list",
+		annotations: map[string]string{whitelistSourceRangeAnnotation: " , "},
+	}, {
+		name:           "invalid range",
+		annotations:    map[string]string{whitelistSourceRangeAnnotation: "10.0.0.0/8,intranet"},
+		expectingError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: tc.annotations}}
+			ranges, warning, err := getSourceRanges(ingress, field.NewPath("app", "metadata", "annotations"))
+			if tc.expectingError {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", ranges)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedRanges, ranges); diff != "" {
+				t.Errorf("Unexpected source ranges (-want +got):\n%s", diff)
+			}
+			if (warning != nil) != tc.expectWarning {
+				t.Fatalf("Expected warning: %v, got %+v", tc.expectWarning, warning)
+			}
+			// The warning must name every allowed range.
+			for _, r := range tc.expectedRanges {
+				if !strings.Contains(warning.Message, r) {
+					t.Errorf("Expected the warning to name %s, got %q", r, warning.Message)
+				}
+			}
+		})
+	}
+}
+
+func Test_envoyGatewaySecurityPolicies(t *testing.T) {
+	newIngress := func(name, path, sourceRanges string) networkingv1.Ingress {
+		ingress := newNginxIngress(name, path, nil)
+		if sourceRanges != "" {
+			ingress.Annotations = map[string]string{whitelistSourceRangeAnnotation: sourceRanges}
+		}
+		return ingress
+	}
+
+	testCases := []struct {
+		name                 string
+		ingresses            []networkingv1.Ingress
+		targetImplementation string
+		expectedPolicies     []unstructured.Unstructured
+		expectedNumWarnings  int
+	}{{
+		name:                "warning only",
+		ingresses:           []networkingv1.Ingress{newIngress("app", "/", "10.0.0.0/8")},
+		expectedNumWarnings: 1,
+	}, {
+		name:                 "no allow-list",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "/", "")},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedPolicies:     nil,
+	}, {
+		name:                 "Envoy Gateway SecurityPolicy",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "/", "10.0.0.0/8,192.168.1.10")},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedPolicies:     []unstructured.Unstructured{envoyGatewaySecurityPolicy("test", "example-com", []string{"HTTPRoute"}, []string{"10.0.0.0/8", "192.168.1.10/32"}, nil)},
+	}, {
+		name:                 "same allow-lists on a host",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "/", "10.0.0.0/8"), newIngress("api", "/api", "10.0.0.0/8")},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedPolicies:     []unstructured.Unstructured{envoyGatewaySecurityPolicy("test", "example-com", []string{"HTTPRoute"}, []string{"10.0.0.0/8"}, nil)},
+	}, {
+		name:                 "different allow-lists on a host",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "/", "10.0.0.0/8"), newIngress("admin", "/admin", "10.1.0.0/16")},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedNumWarnings:  3,
+	}, {
+		name:                 "allow-list on part of a host",
+		ingresses:            []networkingv1.Ingress{newIngress("app", "/", ""), newIngress("admin", "/admin", "10.1.0.0/16")},
+		targetImplementation: TargetImplementationEnvoyGateway,
+		expectedNumWarnings:  2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{TargetImplementation: tc.targetImplementation})
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			if diff := cmp.Diff(tc.expectedPolicies, result.Policies); diff != "" {
+				t.Errorf("Unexpected policies (-want +got):\n%s", diff)
+			}
+			if len(result.Warnings) != tc.expectedNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectedNumWarnings, len(result.Warnings), result.Warnings)
+			}
+			// The allow-lists no policy enforces aren't hinted to the target
+			// implementation already set.
+			for _, warning := range result.Warnings {
+				if tc.targetImplementation != "" && strings.Contains(warning.Message, "--target-implementation="+tc.targetImplementation) {
+					t.Errorf("Unexpected hint to the target implementation in warning %+v", warning)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// SourceIngressAnnotation records the provenance of the generated resources:
+// the Ingresses every resource is generated from, as <namespace>/<name>
+// separated by commas, see Options.AnnotateSourceIngresses. Resources whose
+// Ingresses were all deleted can be pruned safely.
+const SourceIngressAnnotation = "ingress2gateway.kubernetes.io/source-ingress"
+
+// annotateSources adds the Ingresses to the SourceIngressAnnotation of the
+// generated resource, when the sources of the resources are annotated.
+func (a *ingressAggregator) annotateSources(obj metav1.Object, ingresses ...types.NamespacedName) {
+	if a.annotateSourceIngresses {
+		addSourceIngresses(obj, ingresses...)
+	}
+}
+
+// addSourceIngresses adds the Ingresses to the SourceIngressAnnotation of the
+// resource. The annotations of the resource are copied, as they may be shared
+// with other resources.
+func addSourceIngresses(obj metav1.Object, ingresses ...types.NamespacedName) {
+	sources := sourceIngresses(obj)
+	for _, ingress := range ingresses {
+		sources.Insert(ingress.String())
+	}
+	if sources.Len() == 0 {
+		return
+	}
+	annotations := make(map[string]string, len(obj.GetAnnotations())+1)
+	for k, v := range obj.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[SourceIngressAnnotation] = strings.Join(sets.List(sources), ",")
+	obj.SetAnnotations(annotations)
+}
+
+// sourceIngresses returns the Ingresses of the SourceIngressAnnotation of the
+// resource, as <namespace>/<name>.
+func sourceIngresses(obj metav1.Object) sets.Set[string] {
+	sources := sets.New[string]()
+	value, ok := obj.GetAnnotations()[SourceIngressAnnotation]
+	if !ok {
+		return sources
+	}
+	for _, source := range strings.Split(value, ",") {
+		if source != "" {
+			sources.Insert(source)
+		}
+	}
+	return sources
+}
+
+// setSourceIngresses replaces the SourceIngressAnnotation of a resource
+// having it by the Ingresses, such as the Ingresses of the part of a split
+// HTTPRoute.
+func setSourceIngresses(obj metav1.Object, ingresses ...types.NamespacedName) {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[SourceIngressAnnotation]; !ok {
+		return
+	}
+	others := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k != SourceIngressAnnotation {
+			others[k] = v
+		}
+	}
+	obj.SetAnnotations(others)
+	addSourceIngresses(obj, ingresses...)
+}
+
+// namespacedNames returns the Ingresses of a SourceIngressAnnotation.
+func namespacedNames(sources sets.Set[string]) []types.NamespacedName {
+	var ingresses []types.NamespacedName
+	for _, source := range sets.List(sources) {
+		namespace, name, _ := strings.Cut(source, "/")
+		ingresses = append(ingresses, types.NamespacedName{Namespace: namespace, Name: name})
+	}
+	return ingresses
+}
+
+// partIngresses returns the Ingresses of the paths of the rules of a part of
+// a split HTTPRoute.
+func partIngresses(rules []gatewayv1beta1.HTTPRouteRule, ruleIngresses map[string]types.NamespacedName) []types.NamespacedName {
+	var ingresses []types.NamespacedName
+	for _, rule := range rules {
+		if ingress, ok := ruleIngresses[rulePathKey(rule)]; ok {
+			ingresses = append(ingresses, ingress)
+		}
+	}
+	return ingresses
+}
+
+// ingresses returns the Ingresses of the rules of the rule group.
+func (rg *ingressRuleGroup) ingresses() []types.NamespacedName {
+	var ingresses []types.NamespacedName
+	seen := map[types.NamespacedName]bool{}
+	for _, ir := range rg.rules {
+		if !seen[ir.ingress] {
+			seen[ir.ingress] = true
+			ingresses = append(ingresses, ir.ingress)
+		}
+	}
+	return ingresses
+}
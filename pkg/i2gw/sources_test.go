@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_addSourceIngresses(t *testing.T) {
+	testCases := []struct {
+		name                string
+		annotations         map[string]string
+		ingresses           []types.NamespacedName
+		expectedAnnotations map[string]string
+	}{{
+		name: "no Ingresses",
+	}, {
+		name:                "sorted Ingresses",
+		ingresses:           []types.NamespacedName{{Namespace: "test", Name: "web"}, {Namespace: "test", Name: "api"}},
+		expectedAnnotations: map[string]string{SourceIngressAnnotation: "test/api,test/web"},
+	}, {
+		name:                "Ingresses merged with the annotated ones",
+		annotations:         map[string]string{"team": "web", SourceIngressAnnotation: "test/web"},
+		ingresses:           []types.NamespacedName{{Namespace: "test", Name: "api"}, {Namespace: "test", Name: "web"}},
+		expectedAnnotations: map[string]string{"team": "web", SourceIngressAnnotation: "test/api,test/web"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Annotations: tc.annotations}
+			addSourceIngresses(obj, tc.ingresses...)
+			if diff := cmp.Diff(tc.expectedAnnotations, obj.Annotations); diff != "" {
+				t.Errorf("Unexpected annotations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_setSourceIngresses(t *testing.T) {
+	shared := map[string]string{"team": "web", SourceIngressAnnotation: "test/api,test/web"}
+	obj := &metav1.ObjectMeta{Annotations: shared}
+	setSourceIngresses(obj, types.NamespacedName{Namespace: "test", Name: "web"})
+	if diff := cmp.Diff(map[string]string{"team": "web", SourceIngressAnnotation: "test/web"}, obj.Annotations); diff != "" {
+		t.Errorf("Unexpected annotations (-want +got):\n%s", diff)
+	}
+	// The annotations shared with other resources are left unchanged.
+	if shared[SourceIngressAnnotation] != "test/api,test/web" {
+		t.Errorf("Expected the shared annotations to be unchanged, got %v", shared)
+	}
+
+	unannotated := &metav1.ObjectMeta{}
+	setSourceIngresses(unannotated, types.NamespacedName{Namespace: "test", Name: "web"})
+	if unannotated.Annotations != nil {
+		t.Errorf("Expected no annotations, got %v", unannotated.Annotations)
+	}
+}
+
+func Test_annotateSourceIngresses(t *testing.T) {
+	ingresses := []networkingv1.Ingress{newNginxIngress("web", "/", nil), newNginxIngress("api", "/api", nil)}
+
+	testCases := []struct {
+		name            string
+		opts            Options
+		expectedRoutes  map[string]string
+		expectedGateway string
+	}{{
+		name:           "not annotated",
+		expectedRoutes: map[string]string{"example-com": ""},
+	}, {
+		name:            "HTTPRoute of a host",
+		opts:            Options{AnnotateSourceIngresses: true},
+		expectedRoutes:  map[string]string{"example-com": "test/api,test/web"},
+		expectedGateway: "test/api,test/web",
+	}, {
+		name:            "HTTPRoutes of the Ingresses",
+		opts:            Options{AnnotateSourceIngresses: true, RouteGranularity: RouteGranularityIngress},
+		expectedRoutes:  map[string]string{"example-com-web": "test/web", "example-com-api": "test/api"},
+		expectedGateway: "test/api,test/web",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(ingresses, tc.opts)
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected errors: %+v", errs)
+			}
+			routes := map[string]string{}
+			for _, route := range result.HTTPRoutes {
+				routes[route.Name] = route.Annotations[SourceIngressAnnotation]
+			}
+			if diff := cmp.Diff(tc.expectedRoutes, routes); diff != "" {
+				t.Errorf("Unexpected HTTPRoute sources (-want +got):\n%s", diff)
+			}
+			if len(result.Gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d", len(result.Gateways))
+			}
+			if got := result.Gateways[0].Annotations[SourceIngressAnnotation]; got != tc.expectedGateway {
+				t.Errorf("Expected the Gateway sources %q, got %q", tc.expectedGateway, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// sslPassthroughAnnotation passes the TLS connections of the hosts of the
+// Ingress through to its backend, without terminating them.
+const sslPassthroughAnnotation = "nginx.ingress.kubernetes.io/ssl-passthrough"
+
+var tlsRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha2",
+	Kind:    "TLSRoute",
+}
+
+// setPassthrough marks the rule groups whose Ingresses all pass TLS through,
+// which are converted to TLSRoutes instead of HTTPRoutes. Rule groups without
+// host can't be matched by SNI, and rule groups whose Ingresses don't all pass
+// TLS through can't be both terminated and passed through, so they are
+// converted to HTTPRoutes with a warning.
+func (a *ingressAggregator) setPassthrough(rgKeys []string) []Warning {
+	var warnings []Warning
+	for _, rgKey := range rgKeys {
+		rg := a.ruleGroups[ruleGroupKey(rgKey)]
+		value, same := rg.commonValue(func(e *extra) string {
+			if e != nil && e.sslPassthrough {
+				return "true"
+			}
+			return ""
+		})
+		switch {
+		case !same:
+			warnings = append(warnings, rg.differentValuesWarning(sslPassthroughAnnotation,
+				fmt.Sprintf("the Ingresses of host %q don't all pass TLS through, which a single listener can't represent; TLS is terminated for all of them", rg.host)))
+		case value == "":
+		case rg.host == "":
+			warnings = append(warnings, rg.differentValuesWarning(sslPassthroughAnnotation,
+				"TLS passthrough matches the SNI of the connections, so it requires a host; TLS is terminated for the rules without host"))
+		default:
+			rg.passthrough = true
+		}
+	}
+	return warnings
+}
+
+// toTLSRoutes converts the rule groups passing TLS through to TLSRoutes
+// matching the SNI of their host, attached to a Passthrough TLS listener of
+// the host, which is added to the Gateways. As ingress-nginx, the connections
+// are passed through to the backend of the root path of the host, or of its
+// first path when it has no root path.
+func (a *ingressAggregator) toTLSRoutes(gateways []gatewayv1beta1.Gateway) ([]gatewayv1alpha2.TLSRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	var tlsRoutes []gatewayv1alpha2.TLSRoute
+	var warnings []Warning
+	var errors field.ErrorList
+
+	rgKeys := make([]string, 0, len(a.ruleGroups))
+	for rgKey, rg := range a.ruleGroups {
+		if rg.passthrough {
+			rgKeys = append(rgKeys, string(rgKey))
+		}
+	}
+	sort.Strings(rgKeys)
+
+	for _, rgKey := range rgKeys {
+		rg := a.ruleGroups[ruleGroupKey(rgKey)]
+		ir, path, pathIdx, ok := rg.passthroughPath()
+		if !ok {
+			warnings = append(warnings, Warning{
+				Ingress: rg.rules[0].ingress,
+				Field:   field.NewPath(rg.rules[0].ingress.Name, "spec", "rules"),
+				Message: fmt.Sprintf("no backend was found for host %q, whose TLS connections are passed through; no TLSRoute is generated", rg.host),
+			})
+			continue
+		}
+		fieldPath := field.NewPath(ir.ingress.Name, "spec", "rules").Child("http", "paths").Index(pathIdx).Child("backend")
+		backendRef, warning, err := toBackendRef(path.Backend, ir.ingress, rg.services, ir.extra.serviceBackendKind(), fieldPath)
+		if warning != nil {
+			warnings = append(warnings, *warning)
+		}
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if rg.numPaths() > 1 {
+			warnings = append(warnings, Warning{
+				Ingress: ir.ingress,
+				Field:   field.NewPath(ir.ingress.Name, "metadata", "annotations").Key(sslPassthroughAnnotation),
+				Message: fmt.Sprintf("the TLS connections of host %q are passed through to the backend of path %q, the other paths can't be routed without terminating TLS and are ignored", rg.host, path.Path),
+			})
+		}
+
+		gwIdx := -1
+		for i := range gateways {
+			if gateways[i].Namespace == rg.namespace && gateways[i].Name == rg.ingressClass {
+				gwIdx = i
+				break
+			}
+		}
+		if gwIdx == -1 {
+			gateway := gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: rg.namespace,
+					Name:      rg.ingressClass,
+				},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: gatewayv1beta1.ObjectName(rg.ingressClass),
+				},
+			}
+			gateway.SetGroupVersionKind(gatewayGVK)
+			gateways = append(gateways, gateway)
+			gwIdx = len(gateways) - 1
+		}
+
+		hostname := gatewayv1beta1.Hostname(rg.host)
+		listenerName := gatewayv1beta1.SectionName(listenerNamePrefix(&hostname) + "tls-passthrough")
+		passthrough := gatewayv1.TLSModePassthrough
+		gateways[gwIdx].Spec.Listeners = append(gateways[gwIdx].Spec.Listeners, gatewayv1beta1.Listener{
+			Name:     listenerName,
+			Hostname: &hostname,
+			Port:     443,
+			Protocol: gatewayv1.TLSProtocolType,
+			TLS:      &gatewayv1beta1.GatewayTLSConfig{Mode: &passthrough},
+		})
+
+		tlsRoute := gatewayv1alpha2.TLSRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        rg.routeName(),
+				Namespace:   rg.namespace,
+				Annotations: rg.annotations,
+			},
+			Spec: gatewayv1alpha2.TLSRouteSpec{
+				CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayv1alpha2.ParentReference{{
+						Name:        gatewayv1alpha2.ObjectName(rg.ingressClass),
+						SectionName: &listenerName,
+					}},
+				},
+				Hostnames: []gatewayv1alpha2.Hostname{hostname},
+				Rules: []gatewayv1alpha2.TLSRouteRule{{
+					BackendRefs: []gatewayv1alpha2.BackendRef{*backendRef},
+				}},
+			},
+			Status: gatewayv1alpha2.TLSRouteStatus{
+				RouteStatus: gatewayv1alpha2.RouteStatus{
+					Parents: []gatewayv1alpha2.RouteParentStatus{},
+				},
+			},
+		}
+		tlsRoute.SetGroupVersionKind(tlsRouteGVK)
+		a.annotateSources(&tlsRoute, rg.ingresses()...)
+		tlsRoutes = append(tlsRoutes, tlsRoute)
+	}
+
+	return tlsRoutes, gateways, warnings, errors
+}
+
+// passthroughPath returns the path of the rule group whose backend the TLS
+// connections are passed through to: the root path, or the first path when
+// there is no root path. It returns false when the rule group has no paths.
+func (rg *ingressRuleGroup) passthroughPath() (ingressRule, networkingv1.HTTPIngressPath, int, bool) {
+	var first *ingressRule
+	var firstPath networkingv1.HTTPIngressPath
+	var firstIdx int
+	for _, ir := range rg.rules {
+		if ir.rule.HTTP == nil {
+			continue
+		}
+		for j, path := range ir.rule.HTTP.Paths {
+			if path.Path == "/" || path.Path == "" {
+				return ir, path, j, true
+			}
+			if first == nil {
+				ir := ir
+				first, firstPath, firstIdx = &ir, path, j
+			}
+		}
+	}
+	if first == nil {
+		return ingressRule{}, networkingv1.HTTPIngressPath{}, 0, false
+	}
+	return *first, firstPath, firstIdx, true
+}
+
+// numPaths returns the number of paths of the rules of the rule group.
+func (rg *ingressRuleGroup) numPaths() int {
+	n := 0
+	for _, ir := range rg.rules {
+		if ir.rule.HTTP != nil {
+			n += len(ir.rule.HTTP.Paths)
+		}
+	}
+	return n
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_ingresses2TLSRoutes(t *testing.T) {
+	newIngress := func(name, host string, passthrough bool, paths ...networkingv1.HTTPIngressPath) networkingv1.Ingress {
+		ingress := networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+					},
+				}},
+			},
+		}
+		if passthrough {
+			ingress.Annotations = map[string]string{sslPassthroughAnnotation: "true"}
+		}
+		return ingress
+	}
+	prefix := networkingv1.PathTypePrefix
+	newPath := func(path, service string) networkingv1.HTTPIngressPath {
+		return networkingv1.HTTPIngressPath{
+			Path:     path,
+			PathType: &prefix,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: service,
+					Port: networkingv1.ServiceBackendPort{Number: 443},
+				},
+			},
+		}
+	}
+	passthrough := gatewayv1.TLSModePassthrough
+	passthroughListener := gatewayv1beta1.Listener{
+		Name:     "foo-example-com-tls-passthrough",
+		Hostname: gatewayHostnamePtr("foo.example.com"),
+		Port:     443,
+		Protocol: gatewayv1.TLSProtocolType,
+		TLS:      &gatewayv1beta1.GatewayTLSConfig{Mode: &passthrough},
+	}
+	newTLSRoute := func(service string) gatewayv1alpha2.TLSRoute {
+		return gatewayv1alpha2.TLSRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-example-com", Namespace: "test"},
+			Spec: gatewayv1alpha2.TLSRouteSpec{
+				CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayv1alpha2.ParentReference{{
+						Name:        "nginx",
+						SectionName: sectionNamePtr("foo-example-com-tls-passthrough"),
+					}},
+				},
+				Hostnames: []gatewayv1alpha2.Hostname{"foo.example.com"},
+				Rules: []gatewayv1alpha2.TLSRouteRule{{
+					BackendRefs: []gatewayv1alpha2.BackendRef{{
+						BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+							Name: gatewayv1alpha2.ObjectName(service),
+							Port: (*gatewayv1alpha2.PortNumber)(portNumberPtr(443)),
+						},
+					}},
+				}},
+			},
+			Status: gatewayv1alpha2.TLSRouteStatus{
+				RouteStatus: gatewayv1alpha2.RouteStatus{
+					Parents: []gatewayv1alpha2.RouteParentStatus{},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		ingresses         []networkingv1.Ingress
+		expectListeners   []gatewayv1beta1.Listener
+		expectTLSRoutes   []gatewayv1alpha2.TLSRoute
+		expectHTTPRoutes  int
+		expectNumWarnings int
+	}{{
+		name:            "ssl passthrough",
+		ingresses:       []networkingv1.Ingress{newIngress("foo", "foo.example.com", true, newPath("/", "foo"))},
+		expectListeners: []gatewayv1beta1.Listener{passthroughListener},
+		expectTLSRoutes: []gatewayv1alpha2.TLSRoute{newTLSRoute("foo")},
+	}, {
+		name:              "ssl passthrough to the root path",
+		ingresses:         []networkingv1.Ingress{newIngress("foo", "foo.example.com", true, newPath("/api", "api"), newPath("/", "foo"))},
+		expectListeners:   []gatewayv1beta1.Listener{passthroughListener},
+		expectTLSRoutes:   []gatewayv1alpha2.TLSRoute{newTLSRoute("foo")},
+		expectNumWarnings: 1,
+	}, {
+		name:            "ssl passthrough without root path",
+		ingresses:       []networkingv1.Ingress{newIngress("foo", "foo.example.com", true, newPath("/api", "api"))},
+		expectListeners: []gatewayv1beta1.Listener{passthroughListener},
+		expectTLSRoutes: []gatewayv1alpha2.TLSRoute{newTLSRoute("api")},
+	}, {
+		name:              "ssl passthrough without host",
+		ingresses:         []networkingv1.Ingress{newIngress("foo", "", true, newPath("/", "foo"))},
+		expectListeners:   []gatewayv1beta1.Listener{{Name: "http", Port: 80, Protocol: gatewayv1.HTTPProtocolType}},
+		expectHTTPRoutes:  1,
+		expectNumWarnings: 1,
+	}, {
+		name: "ssl passthrough of some Ingresses of a host",
+		ingresses: []networkingv1.Ingress{
+			newIngress("foo", "foo.example.com", true, newPath("/", "foo")),
+			newIngress("bar", "foo.example.com", false, newPath("/bar", "bar")),
+		},
+		expectListeners:   []gatewayv1beta1.Listener{{Name: "foo-example-com-http", Hostname: gatewayHostnamePtr("foo.example.com"), Port: 80, Protocol: gatewayv1.HTTPProtocolType}},
+		expectHTTPRoutes:  1,
+		expectNumWarnings: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, errs := convertIngresses(tc.ingresses, Options{})
+			if len(errs) > 0 {
+				t.Fatalf("Expected no errors, got %+v", errs)
+			}
+			if len(result.Warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(result.Warnings), result.Warnings)
+			}
+			if len(result.HTTPRoutes) != tc.expectHTTPRoutes {
+				t.Errorf("Expected %d HTTPRoutes, got %d: %+v", tc.expectHTTPRoutes, len(result.HTTPRoutes), result.HTTPRoutes)
+			}
+
+			if len(result.Gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d: %+v", len(result.Gateways), result.Gateways)
+			}
+			if diff := cmp.Diff(tc.expectListeners, result.Gateways[0].Spec.Listeners); diff != "" {
+				t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+			}
+
+			if len(result.TLSRoutes) != len(tc.expectTLSRoutes) {
+				t.Fatalf("Expected %d TLSRoutes, got %d: %+v", len(tc.expectTLSRoutes), len(result.TLSRoutes), result.TLSRoutes)
+			}
+			for i, got := range result.TLSRoutes {
+				want := tc.expectTLSRoutes[i]
+				want.SetGroupVersionKind(tlsRouteGVK)
+				if !apiequality.Semantic.DeepEqual(got, want) {
+					t.Errorf("Expected TLSRoute %d to be %+v\n Got: %+v\n Diff: %s", i, want, got, cmp.Diff(want, got))
+				}
+			}
+		})
+	}
+}
+
+func Test_passthroughAndTerminatedHosts(t *testing.T) {
+	prefix := networkingv1.PathTypePrefix
+	newIngress := func(name, host string, annotations map[string]string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: name}},
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &prefix,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 443}},
+							},
+						}}},
+					},
+				}},
+			},
+		}
+	}
+	ingresses := []networkingv1.Ingress{
+		newIngress("passthrough", "passthrough.example.com", map[string]string{sslPassthroughAnnotation: "true"}),
+		newIngress("terminated", "terminated.example.com", nil),
+	}
+
+	testCases := []struct {
+		name        string
+		opts        Options
+		expectPorts map[gatewayv1.SectionName]gatewayv1.PortNumber
+	}{{
+		name: "Gateway of the Ingress class",
+		expectPorts: map[gatewayv1.SectionName]gatewayv1.PortNumber{
+			"passthrough-example-com-tls-passthrough": 443,
+			"terminated-example-com-http":             80,
+			"terminated-example-com-https":            443,
+		},
+	}, {
+		name: "single Gateway per namespace",
+		opts: Options{SingleGatewayPerNamespace: true},
+		expectPorts: map[gatewayv1.SectionName]gatewayv1.PortNumber{
+			"passthrough-example-com-tls-passthrough": 443,
+			"terminated-example-com-http":             80,
+			"terminated-example-com-https":            443,
+		},
+	}, {
+		name: "TLS listener port",
+		opts: Options{HTTPSListenerPort: 8443},
+		expectPorts: map[gatewayv1.SectionName]gatewayv1.PortNumber{
+			"passthrough-example-com-tls-passthrough": 8443,
+			"terminated-example-com-http":             80,
+			"terminated-example-com-https":            8443,
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(ingresses, tc.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			// As with --dry-run=client.
+			if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+				t.Fatalf("Expected valid resources, got %v", err)
+			}
+			if len(result.Gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d: %+v", len(result.Gateways), result.Gateways)
+			}
+			ports := map[gatewayv1.SectionName]gatewayv1.PortNumber{}
+			for _, listener := range result.Gateways[0].Spec.Listeners {
+				ports[listener.Name] = listener.Port
+			}
+			if diff := cmp.Diff(tc.expectPorts, ports); diff != "" {
+				t.Errorf("Unexpected listener ports (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
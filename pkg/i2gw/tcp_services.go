@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// tcpServicesAnnotation references the ingress-nginx TCP services ConfigMap,
+// as either <namespace>/<name> or <name> in the namespace of the Ingress.
+// Every entry of the ConfigMap maps an exposed port to a backend, with the
+// format <port>: <namespace>/<service>:<service port>[:PROXY][:PROXY].
+const tcpServicesAnnotation = "nginx.ingress.kubernetes.io/tcp-services"
+
+var tcpRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha2",
+	Kind:    "TCPRoute",
+}
+
+// tcpServices is a TCP services ConfigMap referenced from the Ingresses of a
+// Gateway.
+type tcpServices struct {
+	namespace    string
+	ingressClass string
+	configMap    types.NamespacedName
+	// ingress is the first Ingress referencing the ConfigMap.
+	ingress types.NamespacedName
+}
+
+// TCPServicesConfigMapRef returns the TCP services ConfigMap referenced by the
+// Ingress, if any.
+func TCPServicesConfigMapRef(ingress networkingv1.Ingress) (types.NamespacedName, bool) {
+	ref, ok := ingress.Annotations[tcpServicesAnnotation]
+	if !ok || ref == "" {
+		return types.NamespacedName{}, false
+	}
+	if parts := strings.SplitN(ref, "/", 2); len(parts) == 2 {
+		return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+	}
+	return types.NamespacedName{Namespace: ingress.Namespace, Name: ref}, true
+}
+
+func (a *ingressAggregator) addTCPServices(ingress networkingv1.Ingress, ingressClass string) {
+	cmRef, ok := TCPServicesConfigMapRef(ingress)
+	if !ok {
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%s", ingress.Namespace, ingressClass, cmRef)
+	if _, ok := a.tcpServices[key]; ok {
+		return
+	}
+	a.tcpServices[key] = tcpServices{
+		namespace:    ingress.Namespace,
+		ingressClass: ingressClass,
+		configMap:    cmRef,
+		ingress:      types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+	}
+}
+
+// toTCPRoutes generates a TCPRoute for every entry of the referenced TCP
+// services ConfigMaps, and adds the matching TCP listeners to the given
+// Gateways. Gateways are created when the Ingresses had no HTTP rules.
+func (a *ingressAggregator) toTCPRoutes(gateways []gatewayv1beta1.Gateway) ([]gatewayv1alpha2.TCPRoute, []gatewayv1beta1.Gateway, []Warning, field.ErrorList) {
+	var tcpRoutes []gatewayv1alpha2.TCPRoute
+	var warnings []Warning
+	var errors field.ErrorList
+
+	keys := make([]string, 0, len(a.tcpServices))
+	for key := range a.tcpServices {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ts := a.tcpServices[key]
+		fieldPath := field.NewPath(ts.ingress.Name).Child("metadata").Child("annotations").Key(tcpServicesAnnotation)
+		cm, ok := a.configMaps[ts.configMap]
+		if !ok {
+			errors = append(errors, field.NotFound(fieldPath, ts.configMap.String()))
+			continue
+		}
+
+		gwIdx := -1
+		for i := range gateways {
+			if gateways[i].Namespace == ts.namespace && gateways[i].Name == ts.ingressClass {
+				gwIdx = i
+				break
+			}
+		}
+		if gwIdx == -1 {
+			gateway := gatewayv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ts.namespace,
+					Name:      ts.ingressClass,
+				},
+				Spec: gatewayv1beta1.GatewaySpec{
+					GatewayClassName: gatewayv1beta1.ObjectName(ts.ingressClass),
+				},
+			}
+			gateway.SetGroupVersionKind(gatewayGVK)
+			gateways = append(gateways, gateway)
+			gwIdx = len(gateways) - 1
+		}
+
+		ports := make([]string, 0, len(cm.Data))
+		for port := range cm.Data {
+			ports = append(ports, port)
+		}
+		sort.Strings(ports)
+
+		for _, port := range ports {
+			entryPath := field.NewPath(cm.Name, "data").Key(port)
+			listenerPort, err := strconv.ParseInt(port, 10, 32)
+			if err != nil {
+				errors = append(errors, field.Invalid(entryPath, port, "port must be a number"))
+				continue
+			}
+			if listenerPort < 1 || listenerPort > 65535 {
+				errors = append(errors, field.Invalid(entryPath, port, "port must be between 1 and 65535"))
+				continue
+			}
+			backend, proxyProtocol, err := parseTCPServiceBackend(cm.Data[port])
+			if err != nil {
+				errors = append(errors, field.Invalid(entryPath, cm.Data[port], err.Error()))
+				continue
+			}
+			if string(*backend.Namespace) == ts.namespace {
+				backend.Namespace = nil
+			}
+
+			listenerName := gatewayv1beta1.SectionName(fmt.Sprintf("tcp-%s", port))
+			if err := listenerConflict(gateways[gwIdx], listenerName, gatewayv1beta1.PortNumber(listenerPort), entryPath); err != nil {
+				errors = append(errors, err)
+				continue
+			}
+			gateways[gwIdx].Spec.Listeners = append(gateways[gwIdx].Spec.Listeners, gatewayv1beta1.Listener{
+				Name:     listenerName,
+				Port:     gatewayv1beta1.PortNumber(listenerPort),
+				Protocol: gatewayv1.TCPProtocolType,
+			})
+
+			tcpRoute := gatewayv1alpha2.TCPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%s", cm.Name, port),
+					Namespace: ts.namespace,
+				},
+				Spec: gatewayv1alpha2.TCPRouteSpec{
+					CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+						ParentRefs: []gatewayv1alpha2.ParentReference{{
+							Name:        gatewayv1alpha2.ObjectName(ts.ingressClass),
+							SectionName: (*gatewayv1alpha2.SectionName)(&listenerName),
+						}},
+					},
+					Rules: []gatewayv1alpha2.TCPRouteRule{{
+						BackendRefs: []gatewayv1alpha2.BackendRef{{BackendObjectReference: backend}},
+					}},
+				},
+				Status: gatewayv1alpha2.TCPRouteStatus{
+					RouteStatus: gatewayv1alpha2.RouteStatus{
+						Parents: []gatewayv1alpha2.RouteParentStatus{},
+					},
+				},
+			}
+			tcpRoute.SetGroupVersionKind(tcpRouteGVK)
+			a.annotateSources(&tcpRoute, ts.ingress)
+			tcpRoutes = append(tcpRoutes, tcpRoute)
+
+			if backend.Namespace != nil {
+				warnings = append(warnings, Warning{
+					Ingress: ts.ingress,
+					Field:   entryPath,
+					Message: fmt.Sprintf("TCPRoute %s/%s references a Service in namespace %s, which requires a ReferenceGrant", tcpRoute.Namespace, tcpRoute.Name, *backend.Namespace),
+				})
+			}
+			if proxyProtocol {
+				warnings = append(warnings, Warning{
+					Ingress: ts.ingress,
+					Field:   entryPath,
+					Message: "PROXY protocol cannot be represented in TCPRoute and must be configured on the Gateway implementation",
+				})
+			}
+		}
+	}
+
+	return tcpRoutes, gateways, warnings, errors
+}
+
+// listenerConflict returns an error for the TCP services ConfigMap entry at
+// entryPath when the Gateway already has a listener of the same name, such as
+// the listener of the same port of another ConfigMap, or a listener of the
+// same port, such as the listener of a port written with leading zeros.
+func listenerConflict(gateway gatewayv1beta1.Gateway, name gatewayv1beta1.SectionName, port gatewayv1beta1.PortNumber, entryPath *field.Path) *field.Error {
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Name == name {
+			return field.Duplicate(entryPath, string(name))
+		}
+		if listener.Port == port {
+			return field.Invalid(entryPath, port, fmt.Sprintf("port is already used by listener %s of Gateway %s/%s", listener.Name, gateway.Namespace, gateway.Name))
+		}
+	}
+	return nil
+}
+
+// parseTCPServiceBackend parses a TCP services ConfigMap entry of the form
+// <namespace>/<service>:<service port>[:PROXY][:PROXY], and reports whether
+// PROXY protocol was requested.
+func parseTCPServiceBackend(value string) (gatewayv1alpha2.BackendObjectReference, bool, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 {
+		return gatewayv1alpha2.BackendObjectReference{}, false, fmt.Errorf("expected <namespace>/<service>:<port>")
+	}
+	svc := strings.SplitN(parts[0], "/", 2)
+	if len(svc) != 2 || svc[0] == "" || svc[1] == "" {
+		return gatewayv1alpha2.BackendObjectReference{}, false, fmt.Errorf("expected <namespace>/<service>, got %q", parts[0])
+	}
+	port, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return gatewayv1alpha2.BackendObjectReference{}, false, fmt.Errorf("service port must be a number, got %q", parts[1])
+	}
+	if port < 1 || port > 65535 {
+		return gatewayv1alpha2.BackendObjectReference{}, false, fmt.Errorf("service port must be between 1 and 65535, got %d", port)
+	}
+
+	var proxyProtocol bool
+	for _, opt := range parts[2:] {
+		if opt == "PROXY" {
+			proxyProtocol = true
+		}
+	}
+
+	portNumber := gatewayv1alpha2.PortNumber(port)
+	namespace := gatewayv1alpha2.Namespace(svc[0])
+	return gatewayv1alpha2.BackendObjectReference{
+		Name:      gatewayv1alpha2.ObjectName(svc[1]),
+		Namespace: &namespace,
+		Port:      &portNumber,
+	}, proxyProtocol, nil
+}
+
+// configMapsByName indexes ConfigMaps by their namespace/name.
+func configMapsByName(configMaps []corev1.ConfigMap) map[types.NamespacedName]corev1.ConfigMap {
+	byName := make(map[types.NamespacedName]corev1.ConfigMap, len(configMaps))
+	for _, cm := range configMaps {
+		byName[types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}] = cm
+	}
+	return byName
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_ingresses2TCPRoutes(t *testing.T) {
+	tcpServices := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "tcp-services", Namespace: "ingress-nginx"},
+		Data: map[string]string{
+			"9000": "test/example-go:8080",
+			"5432": "database/postgres:5432::PROXY",
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		annotation        string
+		configMaps        []corev1.ConfigMap
+		expectListeners   []gatewayv1beta1.Listener
+		expectTCPRoutes   []gatewayv1alpha2.TCPRoute
+		expectNumWarnings int
+		expectNumErrors   int
+	}{{
+		name:       "tcp services configmap",
+		annotation: "ingress-nginx/tcp-services",
+		configMaps: []corev1.ConfigMap{tcpServices},
+		expectListeners: []gatewayv1beta1.Listener{{
+			Name:     "tcp-5432",
+			Port:     5432,
+			Protocol: gatewayv1.TCPProtocolType,
+		}, {
+			Name:     "tcp-9000",
+			Port:     9000,
+			Protocol: gatewayv1.TCPProtocolType,
+		}},
+		expectTCPRoutes: []gatewayv1alpha2.TCPRoute{{
+			ObjectMeta: metav1.ObjectMeta{Name: "tcp-services-5432", Namespace: "test"},
+			Spec: gatewayv1alpha2.TCPRouteSpec{
+				CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayv1alpha2.ParentReference{{
+						Name:        "example",
+						SectionName: sectionNamePtr("tcp-5432"),
+					}},
+				},
+				Rules: []gatewayv1alpha2.TCPRouteRule{{
+					BackendRefs: []gatewayv1alpha2.BackendRef{{
+						BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+							Name:      "postgres",
+							Namespace: namespacePtr("database"),
+							Port:      (*gatewayv1alpha2.PortNumber)(portNumberPtr(5432)),
+						},
+					}},
+				}},
+			},
+		}, {
+			ObjectMeta: metav1.ObjectMeta{Name: "tcp-services-9000", Namespace: "test"},
+			Spec: gatewayv1alpha2.TCPRouteSpec{
+				CommonRouteSpec: gatewayv1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayv1alpha2.ParentReference{{
+						Name:        "example",
+						SectionName: sectionNamePtr("tcp-9000"),
+					}},
+				},
+				Rules: []gatewayv1alpha2.TCPRouteRule{{
+					BackendRefs: []gatewayv1alpha2.BackendRef{{
+						BackendObjectReference: gatewayv1alpha2.BackendObjectReference{
+							Name: "example-go",
+							Port: (*gatewayv1alpha2.PortNumber)(portNumberPtr(8080)),
+						},
+					}},
+				}},
+			},
+		}},
+		// Cross namespace backend and PROXY protocol on port 5432.
+		expectNumWarnings: 2,
+	}, {
+		name:            "missing tcp services configmap",
+		annotation:      "ingress-nginx/tcp-services",
+		expectNumErrors: 1,
+	}, {
+		name:       "ports out of range",
+		annotation: "ingress-nginx/tcp-services",
+		configMaps: []corev1.ConfigMap{{
+			ObjectMeta: metav1.ObjectMeta{Name: "tcp-services", Namespace: "ingress-nginx"},
+			Data: map[string]string{
+				"0":     "test/example-go:8080",
+				"70000": "test/example-go:8080",
+				"9000":  "test/example-go:70000",
+			},
+		}},
+		expectNumErrors: 3,
+	}, {
+		name:       "port written twice",
+		annotation: "ingress-nginx/tcp-services",
+		configMaps: []corev1.ConfigMap{{
+			ObjectMeta: metav1.ObjectMeta{Name: "tcp-services", Namespace: "ingress-nginx"},
+			Data: map[string]string{
+				"9000":  "test/example-go:8080",
+				"09000": "test/example-go:8080",
+			},
+		}},
+		expectNumErrors: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "example",
+					Namespace:   "test",
+					Annotations: map[string]string{tcpServicesAnnotation: tc.annotation},
+				},
+			}
+
+			_, _, tcpRoutes, gateways, warnings, errs := Ingresses2GatewaysAndHTTPRoutes([]networkingv1.Ingress{ingress}, tc.configMaps, nil, nil, false)
+			if len(errs) != tc.expectNumErrors {
+				t.Fatalf("Expected %d errors, got %d: %+v", tc.expectNumErrors, len(errs), errs)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+			if tc.expectNumErrors > 0 {
+				return
+			}
+
+			if len(gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+			}
+			if diff := cmp.Diff(tc.expectListeners, gateways[0].Spec.Listeners); diff != "" {
+				t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+			}
+
+			if len(tcpRoutes) != len(tc.expectTCPRoutes) {
+				t.Fatalf("Expected %d TCPRoutes, got %d: %+v", len(tc.expectTCPRoutes), len(tcpRoutes), tcpRoutes)
+			}
+			for i, got := range tcpRoutes {
+				want := tc.expectTCPRoutes[i]
+				want.SetGroupVersionKind(tcpRouteGVK)
+				if !apiequality.Semantic.DeepEqual(got, want) {
+					t.Errorf("Expected TCPRoute %d to be %+v\n Got: %+v\n Diff: %s", i, want, got, cmp.Diff(want, got))
+				}
+			}
+		})
+	}
+}
+
+func Test_ingresses2TCPRoutesDuplicateListeners(t *testing.T) {
+	newConfigMap := func(name string) corev1.ConfigMap {
+		return corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Data:       map[string]string{"9000": "test/" + name + ":8080"},
+		}
+	}
+	newIngress := func(name, configMap string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "test",
+				Annotations: map[string]string{tcpServicesAnnotation: configMap},
+			},
+			Spec: networkingv1.IngressSpec{IngressClassName: stringPtr("nginx")},
+		}
+	}
+	ingresses := []networkingv1.Ingress{newIngress("api", "tcp-api"), newIngress("web", "tcp-web")}
+	configMaps := []corev1.ConfigMap{newConfigMap("tcp-api"), newConfigMap("tcp-web")}
+
+	_, _, tcpRoutes, _, _, errs := Ingresses2GatewaysAndHTTPRoutes(ingresses, configMaps, nil, nil, false)
+	expected := field.ErrorList{field.Duplicate(field.NewPath("tcp-web", "data").Key("9000"), "tcp-9000")}
+	if diff := cmp.Diff(expected, errs); diff != "" {
+		t.Errorf("Unexpected errors (-want +got):\n%s", diff)
+	}
+	if len(tcpRoutes) != 1 || tcpRoutes[0].Name != "tcp-api-9000" {
+		t.Errorf("Expected only TCPRoute tcp-api-9000, got %+v", tcpRoutes)
+	}
+}
+
+func Test_parseTCPServiceBackend(t *testing.T) {
+	testCases := []struct {
+		value          string
+		expectedProxy  bool
+		expectingError bool
+	}{
+		{value: "default/example-go:8080"},
+		{value: "default/example-go:8080:PROXY", expectedProxy: true},
+		{value: "default/example-go:8080::PROXY", expectedProxy: true},
+		{value: "example-go:8080", expectingError: true},
+		{value: "default/example-go", expectingError: true},
+		{value: "default/example-go:http", expectingError: true},
+		{value: "default/example-go:0", expectingError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			_, proxy, err := parseTCPServiceBackend(tc.value)
+			if tc.expectingError != (err != nil) {
+				t.Fatalf("parseTCPServiceBackend(%q) error = %v, expecting error: %v", tc.value, err, tc.expectingError)
+			}
+			if proxy != tc.expectedProxy {
+				t.Errorf("parseTCPServiceBackend(%q) proxy = %v, expected %v", tc.value, proxy, tc.expectedProxy)
+			}
+		})
+	}
+}
+
+func sectionNamePtr(s string) *gatewayv1alpha2.SectionName {
+	sn := gatewayv1alpha2.SectionName(s)
+	return &sn
+}
+
+func namespacePtr(s string) *gatewayv1alpha2.Namespace {
+	ns := gatewayv1alpha2.Namespace(s)
+	return &ns
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const (
+	proxyReadTimeoutAnnotation = "nginx.ingress.kubernetes.io/proxy-read-timeout"
+	proxySendTimeoutAnnotation = "nginx.ingress.kubernetes.io/proxy-send-timeout"
+)
+
+// getProxyTimeouts converts the proxy timeouts of ingress-nginx, in seconds,
+// to the timeouts of HTTPRoute rules. The read timeout, which bounds the wait
+// for the response of the backend to every request sent to it, is the
+// backendRequest timeout. The send timeout bounds the wait between two writes
+// of the request to the backend, which no HTTPRoute timeout represents, so it
+// is reported as a warning and not converted, as are invalid timeouts.
+func getProxyTimeouts(ingress networkingv1.Ingress, fieldPath *field.Path) (*gatewayv1.HTTPRouteTimeouts, []Warning) {
+	var warnings []Warning
+	key := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	if value, ok := ingress.Annotations[proxySendTimeoutAnnotation]; ok {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   fieldPath.Key(proxySendTimeoutAnnotation),
+			Message: fmt.Sprintf("the send timeout %q bounds the wait between two writes of the request to the backend, which HTTPRoute timeouts can't represent; configure it on the Gateway implementation", value),
+		})
+	}
+
+	value, ok := ingress.Annotations[proxyReadTimeoutAnnotation]
+	if !ok {
+		return nil, warnings
+	}
+	d, err := toGatewayDuration(value)
+	if _, atoiErr := strconv.Atoi(value); atoiErr != nil {
+		err = atoiErr
+	}
+	if err != nil {
+		warnings = append(warnings, Warning{
+			Ingress: key,
+			Field:   fieldPath.Key(proxyReadTimeoutAnnotation),
+			Message: fmt.Sprintf("the timeout %q is not converted, it must be a positive number of seconds of at most %d hours", value, maxGatewayDurationHours),
+		})
+		return nil, warnings
+	}
+	return &gatewayv1.HTTPRouteTimeouts{BackendRequest: &d}, warnings
+}
+
+// routeTimeouts returns the timeouts of the rules of the paths, or nil when
+// there are none.
+func (e *extra) routeTimeouts() *gatewayv1.HTTPRouteTimeouts {
+	if e == nil {
+		return nil
+	}
+	return e.timeouts
+}
+
+// timeoutsWarning returns the warning of proxy timeouts converted to routes
+// without timeouts, which only Gateway API v1 routes have.
+func timeoutsWarning(ingress types.NamespacedName, timeouts *gatewayv1.HTTPRouteTimeouts) Warning {
+	var settings []string
+	if timeouts.Request != nil {
+		settings = append(settings, fmt.Sprintf("request=%s", *timeouts.Request))
+	}
+	if timeouts.BackendRequest != nil {
+		settings = append(settings, fmt.Sprintf("backendRequest=%s", *timeouts.BackendRequest))
+	}
+	return Warning{
+		Ingress: ingress,
+		Field:   field.NewPath(ingress.Name, "metadata", "annotations").Key(proxyReadTimeoutAnnotation),
+		Message: fmt.Sprintf("proxy timeouts cannot be represented in HTTPRoute %s, convert to Gateway API v1 to map them to the timeouts of the rules or configure them on the Gateway implementation: %s",
+			httpRouteGVK.Version, strings.Join(settings, ", ")),
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_getProxyTimeouts(t *testing.T) {
+	duration := func(d string) *gatewayv1.Duration {
+		duration := gatewayv1.Duration(d)
+		return &duration
+	}
+
+	testCases := []struct {
+		name              string
+		annotations       map[string]string
+		expectedTimeouts  *gatewayv1.HTTPRouteTimeouts
+		expectNumWarnings int
+	}{{
+		name: "no timeouts",
+	}, {
+		name:             "read timeout",
+		annotations:      map[string]string{proxyReadTimeoutAnnotation: "120"},
+		expectedTimeouts: &gatewayv1.HTTPRouteTimeouts{BackendRequest: duration("2m")},
+	}, {
+		name:              "read and send timeouts",
+		annotations:       map[string]string{proxyReadTimeoutAnnotation: "30", proxySendTimeoutAnnotation: "15"},
+		expectedTimeouts:  &gatewayv1.HTTPRouteTimeouts{BackendRequest: duration("30s")},
+		expectNumWarnings: 1,
+	}, {
+		name:              "send timeout",
+		annotations:       map[string]string{proxySendTimeoutAnnotation: "3600"},
+		expectNumWarnings: 1,
+	}, {
+		name:              "invalid read timeout",
+		annotations:       map[string]string{proxyReadTimeoutAnnotation: "60s"},
+		expectNumWarnings: 1,
+	}, {
+		name:              "invalid timeouts",
+		annotations:       map[string]string{proxyReadTimeoutAnnotation: "0", proxySendTimeoutAnnotation: "-1"},
+		expectNumWarnings: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ingress := networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", Annotations: tc.annotations}}
+			timeouts, warnings := getProxyTimeouts(ingress, field.NewPath("app", "metadata", "annotations"))
+			if diff := cmp.Diff(tc.expectedTimeouts, timeouts); diff != "" {
+				t.Errorf("Unexpected timeouts (-want +got):\n%s", diff)
+			}
+			if len(warnings) != tc.expectNumWarnings {
+				t.Errorf("Expected %d warnings, got %d: %+v", tc.expectNumWarnings, len(warnings), warnings)
+			}
+		})
+	}
+}
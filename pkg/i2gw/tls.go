@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// tlsGroup holds the rule groups of a Gateway served with the same Secrets.
+type tlsGroup struct {
+	gwKey  string
+	refs   []gatewayv1beta1.SecretObjectReference
+	rgKeys []ruleGroupKey
+	hosts  []string
+	// tlsHosts are the hosts of the TLS entries of the rule groups.
+	tlsHosts map[string]bool
+}
+
+// tlsCoversHost returns whether the TLS entry of an Ingress applies to the
+// host of one of its rules. Entries without hosts apply to all the rules.
+func tlsCoversHost(tls networkingv1.IngressTLS, host string) bool {
+	if len(tls.Hosts) == 0 {
+		return true
+	}
+	for _, h := range tls.Hosts {
+		if h == host {
+			return true
+		}
+		if labels := strings.SplitN(host, ".", 2); len(labels) == 2 && h == "*."+labels[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateRefs returns the Secrets of the TLS entries of the rule group,
+// without duplicates, in the order of the Ingresses.
+func (rg *ingressRuleGroup) certificateRefs() []gatewayv1beta1.SecretObjectReference {
+	var refs []gatewayv1beta1.SecretObjectReference
+	seen := map[string]bool{}
+	for _, tls := range rg.tls {
+		if seen[tls.SecretName] {
+			continue
+		}
+		seen[tls.SecretName] = true
+		refs = append(refs, gatewayv1beta1.SecretObjectReference{Name: gatewayv1beta1.ObjectName(tls.SecretName)})
+	}
+	return append(refs, rg.managedCertificateRefs()...)
+}
+
+// certificateRefKey identifies the certificate of a certificateRef, by its
+// name for Secrets and by its kind and name otherwise.
+func certificateRefKey(ref gatewayv1beta1.SecretObjectReference) string {
+	if ref.Kind == nil || *ref.Kind == "Secret" {
+		return string(ref.Name)
+	}
+	return string(*ref.Kind) + "/" + string(ref.Name)
+}
+
+// certificateConflicts reports the Secrets of the TLS entries of the rule
+// group other than its first one, as a listener serving the hosts of several
+// rule groups can't tell which Secret is the certificate of the host.
+func (rg *ingressRuleGroup) certificateConflicts() field.ErrorList {
+	var errs field.ErrorList
+	for i, tls := range rg.tls {
+		first := rg.tls[0]
+		if tls.SecretName == first.SecretName {
+			continue
+		}
+		ingress := rg.tlsIngresses[i]
+		errs = append(errs, field.Invalid(field.NewPath(ingress.Name, "spec", "tls"), tls.SecretName,
+			fmt.Sprintf("host %q is already served with Secret %q of Ingress %s, the certificateRefs of a listener can't serve a host with two Secrets", rg.host, first.SecretName, rg.tlsIngresses[0])))
+	}
+	return errs
+}
+
+// toHTTPSListeners returns the HTTPS listeners of the rule groups with TLS, by
+// rule group key. The rule groups of a Gateway served with the same Secrets
+// share one listener, named after the first Secret, when a TLS entry of the
+// Secrets lists the wildcard of their parent domain, which is the hostname
+// of the listener, so that the listener serves no other hosts than the
+// Secrets. Other rule groups are served by a listener for their host, as are
+// rule groups sharing Secrets when the hostname of the shared listener would
+// be the hostname of another HTTPS listener. When certificateRefs are merged,
+// all the rule groups of a Gateway share one listener with all their Secrets.
+// In both cases, the rule groups with several Secrets are reported as errors.
+func (a *ingressAggregator) toHTTPSListeners(rgKeys []string) (map[ruleGroupKey]*gatewayv1beta1.Listener, field.ErrorList) {
+	var errs field.ErrorList
+	var groups []*tlsGroup
+	groupsByKey := map[string]*tlsGroup{}
+	// groupsByHost holds the TLS group of every host, by Gateway.
+	groupsByHost := map[string]map[string]*tlsGroup{}
+	for _, rgKey := range rgKeys {
+		rg := a.ruleGroups[ruleGroupKey(rgKey)]
+		refs := rg.certificateRefs()
+		if len(refs) == 0 {
+			continue
+		}
+		gwKey := fmt.Sprintf("%s/%s", rg.namespace, rg.ingressClass)
+		names := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			names = append(names, certificateRefKey(ref))
+		}
+		sort.Strings(names)
+		key := gwKey + "/" + strings.Join(names, ",")
+		if conflicts := rg.certificateConflicts(); len(conflicts) > 0 {
+			errs = append(errs, conflicts...)
+			refs = refs[:1]
+		}
+		if a.mergeCertificateRefs {
+			key = gwKey
+		}
+		g := groupsByKey[key]
+		if g == nil {
+			g = &tlsGroup{gwKey: gwKey}
+			groupsByKey[key] = g
+			groups = append(groups, g)
+		}
+		g.addRefs(refs)
+		g.rgKeys = append(g.rgKeys, ruleGroupKey(rgKey))
+		g.hosts = append(g.hosts, rg.host)
+		if g.tlsHosts == nil {
+			g.tlsHosts = map[string]bool{}
+		}
+		for _, tls := range rg.tls {
+			for _, host := range tls.Hosts {
+				g.tlsHosts[host] = true
+			}
+		}
+		if groupsByHost[gwKey] == nil {
+			groupsByHost[gwKey] = map[string]*tlsGroup{}
+		}
+		groupsByHost[gwKey][rg.host] = g
+	}
+
+	listeners := map[ruleGroupKey]*gatewayv1beta1.Listener{}
+	sharedHostnames := map[string]bool{}
+	for _, g := range groups {
+		if len(g.hosts) > 1 {
+			hostname := sharedListenerHostname(g.hosts)
+			var h string
+			if hostname != nil {
+				h = string(*hostname)
+			}
+			// Merged certificateRefs serve all the hosts of the Gateway by
+			// one listener, whatever its hostname.
+			covered := a.mergeCertificateRefs || (hostname != nil && g.tlsHosts[h])
+			owner, ok := groupsByHost[g.gwKey][h]
+			if covered && (!ok || owner == g) && !sharedHostnames[g.gwKey+"/"+h] {
+				sharedHostnames[g.gwKey+"/"+h] = true
+				listener := &gatewayv1beta1.Listener{
+					Name:     gatewayv1beta1.SectionName("https-" + nameFromHost(string(g.refs[0].Name))),
+					Hostname: hostname,
+					Port:     443,
+					Protocol: gatewayv1.HTTPSProtocolType,
+					TLS:      &gatewayv1beta1.GatewayTLSConfig{CertificateRefs: g.refs},
+				}
+				for _, rgKey := range g.rgKeys {
+					listeners[rgKey] = listener
+				}
+				continue
+			}
+		}
+		for i, rgKey := range g.rgKeys {
+			var hostname *gatewayv1beta1.Hostname
+			if g.hosts[i] != "" {
+				host := gatewayv1beta1.Hostname(g.hosts[i])
+				hostname = &host
+			}
+			listeners[rgKey] = &gatewayv1beta1.Listener{
+				Name:     gatewayv1beta1.SectionName(listenerNamePrefix(hostname) + "https"),
+				Hostname: hostname,
+				Port:     443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+				TLS:      &gatewayv1beta1.GatewayTLSConfig{CertificateRefs: g.refs},
+			}
+		}
+	}
+	return listeners, errs
+}
+
+// addRefs adds the Secrets to the ones of the group, without duplicates.
+func (g *tlsGroup) addRefs(refs []gatewayv1beta1.SecretObjectReference) {
+	for _, ref := range refs {
+		found := false
+		for _, r := range g.refs {
+			if certificateRefKey(r) == certificateRefKey(ref) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			g.refs = append(g.refs, ref)
+		}
+	}
+}
+
+// sharedListenerHostname returns the hostname of a listener serving all the
+// hosts: the wildcard of their parent domain when they are all subdomains of
+// the same domain, otherwise no hostname, matching all hosts.
+func sharedListenerHostname(hosts []string) *gatewayv1beta1.Hostname {
+	var parent string
+	for _, host := range hosts {
+		labels := strings.SplitN(host, ".", 2)
+		if host == "" || len(labels) != 2 || (parent != "" && labels[1] != parent) {
+			return nil
+		}
+		parent = labels[1]
+	}
+	hostname := gatewayv1beta1.Hostname("*." + parent)
+	return &hostname
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_sharedTLSListeners(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, host string, tls ...networkingv1.IngressTLS) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				TLS:              tls,
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/" + name,
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: name,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	httpListener := func(host string) gatewayv1beta1.Listener {
+		hostname := gatewayv1beta1.Hostname(host)
+		return gatewayv1beta1.Listener{
+			Name:     gatewayv1beta1.SectionName(nameFromHost(host) + "-http"),
+			Hostname: &hostname,
+			Port:     80,
+			Protocol: gatewayv1.HTTPProtocolType,
+		}
+	}
+	httpsListener := func(name, hostname string, secrets ...string) gatewayv1beta1.Listener {
+		listener := gatewayv1beta1.Listener{
+			Name:     gatewayv1beta1.SectionName(name),
+			Port:     443,
+			Protocol: gatewayv1.HTTPSProtocolType,
+			TLS:      &gatewayv1beta1.GatewayTLSConfig{},
+		}
+		if hostname != "" {
+			listener.Hostname = gatewayHostnamePtr(hostname)
+		}
+		for _, secret := range secrets {
+			listener.TLS.CertificateRefs = append(listener.TLS.CertificateRefs, gatewayv1beta1.SecretObjectReference{Name: gatewayv1beta1.ObjectName(secret)})
+		}
+		return listener
+	}
+
+	testCases := []struct {
+		name              string
+		ingresses         []networkingv1.Ingress
+		expectedListeners []gatewayv1beta1.Listener
+	}{{
+		name: "two Ingresses sharing a Secret",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", networkingv1.IngressTLS{Hosts: []string{"bar.example.com"}, SecretName: "example-cert"}),
+			newIngress("foo", "foo.example.com", networkingv1.IngressTLS{Hosts: []string{"foo.example.com"}, SecretName: "example-cert"}),
+		},
+		expectedListeners: []gatewayv1beta1.Listener{
+			httpListener("bar.example.com"),
+			httpsListener("bar-example-com-https", "bar.example.com", "example-cert"),
+			httpListener("foo.example.com"),
+			httpsListener("foo-example-com-https", "foo.example.com", "example-cert"),
+		},
+	}, {
+		name: "two Ingresses sharing a wildcard Secret",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", networkingv1.IngressTLS{Hosts: []string{"*.example.com"}, SecretName: "example-cert"}),
+			newIngress("foo", "foo.example.com", networkingv1.IngressTLS{Hosts: []string{"*.example.com"}, SecretName: "example-cert"}),
+		},
+		expectedListeners: []gatewayv1beta1.Listener{
+			httpListener("bar.example.com"),
+			httpsListener("https-example-cert", "*.example.com", "example-cert"),
+			httpListener("foo.example.com"),
+		},
+	}, {
+		name: "Secret shared by hosts of different domains",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", networkingv1.IngressTLS{Hosts: []string{"bar.example.com"}, SecretName: "multi-cert"}),
+			newIngress("foo", "foo.example.org", networkingv1.IngressTLS{Hosts: []string{"foo.example.org"}, SecretName: "multi-cert"}),
+		},
+		expectedListeners: []gatewayv1beta1.Listener{
+			httpListener("bar.example.com"),
+			httpsListener("bar-example-com-https", "bar.example.com", "multi-cert"),
+			httpListener("foo.example.org"),
+			httpsListener("foo-example-org-https", "foo.example.org", "multi-cert"),
+		},
+	}, {
+		name: "same host and Secret in two Ingresses",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "foo.example.com", networkingv1.IngressTLS{Hosts: []string{"foo.example.com"}, SecretName: "foo-cert"}),
+			newIngress("foo", "foo.example.com", networkingv1.IngressTLS{Hosts: []string{"foo.example.com"}, SecretName: "foo-cert"}),
+		},
+		expectedListeners: []gatewayv1beta1.Listener{
+			httpListener("foo.example.com"),
+			httpsListener("foo-example-com-https", "foo.example.com", "foo-cert"),
+		},
+	}, {
+		name: "shared hostname taken by another listener",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", networkingv1.IngressTLS{Hosts: []string{"bar.example.com"}, SecretName: "example-cert"}),
+			newIngress("foo", "foo.example.com", networkingv1.IngressTLS{Hosts: []string{"foo.example.com"}, SecretName: "example-cert"}),
+			newIngress("wildcard", "*.example.com", networkingv1.IngressTLS{Hosts: []string{"*.example.com"}, SecretName: "wildcard-cert"}),
+		},
+		expectedListeners: []gatewayv1beta1.Listener{
+			httpListener("*.example.com"),
+			httpsListener("wildcard-example-com-https", "*.example.com", "wildcard-cert"),
+			httpListener("bar.example.com"),
+			httpsListener("bar-example-com-https", "bar.example.com", "example-cert"),
+			httpListener("foo.example.com"),
+			httpsListener("foo-example-com-https", "foo.example.com", "example-cert"),
+		},
+	}, {
+		name: "TLS entries of other hosts",
+		ingresses: []networkingv1.Ingress{
+			newIngress("foo", "foo.example.com",
+				networkingv1.IngressTLS{Hosts: []string{"foo.example.com"}, SecretName: "foo-cert"},
+				networkingv1.IngressTLS{Hosts: []string{"bar.example.com"}, SecretName: "bar-cert"}),
+		},
+		expectedListeners: []gatewayv1beta1.Listener{
+			httpListener("foo.example.com"),
+			httpsListener("foo-example-com-https", "foo.example.com", "foo-cert"),
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, _, gateways, _, errs := Ingresses2GatewaysAndHTTPRoutes(tc.ingresses, nil, nil, nil, false)
+			if len(errs) > 0 {
+				t.Fatalf("Unexpected conversion errors: %+v", errs)
+			}
+			if len(gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d: %+v", len(gateways), gateways)
+			}
+			if diff := cmp.Diff(tc.expectedListeners, gateways[0].Spec.Listeners); diff != "" {
+				t.Errorf("Unexpected Gateway listeners (-want +got):\n%s", diff)
+			}
+			for _, listener := range gateways[0].Spec.Listeners {
+				if listener.Protocol == gatewayv1.HTTPSProtocolType && listener.Hostname == nil {
+					t.Errorf("Unexpected catch-all HTTPS listener %s", listener.Name)
+				}
+			}
+		})
+	}
+}
+
+func Test_tlsCoversHost(t *testing.T) {
+	testCases := []struct {
+		hosts    []string
+		host     string
+		expected bool
+	}{
+		{hosts: nil, host: "foo.example.com", expected: true},
+		{hosts: []string{"foo.example.com"}, host: "foo.example.com", expected: true},
+		{hosts: []string{"bar.example.com"}, host: "foo.example.com", expected: false},
+		{hosts: []string{"*.example.com"}, host: "foo.example.com", expected: true},
+		{hosts: []string{"*.example.com"}, host: "example.com", expected: false},
+		{hosts: []string{"foo.example.com"}, host: "", expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := tlsCoversHost(networkingv1.IngressTLS{Hosts: tc.hosts}, tc.host); actual != tc.expected {
+			t.Errorf("tlsCoversHost(%v, %q) = %t, expected %t", tc.hosts, tc.host, actual, tc.expected)
+		}
+	}
+}
+
+func Test_mergeCertificateRefs(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	newIngress := func(name, host, secret string) networkingv1.Ingress {
+		return networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: stringPtr("nginx"),
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: secret}},
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &iPrefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: name, Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	type listener struct {
+		Name     string
+		Hostname string
+		Secrets  []string
+	}
+
+	testCases := []struct {
+		name      string
+		ingresses []networkingv1.Ingress
+		// unmerged converts the Ingresses without merging the
+		// certificateRefs.
+		unmerged          bool
+		expectedListeners []listener
+		expectedError     string
+	}{{
+		name: "hosts of a domain with different Secrets",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", "bar-cert"),
+			newIngress("foo", "foo.example.com", "foo-cert"),
+			newIngress("baz", "baz.example.com", "bar-cert"),
+		},
+		expectedListeners: []listener{{Name: "https-bar-cert", Hostname: "*.example.com", Secrets: []string{"bar-cert", "foo-cert"}}},
+	}, {
+		name: "hosts of different domains",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", "bar-cert"),
+			newIngress("foo", "foo.example.org", "foo-cert"),
+		},
+		expectedListeners: []listener{{Name: "https-bar-cert", Secrets: []string{"bar-cert", "foo-cert"}}},
+	}, {
+		name: "host with two Secrets",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", "bar-cert"),
+			newIngress("foo", "bar.example.com", "foo-cert"),
+		},
+		expectedError: `host "bar.example.com" is already served with Secret "bar-cert" of Ingress test/bar`,
+	}, {
+		name: "host with two Secrets without merging",
+		ingresses: []networkingv1.Ingress{
+			newIngress("bar", "bar.example.com", "bar-cert"),
+			newIngress("foo", "bar.example.com", "foo-cert"),
+		},
+		unmerged:      true,
+		expectedError: `host "bar.example.com" is already served with Secret "bar-cert" of Ingress test/bar`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.ingresses, Options{MergeCertificateRefs: !tc.unmerged})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if len(result.Gateways) != 1 {
+				t.Fatalf("Expected 1 Gateway, got %d", len(result.Gateways))
+			}
+			var listeners []listener
+			for _, l := range result.Gateways[0].Spec.Listeners {
+				if l.Protocol != gatewayv1.HTTPSProtocolType {
+					continue
+				}
+				got := listener{Name: string(l.Name)}
+				if l.Hostname != nil {
+					got.Hostname = string(*l.Hostname)
+				}
+				for _, ref := range l.TLS.CertificateRefs {
+					got.Secrets = append(got.Secrets, string(ref.Name))
+				}
+				listeners = append(listeners, got)
+			}
+			if diff := cmp.Diff(tc.expectedListeners, listeners); diff != "" {
+				t.Errorf("Unexpected HTTPS listeners (-want +got):\n%s", diff)
+			}
+			if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+				t.Errorf("Expected valid resources, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// ValidateResources checks the generated resources locally against the
+// OpenAPI schemas and CEL validation rules of the CRDs of the Gateway API
+// version bundled with ingress2gateway, as ValidateResourcesWithCRDs does. It
+// doesn't require cluster access, so the existence of the referenced
+// resources isn't checked.
+func ValidateResources(httpRoutes []gatewayv1beta1.HTTPRoute, grpcRoutes []gatewayv1.GRPCRoute, tcpRoutes []gatewayv1alpha2.TCPRoute, tlsRoutes []gatewayv1alpha2.TLSRoute, gateways []gatewayv1beta1.Gateway, backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy, referenceGrants []gatewayv1beta1.ReferenceGrant) error {
+	crds, err := bundledCRDSchemas()
+	if err != nil {
+		return err
+	}
+	return ValidateResourcesWithCRDs(crds, httpRoutes, grpcRoutes, tcpRoutes, tlsRoutes, gateways, backendTLSPolicies, referenceGrants)
+}
+
+// validateObjectMeta returns the path of the object, its kind followed by its
+// namespace and name, along with the errors of its name.
+func validateObjectMeta(kind string, meta metav1.ObjectMeta) (*field.Path, field.ErrorList) {
+	path := field.NewPath(kind, meta.Namespace+"/"+meta.Name)
+	var errs field.ErrorList
+	if meta.Name == "" {
+		errs = append(errs, field.Required(path.Child("metadata", "name"), "name is required"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(meta.Name) {
+			errs = append(errs, field.Invalid(path.Child("metadata", "name"), meta.Name, msg))
+		}
+	}
+	return path, errs
+}
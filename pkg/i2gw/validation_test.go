@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/pointer"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayv1alpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_ValidateResources(t *testing.T) {
+	iPrefix := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: stringPtr("nginx"),
+			TLS:              []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-com"}},
+			Rules: []networkingv1.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &iPrefix,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: "app",
+							Port: networkingv1.ServiceBackendPort{Number: 80},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+
+	newGateway := func() gatewayv1beta1.Gateway {
+		return gatewayv1beta1.Gateway{
+			TypeMeta:   metav1.TypeMeta{APIVersion: gatewayv1beta1.GroupVersion.String(), Kind: "Gateway"},
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "test"},
+			Spec: gatewayv1.GatewaySpec{
+				GatewayClassName: "nginx",
+				Listeners: []gatewayv1.Listener{{
+					Name:     "example-com-http",
+					Hostname: (*gatewayv1.Hostname)(pointer.String("example.com")),
+					Port:     80,
+					Protocol: gatewayv1.HTTPProtocolType,
+				}},
+			},
+		}
+	}
+	newHTTPRoute := func(rule gatewayv1.HTTPRouteRule) gatewayv1beta1.HTTPRoute {
+		return gatewayv1beta1.HTTPRoute{
+			TypeMeta:   metav1.TypeMeta{APIVersion: gatewayv1beta1.GroupVersion.String(), Kind: "HTTPRoute"},
+			ObjectMeta: metav1.ObjectMeta{Name: "example-com", Namespace: "test"},
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{Name: "nginx"}}},
+				Hostnames:       []gatewayv1.Hostname{"example.com"},
+				Rules:           []gatewayv1.HTTPRouteRule{rule},
+			},
+		}
+	}
+	backendRef := gatewayv1.HTTPBackendRef{BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{
+		Name: "app",
+		Port: (*gatewayv1.PortNumber)(pointer.Int32(80)),
+	}}}
+	newPolicy := func() gatewayv1alpha3.BackendTLSPolicy {
+		return gatewayv1alpha3.BackendTLSPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: gatewayv1alpha3.GroupVersion.String(), Kind: "BackendTLSPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+			Spec: gatewayv1alpha3.BackendTLSPolicySpec{
+				TargetRefs: []gatewayv1alpha2.LocalPolicyTargetReferenceWithSectionName{{
+					LocalPolicyTargetReference: gatewayv1alpha2.LocalPolicyTargetReference{Kind: "Service", Name: "app"},
+				}},
+				Validation: gatewayv1alpha3.BackendTLSPolicyValidation{
+					Hostname:          "app.test.svc",
+					CACertificateRefs: []gatewayv1.LocalObjectReference{{Kind: "ConfigMap", Name: "ca"}},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		httpRoutes         []gatewayv1beta1.HTTPRoute
+		gateways           []gatewayv1beta1.Gateway
+		backendTLSPolicies []gatewayv1alpha3.BackendTLSPolicy
+		expectNumErrors    int
+	}{{
+		name:               "valid resources",
+		httpRoutes:         []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{BackendRefs: []gatewayv1.HTTPBackendRef{backendRef}})},
+		gateways:           []gatewayv1beta1.Gateway{newGateway()},
+		backendTLSPolicies: []gatewayv1alpha3.BackendTLSPolicy{newPolicy()},
+	}, {
+		name: "Gateway without class and listeners",
+		gateways: []gatewayv1beta1.Gateway{func() gatewayv1beta1.Gateway {
+			gateway := newGateway()
+			gateway.Spec.GatewayClassName = ""
+			gateway.Spec.Listeners = nil
+			return gateway
+		}()},
+		expectNumErrors: 2,
+	}, {
+		name: "HTTP listener with TLS",
+		gateways: []gatewayv1beta1.Gateway{func() gatewayv1beta1.Gateway {
+			gateway := newGateway()
+			gateway.Spec.Listeners[0].TLS = &gatewayv1.GatewayTLSConfig{CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "example-com"}}}
+			return gateway
+		}()},
+		expectNumErrors: 1,
+	}, {
+		name: "TCP listener with hostname and invalid hostname",
+		gateways: []gatewayv1beta1.Gateway{func() gatewayv1beta1.Gateway {
+			gateway := newGateway()
+			gateway.Spec.Listeners[0].Protocol = gatewayv1.TCPProtocolType
+			gateway.Spec.Listeners = append(gateway.Spec.Listeners, gatewayv1.Listener{
+				Name:     "invalid",
+				Hostname: (*gatewayv1.Hostname)(pointer.String("*example.com")),
+				Port:     8080,
+				Protocol: gatewayv1.HTTPProtocolType,
+			})
+			return gateway
+		}()},
+		expectNumErrors: 2,
+	}, {
+		name: "too many rules",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{func() gatewayv1beta1.HTTPRoute {
+			route := newHTTPRoute(gatewayv1.HTTPRouteRule{BackendRefs: []gatewayv1.HTTPBackendRef{backendRef}})
+			for len(route.Spec.Rules) <= 16 {
+				route.Spec.Rules = append(route.Spec.Rules, route.Spec.Rules[0])
+			}
+			return route
+		}()},
+		expectNumErrors: 1,
+	}, {
+		name: "unsupported path type",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			Matches: []gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{
+				Type:  (*gatewayv1.PathMatchType)(pointer.String("ImplementationSpecific")),
+				Value: pointer.String("/"),
+			}}},
+			BackendRefs: []gatewayv1.HTTPBackendRef{backendRef},
+		})},
+		// Rejected by both the enum and a CEL rule of the schema.
+		expectNumErrors: 2,
+	}, {
+		name: "filter without configuration",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			Filters:     []gatewayv1.HTTPRouteFilter{{Type: gatewayv1.HTTPRouteFilterRequestHeaderModifier}},
+			BackendRefs: []gatewayv1.HTTPBackendRef{backendRef},
+		})},
+		expectNumErrors: 1,
+	}, {
+		name: "redirect and rewrite",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			Filters: []gatewayv1.HTTPRouteFilter{{
+				Type:            gatewayv1.HTTPRouteFilterRequestRedirect,
+				RequestRedirect: &gatewayv1.HTTPRequestRedirectFilter{StatusCode: pointer.Int(308)},
+			}, {
+				Type:       gatewayv1.HTTPRouteFilterURLRewrite,
+				URLRewrite: &gatewayv1.HTTPURLRewriteFilter{},
+			}},
+		})},
+		expectNumErrors: 2,
+	}, {
+		name: "redirect with backends",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			Filters: []gatewayv1.HTTPRouteFilter{{
+				Type:            gatewayv1.HTTPRouteFilterRequestRedirect,
+				RequestRedirect: &gatewayv1.HTTPRequestRedirectFilter{Scheme: pointer.String("https")},
+			}},
+			BackendRefs: []gatewayv1.HTTPBackendRef{backendRef},
+		})},
+		expectNumErrors: 1,
+	}, {
+		name: "Service backend without port",
+		httpRoutes: []gatewayv1beta1.HTTPRoute{newHTTPRoute(gatewayv1.HTTPRouteRule{
+			BackendRefs: []gatewayv1.HTTPBackendRef{{BackendRef: gatewayv1.BackendRef{
+				BackendObjectReference: gatewayv1.BackendObjectReference{Name: "app"},
+			}}},
+		})},
+		expectNumErrors: 1,
+	}, {
+		name: "BackendTLSPolicy without hostname and CA certificates",
+		backendTLSPolicies: []gatewayv1alpha3.BackendTLSPolicy{func() gatewayv1alpha3.BackendTLSPolicy {
+			policy := newPolicy()
+			policy.Spec.Validation = gatewayv1alpha3.BackendTLSPolicyValidation{}
+			return policy
+		}()},
+		expectNumErrors: 2,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateResources(tc.httpRoutes, nil, nil, nil, tc.gateways, tc.backendTLSPolicies, nil)
+			var numErrors int
+			if err != nil {
+				numErrors = len(err.(utilerrors.Aggregate).Errors())
+			}
+			if numErrors != tc.expectNumErrors {
+				t.Errorf("Expected %d errors, got %d: %v", tc.expectNumErrors, numErrors, err)
+			}
+		})
+	}
+
+	t.Run("converted resources", func(t *testing.T) {
+		result, err := Convert([]networkingv1.Ingress{ingress}, Options{})
+		if err != nil {
+			t.Fatalf("Unexpected conversion error: %v", err)
+		}
+		if err := ValidateResources(result.HTTPRoutes, result.GRPCRoutes, result.TCPRoutes, result.TLSRoutes, result.Gateways, result.BackendTLSPolicies, result.ReferenceGrants); err != nil {
+			t.Errorf("Unexpected validation error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package i2gw
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Warning describes Ingress configuration that could not be converted, or
+// was converted with a loss of fidelity. Unlike errors, warnings do not
+// prevent the Gateway API resources from being generated, but they flag
+// configuration that has to be ported manually.
+type Warning struct {
+	// Ingress is the namespace/name of the Ingress the warning was raised for.
+	// It is empty for warnings raised for the generated resources, such as
+	// the preflight checks of the backends.
+	Ingress types.NamespacedName
+
+	// HTTPRoute is the namespace/name of the generated HTTPRoute affected by
+	// the warning. It is empty when the warning isn't tied to a single route.
+	HTTPRoute types.NamespacedName
+
+	// Field points at the part of the Ingress, or of the generated resource
+	// when Ingress is empty, that caused the warning.
+	Field *field.Path
+
+	// Message describes what was not converted.
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Ingress.Name == "" {
+		return fmt.Sprintf("%s: %s", w.Field, w.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", w.Ingress, w.Field, w.Message)
+}